@@ -2,13 +2,20 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/handlers"
+	"order-processing-microservice/internal/models"
 	"order-processing-microservice/internal/queue"
 	"order-processing-microservice/internal/repository"
 	"order-processing-microservice/internal/services"
@@ -28,63 +35,441 @@ func main() {
 		// Fallback to environment variables only
 		logrus.Warnf("Config file not found, using environment variables: %v", err)
 		cfg = &config.Config{
+			Server: config.ServerConfig{
+				Host:         getEnv("SERVER_HOST", "localhost"),
+				Port:         getEnvInt("SERVER_PORT", 8081),
+				AdminPort:    getEnvInt("SERVER_ADMIN_PORT", 9081),
+				ReadTimeout:  getEnvInt("SERVER_READ_TIMEOUT", 10),
+				WriteTimeout: getEnvInt("SERVER_WRITE_TIMEOUT", 10),
+			},
 			Database: config.DatabaseConfig{
-				Host:         getEnv("DATABASE_HOST", "localhost"),
-				Port:         getEnvInt("DATABASE_PORT", 5432),
-				Username:     getEnv("DATABASE_USERNAME", "postgres"),
-				Password:     getEnv("DATABASE_PASSWORD", "postgres"),
-				Database:     getEnv("DATABASE_DATABASE", "orders"),
-				SSLMode:      getEnv("DATABASE_SSL_MODE", "disable"),
-				MaxOpenConns: getEnvInt("DATABASE_MAX_OPEN_CONNS", 25),
-				MaxIdleConns: getEnvInt("DATABASE_MAX_IDLE_CONNS", 5),
+				Host:                   getEnv("DATABASE_HOST", "localhost"),
+				Port:                   getEnvInt("DATABASE_PORT", 5432),
+				Username:               getEnv("DATABASE_USERNAME", "postgres"),
+				Password:               getEnv("DATABASE_PASSWORD", "postgres"),
+				Database:               getEnv("DATABASE_DATABASE", "orders"),
+				SSLMode:                getEnv("DATABASE_SSL_MODE", "disable"),
+				MaxOpenConns:           getEnvInt("DATABASE_MAX_OPEN_CONNS", 25),
+				MaxIdleConns:           getEnvInt("DATABASE_MAX_IDLE_CONNS", 5),
+				MaxConnIdleTime:        getEnvInt("DATABASE_MAX_CONN_IDLE_TIME", 1800),
+				StatementCacheCapacity: getEnvInt("DATABASE_STATEMENT_CACHE_CAPACITY", 512),
+				ApplicationName:        getEnv("DATABASE_APPLICATION_NAME", "order-consumer"),
+				QueryComments:          getEnvBool("DATABASE_QUERY_COMMENTS", true),
+				Schema:                 getEnv("DATABASE_SCHEMA", ""),
+				TablePrefix:            getEnv("DATABASE_TABLE_PREFIX", ""),
 			},
 			Kafka: config.KafkaConfig{
-				Brokers:          []string{getEnv("KAFKA_BROKERS", "kafka:9092")},
-				GroupID:          getEnv("KAFKA_GROUP_ID", "order-processing-group"),
-				OrderTopic:       getEnv("KAFKA_ORDER_TOPIC", "order-events"),
-				RetryAttempts:    getEnvInt("KAFKA_RETRY_ATTEMPTS", 3),
-				SessionTimeout:   getEnvInt("KAFKA_SESSION_TIMEOUT", 30000),
-				CommitInterval:   getEnvInt("KAFKA_COMMIT_INTERVAL", 1000),
-				EnableAutoCommit: getEnvBool("KAFKA_ENABLE_AUTO_COMMIT", true),
+				Brokers:                    []string{getEnv("KAFKA_BROKERS", "kafka:9092")},
+				GroupID:                    getEnv("KAFKA_GROUP_ID", "order-processing-group"),
+				OrderTopic:                 getEnv("KAFKA_ORDER_TOPIC", "order-events"),
+				RetryAttempts:              getEnvInt("KAFKA_RETRY_ATTEMPTS", 3),
+				SessionTimeout:             getEnvInt("KAFKA_SESSION_TIMEOUT", 30000),
+				RebalanceStrategy:          getEnv("KAFKA_REBALANCE_STRATEGY", "sticky"),
+				CommitInterval:             getEnvInt("KAFKA_COMMIT_INTERVAL", 1000),
+				EnableAutoCommit:           getEnvBool("KAFKA_ENABLE_AUTO_COMMIT", false),
+				CommitBatchSize:            getEnvInt("KAFKA_COMMIT_BATCH_SIZE", 100),
+				ConsumerWorkers:            getEnvInt("KAFKA_CONSUMER_WORKERS", 1),
+				ConsumerMinWorkers:         getEnvInt("KAFKA_CONSUMER_MIN_WORKERS", 0),
+				ConsumerTargetLatency:      getEnvInt("KAFKA_CONSUMER_TARGET_LATENCY", 200),
+				Region:                     getEnv("KAFKA_REGION", ""),
+				CloudEventsTopics:          strings.Split(getEnv("KAFKA_CLOUDEVENTS_TOPICS", ""), ","),
+				TopicRoutes:                strings.Split(getEnv("KAFKA_TOPIC_ROUTES", ""), ","),
+				TenantTopics:               strings.Split(getEnv("KAFKA_TENANT_TOPICS", ""), ","),
+				ConsumerTopics:             strings.Split(getEnv("KAFKA_CONSUMER_TOPICS", ""), ","),
+				CloudEventsSource:          getEnv("KAFKA_CLOUDEVENTS_SOURCE", "/order-processing-microservice"),
+				Codec:                      getEnv("KAFKA_CODEC", "json"),
+				MigrationTopic:             getEnv("KAFKA_MIGRATION_TOPIC", ""),
+				MigrationPhase:             getEnv("KAFKA_MIGRATION_PHASE", ""),
+				MigrationIdle:              getEnvInt("KAFKA_MIGRATION_IDLE", 30),
+				MigrationSkew:              getEnvInt("KAFKA_MIGRATION_SKEW", 5),
+				EmptyAssignmentThreshold:   getEnvInt("KAFKA_EMPTY_ASSIGNMENT_THRESHOLD", 60),
+				ReadyMaxLag:                getEnvInt("KAFKA_READY_MAX_LAG", 10000),
+				StallTimeout:               getEnvInt("KAFKA_STALL_TIMEOUT", 0),
+				KeyStrategy:                getEnv("KAFKA_KEY_STRATEGY", "order_id"),
+				KeyHeader:                  getEnv("KAFKA_KEY_HEADER", ""),
+				Partitioner:                getEnv("KAFKA_PARTITIONER", "hash"),
+				DLQTopic:                   getEnv("KAFKA_DLQ_TOPIC", ""),
+				PoisonMaxAttempts:          getEnvInt("KAFKA_POISON_MAX_ATTEMPTS", 3),
+				PoisonBackoff:              getEnvInt("KAFKA_POISON_BACKOFF", 1000),
+				RetryDelays:                strings.Split(getEnv("KAFKA_RETRY_DELAYS", ""), ","),
+				MaxMessageBytes:            getEnvInt("KAFKA_MAX_MESSAGE_BYTES", 1000000),
+				PublishTimeout:             getEnvInt("KAFKA_PUBLISH_TIMEOUT", 5000),
+				SecondaryBrokers:           strings.Split(getEnv("KAFKA_SECONDARY_BROKERS", ""), ","),
+				FailoverThreshold:          getEnvInt("KAFKA_FAILOVER_THRESHOLD", 3),
+				FailoverPeriod:             getEnvInt("KAFKA_FAILOVER_PERIOD", 0),
+				FailbackInterval:           getEnvInt("KAFKA_FAILBACK_INTERVAL", 60),
+				Idempotent:                 getEnvBool("KAFKA_IDEMPOTENT", false),
+				TransactionalID:            getEnv("KAFKA_TRANSACTIONAL_ID", ""),
+				SchemaRegistryURL:          getEnv("KAFKA_SCHEMA_REGISTRY_URL", ""),
+				SchemaRegistryUsername:     getEnv("KAFKA_SCHEMA_REGISTRY_USERNAME", ""),
+				SchemaRegistryPassword:     getEnv("KAFKA_SCHEMA_REGISTRY_PASSWORD", ""),
+				SchemaRegistryTimeout:      getEnvInt("KAFKA_SCHEMA_REGISTRY_TIMEOUT", 10),
+				SchemaRegistryAutoRegister: getEnvBool("KAFKA_SCHEMA_REGISTRY_AUTO_REGISTER", true),
+				AutoCreateTopics:           getEnvBool("KAFKA_AUTO_CREATE_TOPICS", false),
+				TopicPartitions:            getEnvInt("KAFKA_TOPIC_PARTITIONS", 3),
+				TopicReplicationFactor:     getEnvInt("KAFKA_TOPIC_REPLICATION_FACTOR", 1),
+				TopicRetention:             getEnvInt("KAFKA_TOPIC_RETENTION", 0),
+				DLQRetention:               getEnvInt("KAFKA_DLQ_RETENTION", 0),
 			},
 			Logger: config.LoggerConfig{
-				Level:  getEnv("LOGGER_LEVEL", "info"),
-				Format: getEnv("LOGGER_FORMAT", "json"),
+				Level:         getEnv("LOGGER_LEVEL", "info"),
+				Format:        getEnv("LOGGER_FORMAT", "json"),
+				RedactEnabled: getEnvBool("LOGGER_REDACT_ENABLED", true),
+				CrashDir:      getEnv("LOGGER_CRASH_DIR", ""),
+				CrashEndpoint: getEnv("LOGGER_CRASH_ENDPOINT", ""),
+			},
+			Saga: config.SagaConfig{
+				Enabled:               getEnvBool("SAGA_ENABLED", false),
+				PaymentRequestTopic:   getEnv("SAGA_PAYMENT_REQUEST_TOPIC", "payment.authorize.request"),
+				PaymentReplyTopic:     getEnv("SAGA_PAYMENT_REPLY_TOPIC", "payment.authorize.reply"),
+				InventoryRequestTopic: getEnv("SAGA_INVENTORY_REQUEST_TOPIC", "inventory.reserve.request"),
+				InventoryReplyTopic:   getEnv("SAGA_INVENTORY_REPLY_TOPIC", "inventory.reserve.reply"),
+				ReplyTimeout:          getEnvInt("SAGA_REPLY_TIMEOUT", 30),
+				TimeoutCheckInterval:  getEnvInt("SAGA_TIMEOUT_CHECK_INTERVAL", 10),
+			},
+			Queue: config.QueueConfig{
+				Transport:      getEnv("QUEUE_TRANSPORT", "kafka"),
+				PollInterval:   getEnvInt("QUEUE_POLL_INTERVAL", 5),
+				BatchSize:      getEnvInt("QUEUE_BATCH_SIZE", 100),
+				Retention:      getEnvInt("QUEUE_RETENTION", 168),
+				DedupRetention: getEnvInt("QUEUE_DEDUP_RETENTION", 168),
+			},
+			ServiceBus: config.ServiceBusConfig{
+				ConnectionString:      getEnv("SERVICEBUS_CONNECTION_STRING", ""),
+				MaxDeliveryAttempts:   getEnvInt("SERVICEBUS_MAX_DELIVERY_ATTEMPTS", 5),
+				MaxConcurrentSessions: getEnvInt("SERVICEBUS_MAX_CONCURRENT_SESSIONS", 8),
+				SessionIdleTimeout:    getEnvInt("SERVICEBUS_SESSION_IDLE_TIMEOUT", 5),
+			},
+			ProcessingWindows: config.ProcessingWindowsConfig{
+				Enabled: getEnvBool("PROCESSING_WINDOWS_ENABLED", false),
+			},
+			Delivery: config.DeliveryConfig{
+				Enabled:        getEnvBool("DELIVERY_ENABLED", false),
+				TimeZone:       getEnv("DELIVERY_TIME_ZONE", "UTC"),
+				CutoffHour:     getEnvInt("DELIVERY_CUTOFF_HOUR", 14),
+				ProcessingDays: getEnvInt("DELIVERY_PROCESSING_DAYS", 1),
+				StandardDays:   getEnvInt("DELIVERY_STANDARD_DAYS", 5),
+				ExpressDays:    getEnvInt("DELIVERY_EXPRESS_DAYS", 2),
+				OvernightDays:  getEnvInt("DELIVERY_OVERNIGHT_DAYS", 1),
+				Holidays:       strings.Split(getEnv("DELIVERY_HOLIDAYS", ""), ","),
+			},
+			ConsumerRateLimit: config.ConsumerRateLimitConfig{
+				Enabled:         getEnvBool("CONSUMER_RATE_LIMIT_ENABLED", false),
+				EventsPerSecond: getEnvFloat("CONSUMER_RATE_LIMIT_EVENTS_PER_SECOND", 100),
+				Burst:           getEnvInt("CONSUMER_RATE_LIMIT_BURST", 100),
+				MaxConcurrent:   getEnvInt("CONSUMER_RATE_LIMIT_MAX_CONCURRENT", 10),
+			},
+			ProcessingAttempts: config.ProcessingAttemptsConfig{
+				Enabled:       getEnvBool("PROCESSING_ATTEMPTS_ENABLED", false),
+				BackoffBase:   getEnvInt("PROCESSING_ATTEMPTS_BACKOFF_BASE", 30),
+				BackoffMax:    getEnvInt("PROCESSING_ATTEMPTS_BACKOFF_MAX", 1800),
+				BackoffJitter: getEnvFloat("PROCESSING_ATTEMPTS_BACKOFF_JITTER", 0.2),
+			},
+			Audit: config.AuditConfig{
+				Enabled: getEnvBool("AUDIT_ENABLED", false),
+				Topic:   getEnv("AUDIT_TOPIC", "order-audit"),
+			},
+			Heartbeat: config.HeartbeatConfig{
+				Enabled:         getEnvBool("HEARTBEAT_ENABLED", false),
+				Interval:        getEnvInt("HEARTBEAT_INTERVAL", 30),
+				MissedIntervals: getEnvInt("HEARTBEAT_MISSED_INTERVALS", 3),
 			},
 		}
 	}
 
 	logger.Init(&cfg.Logger)
 
+	// Registered first so it runs after every other deferred Close.
+	var consumerErr error
+	defer func() {
+		if consumerErr != nil {
+			os.Exit(1)
+		}
+	}()
+
 	db, err := database.NewPostgresDB(&cfg.Database)
 	if err != nil {
 		logrus.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer db.Close()
 
-	producer, err := queue.NewKafkaProducer(&cfg.Kafka)
+	if err := db.CheckSchema(context.Background()); err != nil {
+		logrus.Fatalf("Refusing to start: %v", err)
+	}
+
+	if cfg.Queue.Transport == queue.TransportKafka || cfg.Queue.Transport == "" {
+		if err := queue.ProvisionTopics(&cfg.Kafka); err != nil {
+			logrus.Fatalf("Failed to provision Kafka topics: %v", err)
+		}
+	}
+
+	producer, err := queue.NewTransportProducer(cfg, db.GetDB())
+	if err != nil {
+		logrus.Fatalf("Failed to create producer: %v", err)
+	}
+
+	eventStore := repository.NewPostgresEventStore(db.GetDB())
+	recordingProducer := services.NewRecordingProducer(producer, eventStore)
+
+	var deadLetters *queue.DeadLetterQueue
+	if cfg.Kafka.DLQTopic != "" {
+		deadLetters, err = queue.NewDeadLetterQueue(&cfg.Kafka, repository.NewPostgresDeadLetterRepository(db.GetDB()))
+		if err != nil {
+			logrus.Fatalf("Failed to create dead-letter queue: %v", err)
+		}
+	}
+	if deadLetters == nil {
+		logrus.Warn("KAFKA_DLQ_TOPIC is not set; messages that fail their last attempt are logged and skipped")
+	}
+	var quarantine *queue.Quarantine
+	if deadLetters != nil && cfg.Kafka.PoisonMaxAttempts > 0 {
+		quarantine = queue.NewQuarantine(repository.NewPostgresMessageFailureRepository(db.GetDB()), &cfg.Kafka)
+	}
+	enableDeadLetters := func(c queue.Consumer) {
+		if deadLetters == nil {
+			return
+		}
+		if dlqConsumer, ok := c.(queue.DeadLetterConsumer); ok {
+			dlqConsumer.EnableDeadLetters(deadLetters)
+			if quarantineConsumer, ok := c.(queue.QuarantineConsumer); ok && quarantine != nil {
+				quarantineConsumer.EnableQuarantine(quarantine)
+			}
+			return
+		}
+		logrus.Warnf("Queue transport %s does not support KAFKA_DLQ_TOPIC; failed messages are skipped", cfg.Queue.Transport)
+	}
+
+	retryTiers, err := queue.ParseRetryTiers(cfg.Kafka.RetryDelays)
 	if err != nil {
-		logrus.Fatalf("Failed to create Kafka producer: %v", err)
+		logrus.Fatalf("Invalid KAFKA_RETRY_DELAYS: %v", err)
+	}
+	var retries *queue.RetryQueue
+	if len(retryTiers) > 0 {
+		retries, err = queue.NewRetryQueue(&cfg.Kafka, retryTiers)
+		if err != nil {
+			logrus.Fatalf("Failed to create retry queue: %v", err)
+		}
+	}
+	enableRetries := func(c queue.Consumer) {
+		if retries == nil {
+			return
+		}
+		if retryConsumer, ok := c.(queue.RetryConsumer); ok {
+			retryConsumer.EnableRetries(retries)
+			return
+		}
+		logrus.Warnf("Queue transport %s does not support KAFKA_RETRY_DELAYS; failed messages are not retried", cfg.Queue.Transport)
+	}
+	var limiter *queue.RateLimiter
+	if cfg.ConsumerRateLimit.Enabled {
+		limiter = queue.NewRateLimiter(&cfg.ConsumerRateLimit)
+	}
+	enableRateLimit := func(c queue.Consumer) {
+		if limiter == nil {
+			return
+		}
+		if limitedConsumer, ok := c.(queue.RateLimitedConsumer); ok {
+			limitedConsumer.EnableRateLimit(limiter)
+			return
+		}
+		logrus.Warnf("Queue transport %s does not support CONSUMER_RATE_LIMIT_ENABLED; events are not rate limited", cfg.Queue.Transport)
+	}
+	crashes := logger.NewCrashReporter("consumer", &cfg.Logger)
+	enableCrashReports := func(c queue.Consumer) {
+		if crashConsumer, ok := c.(queue.CrashReportingConsumer); ok {
+			crashConsumer.EnableCrashReports(crashes)
+			return
+		}
+		logrus.Warnf("Queue transport %s does not support crash reports; handler panics are not recovered", cfg.Queue.Transport)
+	}
+	enableTransactions := func(c queue.Consumer) {
+		if cfg.Kafka.TransactionalID == "" {
+			return
+		}
+		txnProducer, ok := producer.(queue.TransactionalProducer)
+		if !ok {
+			logrus.Warnf("Queue transport %s does not support KAFKA_TRANSACTIONAL_ID; offsets are committed separately", cfg.Queue.Transport)
+			return
+		}
+		if txnConsumer, ok := c.(queue.TransactionalConsumer); ok {
+			txnConsumer.EnableTransactions(txnProducer)
+			return
+		}
+		logrus.Warn("Consumer does not support KAFKA_TRANSACTIONAL_ID during topic cutover; offsets are committed separately")
 	}
-	defer producer.Close()
 
-	consumer, err := queue.NewKafkaConsumer(&cfg.Kafka)
+	var consumer queue.Consumer
+	if cfg.Kafka.MigrationPhase == queue.MigrationPhaseCutover && cfg.Queue.Transport == queue.TransportKafka {
+		consumer, err = queue.NewCutoverConsumer(&cfg.Kafka)
+	} else {
+		topics, topicsErr := queue.SubscribedTopics(&cfg.Kafka)
+		if topicsErr != nil {
+			logrus.Fatalf("Failed to resolve consumer topics: %v", topicsErr)
+		}
+		consumer, err = queue.NewTransportConsumer(cfg, &cfg.Kafka, db.GetDB(), topics)
+	}
 	if err != nil {
-		logrus.Fatalf("Failed to create Kafka consumer: %v", err)
+		logrus.Fatalf("Failed to create consumer: %v", err)
 	}
-	defer consumer.Close()
+	consumers := []queue.Consumer{consumer}
+	enableDeadLetters(consumer)
+	enableRetries(consumer)
+	enableRateLimit(consumer)
+	enableCrashReports(consumer)
+	enableTransactions(consumer)
 
-	orderRepo := repository.NewPostgresOrderRepository(db.GetDB())
-	orderProcessor := services.NewOrderProcessor(orderRepo, producer)
+	orderRepo := repository.NewPostgresOrderRepository(db.GetPool())
+	orderProcessor := services.NewOrderProcessor(orderRepo, recordingProducer)
+	orderProcessor.EnableCompensations(repository.NewPostgresCompensationRepository(db.GetDB()))
+	processedEvents := repository.NewPostgresProcessedEventRepository(db.GetDB())
+	orderProcessor.EnableDeduplication(processedEvents)
+	if cfg.Audit.Enabled {
+		orderProcessor.EnableAuditing(services.NewAuditor(producer, &cfg.Audit))
+	}
+	if cfg.ProcessingWindows.Enabled {
+		orderProcessor.EnableProcessingWindows(services.NewProcessingWindowService(repository.NewPostgresProcessingWindowRepository(db.GetDB())))
+	}
+	if cfg.Delivery.Enabled {
+		deliveryEstimator, err := services.NewDeliveryEstimator(&cfg.Delivery)
+		if err != nil {
+			logrus.Fatalf("Failed to create delivery estimator: %v", err)
+		}
+		orderProcessor.EnableDeliveryEstimates(deliveryEstimator)
+	}
+	if cfg.ProcessingAttempts.Enabled {
+		orderProcessor.EnableAttemptTracking(repository.NewPostgresProcessingAttemptRepository(db.GetDB()), models.BackoffPolicy{
+			Base:   time.Duration(cfg.ProcessingAttempts.BackoffBase) * time.Second,
+			Max:    time.Duration(cfg.ProcessingAttempts.BackoffMax) * time.Second,
+			Jitter: cfg.ProcessingAttempts.BackoffJitter,
+		})
+	}
+	var heartbeatMonitor *services.HeartbeatMonitor
+	if cfg.Heartbeat.Enabled {
+		heartbeatMonitor = services.NewHeartbeatMonitor(&cfg.Heartbeat)
+		orderProcessor.EnableHeartbeatMonitor(heartbeatMonitor)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if heartbeatMonitor != nil {
+		go heartbeatMonitor.Run(ctx, time.Duration(cfg.Heartbeat.Interval)*time.Second)
+	}
+
 	if err := consumer.Subscribe(ctx, orderProcessor); err != nil {
-		logrus.Fatalf("Failed to subscribe to Kafka topics: %v", err)
+		logrus.Fatalf("Failed to subscribe to topics: %v", err)
 	}
 
+	consumerHandlers := handlers.NewConsumerHandlers()
+	consumerAdminHandlers := handlers.NewConsumerAdminHandlers()
+	if heartbeatMonitor != nil {
+		consumerHandlers.RegisterHeartbeatMonitor(heartbeatMonitor)
+	}
+	registerConsumer := func(name string, c queue.Consumer) {
+		if reporter, ok := c.(queue.LagReporter); ok {
+			consumerHandlers.RegisterLagReporter(name, reporter)
+		}
+		if reporter, ok := c.(queue.WatchdogReporter); ok {
+			consumerHandlers.RegisterWatchdog(name, reporter)
+		}
+		if reporter, ok := c.(queue.WorkerReporter); ok {
+			consumerHandlers.RegisterWorkerReporter(name, reporter)
+		}
+		if pausable, ok := c.(queue.PausableConsumer); ok {
+			consumerAdminHandlers.RegisterConsumer(name, pausable)
+		}
+		if switching, ok := c.(queue.ClusterSwitchingConsumer); ok {
+			consumerAdminHandlers.RegisterClusterSwitch(name, switching)
+		}
+	}
+	registerConsumer("orders", consumer)
+
+	// Background jobs that publish events; waited for before the producer
+	// is closed.
+	var workers sync.WaitGroup
+
+	topicRouter, err := queue.NewTopicRouter(&cfg.Kafka)
+	if err != nil {
+		logrus.Fatalf("Failed to parse topic routes: %v", err)
+	}
+	tenantTopics := topicRouter.TenantTopics()
+
+	consumerErrs := make(chan error, 2+len(tenantTopics))
+	watchConsumer := func(c queue.Consumer) {
+		go func() {
+			if err := c.Wait(); err != nil {
+				consumerErrs <- err
+			}
+		}()
+	}
+	watchConsumer(consumer)
+
+	if cfg.Saga.Enabled {
+		orderProcessor.EnableSaga(repository.NewPostgresSagaRepository(db.GetDB()), producer, &cfg.Saga)
+
+		replyConsumerCfg := cfg.Kafka
+		replyConsumerCfg.GroupID = cfg.Kafka.GroupID + "-saga-replies"
+		replyConsumer, err := queue.NewTransportConsumer(cfg, &replyConsumerCfg, db.GetDB(), []string{cfg.Saga.PaymentReplyTopic, cfg.Saga.InventoryReplyTopic})
+		if err != nil {
+			logrus.Fatalf("Failed to create saga reply consumer: %v", err)
+		}
+		consumers = append(consumers, replyConsumer)
+		enableDeadLetters(replyConsumer)
+		enableRetries(replyConsumer)
+		enableRateLimit(replyConsumer)
+		enableCrashReports(replyConsumer)
+		enableTransactions(replyConsumer)
+
+		if err := replyConsumer.Subscribe(ctx, orderProcessor); err != nil {
+			logrus.Fatalf("Failed to subscribe to saga reply topics: %v", err)
+		}
+		watchConsumer(replyConsumer)
+		registerConsumer("saga_replies", replyConsumer)
+
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			ticker := time.NewTicker(time.Duration(cfg.Saga.TimeoutCheckInterval) * time.Second)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := orderProcessor.CheckSagaTimeouts(ctx); err != nil {
+						logrus.WithError(err).Error("Failed to check saga timeouts")
+					}
+				}
+			}
+		}()
+	}
+
+	// Isolated tenants are consumed from their own topic by a group of their
+	// own, so their backlog does not delay other tenants' orders.
+	for tenant, topic := range tenantTopics {
+		tenantConsumerCfg := cfg.Kafka
+		tenantConsumerCfg.GroupID = queue.TenantGroupID(cfg.Kafka.GroupID, tenant)
+		tenantConsumer, err := queue.NewTransportConsumer(cfg, &tenantConsumerCfg, db.GetDB(), []string{topic})
+		if err != nil {
+			logrus.Fatalf("Failed to create consumer for tenant %s: %v", tenant, err)
+		}
+		consumers = append(consumers, tenantConsumer)
+		enableDeadLetters(tenantConsumer)
+		enableRetries(tenantConsumer)
+		enableRateLimit(tenantConsumer)
+		enableCrashReports(tenantConsumer)
+		enableTransactions(tenantConsumer)
+
+		if err := tenantConsumer.Subscribe(ctx, orderProcessor); err != nil {
+			logrus.Fatalf("Failed to subscribe to topic of tenant %s: %v", tenant, err)
+		}
+		watchConsumer(tenantConsumer)
+		registerConsumer("tenant_"+tenant, tenantConsumer)
+	}
+
+	workers.Add(1)
 	go func() {
+		defer workers.Done()
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
 
@@ -93,38 +478,120 @@ func main() {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
+				if err := orderProcessor.ReleaseScheduledOrders(ctx); err != nil {
+					logrus.WithError(err).Error("Failed to release scheduled orders")
+				}
 				if err := orderProcessor.ProcessPendingOrders(ctx); err != nil {
 					logrus.WithError(err).Error("Failed to process pending orders")
 				}
+				if err := orderProcessor.ReconcileCompensations(ctx); err != nil {
+					logrus.WithError(err).Error("Failed to reconcile compensations")
+				}
+				if cfg.Queue.DedupRetention > 0 {
+					cutoff := time.Now().Add(-time.Duration(cfg.Queue.DedupRetention) * time.Hour)
+					if _, err := processedEvents.DeleteBefore(ctx, cutoff); err != nil {
+						logrus.WithError(err).Error("Failed to delete expired processed events")
+					}
+				}
 			}
 		}
 	}()
 
+	r := gin.New()
+	r.Use(handlers.RecoveryMiddleware(crashes))
+	consumerHandlers.RegisterRoutes(r)
+
+	srv := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:      r,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	}
+
+	go func() {
+		logrus.Infof("Consumer health server starting on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logrus.Fatalf("Failed to start health server: %v", err)
+		}
+	}()
+
+	var adminSrv *http.Server
+	if cfg.Server.AdminPort > 0 {
+		adminRouter := gin.New()
+		adminRouter.Use(handlers.RecoveryMiddleware(crashes))
+		consumerAdminHandlers.RegisterRoutes(adminRouter)
+
+		adminSrv = &http.Server{
+			Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.AdminPort),
+			Handler:      adminRouter,
+			ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+			WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		}
+
+		go func() {
+			logrus.Infof("Consumer admin server starting on %s", adminSrv.Addr)
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logrus.Fatalf("Failed to start admin server: %v", err)
+			}
+		}()
+	}
+
 	logrus.Info("Order processing consumer started")
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
 
-	logrus.Info("Shutting down consumer...")
+	select {
+	case <-quit:
+		logrus.Info("Shutting down consumer...")
+	case consumerErr = <-consumerErrs:
+		logrus.WithError(consumerErr).Error("Kafka consumer stopped with a fatal error, shutting down")
+	}
 
+	// Shut down in dependency order: stop intake and let in-flight handlers
+	// and background jobs finish, then flush the producers they publish
+	// through, then close the database.
 	cancel()
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
 
-	done := make(chan struct{})
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logrus.Errorf("Health server forced to shutdown: %v", err)
+	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+			logrus.Errorf("Admin server forced to shutdown: %v", err)
+		}
+	}
+
+	drained := make(chan struct{})
 	go func() {
-		consumer.Close()
-		close(done)
+		for _, c := range consumers {
+			c.Close()
+		}
+		workers.Wait()
+		close(drained)
 	}()
 
 	select {
-	case <-done:
+	case <-drained:
 		logrus.Info("Consumer stopped gracefully")
 	case <-shutdownCtx.Done():
-		logrus.Error("Consumer shutdown timeout exceeded")
+		logrus.Error("Consumer shutdown timeout exceeded, closing producers with handlers still running")
+	}
+
+	if retries != nil {
+		retries.Close()
+	}
+	if deadLetters != nil {
+		deadLetters.Close()
 	}
+	if err := producer.Close(); err != nil {
+		logrus.WithError(err).Error("Failed to close producer")
+	}
+	db.Close()
+	logrus.Info("Consumer shutdown complete")
 }
 
 func getEnv(key, defaultValue string) string {
@@ -150,4 +617,13 @@ func getEnvBool(key string, defaultValue bool) bool {
 		}
 	}
 	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
 }
\ No newline at end of file
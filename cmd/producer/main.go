@@ -7,15 +7,18 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"order-processing-microservice/internal/handlers"
+	"order-processing-microservice/internal/models"
 	"order-processing-microservice/internal/queue"
 	"order-processing-microservice/internal/repository"
 	"order-processing-microservice/internal/services"
+	"order-processing-microservice/internal/storage"
 	"order-processing-microservice/pkg/config"
 	"order-processing-microservice/pkg/database"
 	"order-processing-microservice/pkg/logger"
@@ -23,7 +26,16 @@ import (
 
 func main() {
 	configFile := "configs/local.env"
-	if len(os.Args) > 1 {
+	var migration *migrateCommand
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		cmd, err := parseMigrateCommand(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n\n%s\n", err, migrateUsage)
+			os.Exit(2)
+		}
+		migration = &cmd
+		configFile = cmd.configFile
+	} else if len(os.Args) > 1 {
 		configFile = os.Args[1]
 	}
 
@@ -39,27 +51,173 @@ func main() {
 				WriteTimeout: getEnvInt("SERVER_WRITE_TIMEOUT", 10),
 			},
 			Database: config.DatabaseConfig{
-				Host:         getEnv("DATABASE_HOST", "localhost"),
-				Port:         getEnvInt("DATABASE_PORT", 5432),
-				Username:     getEnv("DATABASE_USERNAME", "postgres"),
-				Password:     getEnv("DATABASE_PASSWORD", "postgres"),
-				Database:     getEnv("DATABASE_DATABASE", "orders"),
-				SSLMode:      getEnv("DATABASE_SSL_MODE", "disable"),
-				MaxOpenConns: getEnvInt("DATABASE_MAX_OPEN_CONNS", 25),
-				MaxIdleConns: getEnvInt("DATABASE_MAX_IDLE_CONNS", 5),
+				Host:                    getEnv("DATABASE_HOST", "localhost"),
+				Port:                    getEnvInt("DATABASE_PORT", 5432),
+				Username:                getEnv("DATABASE_USERNAME", "postgres"),
+				Password:                getEnv("DATABASE_PASSWORD", "postgres"),
+				Database:                getEnv("DATABASE_DATABASE", "orders"),
+				SSLMode:                 getEnv("DATABASE_SSL_MODE", "disable"),
+				MaxOpenConns:            getEnvInt("DATABASE_MAX_OPEN_CONNS", 25),
+				MaxIdleConns:            getEnvInt("DATABASE_MAX_IDLE_CONNS", 5),
+				MaxConnIdleTime:         getEnvInt("DATABASE_MAX_CONN_IDLE_TIME", 1800),
+				StatementCacheCapacity:  getEnvInt("DATABASE_STATEMENT_CACHE_CAPACITY", 512),
+				CDCEnabled:              getEnvBool("DATABASE_CDC_ENABLED", false),
+				CDCPublication:          getEnv("DATABASE_CDC_PUBLICATION", "order_cdc"),
+				UniqueExternalReference: getEnvBool("DATABASE_UNIQUE_EXTERNAL_REFERENCE", false),
+				ApplicationName:         getEnv("DATABASE_APPLICATION_NAME", "order-producer"),
+				QueryComments:           getEnvBool("DATABASE_QUERY_COMMENTS", true),
+				IDStrategy:              getEnv("DATABASE_ID_STRATEGY", "uuidv4"),
+				Schema:                  getEnv("DATABASE_SCHEMA", ""),
+				TablePrefix:             getEnv("DATABASE_TABLE_PREFIX", ""),
 			},
 			Kafka: config.KafkaConfig{
-				Brokers:          []string{getEnv("KAFKA_BROKERS", "kafka:9092")},
-				GroupID:          getEnv("KAFKA_GROUP_ID", "order-processing-group"),
-				OrderTopic:       getEnv("KAFKA_ORDER_TOPIC", "order-events"),
-				RetryAttempts:    getEnvInt("KAFKA_RETRY_ATTEMPTS", 3),
-				SessionTimeout:   getEnvInt("KAFKA_SESSION_TIMEOUT", 30000),
-				CommitInterval:   getEnvInt("KAFKA_COMMIT_INTERVAL", 1000),
-				EnableAutoCommit: getEnvBool("KAFKA_ENABLE_AUTO_COMMIT", true),
+				Brokers:                    []string{getEnv("KAFKA_BROKERS", "kafka:9092")},
+				GroupID:                    getEnv("KAFKA_GROUP_ID", "order-processing-group"),
+				OrderTopic:                 getEnv("KAFKA_ORDER_TOPIC", "order-events"),
+				RetryAttempts:              getEnvInt("KAFKA_RETRY_ATTEMPTS", 3),
+				SessionTimeout:             getEnvInt("KAFKA_SESSION_TIMEOUT", 30000),
+				CommitInterval:             getEnvInt("KAFKA_COMMIT_INTERVAL", 1000),
+				EnableAutoCommit:           getEnvBool("KAFKA_ENABLE_AUTO_COMMIT", false),
+				CommitBatchSize:            getEnvInt("KAFKA_COMMIT_BATCH_SIZE", 100),
+				Region:                     getEnv("KAFKA_REGION", ""),
+				CloudEventsTopics:          strings.Split(getEnv("KAFKA_CLOUDEVENTS_TOPICS", ""), ","),
+				TopicRoutes:                strings.Split(getEnv("KAFKA_TOPIC_ROUTES", ""), ","),
+				TenantTopics:               strings.Split(getEnv("KAFKA_TENANT_TOPICS", ""), ","),
+				CloudEventsSource:          getEnv("KAFKA_CLOUDEVENTS_SOURCE", "/order-processing-microservice"),
+				Codec:                      getEnv("KAFKA_CODEC", "json"),
+				MigrationTopic:             getEnv("KAFKA_MIGRATION_TOPIC", ""),
+				MigrationPhase:             getEnv("KAFKA_MIGRATION_PHASE", ""),
+				MigrationIdle:              getEnvInt("KAFKA_MIGRATION_IDLE", 30),
+				MigrationSkew:              getEnvInt("KAFKA_MIGRATION_SKEW", 5),
+				KeyStrategy:                getEnv("KAFKA_KEY_STRATEGY", "order_id"),
+				KeyHeader:                  getEnv("KAFKA_KEY_HEADER", ""),
+				Partitioner:                getEnv("KAFKA_PARTITIONER", "hash"),
+				MaxMessageBytes:            getEnvInt("KAFKA_MAX_MESSAGE_BYTES", 1000000),
+				PublishTimeout:             getEnvInt("KAFKA_PUBLISH_TIMEOUT", 5000),
+				SecondaryBrokers:           strings.Split(getEnv("KAFKA_SECONDARY_BROKERS", ""), ","),
+				FailoverThreshold:          getEnvInt("KAFKA_FAILOVER_THRESHOLD", 3),
+				FailoverPeriod:             getEnvInt("KAFKA_FAILOVER_PERIOD", 0),
+				FailbackInterval:           getEnvInt("KAFKA_FAILBACK_INTERVAL", 60),
+				Idempotent:                 getEnvBool("KAFKA_IDEMPOTENT", false),
+				TransactionalID:            getEnv("KAFKA_TRANSACTIONAL_ID", ""),
+				SchemaRegistryURL:          getEnv("KAFKA_SCHEMA_REGISTRY_URL", ""),
+				SchemaRegistryUsername:     getEnv("KAFKA_SCHEMA_REGISTRY_USERNAME", ""),
+				SchemaRegistryPassword:     getEnv("KAFKA_SCHEMA_REGISTRY_PASSWORD", ""),
+				SchemaRegistryTimeout:      getEnvInt("KAFKA_SCHEMA_REGISTRY_TIMEOUT", 10),
+				SchemaRegistryAutoRegister: getEnvBool("KAFKA_SCHEMA_REGISTRY_AUTO_REGISTER", true),
+				AutoCreateTopics:           getEnvBool("KAFKA_AUTO_CREATE_TOPICS", false),
+				TopicPartitions:            getEnvInt("KAFKA_TOPIC_PARTITIONS", 3),
+				TopicReplicationFactor:     getEnvInt("KAFKA_TOPIC_REPLICATION_FACTOR", 1),
+				TopicRetention:             getEnvInt("KAFKA_TOPIC_RETENTION", 0),
+				DLQRetention:               getEnvInt("KAFKA_DLQ_RETENTION", 0),
 			},
 			Logger: config.LoggerConfig{
-				Level:  getEnv("LOGGER_LEVEL", "info"),
-				Format: getEnv("LOGGER_FORMAT", "json"),
+				Level:         getEnv("LOGGER_LEVEL", "info"),
+				Format:        getEnv("LOGGER_FORMAT", "json"),
+				RedactEnabled: getEnvBool("LOGGER_REDACT_ENABLED", true),
+				CrashDir:      getEnv("LOGGER_CRASH_DIR", ""),
+				CrashEndpoint: getEnv("LOGGER_CRASH_ENDPOINT", ""),
+			},
+			Quota: config.QuotaConfig{
+				MaxActiveOrders: getEnvInt("QUOTA_MAX_ACTIVE_ORDERS", 0),
+				MaxOrdersPerDay: getEnvInt("QUOTA_MAX_ORDERS_PER_DAY", 0),
+			},
+			Usage: config.UsageConfig{
+				Enabled:       getEnvBool("USAGE_ENABLED", true),
+				FlushInterval: getEnvInt("USAGE_FLUSH_INTERVAL", 60),
+			},
+			Queue: config.QueueConfig{
+				Transport:    getEnv("QUEUE_TRANSPORT", "kafka"),
+				PollInterval: getEnvInt("QUEUE_POLL_INTERVAL", 5),
+				BatchSize:    getEnvInt("QUEUE_BATCH_SIZE", 100),
+				Retention:    getEnvInt("QUEUE_RETENTION", 168),
+			},
+			ServiceBus: config.ServiceBusConfig{
+				ConnectionString:      getEnv("SERVICEBUS_CONNECTION_STRING", ""),
+				MaxDeliveryAttempts:   getEnvInt("SERVICEBUS_MAX_DELIVERY_ATTEMPTS", 5),
+				MaxConcurrentSessions: getEnvInt("SERVICEBUS_MAX_CONCURRENT_SESSIONS", 8),
+				SessionIdleTimeout:    getEnvInt("SERVICEBUS_SESSION_IDLE_TIMEOUT", 5),
+			},
+			Demo: config.DemoConfig{
+				Enabled:     getEnvBool("DEMO_ENABLED", false),
+				Interval:    getEnvInt("DEMO_INTERVAL", 1000),
+				Seed:        int64(getEnvInt("DEMO_SEED", 1)),
+				FailureRate: getEnvFloat("DEMO_FAILURE_RATE", 0.1),
+				Customers:   getEnvInt("DEMO_CUSTOMERS", 50),
+				Products:    getEnvInt("DEMO_PRODUCTS", 20),
+				MinItems:    getEnvInt("DEMO_MIN_ITEMS", 1),
+				MaxItems:    getEnvInt("DEMO_MAX_ITEMS", 5),
+				MaxQuantity: getEnvInt("DEMO_MAX_QUANTITY", 3),
+			},
+			OrderContext: config.OrderContextConfig{
+				Enabled:   getEnvBool("ORDER_CONTEXT_ENABLED", true),
+				Retention: getEnvInt("ORDER_CONTEXT_RETENTION", 2160),
+			},
+			Attachment: config.AttachmentConfig{
+				Enabled:      getEnvBool("ATTACHMENT_ENABLED", false),
+				Backend:      getEnv("ATTACHMENT_BACKEND", "local"),
+				MaxSize:      int64(getEnvInt("ATTACHMENT_MAX_SIZE", 10485760)),
+				AllowedTypes: strings.Split(getEnv("ATTACHMENT_ALLOWED_TYPES", "application/pdf,image/png,image/jpeg"), ","),
+				URLExpiry:    getEnvInt("ATTACHMENT_URL_EXPIRY", 900),
+				SigningKey:   getEnv("ATTACHMENT_SIGNING_KEY", ""),
+				LocalPath:    getEnv("ATTACHMENT_LOCAL_PATH", "./data/attachments"),
+				PublicURL:    getEnv("ATTACHMENT_PUBLIC_URL", "http://localhost:8080"),
+				S3Bucket:     getEnv("ATTACHMENT_S3_BUCKET", ""),
+				S3Region:     getEnv("ATTACHMENT_S3_REGION", "us-east-1"),
+				S3Endpoint:   getEnv("ATTACHMENT_S3_ENDPOINT", ""),
+			},
+			Delivery: config.DeliveryConfig{
+				Enabled:        getEnvBool("DELIVERY_ENABLED", false),
+				TimeZone:       getEnv("DELIVERY_TIME_ZONE", "UTC"),
+				CutoffHour:     getEnvInt("DELIVERY_CUTOFF_HOUR", 14),
+				ProcessingDays: getEnvInt("DELIVERY_PROCESSING_DAYS", 1),
+				StandardDays:   getEnvInt("DELIVERY_STANDARD_DAYS", 5),
+				ExpressDays:    getEnvInt("DELIVERY_EXPRESS_DAYS", 2),
+				OvernightDays:  getEnvInt("DELIVERY_OVERNIGHT_DAYS", 1),
+				Holidays:       strings.Split(getEnv("DELIVERY_HOLIDAYS", ""), ","),
+			},
+			BulkCancel: config.BulkCancelConfig{
+				BatchSize:  getEnvInt("BULK_CANCEL_BATCH_SIZE", 100),
+				BatchDelay: getEnvInt("BULK_CANCEL_BATCH_DELAY", 100),
+			},
+			CircuitBreaker: config.CircuitBreakerConfig{
+				Enabled:          getEnvBool("CIRCUIT_BREAKER_ENABLED", false),
+				FailureThreshold: getEnvInt("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 5),
+				OpenTimeout:      getEnvInt("CIRCUIT_BREAKER_OPEN_TIMEOUT", 30),
+				ForwardInterval:  getEnvInt("CIRCUIT_BREAKER_FORWARD_INTERVAL", 5),
+				ForwardBatchSize: getEnvInt("CIRCUIT_BREAKER_FORWARD_BATCH_SIZE", 100),
+			},
+			EventScheduler: config.EventSchedulerConfig{
+				Enabled:      getEnvBool("EVENT_SCHEDULER_ENABLED", false),
+				PollInterval: getEnvInt("EVENT_SCHEDULER_POLL_INTERVAL", 5),
+				BatchSize:    getEnvInt("EVENT_SCHEDULER_BATCH_SIZE", 100),
+			},
+			EventThrottle: config.EventThrottleConfig{
+				Enabled:   getEnvBool("EVENT_THROTTLE_ENABLED", false),
+				Intervals: strings.Split(getEnv("EVENT_THROTTLE_INTERVALS", ""), ","),
+			},
+			ProcessingAttempts: config.ProcessingAttemptsConfig{
+				Enabled: getEnvBool("PROCESSING_ATTEMPTS_ENABLED", false),
+			},
+			EventArchive: config.EventArchiveConfig{
+				Enabled:    getEnvBool("EVENT_ARCHIVE_ENABLED", false),
+				Interval:   getEnvInt("EVENT_ARCHIVE_INTERVAL", 300),
+				Delay:      getEnvInt("EVENT_ARCHIVE_DELAY", 600),
+				Prefix:     getEnv("EVENT_ARCHIVE_PREFIX", "events"),
+				Backend:    getEnv("EVENT_ARCHIVE_BACKEND", "local"),
+				LocalPath:  getEnv("EVENT_ARCHIVE_LOCAL_PATH", "./data/event-archive"),
+				S3Bucket:   getEnv("EVENT_ARCHIVE_S3_BUCKET", ""),
+				S3Region:   getEnv("EVENT_ARCHIVE_S3_REGION", "us-east-1"),
+				S3Endpoint: getEnv("EVENT_ARCHIVE_S3_ENDPOINT", ""),
+			},
+			Audit: config.AuditConfig{
+				Enabled: getEnvBool("AUDIT_ENABLED", false),
+				Topic:   getEnv("AUDIT_TOPIC", "order-audit"),
+			},
+			Heartbeat: config.HeartbeatConfig{
+				Enabled:         getEnvBool("HEARTBEAT_ENABLED", false),
+				Interval:        getEnvInt("HEARTBEAT_INTERVAL", 30),
+				MissedIntervals: getEnvInt("HEARTBEAT_MISSED_INTERVALS", 3),
 			},
 		}
 	}
@@ -72,28 +230,177 @@ func main() {
 	}
 	defer db.Close()
 
-	if err := db.CreateTables(); err != nil {
-		logrus.Fatalf("Failed to create database tables: %v", err)
+	if migration != nil {
+		if err := migration.run(context.Background(), db); err != nil {
+			logrus.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	if err := db.CheckSchema(context.Background()); err != nil {
+		logrus.Fatalf("Refusing to start: %v", err)
+	}
+
+	if cfg.Queue.Transport == queue.TransportKafka || cfg.Queue.Transport == "" {
+		if err := queue.ProvisionTopics(&cfg.Kafka); err != nil {
+			logrus.Fatalf("Failed to provision Kafka topics: %v", err)
+		}
 	}
 
-	producer, err := queue.NewKafkaProducer(&cfg.Kafka)
+	producer, err := queue.NewTransportProducer(cfg, db.GetDB())
 	if err != nil {
-		logrus.Fatalf("Failed to create Kafka producer: %v", err)
+		logrus.Fatalf("Failed to create producer: %v", err)
 	}
 	defer producer.Close()
 
-	orderRepo := repository.NewPostgresOrderRepository(db.GetDB())
-	orderService := services.NewOrderService(orderRepo, producer)
-	producerHandlers := handlers.NewProducerHandlers(orderService)
+	var publisher queue.Producer = producer
+	forwarderCtx, stopForwarder := context.WithCancel(context.Background())
+	defer stopForwarder()
+	var circuitBreaker *services.CircuitBreakerProducer
+	if cfg.CircuitBreaker.Enabled {
+		circuitBreaker = services.NewCircuitBreakerProducer(producer, repository.NewPostgresFallbackEventRepository(db.GetDB()), &cfg.CircuitBreaker)
+		go circuitBreaker.Run(forwarderCtx)
+		publisher = circuitBreaker
+	}
+
+	// Throttling sits below the event store, which keeps every event.
+	var throttlingProducer *services.ThrottlingProducer
+	if cfg.EventThrottle.Enabled {
+		throttlingProducer, err = services.NewThrottlingProducer(publisher, &cfg.EventThrottle)
+		if err != nil {
+			logrus.Fatalf("Failed to create event throttle: %v", err)
+		}
+		defer throttlingProducer.Flush()
+		publisher = throttlingProducer
+	}
+
+	eventStore := repository.NewPostgresEventStore(db.GetDB())
+	recordingProducer := services.NewRecordingProducer(publisher, eventStore)
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	if cfg.EventScheduler.Enabled {
+		eventScheduler := services.NewEventScheduler(repository.NewPostgresScheduledEventRepository(db.GetDB()), recordingProducer, &cfg.EventScheduler)
+		go eventScheduler.Run(schedulerCtx)
+	}
+
+	if cfg.EventArchive.Enabled {
+		archiveStore, err := storage.NewArchiveStore(context.Background(), &cfg.EventArchive)
+		if err != nil {
+			logrus.Fatalf("Failed to create event archive store: %v", err)
+		}
+		eventArchiver := services.NewEventArchiver(repository.NewPostgresEventArchiveRepository(db.GetDB()), archiveStore, &cfg.EventArchive)
+		go eventArchiver.Run(schedulerCtx)
+	}
+
+	// Heartbeats go straight to the transport: they are neither stored nor
+	// throttled, and a breaker holding them back would hide the outage.
+	if cfg.Heartbeat.Enabled {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "order-producer"
+		}
+		go services.NewHeartbeatEmitter(producer, hostname, &cfg.Heartbeat).Run(schedulerCtx)
+	}
+
+	idGenerator, err := models.NewIDGenerator(cfg.Database.IDStrategy)
+	if err != nil {
+		logrus.Fatalf("Failed to create ID generator: %v", err)
+	}
+	orderRepo := repository.NewPostgresOrderRepository(db.GetPool())
+	orderRepo.SetIDGenerator(idGenerator)
+	orderService := services.NewOrderService(orderRepo, recordingProducer)
+	orderService.SetIDGenerator(idGenerator)
+	orderService.EnableIdempotentStatusUpdates(eventStore)
+	if cfg.Audit.Enabled {
+		orderService.EnableAuditing(services.NewAuditor(producer, &cfg.Audit))
+	}
+	quotaService := services.NewQuotaService(repository.NewPostgresQuotaRepository(db.GetDB()), &cfg.Quota)
+	orderService.EnableQuotas(quotaService)
+	if cfg.Delivery.Enabled {
+		deliveryEstimator, err := services.NewDeliveryEstimator(&cfg.Delivery)
+		if err != nil {
+			logrus.Fatalf("Failed to create delivery estimator: %v", err)
+		}
+		orderService.EnableDeliveryEstimates(deliveryEstimator)
+	}
+	usageMeter := services.NewUsageMeter(repository.NewPostgresUsageRepository(db.GetDB()))
+	historyService := services.NewOrderHistoryService(eventStore)
+	if cfg.ProcessingAttempts.Enabled {
+		historyService.EnableAttempts(repository.NewPostgresProcessingAttemptRepository(db.GetDB()))
+	}
+	producerHandlers := handlers.NewProducerHandlers(orderService, historyService)
+	distributionRecorder := services.NewOrderDistributionRecorder()
+	orderService.EnableDistributionMetrics(distributionRecorder)
+	producerHandlers.EnableDistributionMetrics(distributionRecorder)
 
 	r := gin.New()
 	r.Use(handlers.LoggerMiddleware())
 	r.Use(handlers.CORSMiddleware())
 	r.Use(handlers.SecurityHeadersMiddleware())
 	r.Use(handlers.RequestIDMiddleware())
-	r.Use(gin.Recovery())
+	r.Use(handlers.RecoveryMiddleware(logger.NewCrashReporter("producer-api", &cfg.Logger)))
+
+	usageCtx, stopUsage := context.WithCancel(context.Background())
+	defer stopUsage()
+	if cfg.Usage.Enabled {
+		orderService.EnableUsageMetering(usageMeter)
+		r.Use(handlers.UsageMiddleware(usageMeter))
+		go usageMeter.Run(usageCtx, time.Duration(cfg.Usage.FlushInterval)*time.Second)
+	}
+
+	orderContextCtx, stopOrderContext := context.WithCancel(context.Background())
+	defer stopOrderContext()
+	if cfg.OrderContext.Enabled {
+		orderContextService := services.NewOrderContextService(repository.NewPostgresOrderContextRepository(db.GetDB()), &cfg.OrderContext)
+		orderService.EnableOrderContext(orderContextService)
+		producerHandlers.EnableOrderContext(orderContextService)
+		go orderContextService.Run(orderContextCtx, time.Hour)
+	}
+
+	demoCtx, stopDemo := context.WithCancel(context.Background())
+	defer stopDemo()
+	if cfg.Demo.Enabled {
+		go services.NewDemoGenerator(orderService, &cfg.Demo).Run(demoCtx)
+	}
 
 	producerHandlers.RegisterRoutes(r)
+	if cfg.Attachment.Enabled {
+		blobStore, err := storage.NewBlobStore(context.Background(), &cfg.Attachment)
+		if err != nil {
+			logrus.Fatalf("Failed to create attachment store: %v", err)
+		}
+		attachmentService := services.NewAttachmentService(repository.NewPostgresAttachmentRepository(db.GetDB()), orderRepo, blobStore, &cfg.Attachment)
+		handlers.NewAttachmentHandlers(attachmentService, blobStore).RegisterRoutes(r)
+	}
+	handlers.NewCatalogHandlers().RegisterRoutes(r)
+	deadLetterService := services.NewDeadLetterService(repository.NewPostgresDeadLetterRepository(db.GetDB()))
+	if cfg.Queue.Transport == queue.TransportKafka || cfg.Queue.Transport == "" {
+		requeuer, err := queue.NewDeadLetterRequeuer(&cfg.Kafka)
+		if err != nil {
+			logrus.Fatalf("Failed to create dead letter requeuer: %v", err)
+		}
+		defer requeuer.Close()
+		deadLetterService.EnableRequeue(requeuer)
+	}
+	adminHandlers := handlers.NewAdminHandlers(orderService, quotaService, usageMeter, deadLetterService)
+	if reporter, ok := producer.(queue.PayloadStatsReporter); ok {
+		adminHandlers.RegisterPayloadStatsReporter(reporter)
+	}
+	if reporter, ok := producer.(queue.ClusterReporter); ok {
+		adminHandlers.RegisterClusterReporter(reporter)
+	}
+	if throttlingProducer != nil {
+		adminHandlers.RegisterEventThrottle(throttlingProducer)
+	}
+	if circuitBreaker != nil {
+		adminHandlers.RegisterCircuitBreaker(circuitBreaker)
+	}
+	adminHandlers.RegisterProcessingWindows(services.NewProcessingWindowService(repository.NewPostgresProcessingWindowRepository(db.GetDB())))
+	bulkCancelService := services.NewBulkCancelService(repository.NewPostgresBulkCancelRepository(db.GetDB()), orderService, &cfg.BulkCancel)
+	defer bulkCancelService.Close()
+	adminHandlers.RegisterBulkCancel(bulkCancelService)
+	adminHandlers.RegisterRoutes(r)
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
@@ -114,6 +421,7 @@ func main() {
 	<-quit
 
 	logrus.Info("Shutting down Producer API server...")
+	stopDemo()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -122,6 +430,14 @@ func main() {
 		logrus.Errorf("Producer API server forced to shutdown: %v", err)
 	}
 
+	stopUsage()
+	stopOrderContext()
+	stopForwarder()
+	stopScheduler()
+	if err := usageMeter.Flush(ctx); err != nil {
+		logrus.Errorf("Failed to flush usage: %v", err)
+	}
+
 	logrus.Info("Producer API server stopped")
 }
 
@@ -148,4 +464,13 @@ func getEnvBool(key string, defaultValue bool) bool {
 		}
 	}
 	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
 }
\ No newline at end of file
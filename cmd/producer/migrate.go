@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/pkg/database"
+)
+
+const migrateUsage = `usage: producer migrate <command> [config-file]
+
+commands:
+  up           apply every pending migration
+  down [N]     roll back the newest N migrations (default 1)
+  version      print the applied and the expected schema version
+  force V      mark version V as applied and clean after a failed migration`
+
+// migrateCommand is a `producer migrate` invocation.
+type migrateCommand struct {
+	action     string
+	n          int
+	configFile string
+}
+
+func parseMigrateCommand(args []string) (migrateCommand, error) {
+	cmd := migrateCommand{configFile: "configs/local.env"}
+	if len(args) == 0 {
+		return cmd, fmt.Errorf("missing migrate command")
+	}
+	cmd.action, args = args[0], args[1:]
+
+	switch cmd.action {
+	case "up", "version":
+	case "down":
+		cmd.n = 1
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil {
+				cmd.n, args = n, args[1:]
+			}
+		}
+	case "force":
+		if len(args) == 0 {
+			return cmd, fmt.Errorf("force requires a version")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return cmd, fmt.Errorf("invalid version %q", args[0])
+		}
+		cmd.n, args = n, args[1:]
+	default:
+		return cmd, fmt.Errorf("unknown migrate command %q", cmd.action)
+	}
+
+	if len(args) > 1 {
+		return cmd, fmt.Errorf("unexpected arguments %q", args[1:])
+	}
+	if len(args) == 1 {
+		cmd.configFile = args[0]
+	}
+	return cmd, nil
+}
+
+func (c migrateCommand) run(ctx context.Context, db *database.PostgresDB) error {
+	switch c.action {
+	case "up":
+		if err := db.Migrate(ctx); err != nil {
+			return err
+		}
+	case "down":
+		if err := db.Rollback(ctx, c.n); err != nil {
+			return err
+		}
+	case "force":
+		if err := db.ForceSchemaVersion(ctx, c.n); err != nil {
+			return err
+		}
+	}
+
+	version, dirty, err := db.SchemaState(ctx)
+	if err != nil {
+		return err
+	}
+	expected, err := database.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{
+		"schema_version":   version,
+		"expected_version": expected,
+		"dirty":            dirty,
+	}).Info("Database schema version")
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/internal/storage"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/database"
+	"order-processing-microservice/pkg/logger"
+)
+
+func main() {
+	confirm := flag.Bool("confirm", false, "truncate the read-model tables and rebuild them from the order topic")
+	fromArchive := flag.Bool("archive", false, "replay the event archive before the events on the order topic that follow it")
+	flag.Parse()
+
+	configFile := "configs/local.env"
+	if flag.NArg() > 0 {
+		configFile = flag.Arg(0)
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logrus.Fatalf("Failed to load config: %v", err)
+	}
+
+	logger.Init(&cfg.Logger)
+
+	if !*confirm {
+		logrus.Fatal("Refusing to rebuild projection without -confirm; this truncates the orders and order_items tables and the order sagas, compensations and item changes")
+	}
+
+	db, err := database.NewPostgresDB(&cfg.Database)
+	if err != nil {
+		logrus.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	replayer, err := queue.NewKafkaReplayer(&cfg.Kafka)
+	if err != nil {
+		logrus.Fatalf("Failed to create Kafka replayer: %v", err)
+	}
+	defer replayer.Close()
+
+	builder := services.NewProjectionBuilder(repository.NewPostgresProjectionRepository(db.GetDB()))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	start := time.Now()
+
+	if err := builder.Reset(ctx); err != nil {
+		logrus.Fatalf("Failed to reset projection: %v", err)
+	}
+
+	var replayed int
+	var archivedUntil time.Time
+	if *fromArchive {
+		archiveStore, err := storage.NewArchiveStore(ctx, &cfg.EventArchive)
+		if err != nil {
+			logrus.Fatalf("Failed to create event archive store: %v", err)
+		}
+		archiveReplayer := services.NewArchiveReplayer(repository.NewPostgresEventArchiveRepository(db.GetDB()), archiveStore)
+		if archivedUntil, err = archiveReplayer.ArchivedUntil(ctx); err != nil {
+			logrus.Fatalf("Failed to read event archive manifest: %v", err)
+		}
+		if replayed, err = archiveReplayer.ReplayBetween(ctx, time.Time{}, archivedUntil, builder); err != nil {
+			logrus.WithField("events_replayed", replayed).Errorf("Projection rebuild failed: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	fromTopic, err := replayer.ReplaySince(ctx, archivedUntil, builder)
+	replayed += fromTopic
+	if err != nil {
+		logrus.WithField("events_replayed", replayed).Errorf("Projection rebuild failed: %v", err)
+		os.Exit(1)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"events_replayed": replayed,
+		"duration":        time.Since(start).String(),
+	}).Info("Projection rebuilt successfully")
+}
\ No newline at end of file
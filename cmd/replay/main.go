@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/internal/storage"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/database"
+	"order-processing-microservice/pkg/logger"
+)
+
+func main() {
+	since := flag.String("since", "", "replay events with a timestamp at or after this RFC3339 time")
+	until := flag.String("until", "", "replay events with a timestamp before this RFC3339 time")
+	partition := flag.Int("partition", -1, "replay a single partition by offset instead of by timestamp")
+	fromOffset := flag.Int64("from-offset", 0, "first offset of -partition to replay")
+	toOffset := flag.Int64("to-offset", -1, "last offset of -partition to replay; -1 replays to the newest message")
+	reapplyProcessed := flag.Bool("reapply-processed", false, "re-apply events already recorded in processed_events")
+	fromArchive := flag.Bool("archive", false, "replay events from the event archive instead of the order topic")
+	flag.Parse()
+
+	configFile := "configs/local.env"
+	if flag.NArg() > 0 {
+		configFile = flag.Arg(0)
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logrus.Fatalf("Failed to load config: %v", err)
+	}
+
+	logger.Init(&cfg.Logger)
+
+	var sinceTime, untilTime time.Time
+	if *since != "" {
+		if sinceTime, err = time.Parse(time.RFC3339, *since); err != nil {
+			logrus.Fatalf("Invalid -since: %v", err)
+		}
+	}
+	if *until != "" {
+		if untilTime, err = time.Parse(time.RFC3339, *until); err != nil {
+			logrus.Fatalf("Invalid -until: %v", err)
+		}
+	}
+	if *partition >= 0 && (*since != "" || *until != "") {
+		logrus.Fatal("-partition cannot be combined with -since or -until")
+	}
+	if *partition >= 0 && *fromArchive {
+		logrus.Fatal("-partition cannot be combined with -archive")
+	}
+	if *partition < 0 && *since == "" {
+		logrus.Fatal("Refusing to replay the whole topic; set -since or -partition")
+	}
+
+	db, err := database.NewPostgresDB(&cfg.Database)
+	if err != nil {
+		logrus.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	var replayer *queue.KafkaReplayer
+	var archiveReplayer *services.ArchiveReplayer
+	if *fromArchive {
+		archiveStore, err := storage.NewArchiveStore(context.Background(), &cfg.EventArchive)
+		if err != nil {
+			logrus.Fatalf("Failed to create event archive store: %v", err)
+		}
+		archiveReplayer = services.NewArchiveReplayer(repository.NewPostgresEventArchiveRepository(db.GetDB()), archiveStore)
+	} else {
+		replayer, err = queue.NewKafkaReplayer(&cfg.Kafka)
+		if err != nil {
+			logrus.Fatalf("Failed to create Kafka replayer: %v", err)
+		}
+		defer replayer.Close()
+	}
+
+	producer, err := queue.NewTransportProducer(cfg, db.GetDB())
+	if err != nil {
+		logrus.Fatalf("Failed to create producer: %v", err)
+	}
+	defer producer.Close()
+
+	orderProcessor := services.NewOrderProcessor(
+		repository.NewPostgresOrderRepository(db.GetPool()),
+		services.NewRecordingProducer(producer, repository.NewPostgresEventStore(db.GetDB())),
+	)
+	orderProcessor.EnableCompensations(repository.NewPostgresCompensationRepository(db.GetDB()))
+	if !*reapplyProcessed {
+		orderProcessor.EnableDeduplication(repository.NewPostgresProcessedEventRepository(db.GetDB()))
+	}
+	if cfg.ProcessingWindows.Enabled {
+		orderProcessor.EnableProcessingWindows(services.NewProcessingWindowService(repository.NewPostgresProcessingWindowRepository(db.GetDB())))
+	}
+	if cfg.Delivery.Enabled {
+		deliveryEstimator, err := services.NewDeliveryEstimator(&cfg.Delivery)
+		if err != nil {
+			logrus.Fatalf("Failed to create delivery estimator: %v", err)
+		}
+		orderProcessor.EnableDeliveryEstimates(deliveryEstimator)
+	}
+	if cfg.Saga.Enabled {
+		orderProcessor.EnableSaga(repository.NewPostgresSagaRepository(db.GetDB()), producer, &cfg.Saga)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	start := time.Now()
+
+	var replayed int
+	switch {
+	case archiveReplayer != nil:
+		replayed, err = archiveReplayer.ReplayBetween(ctx, sinceTime, untilTime, orderProcessor)
+	case *partition >= 0:
+		replayed, err = replayer.ReplayOffsets(ctx, int32(*partition), *fromOffset, *toOffset, orderProcessor)
+	default:
+		replayed, err = replayer.ReplayBetween(ctx, sinceTime, untilTime, orderProcessor)
+	}
+	if err != nil {
+		logrus.WithField("events_replayed", replayed).Errorf("Replay failed: %v", err)
+		os.Exit(1)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"events_replayed": replayed,
+		"duration":        time.Since(start).String(),
+	}).Info("Replay finished")
+}
\ No newline at end of file
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/database"
+	"order-processing-microservice/pkg/logger"
+)
+
+func main() {
+	timeout := flag.Duration("timeout", 60*time.Second, "how long to wait for the smoke order to complete and its events to reach the topic")
+	pollInterval := flag.Duration("poll-interval", time.Second, "how often to check the order status and the topic")
+	flag.Parse()
+
+	configFile := "configs/local.env"
+	if flag.NArg() > 0 {
+		configFile = flag.Arg(0)
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		logrus.Fatalf("Failed to load config: %v", err)
+	}
+
+	logger.Init(&cfg.Logger)
+
+	db, err := database.NewPostgresDB(&cfg.Database)
+	if err != nil {
+		logrus.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	producer, err := queue.NewTransportProducer(cfg, db.GetDB())
+	if err != nil {
+		logrus.Fatalf("Failed to create producer: %v", err)
+	}
+	defer producer.Close()
+
+	var replayer services.EventReplayer
+	if cfg.Queue.Transport == queue.TransportKafka || cfg.Queue.Transport == "" {
+		kafkaReplayer, err := queue.NewKafkaReplayer(&cfg.Kafka)
+		if err != nil {
+			logrus.Fatalf("Failed to create Kafka replayer: %v", err)
+		}
+		defer kafkaReplayer.Close()
+		replayer = kafkaReplayer
+	} else {
+		logrus.WithField("transport", cfg.Queue.Transport).Warn("Skipping topic check; only supported for the Kafka transport")
+	}
+
+	eventStore := repository.NewPostgresEventStore(db.GetDB())
+	orderRepo := repository.NewPostgresOrderRepository(db.GetPool())
+	orderService := services.NewOrderService(orderRepo, services.NewRecordingProducer(producer, eventStore))
+	check := services.NewSmokeCheck(orderService, orderRepo, replayer, *pollInterval)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, *timeout)
+	defer cancelTimeout()
+
+	result, err := check.Run(ctx)
+	if err != nil {
+		entry := logrus.WithError(err)
+		if result != nil {
+			entry = entry.WithFields(logrus.Fields{
+				"order_id": result.OrderID,
+				"status":   result.Status,
+				"events":   result.Events,
+			})
+		}
+		entry.Error("Smoke test failed")
+		os.Exit(1)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"order_id": result.OrderID,
+		"events":   result.Events,
+		"duration": result.Duration.String(),
+	}).Info("Smoke test passed")
+}
\ No newline at end of file
@@ -7,12 +7,16 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/cache"
 	"order-processing-microservice/internal/handlers"
+	"order-processing-microservice/internal/models"
 	"order-processing-microservice/internal/queue"
 	"order-processing-microservice/internal/repository"
 	"order-processing-microservice/internal/services"
@@ -39,57 +43,229 @@ func main() {
 				WriteTimeout: getEnvInt("SERVER_WRITE_TIMEOUT", 10),
 			},
 			Database: config.DatabaseConfig{
-				Host:         getEnv("DATABASE_HOST", "localhost"),
-				Port:         getEnvInt("DATABASE_PORT", 5432),
-				Username:     getEnv("DATABASE_USERNAME", "postgres"),
-				Password:     getEnv("DATABASE_PASSWORD", "postgres"),
-				Database:     getEnv("DATABASE_DATABASE", "orders"),
-				SSLMode:      getEnv("DATABASE_SSL_MODE", "disable"),
-				MaxOpenConns: getEnvInt("DATABASE_MAX_OPEN_CONNS", 25),
-				MaxIdleConns: getEnvInt("DATABASE_MAX_IDLE_CONNS", 5),
+				Host:                   getEnv("DATABASE_HOST", "localhost"),
+				Port:                   getEnvInt("DATABASE_PORT", 5432),
+				Username:               getEnv("DATABASE_USERNAME", "postgres"),
+				Password:               getEnv("DATABASE_PASSWORD", "postgres"),
+				Database:               getEnv("DATABASE_DATABASE", "orders"),
+				SSLMode:                getEnv("DATABASE_SSL_MODE", "disable"),
+				MaxOpenConns:           getEnvInt("DATABASE_MAX_OPEN_CONNS", 25),
+				MaxIdleConns:           getEnvInt("DATABASE_MAX_IDLE_CONNS", 5),
+				MaxConnIdleTime:        getEnvInt("DATABASE_MAX_CONN_IDLE_TIME", 1800),
+				StatementCacheCapacity: getEnvInt("DATABASE_STATEMENT_CACHE_CAPACITY", 512),
+				ApplicationName:        getEnv("DATABASE_APPLICATION_NAME", "order-status-api"),
+				QueryComments:          getEnvBool("DATABASE_QUERY_COMMENTS", true),
+				ReplicaHost:            getEnv("DATABASE_REPLICA_HOST", ""),
+				Schema:                 getEnv("DATABASE_SCHEMA", ""),
+				TablePrefix:            getEnv("DATABASE_TABLE_PREFIX", ""),
 			},
 			Kafka: config.KafkaConfig{
-				Brokers:          []string{getEnv("KAFKA_BROKERS", "kafka:9092")},
-				GroupID:          getEnv("KAFKA_GROUP_ID", "order-processing-group"),
-				OrderTopic:       getEnv("KAFKA_ORDER_TOPIC", "order-events"),
-				RetryAttempts:    getEnvInt("KAFKA_RETRY_ATTEMPTS", 3),
-				SessionTimeout:   getEnvInt("KAFKA_SESSION_TIMEOUT", 30000),
-				CommitInterval:   getEnvInt("KAFKA_COMMIT_INTERVAL", 1000),
-				EnableAutoCommit: getEnvBool("KAFKA_ENABLE_AUTO_COMMIT", true),
+				Brokers:                  []string{getEnv("KAFKA_BROKERS", "kafka:9092")},
+				GroupID:                  getEnv("KAFKA_GROUP_ID", "order-processing-group"),
+				OrderTopic:               getEnv("KAFKA_ORDER_TOPIC", "order-events"),
+				RetryAttempts:            getEnvInt("KAFKA_RETRY_ATTEMPTS", 3),
+				SessionTimeout:           getEnvInt("KAFKA_SESSION_TIMEOUT", 30000),
+				RebalanceStrategy:        getEnv("KAFKA_REBALANCE_STRATEGY", "sticky"),
+				CommitInterval:           getEnvInt("KAFKA_COMMIT_INTERVAL", 1000),
+				EnableAutoCommit:         getEnvBool("KAFKA_ENABLE_AUTO_COMMIT", false),
+				CommitBatchSize:          getEnvInt("KAFKA_COMMIT_BATCH_SIZE", 100),
+				Region:                   getEnv("KAFKA_REGION", ""),
+				MigrationTopic:           getEnv("KAFKA_MIGRATION_TOPIC", ""),
+				TopicRoutes:              strings.Split(getEnv("KAFKA_TOPIC_ROUTES", ""), ","),
+				TenantTopics:             strings.Split(getEnv("KAFKA_TENANT_TOPICS", ""), ","),
+				EmptyAssignmentThreshold: getEnvInt("KAFKA_EMPTY_ASSIGNMENT_THRESHOLD", 60),
+				KeyStrategy:              getEnv("KAFKA_KEY_STRATEGY", "order_id"),
+				KeyHeader:                getEnv("KAFKA_KEY_HEADER", ""),
+				Partitioner:              getEnv("KAFKA_PARTITIONER", "hash"),
+				MaxMessageBytes:          getEnvInt("KAFKA_MAX_MESSAGE_BYTES", 1000000),
+				PublishTimeout:           getEnvInt("KAFKA_PUBLISH_TIMEOUT", 5000),
+				SecondaryBrokers:         strings.Split(getEnv("KAFKA_SECONDARY_BROKERS", ""), ","),
+				FailoverThreshold:        getEnvInt("KAFKA_FAILOVER_THRESHOLD", 3),
+				FailoverPeriod:           getEnvInt("KAFKA_FAILOVER_PERIOD", 0),
+				FailbackInterval:         getEnvInt("KAFKA_FAILBACK_INTERVAL", 60),
+				Idempotent:               getEnvBool("KAFKA_IDEMPOTENT", false),
+				TransactionalID:          getEnv("KAFKA_TRANSACTIONAL_ID", ""),
+				SchemaRegistryURL:        getEnv("KAFKA_SCHEMA_REGISTRY_URL", ""),
+				SchemaRegistryUsername:   getEnv("KAFKA_SCHEMA_REGISTRY_USERNAME", ""),
+				SchemaRegistryPassword:   getEnv("KAFKA_SCHEMA_REGISTRY_PASSWORD", ""),
+				SchemaRegistryTimeout:    getEnvInt("KAFKA_SCHEMA_REGISTRY_TIMEOUT", 10),
 			},
 			Logger: config.LoggerConfig{
-				Level:  getEnv("LOGGER_LEVEL", "info"),
-				Format: getEnv("LOGGER_FORMAT", "json"),
+				Level:         getEnv("LOGGER_LEVEL", "info"),
+				Format:        getEnv("LOGGER_FORMAT", "json"),
+				RedactEnabled: getEnvBool("LOGGER_REDACT_ENABLED", true),
+				CrashDir:      getEnv("LOGGER_CRASH_DIR", ""),
+				CrashEndpoint: getEnv("LOGGER_CRASH_ENDPOINT", ""),
+			},
+			Queue: config.QueueConfig{
+				Transport:    getEnv("QUEUE_TRANSPORT", "kafka"),
+				PollInterval: getEnvInt("QUEUE_POLL_INTERVAL", 5),
+				BatchSize:    getEnvInt("QUEUE_BATCH_SIZE", 100),
+				Retention:    getEnvInt("QUEUE_RETENTION", 168),
+			},
+			ServiceBus: config.ServiceBusConfig{
+				ConnectionString:      getEnv("SERVICEBUS_CONNECTION_STRING", ""),
+				MaxDeliveryAttempts:   getEnvInt("SERVICEBUS_MAX_DELIVERY_ATTEMPTS", 5),
+				MaxConcurrentSessions: getEnvInt("SERVICEBUS_MAX_CONCURRENT_SESSIONS", 8),
+				SessionIdleTimeout:    getEnvInt("SERVICEBUS_SESSION_IDLE_TIMEOUT", 5),
+			},
+			Cache: config.CacheConfig{
+				Enabled:            getEnvBool("CACHE_ENABLED", true),
+				TTL:                getEnvInt("CACHE_TTL", 5),
+				StaleTTL:           getEnvInt("CACHE_STALE_TTL", 30),
+				RefreshTimeout:     getEnvInt("CACHE_REFRESH_TIMEOUT", 5),
+				MaxEntries:         getEnvInt("CACHE_MAX_ENTRIES", 1000),
+				StatusCacheSize:    getEnvInt("CACHE_STATUS_CACHE_SIZE", 10000),
+				LiveStreamInterval: getEnvInt("CACHE_LIVE_STREAM_INTERVAL", 1),
+			},
+			Export: config.ExportConfig{
+				MaxLimit:  getEnvInt("EXPORT_MAX_LIMIT", 1000),
+				SafetyLag: getEnvInt("EXPORT_SAFETY_LAG", 5),
+			},
+			Tracking: config.TrackingConfig{
+				Enabled:    getEnvBool("TRACKING_ENABLED", false),
+				SigningKey: getEnv("TRACKING_SIGNING_KEY", ""),
+				TTL:        getEnvInt("TRACKING_TTL", 720),
+				BaseURL:    getEnv("TRACKING_BASE_URL", "http://localhost:9080"),
+				ShowItems:  getEnvBool("TRACKING_SHOW_ITEMS", false),
+				ShowTotal:  getEnvBool("TRACKING_SHOW_TOTAL", false),
+			},
+			EventFeed: config.EventFeedConfig{
+				Port:   getEnvInt("EVENT_FEED_PORT", 0),
+				Buffer: getEnvInt("EVENT_FEED_BUFFER", 256),
 			},
 		}
 	}
 
 	logger.Init(&cfg.Logger)
 
+	// Registered first so it runs after every other deferred Close.
+	var consumerErr error
+	defer func() {
+		if consumerErr != nil {
+			os.Exit(1)
+		}
+	}()
+
 	db, err := database.NewPostgresDB(&cfg.Database)
 	if err != nil {
 		logrus.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 
-	producer, err := queue.NewKafkaProducer(&cfg.Kafka)
+	if err := db.CheckSchema(context.Background()); err != nil {
+		logrus.Fatalf("Refusing to start: %v", err)
+	}
+
+	producer, err := queue.NewTransportProducer(cfg, db.GetDB())
 	if err != nil {
-		logrus.Fatalf("Failed to create Kafka producer: %v", err)
+		logrus.Fatalf("Failed to create producer: %v", err)
 	}
 	defer producer.Close()
 
-	orderRepo := repository.NewPostgresOrderRepository(db.GetDB())
-	orderService := services.NewOrderService(orderRepo, producer)
-	statusHandlers := handlers.NewStatusHandlers(orderService)
+	// The status API only reads orders, so it can be pointed at a replica.
+	readDB := db
+	if cfg.Database.ReplicaHost != "" {
+		replicaCfg := cfg.Database
+		replicaCfg.Host = cfg.Database.ReplicaHost
+		readDB, err = database.NewPostgresDB(&replicaCfg)
+		if err != nil {
+			logrus.Fatalf("Failed to connect to database replica: %v", err)
+		}
+		defer readDB.Close()
+	}
+
+	orderService := services.NewOrderQueryService(repository.NewPostgresOrderReader(readDB.GetPool()))
+
+	var responseCache *cache.SWRCache
+	if cfg.Cache.Enabled {
+		responseCache = cache.NewSWRCache(&cfg.Cache)
+	}
+
+	statusCache := cache.NewOrderStatusCache(cfg.Cache.StatusCacheSize, func(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+		return orderService.GetOrderByID(ctx, id, models.WithoutItems())
+	})
+
+	hostname, _ := os.Hostname()
+	cacheConsumerCfg := cfg.Kafka
+	cacheConsumerCfg.GroupID = fmt.Sprintf("%s-status-cache-%s", cfg.Kafka.GroupID, hostname)
+	cacheConsumerCfg.InitialOffset = "newest"
+
+	// The cache only needs the newest events, so during a topic migration it
+	// simply follows both topics; stale duplicates are ignored by the cache.
+	// Routed event types and isolated tenants are followed on their own
+	// topics.
+	topicRouter, err := queue.NewTopicRouter(&cfg.Kafka)
+	if err != nil {
+		logrus.Fatalf("Failed to parse topic routes: %v", err)
+	}
+	cacheTopics := topicRouter.Topics()
+	for _, topic := range topicRouter.TenantTopics() {
+		cacheTopics = append(cacheTopics, topic)
+	}
+	if cfg.Kafka.MigrationTopic != "" {
+		cacheTopics = append(cacheTopics, cfg.Kafka.MigrationTopic)
+	}
+
+	cacheConsumer, err := queue.NewTransportConsumer(cfg, &cacheConsumerCfg, db.GetDB(), cacheTopics)
+	if err != nil {
+		logrus.Fatalf("Failed to create status cache consumer: %v", err)
+	}
+	defer cacheConsumer.Close()
+
+	consumerCtx, consumerCancel := context.WithCancel(context.Background())
+	defer consumerCancel()
+
+	// The gRPC event feed streams the events followed by the status cache.
+	var cacheHandler queue.EventHandler = statusCache
+	var feedServer *queue.FeedServer
+	if cfg.EventFeed.Port > 0 {
+		feed := queue.NewEventFeed(cfg.EventFeed.Buffer)
+		feedServer = queue.NewFeedServer(feed)
+		cacheHandler = queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+			feed.HandleEvent(ctx, event)
+			return statusCache.HandleEvent(ctx, event)
+		})
+	}
+
+	if err := cacheConsumer.Subscribe(consumerCtx, cacheHandler); err != nil {
+		logrus.Fatalf("Failed to subscribe status cache to topics: %v", err)
+	}
+
+	consumerErrs := make(chan error, 1)
+	go func() {
+		if err := cacheConsumer.Wait(); err != nil {
+			consumerErrs <- err
+		}
+	}()
+
+	statusHandlers := handlers.NewStatusHandlers(orderService, responseCache, statusCache, time.Duration(cfg.Cache.LiveStreamInterval)*time.Second)
+	if reporter, ok := cacheConsumer.(queue.AssignmentReporter); ok {
+		statusHandlers.RegisterAssignmentReporter("status_cache", reporter)
+	}
+	if reporter, ok := producer.(queue.PayloadStatsReporter); ok {
+		statusHandlers.RegisterPayloadStatsReporter(reporter)
+	}
+	if reporter, ok := producer.(queue.ClusterReporter); ok {
+		statusHandlers.RegisterClusterReporter(reporter)
+	}
+	exportHandlers := handlers.NewExportHandlers(orderService, &cfg.Export)
 
 	r := gin.New()
 	r.Use(handlers.LoggerMiddleware())
 	r.Use(handlers.CORSMiddleware())
 	r.Use(handlers.SecurityHeadersMiddleware())
 	r.Use(handlers.RequestIDMiddleware())
-	r.Use(gin.Recovery())
+	r.Use(handlers.RecoveryMiddleware(logger.NewCrashReporter("status-api", &cfg.Logger)))
 
 	statusHandlers.RegisterRoutes(r)
+	exportHandlers.RegisterRoutes(r)
+	if cfg.Tracking.Enabled {
+		if cfg.Tracking.SigningKey == "" {
+			logrus.Fatal("TRACKING_SIGNING_KEY is required when tracking is enabled")
+		}
+		handlers.NewTrackingHandlers(services.NewTrackingService(orderService, &cfg.Tracking)).RegisterRoutes(r)
+	}
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
@@ -105,11 +281,32 @@ func main() {
 		}
 	}()
 
+	var feedSrv *http.Server
+	if feedServer != nil {
+		// Streams are long-lived, so only reading the headers has a timeout.
+		feedSrv = &http.Server{
+			Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.EventFeed.Port),
+			Handler:           feedServer.Handler(),
+			ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		}
+
+		go func() {
+			logrus.Infof("Order event feed starting on %s", feedSrv.Addr)
+			if err := feedSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logrus.Fatalf("Failed to start order event feed: %v", err)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
 
-	logrus.Info("Shutting down Status API server...")
+	select {
+	case <-quit:
+		logrus.Info("Shutting down Status API server...")
+	case consumerErr = <-consumerErrs:
+		logrus.WithError(consumerErr).Error("Status cache consumer stopped with a fatal error, shutting down")
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -117,6 +314,12 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logrus.Errorf("Status API server forced to shutdown: %v", err)
 	}
+	if feedSrv != nil {
+		feedServer.Close()
+		if err := feedSrv.Shutdown(ctx); err != nil {
+			logrus.Errorf("Order event feed forced to shutdown: %v", err)
+		}
+	}
 
 	logrus.Info("Status API server stopped")
 }
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+)
+
+// OrderStatusEntry is the cached status of an order. Sequence is that of the
+// event it was taken from, 0 if it was loaded from the database.
+type OrderStatusEntry struct {
+	OrderID   uuid.UUID          `json:"order_id"`
+	Status    models.OrderStatus `json:"status"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Sequence  uint64             `json:"sequence,omitempty"`
+}
+
+// olderThan reports whether e is older than other: by their event sequence if
+// both have one, since timestamps from different instances may be skewed, and
+// by UpdatedAt otherwise.
+func (e OrderStatusEntry) olderThan(other OrderStatusEntry) bool {
+	if e.Sequence > 0 && other.Sequence > 0 {
+		return e.Sequence < other.Sequence
+	}
+	return e.UpdatedAt.Before(other.UpdatedAt)
+}
+
+type OrderLoader func(ctx context.Context, id uuid.UUID) (*models.Order, error)
+
+type OrderStatusCache struct {
+	capacity int
+	loader   OrderLoader
+
+	mu    sync.Mutex
+	items map[uuid.UUID]*list.Element
+	lru   *list.List
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+
+	logger *logrus.Entry
+}
+
+var eventStatuses = map[models.EventType]models.OrderStatus{
+	models.OrderCreatedEvent:    models.OrderStatusPending,
+	models.OrderProcessingEvent: models.OrderStatusProcessing,
+	models.OrderCompletedEvent:  models.OrderStatusCompleted,
+	models.OrderFailedEvent:     models.OrderStatusFailed,
+	models.OrderCanceledEvent:   models.OrderStatusCanceled,
+}
+
+func NewOrderStatusCache(capacity int, loader OrderLoader) *OrderStatusCache {
+	return &OrderStatusCache{
+		capacity: capacity,
+		loader:   loader,
+		items:    make(map[uuid.UUID]*list.Element),
+		lru:      list.New(),
+		logger:   logrus.WithField("component", "order_status_cache"),
+	}
+}
+
+func (c *OrderStatusCache) Get(ctx context.Context, id uuid.UUID) (OrderStatusEntry, error) {
+	c.mu.Lock()
+	if elem, ok := c.items[id]; ok {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(OrderStatusEntry)
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return entry, nil
+	}
+	c.mu.Unlock()
+
+	c.misses.Add(1)
+	order, err := c.loader(ctx, id)
+	if err != nil {
+		return OrderStatusEntry{}, err
+	}
+
+	entry := OrderStatusEntry{
+		OrderID:   order.ID,
+		Status:    order.Status,
+		UpdatedAt: order.UpdatedAt,
+	}
+	c.Update(entry)
+	return entry, nil
+}
+
+func (c *OrderStatusCache) Update(entry OrderStatusEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[entry.OrderID]; ok {
+		existing := elem.Value.(OrderStatusEntry)
+		if entry.olderThan(existing) {
+			return
+		}
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.items[entry.OrderID] = c.lru.PushFront(entry)
+
+	for c.capacity > 0 && c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.items, oldest.Value.(OrderStatusEntry).OrderID)
+		c.evictions.Add(1)
+	}
+}
+
+func (c *OrderStatusCache) Invalidate(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[id]; ok {
+		c.lru.Remove(elem)
+		delete(c.items, id)
+	}
+}
+
+func (c *OrderStatusCache) Stats() Stats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+
+	return Stats{
+		Entries:   entries,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+func (c *OrderStatusCache) HandleEvent(ctx context.Context, event *models.Event) error {
+	data, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid event data format")
+	}
+
+	orderIDStr, ok := data["order_id"].(string)
+	if !ok {
+		return nil
+	}
+
+	orderID, err := uuid.Parse(orderIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid order_id in event data: %w", err)
+	}
+
+	status, known := eventStatuses[event.Type]
+	if event.Type == models.OrderStatusChangedEvent {
+		newStatus, _ := data["new_status"].(string)
+		status, known = models.OrderStatus(newStatus), newStatus != ""
+	}
+
+	if !known {
+		c.Invalidate(orderID)
+		return nil
+	}
+
+	c.Update(OrderStatusEntry{
+		OrderID:   orderID,
+		Status:    status,
+		UpdatedAt: event.Timestamp,
+		Sequence:  event.Sequence,
+	})
+
+	c.logger.WithFields(logrus.Fields{
+		"order_id": orderID,
+		"status":   status,
+	}).Debug("Order status cache updated from event")
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/pkg/config"
+)
+
+type State string
+
+const (
+	StateHit    State = "HIT"
+	StateStale  State = "STALE"
+	StateMiss   State = "MISS"
+	StateBypass State = "BYPASS"
+)
+
+type Loader func(ctx context.Context) (interface{}, error)
+
+type Stats struct {
+	Entries       int    `json:"entries"`
+	Hits          uint64 `json:"hits"`
+	StaleHits     uint64 `json:"stale_hits"`
+	Misses        uint64 `json:"misses"`
+	Refreshes     uint64 `json:"refreshes"`
+	RefreshErrors uint64 `json:"refresh_errors"`
+	Evictions     uint64 `json:"evictions"`
+}
+
+type entry struct {
+	value    interface{}
+	storedAt time.Time
+}
+
+type SWRCache struct {
+	ttl            time.Duration
+	staleTTL       time.Duration
+	refreshTimeout time.Duration
+	maxEntries     int
+
+	mu         sync.Mutex
+	entries    map[string]*entry
+	refreshing map[string]bool
+
+	hits          atomic.Uint64
+	staleHits     atomic.Uint64
+	misses        atomic.Uint64
+	refreshes     atomic.Uint64
+	refreshErrors atomic.Uint64
+	evictions     atomic.Uint64
+
+	logger *logrus.Entry
+}
+
+func NewSWRCache(cfg *config.CacheConfig) *SWRCache {
+	return &SWRCache{
+		ttl:            time.Duration(cfg.TTL) * time.Second,
+		staleTTL:       time.Duration(cfg.StaleTTL) * time.Second,
+		refreshTimeout: time.Duration(cfg.RefreshTimeout) * time.Second,
+		maxEntries:     cfg.MaxEntries,
+		entries:        make(map[string]*entry),
+		refreshing:     make(map[string]bool),
+		logger:         logrus.WithField("component", "response_cache"),
+	}
+}
+
+func (c *SWRCache) Get(ctx context.Context, key string, loader Loader) (interface{}, State, error) {
+	if c == nil {
+		value, err := loader(ctx)
+		return value, StateBypass, err
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		age := time.Since(e.storedAt)
+		if age < c.ttl {
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return e.value, StateHit, nil
+		}
+
+		if age < c.ttl+c.staleTTL {
+			if !c.refreshing[key] {
+				c.refreshing[key] = true
+				go c.refresh(key, loader)
+			}
+			c.mu.Unlock()
+			c.staleHits.Add(1)
+			return e.value, StateStale, nil
+		}
+	}
+	c.mu.Unlock()
+
+	c.misses.Add(1)
+	value, err := loader(ctx)
+	if err != nil {
+		return nil, StateMiss, err
+	}
+
+	c.set(key, value)
+	return value, StateMiss, nil
+}
+
+func (c *SWRCache) Invalidate(key string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+func (c *SWRCache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return Stats{
+		Entries:       entries,
+		Hits:          c.hits.Load(),
+		StaleHits:     c.staleHits.Load(),
+		Misses:        c.misses.Load(),
+		Refreshes:     c.refreshes.Load(),
+		RefreshErrors: c.refreshErrors.Load(),
+		Evictions:     c.evictions.Load(),
+	}
+}
+
+func (c *SWRCache) refresh(key string, loader Loader) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.refreshing, key)
+		c.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.refreshTimeout)
+	defer cancel()
+
+	c.refreshes.Add(1)
+	value, err := loader(ctx)
+	if err != nil {
+		c.refreshErrors.Add(1)
+		c.logger.WithFields(logrus.Fields{
+			"key":   key,
+			"error": err,
+		}).Warn("Failed to revalidate cache entry, serving stale value")
+		return
+	}
+
+	c.set(key, value)
+}
+
+func (c *SWRCache) set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = &entry{value: value, storedAt: time.Now()}
+
+	if c.maxEntries <= 0 || len(c.entries) <= c.maxEntries {
+		return
+	}
+
+	var oldestKey string
+	var oldest time.Time
+	for k, e := range c.entries {
+		if time.Since(e.storedAt) >= c.ttl+c.staleTTL {
+			delete(c.entries, k)
+			c.evictions.Add(1)
+			continue
+		}
+		if oldestKey == "" || e.storedAt.Before(oldest) {
+			oldestKey, oldest = k, e.storedAt
+		}
+	}
+
+	if len(c.entries) > c.maxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+		c.evictions.Add(1)
+	}
+}
\ No newline at end of file
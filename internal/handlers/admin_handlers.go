@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/utils"
+)
+
+type AdminHandlers struct {
+	orderService      *services.OrderService
+	quotaService      *services.QuotaService
+	usageMeter        *services.UsageMeter
+	deadLetterService *services.DeadLetterService
+	payloadStats      queue.PayloadStatsReporter
+	cluster           queue.ClusterReporter
+	processingWindows *services.ProcessingWindowService
+	bulkCancel        *services.BulkCancelService
+	eventThrottle     *services.ThrottlingProducer
+	circuitBreaker    *services.CircuitBreakerProducer
+}
+
+func NewAdminHandlers(orderService *services.OrderService, quotaService *services.QuotaService, usageMeter *services.UsageMeter, deadLetterService *services.DeadLetterService) *AdminHandlers {
+	return &AdminHandlers{
+		orderService:      orderService,
+		quotaService:      quotaService,
+		usageMeter:        usageMeter,
+		deadLetterService: deadLetterService,
+	}
+}
+
+// RegisterPayloadStatsReporter exposes the producer's event size histogram
+// under /api/v1/admin/event-sizes.
+func (h *AdminHandlers) RegisterPayloadStatsReporter(reporter queue.PayloadStatsReporter) {
+	h.payloadStats = reporter
+}
+
+// RegisterClusterReporter exposes the producer's active Kafka cluster under
+// /api/v1/admin/kafka-cluster.
+func (h *AdminHandlers) RegisterClusterReporter(reporter queue.ClusterReporter) {
+	h.cluster = reporter
+}
+
+// RegisterProcessingWindows exposes the per-tenant processing windows under
+// /api/v1/admin/tenants/:tenantId/processing-windows.
+func (h *AdminHandlers) RegisterProcessingWindows(processingWindows *services.ProcessingWindowService) {
+	h.processingWindows = processingWindows
+}
+
+// RegisterBulkCancel exposes bulk cancel jobs under
+// /api/v1/admin/orders/cancel.
+func (h *AdminHandlers) RegisterBulkCancel(bulkCancel *services.BulkCancelService) {
+	h.bulkCancel = bulkCancel
+}
+
+// RegisterEventThrottle exposes the event throttle counts under
+// /api/v1/admin/event-throttle.
+func (h *AdminHandlers) RegisterEventThrottle(eventThrottle *services.ThrottlingProducer) {
+	h.eventThrottle = eventThrottle
+}
+
+// RegisterCircuitBreaker exposes the producer's circuit breaker under
+// /api/v1/admin/circuit-breaker.
+func (h *AdminHandlers) RegisterCircuitBreaker(circuitBreaker *services.CircuitBreakerProducer) {
+	h.circuitBreaker = circuitBreaker
+}
+
+func (h *AdminHandlers) HoldOrders(c *gin.Context) {
+	h.transitionOrders(c, models.OrderStatusOnHold, h.orderService.HoldOrders, "Orders placed on hold")
+}
+
+func (h *AdminHandlers) ReleaseOrders(c *gin.Context) {
+	h.transitionOrders(c, models.OrderStatusPending, h.orderService.ReleaseOrders, "Orders released")
+}
+
+type transitionFunc func(ctx context.Context, filter models.OrderFilter, reason string) ([]*models.Order, error)
+
+func (h *AdminHandlers) transitionOrders(c *gin.Context, status models.OrderStatus, transition transitionFunc, message string) {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order filter")
+		return
+	}
+
+	if filter.IsEmpty() && c.Query("all") != "true" {
+		utils.RespondWithError(c, http.StatusBadRequest,
+			fmt.Errorf("filter required"), "Provide customer_id, created_after or created_before, or all=true to match every order")
+		return
+	}
+
+	orders, err := transition(c.Request.Context(), filter, c.Query("reason"))
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	orderIDs := make([]uuid.UUID, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	utils.RespondWithSuccess(c, &models.BulkStatusChangeResponse{
+		Status:   status,
+		Filter:   filter,
+		Count:    len(orderIDs),
+		OrderIDs: orderIDs,
+	}, message)
+}
+
+// CancelOrders starts a job cancelling the orders matching the filter in the
+// request body and returns it; GetBulkCancelJob reports its progress.
+func (h *AdminHandlers) CancelOrders(c *gin.Context) {
+	var req models.BulkCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+	req.Actor = getActor(c)
+
+	job, err := h.bulkCancel.Start(c.Request.Context(), &req)
+	if err != nil {
+		if !respondWithServiceError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithAccepted(c, job, "Bulk cancel job started")
+}
+
+// RecalculateTotals recomputes the totals of the orders in the request body,
+// e.g. after a pricing bug, and returns the adjustments made.
+func (h *AdminHandlers) RecalculateTotals(c *gin.Context) {
+	var req models.RecalculateTotalsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+	req.Actor = getActor(c)
+
+	result, err := h.orderService.RecalculateTotals(c.Request.Context(), &req)
+	if err != nil {
+		if !respondWithServiceError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	message := "Order totals recalculated"
+	if req.DryRun {
+		message = "Order totals recalculated (dry run, nothing saved)"
+	}
+	utils.RespondWithSuccess(c, result, message)
+}
+
+func (h *AdminHandlers) GetBulkCancelJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid job ID format")
+		return
+	}
+
+	job, err := h.bulkCancel.GetJob(c.Request.Context(), id)
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, job)
+}
+
+func (h *AdminHandlers) GetTenantQuota(c *gin.Context) {
+	status, err := h.quotaService.GetQuotaStatus(c.Request.Context(), c.Param("tenantId"))
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, status, "Tenant quota retrieved successfully")
+}
+
+func (h *AdminHandlers) UpdateTenantQuota(c *gin.Context) {
+	var req models.UpdateTenantQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+
+	quota, err := h.quotaService.UpdateQuota(c.Request.Context(), c.Param("tenantId"), &req)
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, quota, "Tenant quota updated successfully")
+}
+
+func (h *AdminHandlers) GetProcessingWindows(c *gin.Context) {
+	windows, err := h.processingWindows.GetWindows(c.Request.Context(), c.Param("tenantId"))
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, windows, "Processing windows retrieved successfully")
+}
+
+func (h *AdminHandlers) UpdateProcessingWindows(c *gin.Context) {
+	var req models.UpdateProcessingWindowsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+
+	windows, err := h.processingWindows.UpdateWindows(c.Request.Context(), c.Param("tenantId"), &req)
+	if err != nil {
+		if !respondWithServiceError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, windows, "Processing windows updated successfully")
+}
+
+func (h *AdminHandlers) DeleteProcessingWindows(c *gin.Context) {
+	if err := h.processingWindows.DeleteWindows(c.Request.Context(), c.Param("tenantId")); err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, nil, "Processing windows deleted successfully")
+}
+
+// GetUsage reports hourly usage between from (default 24 hours ago) and to
+// (default now), optionally for a single tenant.
+func (h *AdminHandlers) GetUsage(c *gin.Context) {
+	now := time.Now().UTC()
+	filter := models.UsageFilter{
+		TenantID: c.Query("tenant_id"),
+		From:     now.Add(-24 * time.Hour),
+		To:       now,
+	}
+
+	if raw := c.Query("from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, err, "from must be an RFC3339 timestamp")
+			return
+		}
+		filter.From = from
+	}
+
+	if raw := c.Query("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, err, "to must be an RFC3339 timestamp")
+			return
+		}
+		filter.To = to
+	}
+
+	if !filter.From.Before(filter.To) {
+		utils.RespondWithError(c, http.StatusBadRequest, fmt.Errorf("invalid time range"), "from must be before to")
+		return
+	}
+
+	report, err := h.usageMeter.GetUsage(c.Request.Context(), filter)
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, report, "Usage retrieved successfully")
+}
+
+func (h *AdminHandlers) ListDeadLetters(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	events, total, err := h.deadLetterService.ListDeadLetters(c.Request.Context(), limit, offset)
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+	if events == nil {
+		events = []*models.DeadLetterEvent{}
+	}
+
+	utils.RespondWithList(c, events, utils.NewOffsetMeta(limit, offset, len(events)).WithTotal(total))
+}
+
+func (h *AdminHandlers) GetDeadLetter(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid dead letter ID format")
+		return
+	}
+
+	event, err := h.deadLetterService.GetDeadLetter(c.Request.Context(), id)
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, event, "Dead letter event retrieved successfully")
+}
+
+func (h *AdminHandlers) RequeueDeadLetters(c *gin.Context) {
+	var req models.DeadLetterSelectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+
+	response, err := h.deadLetterService.RequeueDeadLetters(c.Request.Context(), req.IDs)
+	if err != nil {
+		if !respondWithServiceError(c, err) && !respondWithPublishError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, response, "Dead letters requeued successfully")
+}
+
+func (h *AdminHandlers) PurgeDeadLetters(c *gin.Context) {
+	var req models.DeadLetterSelectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+
+	response, err := h.deadLetterService.PurgeDeadLetters(c.Request.Context(), req.IDs)
+	if err != nil {
+		if !respondWithServiceError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, response, "Dead letters purged successfully")
+}
+
+func (h *AdminHandlers) GetEventSizes(c *gin.Context) {
+	if h.payloadStats == nil {
+		utils.RespondWithError(c, http.StatusNotImplemented, fmt.Errorf("event size metrics are not recorded by the configured transport"), "Event size metrics unavailable")
+		return
+	}
+
+	utils.RespondWithSuccess(c, h.payloadStats.PayloadStats(), "Event size metrics retrieved successfully")
+}
+
+func (h *AdminHandlers) GetEventThrottle(c *gin.Context) {
+	if h.eventThrottle == nil {
+		utils.RespondWithError(c, http.StatusNotImplemented, fmt.Errorf("event throttling is not enabled"), "Event throttling not enabled")
+		return
+	}
+
+	utils.RespondWithSuccess(c, h.eventThrottle.ThrottleStats(), "Event throttle metrics retrieved successfully")
+}
+
+func (h *AdminHandlers) GetCircuitBreaker(c *gin.Context) {
+	if h.circuitBreaker == nil {
+		utils.RespondWithError(c, http.StatusNotImplemented, fmt.Errorf("the circuit breaker is not enabled"), "Circuit breaker not enabled")
+		return
+	}
+
+	utils.RespondWithSuccess(c, h.circuitBreaker.Status(), "Circuit breaker status retrieved successfully")
+}
+
+func (h *AdminHandlers) GetKafkaCluster(c *gin.Context) {
+	if h.cluster == nil {
+		utils.RespondWithError(c, http.StatusNotImplemented, fmt.Errorf("no secondary Kafka cluster is configured"), "Kafka failover not configured")
+		return
+	}
+
+	utils.RespondWithSuccess(c, h.cluster.ClusterStatus(), "Kafka cluster status retrieved successfully")
+}
+
+func parseOrderFilter(c *gin.Context) (models.OrderFilter, error) {
+	var filter models.OrderFilter
+
+	if raw := c.Query("customer_id"); raw != "" {
+		customerID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid customer_id: %w", err)
+		}
+		filter.CustomerID = &customerID
+	}
+
+	if raw := c.Query("created_after"); raw != "" {
+		createdAfter, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_after: %w", err)
+		}
+		filter.CreatedAfter = &createdAfter
+	}
+
+	if raw := c.Query("created_before"); raw != "" {
+		createdBefore, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_before: %w", err)
+		}
+		filter.CreatedBefore = &createdBefore
+	}
+
+	if raw := c.Query("channel"); raw != "" {
+		channel := models.OrderChannel(raw)
+		if !channel.IsValid() {
+			return filter, fmt.Errorf("invalid channel: %s", raw)
+		}
+		filter.Channel = channel
+	}
+
+	return filter, nil
+}
+
+func (h *AdminHandlers) RegisterRoutes(r *gin.Engine) {
+	admin := r.Group("/api/v1/admin")
+	{
+		orders := admin.Group("/orders")
+		{
+			orders.POST("/hold", h.HoldOrders)
+			orders.POST("/release", h.ReleaseOrders)
+			orders.POST("/recalculate", h.RecalculateTotals)
+			if h.bulkCancel != nil {
+				orders.POST("/cancel", h.CancelOrders)
+				orders.GET("/cancel/:jobId", h.GetBulkCancelJob)
+			}
+		}
+
+		tenants := admin.Group("/tenants")
+		{
+			tenants.GET("/:tenantId/quota", h.GetTenantQuota)
+			tenants.PUT("/:tenantId/quota", h.UpdateTenantQuota)
+			if h.processingWindows != nil {
+				tenants.GET("/:tenantId/processing-windows", h.GetProcessingWindows)
+				tenants.PUT("/:tenantId/processing-windows", h.UpdateProcessingWindows)
+				tenants.DELETE("/:tenantId/processing-windows", h.DeleteProcessingWindows)
+			}
+		}
+
+		admin.GET("/usage", h.GetUsage)
+		admin.GET("/event-sizes", h.GetEventSizes)
+		admin.GET("/kafka-cluster", h.GetKafkaCluster)
+		admin.GET("/event-throttle", h.GetEventThrottle)
+		admin.GET("/circuit-breaker", h.GetCircuitBreaker)
+
+		deadLetters := admin.Group("/dead-letters")
+		{
+			deadLetters.GET("", h.ListDeadLetters)
+			deadLetters.GET("/:id", h.GetDeadLetter)
+			deadLetters.POST("/requeue", h.RequeueDeadLetters)
+			deadLetters.POST("/purge", h.PurgeDeadLetters)
+		}
+	}
+}
\ No newline at end of file
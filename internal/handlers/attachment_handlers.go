@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/internal/storage"
+	"order-processing-microservice/pkg/utils"
+)
+
+// multipartOverhead is the room left above the attachment size limit for the
+// multipart boundaries and part headers of an upload.
+const multipartOverhead = 64 << 10
+
+type AttachmentHandlers struct {
+	attachmentService *services.AttachmentService
+	store             storage.BlobStore
+}
+
+func NewAttachmentHandlers(attachmentService *services.AttachmentService, store storage.BlobStore) *AttachmentHandlers {
+	return &AttachmentHandlers{
+		attachmentService: attachmentService,
+		store:             store,
+	}
+}
+
+func (h *AttachmentHandlers) UploadAttachment(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order ID format")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachmentService.MaxSize()+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "A file is required in the file form field")
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.Upload(c.Request.Context(), orderID, header.Filename, file)
+	if err != nil {
+		if !respondWithRepositoryError(c, err) && !respondWithServiceError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithCreated(c, attachment, "Attachment uploaded successfully")
+}
+
+func (h *AttachmentHandlers) ListAttachments(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order ID format")
+		return
+	}
+
+	attachments, err := h.attachmentService.List(c.Request.Context(), orderID)
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, attachments)
+}
+
+// GetAttachment returns the attachment metadata with a signed download URL.
+func (h *AttachmentHandlers) GetAttachment(c *gin.Context) {
+	orderID, id, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	download, err := h.attachmentService.Get(c.Request.Context(), orderID, id)
+	if err != nil {
+		respondWithAttachmentError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, download)
+}
+
+// DownloadAttachment redirects to a signed download URL of the attachment.
+func (h *AttachmentHandlers) DownloadAttachment(c *gin.Context) {
+	orderID, id, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	download, err := h.attachmentService.Get(c.Request.Context(), orderID, id)
+	if err != nil {
+		respondWithAttachmentError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, download.DownloadURL)
+}
+
+func (h *AttachmentHandlers) DeleteAttachment(c *gin.Context) {
+	orderID, id, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentService.Delete(c.Request.Context(), orderID, id); err != nil {
+		respondWithAttachmentError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, nil, "Attachment deleted successfully")
+}
+
+// ServeSignedDownload streams a blob of a store that signs its own URLs. The
+// signature is the only authorization, so the route needs no credentials.
+func (h *AttachmentHandlers) ServeSignedDownload(c *gin.Context) {
+	verifier := h.store.(storage.SignedURLVerifier)
+	key, opts, err := verifier.VerifySignedURL(c.Request.URL.Query())
+	if err != nil {
+		utils.RespondWithError(c, http.StatusForbidden, err, "Invalid or expired download URL")
+		return
+	}
+
+	blob, err := h.store.Get(c.Request.Context(), key)
+	if err != nil {
+		if !respondWithServiceError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+	defer blob.Close()
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": opts.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, blob); err != nil {
+		c.Error(err)
+	}
+}
+
+func (h *AttachmentHandlers) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api/v1")
+	{
+		attachments := api.Group("/orders/:id/attachments")
+		{
+			attachments.POST("", h.UploadAttachment)
+			attachments.GET("", h.ListAttachments)
+			attachments.GET("/:attachmentId", h.GetAttachment)
+			attachments.GET("/:attachmentId/download", h.DownloadAttachment)
+			attachments.DELETE("/:attachmentId", h.DeleteAttachment)
+		}
+	}
+
+	if _, ok := h.store.(storage.SignedURLVerifier); ok {
+		r.GET(storage.LocalDownloadPath, h.ServeSignedDownload)
+	}
+}
+
+func parseAttachmentParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order ID format")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid attachment ID format")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orderID, id, true
+}
+
+func respondWithAttachmentError(c *gin.Context, err error) {
+	if !respondWithRepositoryError(c, err) {
+		utils.RespondWithInternalError(c, err)
+	}
+}
\ No newline at end of file
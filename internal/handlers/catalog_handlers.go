@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/utils"
+)
+
+type CatalogHandlers struct {
+	catalog *models.EventCatalog
+}
+
+func NewCatalogHandlers() *CatalogHandlers {
+	return &CatalogHandlers{
+		catalog: models.BuildEventCatalog(),
+	}
+}
+
+func (h *CatalogHandlers) GetEventCatalog(c *gin.Context) {
+	utils.RespondWithSuccess(c, h.catalog)
+}
+
+func (h *CatalogHandlers) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api/v1")
+	{
+		api.GET("/events/catalog", h.GetEventCatalog)
+	}
+}
\ No newline at end of file
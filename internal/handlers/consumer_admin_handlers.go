@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/utils"
+)
+
+// ConsumerAdminHandlers serves the consumer's admin API, on a port of its own
+// so it is not exposed together with the health endpoints.
+type ConsumerAdminHandlers struct {
+	consumers map[string]queue.PausableConsumer
+	clusters  map[string]queue.ClusterSwitchingConsumer
+}
+
+// PauseRequest selects the partitions to pause or resume. An empty topic
+// selects every consumed topic, and no partitions every partition.
+type PauseRequest struct {
+	Topic      string  `json:"topic"`
+	Partitions []int32 `json:"partitions"`
+}
+
+// ClusterRequest selects the Kafka cluster to consume from: primary or
+// secondary.
+type ClusterRequest struct {
+	Cluster string `json:"cluster" binding:"required"`
+}
+
+func NewConsumerAdminHandlers() *ConsumerAdminHandlers {
+	return &ConsumerAdminHandlers{
+		consumers: make(map[string]queue.PausableConsumer),
+		clusters:  make(map[string]queue.ClusterSwitchingConsumer),
+	}
+}
+
+// RegisterConsumer allows pausing and resuming consumer under name.
+func (h *ConsumerAdminHandlers) RegisterConsumer(name string, consumer queue.PausableConsumer) {
+	h.consumers[name] = consumer
+}
+
+// RegisterClusterSwitch allows switching the Kafka cluster of consumer under
+// name.
+func (h *ConsumerAdminHandlers) RegisterClusterSwitch(name string, consumer queue.ClusterSwitchingConsumer) {
+	h.clusters[name] = consumer
+}
+
+func (h *ConsumerAdminHandlers) ListPaused(c *gin.Context) {
+	paused := make(map[string]map[string][]int32, len(h.consumers))
+	for name, consumer := range h.consumers {
+		paused[name] = consumer.Paused()
+	}
+	utils.RespondWithSuccess(c, paused, "Paused partitions retrieved successfully")
+}
+
+func (h *ConsumerAdminHandlers) Pause(c *gin.Context) {
+	h.setPaused(c, true)
+}
+
+func (h *ConsumerAdminHandlers) Resume(c *gin.Context) {
+	h.setPaused(c, false)
+}
+
+func (h *ConsumerAdminHandlers) setPaused(c *gin.Context, pause bool) {
+	name := c.Param("name")
+	consumer, ok := h.consumers[name]
+	if !ok {
+		utils.RespondWithError(c, http.StatusNotFound, fmt.Errorf("consumer %s not found", name), "Consumer not found")
+		return
+	}
+
+	var req PauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+
+	var err error
+	message := "Consumption paused"
+	if pause {
+		err = consumer.Pause(req.Topic, req.Partitions)
+	} else {
+		err = consumer.Resume(req.Topic, req.Partitions)
+		message = "Consumption resumed"
+	}
+	if err != nil {
+		if !respondWithServiceError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, consumer.Paused(), message)
+}
+
+func (h *ConsumerAdminHandlers) GetCluster(c *gin.Context) {
+	name := c.Param("name")
+	consumer, ok := h.clusters[name]
+	if !ok {
+		utils.RespondWithError(c, http.StatusNotFound, fmt.Errorf("consumer %s not found", name), "Consumer not found")
+		return
+	}
+
+	utils.RespondWithSuccess(c, consumer.Cluster(), "Kafka cluster retrieved successfully")
+}
+
+func (h *ConsumerAdminHandlers) SwitchCluster(c *gin.Context) {
+	name := c.Param("name")
+	consumer, ok := h.clusters[name]
+	if !ok {
+		utils.RespondWithError(c, http.StatusNotFound, fmt.Errorf("consumer %s not found", name), "Consumer not found")
+		return
+	}
+
+	var req ClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+
+	if err := consumer.SwitchCluster(req.Cluster); err != nil {
+		if !respondWithServiceError(c, err) && !respondWithPublishError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, consumer.Cluster(), "Kafka cluster switched")
+}
+
+func (h *ConsumerAdminHandlers) RegisterRoutes(r *gin.Engine) {
+	consumers := r.Group("/api/v1/admin/consumers")
+	{
+		consumers.GET("", h.ListPaused)
+		consumers.POST("/:name/pause", h.Pause)
+		consumers.POST("/:name/resume", h.Resume)
+		consumers.GET("/:name/cluster", h.GetCluster)
+		consumers.POST("/:name/cluster", h.SwitchCluster)
+	}
+}
\ No newline at end of file
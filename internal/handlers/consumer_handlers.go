@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/services"
+)
+
+// ConsumerHandlers serves the health endpoints of the consumer service.
+type ConsumerHandlers struct {
+	consumers map[string]queue.LagReporter
+	watchdogs map[string]queue.WatchdogReporter
+	workers   map[string]queue.WorkerReporter
+	heartbeat *services.HeartbeatMonitor
+}
+
+func NewConsumerHandlers() *ConsumerHandlers {
+	return &ConsumerHandlers{
+		consumers: make(map[string]queue.LagReporter),
+		watchdogs: make(map[string]queue.WatchdogReporter),
+		workers:   make(map[string]queue.WorkerReporter),
+	}
+}
+
+// RegisterLagReporter adds a consumer that must catch up before the service
+// reports ready.
+func (h *ConsumerHandlers) RegisterLagReporter(name string, reporter queue.LagReporter) {
+	h.consumers[name] = reporter
+}
+
+// RegisterWatchdog adds a consumer whose stall watchdog is reported at
+// GET /watchdog.
+func (h *ConsumerHandlers) RegisterWatchdog(name string, reporter queue.WatchdogReporter) {
+	h.watchdogs[name] = reporter
+}
+
+// RegisterWorkerReporter adds a consumer whose workers are exposed to
+// Prometheus at GET /metrics.
+func (h *ConsumerHandlers) RegisterWorkerReporter(name string, reporter queue.WorkerReporter) {
+	h.workers[name] = reporter
+}
+
+// RegisterHeartbeatMonitor exposes the time since the last producer
+// heartbeat at GET /metrics and GET /heartbeat.
+func (h *ConsumerHandlers) RegisterHeartbeatMonitor(monitor *services.HeartbeatMonitor) {
+	h.heartbeat = monitor
+}
+
+func (h *ConsumerHandlers) HealthCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "healthy",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"service":   "order-processing-consumer",
+		"version":   "1.0.0",
+	})
+}
+
+// Readiness responds 503 until every registered consumer has joined its group
+// and caught up to within its lag threshold.
+func (h *ConsumerHandlers) Readiness(c *gin.Context) {
+	lags := make(map[string]queue.ConsumerLag, len(h.consumers))
+	ready := true
+	for name, reporter := range h.consumers {
+		lag := reporter.Lag()
+		lags[name] = lag
+		if !lag.Ready {
+			ready = false
+		}
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, gin.H{
+		"status":    status,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"consumers": lags,
+	})
+}
+
+// Watchdog reports the stall watchdog of every registered consumer, including
+// how often it rebuilt the consumer's group client.
+func (h *ConsumerHandlers) Watchdog(c *gin.Context) {
+	watchdogs := make(map[string]queue.WatchdogStatus, len(h.watchdogs))
+	for name, reporter := range h.watchdogs {
+		watchdogs[name] = reporter.Watchdog()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"consumers": watchdogs,
+	})
+}
+
+// Heartbeat reports the producer heartbeats the consumer handled.
+func (h *ConsumerHandlers) Heartbeat(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"heartbeat": h.heartbeat.Status(),
+	})
+}
+
+// Metrics serves the workers of each registered consumer and partition as a
+// Prometheus gauge, followed by the heartbeat metrics if registered.
+func (h *ConsumerHandlers) Metrics(c *gin.Context) {
+	var b strings.Builder
+	b.WriteString("# HELP consumer_workers Handlers a consumer may run at once on a partition.\n")
+	b.WriteString("# TYPE consumer_workers gauge\n")
+
+	names := make([]string, 0, len(h.workers))
+	for name := range h.workers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		workers := h.workers[name].Workers()
+		topics := make([]string, 0, len(workers.Partitions))
+		for topic := range workers.Partitions {
+			topics = append(topics, topic)
+		}
+		slices.Sort(topics)
+		for _, topic := range topics {
+			partitions := make([]int32, 0, len(workers.Partitions[topic]))
+			for partition := range workers.Partitions[topic] {
+				partitions = append(partitions, partition)
+			}
+			slices.Sort(partitions)
+			for _, partition := range partitions {
+				fmt.Fprintf(&b, "consumer_workers{consumer=%q,topic=%q,partition=\"%d\"} %d\n",
+					name, topic, partition, workers.Partitions[topic][partition])
+			}
+		}
+	}
+
+	if h.heartbeat != nil {
+		h.heartbeat.WritePrometheus(&b)
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
+func (h *ConsumerHandlers) RegisterRoutes(r *gin.Engine) {
+	r.GET("/health", h.HealthCheck)
+	r.GET("/ready", h.Readiness)
+	r.GET("/watchdog", h.Watchdog)
+	r.GET("/metrics", h.Metrics)
+	if h.heartbeat != nil {
+		r.GET("/heartbeat", h.Heartbeat)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/internal/storage"
+	"order-processing-microservice/pkg/utils"
+)
+
+// respondWithRepositoryError responds 404 if err is a missing resource and
+// 409 if it is a version conflict or a duplicate, and reports whether it
+// responded. Other errors are left to the caller.
+func respondWithRepositoryError(c *gin.Context, err error) bool {
+	var repoErr *repository.Error
+	if !errors.As(err, &repoErr) {
+		return false
+	}
+	resource := strings.ToUpper(repoErr.Resource[:1]) + repoErr.Resource[1:]
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		utils.RespondWithNotFound(c, resource)
+	case errors.Is(err, repository.ErrVersionConflict):
+		utils.RespondWithError(c, http.StatusConflict, err, resource+" was changed concurrently; reload it and retry")
+	case errors.Is(err, repository.ErrDuplicate):
+		utils.RespondWithError(c, http.StatusConflict, err, resource+" already exists")
+	default:
+		return false
+	}
+	return true
+}
+
+// respondWithServiceError responds with the status of a request the services
+// rejected, e.g. 400 for an invalid request or 429 for an exhausted daily
+// quota, and reports whether it responded. Other errors are left to the
+// caller.
+func respondWithServiceError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrInvalidBulkCancel),
+		errors.Is(err, services.ErrTooManyOrders),
+		errors.Is(err, services.ErrInvalidPrice),
+		errors.Is(err, services.ErrInvalidProcessingWindows),
+		errors.Is(err, services.ErrTooManyDeadLetters),
+		errors.Is(err, services.ErrAttachmentEmpty),
+		errors.Is(err, queue.ErrTopicNotConsumed),
+		errors.Is(err, queue.ErrUnknownCluster):
+		utils.RespondWithError(c, http.StatusBadRequest, err)
+	case errors.Is(err, services.ErrBulkCancelFilterRequired):
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Provide customer_id, created_after, created_before or channel, or all=true to match every order")
+	case errors.Is(err, models.ErrActiveOrderQuotaExceeded):
+		utils.RespondWithError(c, http.StatusForbidden, err, "Tenant has reached its active order quota")
+	case errors.Is(err, models.ErrDailyOrderQuotaExceeded):
+		nextDay := models.QuotaDayStart(time.Now()).Add(24 * time.Hour)
+		c.Header("Retry-After", strconv.Itoa(int(time.Until(nextDay).Seconds())+1))
+		utils.RespondWithError(c, http.StatusTooManyRequests, err, "Tenant has reached its daily order quota")
+	case errors.Is(err, services.ErrInvalidTrackingToken):
+		utils.RespondWithNotFound(c, "Order")
+	case errors.Is(err, services.ErrTrackingTokenExpired):
+		utils.RespondWithError(c, http.StatusGone, err, "Tracking link expired")
+	case errors.Is(err, storage.ErrBlobNotFound):
+		utils.RespondWithNotFound(c, "Attachment")
+	case errors.Is(err, queue.ErrTopicPausedAsWhole):
+		utils.RespondWithError(c, http.StatusConflict, err)
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		utils.RespondWithError(c, http.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, services.ErrAttachmentTypeNotAllowed):
+		utils.RespondWithError(c, http.StatusUnsupportedMediaType, err)
+	case errors.Is(err, services.ErrDeadLetterRequeueDisabled):
+		utils.RespondWithError(c, http.StatusNotImplemented, err, "Dead letter requeue not enabled")
+	case errors.Is(err, queue.ErrClusterSwitchDisabled):
+		utils.RespondWithError(c, http.StatusNotImplemented, err, "Kafka failover not configured")
+	default:
+		return false
+	}
+	return true
+}
+
+// respondWithPublishError responds 504 if err is a publish timeout and 503 if
+// the broker is unavailable, and reports whether it responded.
+func respondWithPublishError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, queue.ErrPublishTimeout):
+		utils.RespondWithError(c, http.StatusGatewayTimeout, err, "Timed out publishing to the broker")
+	case errors.Is(err, queue.ErrBrokerUnavailable):
+		utils.RespondWithError(c, http.StatusServiceUnavailable, err, "Broker unavailable")
+	default:
+		return false
+	}
+	return true
+}
\ No newline at end of file
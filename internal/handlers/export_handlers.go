@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/utils"
+)
+
+type ExportHandlers struct {
+	orderService *services.OrderQueryService
+	maxLimit     int
+	safetyLag    time.Duration
+}
+
+func NewExportHandlers(orderService *services.OrderQueryService, cfg *config.ExportConfig) *ExportHandlers {
+	return &ExportHandlers{
+		orderService: orderService,
+		maxLimit:     cfg.MaxLimit,
+		safetyLag:    time.Duration(cfg.SafetyLag) * time.Second,
+	}
+}
+
+func (h *ExportHandlers) GetChanges(c *gin.Context) {
+	cursor, err := models.ParseChangeCursor(c.Query("since"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid since cursor")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(h.maxLimit)))
+	if err != nil || limit <= 0 || limit > h.maxLimit {
+		limit = h.maxLimit
+	}
+
+	orders, next, hasMore, err := h.orderService.GetOrderChanges(c.Request.Context(), cursor, h.safetyLag, limit)
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	responses := make([]*models.OrderResponse, 0, len(orders))
+	for _, order := range orders {
+		responses = append(responses, &models.OrderResponse{
+			ID:                order.ID,
+			OrderNumber:       order.OrderNumber,
+			ExternalReference: order.ExternalReference,
+			Channel:           order.Channel,
+			ShippingMethod:    order.ShippingMethod,
+			EstimatedDelivery: order.EstimatedDelivery,
+			CustomerID:        order.CustomerID,
+			Status:            order.Status,
+			FailureCode:       order.FailureCode,
+			FailureDetail:     order.FailureDetail,
+			Items:             order.Items,
+			TotalAmount:       order.TotalAmount,
+			CreatedAt:         order.CreatedAt,
+			UpdatedAt:         order.UpdatedAt,
+			DeletedAt:         order.DeletedAt,
+		})
+	}
+
+	utils.RespondWithList(c, responses, utils.NewCursorMeta(limit, c.Query("since"), next.Encode(), len(responses), hasMore))
+}
+
+func (h *ExportHandlers) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api/v1")
+	{
+		export := api.Group("/export")
+		{
+			export.GET("/changes", h.GetChanges)
+		}
+	}
+}
\ No newline at end of file
@@ -1,10 +1,20 @@
 package handlers
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/database"
+	"order-processing-microservice/pkg/logger"
+	"order-processing-microservice/pkg/requestctx"
+	"order-processing-microservice/pkg/utils"
 )
 
 func LoggerMiddleware() gin.HandlerFunc {
@@ -21,11 +31,17 @@ func LoggerMiddleware() gin.HandlerFunc {
 		statusCode := c.Writer.Status()
 		bodySize := c.Writer.Size()
 
+		for _, param := range c.Params {
+			if masked := logger.RedactValue(param.Key, param.Value); masked != param.Value {
+				path = strings.Replace(path, param.Value, masked, 1)
+			}
+		}
+
 		if raw != "" {
-			path = path + "?" + raw
+			path = path + "?" + logger.RedactQuery(raw)
 		}
 
-		logger := logrus.WithFields(logrus.Fields{
+		entry := logrus.WithFields(requestctx.LogFields(c.Request.Context())).WithFields(logrus.Fields{
 			"status":     statusCode,
 			"latency":    latency,
 			"client_ip":  clientIP,
@@ -36,9 +52,9 @@ func LoggerMiddleware() gin.HandlerFunc {
 		})
 
 		if len(c.Errors) > 0 {
-			logger.Error(c.Errors.String())
+			entry.Error(c.Errors.String())
 		} else {
-			logger.Info("Request processed")
+			entry.Info("Request processed")
 		}
 	}
 }
@@ -70,6 +86,9 @@ func SecurityHeadersMiddleware() gin.HandlerFunc {
 	}
 }
 
+// RequestIDMiddleware sets up the request-scoped values of the request's
+// context, see requestctx: its ID, the caller's tenant and principal, and its
+// trace.
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.GetHeader("X-Request-ID")
@@ -77,11 +96,130 @@ func RequestIDMiddleware() gin.HandlerFunc {
 			requestID = generateRequestID()
 		}
 		c.Header("X-Request-ID", requestID)
-		c.Set("request_id", requestID)
+
+		ctx := requestctx.WithRequestID(c.Request.Context(), requestID)
+		ctx = requestctx.WithTenant(ctx, headerTenantID(c))
+		ctx = requestctx.WithPrincipal(ctx, requestctx.Principal{
+			Actor:    headerActor(c),
+			APIKeyID: strings.TrimSpace(c.GetHeader("X-API-Key-ID")),
+		})
+		// Events published for the request carry its span, in the caller's
+		// trace or a new one, and its request ID as correlation ID.
+		ctx = requestctx.WithTrace(ctx, requestctx.Trace{
+			Traceparent:   queue.ChildTraceparent(c.GetHeader("traceparent")),
+			CorrelationID: requestID,
+		})
+		ctx = database.WithQueryTag(ctx, "request_id", requestID)
+		ctx = database.WithQueryTag(ctx, "traceparent", c.GetHeader("traceparent"))
+		c.Request = c.Request.WithContext(ctx)
 		c.Next()
 	}
 }
 
+// RecoveryMiddleware turns a panic in a handler into a 500 response and a
+// crash report of the request, whose ID is returned in the X-Crash-ID header.
+// http.ErrAbortHandler, which aborts the response on purpose, is passed on.
+func RecoveryMiddleware(crashes *logger.CrashReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			fields := requestctx.LogFields(c.Request.Context())
+			fields["method"] = c.Request.Method
+			fields["route"] = c.FullPath()
+			fields["client_ip"] = c.ClientIP()
+			report := crashes.Report(recovered, fields)
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.Header("X-Crash-ID", report.ID)
+			utils.RespondWithInternalError(c, fmt.Errorf("request failed unexpectedly, crash report %s", report.ID))
+			c.Abort()
+		}()
+		c.Next()
+	}
+}
+
+// UsageMiddleware counts every API call against the caller's tenant.
+func UsageMiddleware(usageMeter *services.UsageMeter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		usageMeter.Record(getTenantID(c), models.UsageMetricAPICalls)
+	}
+}
+
+// getTenantID returns the tenant of the request, see RequestIDMiddleware.
+func getTenantID(c *gin.Context) string {
+	return requestctx.Tenant(c.Request.Context())
+}
+
+// getActor returns the actor of the request, see RequestIDMiddleware.
+func getActor(c *gin.Context) string {
+	return requestctx.Actor(c.Request.Context())
+}
+
+func headerTenantID(c *gin.Context) string {
+	if tenantID := strings.TrimSpace(c.GetHeader("X-Tenant-ID")); tenantID != "" {
+		return tenantID
+	}
+	return models.DefaultTenantID
+}
+
+func headerActor(c *gin.Context) string {
+	if actor := strings.TrimSpace(c.GetHeader("X-Actor")); actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+// getOrderContext captures the request origin of an order being created. The
+// API key ID is set by the gateway that authenticated the caller.
+func getOrderContext(c *gin.Context) *models.OrderContext {
+	origin := c.GetHeader("Origin")
+	if origin == "" {
+		origin = c.GetHeader("Referer")
+	}
+	ctx := c.Request.Context()
+	return &models.OrderContext{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		APIKeyID:  requestctx.PrincipalFrom(ctx).APIKeyID,
+		Origin:    origin,
+		RequestID: requestctx.RequestID(ctx),
+	}
+}
+
+// maskOrderContext masks the personal and credential data of an order
+// context for responses.
+func maskOrderContext(orderContext *models.OrderContext) *models.OrderContext {
+	masked := *orderContext
+	if masked.IPAddress != "" {
+		masked.IPAddress = logger.MaskIP(masked.IPAddress)
+	}
+	if masked.APIKeyID != "" {
+		masked.APIKeyID = logger.MaskPartial(masked.APIKeyID)
+	}
+	return &masked
+}
+
+func parseOrderFields(c *gin.Context) (models.OrderFields, bool) {
+	fields, err := models.ParseOrderFields(c.Query("fields"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err,
+			"Valid fields: id, order_number, external_reference, channel, shipping_method, customer_id, status, failure_code, failure_detail, items, total_amount, estimated_delivery, created_at, updated_at")
+		return nil, false
+	}
+	return fields, true
+}
+
 func generateRequestID() string {
 	return time.Now().Format("20060102150405") + "-" + "req"
 }
\ No newline at end of file
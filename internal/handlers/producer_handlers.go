@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -13,15 +15,31 @@ import (
 )
 
 type ProducerHandlers struct {
-	orderService *services.OrderService
+	orderService   *services.OrderService
+	historyService *services.OrderHistoryService
+	orderContexts  *services.OrderContextService
+	distribution   *services.OrderDistributionRecorder
 }
 
-func NewProducerHandlers(orderService *services.OrderService) *ProducerHandlers {
+func NewProducerHandlers(orderService *services.OrderService, historyService *services.OrderHistoryService) *ProducerHandlers {
 	return &ProducerHandlers{
-		orderService: orderService,
+		orderService:   orderService,
+		historyService: historyService,
 	}
 }
 
+// EnableOrderContext exposes the recorded request origin of orders at
+// GET /api/v1/orders/:id/context.
+func (h *ProducerHandlers) EnableOrderContext(orderContexts *services.OrderContextService) {
+	h.orderContexts = orderContexts
+}
+
+// EnableDistributionMetrics exposes the order distributions in recorder to
+// Prometheus at GET /metrics.
+func (h *ProducerHandlers) EnableDistributionMetrics(recorder *services.OrderDistributionRecorder) {
+	h.distribution = recorder
+}
+
 func (h *ProducerHandlers) CreateOrder(c *gin.Context) {
 	var req models.CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -30,25 +48,45 @@ func (h *ProducerHandlers) CreateOrder(c *gin.Context) {
 	}
 
 	if len(req.Items) == 0 {
-		utils.RespondWithError(c, http.StatusBadRequest, 
+		utils.RespondWithError(c, http.StatusBadRequest,
 			fmt.Errorf("at least one item is required"), "Order must contain at least one item")
 		return
 	}
 
+	req.TenantID = getTenantID(c)
+	req.Context = getOrderContext(c)
+	if req.Channel == "" {
+		req.Channel = models.OrderChannel(strings.TrimSpace(c.GetHeader("X-Order-Channel")))
+		if req.Channel != "" && !req.Channel.IsValid() {
+			utils.RespondWithError(c, http.StatusBadRequest,
+				fmt.Errorf("invalid channel"), "Valid channels: web, mobile, api, pos")
+			return
+		}
+	}
+
 	order, err := h.orderService.CreateOrder(c.Request.Context(), &req)
 	if err != nil {
-		utils.RespondWithInternalError(c, err)
+		if !respondWithRepositoryError(c, err) && !respondWithServiceError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
 		return
 	}
 
 	response := &models.OrderResponse{
-		ID:          order.ID,
-		CustomerID:  order.CustomerID,
-		Status:      order.Status,
-		Items:       order.Items,
-		TotalAmount: order.TotalAmount,
-		CreatedAt:   order.CreatedAt,
-		UpdatedAt:   order.UpdatedAt,
+		ID:                order.ID,
+		OrderNumber:       order.OrderNumber,
+		ExternalReference: order.ExternalReference,
+		Channel:           order.Channel,
+		ShippingMethod:    order.ShippingMethod,
+		EstimatedDelivery: order.EstimatedDelivery,
+		CustomerID:        order.CustomerID,
+		Status:            order.Status,
+		FailureCode:       order.FailureCode,
+		FailureDetail:     order.FailureDetail,
+		Items:             order.Items,
+		TotalAmount:       order.TotalAmount,
+		CreatedAt:         order.CreatedAt,
+		UpdatedAt:         order.UpdatedAt,
 	}
 
 	utils.RespondWithCreated(c, response, "Order created successfully")
@@ -62,27 +100,224 @@ func (h *ProducerHandlers) GetOrder(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.GetOrderByID(c.Request.Context(), id)
-	if err != nil {
-		if err.Error() == "order not found" {
-			utils.RespondWithNotFound(c, "Order")
+	fields, ok := parseOrderFields(c)
+	if !ok {
+		return
+	}
+
+	var order *models.Order
+	if asOfParam := c.Query("as_of"); asOfParam != "" {
+		asOf, parseErr := time.Parse(time.RFC3339, asOfParam)
+		if parseErr != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, parseErr, "as_of must be an RFC3339 timestamp")
 			return
 		}
+		order, err = h.historyService.GetOrderAsOf(c.Request.Context(), id, asOf)
+	} else {
+		order, err = h.orderService.GetOrderByID(c.Request.Context(), id, fields.LoadOptions()...)
+	}
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	response := &models.OrderResponse{
+		ID:                order.ID,
+		OrderNumber:       order.OrderNumber,
+		ExternalReference: order.ExternalReference,
+		Channel:           order.Channel,
+		ShippingMethod:    order.ShippingMethod,
+		EstimatedDelivery: order.EstimatedDelivery,
+		CustomerID:        order.CustomerID,
+		Status:            order.Status,
+		FailureCode:       order.FailureCode,
+		FailureDetail:     order.FailureDetail,
+		Items:             order.Items,
+		TotalAmount:       order.TotalAmount,
+		CreatedAt:         order.CreatedAt,
+		UpdatedAt:         order.UpdatedAt,
+	}
+
+	utils.RespondWithSuccess(c, fields.Select(response))
+}
+
+// GetOrderTimeline returns the recorded events and processing attempts of an
+// order.
+func (h *ProducerHandlers) GetOrderTimeline(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order ID format")
+		return
+	}
+
+	timeline, err := h.historyService.GetTimeline(c.Request.Context(), id)
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, timeline)
+}
+
+// GetOrderTransitions returns the statuses the order may currently be moved
+// to, so clients need not duplicate the state machine.
+func (h *ProducerHandlers) GetOrderTransitions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order ID format")
+		return
+	}
+
+	transitions, err := h.orderService.GetAllowedTransitions(c.Request.Context(), id)
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, transitions)
+}
+
+func (h *ProducerHandlers) BatchGetOrders(c *gin.Context) {
+	var req models.BatchGetOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+
+	if len(req.IDs) > models.MaxBatchGetOrders {
+		utils.RespondWithError(c, http.StatusBadRequest,
+			fmt.Errorf("too many order IDs"), fmt.Sprintf("At most %d order IDs can be requested at once", models.MaxBatchGetOrders))
+		return
+	}
+
+	fields, ok := parseOrderFields(c)
+	if !ok {
+		return
+	}
+
+	orders, missing, err := h.orderService.GetOrdersByIDs(c.Request.Context(), req.IDs, fields.LoadOptions()...)
+	if err != nil {
 		utils.RespondWithInternalError(c, err)
 		return
 	}
 
+	responses := make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		responses = append(responses, fields.Select(&models.OrderResponse{
+			ID:                order.ID,
+			OrderNumber:       order.OrderNumber,
+			ExternalReference: order.ExternalReference,
+			Channel:           order.Channel,
+			ShippingMethod:    order.ShippingMethod,
+			EstimatedDelivery: order.EstimatedDelivery,
+			CustomerID:        order.CustomerID,
+			Status:            order.Status,
+			FailureCode:       order.FailureCode,
+			FailureDetail:     order.FailureDetail,
+			Items:             order.Items,
+			TotalAmount:       order.TotalAmount,
+			CreatedAt:         order.CreatedAt,
+			UpdatedAt:         order.UpdatedAt,
+		}))
+	}
+
+	utils.RespondWithSuccess(c, &models.BatchGetOrdersResponse{
+		Orders:  responses,
+		Missing: missing,
+	})
+}
+
+func (h *ProducerHandlers) GetOrderByNumber(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+	if !models.IsValidOrderNumber(code) {
+		utils.RespondWithError(c, http.StatusBadRequest,
+			fmt.Errorf("invalid order number"), "Order number must look like ORD-2024-000123")
+		return
+	}
+
+	fields, ok := parseOrderFields(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orderService.GetOrderByNumber(c.Request.Context(), getTenantID(c), code, fields.LoadOptions()...)
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
 	response := &models.OrderResponse{
-		ID:          order.ID,
-		CustomerID:  order.CustomerID,
-		Status:      order.Status,
-		Items:       order.Items,
-		TotalAmount: order.TotalAmount,
-		CreatedAt:   order.CreatedAt,
-		UpdatedAt:   order.UpdatedAt,
+		ID:                order.ID,
+		OrderNumber:       order.OrderNumber,
+		ExternalReference: order.ExternalReference,
+		Channel:           order.Channel,
+		ShippingMethod:    order.ShippingMethod,
+		EstimatedDelivery: order.EstimatedDelivery,
+		CustomerID:        order.CustomerID,
+		Status:            order.Status,
+		FailureCode:       order.FailureCode,
+		FailureDetail:     order.FailureDetail,
+		Items:             order.Items,
+		TotalAmount:       order.TotalAmount,
+		CreatedAt:         order.CreatedAt,
+		UpdatedAt:         order.UpdatedAt,
 	}
 
-	utils.RespondWithSuccess(c, response)
+	utils.RespondWithSuccess(c, fields.Select(response))
+}
+
+func (h *ProducerHandlers) GetOrdersByExternalReference(c *gin.Context) {
+	reference := c.Param("ref")
+	if len(reference) > 128 {
+		utils.RespondWithError(c, http.StatusBadRequest,
+			fmt.Errorf("invalid external reference"), "External reference must be at most 128 characters")
+		return
+	}
+
+	fields, ok := parseOrderFields(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.orderService.GetOrdersByExternalReference(c.Request.Context(), getTenantID(c), reference, fields.LoadOptions()...)
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	responses := make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		responses = append(responses, fields.Select(&models.OrderResponse{
+			ID:                order.ID,
+			OrderNumber:       order.OrderNumber,
+			ExternalReference: order.ExternalReference,
+			Channel:           order.Channel,
+			ShippingMethod:    order.ShippingMethod,
+			EstimatedDelivery: order.EstimatedDelivery,
+			CustomerID:        order.CustomerID,
+			Status:            order.Status,
+			FailureCode:       order.FailureCode,
+			FailureDetail:     order.FailureDetail,
+			Items:             order.Items,
+			TotalAmount:       order.TotalAmount,
+			CreatedAt:         order.CreatedAt,
+			UpdatedAt:         order.UpdatedAt,
+		}))
+	}
+
+	meta := utils.NewOffsetMeta(models.MaxExternalReferenceMatches, 0, len(responses))
+	if len(responses) < models.MaxExternalReferenceMatches {
+		meta.WithTotal(int64(len(responses)))
+	}
+	utils.RespondWithList(c, responses, meta)
 }
 
 func (h *ProducerHandlers) GetOrdersByCustomer(c *gin.Context) {
@@ -106,27 +341,61 @@ func (h *ProducerHandlers) GetOrdersByCustomer(c *gin.Context) {
 		offset = 0
 	}
 
-	orders, err := h.orderService.GetOrdersByCustomerID(c.Request.Context(), customerID, limit, offset)
+	fields, ok := parseOrderFields(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.orderService.GetOrdersByCustomerID(c.Request.Context(), customerID, limit, offset, fields.LoadOptions()...)
 	if err != nil {
 		utils.RespondWithInternalError(c, err)
 		return
 	}
 
-	var responses []*models.OrderResponse
+	total, err := h.orderService.CountOrdersByCustomerID(c.Request.Context(), customerID)
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	responses := make([]interface{}, 0, len(orders))
 	for _, order := range orders {
 		response := &models.OrderResponse{
-			ID:          order.ID,
-			CustomerID:  order.CustomerID,
-			Status:      order.Status,
-			Items:       order.Items,
-			TotalAmount: order.TotalAmount,
-			CreatedAt:   order.CreatedAt,
-			UpdatedAt:   order.UpdatedAt,
+			ID:                order.ID,
+			OrderNumber:       order.OrderNumber,
+			ExternalReference: order.ExternalReference,
+			Channel:           order.Channel,
+			ShippingMethod:    order.ShippingMethod,
+			EstimatedDelivery: order.EstimatedDelivery,
+			CustomerID:        order.CustomerID,
+			Status:            order.Status,
+			FailureCode:       order.FailureCode,
+			FailureDetail:     order.FailureDetail,
+			Items:             order.Items,
+			TotalAmount:       order.TotalAmount,
+			CreatedAt:         order.CreatedAt,
+			UpdatedAt:         order.UpdatedAt,
 		}
-		responses = append(responses, response)
+		responses = append(responses, fields.Select(response))
 	}
 
-	utils.RespondWithSuccess(c, responses)
+	utils.RespondWithList(c, responses, utils.NewOffsetMeta(limit, offset, len(responses)).WithTotal(total))
+}
+
+func (h *ProducerHandlers) CountOrdersByCustomer(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("customerId"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid customer ID format")
+		return
+	}
+
+	count, err := h.orderService.CountOrdersByCustomerID(c.Request.Context(), customerID)
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	utils.RespondWithCount(c, count)
 }
 
 func (h *ProducerHandlers) UpdateOrderStatus(c *gin.Context) {
@@ -148,11 +417,9 @@ func (h *ProducerHandlers) UpdateOrderStatus(c *gin.Context) {
 	}
 
 	if err := h.orderService.UpdateOrderStatus(c.Request.Context(), id, req.Status, req.Reason); err != nil {
-		if err.Error() == "order not found" {
-			utils.RespondWithNotFound(c, "Order")
-			return
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithError(c, http.StatusBadRequest, err)
 		}
-		utils.RespondWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -167,40 +434,84 @@ func (h *ProducerHandlers) CancelOrder(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Reason string `json:"reason,omitempty"`
-	}
-
+	var req models.CancelOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		req.Reason = "Cancelled by user"
 	}
 
-	if err := h.orderService.CancelOrder(c.Request.Context(), id, req.Reason); err != nil {
-		if err.Error() == "order not found" {
-			utils.RespondWithNotFound(c, "Order")
-			return
+	if req.ReasonCode != "" && !req.ReasonCode.IsValid() {
+		utils.RespondWithError(c, http.StatusBadRequest,
+			fmt.Errorf("invalid reason code"), "Valid reason codes: user_request, expired, fraud, admin")
+		return
+	}
+	req.Actor = getActor(c)
+
+	if err := h.orderService.CancelOrder(c.Request.Context(), id, &req); err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithError(c, http.StatusBadRequest, err)
 		}
-		utils.RespondWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
 	utils.RespondWithSuccess(c, nil, "Order cancelled successfully")
 }
 
+// GetOrderContext returns where an order was placed from, with the IP
+// address and API key ID masked.
+func (h *ProducerHandlers) GetOrderContext(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order ID format")
+		return
+	}
+
+	orderContext, err := h.orderContexts.Get(c.Request.Context(), id)
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, maskOrderContext(orderContext))
+}
+
+func (h *ProducerHandlers) GetMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := h.distribution.WritePrometheus(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
 func (h *ProducerHandlers) RegisterRoutes(r *gin.Engine) {
+	if h.distribution != nil {
+		r.GET("/metrics", h.GetMetrics)
+	}
+
 	api := r.Group("/api/v1")
 	{
 		orders := api.Group("/orders")
 		{
 			orders.POST("", h.CreateOrder)
+			orders.POST("/batch-get", h.BatchGetOrders)
 			orders.GET("/:id", h.GetOrder)
+			orders.GET("/:id/timeline", h.GetOrderTimeline)
+			orders.GET("/:id/transitions", h.GetOrderTransitions)
+			orders.GET("/number/:code", h.GetOrderByNumber)
+			orders.GET("/by-reference/:ref", h.GetOrdersByExternalReference)
 			orders.PUT("/:id/status", h.UpdateOrderStatus)
 			orders.PUT("/:id/cancel", h.CancelOrder)
+			if h.orderContexts != nil {
+				orders.GET("/:id/context", h.GetOrderContext)
+			}
 		}
 
 		customers := api.Group("/customers")
 		{
 			customers.GET("/:customerId/orders", h.GetOrdersByCustomer)
+			customers.HEAD("/:customerId/orders", h.CountOrdersByCustomer)
+			customers.GET("/:customerId/orders/count", h.CountOrdersByCustomer)
 		}
 	}
 }
\ No newline at end of file
@@ -1,40 +1,102 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"order-processing-microservice/internal/cache"
 	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
 	"order-processing-microservice/internal/services"
 	"order-processing-microservice/pkg/utils"
 )
 
 type StatusHandlers struct {
-	orderService *services.OrderService
+	orderService       *services.OrderQueryService
+	responseCache      *cache.SWRCache
+	statusCache        *cache.OrderStatusCache
+	liveStreamInterval time.Duration
+	assignments        map[string]queue.AssignmentReporter
+	payloadStats       queue.PayloadStatsReporter
+	cluster            queue.ClusterReporter
 }
 
-func NewStatusHandlers(orderService *services.OrderService) *StatusHandlers {
+// defaultLiveStreamInterval is how often a live status stream checks for a
+// new status when no positive interval is configured.
+const defaultLiveStreamInterval = time.Second
+
+func NewStatusHandlers(orderService *services.OrderQueryService, responseCache *cache.SWRCache, statusCache *cache.OrderStatusCache, liveStreamInterval time.Duration) *StatusHandlers {
+	if liveStreamInterval <= 0 {
+		liveStreamInterval = defaultLiveStreamInterval
+	}
+
 	return &StatusHandlers{
-		orderService: orderService,
+		orderService:       orderService,
+		responseCache:      responseCache,
+		statusCache:        statusCache,
+		liveStreamInterval: liveStreamInterval,
+		assignments:        make(map[string]queue.AssignmentReporter),
+	}
+}
+
+// RegisterAssignmentReporter adds a consumer whose partition assignment is
+// reported under the given name by the health and metrics endpoints.
+func (h *StatusHandlers) RegisterAssignmentReporter(name string, reporter queue.AssignmentReporter) {
+	h.assignments[name] = reporter
+}
+
+// RegisterPayloadStatsReporter adds the producer's event size histogram to
+// the metrics endpoint.
+func (h *StatusHandlers) RegisterPayloadStatsReporter(reporter queue.PayloadStatsReporter) {
+	h.payloadStats = reporter
+}
+
+// RegisterClusterReporter adds the producer's active Kafka cluster to the
+// metrics endpoint.
+func (h *StatusHandlers) RegisterClusterReporter(reporter queue.ClusterReporter) {
+	h.cluster = reporter
+}
+
+func (h *StatusHandlers) consumerAssignments() (map[string]queue.PartitionAssignment, bool) {
+	assignments := make(map[string]queue.PartitionAssignment, len(h.assignments))
+	healthy := true
+	for name, reporter := range h.assignments {
+		assignment := reporter.Assignment()
+		assignments[name] = assignment
+		if !assignment.Healthy {
+			healthy = false
+		}
 	}
+	return assignments, healthy
 }
 
 func (h *StatusHandlers) HealthCheck(c *gin.Context) {
+	assignments, healthy := h.consumerAssignments()
+
+	status := "healthy"
+	if !healthy {
+		status = "degraded"
+	}
+
 	health := gin.H{
-		"status":    "healthy",
+		"status":    status,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 		"service":   "order-processing-microservice",
 		"version":   "1.0.0",
+		"consumers": assignments,
 	}
 
 	c.JSON(http.StatusOK, health)
 }
 
 func (h *StatusHandlers) GetOrderStats(c *gin.Context) {
-	stats, err := h.orderService.GetOrderStats(c.Request.Context())
+	stats, err := h.cachedOrderStats(c)
 	if err != nil {
 		utils.RespondWithInternalError(c, err)
 		return
@@ -43,21 +105,104 @@ func (h *StatusHandlers) GetOrderStats(c *gin.Context) {
 	utils.RespondWithSuccess(c, stats)
 }
 
-func (h *StatusHandlers) GetOrdersByStatus(c *gin.Context) {
-	statusParam := c.Param("status")
-	status := models.OrderStatus(statusParam)
+func (h *StatusHandlers) cachedOrderStats(c *gin.Context) (*models.OrderStats, error) {
+	value, state, err := h.responseCache.Get(c.Request.Context(), "stats", func(ctx context.Context) (interface{}, error) {
+		return h.orderService.GetOrderStats(ctx)
+	})
+	c.Header("X-Cache", string(state))
+	if err != nil {
+		return nil, err
+	}
 
-	validStatuses := map[models.OrderStatus]bool{
-		models.OrderStatusPending:    true,
-		models.OrderStatusProcessing: true,
-		models.OrderStatusCompleted:  true,
-		models.OrderStatusCanceled:   true,
-		models.OrderStatusFailed:     true,
+	stats := value.(*models.OrderStats)
+	// Degraded stats are served but not kept, so the next request retries.
+	if stats.Degraded {
+		h.responseCache.Invalidate("stats")
 	}
+	return stats, nil
+}
+
+// defaultPipelineWindow and maxPipelineWindow bound how far back the
+// pipeline endpoint looks for orders.
+const (
+	defaultPipelineWindow = 24 * time.Hour
+	maxPipelineWindow     = 30 * 24 * time.Hour
+)
 
+// GetOrderPipeline reports how many of the orders created within the window
+// are pending, processing or terminal, and how long they stayed in each stage.
+func (h *StatusHandlers) GetOrderPipeline(c *gin.Context) {
+	window := defaultPipelineWindow
+	if raw := c.Query("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > maxPipelineWindow {
+			utils.RespondWithError(c, http.StatusBadRequest,
+				fmt.Errorf("invalid window"), "Window must be a positive duration of at most 720h, e.g. 24h")
+			return
+		}
+		window = parsed
+	}
+
+	value, state, err := h.responseCache.Get(c.Request.Context(), "pipeline:"+window.String(), func(ctx context.Context) (interface{}, error) {
+		return h.orderService.GetOrderPipeline(ctx, time.Now().UTC().Add(-window))
+	})
+	c.Header("X-Cache", string(state))
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, value.(*models.OrderPipeline))
+}
+
+type orderPage struct {
+	orders []*models.Order
+	total  int64
+}
+
+var validStatuses = map[models.OrderStatus]bool{
+	models.OrderStatusPending:    true,
+	models.OrderStatusProcessing: true,
+	models.OrderStatusCompleted:  true,
+	models.OrderStatusCanceled:   true,
+	models.OrderStatusFailed:     true,
+	models.OrderStatusOnHold:     true,
+	models.OrderStatusScheduled:  true,
+}
+
+func parseStatusParam(c *gin.Context) (models.OrderStatus, bool) {
+	status := models.OrderStatus(c.Param("status"))
 	if !validStatuses[status] {
-		utils.RespondWithError(c, http.StatusBadRequest, 
-			fmt.Errorf("invalid status"), "Valid statuses: pending, processing, completed, canceled, failed")
+		utils.RespondWithError(c, http.StatusBadRequest,
+			fmt.Errorf("invalid status"), "Valid statuses: pending, processing, completed, canceled, failed, on_hold, scheduled")
+		return "", false
+	}
+	return status, true
+}
+
+func parseChannelQuery(c *gin.Context) (models.OrderChannel, bool) {
+	channel := models.OrderChannel(c.Query("channel"))
+	if channel != "" && !channel.IsValid() {
+		utils.RespondWithError(c, http.StatusBadRequest,
+			fmt.Errorf("invalid channel"), "Valid channels: web, mobile, api, pos")
+		return "", false
+	}
+	return channel, true
+}
+
+func (h *StatusHandlers) GetOrdersByStatus(c *gin.Context) {
+	status, ok := parseStatusParam(c)
+	if !ok {
+		return
+	}
+
+	channel, ok := parseChannelQuery(c)
+	if !ok {
+		return
+	}
+
+	fields, ok := parseOrderFields(c)
+	if !ok {
 		return
 	}
 
@@ -74,67 +219,164 @@ func (h *StatusHandlers) GetOrdersByStatus(c *gin.Context) {
 		offset = 0
 	}
 
-	orders, err := h.orderService.GetOrdersByStatus(c.Request.Context(), status, limit, offset)
+	loadOptions := fields.LoadOptions()
+	cacheKey := fmt.Sprintf("orders:%s:%s:%d:%d", status, channel, limit, offset)
+	if models.NewLoadOptions(loadOptions...).SkipItems {
+		cacheKey += ":without-items"
+	}
+	value, state, err := h.responseCache.Get(c.Request.Context(), cacheKey, func(ctx context.Context) (interface{}, error) {
+		orders, err := h.orderService.GetOrdersByStatus(ctx, status, channel, limit, offset, loadOptions...)
+		if err != nil {
+			return nil, err
+		}
+		total, err := h.orderService.CountOrdersByStatus(ctx, status, channel)
+		if err != nil {
+			return nil, err
+		}
+		return &orderPage{orders: orders, total: total}, nil
+	})
+	c.Header("X-Cache", string(state))
 	if err != nil {
 		utils.RespondWithInternalError(c, err)
 		return
 	}
+	page := value.(*orderPage)
 
-	var responses []*models.OrderResponse
-	for _, order := range orders {
+	responses := make([]interface{}, 0, len(page.orders))
+	for _, order := range page.orders {
 		response := &models.OrderResponse{
-			ID:          order.ID,
-			CustomerID:  order.CustomerID,
-			Status:      order.Status,
-			Items:       order.Items,
-			TotalAmount: order.TotalAmount,
-			CreatedAt:   order.CreatedAt,
-			UpdatedAt:   order.UpdatedAt,
+			ID:                order.ID,
+			OrderNumber:       order.OrderNumber,
+			ExternalReference: order.ExternalReference,
+			Channel:           order.Channel,
+			ShippingMethod:    order.ShippingMethod,
+			EstimatedDelivery: order.EstimatedDelivery,
+			CustomerID:        order.CustomerID,
+			Status:            order.Status,
+			FailureCode:       order.FailureCode,
+			FailureDetail:     order.FailureDetail,
+			Items:             order.Items,
+			TotalAmount:       order.TotalAmount,
+			CreatedAt:         order.CreatedAt,
+			UpdatedAt:         order.UpdatedAt,
 		}
-		responses = append(responses, response)
+		responses = append(responses, fields.Select(response))
 	}
 
-	responseData := gin.H{
-		"orders": responses,
-		"meta": gin.H{
-			"status": status,
-			"limit":  limit,
-			"offset": offset,
-			"count":  len(responses),
-		},
+	utils.RespondWithList(c, responses, utils.NewOffsetMeta(limit, offset, len(responses)).WithTotal(page.total))
+}
+
+func (h *StatusHandlers) CountOrdersByStatus(c *gin.Context) {
+	status, ok := parseStatusParam(c)
+	if !ok {
+		return
+	}
+
+	channel, ok := parseChannelQuery(c)
+	if !ok {
+		return
+	}
+
+	value, state, err := h.responseCache.Get(c.Request.Context(), fmt.Sprintf("count:%s:%s", status, channel), func(ctx context.Context) (interface{}, error) {
+		return h.orderService.CountOrdersByStatus(ctx, status, channel)
+	})
+	c.Header("X-Cache", string(state))
+	if err != nil {
+		utils.RespondWithInternalError(c, err)
+		return
 	}
 
-	utils.RespondWithSuccess(c, responseData)
+	utils.RespondWithCount(c, value.(int64))
 }
 
 func (h *StatusHandlers) GetMetrics(c *gin.Context) {
-	stats, err := h.orderService.GetOrderStats(c.Request.Context())
+	stats, err := h.cachedOrderStats(c)
 	if err != nil {
 		utils.RespondWithInternalError(c, err)
 		return
 	}
 
+	assignments, _ := h.consumerAssignments()
+
 	metrics := gin.H{
-		"orders": stats,
-		"system": gin.H{
-			"uptime":    time.Since(time.Now().Add(-time.Hour)).String(), // Placeholder
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"orders":       stats,
+		"cache":        h.responseCache.Stats(),
+		"status_cache": h.statusCache.Stats(),
+		"consumers":    assignments,
+		"system": models.SystemMetrics{
+			Uptime:    time.Since(time.Now().Add(-time.Hour)).String(), // Placeholder
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
 		},
 	}
+	if h.payloadStats != nil {
+		metrics["event_sizes"] = h.payloadStats.PayloadStats()
+	}
+	if h.cluster != nil {
+		metrics["kafka_cluster"] = h.cluster.ClusterStatus()
+	}
 
 	utils.RespondWithSuccess(c, metrics)
 }
 
+func (h *StatusHandlers) StreamOrderStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order ID format")
+		return
+	}
+
+	ctx := c.Request.Context()
+	last, err := h.statusCache.Get(ctx, id)
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.SSEvent("status", last)
+	c.Writer.Flush()
+	if last.Status.IsTerminal() {
+		return
+	}
+
+	ticker := time.NewTicker(h.liveStreamInterval)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			current, err := h.statusCache.Get(ctx, id)
+			if err != nil {
+				return true
+			}
+			if current.Status != last.Status {
+				c.SSEvent("status", current)
+				last = current
+			}
+			return !current.Status.IsTerminal()
+		}
+	})
+}
+
 func (h *StatusHandlers) RegisterRoutes(r *gin.Engine) {
 	r.GET("/health", h.HealthCheck)
-	
+
 	api := r.Group("/api/v1")
 	{
 		status := api.Group("/status")
 		{
 			status.GET("/stats", h.GetOrderStats)
+			status.GET("/pipeline", h.GetOrderPipeline)
 			status.GET("/orders/:status", h.GetOrdersByStatus)
+			status.HEAD("/orders/:status", h.CountOrdersByStatus)
+			status.GET("/orders/:status/count", h.CountOrdersByStatus)
 			status.GET("/metrics", h.GetMetrics)
+			status.GET("/live/:id", h.StreamOrderStatus)
 		}
 	}
 }
\ No newline at end of file
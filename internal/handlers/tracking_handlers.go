@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/utils"
+)
+
+type TrackingHandlers struct {
+	trackingService *services.TrackingService
+}
+
+func NewTrackingHandlers(trackingService *services.TrackingService) *TrackingHandlers {
+	return &TrackingHandlers{
+		trackingService: trackingService,
+	}
+}
+
+// CreateTrackingToken issues a tracking link for an order, e.g. for an order
+// confirmation email.
+func (h *TrackingHandlers) CreateTrackingToken(c *gin.Context) {
+	var req models.CreateTrackingTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+
+	token, err := h.trackingService.IssueToken(c.Request.Context(), req.OrderID)
+	if err != nil {
+		if !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithCreated(c, token)
+}
+
+// TrackOrder serves the public status of an order to holders of its tracking
+// token. Unknown, tampered and deleted-order tokens all answer 404.
+func (h *TrackingHandlers) TrackOrder(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+
+	view, err := h.trackingService.Track(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		if !respondWithServiceError(c, err) && !respondWithRepositoryError(c, err) {
+			utils.RespondWithInternalError(c, err)
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, view)
+}
+
+func (h *TrackingHandlers) RegisterRoutes(r *gin.Engine) {
+	r.GET("/track/:token", h.TrackOrder)
+
+	api := r.Group("/api/v1")
+	{
+		tracking := api.Group("/tracking")
+		{
+			tracking.POST("/tokens", h.CreateTrackingToken)
+		}
+	}
+}
\ No newline at end of file
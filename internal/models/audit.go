@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderAuditEvent carries an AuditRecord on the audit topic.
+const OrderAuditEvent EventType = "order.audit"
+
+type AuditAction string
+
+const (
+	AuditActionCreate       AuditAction = "create"
+	AuditActionStatusChange AuditAction = "status_change"
+	AuditActionCancel       AuditAction = "cancel"
+	AuditActionAdjust       AuditAction = "adjust"
+)
+
+// SystemActor is the actor of changes the service makes on its own, such as
+// the consumer processing an order.
+const SystemActor = "system"
+
+// AuditRecord is the compact audit trail entry of one order state change:
+// who changed which order how, and the request that caused it. Adjustments
+// record the old and new total instead of a status change.
+type AuditRecord struct {
+	ID         uuid.UUID   `json:"id"`
+	OrderID    uuid.UUID   `json:"order_id"`
+	TenantID   string      `json:"tenant_id,omitempty"`
+	Action     AuditAction `json:"action"`
+	Actor      string      `json:"actor"`
+	OldStatus  OrderStatus `json:"old_status,omitempty"`
+	NewStatus  OrderStatus `json:"new_status"`
+	OldTotal   *float64    `json:"old_total,omitempty"`
+	NewTotal   *float64    `json:"new_total,omitempty"`
+	RequestID  string      `json:"request_id,omitempty"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+// NewAuditRecord records the change of order from oldStatus to its current
+// status; an empty oldStatus records its creation.
+func NewAuditRecord(order *Order, oldStatus OrderStatus, actor, requestID string) *AuditRecord {
+	action := AuditActionStatusChange
+	switch {
+	case oldStatus == "":
+		action = AuditActionCreate
+	case order.Status == OrderStatusCanceled:
+		action = AuditActionCancel
+	}
+	if actor == "" {
+		actor = SystemActor
+	}
+
+	return &AuditRecord{
+		ID:         uuid.New(),
+		OrderID:    order.ID,
+		TenantID:   order.TenantID,
+		Action:     action,
+		Actor:      actor,
+		OldStatus:  oldStatus,
+		NewStatus:  order.Status,
+		RequestID:  requestID,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// NewAdjustmentAuditRecord records the recalculation of order's total by
+// adjustment; the status is unchanged.
+func NewAdjustmentAuditRecord(order *Order, adjustment *OrderAdjustment, requestID string) *AuditRecord {
+	actor := adjustment.Actor
+	if actor == "" {
+		actor = SystemActor
+	}
+	oldTotal, newTotal := adjustment.OldTotal, adjustment.NewTotal
+
+	return &AuditRecord{
+		ID:         uuid.New(),
+		OrderID:    order.ID,
+		TenantID:   order.TenantID,
+		Action:     AuditActionAdjust,
+		Actor:      actor,
+		OldStatus:  order.Status,
+		NewStatus:  order.Status,
+		OldTotal:   &oldTotal,
+		NewTotal:   &newTotal,
+		RequestID:  requestID,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// NewOrderAuditEvent wraps record in an event for the audit topic.
+func NewOrderAuditEvent(record *AuditRecord) *Event {
+	event := NewEvent(OrderAuditEvent, record)
+	event.ID = record.ID
+	event.Timestamp = record.OccurredAt
+	return event
+}
\ No newline at end of file
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BulkCancelJobStatus string
+
+const (
+	BulkCancelJobRunning   BulkCancelJobStatus = "running"
+	BulkCancelJobCompleted BulkCancelJobStatus = "completed"
+	BulkCancelJobFailed    BulkCancelJobStatus = "failed"
+)
+
+// BulkCancelRequest selects the orders a bulk cancel job cancels. Status is
+// pending when empty; only orders that have not started processing can be
+// canceled in bulk.
+type BulkCancelRequest struct {
+	CustomerID    *uuid.UUID       `json:"customer_id,omitempty"`
+	Status        OrderStatus      `json:"status,omitempty"`
+	CreatedAfter  *time.Time       `json:"created_after,omitempty"`
+	CreatedBefore *time.Time       `json:"created_before,omitempty"`
+	Channel       OrderChannel     `json:"channel,omitempty"`
+	All           bool             `json:"all,omitempty"`
+	ReasonCode    CancelReasonCode `json:"reason_code,omitempty"`
+	Reason        string           `json:"reason,omitempty"`
+	Actor         string           `json:"-"`
+}
+
+func (r *BulkCancelRequest) Filter() OrderFilter {
+	return OrderFilter{
+		CustomerID:    r.CustomerID,
+		CreatedAfter:  r.CreatedAfter,
+		CreatedBefore: r.CreatedBefore,
+		Channel:       r.Channel,
+	}
+}
+
+// IsBulkCancelableStatus reports whether orders in status can be canceled by
+// a bulk cancel job.
+func IsBulkCancelableStatus(status OrderStatus) bool {
+	switch status {
+	case OrderStatusPending, OrderStatusOnHold, OrderStatusScheduled:
+		return true
+	}
+	return false
+}
+
+// BulkCancelJob is the progress and summary of a bulk cancel. Canceled counts
+// the orders canceled so far; Published the canceled events published.
+type BulkCancelJob struct {
+	ID          uuid.UUID           `json:"id"`
+	Status      BulkCancelJobStatus `json:"status"`
+	OrderStatus OrderStatus         `json:"order_status"`
+	Filter      OrderFilter         `json:"filter"`
+	ReasonCode  CancelReasonCode    `json:"reason_code"`
+	Reason      string              `json:"reason,omitempty"`
+	Actor       string              `json:"actor,omitempty"`
+	Batches     int                 `json:"batches"`
+	Canceled    int                 `json:"canceled"`
+	Published   int                 `json:"published"`
+	Error       string              `json:"error,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+}
\ No newline at end of file
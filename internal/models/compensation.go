@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const OrderCompensationNeededEvent EventType = "order.compensation.needed"
+
+type CompensationStatus string
+
+const (
+	CompensationStatusPending      CompensationStatus = "pending"
+	CompensationStatusResolved     CompensationStatus = "resolved"
+	CompensationStatusManualReview CompensationStatus = "manual_review"
+)
+
+// Compensation records an order whose saga steps took effect but whose final
+// status could not be persisted.
+type Compensation struct {
+	ID             uuid.UUID          `json:"id" db:"id"`
+	OrderID        uuid.UUID          `json:"order_id" db:"order_id"`
+	TargetStatus   OrderStatus        `json:"target_status" db:"target_status"`
+	CompletedSteps []SagaStep         `json:"completed_steps" db:"completed_steps"`
+	FailureCode    FailureCode        `json:"failure_code,omitempty" db:"failure_code"`
+	FailureDetail  string             `json:"failure_detail,omitempty" db:"failure_detail"`
+	Error          string             `json:"error" db:"error"`
+	Status         CompensationStatus `json:"status" db:"status"`
+	CreatedAt      time.Time          `json:"created_at" db:"created_at"`
+	ResolvedAt     *time.Time         `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+type CompensationNeededEventData struct {
+	CompensationID uuid.UUID   `json:"compensation_id"`
+	OrderID        uuid.UUID   `json:"order_id"`
+	CustomerID     uuid.UUID   `json:"customer_id"`
+	TargetStatus   OrderStatus `json:"target_status"`
+	CompletedSteps []SagaStep  `json:"completed_steps"`
+	Error          string      `json:"error"`
+	DetectedAt     time.Time   `json:"detected_at"`
+}
+
+var sagaSteps = []SagaStep{SagaStepPaymentAuthorization, SagaStepInventoryReservation}
+
+// CompletedSagaSteps lists the steps whose side effects have taken place: all
+// of them for a completed saga, otherwise those before the current step.
+func CompletedSagaSteps(saga *Saga) []SagaStep {
+	if saga.Status == SagaStatusCompleted {
+		return sagaSteps
+	}
+
+	var completed []SagaStep
+	for _, step := range sagaSteps {
+		if step == saga.Step {
+			break
+		}
+		completed = append(completed, step)
+	}
+	return completed
+}
+
+// NewCompensation snapshots the transition that could not be persisted;
+// order.FailureCode and order.FailureDetail are kept for failed targets.
+func NewCompensation(order *Order, target OrderStatus, completedSteps []SagaStep, err error) *Compensation {
+	return &Compensation{
+		ID:             uuid.New(),
+		OrderID:        order.ID,
+		TargetStatus:   target,
+		CompletedSteps: completedSteps,
+		FailureCode:    order.FailureCode,
+		FailureDetail:  order.FailureDetail,
+		Error:          err.Error(),
+		Status:         CompensationStatusPending,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+func NewCompensationNeededEvent(order *Order, compensation *Compensation) *Event {
+	data := CompensationNeededEventData{
+		CompensationID: compensation.ID,
+		OrderID:        order.ID,
+		CustomerID:     order.CustomerID,
+		TargetStatus:   compensation.TargetStatus,
+		CompletedSteps: compensation.CompletedSteps,
+		Error:          compensation.Error,
+		DetectedAt:     compensation.CreatedAt,
+	}
+	return NewEvent(OrderCompensationNeededEvent, data)
+}
\ No newline at end of file
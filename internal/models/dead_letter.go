@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DeadLetterReason string
+
+const (
+	DeadLetterReasonDecodeFailed  DeadLetterReason = "decode_failed"
+	DeadLetterReasonHandlerFailed DeadLetterReason = "handler_failed"
+)
+
+// MaxDeadLetterSelection caps the dead letters requeued or purged at once.
+const MaxDeadLetterSelection = 100
+
+// DeadLetterEvent records a message the consumer could not process and
+// republished to the dead-letter topic. EventID and EventType are taken from
+// the message headers and are empty when the producer did not set them.
+// RequeuedAt is set once the message was published back to its topic.
+type DeadLetterEvent struct {
+	ID            uuid.UUID         `json:"id" db:"id"`
+	EventID       *uuid.UUID        `json:"event_id,omitempty" db:"event_id"`
+	EventType     string            `json:"event_type,omitempty" db:"event_type"`
+	Topic         string            `json:"topic" db:"topic"`
+	Partition     int32             `json:"partition" db:"partition"`
+	Offset        int64             `json:"offset" db:"offset"`
+	ConsumerGroup string            `json:"consumer_group" db:"consumer_group"`
+	DLQTopic      string            `json:"dlq_topic" db:"dlq_topic"`
+	Reason        DeadLetterReason  `json:"reason" db:"reason"`
+	Error         string            `json:"error" db:"error"`
+	Headers       map[string]string `json:"headers" db:"headers"`
+	Key           []byte            `json:"key,omitempty" db:"message_key"`
+	Payload       []byte            `json:"payload" db:"payload"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	RequeuedAt    *time.Time        `json:"requeued_at,omitempty" db:"requeued_at"`
+}
+
+type DeadLetterSelectionRequest struct {
+	IDs []uuid.UUID `json:"ids" binding:"required,min=1"`
+}
+
+// DeadLetterRequeueResponse lists the dead letters published back to their
+// topic and the requested IDs that were not found.
+type DeadLetterRequeueResponse struct {
+	Requeued []uuid.UUID `json:"requeued"`
+	Missing  []uuid.UUID `json:"missing"`
+}
+
+type DeadLetterPurgeResponse struct {
+	Purged int64 `json:"purged"`
+}
+
+// MessagePosition identifies a consumed message by where it was read, as seen
+// by a consumer group.
+type MessagePosition struct {
+	GroupID   string
+	Topic     string
+	Partition int32
+	Offset    int64
+}
\ No newline at end of file
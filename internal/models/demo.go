@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DemoTenantID owns the orders of the demo generator.
+const DemoTenantID = "demo"
+
+// Outcomes a demo order is scripted to have when the consumer processes it.
+const (
+	DemoOutcomeComplete = "complete"
+	DemoOutcomeFail     = "fail"
+)
+
+// DemoReference renders the external reference of a demo order, e.g.
+// demo-fail-3f2a9c1e-000042, which carries its scripted outcome.
+func DemoReference(outcome, run string, sequence int) string {
+	return fmt.Sprintf("demo-%s-%s-%06d", outcome, run, sequence)
+}
+
+// DemoOutcome returns the scripted outcome of a demo tenant order, and false
+// for any other order.
+func DemoOutcome(order *Order) (string, bool) {
+	if order.TenantID != DemoTenantID {
+		return "", false
+	}
+
+	parts := strings.SplitN(order.ExternalReference, "-", 3)
+	if len(parts) != 3 || parts[0] != "demo" {
+		return "", false
+	}
+	switch parts[1] {
+	case DemoOutcomeComplete, DemoOutcomeFail:
+		return parts[1], true
+	default:
+		return "", false
+	}
+}
\ No newline at end of file
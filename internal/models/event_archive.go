@@ -0,0 +1,64 @@
+package models
+
+import (
+	"path"
+	"time"
+)
+
+type EventArchiveStatus string
+
+const (
+	EventArchiveStatusRunning   EventArchiveStatus = "running"
+	EventArchiveStatusCompleted EventArchiveStatus = "completed"
+	EventArchiveStatusFailed    EventArchiveStatus = "failed"
+)
+
+// EventArchive is the manifest record of the archive of one hour of recorded
+// events. Hour is the start of the hour in UTC; ObjectKey is where the
+// gzipped NDJSON file, one event payload per line, is stored.
+type EventArchive struct {
+	Hour        time.Time          `json:"hour" db:"hour"`
+	ObjectKey   string             `json:"object_key" db:"object_key"`
+	Status      EventArchiveStatus `json:"status" db:"status"`
+	EventCount  int                `json:"event_count" db:"event_count"`
+	SizeBytes   int64              `json:"size_bytes" db:"size_bytes"`
+	Error       string             `json:"error,omitempty" db:"error"`
+	StartedAt   time.Time          `json:"started_at" db:"started_at"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+func NewEventArchive(prefix string, hour time.Time) *EventArchive {
+	hour = hour.UTC().Truncate(time.Hour)
+	return &EventArchive{
+		Hour:      hour,
+		ObjectKey: EventArchiveKey(prefix, hour),
+		Status:    EventArchiveStatusRunning,
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+// EventArchiveKey returns the key of the archive of hour under prefix, e.g.
+// events/2024/03/01/13.ndjson.gz.
+func EventArchiveKey(prefix string, hour time.Time) string {
+	return path.Join(prefix, hour.UTC().Format("2006/01/02/15")+".ndjson.gz")
+}
+
+// End returns the end of the archived hour, exclusive.
+func (a *EventArchive) End() time.Time {
+	return a.Hour.Add(time.Hour)
+}
+
+// Complete marks the archive as written with count events in size bytes.
+func (a *EventArchive) Complete(count int, size int64) {
+	now := time.Now().UTC()
+	a.Status = EventArchiveStatusCompleted
+	a.EventCount = count
+	a.SizeBytes = size
+	a.CompletedAt = &now
+}
+
+// Fail marks the archive as failed with err, to be retried on the next run.
+func (a *EventArchive) Fail(err error) {
+	a.Status = EventArchiveStatusFailed
+	a.Error = err.Error()
+}
\ No newline at end of file
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventDescriptor struct {
+	Type        EventType              `json:"type"`
+	Version     string                 `json:"version"`
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+type EventCatalog struct {
+	Envelope map[string]interface{} `json:"envelope"`
+	Events   []EventDescriptor      `json:"events"`
+}
+
+const eventSchemaVersion = "1.0"
+
+var catalogEntries = []struct {
+	eventType   EventType
+	description string
+	data        interface{}
+}{
+	{OrderCreatedEvent, "An order was accepted and persisted as pending.", OrderCreatedEventData{}},
+	{OrderStatusChangedEvent, "An order status was changed through the API.", OrderStatusChangedEventData{}},
+	{OrderProcessingEvent, "The consumer started processing a pending order.", OrderProcessingEventData{}},
+	{OrderCompletedEvent, "Processing finished successfully.", OrderCompletedEventData{}},
+	{OrderFailedEvent, "Processing failed; reason and error describe why.", OrderFailedEventData{}},
+	{OrderCanceledEvent, "The order was canceled; reason_code (user_request, expired, fraud, admin) and actor say why and by whom.", OrderCanceledEventData{}},
+	{PaymentAuthorizeRequestEvent, "Saga command asking the payment service to authorize the order amount.", SagaCommandData{}},
+	{PaymentAuthorizeReplyEvent, "Payment service reply to an authorization command, matched by correlation_id.", SagaReplyData{}},
+	{InventoryReserveRequestEvent, "Saga command asking the inventory service to reserve the order items.", SagaCommandData{}},
+	{InventoryReserveReplyEvent, "Inventory service reply to a reservation command, matched by correlation_id.", SagaReplyData{}},
+	{OrderCompensationNeededEvent, "Saga steps took effect but the order's final status could not be saved; completed_steps may need to be undone.", CompensationNeededEventData{}},
+	{OrderAdjustedEvent, "An order's totals were recalculated, e.g. after a pricing fix; items lists the repriced items and delta the change of the total.", OrderAdjustment{}},
+	{HeartbeatEvent, "Liveness signal published on the order topic by each producer instance every HEARTBEAT_INTERVAL seconds; not about any order.", HeartbeatEventData{}},
+	{OrderAuditEvent, "Audit trail entry of an order state change or total adjustment, published to the audit topic only: who made it, the old and new status or total and the request ID.", AuditRecord{}},
+}
+
+// BuildEventCatalog describes every emitted event with a JSON schema derived
+// from its Go data type, so the catalog cannot drift from the code.
+func BuildEventCatalog() *EventCatalog {
+	catalog := &EventCatalog{
+		Envelope: JSONSchemaFor(Event{}),
+		Events:   make([]EventDescriptor, 0, len(catalogEntries)),
+	}
+
+	for _, entry := range catalogEntries {
+		catalog.Events = append(catalog.Events, EventDescriptor{
+			Type:        entry.eventType,
+			Version:     eventSchemaVersion,
+			Description: entry.description,
+			Schema:      JSONSchemaFor(entry.data),
+		})
+	}
+
+	return catalog
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+func JSONSchemaFor(v interface{}) map[string]interface{} {
+	schema := schemaForType(reflect.TypeOf(v))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return schema
+}
+
+func schemaForType(t reflect.Type) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaForType(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		// interface{} fields (such as Event.Data) accept any JSON value.
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = schemaForType(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
\ No newline at end of file
@@ -10,12 +10,12 @@ import (
 type EventType string
 
 const (
-	OrderCreatedEvent        EventType = "order.created"
-	OrderStatusChangedEvent  EventType = "order.status.changed"
-	OrderProcessingEvent     EventType = "order.processing"
-	OrderCompletedEvent      EventType = "order.completed"
-	OrderFailedEvent         EventType = "order.failed"
-	OrderCanceledEvent       EventType = "order.canceled"
+	OrderCreatedEvent       EventType = "order.created"
+	OrderStatusChangedEvent EventType = "order.status.changed"
+	OrderProcessingEvent    EventType = "order.processing"
+	OrderCompletedEvent     EventType = "order.completed"
+	OrderFailedEvent        EventType = "order.failed"
+	OrderCanceledEvent      EventType = "order.canceled"
 )
 
 type Event struct {
@@ -24,23 +24,39 @@ type Event struct {
 	Data      interface{} `json:"data"`
 	Timestamp time.Time   `json:"timestamp"`
 	Version   string      `json:"version"`
+	Region    string      `json:"region,omitempty"`
+
+	// Sequence numbers the events of one order from 1 in the order they
+	// were published, from a counter kept in the database. Timestamp comes
+	// from the clock of the publishing instance and may be skewed, so
+	// consumers order the events of an order by Sequence. It is 0 for events
+	// that are not about an order or could not be numbered.
+	Sequence uint64 `json:"sequence,omitempty"`
+
+	// TenantID routes the event to its tenant's isolated topic, if any. It
+	// is set by the order event constructors and not encoded.
+	TenantID string `json:"-"`
 }
 
 type OrderCreatedEventData struct {
-	OrderID     uuid.UUID   `json:"order_id"`
-	CustomerID  uuid.UUID   `json:"customer_id"`
-	Items       []OrderItem `json:"items"`
-	TotalAmount float64     `json:"total_amount"`
-	CreatedAt   time.Time   `json:"created_at"`
+	OrderID           uuid.UUID    `json:"order_id"`
+	TenantID          string       `json:"tenant_id,omitempty"`
+	OrderNumber       string       `json:"order_number,omitempty"`
+	ExternalReference string       `json:"external_reference,omitempty"`
+	Channel           OrderChannel `json:"channel,omitempty"`
+	CustomerID        uuid.UUID    `json:"customer_id"`
+	Items             []OrderItem  `json:"items"`
+	TotalAmount       float64      `json:"total_amount"`
+	CreatedAt         time.Time    `json:"created_at"`
 }
 
 type OrderStatusChangedEventData struct {
-	OrderID     uuid.UUID   `json:"order_id"`
-	CustomerID  uuid.UUID   `json:"customer_id"`
-	OldStatus   OrderStatus `json:"old_status"`
-	NewStatus   OrderStatus `json:"new_status"`
-	UpdatedAt   time.Time   `json:"updated_at"`
-	Reason      string      `json:"reason,omitempty"`
+	OrderID    uuid.UUID   `json:"order_id"`
+	CustomerID uuid.UUID   `json:"customer_id"`
+	OldStatus  OrderStatus `json:"old_status"`
+	NewStatus  OrderStatus `json:"new_status"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	Reason     string      `json:"reason,omitempty"`
 }
 
 type OrderProcessingEventData struct {
@@ -50,25 +66,31 @@ type OrderProcessingEventData struct {
 }
 
 type OrderCompletedEventData struct {
-	OrderID     uuid.UUID `json:"order_id"`
-	CustomerID  uuid.UUID `json:"customer_id"`
-	CompletedAt time.Time `json:"completed_at"`
-	TotalAmount float64   `json:"total_amount"`
+	OrderID           uuid.UUID  `json:"order_id"`
+	CustomerID        uuid.UUID  `json:"customer_id"`
+	CompletedAt       time.Time  `json:"completed_at"`
+	TotalAmount       float64    `json:"total_amount"`
+	EstimatedDelivery *time.Time `json:"estimated_delivery,omitempty"`
 }
 
 type OrderFailedEventData struct {
-	OrderID    uuid.UUID `json:"order_id"`
-	CustomerID uuid.UUID `json:"customer_id"`
-	FailedAt   time.Time `json:"failed_at"`
-	Reason     string    `json:"reason"`
-	Error      string    `json:"error,omitempty"`
+	OrderID       uuid.UUID   `json:"order_id"`
+	CustomerID    uuid.UUID   `json:"customer_id"`
+	FailedAt      time.Time   `json:"failed_at"`
+	Reason        string      `json:"reason"`
+	Error         string      `json:"error,omitempty"`
+	FailureCode   FailureCode `json:"failure_code"`
+	FailureDetail string      `json:"failure_detail,omitempty"`
 }
 
 type OrderCanceledEventData struct {
-	OrderID     uuid.UUID `json:"order_id"`
-	CustomerID  uuid.UUID `json:"customer_id"`
-	CanceledAt  time.Time `json:"canceled_at"`
-	Reason      string    `json:"reason,omitempty"`
+	OrderID        uuid.UUID        `json:"order_id"`
+	CustomerID     uuid.UUID        `json:"customer_id"`
+	PreviousStatus OrderStatus      `json:"previous_status"`
+	CanceledAt     time.Time        `json:"canceled_at"`
+	ReasonCode     CancelReasonCode `json:"reason_code"`
+	Reason         string           `json:"reason,omitempty"`
+	Actor          string           `json:"actor"`
 }
 
 func NewEvent(eventType EventType, data interface{}) *Event {
@@ -81,6 +103,13 @@ func NewEvent(eventType EventType, data interface{}) *Event {
 	}
 }
 
+// newOrderEvent returns an event about order, routed by its tenant.
+func newOrderEvent(order *Order, eventType EventType, data interface{}) *Event {
+	event := NewEvent(eventType, data)
+	event.TenantID = order.TenantID
+	return event
+}
+
 func (e *Event) ToJSON() ([]byte, error) {
 	return json.Marshal(e)
 }
@@ -89,15 +118,27 @@ func (e *Event) FromJSON(data []byte) error {
 	return json.Unmarshal(data, e)
 }
 
+func (e *Event) DecodeData(v interface{}) error {
+	raw, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
 func NewOrderCreatedEvent(order *Order) *Event {
 	data := OrderCreatedEventData{
-		OrderID:     order.ID,
-		CustomerID:  order.CustomerID,
-		Items:       order.Items,
-		TotalAmount: order.TotalAmount,
-		CreatedAt:   order.CreatedAt,
+		OrderID:           order.ID,
+		TenantID:          order.TenantID,
+		OrderNumber:       order.OrderNumber,
+		ExternalReference: order.ExternalReference,
+		Channel:           order.Channel,
+		CustomerID:        order.CustomerID,
+		Items:             order.Items,
+		TotalAmount:       order.TotalAmount,
+		CreatedAt:         order.CreatedAt,
 	}
-	return NewEvent(OrderCreatedEvent, data)
+	return newOrderEvent(order, OrderCreatedEvent, data)
 }
 
 func NewOrderStatusChangedEvent(order *Order, oldStatus OrderStatus, reason string) *Event {
@@ -109,7 +150,7 @@ func NewOrderStatusChangedEvent(order *Order, oldStatus OrderStatus, reason stri
 		UpdatedAt:  order.UpdatedAt,
 		Reason:     reason,
 	}
-	return NewEvent(OrderStatusChangedEvent, data)
+	return newOrderEvent(order, OrderStatusChangedEvent, data)
 }
 
 func NewOrderProcessingEvent(order *Order) *Event {
@@ -118,36 +159,42 @@ func NewOrderProcessingEvent(order *Order) *Event {
 		CustomerID: order.CustomerID,
 		StartedAt:  time.Now().UTC(),
 	}
-	return NewEvent(OrderProcessingEvent, data)
+	return newOrderEvent(order, OrderProcessingEvent, data)
 }
 
 func NewOrderCompletedEvent(order *Order) *Event {
 	data := OrderCompletedEventData{
-		OrderID:     order.ID,
-		CustomerID:  order.CustomerID,
-		CompletedAt: time.Now().UTC(),
-		TotalAmount: order.TotalAmount,
+		OrderID:           order.ID,
+		CustomerID:        order.CustomerID,
+		CompletedAt:       time.Now().UTC(),
+		TotalAmount:       order.TotalAmount,
+		EstimatedDelivery: order.EstimatedDelivery,
 	}
-	return NewEvent(OrderCompletedEvent, data)
+	return newOrderEvent(order, OrderCompletedEvent, data)
 }
 
 func NewOrderFailedEvent(order *Order, reason, errorMsg string) *Event {
 	data := OrderFailedEventData{
-		OrderID:    order.ID,
-		CustomerID: order.CustomerID,
-		FailedAt:   time.Now().UTC(),
-		Reason:     reason,
-		Error:      errorMsg,
+		OrderID:       order.ID,
+		CustomerID:    order.CustomerID,
+		FailedAt:      time.Now().UTC(),
+		Reason:        reason,
+		Error:         errorMsg,
+		FailureCode:   order.FailureCode,
+		FailureDetail: order.FailureDetail,
 	}
-	return NewEvent(OrderFailedEvent, data)
+	return newOrderEvent(order, OrderFailedEvent, data)
 }
 
-func NewOrderCanceledEvent(order *Order, reason string) *Event {
+func NewOrderCanceledEvent(order *Order, previousStatus OrderStatus, req *CancelOrderRequest) *Event {
 	data := OrderCanceledEventData{
-		OrderID:    order.ID,
-		CustomerID: order.CustomerID,
-		CanceledAt: time.Now().UTC(),
-		Reason:     reason,
+		OrderID:        order.ID,
+		CustomerID:     order.CustomerID,
+		PreviousStatus: previousStatus,
+		CanceledAt:     order.UpdatedAt,
+		ReasonCode:     req.ReasonCode,
+		Reason:         req.Reason,
+		Actor:          req.Actor,
 	}
-	return NewEvent(OrderCanceledEvent, data)
+	return newOrderEvent(order, OrderCanceledEvent, data)
 }
\ No newline at end of file
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChangeCursor struct {
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (c ChangeCursor) Encode() string {
+	raw := c.UpdatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseChangeCursor(s string) (ChangeCursor, error) {
+	if s == "" {
+		return ChangeCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ChangeCursor{}, fmt.Errorf("invalid cursor encoding: %w", err)
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return ChangeCursor{}, fmt.Errorf("invalid cursor format")
+	}
+
+	updatedAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return ChangeCursor{}, fmt.Errorf("invalid cursor timestamp: %w", err)
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return ChangeCursor{}, fmt.Errorf("invalid cursor id: %w", err)
+	}
+
+	return ChangeCursor{UpdatedAt: updatedAt, ID: id}, nil
+}
\ No newline at end of file
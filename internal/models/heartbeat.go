@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// HeartbeatEvent is published on the order topic by every producer instance
+// at a fixed interval, so consumers can tell a quiet period from a broken
+// pipeline.
+const HeartbeatEvent EventType = "heartbeat"
+
+// HeartbeatEventData identifies the producer instance that sent a heartbeat.
+// Sequence counts its heartbeats from 1 since it started, so a gap shows
+// heartbeats that were lost.
+type HeartbeatEventData struct {
+	Source   string    `json:"source"`
+	Sequence uint64    `json:"sequence"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+func NewHeartbeatEvent(source string, sequence uint64) *Event {
+	now := time.Now().UTC()
+	event := NewEvent(HeartbeatEvent, HeartbeatEventData{
+		Source:   source,
+		Sequence: sequence,
+		SentAt:   now,
+	})
+	event.Timestamp = now
+	return event
+}
\ No newline at end of file
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ID strategies. uuidv7 and ulid start with a millisecond timestamp, so new
+// keys land at the end of the primary key index instead of at random pages.
+const (
+	IDStrategyUUIDv4 = "uuidv4"
+	IDStrategyUUIDv7 = "uuidv7"
+	IDStrategyULID   = "ulid"
+)
+
+// IDGenerator creates primary keys. Every strategy produces a 128-bit
+// uuid.UUID, so IDs of different strategies share the same UUID columns and
+// API format and a strategy can be changed without migrating existing rows.
+type IDGenerator interface {
+	NewID() uuid.UUID
+}
+
+// NewIDGenerator returns the generator of strategy; an empty strategy is
+// uuidv4.
+func NewIDGenerator(strategy string) (IDGenerator, error) {
+	switch strategy {
+	case IDStrategyUUIDv4, "":
+		return uuidV4Generator{}, nil
+	case IDStrategyUUIDv7:
+		return uuidV7Generator{}, nil
+	case IDStrategyULID:
+		return &ulidGenerator{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ID strategy %q", strategy)
+	}
+}
+
+// DefaultIDGenerator returns the random UUIDv4 generator.
+func DefaultIDGenerator() IDGenerator {
+	return uuidV4Generator{}
+}
+
+type uuidV4Generator struct{}
+
+func (uuidV4Generator) NewID() uuid.UUID {
+	return uuid.New()
+}
+
+type uuidV7Generator struct{}
+
+func (uuidV7Generator) NewID() uuid.UUID {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.New()
+	}
+	return id
+}
+
+// ulidGenerator creates ULIDs stored in their 16-byte binary form: a 48-bit
+// big-endian millisecond timestamp followed by 80 random bits. IDs created
+// in the same millisecond increment the random part, so they stay ordered.
+// The timestamp layout matches UUIDv7, so ulid and uuidv7 keys sort together
+// by creation time.
+type ulidGenerator struct {
+	mu     sync.Mutex
+	lastMS uint64
+	last   uuid.UUID
+}
+
+func (g *ulidGenerator) NewID() uuid.UUID {
+	ms := uint64(time.Now().UnixMilli())
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if ms <= g.lastMS && incrementULID(&g.last) {
+		return g.last
+	}
+
+	var id uuid.UUID
+	binary.BigEndian.PutUint16(id[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+	if _, err := rand.Read(id[6:]); err != nil {
+		return uuid.New()
+	}
+	g.lastMS, g.last = ms, id
+	return id
+}
+
+// incrementULID adds one to the random part of id and reports false when it
+// overflows.
+func incrementULID(id *uuid.UUID) bool {
+	for i := len(id) - 1; i >= 6; i-- {
+		id[i]++
+		if id[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
@@ -14,17 +14,34 @@ const (
 	OrderStatusCompleted  OrderStatus = "completed"
 	OrderStatusCanceled   OrderStatus = "canceled"
 	OrderStatusFailed     OrderStatus = "failed"
+	OrderStatusOnHold     OrderStatus = "on_hold"
+	OrderStatusScheduled  OrderStatus = "scheduled"
 )
 
+const MaxExternalReferenceMatches = 100
+
+// DispatchLease is how long whoever published a pending order's created event
+// owns its dispatch before reconciliation may publish it again.
+const DispatchLease = 2 * time.Minute
+
 type Order struct {
-	ID          uuid.UUID   `json:"id" db:"id"`
-	CustomerID  uuid.UUID   `json:"customer_id" db:"customer_id" binding:"required"`
-	Status      OrderStatus `json:"status" db:"status"`
-	Items       []OrderItem `json:"items" binding:"required,min=1"`
-	TotalAmount float64     `json:"total_amount" db:"total_amount"`
-	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
-	Version     int         `json:"version" db:"version"`
+	ID                uuid.UUID      `json:"id" db:"id"`
+	TenantID          string         `json:"tenant_id" db:"tenant_id"`
+	OrderNumber       string         `json:"order_number" db:"order_number"`
+	ExternalReference string         `json:"external_reference,omitempty" db:"external_reference"`
+	Channel           OrderChannel   `json:"channel,omitempty" db:"channel"`
+	ShippingMethod    ShippingMethod `json:"shipping_method,omitempty" db:"shipping_method"`
+	CustomerID        uuid.UUID      `json:"customer_id" db:"customer_id" binding:"required"`
+	Status            OrderStatus    `json:"status" db:"status"`
+	FailureCode       FailureCode    `json:"failure_code,omitempty" db:"failure_code"`
+	FailureDetail     string         `json:"failure_detail,omitempty" db:"failure_detail"`
+	Items             []OrderItem    `json:"items" binding:"required,min=1"`
+	TotalAmount       float64        `json:"total_amount" db:"total_amount"`
+	EstimatedDelivery *time.Time     `json:"estimated_delivery,omitempty" db:"estimated_delivery"`
+	CreatedAt         time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at" db:"updated_at"`
+	Version           int            `json:"version" db:"version"`
+	DeletedAt         *time.Time     `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 type OrderItem struct {
@@ -37,8 +54,13 @@ type OrderItem struct {
 }
 
 type CreateOrderRequest struct {
-	CustomerID uuid.UUID               `json:"customer_id" binding:"required"`
-	Items      []CreateOrderItemRequest `json:"items" binding:"required,min=1"`
+	TenantID          string                   `json:"-"`
+	ExternalReference string                   `json:"external_reference,omitempty" binding:"omitempty,max=128"`
+	Channel           OrderChannel             `json:"channel,omitempty" binding:"omitempty,oneof=web mobile api pos"`
+	ShippingMethod    ShippingMethod           `json:"shipping_method,omitempty" binding:"omitempty,oneof=standard express overnight"`
+	Context           *OrderContext            `json:"-"`
+	CustomerID        uuid.UUID                `json:"customer_id" binding:"required"`
+	Items             []CreateOrderItemRequest `json:"items" binding:"required,min=1"`
 }
 
 type CreateOrderItemRequest struct {
@@ -48,13 +70,25 @@ type CreateOrderItemRequest struct {
 }
 
 type OrderResponse struct {
-	ID          uuid.UUID   `json:"id"`
-	CustomerID  uuid.UUID   `json:"customer_id"`
-	Status      OrderStatus `json:"status"`
-	Items       []OrderItem `json:"items"`
-	TotalAmount float64     `json:"total_amount"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID                uuid.UUID      `json:"id"`
+	OrderNumber       string         `json:"order_number,omitempty"`
+	ExternalReference string         `json:"external_reference,omitempty"`
+	Channel           OrderChannel   `json:"channel,omitempty"`
+	ShippingMethod    ShippingMethod `json:"shipping_method,omitempty"`
+	CustomerID        uuid.UUID      `json:"customer_id"`
+	Status            OrderStatus    `json:"status"`
+	FailureCode       FailureCode    `json:"failure_code,omitempty"`
+	FailureDetail     string         `json:"failure_detail,omitempty"`
+	Items             []OrderItem    `json:"items"`
+	TotalAmount       float64        `json:"total_amount"`
+	EstimatedDelivery *time.Time     `json:"estimated_delivery,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         *time.Time     `json:"deleted_at,omitempty"`
+}
+
+func (s OrderStatus) IsTerminal() bool {
+	return s == OrderStatusCompleted || s == OrderStatusCanceled
 }
 
 func (o *Order) CalculateTotalAmount() {
@@ -66,21 +100,36 @@ func (o *Order) CalculateTotalAmount() {
 	o.TotalAmount = total
 }
 
-func (o *Order) IsValidStatusTransition(newStatus OrderStatus) bool {
-	validTransitions := map[OrderStatus][]OrderStatus{
-		OrderStatusPending:    {OrderStatusProcessing, OrderStatusCanceled},
-		OrderStatusProcessing: {OrderStatusCompleted, OrderStatusFailed, OrderStatusCanceled},
-		OrderStatusCompleted:  {},
-		OrderStatusCanceled:   {},
-		OrderStatusFailed:     {OrderStatusPending},
-	}
+// statusTransitions is the order state machine: the statuses each status may
+// move to.
+var statusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:    {OrderStatusProcessing, OrderStatusCanceled, OrderStatusOnHold, OrderStatusScheduled},
+	OrderStatusProcessing: {OrderStatusCompleted, OrderStatusFailed, OrderStatusCanceled},
+	OrderStatusCompleted:  {},
+	OrderStatusCanceled:   {},
+	OrderStatusFailed:     {OrderStatusPending},
+	OrderStatusOnHold:     {OrderStatusPending, OrderStatusCanceled},
+	OrderStatusScheduled:  {OrderStatusPending, OrderStatusCanceled},
+}
 
-	allowedStatuses, exists := validTransitions[o.Status]
-	if !exists {
-		return false
-	}
+// OrderTransitions lists the statuses an order may currently move to.
+type OrderTransitions struct {
+	OrderID uuid.UUID     `json:"order_id"`
+	Status  OrderStatus   `json:"status"`
+	Allowed []OrderStatus `json:"allowed"`
+	Version int           `json:"version"`
+}
 
-	for _, allowedStatus := range allowedStatuses {
+// AllowedTransitions returns the statuses the order may move to from its
+// current status, never nil.
+func (o *Order) AllowedTransitions() []OrderStatus {
+	allowed := make([]OrderStatus, len(statusTransitions[o.Status]))
+	copy(allowed, statusTransitions[o.Status])
+	return allowed
+}
+
+func (o *Order) IsValidStatusTransition(newStatus OrderStatus) bool {
+	for _, allowedStatus := range statusTransitions[o.Status] {
 		if allowedStatus == newStatus {
 			return true
 		}
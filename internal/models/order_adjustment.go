@@ -0,0 +1,138 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderAdjustedEvent is published when an order's totals were recalculated,
+// e.g. after a pricing bug was fixed.
+const OrderAdjustedEvent EventType = "order.adjusted"
+
+// MaxRecalculationOrders caps the orders recalculated by one request.
+const MaxRecalculationOrders = 500
+
+// RecalculateTotalsRequest selects the orders whose totals are recomputed.
+// Prices maps product IDs to corrected unit prices; the items of other
+// products keep their price and only have their totals recomputed. With
+// DryRun set the adjustments are returned without being saved.
+type RecalculateTotalsRequest struct {
+	OrderIDs []uuid.UUID           `json:"order_ids" binding:"required,min=1"`
+	Prices   map[uuid.UUID]float64 `json:"prices,omitempty"`
+	Reason   string                `json:"reason" binding:"required"`
+	DryRun   bool                  `json:"dry_run,omitempty"`
+	Actor    string                `json:"-"`
+}
+
+// ItemAdjustment is how a recalculation changed one item's price and total.
+type ItemAdjustment struct {
+	ItemID    uuid.UUID `json:"item_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	OldPrice  float64   `json:"old_price"`
+	NewPrice  float64   `json:"new_price"`
+	OldTotal  float64   `json:"old_total"`
+	NewTotal  float64   `json:"new_total"`
+}
+
+// OrderAdjustment is how a recalculation changed an order's total. Version is
+// the order version the adjustment produced.
+type OrderAdjustment struct {
+	OrderID    uuid.UUID        `json:"order_id"`
+	CustomerID uuid.UUID        `json:"customer_id"`
+	TenantID   string           `json:"tenant_id,omitempty"`
+	OldTotal   float64          `json:"old_total"`
+	NewTotal   float64          `json:"new_total"`
+	Delta      float64          `json:"delta"`
+	Items      []ItemAdjustment `json:"items,omitempty"`
+	Reason     string           `json:"reason"`
+	Actor      string           `json:"actor,omitempty"`
+	Version    int              `json:"version"`
+	AdjustedAt time.Time        `json:"adjusted_at"`
+}
+
+// RecalculationResult lists the orders a recalculation adjusted, those whose
+// totals were already correct and those it failed to adjust, with why.
+type RecalculationResult struct {
+	DryRun      bool                 `json:"dry_run"`
+	Adjustments []*OrderAdjustment   `json:"adjustments"`
+	Unchanged   []uuid.UUID          `json:"unchanged"`
+	Failed      map[uuid.UUID]string `json:"failed,omitempty"`
+}
+
+// AdjustOrder recomputes the item and order totals of order, repricing the
+// items of the products in prices, and returns the resulting adjustment, or
+// nil if every amount is already correct to the cent. The order itself is
+// not changed; see OrderAdjustment.Apply.
+func AdjustOrder(order *Order, prices map[uuid.UUID]float64) *OrderAdjustment {
+	adjustment := &OrderAdjustment{
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		TenantID:   order.TenantID,
+		OldTotal:   order.TotalAmount,
+		Version:    order.Version + 1,
+	}
+
+	var total float64
+	for _, item := range order.Items {
+		price := item.Price
+		if corrected, ok := prices[item.ProductID]; ok {
+			price = roundCents(corrected)
+		}
+		itemTotal := roundCents(price * float64(item.Quantity))
+		total += itemTotal
+
+		if cents(price) == cents(item.Price) && cents(itemTotal) == cents(item.Total) {
+			continue
+		}
+		adjustment.Items = append(adjustment.Items, ItemAdjustment{
+			ItemID:    item.ID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			OldPrice:  item.Price,
+			NewPrice:  price,
+			OldTotal:  item.Total,
+			NewTotal:  itemTotal,
+		})
+	}
+
+	adjustment.NewTotal = roundCents(total)
+	adjustment.Delta = roundCents(adjustment.NewTotal - adjustment.OldTotal)
+	if len(adjustment.Items) == 0 && cents(adjustment.NewTotal) == cents(adjustment.OldTotal) {
+		return nil
+	}
+	return adjustment
+}
+
+// Apply updates the items, total and version of order to those of the
+// adjustment once it was saved.
+func (a *OrderAdjustment) Apply(order *Order) {
+	byID := make(map[uuid.UUID]ItemAdjustment, len(a.Items))
+	for _, item := range a.Items {
+		byID[item.ItemID] = item
+	}
+	for i := range order.Items {
+		if item, ok := byID[order.Items[i].ID]; ok {
+			order.Items[i].Price = item.NewPrice
+			order.Items[i].Total = item.NewTotal
+		}
+	}
+
+	order.TotalAmount = a.NewTotal
+	order.Version = a.Version
+	order.UpdatedAt = a.AdjustedAt
+}
+
+func NewOrderAdjustedEvent(order *Order, adjustment *OrderAdjustment) *Event {
+	return newOrderEvent(order, OrderAdjustedEvent, adjustment)
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func roundCents(amount float64) float64 {
+	return float64(cents(amount)) / 100
+}
\ No newline at end of file
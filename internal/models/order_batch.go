@@ -0,0 +1,14 @@
+package models
+
+import "github.com/google/uuid"
+
+const MaxBatchGetOrders = 100
+
+type BatchGetOrdersRequest struct {
+	IDs []uuid.UUID `json:"ids" binding:"required,min=1"`
+}
+
+type BatchGetOrdersResponse struct {
+	Orders  []interface{} `json:"orders"`
+	Missing []uuid.UUID   `json:"missing"`
+}
\ No newline at end of file
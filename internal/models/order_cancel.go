@@ -0,0 +1,24 @@
+package models
+
+type CancelReasonCode string
+
+const (
+	CancelReasonUserRequest CancelReasonCode = "user_request"
+	CancelReasonExpired     CancelReasonCode = "expired"
+	CancelReasonFraud       CancelReasonCode = "fraud"
+	CancelReasonAdmin       CancelReasonCode = "admin"
+)
+
+func (c CancelReasonCode) IsValid() bool {
+	switch c {
+	case CancelReasonUserRequest, CancelReasonExpired, CancelReasonFraud, CancelReasonAdmin:
+		return true
+	}
+	return false
+}
+
+type CancelOrderRequest struct {
+	ReasonCode CancelReasonCode `json:"reason_code,omitempty"`
+	Reason     string           `json:"reason,omitempty"`
+	Actor      string           `json:"-"`
+}
\ No newline at end of file
@@ -0,0 +1,20 @@
+package models
+
+// OrderChannel is the sales channel an order was placed through.
+type OrderChannel string
+
+const (
+	OrderChannelWeb    OrderChannel = "web"
+	OrderChannelMobile OrderChannel = "mobile"
+	OrderChannelAPI    OrderChannel = "api"
+	OrderChannelPOS    OrderChannel = "pos"
+)
+
+func (c OrderChannel) IsValid() bool {
+	switch c {
+	case OrderChannelWeb, OrderChannelMobile, OrderChannelAPI, OrderChannelPOS:
+		return true
+	default:
+		return false
+	}
+}
\ No newline at end of file
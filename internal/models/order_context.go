@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderContext records where an order was placed from, for fraud and support
+// investigations. It is stored as captured and masked when returned.
+type OrderContext struct {
+	OrderID   uuid.UUID `json:"order_id"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	APIKeyID  string    `json:"api_key_id,omitempty"`
+	Origin    string    `json:"origin,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MaxUserAgentLength bounds the stored user agent; longer values are cut.
+const MaxUserAgentLength = 512
\ No newline at end of file
@@ -0,0 +1,57 @@
+package models
+
+import "strconv"
+
+// OrderValueBounds and OrderItemBounds are the upper bounds of the order value
+// and items-per-order histograms; larger values fall into the "+Inf" bucket.
+var (
+	OrderValueBounds = []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+	OrderItemBounds  = []float64{1, 2, 3, 5, 10, 20}
+)
+
+// Distribution is a histogram of order values or sizes. Buckets are keyed by
+// their upper bound, as in the bounds above, and are not cumulative.
+type Distribution struct {
+	Count   int64            `json:"count"`
+	Sum     float64          `json:"sum"`
+	Buckets map[string]int64 `json:"buckets"`
+}
+
+// BucketLabel returns the label of the bucket of bounds that value falls into.
+func BucketLabel(bounds []float64, value float64) string {
+	for _, bound := range bounds {
+		if value <= bound {
+			return FormatBound(bound)
+		}
+	}
+	return "+Inf"
+}
+
+// FormatBound returns the bucket label of bound.
+func FormatBound(bound float64) string {
+	return strconv.FormatFloat(bound, 'f', -1, 64)
+}
+
+// Add records count values summing to sum in the bucket labelled label.
+func (d *Distribution) Add(label string, count int64, sum float64) {
+	if d.Buckets == nil {
+		d.Buckets = make(map[string]int64)
+	}
+	d.Buckets[label] += count
+	d.Count += count
+	d.Sum += sum
+}
+
+// OrderDistributions are the value and item count distributions of orders.
+// Items are counted as line items, whatever their quantity.
+type OrderDistributions struct {
+	TotalAmount   Distribution `json:"total_amount"`
+	ItemsPerOrder Distribution `json:"items_per_order"`
+}
+
+// Observe records order in both distributions.
+func (d *OrderDistributions) Observe(order *Order) {
+	d.TotalAmount.Add(BucketLabel(OrderValueBounds, order.TotalAmount), 1, order.TotalAmount)
+	items := float64(len(order.Items))
+	d.ItemsPerOrder.Add(BucketLabel(OrderItemBounds, items), 1, items)
+}
\ No newline at end of file
@@ -0,0 +1,23 @@
+package models
+
+type FailureCode string
+
+const (
+	FailureCodePaymentDeclined      FailureCode = "payment_declined"
+	FailureCodeInventoryUnavailable FailureCode = "inventory_unavailable"
+	FailureCodeTimeout              FailureCode = "timeout"
+	FailureCodeInternal             FailureCode = "internal"
+)
+
+// FailureCodeForSagaStep classifies a rejected saga command by the step that
+// rejected it.
+func FailureCodeForSagaStep(step SagaStep) FailureCode {
+	switch step {
+	case SagaStepPaymentAuthorization:
+		return FailureCodePaymentDeclined
+	case SagaStepInventoryReservation:
+		return FailureCodeInventoryUnavailable
+	default:
+		return FailureCodeInternal
+	}
+}
\ No newline at end of file
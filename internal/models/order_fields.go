@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var orderResponseFields = map[string]bool{
+	"id":                 true,
+	"order_number":       true,
+	"external_reference": true,
+	"channel":            true,
+	"shipping_method":    true,
+	"customer_id":        true,
+	"status":             true,
+	"failure_code":       true,
+	"failure_detail":     true,
+	"items":              true,
+	"total_amount":       true,
+	"estimated_delivery": true,
+	"created_at":         true,
+	"updated_at":         true,
+}
+
+// OrderFields is a sparse fieldset requested with ?fields=id,status. A nil
+// OrderFields selects every field.
+type OrderFields map[string]bool
+
+func ParseOrderFields(raw string) (OrderFields, error) {
+	fields := OrderFields{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !orderResponseFields[name] {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		fields[name] = true
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+func (f OrderFields) Has(name string) bool {
+	return f == nil || f[name]
+}
+
+// LoadOptions skips loading items when they were not selected.
+func (f OrderFields) LoadOptions() []LoadOption {
+	if f.Has("items") {
+		return nil
+	}
+	return []LoadOption{WithoutItems()}
+}
+
+// Select returns the response reduced to the selected fields, or the response
+// itself when every field is selected.
+func (f OrderFields) Select(response *OrderResponse) interface{} {
+	if f == nil {
+		return response
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return response
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return response
+	}
+
+	selected := make(map[string]json.RawMessage, len(f))
+	for name, value := range all {
+		if f[name] {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+
+type LoadOptions struct {
+	SkipItems bool
+}
+
+type LoadOption func(*LoadOptions)
+
+func WithoutItems() LoadOption {
+	return func(o *LoadOptions) {
+		o.SkipItems = true
+	}
+}
+
+func NewLoadOptions(opts ...LoadOption) LoadOptions {
+	var options LoadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOrderNotCreated means the replayed events hold no order.created event,
+// as for orders created before the event store recorded their events.
+var ErrOrderNotCreated = errors.New("order history has no order.created event")
+
+func ReplayOrder(events []*Event) (*Order, error) {
+	var order *Order
+
+	for _, event := range events {
+		if event.Type == OrderCreatedEvent {
+			var data OrderCreatedEventData
+			if err := event.DecodeData(&data); err != nil {
+				return nil, fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+			}
+			order = &Order{
+				ID:                data.OrderID,
+				TenantID:          data.TenantID,
+				OrderNumber:       data.OrderNumber,
+				ExternalReference: data.ExternalReference,
+				Channel:           data.Channel,
+				CustomerID:        data.CustomerID,
+				Status:            OrderStatusPending,
+				Items:             data.Items,
+				TotalAmount:       data.TotalAmount,
+				CreatedAt:         data.CreatedAt,
+				UpdatedAt:         data.CreatedAt,
+				Version:           1,
+			}
+			continue
+		}
+
+		if order == nil {
+			continue
+		}
+
+		status, at, ok, err := statusFromEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		order.Status = status
+		order.UpdatedAt = at
+		order.Version++
+	}
+
+	if order == nil {
+		return nil, ErrOrderNotCreated
+	}
+	return order, nil
+}
+
+func statusFromEvent(event *Event) (OrderStatus, time.Time, bool, error) {
+	switch event.Type {
+	case OrderStatusChangedEvent:
+		var data OrderStatusChangedEventData
+		if err := event.DecodeData(&data); err != nil {
+			return "", time.Time{}, false, fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		return data.NewStatus, data.UpdatedAt, true, nil
+	case OrderProcessingEvent:
+		return OrderStatusProcessing, event.Timestamp, true, nil
+	case OrderCompletedEvent:
+		return OrderStatusCompleted, event.Timestamp, true, nil
+	case OrderFailedEvent:
+		return OrderStatusFailed, event.Timestamp, true, nil
+	case OrderCanceledEvent:
+		return OrderStatusCanceled, event.Timestamp, true, nil
+	default:
+		return "", time.Time{}, false, nil
+	}
+}
\ No newline at end of file
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrderFilter struct {
+	CustomerID    *uuid.UUID   `json:"customer_id,omitempty"`
+	CreatedAfter  *time.Time   `json:"created_after,omitempty"`
+	CreatedBefore *time.Time   `json:"created_before,omitempty"`
+	Channel       OrderChannel `json:"channel,omitempty"`
+}
+
+func (f OrderFilter) IsEmpty() bool {
+	return f.CustomerID == nil && f.CreatedAfter == nil && f.CreatedBefore == nil && f.Channel == ""
+}
+
+type BulkStatusChangeResponse struct {
+	Status   OrderStatus `json:"status"`
+	Filter   OrderFilter `json:"filter"`
+	Count    int         `json:"count"`
+	OrderIDs []uuid.UUID `json:"order_ids"`
+}
\ No newline at end of file
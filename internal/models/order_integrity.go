@@ -0,0 +1,115 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InconsistencyKind names a check of the order data integrity scan.
+type InconsistencyKind string
+
+const (
+	// InconsistencyOrphanItem is an item whose order does not exist.
+	InconsistencyOrphanItem InconsistencyKind = "orphan_item"
+	// InconsistencyItemTotal is an item whose total is not price times
+	// quantity.
+	InconsistencyItemTotal InconsistencyKind = "item_total_mismatch"
+	// InconsistencyOrderTotal is an order whose total is not the sum of its
+	// item totals.
+	InconsistencyOrderTotal InconsistencyKind = "order_total_mismatch"
+	// InconsistencyUnknownStatus is an order in a status the service does
+	// not know. It cannot be repaired automatically.
+	InconsistencyUnknownStatus InconsistencyKind = "unknown_status"
+	// InconsistencyStatusVersion is an order whose version is lower than the
+	// number of changes needed to reach its status.
+	InconsistencyStatusVersion InconsistencyKind = "status_version_mismatch"
+)
+
+// InconsistencyKinds lists the checks in the order they run. Items come
+// first so that order totals are checked against repaired item totals.
+var InconsistencyKinds = []InconsistencyKind{
+	InconsistencyOrphanItem,
+	InconsistencyItemTotal,
+	InconsistencyOrderTotal,
+	InconsistencyUnknownStatus,
+	InconsistencyStatusVersion,
+}
+
+// OrderInconsistency is one finding of the integrity scan. Actual and
+// Expected hold the offending and the correct value as text; Expected is
+// empty when the finding cannot be repaired. Version is the order version
+// seen by the scan, so a repair skips orders changed since.
+type OrderInconsistency struct {
+	Kind     InconsistencyKind `json:"kind"`
+	OrderID  uuid.UUID         `json:"order_id"`
+	ItemID   *uuid.UUID        `json:"item_id,omitempty"`
+	Status   OrderStatus       `json:"status,omitempty"`
+	Version  int               `json:"version,omitempty"`
+	Actual   string            `json:"actual"`
+	Expected string            `json:"expected,omitempty"`
+	Repaired bool              `json:"repaired"`
+}
+
+// Repairable reports whether the inconsistency can be repaired
+// automatically.
+func (i *OrderInconsistency) Repairable() bool {
+	return i.Kind != InconsistencyUnknownStatus
+}
+
+// OrderRepair is the audit entry of a repaired inconsistency, recorded in
+// the same transaction as the repair. NewValue is empty for deleted rows.
+type OrderRepair struct {
+	ID         uuid.UUID         `json:"id"`
+	Kind       InconsistencyKind `json:"kind"`
+	OrderID    uuid.UUID         `json:"order_id"`
+	ItemID     *uuid.UUID        `json:"item_id,omitempty"`
+	OldValue   string            `json:"old_value"`
+	NewValue   string            `json:"new_value,omitempty"`
+	Actor      string            `json:"actor"`
+	RepairedAt time.Time         `json:"repaired_at"`
+}
+
+// IntegrityReport is the result of an integrity scan.
+type IntegrityReport struct {
+	Inconsistencies []*OrderInconsistency     `json:"inconsistencies"`
+	Found           map[InconsistencyKind]int `json:"found"`
+	Repaired        int                       `json:"repaired"`
+}
+
+func NewIntegrityReport() *IntegrityReport {
+	return &IntegrityReport{
+		Inconsistencies: []*OrderInconsistency{},
+		Found:           make(map[InconsistencyKind]int),
+	}
+}
+
+func (r *IntegrityReport) Add(inconsistency *OrderInconsistency) {
+	r.Inconsistencies = append(r.Inconsistencies, inconsistency)
+	r.Found[inconsistency.Kind]++
+}
+
+// Unresolved returns the number of inconsistencies left unrepaired.
+func (r *IntegrityReport) Unresolved() int {
+	return len(r.Inconsistencies) - r.Repaired
+}
+
+// MinimumVersions returns the lowest version an order can have in each
+// status. Orders are created pending at version 1 and every status change
+// increments the version, so it is one more than the fewest transitions of
+// the state machine leading from pending to the status.
+func MinimumVersions() map[OrderStatus]int {
+	versions := map[OrderStatus]int{OrderStatusPending: 1}
+	queue := []OrderStatus{OrderStatusPending}
+	for len(queue) > 0 {
+		status := queue[0]
+		queue = queue[1:]
+		for _, next := range statusTransitions[status] {
+			if _, seen := versions[next]; !seen {
+				versions[next] = versions[status] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return versions
+}
\ No newline at end of file
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// OrderItemChange records how an amendment changed one item's quantity. An
+// added item has a PreviousQuantity of 0 and a removed item a Quantity of 0.
+type OrderItemChange struct {
+	ItemID           uuid.UUID `json:"item_id"`
+	ProductID        uuid.UUID `json:"product_id"`
+	PreviousQuantity int       `json:"previous_quantity"`
+	Quantity         int       `json:"quantity"`
+	QuantityDelta    int       `json:"quantity_delta"`
+}
+
+// MergeOrderItems matches the amended items to the order's existing items, by
+// ID or else by product, so that amended items keep their IDs. Unmatched
+// amended items get a new ID and existing items missing from the amendment are
+// removed. It returns the merged items and the change of every item whose
+// quantity or price changed.
+func MergeOrderItems(orderID uuid.UUID, existing, amended []OrderItem) ([]OrderItem, []OrderItemChange, error) {
+	byID := make(map[uuid.UUID]int, len(existing))
+	byProduct := make(map[uuid.UUID]int, len(existing))
+	for i, item := range existing {
+		byID[item.ID] = i
+		if _, ok := byProduct[item.ProductID]; !ok {
+			byProduct[item.ProductID] = i
+		}
+	}
+
+	matched := make(map[int]bool, len(existing))
+	merged := make([]OrderItem, 0, len(amended))
+	var changes []OrderItemChange
+
+	for _, item := range amended {
+		index := -1
+		if item.ID != uuid.Nil {
+			i, ok := byID[item.ID]
+			if !ok {
+				return nil, nil, fmt.Errorf("order item %s not found", item.ID)
+			}
+			index = i
+		} else if i, ok := byProduct[item.ProductID]; ok && !matched[i] {
+			index = i
+		}
+
+		if index >= 0 && matched[index] {
+			return nil, nil, fmt.Errorf("order item %s amended more than once", existing[index].ID)
+		}
+
+		previousQuantity := 0
+		if index >= 0 {
+			matched[index] = true
+			previous := existing[index]
+			item.ID = previous.ID
+			item.ProductID = previous.ProductID
+			previousQuantity = previous.Quantity
+			if previous.Quantity == item.Quantity && previous.Price == item.Price {
+				merged = append(merged, previous)
+				continue
+			}
+		} else {
+			item.ID = uuid.New()
+		}
+
+		item.OrderID = orderID
+		item.Total = item.Price * float64(item.Quantity)
+		merged = append(merged, item)
+		changes = append(changes, OrderItemChange{
+			ItemID:           item.ID,
+			ProductID:        item.ProductID,
+			PreviousQuantity: previousQuantity,
+			Quantity:         item.Quantity,
+			QuantityDelta:    item.Quantity - previousQuantity,
+		})
+	}
+
+	for i, item := range existing {
+		if matched[i] {
+			continue
+		}
+		changes = append(changes, OrderItemChange{
+			ItemID:           item.ID,
+			ProductID:        item.ProductID,
+			PreviousQuantity: item.Quantity,
+			QuantityDelta:    -item.Quantity,
+		})
+	}
+
+	return merged, changes, nil
+}
\ No newline at end of file
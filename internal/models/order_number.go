@@ -0,0 +1,22 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	DefaultTenantID   = "default"
+	OrderNumberPrefix = "ORD"
+)
+
+var orderNumberPattern = regexp.MustCompile(`^ORD-\d{4}-\d{6,}$`)
+
+// FormatOrderNumber renders the customer-facing order number, e.g. ORD-2024-000123.
+func FormatOrderNumber(year int, sequence int64) string {
+	return fmt.Sprintf("%s-%04d-%06d", OrderNumberPrefix, year, sequence)
+}
+
+func IsValidOrderNumber(code string) bool {
+	return orderNumberPattern.MatchString(code)
+}
\ No newline at end of file
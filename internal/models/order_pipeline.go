@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// PipelineStageName names a stage orders move through, from pending to
+// processing to one of the terminal statuses.
+type PipelineStageName string
+
+const (
+	PipelineStagePending    PipelineStageName = "pending"
+	PipelineStageProcessing PipelineStageName = "processing"
+	PipelineStageTerminal   PipelineStageName = "terminal"
+)
+
+// PipelineStages lists the stages in the order orders move through them.
+var PipelineStages = []PipelineStageName{PipelineStagePending, PipelineStageProcessing, PipelineStageTerminal}
+
+// PipelineStageOf returns the stage of an order in status. Orders on hold or
+// scheduled have not been picked up yet and count as pending; failed orders
+// count as terminal although they may still be retried.
+func PipelineStageOf(status OrderStatus) PipelineStageName {
+	switch status {
+	case OrderStatusProcessing:
+		return PipelineStageProcessing
+	case OrderStatusCompleted, OrderStatusFailed, OrderStatusCanceled:
+		return PipelineStageTerminal
+	default:
+		return PipelineStagePending
+	}
+}
+
+// PipelineStage counts the orders currently in a stage, by status, and how
+// long orders stayed in it before moving on. Exited counts the orders that
+// left the stage; MedianDwellSeconds is nil if none did. Orders never leave
+// the terminal stage.
+type PipelineStage struct {
+	Stage              PipelineStageName   `json:"stage"`
+	Count              int                 `json:"count"`
+	Statuses           map[OrderStatus]int `json:"statuses"`
+	Exited             int                 `json:"exited"`
+	MedianDwellSeconds *float64            `json:"median_dwell_seconds,omitempty"`
+}
+
+// OrderPipeline is the funnel of the orders created since Since: how many
+// are in each stage and the median time they took to move through it.
+// MedianLeadTimeSeconds is the median time from creation to a terminal
+// status of the orders that reached one.
+type OrderPipeline struct {
+	Since                 time.Time        `json:"since"`
+	Total                 int              `json:"total"`
+	Stages                []*PipelineStage `json:"stages"`
+	MedianLeadTimeSeconds *float64         `json:"median_lead_time_seconds,omitempty"`
+}
+
+// NewOrderPipeline returns an empty pipeline of the orders created since
+// since.
+func NewOrderPipeline(since time.Time) *OrderPipeline {
+	pipeline := &OrderPipeline{Since: since}
+	for _, name := range PipelineStages {
+		pipeline.Stages = append(pipeline.Stages, &PipelineStage{Stage: name, Statuses: make(map[OrderStatus]int)})
+	}
+	return pipeline
+}
+
+// Stage returns the stage called name.
+func (p *OrderPipeline) Stage(name PipelineStageName) *PipelineStage {
+	for _, stage := range p.Stages {
+		if stage.Stage == name {
+			return stage
+		}
+	}
+	return nil
+}
+
+// Add records count orders in status.
+func (p *OrderPipeline) Add(status OrderStatus, count int) {
+	stage := p.Stage(PipelineStageOf(status))
+	stage.Count += count
+	stage.Statuses[status] += count
+	p.Total += count
+}
+
+// SetDwell records that exited orders left the stage called name after a
+// median of medianSeconds. A nil median leaves the dwell time unset.
+func (p *OrderPipeline) SetDwell(name PipelineStageName, exited int, medianSeconds *float64) {
+	stage := p.Stage(name)
+	stage.Exited = exited
+	stage.MedianDwellSeconds = medianSeconds
+}
\ No newline at end of file
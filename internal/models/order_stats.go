@@ -0,0 +1,104 @@
+package models
+
+// Order stats metrics, as reported in OrderStats.Errors.
+const (
+	StatsMetricStatuses      = "statuses"
+	StatsMetricFailureCodes  = "failure_codes"
+	StatsMetricChannels      = "channels"
+	StatsMetricDistributions = "distributions"
+)
+
+// OrderStats counts orders by status, failure code and channel, and holds the
+// distributions of their values and sizes. Degraded
+// stats are missing the metrics listed in Errors, which could not be
+// collected; their counts are zero.
+type OrderStats struct {
+	Pending    int `json:"pending"`
+	Processing int `json:"processing"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Canceled   int `json:"canceled"`
+	OnHold     int `json:"on_hold"`
+	Scheduled  int `json:"scheduled"`
+	Total      int `json:"total"`
+
+	FailureCodes map[FailureCode]int  `json:"failure_codes"`
+	Channels     map[OrderChannel]int `json:"channels"`
+
+	Distributions *OrderDistributions `json:"distributions,omitempty"`
+
+	Degraded bool              `json:"degraded,omitempty"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+// Add records count orders in status. Unknown statuses only count towards Total.
+func (s *OrderStats) Add(status OrderStatus, count int) {
+	switch status {
+	case OrderStatusPending:
+		s.Pending += count
+	case OrderStatusProcessing:
+		s.Processing += count
+	case OrderStatusCompleted:
+		s.Completed += count
+	case OrderStatusFailed:
+		s.Failed += count
+	case OrderStatusCanceled:
+		s.Canceled += count
+	case OrderStatusOnHold:
+		s.OnHold += count
+	case OrderStatusScheduled:
+		s.Scheduled += count
+	}
+	s.Total += count
+}
+
+// AddFailure records count failed orders with code. Orders that failed before
+// failures were classified have no code and are left out of the breakdown.
+func (s *OrderStats) AddFailure(code FailureCode, count int) {
+	if code == "" {
+		return
+	}
+	if s.FailureCodes == nil {
+		s.FailureCodes = make(map[FailureCode]int)
+	}
+	s.FailureCodes[code] += count
+}
+
+// AddChannel records count orders placed through channel. Orders created
+// without a channel are left out of the breakdown.
+func (s *OrderStats) AddChannel(channel OrderChannel, count int) {
+	if channel == "" {
+		return
+	}
+	if s.Channels == nil {
+		s.Channels = make(map[OrderChannel]int)
+	}
+	s.Channels[channel] += count
+}
+
+// AddError records that metric could not be collected.
+func (s *OrderStats) AddError(metric string, err error) {
+	if s.Errors == nil {
+		s.Errors = make(map[string]string)
+	}
+	s.Errors[metric] = err.Error()
+	s.Degraded = true
+}
+
+type SystemMetrics struct {
+	Uptime    string `json:"uptime"`
+	Timestamp string `json:"timestamp"`
+}
+
+type GetOrderStatsResponse struct {
+	Data OrderStats `json:"data"`
+}
+
+type MetricsData struct {
+	Orders *OrderStats   `json:"orders"`
+	System SystemMetrics `json:"system"`
+}
+
+type GetMetricsResponse struct {
+	Data MetricsData `json:"data"`
+}
\ No newline at end of file
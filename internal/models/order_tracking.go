@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderTrackingView is the public status of an order served to holders of a
+// tracking token. It never includes customer details; items and the total
+// are only filled when TrackingConfig allows them.
+type OrderTrackingView struct {
+	OrderNumber string         `json:"order_number,omitempty"`
+	Status      OrderStatus    `json:"status"`
+	Items       []TrackingItem `json:"items,omitempty"`
+	TotalAmount *float64       `json:"total_amount,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type TrackingItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+// TrackingToken is a signed link to the public status of an order.
+type TrackingToken struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type CreateTrackingTokenRequest struct {
+	OrderID uuid.UUID `json:"order_id" binding:"required"`
+}
\ No newline at end of file
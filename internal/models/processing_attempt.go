@@ -0,0 +1,93 @@
+package models
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AttemptOutcome string
+
+const (
+	AttemptOutcomeRunning   AttemptOutcome = "running"
+	AttemptOutcomeSucceeded AttemptOutcome = "succeeded"
+	AttemptOutcomeFailed    AttemptOutcome = "failed"
+)
+
+// ProcessingAttempt records one attempt at handling an event for an order.
+// Attempts are numbered per order from 1; NextAttemptAt is set on failed
+// attempts to when the retry is due.
+type ProcessingAttempt struct {
+	ID            uuid.UUID      `json:"id" db:"id"`
+	OrderID       uuid.UUID      `json:"order_id" db:"order_id"`
+	EventID       uuid.UUID      `json:"event_id" db:"event_id"`
+	EventType     EventType      `json:"event_type" db:"event_type"`
+	Attempt       int            `json:"attempt" db:"attempt"`
+	Outcome       AttemptOutcome `json:"outcome" db:"outcome"`
+	Error         string         `json:"error,omitempty" db:"error"`
+	StartedAt     time.Time      `json:"started_at" db:"started_at"`
+	EndedAt       *time.Time     `json:"ended_at,omitempty" db:"ended_at"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+}
+
+func NewProcessingAttempt(orderID uuid.UUID, event *Event) *ProcessingAttempt {
+	return &ProcessingAttempt{
+		ID:        uuid.New(),
+		OrderID:   orderID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Outcome:   AttemptOutcomeRunning,
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+// Succeed ends the attempt successfully.
+func (a *ProcessingAttempt) Succeed() {
+	now := time.Now().UTC()
+	a.Outcome = AttemptOutcomeSucceeded
+	a.EndedAt = &now
+}
+
+// Fail ends the attempt with err, to be retried at retryAt.
+func (a *ProcessingAttempt) Fail(err error, retryAt time.Time) {
+	now := time.Now().UTC()
+	a.Outcome = AttemptOutcomeFailed
+	a.Error = err.Error()
+	a.EndedAt = &now
+	a.NextAttemptAt = &retryAt
+}
+
+// BackoffPolicy spaces out retries exponentially: attempt n waits
+// Base * 2^(n-1), capped at Max, less a random share of up to Jitter of that
+// delay so that orders failing together do not retry together.
+type BackoffPolicy struct {
+	Base   time.Duration
+	Max    time.Duration
+	Jitter float64
+}
+
+// Delay returns the wait after the given failed attempt, numbered from 1.
+func (p BackoffPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := float64(p.Base) * math.Pow(2, float64(attempt-1))
+	if p.Max > 0 && delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+
+	jitter := math.Min(math.Max(p.Jitter, 0), 1)
+	delay -= delay * jitter * rand.Float64()
+	return time.Duration(delay)
+}
+
+// OrderTimeline lists the recorded events and processing attempts of an order,
+// each oldest first.
+type OrderTimeline struct {
+	OrderID  uuid.UUID            `json:"order_id"`
+	Events   []*Event             `json:"events"`
+	Attempts []*ProcessingAttempt `json:"attempts"`
+}
\ No newline at end of file
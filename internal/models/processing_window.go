@@ -0,0 +1,181 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TenantProcessingWindows restricts when the consumer processes a tenant's
+// orders. Each rule is "<days> <start>-<end>" in TimeZone, e.g.
+// "mon-fri 09:00-17:00". Days are "*", a day, a range or a comma separated
+// list of those; an end at or before the start runs into the next day.
+// Orders created outside every window are scheduled until the next one opens.
+type TenantProcessingWindows struct {
+	TenantID  string     `json:"tenant_id" db:"tenant_id"`
+	TimeZone  string     `json:"time_zone" db:"time_zone"`
+	Rules     []string   `json:"rules" db:"rules"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+type UpdateProcessingWindowsRequest struct {
+	TimeZone string   `json:"time_zone"`
+	Rules    []string `json:"rules" binding:"required,min=1"`
+}
+
+// ProcessingSchedule is the parsed form of TenantProcessingWindows.
+type ProcessingSchedule struct {
+	location *time.Location
+	rules    []windowRule
+}
+
+type windowRule struct {
+	days       [7]bool
+	start, end int // minutes since midnight
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseProcessingSchedule parses the rules of TenantProcessingWindows. An
+// empty timeZone is UTC.
+func ParseProcessingSchedule(timeZone string, rules []string) (*ProcessingSchedule, error) {
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q", timeZone)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("at least one processing window is required")
+	}
+
+	schedule := &ProcessingSchedule{location: location}
+	for _, raw := range rules {
+		rule, err := parseWindowRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid processing window %q: %w", raw, err)
+		}
+		schedule.rules = append(schedule.rules, rule)
+	}
+	return schedule, nil
+}
+
+func parseWindowRule(raw string) (windowRule, error) {
+	var rule windowRule
+
+	fields := strings.Fields(strings.ToLower(raw))
+	if len(fields) != 2 {
+		return rule, fmt.Errorf("expected \"<days> <start>-<end>\"")
+	}
+
+	for _, part := range strings.Split(fields[0], ",") {
+		if part == "*" {
+			rule.days = [7]bool{true, true, true, true, true, true, true}
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		from, ok := weekdays[first]
+		if !ok {
+			return rule, fmt.Errorf("unknown day %q", first)
+		}
+		to := from
+		if isRange {
+			if to, ok = weekdays[last]; !ok {
+				return rule, fmt.Errorf("unknown day %q", last)
+			}
+		}
+		for day := from; ; day = (day + 1) % 7 {
+			rule.days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+
+	start, end, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return rule, fmt.Errorf("expected a time range such as 09:00-17:00")
+	}
+	var err error
+	if rule.start, err = parseClock(start); err != nil {
+		return rule, err
+	}
+	if rule.end, err = parseClock(end); err != nil {
+		return rule, err
+	}
+	if rule.start == rule.end || rule.start == 24*60 {
+		return rule, fmt.Errorf("empty time range")
+	}
+	return rule, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight; "24:00" is the end
+// of the day.
+func parseClock(raw string) (int, error) {
+	hours, minutes, ok := strings.Cut(raw, ":")
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if !ok || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid time %q", raw)
+	}
+	return h*60 + m, nil
+}
+
+// IsOpen reports whether t falls inside a window.
+func (s *ProcessingSchedule) IsOpen(t time.Time) bool {
+	local := t.In(s.location)
+	minute := local.Hour()*60 + local.Minute()
+	today := local.Weekday()
+	yesterday := (today + 6) % 7
+
+	for _, rule := range s.rules {
+		if rule.end > rule.start {
+			if rule.days[today] && minute >= rule.start && minute < rule.end {
+				return true
+			}
+			continue
+		}
+		// Overnight: the window belongs to the day it starts on.
+		if (rule.days[today] && minute >= rule.start) || (rule.days[yesterday] && minute < rule.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns t when it falls inside a window and otherwise the time the
+// next window opens, in UTC.
+func (s *ProcessingSchedule) NextOpen(t time.Time) time.Time {
+	if s.IsOpen(t) {
+		return t.UTC()
+	}
+
+	local := t.In(s.location)
+	var next time.Time
+	for offset := 0; offset <= 7; offset++ {
+		day := local.AddDate(0, 0, offset)
+		for _, rule := range s.rules {
+			if !rule.days[day.Weekday()] {
+				continue
+			}
+			opens := time.Date(day.Year(), day.Month(), day.Day(), rule.start/60, rule.start%60, 0, 0, s.location)
+			if opens.After(t) && (next.IsZero() || opens.Before(next)) {
+				next = opens
+			}
+		}
+		if !next.IsZero() {
+			break
+		}
+	}
+	return next.UTC()
+}
\ No newline at end of file
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SagaStep string
+
+const (
+	SagaStepPaymentAuthorization SagaStep = "payment_authorization"
+	SagaStepInventoryReservation SagaStep = "inventory_reservation"
+)
+
+type SagaStatus string
+
+const (
+	SagaStatusAwaitingReply SagaStatus = "awaiting_reply"
+	SagaStatusCompleted     SagaStatus = "completed"
+	SagaStatusFailed        SagaStatus = "failed"
+	SagaStatusTimedOut      SagaStatus = "timed_out"
+)
+
+const (
+	PaymentAuthorizeRequestEvent EventType = "payment.authorize.request"
+	PaymentAuthorizeReplyEvent   EventType = "payment.authorize.reply"
+	InventoryReserveRequestEvent EventType = "inventory.reserve.request"
+	InventoryReserveReplyEvent   EventType = "inventory.reserve.reply"
+)
+
+type Saga struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	OrderID       uuid.UUID  `json:"order_id" db:"order_id"`
+	Step          SagaStep   `json:"step" db:"step"`
+	Status        SagaStatus `json:"status" db:"status"`
+	CorrelationID uuid.UUID  `json:"correlation_id" db:"correlation_id"`
+	Deadline      time.Time  `json:"deadline" db:"deadline"`
+	LastError     string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type SagaCommandData struct {
+	CorrelationID uuid.UUID   `json:"correlation_id"`
+	OrderID       uuid.UUID   `json:"order_id"`
+	CustomerID    uuid.UUID   `json:"customer_id"`
+	Amount        float64     `json:"amount"`
+	Items         []OrderItem `json:"items,omitempty"`
+	ReplyTopic    string      `json:"reply_topic"`
+}
+
+type SagaReplyData struct {
+	CorrelationID uuid.UUID `json:"correlation_id"`
+	OrderID       uuid.UUID `json:"order_id"`
+	Success       bool      `json:"success"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+func NewSaga(orderID uuid.UUID, step SagaStep, timeout time.Duration) *Saga {
+	now := time.Now().UTC()
+	return &Saga{
+		ID:            uuid.New(),
+		OrderID:       orderID,
+		Step:          step,
+		Status:        SagaStatusAwaitingReply,
+		CorrelationID: uuid.New(),
+		Deadline:      now.Add(timeout),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+func (s *Saga) AdvanceTo(step SagaStep, timeout time.Duration) {
+	now := time.Now().UTC()
+	s.Step = step
+	s.Status = SagaStatusAwaitingReply
+	s.CorrelationID = uuid.New()
+	s.Deadline = now.Add(timeout)
+	s.UpdatedAt = now
+}
+
+func (s *Saga) Finish(status SagaStatus, lastError string) {
+	s.Status = status
+	s.LastError = lastError
+	s.UpdatedAt = time.Now().UTC()
+}
+
+func NewSagaCommandEvent(eventType EventType, saga *Saga, order *Order, replyTopic string) *Event {
+	data := SagaCommandData{
+		CorrelationID: saga.CorrelationID,
+		OrderID:       order.ID,
+		CustomerID:    order.CustomerID,
+		Amount:        order.TotalAmount,
+		Items:         order.Items,
+		ReplyTopic:    replyTopic,
+	}
+	return NewEvent(eventType, data)
+}
\ No newline at end of file
@@ -0,0 +1,20 @@
+package models
+
+// ShippingMethod is how an order is shipped once processed; it sets the
+// transit time of the delivery estimate.
+type ShippingMethod string
+
+const (
+	ShippingMethodStandard  ShippingMethod = "standard"
+	ShippingMethodExpress   ShippingMethod = "express"
+	ShippingMethodOvernight ShippingMethod = "overnight"
+)
+
+func (m ShippingMethod) IsValid() bool {
+	switch m {
+	case ShippingMethodStandard, ShippingMethodExpress, ShippingMethodOvernight:
+		return true
+	default:
+		return false
+	}
+}
\ No newline at end of file
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ActiveOrderStatuses are the statuses counted against a tenant's active
+// order quota.
+var ActiveOrderStatuses = []OrderStatus{OrderStatusPending, OrderStatusProcessing, OrderStatusOnHold, OrderStatusScheduled}
+
+// TenantQuota limits the orders a tenant may create. A limit of 0 is
+// unlimited. Default is set when the tenant has no quota of its own and the
+// configured defaults apply.
+type TenantQuota struct {
+	TenantID        string     `json:"tenant_id" db:"tenant_id"`
+	MaxActiveOrders int        `json:"max_active_orders" db:"max_active_orders"`
+	MaxOrdersPerDay int        `json:"max_orders_per_day" db:"max_orders_per_day"`
+	Default         bool       `json:"default"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+type TenantQuotaUsage struct {
+	ActiveOrders int64 `json:"active_orders"`
+	OrdersToday  int64 `json:"orders_today"`
+}
+
+type TenantQuotaResponse struct {
+	Quota *TenantQuota      `json:"quota"`
+	Usage *TenantQuotaUsage `json:"usage"`
+}
+
+type UpdateTenantQuotaRequest struct {
+	MaxActiveOrders *int `json:"max_active_orders" binding:"omitempty,min=0"`
+	MaxOrdersPerDay *int `json:"max_orders_per_day" binding:"omitempty,min=0"`
+}
+
+func (q *TenantQuota) Unlimited() bool {
+	return q.MaxActiveOrders <= 0 && q.MaxOrdersPerDay <= 0
+}
+
+// The errors Check returns, matched with errors.Is.
+var (
+	ErrActiveOrderQuotaExceeded = errors.New("active order quota exceeded")
+	ErrDailyOrderQuotaExceeded  = errors.New("daily order quota exceeded")
+)
+
+// Check returns an error when creating one more order would exceed the quota.
+func (q *TenantQuota) Check(usage *TenantQuotaUsage) error {
+	if q.MaxActiveOrders > 0 && usage.ActiveOrders >= int64(q.MaxActiveOrders) {
+		return fmt.Errorf("%w: %d of %d active orders", ErrActiveOrderQuotaExceeded, usage.ActiveOrders, q.MaxActiveOrders)
+	}
+	if q.MaxOrdersPerDay > 0 && usage.OrdersToday >= int64(q.MaxOrdersPerDay) {
+		return fmt.Errorf("%w: %d of %d orders today", ErrDailyOrderQuotaExceeded, usage.OrdersToday, q.MaxOrdersPerDay)
+	}
+	return nil
+}
+
+// QuotaDayStart returns the start of the UTC day the daily quota of t counts
+// orders from.
+func QuotaDayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
\ No newline at end of file
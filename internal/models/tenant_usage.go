@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+type UsageMetric string
+
+const (
+	UsageMetricOrdersCreated   UsageMetric = "orders_created"
+	UsageMetricAPICalls        UsageMetric = "api_calls"
+	UsageMetricEventsPublished UsageMetric = "events_published"
+)
+
+// UsageRecord is a tenant's count of one metric within an hour.
+type UsageRecord struct {
+	TenantID string      `json:"tenant_id" db:"tenant_id"`
+	Hour     time.Time   `json:"hour" db:"hour"`
+	Metric   UsageMetric `json:"metric" db:"metric"`
+	Count    int64       `json:"count" db:"count"`
+}
+
+type UsageFilter struct {
+	TenantID string
+	From     time.Time
+	To       time.Time
+}
+
+type UsageReport struct {
+	TenantID string                `json:"tenant_id,omitempty"`
+	From     time.Time             `json:"from"`
+	To       time.Time             `json:"to"`
+	Totals   map[UsageMetric]int64 `json:"totals"`
+	Records  []UsageRecord         `json:"records"`
+}
+
+// UsageHour returns the hour t is aggregated into.
+func UsageHour(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
+
+func NewUsageReport(filter UsageFilter, records []UsageRecord) *UsageReport {
+	report := &UsageReport{
+		TenantID: filter.TenantID,
+		From:     filter.From,
+		To:       filter.To,
+		Totals:   make(map[UsageMetric]int64),
+		Records:  records,
+	}
+	if report.Records == nil {
+		report.Records = []UsageRecord{}
+	}
+	for _, record := range records {
+		report.Totals[record.Metric] += record.Count
+	}
+	return report
+}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package queue
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const assignmentCheckInterval = 5 * time.Second
+
+// PartitionAssignment is a consumer's current share of its group's
+// partitions. A consumer is unhealthy once it has held no partitions for
+// longer than the configured threshold.
+type PartitionAssignment struct {
+	GroupID    string             `json:"group_id"`
+	Partitions map[string][]int32 `json:"partitions"`
+	Count      int                `json:"count"`
+	EmptySince *time.Time         `json:"empty_since,omitempty"`
+	Healthy    bool               `json:"healthy"`
+}
+
+type assignmentTracker struct {
+	mu         sync.Mutex
+	groupID    string
+	partitions map[string][]int32
+	emptySince time.Time
+	threshold  time.Duration
+	warned     bool
+	logger     *logrus.Entry
+}
+
+func newAssignmentTracker(groupID string, threshold time.Duration, logger *logrus.Entry) *assignmentTracker {
+	return &assignmentTracker{
+		groupID:    groupID,
+		emptySince: time.Now(),
+		threshold:  threshold,
+		logger:     logger,
+	}
+}
+
+func (t *assignmentTracker) assign(claims map[string][]int32) {
+	partitions := make(map[string][]int32, len(claims))
+	count := 0
+	for topic, claimed := range claims {
+		sorted := append([]int32(nil), claimed...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		partitions[topic] = sorted
+		count += len(sorted)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partitions = partitions
+	if count == 0 {
+		if t.emptySince.IsZero() {
+			t.emptySince = time.Now()
+		}
+		t.logger.Warn("Consumer group session started without any assigned partitions")
+		return
+	}
+
+	if t.warned {
+		t.logger.WithField("partitions", count).Info("Consumer has partitions assigned again")
+	}
+	t.emptySince = time.Time{}
+	t.warned = false
+	t.logger.WithField("partitions", partitions).Info("Partitions assigned")
+}
+
+func (t *assignmentTracker) revoke() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partitions = nil
+	if t.emptySince.IsZero() {
+		t.emptySince = time.Now()
+	}
+}
+
+// check warns once per empty period when the consumer has held no
+// partitions for longer than the threshold.
+func (t *assignmentTracker) check() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.warned || t.healthy() {
+		return
+	}
+	t.warned = true
+	t.logger.WithFields(logrus.Fields{
+		"empty_since": t.emptySince,
+		"threshold":   t.threshold,
+	}).Warn("Consumer has had no assigned partitions for longer than the threshold; check partition count and group membership")
+}
+
+func (t *assignmentTracker) healthy() bool {
+	if t.threshold <= 0 || t.emptySince.IsZero() {
+		return true
+	}
+	return time.Since(t.emptySince) < t.threshold
+}
+
+func (t *assignmentTracker) snapshot() PartitionAssignment {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	assignment := PartitionAssignment{
+		GroupID:    t.groupID,
+		Partitions: make(map[string][]int32, len(t.partitions)),
+		Healthy:    t.healthy(),
+	}
+	for topic, partitions := range t.partitions {
+		assignment.Partitions[topic] = append([]int32(nil), partitions...)
+		assignment.Count += len(partitions)
+	}
+	if !t.emptySince.IsZero() {
+		emptySince := t.emptySince
+		assignment.EmptySince = &emptySince
+	}
+	return assignment
+}
\ No newline at end of file
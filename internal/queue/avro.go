@@ -0,0 +1,355 @@
+package queue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// avroSchema is the subset of Avro the event schemas use: null, boolean, int,
+// long, double, string, records, arrays and unions, plus the uuid and
+// timestamp-micros logical types.
+type avroSchema struct {
+	Type        string
+	LogicalType string
+	Name        string
+	Fields      []avroField
+	Items       *avroSchema
+	Branches    []*avroSchema
+}
+
+type avroField struct {
+	Name       string
+	Type       *avroSchema
+	Default    interface{}
+	HasDefault bool
+}
+
+func parseAvroSchema(raw string) (*avroSchema, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %w", err)
+	}
+	return parseAvroType(v, "", make(map[string]*avroSchema))
+}
+
+func parseAvroType(v interface{}, namespace string, named map[string]*avroSchema) (*avroSchema, error) {
+	switch t := v.(type) {
+	case string:
+		switch t {
+		case "null", "boolean", "int", "long", "double", "string":
+			return &avroSchema{Type: t}, nil
+		}
+		if s, ok := named[t]; ok {
+			return s, nil
+		}
+		if s, ok := named[namespace+"."+t]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unknown avro type %q", t)
+	case []interface{}:
+		union := &avroSchema{Type: "union"}
+		for _, branch := range t {
+			s, err := parseAvroType(branch, namespace, named)
+			if err != nil {
+				return nil, err
+			}
+			union.Branches = append(union.Branches, s)
+		}
+		return union, nil
+	case map[string]interface{}:
+		typeName, _ := t["type"].(string)
+		switch typeName {
+		case "record":
+			return parseAvroRecord(t, namespace, named)
+		case "array":
+			items, err := parseAvroType(t["items"], namespace, named)
+			if err != nil {
+				return nil, err
+			}
+			return &avroSchema{Type: "array", Items: items}, nil
+		default:
+			s, err := parseAvroType(t["type"], namespace, named)
+			if err != nil {
+				return nil, err
+			}
+			logical, _ := t["logicalType"].(string)
+			return &avroSchema{Type: s.Type, LogicalType: logical}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid avro type %v", v)
+}
+
+func parseAvroRecord(t map[string]interface{}, namespace string, named map[string]*avroSchema) (*avroSchema, error) {
+	name, _ := t["name"].(string)
+	if ns, ok := t["namespace"].(string); ok {
+		namespace = ns
+	}
+	if name == "" {
+		return nil, fmt.Errorf("avro record without a name")
+	}
+	if !strings.Contains(name, ".") && namespace != "" {
+		name = namespace + "." + name
+	}
+
+	record := &avroSchema{Type: "record", Name: name}
+	named[name] = record
+
+	fields, _ := t["fields"].([]interface{})
+	for _, raw := range fields {
+		f, _ := raw.(map[string]interface{})
+		fieldName, _ := f["name"].(string)
+		fieldType, err := parseAvroType(f["type"], namespace, named)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", name, fieldName, err)
+		}
+		def, hasDefault := f["default"]
+		record.Fields = append(record.Fields, avroField{Name: fieldName, Type: fieldType, Default: def, HasDefault: hasDefault})
+	}
+	return record, nil
+}
+
+// appendAvro encodes v, a value shaped like decoded JSON (json.Number for
+// numbers, RFC 3339 strings for timestamps), in the Avro binary encoding.
+func appendAvro(b []byte, s *avroSchema, v interface{}) ([]byte, error) {
+	switch s.Type {
+	case "null":
+		if v != nil {
+			return nil, fmt.Errorf("expected null, got %T", v)
+		}
+		return b, nil
+	case "boolean":
+		x, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected boolean, got %T", v)
+		}
+		if x {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case "int", "long":
+		n, err := avroLong(s, v)
+		if err != nil {
+			return nil, err
+		}
+		return binary.AppendVarint(b, n), nil
+	case "double":
+		x, err := avroDouble(v)
+		if err != nil {
+			return nil, err
+		}
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(x)), nil
+	case "string":
+		x, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T", v)
+		}
+		b = binary.AppendVarint(b, int64(len(x)))
+		return append(b, x...), nil
+	case "array":
+		if v == nil {
+			return append(b, 0), nil
+		}
+		items, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array, got %T", v)
+		}
+		if len(items) > 0 {
+			b = binary.AppendVarint(b, int64(len(items)))
+			for _, item := range items {
+				var err error
+				if b, err = appendAvro(b, s.Items, item); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return append(b, 0), nil
+	case "union":
+		for i, branch := range s.Branches {
+			if !avroMatches(branch, v) {
+				continue
+			}
+			return appendAvro(binary.AppendVarint(b, int64(i)), branch, v)
+		}
+		return nil, fmt.Errorf("no union branch for %T", v)
+	case "record":
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected record %s, got %T", s.Name, v)
+		}
+		for _, f := range s.Fields {
+			value, present := fields[f.Name]
+			if !present {
+				if !f.HasDefault {
+					return nil, fmt.Errorf("missing field %s.%s", s.Name, f.Name)
+				}
+				value = f.Default
+			}
+			var err error
+			if b, err = appendAvro(b, f.Type, value); err != nil {
+				return nil, fmt.Errorf("field %s.%s: %w", s.Name, f.Name, err)
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported avro type %s", s.Type)
+}
+
+func avroLong(s *avroSchema, v interface{}) (int64, error) {
+	if s.LogicalType == "timestamp-micros" {
+		x, ok := v.(string)
+		if !ok {
+			return 0, fmt.Errorf("expected timestamp, got %T", v)
+		}
+		t, err := time.Parse(time.RFC3339Nano, x)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		return t.UnixMicro(), nil
+	}
+	switch x := v.(type) {
+	case json.Number:
+		return x.Int64()
+	case float64:
+		return int64(x), nil
+	}
+	return 0, fmt.Errorf("expected %s, got %T", s.Type, v)
+}
+
+func avroDouble(v interface{}) (float64, error) {
+	switch x := v.(type) {
+	case json.Number:
+		return x.Float64()
+	case float64:
+		return x, nil
+	}
+	return 0, fmt.Errorf("expected double, got %T", v)
+}
+
+func avroMatches(s *avroSchema, v interface{}) bool {
+	switch v.(type) {
+	case nil:
+		return s.Type == "null"
+	case bool:
+		return s.Type == "boolean"
+	case string:
+		return s.Type == "string" || s.LogicalType == "timestamp-micros"
+	case json.Number, float64:
+		return s.Type == "int" || s.Type == "long" || s.Type == "double"
+	case []interface{}:
+		return s.Type == "array"
+	case map[string]interface{}:
+		return s.Type == "record"
+	}
+	return false
+}
+
+// avroReader decodes the Avro binary encoding into values that marshal to the
+// same JSON as the encoded event. Null fields are left out of records, as
+// omitempty does.
+type avroReader struct {
+	buf []byte
+}
+
+func (r *avroReader) read(s *avroSchema) (interface{}, error) {
+	switch s.Type {
+	case "null":
+		return nil, nil
+	case "boolean":
+		if len(r.buf) < 1 {
+			return nil, errAvroShort
+		}
+		v := r.buf[0] != 0
+		r.buf = r.buf[1:]
+		return v, nil
+	case "int", "long":
+		n, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if s.LogicalType == "timestamp-micros" {
+			return time.UnixMicro(n).UTC(), nil
+		}
+		return n, nil
+	case "double":
+		if len(r.buf) < 8 {
+			return nil, errAvroShort
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.buf))
+		r.buf = r.buf[8:]
+		return v, nil
+	case "string":
+		n, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || int64(len(r.buf)) < n {
+			return nil, errAvroShort
+		}
+		v := string(r.buf[:n])
+		r.buf = r.buf[n:]
+		return v, nil
+	case "array":
+		items := []interface{}{}
+		for {
+			n, err := r.long()
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				return items, nil
+			}
+			if n < 0 {
+				// A negative count is followed by the block size in bytes.
+				n = -n
+				if _, err := r.long(); err != nil {
+					return nil, err
+				}
+			}
+			for ; n > 0; n-- {
+				item, err := r.read(s.Items)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+		}
+	case "union":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.Branches)) {
+			return nil, fmt.Errorf("invalid union branch %d", i)
+		}
+		return r.read(s.Branches[i])
+	case "record":
+		fields := make(map[string]interface{}, len(s.Fields))
+		for _, f := range s.Fields {
+			v, err := r.read(f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %w", s.Name, f.Name, err)
+			}
+			if v != nil {
+				fields[f.Name] = v
+			}
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("unsupported avro type %s", s.Type)
+}
+
+var errAvroShort = errors.New("avro data truncated")
+
+func (r *avroReader) long() (int64, error) {
+	n, size := binary.Varint(r.buf)
+	if size <= 0 {
+		return 0, errAvroShort
+	}
+	r.buf = r.buf[size:]
+	return n, nil
+}
\ No newline at end of file
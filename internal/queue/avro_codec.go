@@ -0,0 +1,263 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+const avroContentType = "application/vnd.kafka.avro.v2"
+
+// avroMagicByte starts every message in the Confluent wire format, followed
+// by the 4-byte big-endian schema ID and the Avro binary body.
+const avroMagicByte = 0
+
+// avroSchemaTimeout bounds the registry calls of Unmarshal and, per schema,
+// of newKafkaCodec.
+const avroSchemaTimeout = 10 * time.Second
+
+// AvroCodec encodes events as Avro with schemas held in a schema registry.
+// Each event type has its own record and subject (see avro_schemas.go);
+// RegisterSchemas must succeed before Marshal is used. Unmarshal decodes with
+// the writer schema named in the message, fetched from the registry once.
+type AvroCodec struct {
+	registry     *SchemaRegistryClient
+	autoRegister bool
+	writers      map[string]*avroWriter
+	logger       *logrus.Entry
+
+	mu      sync.RWMutex
+	readers map[uint32]*avroSchema
+}
+
+type avroWriter struct {
+	subject string
+	raw     string
+	schema  *avroSchema
+	id      uint32
+}
+
+// NewAvroCodec parses the event schemas. With autoRegister, RegisterSchemas
+// registers them after a compatibility check; otherwise they must already be
+// registered.
+func NewAvroCodec(registry *SchemaRegistryClient, autoRegister bool) (*AvroCodec, error) {
+	codec := &AvroCodec{
+		registry:     registry,
+		autoRegister: autoRegister,
+		writers:      make(map[string]*avroWriter),
+		readers:      make(map[uint32]*avroSchema),
+		logger:       logrus.WithField("component", "avro_codec"),
+	}
+
+	for eventType, name := range avroRecordNames {
+		if err := codec.addWriter(name, avroDataFields[eventType]); err != nil {
+			return nil, err
+		}
+	}
+	if err := codec.addWriter(avroGenericEvent, ""); err != nil {
+		return nil, err
+	}
+	return codec, nil
+}
+
+func (c *AvroCodec) addWriter(name, dataFields string) error {
+	raw := avroEventSchema(name, dataFields)
+	schema, err := parseAvroSchema(raw)
+	if err != nil {
+		return fmt.Errorf("invalid schema %s: %w", name, err)
+	}
+	c.writers[name] = &avroWriter{subject: schema.Name, raw: raw, schema: schema}
+	return nil
+}
+
+// Subjects returns the registry subjects of the event schemas.
+func (c *AvroCodec) Subjects() []string {
+	subjects := make([]string, 0, len(c.writers))
+	for _, writer := range c.writers {
+		subjects = append(subjects, writer.subject)
+	}
+	sort.Strings(subjects)
+	return subjects
+}
+
+// RegisterSchemas resolves the registry ID of every event schema. A schema
+// that is incompatible with the latest registered version of its subject
+// fails the call, so a breaking change stops the producer at startup instead
+// of reaching consumers.
+func (c *AvroCodec) RegisterSchemas(ctx context.Context) error {
+	for _, writer := range c.writers {
+		var id int
+		var err error
+		if c.autoRegister {
+			compatible, checkErr := c.registry.CheckCompatibility(ctx, writer.subject, writer.raw)
+			if checkErr != nil {
+				return checkErr
+			}
+			if !compatible {
+				return fmt.Errorf("schema for subject %s is incompatible with the latest registered version", writer.subject)
+			}
+			id, err = c.registry.Register(ctx, writer.subject, writer.raw)
+		} else {
+			id, err = c.registry.Lookup(ctx, writer.subject, writer.raw)
+			if isRegistryError(err, registrySubjectNotFound, registrySchemaNotFound) {
+				return fmt.Errorf("schema for subject %s is not registered and auto-registration is disabled", writer.subject)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		writer.id = uint32(id)
+		c.mu.Lock()
+		c.readers[writer.id] = writer.schema
+		c.mu.Unlock()
+
+		c.logger.WithFields(logrus.Fields{
+			"subject":   writer.subject,
+			"schema_id": id,
+		}).Debug("Event schema registered")
+	}
+	return nil
+}
+
+func (c *AvroCodec) ContentType() string {
+	return avroContentType
+}
+
+func (c *AvroCodec) Marshal(event *models.Event) ([]byte, error) {
+	name, ok := avroRecordNames[event.Type]
+	if !ok {
+		name = avroGenericEvent
+	}
+	writer := c.writers[name]
+	if writer.id == 0 {
+		return nil, fmt.Errorf("schema for subject %s is not registered", writer.subject)
+	}
+
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value map[string]interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if name == avroGenericEvent {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal event data: %w", err)
+		}
+		value["data"] = string(data)
+	}
+
+	b := make([]byte, 5, 5+len(raw)/2)
+	b[0] = avroMagicByte
+	binary.BigEndian.PutUint32(b[1:], writer.id)
+	if b, err = appendAvro(b, writer.schema, value); err != nil {
+		return nil, fmt.Errorf("failed to encode %s as avro: %w", event.Type, err)
+	}
+	return b, nil
+}
+
+func (c *AvroCodec) Unmarshal(data []byte) (*models.Event, error) {
+	if len(data) < 5 || data[0] != avroMagicByte {
+		return nil, fmt.Errorf("not an avro message")
+	}
+
+	schema, err := c.readerSchema(binary.BigEndian.Uint32(data[1:5]))
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &avroReader{buf: data[5:]}
+	value, err := reader.read(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode avro event: %w", err)
+	}
+	fields, _ := value.(map[string]interface{})
+	if raw, ok := fields["data"].(string); ok {
+		var generic interface{}
+		if err := json.Unmarshal([]byte(raw), &generic); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event data: %w", err)
+		}
+		fields["data"] = generic
+	}
+
+	// Consumers read event data as decoded JSON, so mirror that shape.
+	asJSON, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert event: %w", err)
+	}
+	return JSONCodec{}.Unmarshal(asJSON)
+}
+
+func (c *AvroCodec) readerSchema(id uint32) (*avroSchema, error) {
+	c.mu.RLock()
+	schema, ok := c.readers[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), avroSchemaTimeout)
+	defer cancel()
+	raw, err := c.registry.SchemaByID(ctx, int(id))
+	if err != nil {
+		return nil, err
+	}
+	if schema, err = parseAvroSchema(raw); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.readers[id] = schema
+	c.mu.Unlock()
+	return schema, nil
+}
+
+// newKafkaCodec creates the codec of cfg.Codec. The avro codec registers its
+// schemas first, failing on incompatible changes.
+func newKafkaCodec(cfg *config.KafkaConfig) (Codec, error) {
+	if cfg.Codec != CodecAvro {
+		return NewCodec(cfg.Codec)
+	}
+	if cfg.SchemaRegistryURL == "" {
+		return nil, fmt.Errorf("the %s codec requires a schema registry URL", CodecAvro)
+	}
+
+	codec, err := NewAvroCodec(NewSchemaRegistryClient(cfg), cfg.SchemaRegistryAutoRegister)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(codec.writers))*avroSchemaTimeout)
+	defer cancel()
+	if err := codec.RegisterSchemas(ctx); err != nil {
+		return nil, err
+	}
+	return codec, nil
+}
+
+// newAvroDecoder returns a codec for decoding avro messages when a schema
+// registry is configured, and nil otherwise.
+func newAvroDecoder(cfg *config.KafkaConfig) *AvroCodec {
+	if cfg.SchemaRegistryURL == "" {
+		return nil
+	}
+	codec, err := NewAvroCodec(NewSchemaRegistryClient(cfg), false)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to create avro decoder")
+		return nil
+	}
+	return codec
+}
\ No newline at end of file
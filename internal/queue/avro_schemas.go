@@ -0,0 +1,135 @@
+package queue
+
+import (
+	"fmt"
+
+	"order-processing-microservice/internal/models"
+)
+
+// avroNamespace is the namespace of every event record. With the
+// subject-per-event-type strategy each record's full name is also its
+// schema registry subject, e.g. orders.events.v1.OrderCreated.
+const avroNamespace = "orders.events.v1"
+
+// avroGenericEvent carries event types without a dedicated schema; their data
+// is embedded as a JSON string.
+const avroGenericEvent = "GenericEvent"
+
+const (
+	avroUUID           = `{"type": "string", "logicalType": "uuid"}`
+	avroTimestamp      = `{"type": "long", "logicalType": "timestamp-micros"}`
+	avroOptionalString = `["null", "string"], "default": null`
+
+	avroOrderItem = `{"type": "record", "name": "OrderItem", "fields": [
+		{"name": "id", "type": ` + avroUUID + `},
+		{"name": "order_id", "type": ` + avroUUID + `},
+		{"name": "product_id", "type": ` + avroUUID + `},
+		{"name": "quantity", "type": "long"},
+		{"name": "price", "type": "double"},
+		{"name": "total", "type": "double"}
+	]}`
+)
+
+// avroRecordNames maps event types to their record name. The saga request and
+// reply types share data shapes but get a record, and so a subject, each.
+var avroRecordNames = map[models.EventType]string{
+	models.OrderCreatedEvent:            "OrderCreated",
+	models.OrderStatusChangedEvent:      "OrderStatusChanged",
+	models.OrderProcessingEvent:         "OrderProcessing",
+	models.OrderCompletedEvent:          "OrderCompleted",
+	models.OrderFailedEvent:             "OrderFailed",
+	models.OrderCanceledEvent:           "OrderCanceled",
+	models.PaymentAuthorizeRequestEvent: "PaymentAuthorizeRequest",
+	models.InventoryReserveRequestEvent: "InventoryReserveRequest",
+	models.PaymentAuthorizeReplyEvent:   "PaymentAuthorizeReply",
+	models.InventoryReserveReplyEvent:   "InventoryReserveReply",
+}
+
+var avroDataFields = map[models.EventType]string{
+	models.OrderCreatedEvent: `
+		{"name": "order_id", "type": ` + avroUUID + `},
+		{"name": "tenant_id", "type": ` + avroOptionalString + `},
+		{"name": "order_number", "type": ` + avroOptionalString + `},
+		{"name": "external_reference", "type": ` + avroOptionalString + `},
+		{"name": "channel", "type": ` + avroOptionalString + `},
+		{"name": "customer_id", "type": ` + avroUUID + `},
+		{"name": "items", "type": {"type": "array", "items": ` + avroOrderItem + `}},
+		{"name": "total_amount", "type": "double"},
+		{"name": "created_at", "type": ` + avroTimestamp + `}`,
+	models.OrderStatusChangedEvent: `
+		{"name": "order_id", "type": ` + avroUUID + `},
+		{"name": "customer_id", "type": ` + avroUUID + `},
+		{"name": "old_status", "type": "string"},
+		{"name": "new_status", "type": "string"},
+		{"name": "updated_at", "type": ` + avroTimestamp + `},
+		{"name": "reason", "type": ` + avroOptionalString + `}`,
+	models.OrderProcessingEvent: `
+		{"name": "order_id", "type": ` + avroUUID + `},
+		{"name": "customer_id", "type": ` + avroUUID + `},
+		{"name": "started_at", "type": ` + avroTimestamp + `}`,
+	models.OrderCompletedEvent: `
+		{"name": "order_id", "type": ` + avroUUID + `},
+		{"name": "customer_id", "type": ` + avroUUID + `},
+		{"name": "completed_at", "type": ` + avroTimestamp + `},
+		{"name": "total_amount", "type": "double"},
+		{"name": "estimated_delivery", "type": ["null", ` + avroTimestamp + `], "default": null}`,
+	models.OrderFailedEvent: `
+		{"name": "order_id", "type": ` + avroUUID + `},
+		{"name": "customer_id", "type": ` + avroUUID + `},
+		{"name": "failed_at", "type": ` + avroTimestamp + `},
+		{"name": "reason", "type": "string"},
+		{"name": "error", "type": ` + avroOptionalString + `},
+		{"name": "failure_code", "type": "string"},
+		{"name": "failure_detail", "type": ` + avroOptionalString + `}`,
+	models.OrderCanceledEvent: `
+		{"name": "order_id", "type": ` + avroUUID + `},
+		{"name": "customer_id", "type": ` + avroUUID + `},
+		{"name": "previous_status", "type": "string"},
+		{"name": "canceled_at", "type": ` + avroTimestamp + `},
+		{"name": "reason_code", "type": "string"},
+		{"name": "reason", "type": ` + avroOptionalString + `},
+		{"name": "actor", "type": "string"}`,
+	models.PaymentAuthorizeRequestEvent: avroSagaCommandFields,
+	models.InventoryReserveRequestEvent: avroSagaCommandFields,
+	models.PaymentAuthorizeReplyEvent:   avroSagaReplyFields,
+	models.InventoryReserveReplyEvent:   avroSagaReplyFields,
+}
+
+const (
+	avroSagaCommandFields = `
+		{"name": "correlation_id", "type": ` + avroUUID + `},
+		{"name": "order_id", "type": ` + avroUUID + `},
+		{"name": "customer_id", "type": ` + avroUUID + `},
+		{"name": "amount", "type": "double"},
+		{"name": "items", "type": ["null", {"type": "array", "items": ` + avroOrderItem + `}], "default": null},
+		{"name": "reply_topic", "type": "string"}`
+	avroSagaReplyFields = `
+		{"name": "correlation_id", "type": ` + avroUUID + `},
+		{"name": "order_id", "type": ` + avroUUID + `},
+		{"name": "success", "type": "boolean"},
+		{"name": "reason", "type": ` + avroOptionalString + `}`
+)
+
+// avroEventSchema wraps the data record of an event type in the event
+// envelope. The generic record keeps its data as a JSON string.
+func avroEventSchema(name, dataFields string) string {
+	dataType := `{"type": "record", "name": "` + name + `Data", "fields": [` + dataFields + `
+	]}`
+	if dataFields == "" {
+		dataType = `"string"`
+	}
+	return fmt.Sprintf(`{
+	"type": "record",
+	"name": %q,
+	"namespace": %q,
+	"fields": [
+		{"name": "id", "type": %s},
+		{"name": "type", "type": "string"},
+		{"name": "timestamp", "type": %s},
+		{"name": "version", "type": "string"},
+		{"name": "region", "type": %s},
+		{"name": "sequence", "type": "long", "default": 0},
+		{"name": "data", "type": %s}
+	]
+}`, name, avroNamespace, avroUUID, avroTimestamp, avroOptionalString, dataType)
+}
\ No newline at end of file
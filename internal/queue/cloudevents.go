@@ -0,0 +1,202 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"order-processing-microservice/internal/models"
+)
+
+const (
+	CloudEventsModeNone       = "none"
+	CloudEventsModeBinary     = "binary"
+	CloudEventsModeStructured = "structured"
+
+	cloudEventsSpecVersion     = "1.0"
+	cloudEventsContentType     = "application/cloudevents+json"
+	cloudEventsDataContentType = "application/json"
+	cloudEventsHeaderPrefix    = "ce_"
+)
+
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Region          string          `json:"region,omitempty"`
+	EventVersion    string          `json:"eventversion,omitempty"`
+	Sequence        uint64          `json:"sequence,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// ParseCloudEventsModes parses "topic=mode" pairs into a per-topic envelope mode lookup.
+func ParseCloudEventsModes(entries []string) (map[string]string, error) {
+	modes := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		topic, mode, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid cloudevents topic mode %q, expected topic=mode", entry)
+		}
+
+		mode = strings.ToLower(strings.TrimSpace(mode))
+		switch mode {
+		case CloudEventsModeNone, CloudEventsModeBinary, CloudEventsModeStructured:
+		default:
+			return nil, fmt.Errorf("unsupported cloudevents mode %q for topic %s", mode, topic)
+		}
+
+		modes[strings.TrimSpace(topic)] = mode
+	}
+	return modes, nil
+}
+
+func encodeCloudEvent(event *models.Event, mode, source string) ([]byte, []sarama.RecordHeader, error) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	ce := CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              event.ID.String(),
+		Source:          source,
+		Type:            string(event.Type),
+		Time:            event.Timestamp,
+		DataContentType: cloudEventsDataContentType,
+		Region:          event.Region,
+		EventVersion:    event.Version,
+		Sequence:        event.Sequence,
+		Data:            data,
+	}
+
+	if mode == CloudEventsModeStructured {
+		value, err := json.Marshal(ce)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal cloudevent: %w", err)
+		}
+		return value, []sarama.RecordHeader{
+			{Key: []byte("content-type"), Value: []byte(cloudEventsContentType)},
+		}, nil
+	}
+
+	headers := []sarama.RecordHeader{
+		{Key: []byte("content-type"), Value: []byte(ce.DataContentType)},
+		{Key: []byte(cloudEventsHeaderPrefix + "specversion"), Value: []byte(ce.SpecVersion)},
+		{Key: []byte(cloudEventsHeaderPrefix + "id"), Value: []byte(ce.ID)},
+		{Key: []byte(cloudEventsHeaderPrefix + "source"), Value: []byte(ce.Source)},
+		{Key: []byte(cloudEventsHeaderPrefix + "type"), Value: []byte(ce.Type)},
+		{Key: []byte(cloudEventsHeaderPrefix + "time"), Value: []byte(ce.Time.Format(time.RFC3339Nano))},
+		{Key: []byte(cloudEventsHeaderPrefix + "eventversion"), Value: []byte(ce.EventVersion)},
+	}
+	if ce.Region != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(cloudEventsHeaderPrefix + "region"), Value: []byte(ce.Region)})
+	}
+	if ce.Sequence > 0 {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(cloudEventsHeaderPrefix + "sequence"), Value: []byte(strconv.FormatUint(ce.Sequence, 10))})
+	}
+	return data, headers, nil
+}
+
+// DecodeMessage accepts CloudEvents binary and structured messages as well as
+// plain events in either codec, told apart by the content-type header.
+func DecodeMessage(message *sarama.ConsumerMessage) (*models.Event, error) {
+	return decodeMessage(message, nil)
+}
+
+// decodeMessage is DecodeMessage that also decodes avro events with avro, if
+// set.
+func decodeMessage(message *sarama.ConsumerMessage, avro *AvroCodec) (*models.Event, error) {
+	headers := make(map[string]string, len(message.Headers))
+	for _, header := range message.Headers {
+		if header != nil {
+			headers[strings.ToLower(string(header.Key))] = string(header.Value)
+		}
+	}
+
+	if _, ok := headers[cloudEventsHeaderPrefix+"specversion"]; ok {
+		ce := CloudEvent{
+			SpecVersion:  headers[cloudEventsHeaderPrefix+"specversion"],
+			ID:           headers[cloudEventsHeaderPrefix+"id"],
+			Source:       headers[cloudEventsHeaderPrefix+"source"],
+			Type:         headers[cloudEventsHeaderPrefix+"type"],
+			Region:       headers[cloudEventsHeaderPrefix+"region"],
+			EventVersion: headers[cloudEventsHeaderPrefix+"eventversion"],
+			Data:         message.Value,
+		}
+		if t, err := time.Parse(time.RFC3339Nano, headers[cloudEventsHeaderPrefix+"time"]); err == nil {
+			ce.Time = t
+		}
+		if sequence, err := strconv.ParseUint(headers[cloudEventsHeaderPrefix+"sequence"], 10, 64); err == nil {
+			ce.Sequence = sequence
+		}
+		return ce.toEvent()
+	}
+
+	if strings.HasPrefix(headers["content-type"], cloudEventsContentType) || isStructuredCloudEvent(message.Value) {
+		var ce CloudEvent
+		if err := json.Unmarshal(message.Value, &ce); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cloudevent: %w", err)
+		}
+		return ce.toEvent()
+	}
+
+	if strings.HasPrefix(headers["content-type"], protobufContentType) {
+		return ProtobufCodec{}.Unmarshal(message.Value)
+	}
+
+	if strings.HasPrefix(headers["content-type"], avroContentType) {
+		if avro == nil {
+			return nil, fmt.Errorf("avro event requires a schema registry")
+		}
+		return avro.Unmarshal(message.Value)
+	}
+
+	return JSONCodec{}.Unmarshal(message.Value)
+}
+
+func isStructuredCloudEvent(value []byte) bool {
+	var probe struct {
+		SpecVersion string `json:"specversion"`
+	}
+	return bytes.Contains(value, []byte(`"specversion"`)) && json.Unmarshal(value, &probe) == nil && probe.SpecVersion != ""
+}
+
+func (ce *CloudEvent) toEvent() (*models.Event, error) {
+	if ce.SpecVersion != cloudEventsSpecVersion {
+		return nil, fmt.Errorf("unsupported cloudevents specversion %q", ce.SpecVersion)
+	}
+
+	id, err := uuid.Parse(ce.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cloudevent id %q: %w", ce.ID, err)
+	}
+
+	event := &models.Event{
+		ID:        id,
+		Type:      models.EventType(ce.Type),
+		Timestamp: ce.Time,
+		Version:   ce.EventVersion,
+		Region:    ce.Region,
+		Sequence:  ce.Sequence,
+	}
+
+	if len(ce.Data) > 0 {
+		if err := json.Unmarshal(ce.Data, &event.Data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cloudevent data: %w", err)
+		}
+	}
+	return event, nil
+}
\ No newline at end of file
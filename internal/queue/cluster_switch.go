@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// The errors SwitchCluster rejects a switch with, matched with errors.Is. A
+// cluster that cannot be reached fails with ErrBrokerUnavailable.
+var (
+	ErrClusterSwitchDisabled = errors.New("no secondary Kafka cluster is configured")
+	ErrUnknownCluster        = errors.New("unknown Kafka cluster")
+)
+
+// ConsumerCluster reports which Kafka cluster a consumer reads from.
+type ConsumerCluster struct {
+	Active     string     `json:"active"`
+	Switches   uint64     `json:"switches"`
+	SwitchedAt *time.Time `json:"switched_at,omitempty"`
+}
+
+type clusterSwitch struct {
+	mu         sync.Mutex
+	newGroup   func(cluster string) (sarama.ConsumerGroup, error)
+	active     string
+	switches   uint64
+	switchedAt time.Time
+}
+
+func (s *clusterSwitch) activeCluster() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.active
+}
+
+func (s *clusterSwitch) status() ConsumerCluster {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := ConsumerCluster{Active: s.active, Switches: s.switches}
+	if !s.switchedAt.IsZero() {
+		switchedAt := s.switchedAt
+		status.SwitchedAt = &switchedAt
+	}
+	return status
+}
+
+// EnableClusterSwitch allows SwitchCluster to move the consumer to another
+// cluster, creating its consumer group client with newGroup. The consumer
+// starts on the primary cluster. It must be called before Subscribe.
+func (c *KafkaConsumer) EnableClusterSwitch(newGroup func(cluster string) (sarama.ConsumerGroup, error)) {
+	c.clusters = &clusterSwitch{newGroup: newGroup, active: ClusterPrimary}
+	if c.rebuilt == nil {
+		c.rebuilt = make(chan struct{}, 1)
+	}
+}
+
+// SwitchCluster moves the consumer to the primary or secondary cluster
+// without restarting it: the current session ends and the consumer rejoins
+// its group on the other cluster, from the offsets committed there. Switching
+// to the active cluster does nothing.
+func (c *KafkaConsumer) SwitchCluster(cluster string) error {
+	if c.clusters == nil {
+		return ErrClusterSwitchDisabled
+	}
+	if cluster != ClusterPrimary && cluster != ClusterSecondary {
+		return fmt.Errorf("%w %q, expected %s or %s", ErrUnknownCluster, cluster, ClusterPrimary, ClusterSecondary)
+	}
+
+	c.clusters.mu.Lock()
+	defer c.clusters.mu.Unlock()
+
+	if c.clusters.active == cluster {
+		return nil
+	}
+
+	group, err := c.clusters.newGroup(cluster)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s Kafka cluster: %w: %w", cluster, ErrBrokerUnavailable, err)
+	}
+	c.replaceGroup(group)
+
+	c.clusters.active = cluster
+	c.clusters.switches++
+	c.clusters.switchedAt = time.Now()
+	c.logger.WithField("cluster", cluster).Warn("Switched consumer to Kafka cluster")
+	return nil
+}
+
+// Cluster reports the cluster the consumer reads from.
+func (c *KafkaConsumer) Cluster() ConsumerCluster {
+	if c.clusters == nil {
+		return ConsumerCluster{Active: ClusterPrimary}
+	}
+	return c.clusters.status()
+}
+
+// activeCluster returns the cluster the consumer group client connects to.
+func (c *KafkaConsumer) activeCluster() string {
+	if c.clusters == nil {
+		return ClusterPrimary
+	}
+	return c.clusters.activeCluster()
+}
\ No newline at end of file
@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"order-processing-microservice/internal/models"
+)
+
+const (
+	CodecJSON     = "json"
+	CodecProtobuf = "protobuf"
+	CodecAvro     = "avro"
+
+	jsonContentType     = "application/json"
+	protobufContentType = "application/x-protobuf"
+)
+
+type Codec interface {
+	Marshal(event *models.Event) ([]byte, error)
+	Unmarshal(data []byte) (*models.Event, error)
+	ContentType() string
+}
+
+func NewCodec(name string) (Codec, error) {
+	switch name {
+	case "", CodecJSON:
+		return JSONCodec{}, nil
+	case CodecProtobuf:
+		return ProtobufCodec{}, nil
+	case CodecAvro:
+		return nil, fmt.Errorf("the %s codec requires a schema registry, see NewAvroCodec", name)
+	default:
+		return nil, fmt.Errorf("unsupported event codec %q", name)
+	}
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(event *models.Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+func (JSONCodec) Unmarshal(data []byte) (*models.Event, error) {
+	var event models.Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	return &event, nil
+}
+
+func (JSONCodec) ContentType() string {
+	return jsonContentType
+}
\ No newline at end of file
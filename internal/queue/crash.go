@@ -0,0 +1,35 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/logger"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+// handleReporting passes event to handler. With crashes set, a panic in the
+// handler is recorded in a crash report of the event, with the trace context
+// and the message fields, and returned as an error, failing the message as a
+// handler error would. Without, the panic is not recovered.
+func handleReporting(ctx context.Context, handler EventHandler, event *models.Event, crashes *logger.CrashReporter, fields logrus.Fields) (err error) {
+	if crashes != nil {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			reportFields := requestctx.LogFields(ctx)
+			for key, value := range fields {
+				reportFields[key] = value
+			}
+			reportFields["event_id"] = event.ID
+			reportFields["event_type"] = event.Type
+			report := crashes.Report(recovered, reportFields)
+			err = fmt.Errorf("handler panicked: %v (crash report %s)", recovered, report.ID)
+		}()
+	}
+	return handler.HandleEvent(ctx, event)
+}
\ No newline at end of file
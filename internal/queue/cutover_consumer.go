@@ -0,0 +1,542 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/logger"
+)
+
+// Topic migration phases. In the dual phase producers write every event to
+// both the order topic and the migration topic while consumers stay on the
+// order topic. In the cutover phase producers write only to the migration
+// topic and consumers drain the order topic before switching over.
+const (
+	MigrationPhaseDual    = "dual"
+	MigrationPhaseCutover = "cutover"
+
+	drainCheckInterval  = 5 * time.Second
+	lastMessageTimeout  = 10 * time.Second
+	recentEventCapacity = 10000
+)
+
+func validateMigration(cfg *config.KafkaConfig) error {
+	switch cfg.MigrationPhase {
+	case "":
+		return nil
+	case MigrationPhaseDual, MigrationPhaseCutover:
+		if cfg.MigrationTopic == "" || cfg.MigrationTopic == cfg.OrderTopic {
+			return fmt.Errorf("migration phase %s requires a migration topic different from %s", cfg.MigrationPhase, cfg.OrderTopic)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported migration phase %q", cfg.MigrationPhase)
+	}
+}
+
+func validateCutover(cfg *config.KafkaConfig) error {
+	if err := validateMigration(cfg); err != nil {
+		return err
+	}
+	if cfg.MigrationPhase != MigrationPhaseCutover {
+		return fmt.Errorf("cutover consumer requires migration phase %s", MigrationPhaseCutover)
+	}
+	return nil
+}
+
+// CutoverConsumer consumes the order topic until the group has committed up to
+// the high watermark of every partition and no message arrived for the idle
+// period, then continues on the migration topic from the time of the last
+// message on the old topic. Events already handled from the old topic are
+// skipped if they reappear on the new one.
+type CutoverConsumer struct {
+	cfg    *config.KafkaConfig
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+	idle   time.Duration
+	skew   time.Duration
+
+	mu      sync.Mutex
+	current *KafkaConsumer
+
+	deadLetters *DeadLetterQueue
+	retries     *RetryQueue
+	crashes     *logger.CrashReporter
+
+	recent      *RecentEvents
+	lastMessage atomic.Int64
+	ready       atomic.Bool
+
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	errs      chan error
+	done      chan struct{}
+	closeOnce sync.Once
+	logger    *logrus.Entry
+}
+
+func NewCutoverConsumer(cfg *config.KafkaConfig) (*CutoverConsumer, error) {
+	if err := validateCutover(cfg); err != nil {
+		return nil, err
+	}
+
+	client, err := sarama.NewClient(cfg.Brokers, sarama.NewConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka cluster admin: %w", err)
+	}
+
+	return NewCutoverConsumerWithClient(cfg, client, admin)
+}
+
+// NewCutoverConsumerWithClient is NewCutoverConsumer with the client and the
+// cluster admin it checks the topics with; closing the consumer closes admin.
+func NewCutoverConsumerWithClient(cfg *config.KafkaConfig, client sarama.Client, admin sarama.ClusterAdmin) (*CutoverConsumer, error) {
+	if err := validateCutover(cfg); err != nil {
+		return nil, err
+	}
+
+	return &CutoverConsumer{
+		cfg:    cfg,
+		client: client,
+		admin:  admin,
+		idle:   time.Duration(cfg.MigrationIdle) * time.Second,
+		skew:   time.Duration(cfg.MigrationSkew) * time.Second,
+		recent: NewRecentEvents(recentEventCapacity),
+		errs:   make(chan error, 1),
+		done:   make(chan struct{}),
+		logger: logrus.WithFields(logrus.Fields{
+			"component":  "cutover_consumer",
+			"group_id":   cfg.GroupID,
+			"from_topic": cfg.OrderTopic,
+			"to_topic":   cfg.MigrationTopic,
+		}),
+	}, nil
+}
+
+// EnableDeadLetters applies to both the old and the migration topic consumer.
+// It must be called before Subscribe.
+func (c *CutoverConsumer) EnableDeadLetters(dlq *DeadLetterQueue) {
+	c.deadLetters = dlq
+}
+
+// EnableRetries applies to both the old and the migration topic consumer,
+// each subscribing to the retry topics of its own topic. It must be called
+// before Subscribe.
+func (c *CutoverConsumer) EnableRetries(retries *RetryQueue) {
+	c.retries = retries
+}
+
+// EnableCrashReports applies to both the old and the migration topic
+// consumer. It must be called before Subscribe.
+func (c *CutoverConsumer) EnableCrashReports(crashes *logger.CrashReporter) {
+	c.crashes = crashes
+}
+
+func (c *CutoverConsumer) Subscribe(ctx context.Context, handler EventHandler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+
+	old, err := NewKafkaConsumerForTopics(c.cfg, []string{c.cfg.OrderTopic})
+	if err != nil {
+		return err
+	}
+	if c.deadLetters != nil {
+		old.EnableDeadLetters(c.deadLetters)
+	}
+	if c.retries != nil {
+		old.EnableRetries(c.retries)
+	}
+	if c.crashes != nil {
+		old.EnableCrashReports(c.crashes)
+	}
+
+	tracking := EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		c.lastMessage.Store(time.Now().UnixNano())
+		c.recent.Add(event.ID)
+		return handler.HandleEvent(ctx, event)
+	})
+
+	if err := old.Subscribe(ctx, tracking); err != nil {
+		return err
+	}
+	c.setCurrent(old)
+	c.lastMessage.Store(time.Now().UnixNano())
+
+	c.wg.Add(1)
+	go c.awaitDrain(ctx, handler)
+
+	c.logger.Info("Consuming old topic until drained")
+	return nil
+}
+
+func (c *CutoverConsumer) awaitDrain(ctx context.Context, handler EventHandler) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, c.lastMessage.Load())) < c.idle {
+				continue
+			}
+
+			drained, err := c.Drained()
+			if err != nil {
+				c.logger.WithError(err).Warn("Failed to check whether old topic is drained")
+				continue
+			}
+			if !drained {
+				continue
+			}
+
+			if err := c.switchToMigrationTopic(ctx, handler); err != nil {
+				c.logger.WithError(err).Error("Failed to switch to migration topic")
+				continue
+			}
+			return
+		}
+	}
+}
+
+// Drained reports whether the group has committed every partition of the
+// order topic up to its high watermark. Empty partitions count as drained
+// whatever the group committed.
+func (c *CutoverConsumer) Drained() (bool, error) {
+	topic := c.cfg.OrderTopic
+	partitions, err := c.client.Partitions(topic)
+	if err != nil {
+		return false, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	committed, err := c.admin.ListConsumerGroupOffsets(c.cfg.GroupID, map[string][]int32{topic: partitions})
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+
+	for _, partition := range partitions {
+		newest, err := c.client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return false, fmt.Errorf("failed to get high watermark: %w", err)
+		}
+
+		offset := int64(-1)
+		if block := committed.GetBlock(topic, partition); block != nil {
+			offset = block.Offset
+		}
+		if offset >= newest {
+			continue
+		}
+
+		oldest, err := c.client.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return false, fmt.Errorf("failed to get low watermark: %w", err)
+		}
+		if oldest < newest {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (c *CutoverConsumer) switchToMigrationTopic(ctx context.Context, handler EventHandler) error {
+	lastOld, err := c.lastMessageTime(c.cfg.OrderTopic)
+	if err != nil {
+		return err
+	}
+
+	startAt := lastOld
+	if !startAt.IsZero() {
+		startAt = startAt.Add(-c.skew)
+	}
+	if err := c.seedOffsets(startAt); err != nil {
+		return err
+	}
+
+	if old := c.getCurrent(); old != nil {
+		old.Close()
+	}
+
+	next, err := NewKafkaConsumerForTopics(c.cfg, []string{c.cfg.MigrationTopic})
+	if err != nil {
+		return err
+	}
+	if c.deadLetters != nil {
+		next.EnableDeadLetters(c.deadLetters)
+	}
+	if c.retries != nil {
+		next.EnableRetries(c.retries)
+	}
+	if c.crashes != nil {
+		next.EnableCrashReports(c.crashes)
+	}
+
+	dedup := EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		if c.recent.Contains(event.ID) {
+			c.logger.WithField("event_id", event.ID).Debug("Skipping event already consumed from old topic")
+			return nil
+		}
+		return handler.HandleEvent(ctx, event)
+	})
+
+	if err := next.Subscribe(ctx, dedup); err != nil {
+		return err
+	}
+	c.setCurrent(next)
+
+	c.logger.WithField("start_at", startAt).Info("Old topic drained, switched to migration topic")
+	return nil
+}
+
+func (c *CutoverConsumer) lastMessageTime(topic string) (time.Time, error) {
+	partitions, err := c.client.Partitions(topic)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(c.client)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create partition consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	var latest time.Time
+	for _, partition := range partitions {
+		newest, err := c.client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get high watermark: %w", err)
+		}
+		oldest, err := c.client.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get low watermark: %w", err)
+		}
+		if newest <= oldest {
+			continue
+		}
+
+		pc, err := consumer.ConsumePartition(topic, partition, newest-1)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read last message of partition %d: %w", partition, err)
+		}
+
+		select {
+		case message := <-pc.Messages():
+			if message != nil && message.Timestamp.After(latest) {
+				latest = message.Timestamp
+			}
+		case <-time.After(lastMessageTimeout):
+			pc.Close()
+			return time.Time{}, fmt.Errorf("timed out reading last message of partition %d", partition)
+		}
+		pc.Close()
+	}
+
+	return latest, nil
+}
+
+// StartOffsets returns the offset the group starts at on each partition of
+// the migration topic it has not consumed yet: that of the first message at or
+// after startAt, the end of the partition if there is none, or the oldest
+// offset if startAt is zero.
+func (c *CutoverConsumer) StartOffsets(startAt time.Time) (map[int32]int64, error) {
+	topic := c.cfg.MigrationTopic
+	partitions, err := c.client.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	committed, err := c.admin.ListConsumerGroupOffsets(c.cfg.GroupID, map[string][]int32{topic: partitions})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+
+	offsets := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		if block := committed.GetBlock(topic, partition); block != nil && block.Offset >= 0 {
+			continue
+		}
+
+		offset := sarama.OffsetOldest
+		if !startAt.IsZero() {
+			offset = startAt.UnixMilli()
+		}
+		resolved, err := c.client.GetOffset(topic, partition, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve start offset: %w", err)
+		}
+		if resolved < 0 {
+			if resolved, err = c.client.GetOffset(topic, partition, sarama.OffsetNewest); err != nil {
+				return nil, fmt.Errorf("failed to resolve start offset: %w", err)
+			}
+		}
+		offsets[partition] = resolved
+	}
+	return offsets, nil
+}
+
+// seedOffsets commits the start offsets of the migration topic, so the switch
+// neither skips events published only to the new topic nor replays the whole
+// dual-write history.
+func (c *CutoverConsumer) seedOffsets(startAt time.Time) error {
+	topic := c.cfg.MigrationTopic
+	offsets, err := c.StartOffsets(startAt)
+	if err != nil {
+		return err
+	}
+
+	offsetManager, err := sarama.NewOffsetManagerFromClient(c.cfg.GroupID, c.client)
+	if err != nil {
+		return fmt.Errorf("failed to create offset manager: %w", err)
+	}
+	defer offsetManager.Close()
+
+	var managed []sarama.PartitionOffsetManager
+	defer func() {
+		for _, pom := range managed {
+			pom.Close()
+		}
+	}()
+
+	for partition, resolved := range offsets {
+		pom, err := offsetManager.ManagePartition(topic, partition)
+		if err != nil {
+			return fmt.Errorf("failed to manage partition %d: %w", partition, err)
+		}
+		managed = append(managed, pom)
+		pom.ResetOffset(resolved, "")
+
+		c.logger.WithFields(logrus.Fields{
+			"partition": partition,
+			"offset":    resolved,
+		}).Info("Seeded migration topic offset")
+	}
+
+	offsetManager.Commit()
+	return nil
+}
+
+func (c *CutoverConsumer) setCurrent(consumer *KafkaConsumer) {
+	c.mu.Lock()
+	c.current = consumer
+	c.mu.Unlock()
+
+	go func() {
+		if err := consumer.Wait(); err != nil {
+			select {
+			case c.errs <- err:
+			default:
+			}
+		}
+	}()
+}
+
+func (c *CutoverConsumer) getCurrent() *KafkaConsumer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+func (c *CutoverConsumer) Assignment() PartitionAssignment {
+	if current := c.getCurrent(); current != nil {
+		return current.Assignment()
+	}
+	return PartitionAssignment{GroupID: c.cfg.GroupID, Partitions: map[string][]int32{}}
+}
+
+// Lag reports the lag of the current topic's consumer. Once ready, the cutover
+// consumer stays ready across the switch to the migration topic.
+func (c *CutoverConsumer) Lag() ConsumerLag {
+	lag := ConsumerLag{Partitions: map[string]map[int32]int64{}, Threshold: int64(c.cfg.ReadyMaxLag)}
+	if current := c.getCurrent(); current != nil {
+		lag = current.Lag()
+	}
+	if lag.Ready {
+		c.ready.Store(true)
+	}
+	lag.Ready = c.ready.Load()
+	return lag
+}
+
+// Wait blocks until either topic's consumer stops on a fatal error or the
+// cutover consumer is closed.
+func (c *CutoverConsumer) Wait() error {
+	select {
+	case err := <-c.errs:
+		return err
+	case <-c.done:
+		return nil
+	}
+}
+
+// Close stops the consumer; calling it again has no effect.
+func (c *CutoverConsumer) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.cancel != nil {
+			c.cancel()
+		}
+		close(c.done)
+		c.wg.Wait()
+
+		if current := c.getCurrent(); current != nil {
+			err = current.Close()
+		}
+		c.admin.Close()
+	})
+	return err
+}
+
+// RecentEvents remembers the IDs of the last capacity events added, forgetting
+// the oldest one as a new one is added.
+type RecentEvents struct {
+	mu    sync.Mutex
+	ids   map[uuid.UUID]struct{}
+	order []uuid.UUID
+	next  int
+}
+
+func NewRecentEvents(capacity int) *RecentEvents {
+	return &RecentEvents{
+		ids:   make(map[uuid.UUID]struct{}, capacity),
+		order: make([]uuid.UUID, capacity),
+	}
+}
+
+// Add remembers id; adding an ID already remembered does not refresh it.
+func (r *RecentEvents) Add(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.ids[id]; ok {
+		return
+	}
+	delete(r.ids, r.order[r.next])
+	r.order[r.next] = id
+	r.ids[id] = struct{}{}
+	r.next = (r.next + 1) % len(r.order)
+}
+
+func (r *RecentEvents) Contains(id uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.ids[id]
+	return ok
+}
\ No newline at end of file
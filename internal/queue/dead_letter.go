@@ -0,0 +1,239 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+// Headers added to dead-lettered messages, next to the original headers.
+const (
+	DeadLetterHeaderReason    = "dlq_reason"
+	DeadLetterHeaderError     = "dlq_error"
+	DeadLetterHeaderTopic     = "dlq_original_topic"
+	DeadLetterHeaderPartition = "dlq_original_partition"
+	DeadLetterHeaderOffset    = "dlq_original_offset"
+	DeadLetterHeaderGroup     = "dlq_consumer_group"
+	DeadLetterHeaderFailedAt  = "dlq_failed_at"
+)
+
+// errEventDecode marks messages that could not be decoded into an event.
+var errEventDecode = errors.New("failed to decode event")
+
+// DeadLetterRecorder stores a record of every dead-lettered message.
+type DeadLetterRecorder interface {
+	Create(ctx context.Context, event *models.DeadLetterEvent) error
+}
+
+// DeadLetterQueue republishes messages a consumer could not process to the
+// dead-letter topic, unchanged apart from the dlq_* headers, and records them.
+type DeadLetterQueue struct {
+	producer sarama.SyncProducer
+	topic    string
+	recorder DeadLetterRecorder
+	logger   *logrus.Entry
+}
+
+func NewDeadLetterQueue(cfg *config.KafkaConfig, recorder DeadLetterRecorder) (*DeadLetterQueue, error) {
+	if cfg.DLQTopic == "" {
+		return nil, fmt.Errorf("dead-letter queue requires a DLQ topic")
+	}
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Retry.Max = cfg.RetryAttempts
+	saramaConfig.Producer.Retry.Backoff = time.Millisecond * 250
+
+	producer, err := sarama.NewSyncProducer(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter producer: %w", err)
+	}
+
+	return NewDeadLetterQueueWithProducer(producer, cfg.DLQTopic, recorder), nil
+}
+
+func NewDeadLetterQueueWithProducer(producer sarama.SyncProducer, topic string, recorder DeadLetterRecorder) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		producer: producer,
+		topic:    topic,
+		recorder: recorder,
+		logger: logrus.WithFields(logrus.Fields{
+			"component": "dead_letter_queue",
+			"topic":     topic,
+		}),
+	}
+}
+
+// Publish dead-letters the message that failed with cause. Failing to record
+// it is logged but does not fail the publish, as the topic already holds it.
+func (q *DeadLetterQueue) Publish(ctx context.Context, groupID string, message *sarama.ConsumerMessage, cause error) error {
+	reason := models.DeadLetterReasonHandlerFailed
+	if errors.Is(cause, errEventDecode) {
+		reason = models.DeadLetterReasonDecodeFailed
+	}
+	failedAt := time.Now().UTC()
+
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+7)
+	original := make(map[string]string, len(message.Headers))
+	for _, h := range message.Headers {
+		if h == nil {
+			continue
+		}
+		headers = append(headers, *h)
+		original[string(h.Key)] = string(h.Value)
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(DeadLetterHeaderReason), Value: []byte(reason)},
+		sarama.RecordHeader{Key: []byte(DeadLetterHeaderError), Value: []byte(cause.Error())},
+		sarama.RecordHeader{Key: []byte(DeadLetterHeaderTopic), Value: []byte(message.Topic)},
+		sarama.RecordHeader{Key: []byte(DeadLetterHeaderPartition), Value: []byte(strconv.FormatInt(int64(message.Partition), 10))},
+		sarama.RecordHeader{Key: []byte(DeadLetterHeaderOffset), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		sarama.RecordHeader{Key: []byte(DeadLetterHeaderGroup), Value: []byte(groupID)},
+		sarama.RecordHeader{Key: []byte(DeadLetterHeaderFailedAt), Value: []byte(failedAt.Format(time.RFC3339))},
+	)
+
+	dlqMessage := &sarama.ProducerMessage{
+		Topic:   q.topic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+	if message.Key != nil {
+		dlqMessage.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	if _, _, err := q.producer.SendMessage(dlqMessage); err != nil {
+		return fmt.Errorf("failed to publish to dead-letter topic: %w", err)
+	}
+
+	logger := q.logger.WithFields(logrus.Fields{
+		"original_topic": message.Topic,
+		"partition":      message.Partition,
+		"offset":         message.Offset,
+		"reason":         reason,
+	})
+	logger.Warn("Message dead-lettered")
+
+	if q.recorder == nil {
+		return nil
+	}
+
+	record := &models.DeadLetterEvent{
+		ID:            uuid.New(),
+		EventType:     original["event_type"],
+		Topic:         message.Topic,
+		Partition:     message.Partition,
+		Offset:        message.Offset,
+		ConsumerGroup: groupID,
+		DLQTopic:      q.topic,
+		Reason:        reason,
+		Error:         cause.Error(),
+		Headers:       original,
+		Key:           message.Key,
+		Payload:       message.Value,
+		CreatedAt:     failedAt,
+	}
+	if eventID, err := uuid.Parse(original["event_id"]); err == nil {
+		record.EventID = &eventID
+	}
+
+	if err := q.recorder.Create(ctx, record); err != nil {
+		logger.WithError(err).Error("Failed to record dead-lettered message")
+	}
+	return nil
+}
+
+func (q *DeadLetterQueue) Close() error {
+	if err := q.producer.Close(); err != nil {
+		return fmt.Errorf("failed to close dead-letter producer: %w", err)
+	}
+	return nil
+}
+
+// DeadLetterRequeuer publishes dead-lettered messages back to the topic they
+// failed on, with their original key and headers. Messages that failed on a
+// retry topic go back to the main topic, without their retry headers, so they
+// get a fresh set of retries.
+type DeadLetterRequeuer struct {
+	producer sarama.SyncProducer
+	logger   *logrus.Entry
+}
+
+func NewDeadLetterRequeuer(cfg *config.KafkaConfig) (*DeadLetterRequeuer, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Retry.Max = cfg.RetryAttempts
+	saramaConfig.Producer.Retry.Backoff = time.Millisecond * 250
+
+	producer, err := sarama.NewSyncProducer(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create requeue producer: %w", err)
+	}
+
+	return NewDeadLetterRequeuerWithProducer(producer), nil
+}
+
+func NewDeadLetterRequeuerWithProducer(producer sarama.SyncProducer) *DeadLetterRequeuer {
+	return &DeadLetterRequeuer{
+		producer: producer,
+		logger:   logrus.WithField("component", "dead_letter_requeuer"),
+	}
+}
+
+func (r *DeadLetterRequeuer) Requeue(ctx context.Context, event *models.DeadLetterEvent) error {
+	topic := event.Topic
+	if original := event.Headers[RetryHeaderOriginalTopic]; original != "" {
+		topic = original
+	}
+
+	keys := make([]string, 0, len(event.Headers))
+	for key := range event.Headers {
+		if strings.HasPrefix(key, "retry_") || strings.HasPrefix(key, "dlq_") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	headers := make([]sarama.RecordHeader, 0, len(keys))
+	for _, key := range keys {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(event.Headers[key])})
+	}
+
+	message := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(event.Payload),
+		Headers: headers,
+	}
+	if event.Key != nil {
+		message.Key = sarama.ByteEncoder(event.Key)
+	}
+
+	if _, _, err := sendMessage(ctx, r.producer, message, 0); err != nil {
+		return fmt.Errorf("failed to requeue dead letter %s: %w", event.ID, err)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"dead_letter_id": event.ID,
+		"topic":          topic,
+	}).Info("Dead-lettered message requeued")
+	return nil
+}
+
+func (r *DeadLetterRequeuer) Close() error {
+	if err := r.producer.Close(); err != nil {
+		return fmt.Errorf("failed to close requeue producer: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
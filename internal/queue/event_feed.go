@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+)
+
+// FeedFilter selects the events of a feed stream: those of one of Types, if
+// any are set, for the customer CustomerID, if set.
+type FeedFilter struct {
+	Types      []models.EventType
+	CustomerID string
+}
+
+// Matches reports whether an event of eventType for customerID passes the
+// filter.
+func (f FeedFilter) Matches(eventType models.EventType, customerID string) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, eventType) {
+		return false
+	}
+	return f.CustomerID == "" || f.CustomerID == customerID
+}
+
+type feedStream struct {
+	filter FeedFilter
+	events chan *models.Event
+}
+
+// EventFeed fans the events it handles out to the streams subscribed to it.
+// Events are never waited for: a stream that falls more than its buffer
+// behind is closed instead of slowing down the consumer feeding it.
+type EventFeed struct {
+	mu      sync.Mutex
+	streams map[*feedStream]struct{}
+	buffer  int
+	logger  *logrus.Entry
+}
+
+func NewEventFeed(buffer int) *EventFeed {
+	if buffer < 1 {
+		buffer = 1
+	}
+	return &EventFeed{
+		streams: make(map[*feedStream]struct{}),
+		buffer:  buffer,
+		logger:  logrus.WithField("component", "event_feed"),
+	}
+}
+
+// Subscribe returns a stream of the events matching filter and a function
+// ending it. The stream is closed when it is ended or falls behind.
+func (f *EventFeed) Subscribe(filter FeedFilter) (<-chan *models.Event, func()) {
+	stream := &feedStream{filter: filter, events: make(chan *models.Event, f.buffer)}
+
+	f.mu.Lock()
+	f.streams[stream] = struct{}{}
+	f.mu.Unlock()
+
+	return stream.events, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.remove(stream)
+	}
+}
+
+// Streams returns the number of open streams.
+func (f *EventFeed) Streams() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.streams)
+}
+
+// HandleEvent passes event to the streams whose filter it matches.
+func (f *EventFeed) HandleEvent(ctx context.Context, event *models.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.streams) == 0 {
+		return nil
+	}
+	customerID := eventCustomerID(event)
+	for stream := range f.streams {
+		if !stream.filter.Matches(event.Type, customerID) {
+			continue
+		}
+		select {
+		case stream.events <- event:
+		default:
+			f.logger.WithField("buffer", f.buffer).Warn("Feed stream fell behind, closing it")
+			f.remove(stream)
+		}
+	}
+	return nil
+}
+
+// remove closes stream unless it was removed already. f.mu must be held.
+func (f *EventFeed) remove(stream *feedStream) {
+	if _, ok := f.streams[stream]; !ok {
+		return
+	}
+	delete(f.streams, stream)
+	close(stream.events)
+}
+
+// eventCustomerID returns the customer of an order event, or "" for events
+// that have none.
+func eventCustomerID(event *models.Event) string {
+	var data struct {
+		CustomerID string `json:"customer_id"`
+	}
+	if err := event.DecodeData(&data); err != nil {
+		return ""
+	}
+	return data.CustomerID
+}
\ No newline at end of file
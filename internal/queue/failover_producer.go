@@ -0,0 +1,230 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+const (
+	ClusterPrimary   = "primary"
+	ClusterSecondary = "secondary"
+)
+
+// ClusterStatus reports which Kafka cluster a FailoverProducer publishes to.
+type ClusterStatus struct {
+	Active              string     `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Failovers           uint64     `json:"failovers"`
+	Failbacks           uint64     `json:"failbacks"`
+	FailedOverAt        *time.Time `json:"failed_over_at,omitempty"`
+}
+
+// FailoverProducer publishes to the primary cluster and switches to the
+// secondary after FailoverThreshold consecutive failed publishes spanning at
+// least FailoverPeriod, so a brief broker hiccup does not fail over. While on
+// the secondary it sends one publish to the primary every FailbackInterval
+// and fails back as soon as one succeeds.
+type FailoverProducer struct {
+	primary          TopicProducer
+	secondary        TopicProducer
+	threshold        int
+	period           time.Duration
+	failbackInterval time.Duration
+
+	mu           sync.Mutex
+	status       ClusterStatus
+	failingSince time.Time
+	lastProbe    time.Time
+	logger       *logrus.Entry
+}
+
+func NewFailoverProducer(primary, secondary TopicProducer, cfg *config.KafkaConfig) *FailoverProducer {
+	threshold := cfg.FailoverThreshold
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &FailoverProducer{
+		primary:          primary,
+		secondary:        secondary,
+		threshold:        threshold,
+		period:           time.Duration(cfg.FailoverPeriod) * time.Second,
+		failbackInterval: time.Duration(cfg.FailbackInterval) * time.Second,
+		status:           ClusterStatus{Active: ClusterPrimary},
+		logger:           logrus.WithField("component", "failover_producer"),
+	}
+}
+
+// newKafkaTransportProducer creates a Kafka producer, wrapped in a
+// FailoverProducer when secondary brokers are configured.
+func newKafkaTransportProducer(cfg *config.KafkaConfig) (TopicProducer, error) {
+	primary, err := NewKafkaProducer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	brokers := secondaryBrokers(cfg)
+	if len(brokers) == 0 {
+		return primary, nil
+	}
+	if cfg.TransactionalID != "" {
+		primary.Close()
+		return nil, fmt.Errorf("transactional producers do not support a secondary cluster")
+	}
+
+	secondaryCfg := *cfg
+	secondaryCfg.Brokers = brokers
+	secondary, err := NewKafkaProducer(&secondaryCfg)
+	if err != nil {
+		primary.Close()
+		return nil, err
+	}
+
+	return NewFailoverProducer(primary, secondary, cfg), nil
+}
+
+// secondaryBrokers returns the configured standby cluster brokers, none if
+// failover is not configured.
+func secondaryBrokers(cfg *config.KafkaConfig) []string {
+	var brokers []string
+	for _, broker := range cfg.SecondaryBrokers {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	return brokers
+}
+
+func (p *FailoverProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	return p.publish(func(producer TopicProducer) error {
+		return producer.PublishEvent(ctx, event)
+	})
+}
+
+func (p *FailoverProducer) PublishEventToTopic(ctx context.Context, topic string, event *models.Event) error {
+	return p.publish(func(producer TopicProducer) error {
+		return producer.PublishEventToTopic(ctx, topic, event)
+	})
+}
+
+// BroadcastEvent broadcasts event on the active cluster, publishing it once
+// through a producer that cannot broadcast.
+func (p *FailoverProducer) BroadcastEvent(ctx context.Context, event *models.Event) error {
+	return p.publish(func(producer TopicProducer) error {
+		if broadcaster, ok := producer.(BroadcastPublisher); ok {
+			return broadcaster.BroadcastEvent(ctx, event)
+		}
+		return producer.PublishEvent(ctx, event)
+	})
+}
+
+func (p *FailoverProducer) publish(send func(TopicProducer) error) error {
+	if p.usePrimary() {
+		err := send(p.primary)
+		if err == nil {
+			p.primarySucceeded()
+			return nil
+		}
+		// An oversized event fails on either cluster.
+		if errors.Is(err, ErrPayloadTooLarge) || !p.primaryFailed(err) {
+			return err
+		}
+	}
+	return send(p.secondary)
+}
+
+func (p *FailoverProducer) usePrimary() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status.Active == ClusterPrimary {
+		return true
+	}
+	if time.Since(p.lastProbe) >= p.failbackInterval {
+		p.lastProbe = time.Now()
+		return true
+	}
+	return false
+}
+
+func (p *FailoverProducer) primarySucceeded() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.ConsecutiveFailures = 0
+	if p.status.Active == ClusterSecondary {
+		p.status.Active = ClusterPrimary
+		p.status.Failbacks++
+		p.status.FailedOverAt = nil
+		p.logger.Info("Primary Kafka cluster recovered, failed back")
+	}
+}
+
+// primaryFailed records a failed publish to the primary and reports whether
+// the event should be sent to the secondary instead.
+func (p *FailoverProducer) primaryFailed(err error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.status.ConsecutiveFailures == 0 {
+		p.failingSince = now
+	}
+	p.status.ConsecutiveFailures++
+	if p.status.Active == ClusterSecondary {
+		return true
+	}
+	if p.status.ConsecutiveFailures < p.threshold || now.Sub(p.failingSince) < p.period {
+		return false
+	}
+
+	p.status.Active = ClusterSecondary
+	p.status.Failovers++
+	p.status.FailedOverAt = &now
+	p.lastProbe = now
+	p.logger.WithError(err).WithFields(logrus.Fields{
+		"consecutive_failures": p.status.ConsecutiveFailures,
+		"failing_for":          now.Sub(p.failingSince).String(),
+	}).Warn("Primary Kafka cluster unavailable, failing over to secondary")
+	return true
+}
+
+// ClusterStatus returns the active cluster and failover counters.
+func (p *FailoverProducer) ClusterStatus() ClusterStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := p.status
+	if status.FailedOverAt != nil {
+		failedOverAt := *status.FailedOverAt
+		status.FailedOverAt = &failedOverAt
+	}
+	return status
+}
+
+// PayloadStats merges the event size histograms of both clusters.
+func (p *FailoverProducer) PayloadStats() map[models.EventType]PayloadSizeHistogram {
+	var reports []map[models.EventType]PayloadSizeHistogram
+	for _, producer := range []TopicProducer{p.primary, p.secondary} {
+		if reporter, ok := producer.(PayloadStatsReporter); ok {
+			reports = append(reports, reporter.PayloadStats())
+		}
+	}
+	return mergePayloadStats(reports...)
+}
+
+func (p *FailoverProducer) Close() error {
+	primaryErr := p.primary.Close()
+	if err := p.secondary.Close(); err != nil {
+		return err
+	}
+	return primaryErr
+}
\ No newline at end of file
@@ -0,0 +1,188 @@
+package queue
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/proto"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/eventpb"
+)
+
+// OrderEventsStreamMethod is the gRPC method served by FeedServer.
+const OrderEventsStreamMethod = "/orders.events.v1.OrderEvents/Stream"
+
+// gRPC status codes returned by FeedServer.
+const (
+	grpcCodeInvalidArgument   = 3
+	grpcCodeResourceExhausted = 8
+	grpcCodeUnimplemented     = 12
+	grpcCodeInternal          = 13
+	grpcCodeUnavailable       = 14
+)
+
+// maxStreamRequestSize bounds the StreamRequest message read from a client.
+const maxStreamRequestSize = 64 << 10
+
+// FeedServer serves an EventFeed as the orders.events.v1.OrderEvents gRPC
+// service (api/proto/orders/events/v1/order_events.proto), streaming each
+// event as an EventEnvelope. It speaks the gRPC wire protocol directly, over
+// cleartext HTTP/2, so the service does not depend on a gRPC framework;
+// clients dial it with any gRPC library.
+type FeedServer struct {
+	feed      *EventFeed
+	codec     ProtobufCodec
+	done      chan struct{}
+	closeOnce sync.Once
+	logger    *logrus.Entry
+}
+
+func NewFeedServer(feed *EventFeed) *FeedServer {
+	return &FeedServer{
+		feed:   feed,
+		done:   make(chan struct{}),
+		logger: logrus.WithField("component", "feed_server"),
+	}
+}
+
+// Close ends the open streams with UNAVAILABLE, for clients to reconnect to
+// another instance. Call it before shutting down the HTTP server, which does
+// not wait for streams to end on their own.
+func (s *FeedServer) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// Handler returns the server as an HTTP handler accepting cleartext HTTP/2,
+// as gRPC clients dial it without TLS.
+func (s *FeedServer) Handler() http.Handler {
+	return h2c.NewHandler(s, &http2.Server{})
+}
+
+func (s *FeedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "only gRPC requests are served", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+
+	if r.URL.Path != OrderEventsStreamMethod {
+		finishStream(w, grpcCodeUnimplemented, "unknown method "+r.URL.Path)
+		return
+	}
+
+	filter, err := readStreamRequest(r.Body)
+	if err != nil {
+		finishStream(w, grpcCodeInvalidArgument, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		finishStream(w, grpcCodeInternal, "streaming is not supported")
+		return
+	}
+
+	events, unsubscribe := s.feed.Subscribe(filter)
+	defer unsubscribe()
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"types":       filter.Types,
+		"customer_id": filter.CustomerID,
+	})
+	logger.Info("Feed stream opened")
+	defer logger.Info("Feed stream closed")
+
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			finishStream(w, grpcCodeUnavailable, "server is shutting down")
+			return
+		case event, ok := <-events:
+			if !ok {
+				finishStream(w, grpcCodeResourceExhausted, "stream fell behind the feed")
+				return
+			}
+			data, err := s.codec.Marshal(event)
+			if err != nil {
+				logger.WithError(err).WithField("event_id", event.ID).Error("Failed to encode feed event")
+				continue
+			}
+			if _, err := w.Write(grpcFrame(data)); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// readStreamRequest reads the length-prefixed StreamRequest a client sends
+// to open a stream.
+func readStreamRequest(body io.Reader) (FeedFilter, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(body, prefix[:]); err != nil {
+		return FeedFilter{}, fmt.Errorf("failed to read request: %w", err)
+	}
+	if prefix[0] != 0 {
+		return FeedFilter{}, fmt.Errorf("compressed requests are not supported")
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxStreamRequestSize {
+		return FeedFilter{}, fmt.Errorf("request of %d bytes exceeds the limit of %d", size, maxStreamRequestSize)
+	}
+	message := make([]byte, size)
+	if _, err := io.ReadFull(body, message); err != nil {
+		return FeedFilter{}, fmt.Errorf("failed to read request: %w", err)
+	}
+
+	var request eventpb.StreamRequest
+	if err := proto.Unmarshal(message, &request); err != nil {
+		return FeedFilter{}, fmt.Errorf("invalid request: %w", err)
+	}
+	filter := FeedFilter{CustomerID: request.CustomerId}
+	for _, eventType := range request.Types {
+		filter.Types = append(filter.Types, models.EventType(eventType))
+	}
+	return filter, nil
+}
+
+// grpcFrame prefixes an uncompressed message with its length.
+func grpcFrame(message []byte) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// finishStream ends a stream with a gRPC status, sent in the trailers.
+func finishStream(w http.ResponseWriter, code int, message string) {
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", encodeGRPCMessage(message))
+	}
+}
+
+// encodeGRPCMessage percent-encodes a status message as gRPC requires.
+func encodeGRPCMessage(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
\ No newline at end of file
@@ -2,7 +2,10 @@ package queue
 
 import (
 	"context"
+
+	"github.com/IBM/sarama"
 	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/logger"
 )
 
 type Producer interface {
@@ -10,11 +13,117 @@ type Producer interface {
 	Close() error
 }
 
+type TopicPublisher interface {
+	PublishEventToTopic(ctx context.Context, topic string, event *models.Event) error
+}
+
+type TopicProducer interface {
+	Producer
+	TopicPublisher
+}
+
+// BroadcastPublisher is implemented by producers that can publish an event to
+// every partition of its topic, so each consumer in a group handles it.
+type BroadcastPublisher interface {
+	BroadcastEvent(ctx context.Context, event *models.Event) error
+}
+
 type Consumer interface {
 	Subscribe(ctx context.Context, handler EventHandler) error
+	Wait() error
 	Close() error
 }
 
+// DeadLetterConsumer is implemented by consumers that can republish
+// messages they fail to process to a dead-letter queue.
+type DeadLetterConsumer interface {
+	EnableDeadLetters(dlq *DeadLetterQueue)
+}
+
+// QuarantineConsumer is implemented by consumers that can attempt failing
+// messages again before dead-lettering them and skip quarantined messages.
+type QuarantineConsumer interface {
+	EnableQuarantine(quarantine *Quarantine)
+}
+
+// RetryConsumer is implemented by consumers that can retry failed messages
+// through tiered retry topics.
+type RetryConsumer interface {
+	EnableRetries(retries *RetryQueue)
+}
+
+// RateLimitedConsumer is implemented by consumers that can slow down to the
+// limits of a RateLimiter.
+type RateLimitedConsumer interface {
+	EnableRateLimit(limiter *RateLimiter)
+}
+
+// CrashReportingConsumer is implemented by consumers that can recover their
+// handler from panics and report them.
+type CrashReportingConsumer interface {
+	EnableCrashReports(crashes *logger.CrashReporter)
+}
+
+// TransactionalProducer is implemented by producers that can commit the
+// events published while handling a consumed message together with its
+// offset.
+type TransactionalProducer interface {
+	Transact(ctx context.Context, groupID string, message *sarama.ConsumerMessage, fn func(ctx context.Context) error) error
+}
+
+// TransactionalConsumer is implemented by consumers that can commit offsets
+// through a TransactionalProducer.
+type TransactionalConsumer interface {
+	EnableTransactions(producer TransactionalProducer)
+}
+
+type AssignmentReporter interface {
+	Assignment() PartitionAssignment
+}
+
+// PayloadStatsReporter is implemented by producers that record the encoded
+// size of the events they publish.
+type PayloadStatsReporter interface {
+	PayloadStats() map[models.EventType]PayloadSizeHistogram
+}
+
+// ClusterReporter is implemented by producers that can switch Kafka clusters.
+type ClusterReporter interface {
+	ClusterStatus() ClusterStatus
+}
+
+// LagReporter is implemented by consumers that track how far behind their
+// partitions they are, for readiness checks.
+type LagReporter interface {
+	Lag() ConsumerLag
+}
+
+// WatchdogReporter is implemented by consumers with a stall watchdog.
+type WatchdogReporter interface {
+	Watchdog() WatchdogStatus
+}
+
+// WorkerReporter is implemented by consumers that handle messages on a pool
+// of workers.
+type WorkerReporter interface {
+	Workers() ConsumerWorkers
+}
+
+// PausableConsumer is implemented by consumers that can stop fetching
+// partitions without leaving their group.
+type PausableConsumer interface {
+	Pause(topic string, partitions []int32) error
+	Resume(topic string, partitions []int32) error
+	Paused() map[string][]int32
+}
+
+// ClusterSwitchingConsumer is implemented by consumers that can move to the
+// secondary Kafka cluster and back while running.
+type ClusterSwitchingConsumer interface {
+	SwitchCluster(cluster string) error
+	Cluster() ConsumerCluster
+}
+
 type EventHandler interface {
 	HandleEvent(ctx context.Context, event *models.Event) error
 }
@@ -2,64 +2,279 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/sirupsen/logrus"
-	"order-processing-microservice/internal/models"
+	"golang.org/x/sync/errgroup"
 	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/logger"
+)
+
+const (
+	RebalanceRange             = "range"
+	RebalanceRoundRobin        = "roundrobin"
+	RebalanceSticky            = "sticky"
+	RebalanceCooperativeSticky = "cooperative-sticky"
 )
 
 type KafkaConsumer struct {
+	groupMu       sync.Mutex
 	consumerGroup sarama.ConsumerGroup
-	topic         string
+	topics        []string
 	groupID       string
+	region        string
 	handler       EventHandler
 	logger        *logrus.Entry
+	assignment    *assignmentTracker
+	lag           *lagTracker
+	pauses        *pauseState
+	deadLetters   *DeadLetterQueue
+	retries       *RetryQueue
+	quarantine    *Quarantine
+	crashes       *logger.CrashReporter
+	txn           TransactionalProducer
+	avro          *AvroCodec
+	limiter       *RateLimiter
+	watchdog      *consumerWatchdog
+	clusters      *clusterSwitch
+	rebuilt       chan struct{}
 	cancel        context.CancelFunc
-	wg            sync.WaitGroup
+	done          chan struct{}
+	err           error
+
+	// With manualCommit, marked offsets are committed every commitBatch
+	// messages and at least every commitInterval, instead of by sarama.
+	manualCommit   bool
+	commitBatch    int
+	commitInterval time.Duration
+
+	// workers process the messages of each partition concurrently, keeping
+	// the messages of one key in order. With minWorkers set below workers,
+	// between minWorkers and workers of them run at once, scaled by a
+	// WorkerScaler aiming at targetLatency per message.
+	workers       int
+	minWorkers    int
+	targetLatency time.Duration
+	workerGauge   *workerGauge
 }
 
 type consumerGroupHandler struct {
-	handler EventHandler
-	logger  *logrus.Entry
+	handler     EventHandler
+	group       func() sarama.ConsumerGroup
+	groupID     string
+	region      string
+	assignment  *assignmentTracker
+	lag         *lagTracker
+	pauses      *pauseState
+	deadLetters *DeadLetterQueue
+	retries     *RetryQueue
+	quarantine  *Quarantine
+	crashes     *logger.CrashReporter
+	txn         TransactionalProducer
+	avro        *AvroCodec
+	limiter     *RateLimiter
+	logger      *logrus.Entry
+
+	manualCommit   bool
+	commitBatch    int
+	commitInterval time.Duration
+	workers        int
+	minWorkers     int
+	targetLatency  time.Duration
+	workerGauge    *workerGauge
 }
 
 func NewKafkaConsumer(cfg *config.KafkaConfig) (*KafkaConsumer, error) {
+	return NewKafkaConsumerForTopics(cfg, []string{cfg.OrderTopic})
+}
+
+// NewBalanceStrategy returns the consumer group rebalance strategy by name:
+// range, roundrobin or sticky (the default), which keeps partitions with their
+// owners across rebalances. cooperative-sticky is rejected: sarama only
+// implements the eager rebalance protocol, so every rebalance still revokes
+// all partitions of the group before reassigning them.
+func NewBalanceStrategy(name string) (sarama.BalanceStrategy, error) {
+	switch name {
+	case RebalanceRange:
+		return sarama.NewBalanceStrategyRange(), nil
+	case RebalanceRoundRobin:
+		return sarama.NewBalanceStrategyRoundRobin(), nil
+	case "", RebalanceSticky:
+		return sarama.NewBalanceStrategySticky(), nil
+	case RebalanceCooperativeSticky:
+		return nil, fmt.Errorf("rebalance strategy %q is not supported by the Kafka client, use %q", name, RebalanceSticky)
+	default:
+		return nil, fmt.Errorf("unsupported rebalance strategy %q", name)
+	}
+}
+
+func NewKafkaConsumerForTopics(cfg *config.KafkaConfig, topics []string) (*KafkaConsumer, error) {
+	strategy, err := NewBalanceStrategy(cfg.RebalanceStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	// The other strategies are offered as fallbacks, so a group can switch
+	// strategy in a rolling deploy: it keeps using one every member supports
+	// until all of them prefer the new one.
+	strategies := []sarama.BalanceStrategy{strategy}
+	for _, name := range []string{RebalanceSticky, RebalanceRoundRobin, RebalanceRange} {
+		if name != strategy.Name() {
+			fallback, _ := NewBalanceStrategy(name)
+			strategies = append(strategies, fallback)
+		}
+	}
+
 	saramaConfig := sarama.NewConfig()
-	saramaConfig.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	saramaConfig.Consumer.Group.Rebalance.GroupStrategies = strategies
 	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	if cfg.InitialOffset == "newest" {
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+	}
 	saramaConfig.Consumer.Group.Session.Timeout = time.Duration(cfg.SessionTimeout) * time.Millisecond
 	saramaConfig.Consumer.Group.Heartbeat.Interval = time.Second * 3
 	saramaConfig.Consumer.MaxProcessingTime = time.Second * 30
 	saramaConfig.Consumer.Return.Errors = true
+	// Skip events of aborted transactions from transactional producers.
+	saramaConfig.Consumer.IsolationLevel = sarama.ReadCommitted
 
+	saramaConfig.Consumer.Offsets.AutoCommit.Enable = cfg.EnableAutoCommit
 	if cfg.EnableAutoCommit {
-		saramaConfig.Consumer.Offsets.AutoCommit.Enable = true
 		saramaConfig.Consumer.Offsets.AutoCommit.Interval = time.Duration(cfg.CommitInterval) * time.Millisecond
 	}
 
-	consumerGroup, err := sarama.NewConsumerGroup(cfg.Brokers, cfg.GroupID, saramaConfig)
+	secondary := secondaryBrokers(cfg)
+	newGroup := func(cluster string) (sarama.ConsumerGroup, error) {
+		if cluster == ClusterSecondary {
+			return sarama.NewConsumerGroup(secondary, cfg.GroupID, saramaConfig)
+		}
+		return sarama.NewConsumerGroup(cfg.Brokers, cfg.GroupID, saramaConfig)
+	}
+
+	consumerGroup, err := newGroup(ClusterPrimary)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kafka consumer group: %w", err)
 	}
 
+	consumer := NewKafkaConsumerWithGroup(consumerGroup, cfg, topics)
+	if len(secondary) > 0 {
+		consumer.EnableClusterSwitch(newGroup)
+	}
+	if cfg.StallTimeout > 0 {
+		// Rebuilds connect to whichever cluster the consumer was switched to.
+		consumer.EnableWatchdog(time.Duration(cfg.StallTimeout)*time.Second, func() (sarama.ConsumerGroup, error) {
+			return newGroup(consumer.activeCluster())
+		})
+	}
+	consumer.logger.Info("Kafka consumer created successfully")
+	return consumer, nil
+}
+
+func NewKafkaConsumerWithGroup(consumerGroup sarama.ConsumerGroup, cfg *config.KafkaConfig, topics []string) *KafkaConsumer {
 	logger := logrus.WithFields(logrus.Fields{
 		"component": "kafka_consumer",
 		"group_id":  cfg.GroupID,
-		"topic":     cfg.OrderTopic,
+		"topics":    topics,
 	})
-	logger.Info("Kafka consumer created successfully")
+
+	commitInterval := time.Duration(cfg.CommitInterval) * time.Millisecond
+	if commitInterval <= 0 {
+		commitInterval = time.Second
+	}
+	targetLatency := time.Duration(cfg.ConsumerTargetLatency) * time.Millisecond
+	if targetLatency <= 0 {
+		targetLatency = 200 * time.Millisecond
+	}
 
 	return &KafkaConsumer{
-		consumerGroup: consumerGroup,
-		topic:         cfg.OrderTopic,
-		groupID:       cfg.GroupID,
-		logger:        logger,
-	}, nil
+		consumerGroup:  consumerGroup,
+		topics:         topics,
+		groupID:        cfg.GroupID,
+		region:         cfg.Region,
+		manualCommit:   !cfg.EnableAutoCommit,
+		commitBatch:    max(cfg.CommitBatchSize, 1),
+		commitInterval: commitInterval,
+		workers:        max(cfg.ConsumerWorkers, 1),
+		minWorkers:     cfg.ConsumerMinWorkers,
+		targetLatency:  targetLatency,
+		workerGauge:    newWorkerGauge(),
+		assignment:     newAssignmentTracker(cfg.GroupID, time.Duration(cfg.EmptyAssignmentThreshold)*time.Second, logger),
+		lag:            newLagTracker(int64(cfg.ReadyMaxLag), logger),
+		pauses:         newPauseState(),
+		avro:           newAvroDecoder(cfg),
+		logger:         logger,
+	}
+}
+
+// EnableDeadLetters republishes messages that fail to decode or whose handler
+// fails to dlq instead of skipping them. It must be called before Subscribe.
+func (c *KafkaConsumer) EnableDeadLetters(dlq *DeadLetterQueue) {
+	c.deadLetters = dlq
+}
+
+// EnableRetries republishes messages whose handler fails to the retry topics
+// of retries, and subscribes to those topics too. Messages that exhaust them
+// go to the dead-letter queue, if enabled. It must be called before Subscribe.
+func (c *KafkaConsumer) EnableRetries(retries *RetryQueue) {
+	c.retries = retries
+	c.topics = append(c.topics, retries.Topics(c.topics)...)
+}
+
+// EnableQuarantine attempts a failing message again, as set by quarantine,
+// before retrying or dead-lettering it, and skips messages that were
+// dead-lettered when they are delivered again. It must be called before
+// Subscribe, together with EnableDeadLetters.
+func (c *KafkaConsumer) EnableQuarantine(quarantine *Quarantine) {
+	c.quarantine = quarantine
+}
+
+// EnableCrashReports recovers the handler from panics, failing the message
+// as the handler would have and recording a crash report of the event with
+// crashes. Without it a panicking handler takes the consumer down. It must be
+// called before Subscribe.
+func (c *KafkaConsumer) EnableCrashReports(crashes *logger.CrashReporter) {
+	c.crashes = crashes
+}
+
+// EnableTransactions commits the offset of each message in a transaction of
+// producer together with the events its handler publishes through producer,
+// instead of marking it on the session. Transactions commit offsets one
+// message at a time, so messages are then processed serially. It must be
+// called before Subscribe.
+func (c *KafkaConsumer) EnableTransactions(producer TransactionalProducer) {
+	c.txn = producer
+	c.workers = 1
+}
+
+// EnableRateLimit holds messages back until limiter lets their handler run,
+// which slows down consumption of the claims instead of failing messages. It
+// must be called before Subscribe.
+func (c *KafkaConsumer) EnableRateLimit(limiter *RateLimiter) {
+	c.limiter = limiter
+}
+
+// EnableWatchdog rebuilds the consumer group client with newGroup whenever
+// the consumer stalls: its unpaused partitions have lag, but no message was
+// processed for stallTimeout. It must be called before Subscribe.
+func (c *KafkaConsumer) EnableWatchdog(stallTimeout time.Duration, newGroup func() (sarama.ConsumerGroup, error)) {
+	c.watchdog = &consumerWatchdog{timeout: stallTimeout, newGroup: newGroup}
+	if c.rebuilt == nil {
+		c.rebuilt = make(chan struct{}, 1)
+	}
+}
+
+// group returns the current consumer group client, which the watchdog may
+// replace.
+func (c *KafkaConsumer) group() sarama.ConsumerGroup {
+	c.groupMu.Lock()
+	defer c.groupMu.Unlock()
+
+	return c.consumerGroup
 }
 
 func (c *KafkaConsumer) Subscribe(ctx context.Context, handler EventHandler) error {
@@ -69,54 +284,334 @@ func (c *KafkaConsumer) Subscribe(ctx context.Context, handler EventHandler) err
 	c.cancel = cancel
 
 	groupHandler := &consumerGroupHandler{
-		handler: handler,
-		logger:  c.logger,
+		handler:        handler,
+		group:          c.group,
+		groupID:        c.groupID,
+		region:         c.region,
+		assignment:     c.assignment,
+		lag:            c.lag,
+		pauses:         c.pauses,
+		deadLetters:    c.deadLetters,
+		retries:        c.retries,
+		quarantine:     c.quarantine,
+		crashes:        c.crashes,
+		txn:            c.txn,
+		avro:           c.avro,
+		limiter:        c.limiter,
+		manualCommit:   c.manualCommit,
+		commitBatch:    c.commitBatch,
+		commitInterval: c.commitInterval,
+		workers:        c.workers,
+		minWorkers:     c.minWorkers,
+		targetLatency:  c.targetLatency,
+		workerGauge:    c.workerGauge,
+		logger:         c.logger,
 	}
 
-	c.wg.Add(2)
+	group, ctx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		return c.consume(ctx, groupHandler)
+	})
+	group.Go(func() error {
+		return c.watchErrors(ctx)
+	})
+	group.Go(func() error {
+		c.monitorAssignment(ctx)
+		return nil
+	})
+	if c.watchdog != nil {
+		group.Go(func() error {
+			c.watch(ctx)
+			return nil
+		})
+	}
 
+	c.done = make(chan struct{})
 	go func() {
-		defer c.wg.Done()
-		for {
+		c.err = group.Wait()
+		close(c.done)
+	}()
+
+	c.logger.Info("Started consuming messages")
+	return nil
+}
+
+func (c *KafkaConsumer) consume(ctx context.Context, handler sarama.ConsumerGroupHandler) error {
+	for {
+		group := c.group()
+		err := group.Consume(ctx, c.topics, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if c.group() != group {
+			// Rebuilt by the watchdog; rejoin with the new client.
+			continue
+		}
+		if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			return nil
+		}
+		if err != nil {
+			if isFatalConsumerError(err) {
+				return fmt.Errorf("fatal error consuming messages: %w", err)
+			}
+			c.logger.WithError(err).Error("Error consuming messages")
 			select {
 			case <-ctx.Done():
-				return
-			default:
-				if err := c.consumerGroup.Consume(ctx, []string{c.topic}, groupHandler); err != nil {
-					c.logger.WithError(err).Error("Error consuming messages")
-					time.Sleep(time.Second)
-				}
+				return nil
+			case <-time.After(time.Second):
 			}
 		}
-	}()
+	}
+}
 
-	go func() {
-		defer c.wg.Done()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case err := <-c.consumerGroup.Errors():
-				if err != nil {
-					c.logger.WithError(err).Error("Consumer group error")
+func (c *KafkaConsumer) watchErrors(ctx context.Context) error {
+	group := c.group()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.rebuilt:
+			group = c.group()
+		case err, ok := <-group.Errors():
+			if !ok {
+				if c.group() != group {
+					group = c.group()
+					continue
 				}
+				return nil
 			}
+			if err == nil {
+				continue
+			}
+			if isFatalConsumerError(err) {
+				return fmt.Errorf("fatal consumer group error: %w", err)
+			}
+			c.logger.WithError(err).Error("Consumer group error")
 		}
-	}()
+	}
+}
 
-	c.logger.Info("Started consuming messages")
+func (c *KafkaConsumer) monitorAssignment(ctx context.Context) {
+	ticker := time.NewTicker(assignmentCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.assignment.check()
+		}
+	}
+}
+
+// watch checks every interval whether the consumer stalled, and if so
+// rebuilds its consumer group client.
+func (c *KafkaConsumer) watch(ctx context.Context) {
+	ticker := time.NewTicker(c.watchdog.checkInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			lag, behind := c.unpausedLag()
+			idle := time.Since(c.lag.lastProgress())
+			stalled := behind > 0 && idle >= c.watchdog.timeout
+			c.watchdog.setStalled(stalled)
+			if stalled {
+				c.selfHeal(lag, behind, idle)
+			}
+		}
+	}
+}
+
+// unpausedLag returns the consumer's lag and the part of its total on
+// partitions that are not paused, which are expected to make progress.
+func (c *KafkaConsumer) unpausedLag() (ConsumerLag, int64) {
+	lag := c.lag.snapshot()
+	paused := c.Paused()
+
+	var behind int64
+	for topic, partitions := range lag.Partitions {
+		for partition, partitionLag := range partitions {
+			if !slices.Contains(paused[topic], partition) {
+				behind += partitionLag
+			}
+		}
+	}
+	return lag, behind
+}
+
+// selfHeal logs what the stalled consumer was doing, then replaces its
+// consumer group client with a new one and closes the old one, which ends
+// the current session; consume then rejoins the group with the new client.
+// If the new client cannot be created the old one is kept and the next check
+// tries again.
+func (c *KafkaConsumer) selfHeal(lag ConsumerLag, behind int64, idle time.Duration) {
+	c.logger.WithFields(logrus.Fields{
+		"idle":          idle.String(),
+		"lag":           behind,
+		"partition_lag": lag.Partitions,
+		"assignment":    c.assignment.snapshot().Partitions,
+		"paused":        c.Paused(),
+	}).Warn("Consumer stalled with lag outstanding, rebuilding consumer group client")
+
+	group, err := c.watchdog.newGroup()
+	if err != nil {
+		c.watchdog.healed(false)
+		c.logger.WithError(err).Error("Failed to rebuild consumer group client")
+		return
+	}
+
+	c.replaceGroup(group)
+	c.watchdog.healed(true)
+	c.logger.Info("Consumer group client rebuilt")
+}
+
+// replaceGroup replaces the consumer group client and closes the old one,
+// which ends the current session; consume then rejoins the group with the new
+// client.
+func (c *KafkaConsumer) replaceGroup(group sarama.ConsumerGroup) {
+	c.groupMu.Lock()
+	old := c.consumerGroup
+	c.consumerGroup = group
+	c.groupMu.Unlock()
+	c.lag.touch()
+
+	select {
+	case c.rebuilt <- struct{}{}:
+	default:
+	}
+	if err := old.Close(); err != nil {
+		c.logger.WithError(err).Warn("Failed to close replaced consumer group client")
+	}
+}
+
+// Watchdog reports the stall watchdog; it is disabled unless EnableWatchdog
+// was called.
+func (c *KafkaConsumer) Watchdog() WatchdogStatus {
+	if c.watchdog == nil {
+		return WatchdogStatus{}
+	}
+
+	var idleSince time.Time
+	if _, behind := c.unpausedLag(); behind > 0 {
+		idleSince = c.lag.lastProgress()
+	}
+	return c.watchdog.status(idleSince)
+}
+
+// Workers reports how many handlers the consumer may run at once on each
+// claimed partition.
+func (c *KafkaConsumer) Workers() ConsumerWorkers {
+	return c.workerGauge.snapshot()
+}
+
+func (c *KafkaConsumer) Assignment() PartitionAssignment {
+	return c.assignment.snapshot()
+}
+
+func (c *KafkaConsumer) Lag() ConsumerLag {
+	return c.lag.snapshot()
+}
+
+// Pause stops fetching partitions of topic without leaving the group, so no
+// rebalance is triggered; messages already fetched are still processed. An
+// empty topic pauses every consumed topic, and no partitions every partition
+// of it, including partitions assigned later.
+func (c *KafkaConsumer) Pause(topic string, partitions []int32) error {
+	selected, err := selectPartitions(c.topics, c.assignment.snapshot().Partitions, topic, partitions)
+	if err != nil {
+		return err
+	}
+	for t := range selected {
+		c.pauses.pause(t, partitions)
+	}
+	c.group().Pause(selected)
+
+	c.logger.WithFields(logrus.Fields{
+		"topic":      topic,
+		"partitions": partitions,
+	}).Warn("Consumption paused")
 	return nil
 }
 
+// Resume resumes partitions paused by Pause. A topic paused as a whole can
+// only be resumed as a whole.
+func (c *KafkaConsumer) Resume(topic string, partitions []int32) error {
+	selected, err := selectPartitions(c.topics, c.assignment.snapshot().Partitions, topic, partitions)
+	if err != nil {
+		return err
+	}
+
+	resumed := make(map[string][]int32, len(selected))
+	for t, claimed := range selected {
+		if resumeErr := c.pauses.resume(t, partitions); resumeErr != nil {
+			err = resumeErr
+			continue
+		}
+		resumed[t] = claimed
+	}
+	c.group().Resume(resumed)
+	// Resumed partitions get a full stall timeout to catch up.
+	c.lag.touch()
+	if err != nil {
+		return err
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"topic":      topic,
+		"partitions": partitions,
+	}).Info("Consumption resumed")
+	return nil
+}
+
+// Paused returns the assigned partitions that are paused.
+func (c *KafkaConsumer) Paused() map[string][]int32 {
+	return c.pauses.resolve(c.assignment.snapshot().Partitions)
+}
+
+var fatalConsumerErrors = []error{
+	sarama.ErrTopicAuthorizationFailed,
+	sarama.ErrGroupAuthorizationFailed,
+	sarama.ErrClusterAuthorizationFailed,
+	sarama.ErrSASLAuthenticationFailed,
+}
+
+// isFatalConsumerError reports errors that retrying cannot fix, such as
+// missing ACLs or an invalid configuration.
+func isFatalConsumerError(err error) bool {
+	for _, fatal := range fatalConsumerErrors {
+		if errors.Is(err, fatal) {
+			return true
+		}
+	}
+
+	var configErr sarama.ConfigurationError
+	return errors.As(err, &configErr)
+}
+
+// Wait blocks until the consumer stops and returns the fatal error that
+// stopped it, or nil after Close.
+func (c *KafkaConsumer) Wait() error {
+	if c.done == nil {
+		return nil
+	}
+	<-c.done
+	return c.err
+}
+
 func (c *KafkaConsumer) Close() error {
 	if c.cancel != nil {
 		c.cancel()
 	}
 
-	c.wg.Wait()
+	c.Wait()
 
-	if c.consumerGroup != nil {
-		if err := c.consumerGroup.Close(); err != nil {
+	if group := c.group(); group != nil {
+		if err := group.Close(); err != nil {
 			c.logger.WithError(err).Error("Failed to close consumer group")
 			return fmt.Errorf("failed to close consumer group: %w", err)
 		}
@@ -125,17 +620,42 @@ func (c *KafkaConsumer) Close() error {
 	return nil
 }
 
-func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
 	h.logger.Info("Consumer group session started")
+	h.assignment.assign(session.Claims())
+	h.lag.join()
 	return nil
 }
 
 func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
 	h.logger.Info("Consumer group session ended")
+	h.assignment.revoke()
+	h.lag.release()
 	return nil
 }
 
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	h.lag.claim(claim)
+	if h.pauses.isPaused(claim.Topic(), claim.Partition()) {
+		h.group().Pause(map[string][]int32{claim.Topic(): {claim.Partition()}})
+	}
+
+	var committer *offsetCommitter
+	var commitTick <-chan time.Time
+	if h.manualCommit {
+		committer = &offsetCommitter{session: session, batch: h.commitBatch}
+		defer committer.commit()
+		ticker := time.NewTicker(h.commitInterval)
+		defer ticker.Stop()
+		commitTick = ticker.C
+	}
+
+	defer h.workerGauge.remove(claim.Topic(), claim.Partition())
+	if h.workers > 1 {
+		return h.consumeConcurrently(session, claim, committer, commitTick)
+	}
+	h.workerGauge.set(claim.Topic(), claim.Partition(), 1)
+
 	for {
 		select {
 		case message := <-claim.Messages():
@@ -143,16 +663,14 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 				return nil
 			}
 
-			if err := h.processMessage(session.Context(), message); err != nil {
-				h.logger.WithFields(logrus.Fields{
-					"partition": message.Partition,
-					"offset":    message.Offset,
-					"error":     err,
-				}).Error("Failed to process message")
-				continue
+			outcome := h.handleMessage(session.Context(), message)
+			if outcome == outcomeInterrupted {
+				return nil
 			}
+			h.complete(session, message, outcome, committer)
 
-			session.MarkMessage(message, "")
+		case <-commitTick:
+			committer.commit()
 
 		case <-session.Context().Done():
 			return nil
@@ -160,22 +678,279 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 	}
 }
 
+// messageOutcome is the result of handling one message.
+type messageOutcome int
+
+const (
+	// outcomeInterrupted: the session ended before a retried message was due.
+	outcomeInterrupted messageOutcome = iota
+	outcomeProcessed
+	// outcomeFailed: the handler failed and the message was retried or
+	// dead-lettered.
+	outcomeFailed
+	// outcomeUnmarked: the handler failed and, with no retry or dead-letter
+	// queue for it, the message is skipped and left unmarked.
+	outcomeUnmarked
+)
+
+const (
+	// handOffBackoff is the first delay before a failed message whose retry
+	// or dead-letter publish failed is handed off again; it doubles up to
+	// maxHandOffBackoff.
+	handOffBackoff    = 250 * time.Millisecond
+	maxHandOffBackoff = 30 * time.Second
+)
+
+// handleMessage processes message once it is due, retrying or dead-lettering
+// it when the handler fails.
+func (h *consumerGroupHandler) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) messageOutcome {
+	if !h.waitUntilDue(ctx, message) {
+		return outcomeInterrupted
+	}
+	key := quarantineKey(h.groupID, message)
+	if h.quarantine != nil && h.quarantine.skip(ctx, key) {
+		return outcomeFailed
+	}
+	release, ok := h.waitForLimit(ctx)
+	if !ok {
+		return outcomeInterrupted
+	}
+
+	err := h.process(ctx, message)
+	release()
+	if h.quarantine != nil {
+		failed := false
+		for err != nil && !errors.Is(err, errEventDecode) && h.quarantine.retry(ctx, key, err) {
+			failed = true
+			err = h.process(ctx, message)
+		}
+		if err != nil && ctx.Err() != nil {
+			return outcomeInterrupted
+		}
+		if err == nil && failed {
+			h.quarantine.recovered(ctx, key)
+		}
+	}
+	if err == nil {
+		return outcomeProcessed
+	}
+	h.logger.WithFields(logrus.Fields{
+		"partition": message.Partition,
+		"offset":    message.Offset,
+		"error":     err,
+	}).Error("Failed to process message")
+	return h.handOff(ctx, message, err)
+}
+
+// handOff retries or dead-letters a message that failed with cause. A publish
+// that fails is attempted again after a growing delay: marking the message
+// would lose it, and ending the claim would end the session of every
+// partition and rebalance the group onto the same message. The partition
+// waits meanwhile; the others keep consuming.
+func (h *consumerGroupHandler) handOff(ctx context.Context, message *sarama.ConsumerMessage, cause error) messageOutcome {
+	delay := handOffBackoff
+	for {
+		handedOff, err := h.handleFailure(ctx, message, cause)
+		if err == nil {
+			if !handedOff {
+				return outcomeUnmarked
+			}
+			return outcomeFailed
+		}
+		h.logger.WithFields(logrus.Fields{
+			"partition": message.Partition,
+			"offset":    message.Offset,
+			"error":     err,
+			"retry_in":  delay,
+		}).Error("Failed to hand off failed message")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return outcomeInterrupted
+		case <-timer.C:
+		}
+		delay = min(2*delay, maxHandOffBackoff)
+	}
+}
+
+// complete records a handled message as processed and marks it consumed
+// unless it was left unmarked.
+func (h *consumerGroupHandler) complete(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage, outcome messageOutcome, committer *offsetCommitter) {
+	h.lag.processed(message)
+	if outcome == outcomeUnmarked {
+		return
+	}
+	if h.markConsumed(session, message, outcome == outcomeFailed) && committer != nil {
+		committer.marked()
+	}
+}
+
+// process handles message, in a transaction that also commits its offset
+// when transactions are enabled.
+func (h *consumerGroupHandler) process(ctx context.Context, message *sarama.ConsumerMessage) error {
+	if h.txn == nil {
+		return h.processMessage(ctx, message)
+	}
+	return h.txn.Transact(ctx, h.groupID, message, func(ctx context.Context) error {
+		return h.processMessage(ctx, message)
+	})
+}
+
+// markConsumed records message as consumed, and reports whether it was marked
+// on the session. With transactions the offset of a processed message is
+// already committed; one that failed and was retried or dead-lettered is
+// committed in a transaction of its own.
+func (h *consumerGroupHandler) markConsumed(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage, failed bool) bool {
+	if h.txn == nil {
+		session.MarkMessage(message, "")
+		return true
+	}
+	if !failed {
+		return false
+	}
+	if err := h.txn.Transact(session.Context(), h.groupID, message, nil); err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"partition": message.Partition,
+			"offset":    message.Offset,
+			"error":     err,
+		}).Error("Failed to commit offset of failed message")
+	}
+	return false
+}
+
+// offsetCommitter commits the offsets marked on a session once batch messages
+// are marked, for consumers with auto-commit disabled.
+type offsetCommitter struct {
+	session sarama.ConsumerGroupSession
+	batch   int
+	pending int
+}
+
+func (c *offsetCommitter) marked() {
+	c.pending++
+	if c.pending >= c.batch {
+		c.commit()
+	}
+}
+
+func (c *offsetCommitter) commit() {
+	if c.pending == 0 {
+		return
+	}
+	c.session.Commit()
+	c.pending = 0
+}
+
+// waitUntilDue blocks until a message from a retry topic is due, and reports
+// false if the session ended first.
+func (h *consumerGroupHandler) waitUntilDue(ctx context.Context, message *sarama.ConsumerMessage) bool {
+	if h.retries == nil {
+		return true
+	}
+	delay := h.retries.Delay(message)
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// waitForLimit blocks until the rate limiter lets a handler run and returns
+// the function releasing its slot, or reports false if the session ended
+// first.
+func (h *consumerGroupHandler) waitForLimit(ctx context.Context) (func(), bool) {
+	if h.limiter == nil {
+		return func() {}, true
+	}
+	if err := h.limiter.Wait(ctx); err != nil {
+		return nil, false
+	}
+	release, err := h.limiter.Acquire(ctx)
+	if err != nil {
+		return nil, false
+	}
+	return release, true
+}
+
+// handleFailure retries or dead-letters a message that failed with cause, and
+// reports whether it did; with neither configured for the message it is
+// skipped. The error is that of a failed publish. Messages that cannot be
+// decoded are never retried.
+func (h *consumerGroupHandler) handleFailure(ctx context.Context, message *sarama.ConsumerMessage, cause error) (bool, error) {
+	if h.retries != nil && !errors.Is(cause, errEventDecode) {
+		scheduled, err := h.retries.Publish(ctx, message, cause)
+		if err != nil {
+			return false, fmt.Errorf("failed to schedule message retry: %w", err)
+		}
+		if scheduled {
+			return true, nil
+		}
+	}
+
+	if h.deadLetters == nil {
+		h.logger.WithFields(logrus.Fields{
+			"partition": message.Partition,
+			"offset":    message.Offset,
+		}).Warn("Skipping failed message; no dead-letter queue is configured")
+		return false, nil
+	}
+	if err := h.deadLetters.Publish(ctx, h.groupID, message, cause); err != nil {
+		return false, fmt.Errorf("failed to dead-letter message: %w", err)
+	}
+	if h.quarantine != nil {
+		h.quarantine.quarantine(ctx, quarantineKey(h.groupID, message))
+	}
+	return true, nil
+}
+
 func (h *consumerGroupHandler) processMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
-	var event models.Event
-	if err := json.Unmarshal(message.Value, &event); err != nil {
+	event, err := decodeMessage(message, h.avro)
+	if err != nil {
 		h.logger.WithError(err).Error("Failed to unmarshal event")
-		return fmt.Errorf("failed to unmarshal event: %w", err)
+		return fmt.Errorf("%w: %v", errEventDecode, err)
 	}
 
-	h.logger.WithFields(logrus.Fields{
+	if h.region != "" && event.Region != "" && event.Region != h.region {
+		h.logger.WithFields(logrus.Fields{
+			"event_id":     event.ID,
+			"event_type":   event.Type,
+			"event_region": event.Region,
+		}).Debug("Ignoring event from foreign region")
+		return nil
+	}
+
+	// The handler runs in a new span of the publisher's trace, and the events
+	// it publishes carry the trace on.
+	tc := messageTraceContext(message)
+	ctx = WithTraceContext(ctx, tc)
+	logger := h.logger.WithFields(tc.LogFields())
+
+	logger.WithFields(logrus.Fields{
 		"event_id":   event.ID,
 		"event_type": event.Type,
 		"partition":  message.Partition,
 		"offset":     message.Offset,
 	}).Info("Processing event")
 
-	if err := h.handler.HandleEvent(ctx, &event); err != nil {
-		h.logger.WithFields(logrus.Fields{
+	err = handleReporting(ctx, h.handler, event, h.crashes, logrus.Fields{
+		"consumer_group": h.groupID,
+		"topic":          message.Topic,
+		"partition":      message.Partition,
+		"offset":         message.Offset,
+		"key":            string(message.Key),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
 			"event_id":   event.ID,
 			"event_type": event.Type,
 			"error":      err,
@@ -183,7 +958,7 @@ func (h *consumerGroupHandler) processMessage(ctx context.Context, message *sara
 		return fmt.Errorf("handler failed to process event: %w", err)
 	}
 
-	h.logger.WithFields(logrus.Fields{
+	logger.WithFields(logrus.Fields{
 		"event_id":   event.ID,
 		"event_type": event.Type,
 	}).Info("Event processed successfully")
@@ -2,22 +2,76 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/sirupsen/logrus"
 	"order-processing-microservice/internal/models"
 	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/requestctx"
 )
 
 type KafkaProducer struct {
-	producer sarama.SyncProducer
-	topic    string
-	logger   *logrus.Entry
+	producer       sarama.SyncProducer
+	client         sarama.Client
+	router         *TopicRouter
+	migrationTopic string
+	migrationPhase string
+	region         string
+	ceModes        map[string]string
+	ceSource       string
+	codec          Codec
+	key            KeyFunc
+	maxBytes       int
+	sizes          *payloadSizeRecorder
+	publishTimeout time.Duration
+	transactional  bool
+	txnMu          sync.Mutex
+	logger         *logrus.Entry
 }
 
+// txnContextKey marks a context as running inside a Transact call; the
+// kafkaTxn of the call is stored under it.
+type txnContextKey struct{}
+
+// kafkaTxn is the transaction of a Transact call.
+type kafkaTxn struct {
+	producer    *KafkaProducer
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// AfterCommit runs fn once the Kafka transaction ctx runs in, if any, is
+// committed, and drops it if the transaction is aborted. Outside a
+// transaction fn runs at once.
+func AfterCommit(ctx context.Context, fn func()) {
+	txn, ok := ctx.Value(txnContextKey{}).(*kafkaTxn)
+	if !ok {
+		fn()
+		return
+	}
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	txn.afterCommit = append(txn.afterCommit, fn)
+}
+
+// ErrPayloadTooLarge is returned when an encoded event exceeds
+// KafkaConfig.MaxMessageBytes; the event is not sent.
+var ErrPayloadTooLarge = errors.New("event payload too large")
+
+// ErrPublishTimeout is returned when the broker has not acknowledged an event
+// within the publish timeout or before the context is done. The event may
+// still be published afterwards.
+var ErrPublishTimeout = errors.New("publish timed out")
+
+// ErrBrokerUnavailable is returned when the broker could not be reached or
+// rejected an event.
+var ErrBrokerUnavailable = errors.New("broker unavailable")
+
 func NewKafkaProducer(cfg *config.KafkaConfig) (*KafkaProducer, error) {
 	saramaConfig := sarama.NewConfig()
 	saramaConfig.Producer.Return.Successes = true
@@ -25,36 +79,183 @@ func NewKafkaProducer(cfg *config.KafkaConfig) (*KafkaProducer, error) {
 	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
 	saramaConfig.Producer.Retry.Max = cfg.RetryAttempts
 	saramaConfig.Producer.Retry.Backoff = time.Millisecond * 250
-	saramaConfig.Producer.Partitioner = sarama.NewRandomPartitioner
 	saramaConfig.Producer.Compression = sarama.CompressionSnappy
 	saramaConfig.Producer.Flush.Frequency = time.Millisecond * 500
+	if cfg.MaxMessageBytes > 0 {
+		saramaConfig.Producer.MaxMessageBytes = cfg.MaxMessageBytes
+	}
+	if cfg.Idempotent || cfg.TransactionalID != "" {
+		saramaConfig.Producer.Idempotent = true
+		saramaConfig.Net.MaxOpenRequests = 1
+	}
+	if cfg.TransactionalID != "" {
+		saramaConfig.Producer.Transaction.ID = cfg.TransactionalID
+	}
+
+	partitioner, err := NewPartitioner(cfg.Partitioner)
+	if err != nil {
+		return nil, err
+	}
+	saramaConfig.Producer.Partitioner = withExplicitPartitions(partitioner)
 
-	producer, err := sarama.NewSyncProducer(cfg.Brokers, saramaConfig)
+	client, err := sarama.NewClient(cfg.Brokers, saramaConfig)
 	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
 	}
 
-	logger := logrus.WithField("component", "kafka_producer")
-	logger.Info("Kafka producer created successfully")
+	kafkaProducer, err := NewKafkaProducerWithClient(producer, client, cfg)
+	if err != nil {
+		producer.Close()
+		client.Close()
+		return nil, err
+	}
+	kafkaProducer.logger.Info("Kafka producer created successfully")
+	return kafkaProducer, nil
+}
+
+func NewKafkaProducerWithProducer(producer sarama.SyncProducer, cfg *config.KafkaConfig) (*KafkaProducer, error) {
+	ceModes, err := ParseCloudEventsModes(cfg.CloudEventsTopics)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := newKafkaCodec(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateMigration(cfg); err != nil {
+		return nil, err
+	}
+
+	key, err := NewKeyFunc(cfg.KeyStrategy, cfg.KeyHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	router, err := NewTopicRouter(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	return &KafkaProducer{
-		producer: producer,
-		topic:    cfg.OrderTopic,
-		logger:   logger,
+		producer:       producer,
+		router:         router,
+		migrationTopic: cfg.MigrationTopic,
+		migrationPhase: cfg.MigrationPhase,
+		region:         cfg.Region,
+		ceModes:        ceModes,
+		ceSource:       cfg.CloudEventsSource,
+		codec:          codec,
+		key:            key,
+		maxBytes:       cfg.MaxMessageBytes,
+		sizes:          newPayloadSizeRecorder(),
+		publishTimeout: time.Duration(cfg.PublishTimeout) * time.Millisecond,
+		transactional:  producer.IsTransactional(),
+		logger:         logrus.WithField("component", "kafka_producer"),
 	}, nil
 }
 
+// NewKafkaProducerWithClient is NewKafkaProducerWithProducer with the client
+// BroadcastEvent looks up partitions with; closing the producer closes client.
+func NewKafkaProducerWithClient(producer sarama.SyncProducer, client sarama.Client, cfg *config.KafkaConfig) (*KafkaProducer, error) {
+	kafkaProducer, err := NewKafkaProducerWithProducer(producer, cfg)
+	if err != nil {
+		return nil, err
+	}
+	kafkaProducer.client = client
+	return kafkaProducer, nil
+}
+
+// PublishEvent publishes event to the topic it is routed to. During a topic
+// migration routed and tenant events still go to their own topic; only the
+// order topic is migrated.
 func (p *KafkaProducer) PublishEvent(ctx context.Context, event *models.Event) error {
-	eventData, err := json.Marshal(event)
+	for _, topic := range p.topics(event) {
+		if err := p.PublishEventToTopic(ctx, topic, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BroadcastEvent publishes event to every partition of the topics PublishEvent
+// would publish it to, so each consumer in a group handles a copy whatever
+// partitions it is assigned. It stops at the first partition that fails.
+func (p *KafkaProducer) BroadcastEvent(ctx context.Context, event *models.Event) error {
+	if p.client == nil {
+		return fmt.Errorf("producer has no client to look up partitions")
+	}
+
+	for _, topic := range p.topics(event) {
+		partitions, err := p.client.Partitions(topic)
+		if err != nil {
+			return fmt.Errorf("failed to get partitions of topic %s: %w", topic, err)
+		}
+		for _, partition := range partitions {
+			if err := p.publish(ctx, topic, partition, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// topics returns the topics event is published to: the one it is routed to,
+// or during a migration of the order topic the old and new one or only the
+// new one.
+func (p *KafkaProducer) topics(event *models.Event) []string {
+	topic := p.router.Route(event)
+	if topic != p.router.orderTopic {
+		return []string{topic}
+	}
+
+	switch p.migrationPhase {
+	case MigrationPhaseDual:
+		return []string{topic, p.migrationTopic}
+	case MigrationPhaseCutover:
+		return []string{p.migrationTopic}
+	default:
+		return []string{topic}
+	}
+}
+
+func (p *KafkaProducer) PublishEventToTopic(ctx context.Context, topic string, event *models.Event) error {
+	return p.publish(ctx, topic, anyPartition, event)
+}
+
+// publish publishes event to partition of topic, or to the partition the
+// partitioner picks for its key if partition is anyPartition.
+func (p *KafkaProducer) publish(ctx context.Context, topic string, partition int32, event *models.Event) error {
+	if event.Region == "" {
+		event.Region = p.region
+	}
+
+	var eventData []byte
+	var ceHeaders []sarama.RecordHeader
+	var err error
+	switch mode := p.ceModes[topic]; mode {
+	case CloudEventsModeBinary, CloudEventsModeStructured:
+		eventData, ceHeaders, err = encodeCloudEvent(event, mode, p.ceSource)
+	default:
+		eventData, err = p.codec.Marshal(event)
+		ceHeaders = []sarama.RecordHeader{
+			{Key: []byte("content-type"), Value: []byte(p.codec.ContentType())},
+		}
+	}
 	if err != nil {
 		p.logger.WithError(err).Error("Failed to marshal event")
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	key := event.ID.String()
 	message := &sarama.ProducerMessage{
-		Topic: p.topic,
-		Key:   sarama.StringEncoder(key),
+		Topic: topic,
 		Value: sarama.ByteEncoder(eventData),
 		Headers: []sarama.RecordHeader{
 			{
@@ -69,13 +270,44 @@ func (p *KafkaProducer) PublishEvent(ctx context.Context, event *models.Event) e
 				Key:   []byte("timestamp"),
 				Value: []byte(event.Timestamp.Format(time.RFC3339)),
 			},
+			{
+				Key:   []byte("region"),
+				Value: []byte(event.Region),
+			},
 		},
 		Timestamp: event.Timestamp,
 	}
+	if event.Sequence > 0 {
+		message.Headers = append(message.Headers, sarama.RecordHeader{
+			Key:   []byte("sequence"),
+			Value: []byte(strconv.FormatUint(event.Sequence, 10)),
+		})
+	}
+	message.Headers = append(message.Headers, ceHeaders...)
+	message.Headers = append(message.Headers, traceHeaders(ctx)...)
+	message.Key = sarama.StringEncoder(p.key(event, message.Headers))
+	if partition != anyPartition {
+		message.Partition = partition
+		message.Metadata = explicitPartition{}
+	}
 
-	partition, offset, err := p.producer.SendMessage(message)
+	size := messageSize(message)
+	tooLarge := p.maxBytes > 0 && size > p.maxBytes
+	p.sizes.observe(event.Type, size, tooLarge)
+	if tooLarge {
+		p.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"topic":      topic,
+			"size_bytes": size,
+			"max_bytes":  p.maxBytes,
+		}).Error("Event payload exceeds the maximum message size")
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrPayloadTooLarge, size, p.maxBytes)
+	}
+
+	partition, offset, err := p.send(ctx, message)
 	if err != nil {
-		p.logger.WithFields(logrus.Fields{
+		p.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
 			"event_id":   event.ID,
 			"event_type": event.Type,
 			"error":      err,
@@ -83,9 +315,10 @@ func (p *KafkaProducer) PublishEvent(ctx context.Context, event *models.Event) e
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
 
-	p.logger.WithFields(logrus.Fields{
+	p.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
 		"event_id":   event.ID,
 		"event_type": event.Type,
+		"topic":      topic,
 		"partition":  partition,
 		"offset":     offset,
 	}).Info("Event published successfully")
@@ -93,6 +326,140 @@ func (p *KafkaProducer) PublishEvent(ctx context.Context, event *models.Event) e
 	return nil
 }
 
+// send publishes message. A transactional producer sends it in the
+// transaction of the enclosing Transact call, or in one of its own outside
+// of Transact.
+func (p *KafkaProducer) send(ctx context.Context, message *sarama.ProducerMessage) (int32, int64, error) {
+	if txn, ok := ctx.Value(txnContextKey{}).(*kafkaTxn); !p.transactional || ok && txn.producer == p {
+		return sendMessage(ctx, p.producer, message, p.publishTimeout)
+	}
+
+	p.txnMu.Lock()
+	defer p.txnMu.Unlock()
+
+	if err := p.producer.BeginTxn(); err != nil {
+		return -1, -1, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	partition, offset, err := sendMessage(ctx, p.producer, message, p.publishTimeout)
+	if err != nil {
+		p.abortTxn()
+		return -1, -1, err
+	}
+	if err := p.producer.CommitTxn(); err != nil {
+		p.abortTxn()
+		return -1, -1, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return partition, offset, nil
+}
+
+// sendMessage sends message, giving up once ctx is done or, if timeout is
+// positive, after timeout with ErrPublishTimeout. A send that gives up is
+// left running, as a SyncProducer cannot cancel it, and may still succeed.
+// Errors from the broker are wrapped in ErrBrokerUnavailable.
+func sendMessage(ctx context.Context, producer sarama.SyncProducer, message *sarama.ProducerMessage, timeout time.Duration) (int32, int64, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return -1, -1, fmt.Errorf("%w: %w", ErrPublishTimeout, err)
+	}
+
+	type result struct {
+		partition int32
+		offset    int64
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		partition, offset, err := producer.SendMessage(message)
+		done <- result{partition: partition, offset: offset, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return -1, -1, fmt.Errorf("%w: %w", ErrBrokerUnavailable, r.err)
+		}
+		return r.partition, r.offset, nil
+	case <-ctx.Done():
+		return -1, -1, fmt.Errorf("%w: %w", ErrPublishTimeout, ctx.Err())
+	}
+}
+
+// Transact runs fn in a Kafka transaction that also commits the offset of
+// message for groupID, so the events fn publishes through the producer and
+// the consumed offset are committed together or not at all. A nil fn only
+// commits the offset. Transactions of one producer run one at a time; the
+// functions passed to AfterCommit run once the transaction is committed.
+func (p *KafkaProducer) Transact(ctx context.Context, groupID string, message *sarama.ConsumerMessage, fn func(ctx context.Context) error) error {
+	if !p.transactional {
+		return fmt.Errorf("producer is not transactional")
+	}
+
+	txn := &kafkaTxn{producer: p}
+	if err := p.transact(context.WithValue(ctx, txnContextKey{}, txn), groupID, message, fn); err != nil {
+		return err
+	}
+	txn.mu.Lock()
+	afterCommit := txn.afterCommit
+	txn.mu.Unlock()
+	for _, fn := range afterCommit {
+		fn()
+	}
+	return nil
+}
+
+func (p *KafkaProducer) transact(ctx context.Context, groupID string, message *sarama.ConsumerMessage, fn func(ctx context.Context) error) error {
+	p.txnMu.Lock()
+	defer p.txnMu.Unlock()
+
+	if err := p.producer.BeginTxn(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if fn != nil {
+		if err := fn(ctx); err != nil {
+			p.abortTxn()
+			return err
+		}
+	}
+	if err := p.producer.AddMessageToTxn(message, groupID, nil); err != nil {
+		p.abortTxn()
+		return fmt.Errorf("failed to add offset to transaction: %w", err)
+	}
+	if err := p.producer.CommitTxn(); err != nil {
+		p.abortTxn()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (p *KafkaProducer) abortTxn() {
+	if err := p.producer.AbortTxn(); err != nil {
+		p.logger.WithError(err).Error("Failed to abort transaction")
+	}
+}
+
+// PayloadStats returns the encoded size histogram of every event type
+// published, including rejected events.
+func (p *KafkaProducer) PayloadStats() map[models.EventType]PayloadSizeHistogram {
+	return p.sizes.snapshot()
+}
+
+// messageSize approximates the record size the broker checks against
+// message.max.bytes: key, value and headers, without batch overhead.
+func messageSize(message *sarama.ProducerMessage) int {
+	size := message.Value.Length()
+	if message.Key != nil {
+		size += message.Key.Length()
+	}
+	for _, header := range message.Headers {
+		size += len(header.Key) + len(header.Value)
+	}
+	return size
+}
+
 func (p *KafkaProducer) Close() error {
 	if p.producer != nil {
 		if err := p.producer.Close(); err != nil {
@@ -101,5 +468,10 @@ func (p *KafkaProducer) Close() error {
 		}
 		p.logger.Info("Kafka producer closed successfully")
 	}
+	if p.client != nil {
+		if err := p.client.Close(); err != nil {
+			return fmt.Errorf("failed to close Kafka client: %w", err)
+		}
+	}
 	return nil
 }
\ No newline at end of file
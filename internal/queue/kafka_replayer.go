@@ -0,0 +1,246 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/pkg/config"
+)
+
+type KafkaReplayer struct {
+	client   sarama.Client
+	consumer sarama.Consumer
+	topic    string
+	avro     *AvroCodec
+	logger   *logrus.Entry
+}
+
+type replayContextKey struct{}
+
+// WithReplay marks ctx as replaying events that were handled before, e.g.
+// from the topic by a replay or from the event archive.
+func WithReplay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replayContextKey{}, true)
+}
+
+// IsReplay reports whether ctx is replaying events.
+func IsReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayContextKey{}).(bool)
+	return replay
+}
+
+// partitionRange is the offsets of a partition to replay, end exclusive.
+type partitionRange struct {
+	partition  int32
+	start, end int64
+}
+
+type partitionCursor struct {
+	partition int32
+	consumer  sarama.PartitionConsumer
+	end       int64
+	head      *sarama.ConsumerMessage
+}
+
+func NewKafkaReplayer(cfg *config.KafkaConfig) (*KafkaReplayer, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Consumer.Return.Errors = true
+	saramaConfig.Consumer.IsolationLevel = sarama.ReadCommitted
+
+	client, err := sarama.NewClient(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+
+	return &KafkaReplayer{
+		client:   client,
+		consumer: consumer,
+		topic:    cfg.OrderTopic,
+		avro:     newAvroDecoder(cfg),
+		logger: logrus.WithFields(logrus.Fields{
+			"component": "kafka_replayer",
+			"topic":     cfg.OrderTopic,
+		}),
+	}, nil
+}
+
+// Replay feeds every event currently on the topic to handler, merging
+// partitions by message timestamp so per-order history is applied in order.
+func (r *KafkaReplayer) Replay(ctx context.Context, handler EventHandler) (int, error) {
+	return r.ReplayBetween(ctx, time.Time{}, time.Time{}, handler)
+}
+
+// ReplaySince is Replay limited to events with a timestamp at or after since.
+func (r *KafkaReplayer) ReplaySince(ctx context.Context, since time.Time, handler EventHandler) (int, error) {
+	return r.ReplayBetween(ctx, since, time.Time{}, handler)
+}
+
+// ReplayBetween is Replay limited to events with a timestamp at or after
+// since and before until. A zero since or until leaves that end open.
+func (r *KafkaReplayer) ReplayBetween(ctx context.Context, since, until time.Time, handler EventHandler) (int, error) {
+	partitions, err := r.consumer.Partitions(r.topic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	from := sarama.OffsetOldest
+	if !since.IsZero() {
+		from = since.UnixMilli()
+	}
+
+	var ranges []partitionRange
+	for _, partition := range partitions {
+		start, err := r.client.GetOffset(r.topic, partition, from)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get start offset for partition %d: %w", partition, err)
+		}
+		end, err := r.client.GetOffset(r.topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get newest offset for partition %d: %w", partition, err)
+		}
+		if !until.IsZero() {
+			// A timestamp lookup with no later message resolves to
+			// OffsetNewest, leaving the end at the newest offset.
+			last, err := r.client.GetOffset(r.topic, partition, until.UnixMilli())
+			if err != nil {
+				return 0, fmt.Errorf("failed to get end offset for partition %d: %w", partition, err)
+			}
+			if last != sarama.OffsetNewest && last < end {
+				end = last
+			}
+		}
+		if start == sarama.OffsetNewest {
+			continue
+		}
+		ranges = append(ranges, partitionRange{partition: partition, start: start, end: end})
+	}
+
+	return r.replay(ctx, ranges, handler)
+}
+
+// ReplayOffsets replays partition from offset from to offset to, both
+// inclusive and clamped to the offsets on the topic. A negative to replays to
+// the newest message.
+func (r *KafkaReplayer) ReplayOffsets(ctx context.Context, partition int32, from, to int64, handler EventHandler) (int, error) {
+	partitions, err := r.consumer.Partitions(r.topic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list partitions: %w", err)
+	}
+	if !slices.Contains(partitions, partition) {
+		return 0, fmt.Errorf("topic %s has no partition %d", r.topic, partition)
+	}
+
+	oldest, err := r.client.GetOffset(r.topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get oldest offset for partition %d: %w", partition, err)
+	}
+	newest, err := r.client.GetOffset(r.topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get newest offset for partition %d: %w", partition, err)
+	}
+
+	end := newest
+	if to >= 0 && to+1 < end {
+		end = to + 1
+	}
+	return r.replay(ctx, []partitionRange{{partition: partition, start: max(from, oldest), end: end}}, handler)
+}
+
+// replay feeds the messages in ranges to handler, merging partitions by
+// message timestamp.
+func (r *KafkaReplayer) replay(ctx context.Context, ranges []partitionRange, handler EventHandler) (int, error) {
+	ctx = WithReplay(ctx)
+
+	var cursors []*partitionCursor
+	defer func() {
+		for _, c := range cursors {
+			c.consumer.Close()
+		}
+	}()
+
+	for _, pr := range ranges {
+		if pr.end <= pr.start {
+			continue
+		}
+
+		pc, err := r.consumer.ConsumePartition(r.topic, pr.partition, pr.start)
+		if err != nil {
+			return 0, fmt.Errorf("failed to consume partition %d: %w", pr.partition, err)
+		}
+
+		cursor := &partitionCursor{partition: pr.partition, consumer: pc, end: pr.end}
+		cursors = append(cursors, cursor)
+		if err := r.advance(ctx, cursor); err != nil {
+			return 0, err
+		}
+	}
+
+	replayed := 0
+	for {
+		var next *partitionCursor
+		for _, c := range cursors {
+			if c.head != nil && (next == nil || c.head.Timestamp.Before(next.head.Timestamp)) {
+				next = c
+			}
+		}
+		if next == nil {
+			break
+		}
+
+		message := next.head
+		event, err := decodeMessage(message, r.avro)
+		if err != nil {
+			r.logger.WithFields(logrus.Fields{
+				"partition": message.Partition,
+				"offset":    message.Offset,
+				"error":     err,
+			}).Warn("Skipping undecodable message during replay")
+		} else if err := handler.HandleEvent(ctx, event); err != nil {
+			return replayed, fmt.Errorf("failed to replay event %s at %d/%d: %w", event.ID, message.Partition, message.Offset, err)
+		} else {
+			replayed++
+		}
+
+		next.head = nil
+		if message.Offset+1 < next.end {
+			if err := r.advance(ctx, next); err != nil {
+				return replayed, err
+			}
+		}
+	}
+
+	r.logger.WithField("events_replayed", replayed).Info("Replay finished")
+	return replayed, nil
+}
+
+func (r *KafkaReplayer) advance(ctx context.Context, c *partitionCursor) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case message := <-c.consumer.Messages():
+		// Offsets may skip, e.g. over transaction markers, past the end.
+		if message.Offset < c.end {
+			c.head = message
+		}
+		return nil
+	case err := <-c.consumer.Errors():
+		return fmt.Errorf("failed to read partition %d: %w", c.partition, err)
+	}
+}
+
+func (r *KafkaReplayer) Close() error {
+	if err := r.consumer.Close(); err != nil {
+		return fmt.Errorf("failed to close replay consumer: %w", err)
+	}
+	return r.client.Close()
+}
\ No newline at end of file
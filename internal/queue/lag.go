@@ -0,0 +1,136 @@
+package queue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+)
+
+// ConsumerLag is how far a consumer is behind the high watermarks of its
+// claimed partitions. A consumer becomes ready once it has joined its group
+// and its total lag dropped to the threshold, and stays ready afterwards so
+// later backlogs do not take it out of service.
+type ConsumerLag struct {
+	Partitions map[string]map[int32]int64 `json:"partitions"`
+	Total      int64                      `json:"total"`
+	Threshold  int64                      `json:"threshold"`
+	Ready      bool                       `json:"ready"`
+}
+
+type partitionLag struct {
+	claim sarama.ConsumerGroupClaim
+	next  int64
+}
+
+type lagTracker struct {
+	mu         sync.Mutex
+	threshold  int64
+	joined     bool
+	ready      bool
+	partitions map[string]map[int32]*partitionLag
+	progressAt time.Time
+	logger     *logrus.Entry
+}
+
+func newLagTracker(threshold int64, logger *logrus.Entry) *lagTracker {
+	return &lagTracker{
+		threshold:  threshold,
+		partitions: make(map[string]map[int32]*partitionLag),
+		logger:     logger,
+	}
+}
+
+func (t *lagTracker) join() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.joined = true
+	t.progressAt = time.Now()
+}
+
+// touch restarts the time since the last progress, e.g. after partitions are
+// resumed.
+func (t *lagTracker) touch() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progressAt = time.Now()
+}
+
+// lastProgress returns when a message was last processed, or a partition
+// claimed, whichever is later.
+func (t *lagTracker) lastProgress() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.progressAt
+}
+
+// claim starts tracking a partition from the offset the claim starts at. Until
+// the first message arrives, a claim starting from the oldest offset counts
+// its whole high watermark as lag and one starting from the newest counts none.
+func (t *lagTracker) claim(claim sarama.ConsumerGroupClaim) {
+	next := claim.InitialOffset()
+	switch next {
+	case sarama.OffsetOldest:
+		next = 0
+	case sarama.OffsetNewest:
+		next = claim.HighWaterMarkOffset()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.partitions[claim.Topic()] == nil {
+		t.partitions[claim.Topic()] = make(map[int32]*partitionLag)
+	}
+	t.partitions[claim.Topic()][claim.Partition()] = &partitionLag{claim: claim, next: next}
+	t.progressAt = time.Now()
+}
+
+func (t *lagTracker) processed(message *sarama.ConsumerMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if partition, ok := t.partitions[message.Topic][message.Partition]; ok {
+		partition.next = message.Offset + 1
+	}
+	t.progressAt = time.Now()
+}
+
+func (t *lagTracker) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partitions = make(map[string]map[int32]*partitionLag)
+}
+
+func (t *lagTracker) snapshot() ConsumerLag {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lag := ConsumerLag{
+		Partitions: make(map[string]map[int32]int64, len(t.partitions)),
+		Threshold:  t.threshold,
+	}
+	for topic, partitions := range t.partitions {
+		lag.Partitions[topic] = make(map[int32]int64, len(partitions))
+		for id, partition := range partitions {
+			behind := partition.claim.HighWaterMarkOffset() - partition.next
+			if behind < 0 {
+				behind = 0
+			}
+			lag.Partitions[topic][id] = behind
+			lag.Total += behind
+		}
+	}
+
+	if !t.ready && t.joined && (t.threshold <= 0 || lag.Total <= t.threshold) {
+		t.ready = true
+		t.logger.WithField("lag", lag.Total).Info("Consumer caught up and is ready")
+	}
+	lag.Ready = t.ready
+	return lag
+}
\ No newline at end of file
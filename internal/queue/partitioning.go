@@ -0,0 +1,197 @@
+package queue
+
+import (
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"order-processing-microservice/internal/models"
+)
+
+const (
+	KeyStrategyEventID    = "event_id"
+	KeyStrategyOrderID    = "order_id"
+	KeyStrategyCustomerID = "customer_id"
+	KeyStrategyHeader     = "header"
+
+	PartitionerRandom  = "random"
+	PartitionerHash    = "hash"
+	PartitionerMurmur2 = "murmur2"
+)
+
+// KeyFunc returns the Kafka message key of an event, given the headers it is
+// published with.
+type KeyFunc func(event *models.Event, headers []sarama.RecordHeader) string
+
+// NewKeyFunc returns the key function of the strategy, order_id by default.
+// Events without the chosen order ID, customer ID or header are keyed by their
+// event ID.
+func NewKeyFunc(strategy, header string) (KeyFunc, error) {
+	switch strategy {
+	case KeyStrategyEventID:
+		return eventIDKey, nil
+	case "", KeyStrategyOrderID:
+		return func(event *models.Event, headers []sarama.RecordHeader) string {
+			return eventDataKey(event, "order_id")
+		}, nil
+	case KeyStrategyCustomerID:
+		return func(event *models.Event, headers []sarama.RecordHeader) string {
+			return eventDataKey(event, "customer_id")
+		}, nil
+	case KeyStrategyHeader:
+		if header == "" {
+			return nil, fmt.Errorf("key strategy %s requires a key header", strategy)
+		}
+		return func(event *models.Event, headers []sarama.RecordHeader) string {
+			for _, h := range headers {
+				if string(h.Key) == header && len(h.Value) > 0 {
+					return string(h.Value)
+				}
+			}
+			return eventIDKey(event, headers)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key strategy %q", strategy)
+	}
+}
+
+func eventIDKey(event *models.Event, headers []sarama.RecordHeader) string {
+	return event.ID.String()
+}
+
+func eventDataKey(event *models.Event, field string) string {
+	var data map[string]interface{}
+	if err := event.DecodeData(&data); err == nil {
+		if value, ok := data[field].(string); ok {
+			if id, err := uuid.Parse(value); err == nil && id != uuid.Nil {
+				return id.String()
+			}
+		}
+	}
+	return event.ID.String()
+}
+
+// NewPartitioner returns the partitioner by name: random, hash (sarama's
+// FNV-1a, the default) or murmur2 (compatible with the Java client's default
+// partitioner).
+func NewPartitioner(name string) (sarama.PartitionerConstructor, error) {
+	switch name {
+	case PartitionerRandom:
+		return sarama.NewRandomPartitioner, nil
+	case "", PartitionerHash:
+		return sarama.NewHashPartitioner, nil
+	case PartitionerMurmur2:
+		return NewMurmur2Partitioner, nil
+	default:
+		return nil, fmt.Errorf("unsupported partitioner %q", name)
+	}
+}
+
+// anyPartition publishes a message to the partition the partitioner picks.
+const anyPartition int32 = -1
+
+// explicitPartition is the Metadata of a message that goes to the partition
+// it names rather than the one the partitioner picks.
+type explicitPartition struct{}
+
+// withExplicitPartitions wraps the partitioners of constructor so messages
+// marked with explicitPartition keep their partition.
+func withExplicitPartitions(constructor sarama.PartitionerConstructor) sarama.PartitionerConstructor {
+	return func(topic string) sarama.Partitioner {
+		return &explicitPartitioner{partitioner: constructor(topic)}
+	}
+}
+
+type explicitPartitioner struct {
+	partitioner sarama.Partitioner
+}
+
+func (p *explicitPartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if _, ok := message.Metadata.(explicitPartition); ok {
+		return message.Partition, nil
+	}
+	return p.partitioner.Partition(message, numPartitions)
+}
+
+func (p *explicitPartitioner) RequiresConsistency() bool {
+	return p.partitioner.RequiresConsistency()
+}
+
+// MessageRequiresConsistency is true for an explicit partition, so sarama
+// indexes all partitions of the topic with it rather than the writable ones.
+func (p *explicitPartitioner) MessageRequiresConsistency(message *sarama.ProducerMessage) bool {
+	if _, ok := message.Metadata.(explicitPartition); ok {
+		return true
+	}
+	if dynamic, ok := p.partitioner.(sarama.DynamicConsistencyPartitioner); ok {
+		return dynamic.MessageRequiresConsistency(message)
+	}
+	return p.partitioner.RequiresConsistency()
+}
+
+type murmur2Partitioner struct {
+	random sarama.Partitioner
+}
+
+// NewMurmur2Partitioner assigns keyed messages to the partition the Java
+// client would choose: the positive murmur2 hash of the key modulo the
+// partition count. Messages without a key are assigned randomly.
+func NewMurmur2Partitioner(topic string) sarama.Partitioner {
+	return &murmur2Partitioner{random: sarama.NewRandomPartitioner(topic)}
+}
+
+func (p *murmur2Partitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if message.Key == nil {
+		return p.random.Partition(message, numPartitions)
+	}
+
+	key, err := message.Key.Encode()
+	if err != nil {
+		return -1, err
+	}
+	return (Murmur2(key) & 0x7fffffff) % numPartitions, nil
+}
+
+func (p *murmur2Partitioner) RequiresConsistency() bool {
+	return true
+}
+
+// Murmur2 is the 32-bit murmur2 hash used by Kafka's Java client.
+func Murmur2(data []byte) int32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+
+	length := len(data)
+	h := seed ^ uint32(length)
+
+	for i := 0; i+4 <= length; i += 4 {
+		k := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := length &^ 3
+	switch length % 4 {
+	case 3:
+		h ^= uint32(data[tail+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[tail+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[tail])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+
+	return int32(h)
+}
\ No newline at end of file
@@ -0,0 +1,131 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// The errors Pause and Resume reject a request with, matched with errors.Is.
+var (
+	ErrTopicNotConsumed   = errors.New("topic is not consumed")
+	ErrTopicPausedAsWhole = errors.New("topic is paused as a whole")
+)
+
+// pauseState records the partitions paused through the admin API. sarama
+// forgets paused partitions when they are reassigned, so the state is
+// reapplied to every new claim. A topic paused without partitions stays
+// paused on whichever of its partitions the consumer is assigned.
+type pauseState struct {
+	mu sync.Mutex
+	// topics maps a paused topic to its paused partitions, or to nil when
+	// all of them are paused.
+	topics map[string]map[int32]bool
+}
+
+func newPauseState() *pauseState {
+	return &pauseState{topics: make(map[string]map[int32]bool)}
+}
+
+func (s *pauseState) pause(topic string, partitions []int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paused, ok := s.topics[topic]
+	if len(partitions) == 0 {
+		s.topics[topic] = nil
+		return
+	}
+	if ok && paused == nil {
+		return
+	}
+	if paused == nil {
+		paused = make(map[int32]bool)
+		s.topics[topic] = paused
+	}
+	for _, partition := range partitions {
+		paused[partition] = true
+	}
+}
+
+func (s *pauseState) resume(topic string, partitions []int32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paused, ok := s.topics[topic]
+	if !ok {
+		return nil
+	}
+	if len(partitions) == 0 {
+		delete(s.topics, topic)
+		return nil
+	}
+	if paused == nil {
+		return fmt.Errorf("%w and must be resumed as a whole: %s", ErrTopicPausedAsWhole, topic)
+	}
+	for _, partition := range partitions {
+		delete(paused, partition)
+	}
+	if len(paused) == 0 {
+		delete(s.topics, topic)
+	}
+	return nil
+}
+
+func (s *pauseState) isPaused(topic string, partition int32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paused, ok := s.topics[topic]
+	return ok && (paused == nil || paused[partition])
+}
+
+// resolve returns the partitions of claims that are paused.
+func (s *pauseState) resolve(claims map[string][]int32) map[string][]int32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resolved := make(map[string][]int32)
+	for topic, partitions := range claims {
+		paused, ok := s.topics[topic]
+		if !ok {
+			continue
+		}
+		for _, partition := range partitions {
+			if paused == nil || paused[partition] {
+				resolved[topic] = append(resolved[topic], partition)
+			}
+		}
+		sort.Slice(resolved[topic], func(i, j int) bool { return resolved[topic][i] < resolved[topic][j] })
+	}
+	return resolved
+}
+
+// selectPartitions returns the partitions of topics that a pause or resume of
+// topic and partitions applies to, among the claimed ones. An empty topic
+// selects every topic, and no partitions every claimed partition.
+func selectPartitions(topics []string, claims map[string][]int32, topic string, partitions []int32) (map[string][]int32, error) {
+	selected := topics
+	if topic != "" {
+		if !slices.Contains(topics, topic) {
+			return nil, fmt.Errorf("%w: %s", ErrTopicNotConsumed, topic)
+		}
+		selected = []string{topic}
+	}
+
+	result := make(map[string][]int32, len(selected))
+	for _, t := range selected {
+		if len(partitions) == 0 {
+			result[t] = claims[t]
+			continue
+		}
+		for _, partition := range partitions {
+			if slices.Contains(claims[t], partition) {
+				result[t] = append(result[t], partition)
+			}
+		}
+	}
+	return result, nil
+}
\ No newline at end of file
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"sync"
+
+	"order-processing-microservice/internal/models"
+)
+
+// payloadSizeBuckets are the upper bounds of the event size histogram; larger
+// events fall into the "+Inf" bucket.
+var payloadSizeBuckets = []struct {
+	label string
+	bound int
+}{
+	{"1KiB", 1 << 10},
+	{"4KiB", 4 << 10},
+	{"16KiB", 16 << 10},
+	{"64KiB", 64 << 10},
+	{"256KiB", 256 << 10},
+	{"1MiB", 1 << 20},
+}
+
+// PayloadSizeHistogram summarizes the encoded sizes of one event type.
+// Buckets are not cumulative. Rejected counts events over the size limit,
+// which are included in the other fields but were not sent.
+type PayloadSizeHistogram struct {
+	Count    uint64            `json:"count"`
+	Sum      uint64            `json:"sum_bytes"`
+	Max      int               `json:"max_bytes"`
+	Rejected uint64            `json:"rejected"`
+	Buckets  map[string]uint64 `json:"buckets"`
+}
+
+type payloadSizeRecorder struct {
+	mu     sync.Mutex
+	byType map[models.EventType]*PayloadSizeHistogram
+}
+
+func newPayloadSizeRecorder() *payloadSizeRecorder {
+	return &payloadSizeRecorder{byType: make(map[models.EventType]*PayloadSizeHistogram)}
+}
+
+func (r *payloadSizeRecorder) observe(eventType models.EventType, size int, rejected bool) {
+	label := "+Inf"
+	for _, bucket := range payloadSizeBuckets {
+		if size <= bucket.bound {
+			label = bucket.label
+			break
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	histogram, ok := r.byType[eventType]
+	if !ok {
+		histogram = &PayloadSizeHistogram{Buckets: make(map[string]uint64)}
+		r.byType[eventType] = histogram
+	}
+	histogram.Count++
+	histogram.Sum += uint64(size)
+	if size > histogram.Max {
+		histogram.Max = size
+	}
+	if rejected {
+		histogram.Rejected++
+	}
+	histogram.Buckets[label]++
+}
+
+func (r *payloadSizeRecorder) snapshot() map[models.EventType]PayloadSizeHistogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make(map[models.EventType]PayloadSizeHistogram, len(r.byType))
+	for eventType, histogram := range r.byType {
+		copied := *histogram
+		copied.Buckets = make(map[string]uint64, len(histogram.Buckets))
+		for label, count := range histogram.Buckets {
+			copied.Buckets[label] = count
+		}
+		stats[eventType] = copied
+	}
+	return stats
+}
+
+func mergePayloadStats(reports ...map[models.EventType]PayloadSizeHistogram) map[models.EventType]PayloadSizeHistogram {
+	merged := make(map[models.EventType]PayloadSizeHistogram)
+	for _, report := range reports {
+		for eventType, histogram := range report {
+			total, ok := merged[eventType]
+			if !ok {
+				total.Buckets = make(map[string]uint64)
+			}
+			total.Count += histogram.Count
+			total.Sum += histogram.Sum
+			total.Rejected += histogram.Rejected
+			if histogram.Max > total.Max {
+				total.Max = histogram.Max
+			}
+			for label, count := range histogram.Buckets {
+				total.Buckets[label] += count
+			}
+			merged[eventType] = total
+		}
+	}
+	return merged
+}
\ No newline at end of file
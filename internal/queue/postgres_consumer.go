@@ -0,0 +1,345 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/logger"
+)
+
+const postgresQueueCleanupInterval = time.Hour
+
+// PostgresConsumer reads the event_queue table written by PostgresProducer.
+// Like a Kafka consumer group, each group ID keeps its own offset per topic
+// and receives every event; members of one group take turns by holding an
+// advisory lock, so events are handled one at a time in queue order. New
+// events are picked up on NOTIFY, with polling as a fallback.
+//
+// Queue IDs are not committed in order, so the queue is ordered by the ID of
+// the transaction that queued each event, then by queue ID, and an offset is
+// a position in that order. Only events of transactions older than every
+// transaction still in flight are read: no event can later appear before
+// them, so advancing the offset past them never skips one.
+type PostgresConsumer struct {
+	db           *sql.DB
+	listener     *pq.Listener
+	topics       []string
+	groupID      string
+	region       string
+	newest       bool
+	pollInterval time.Duration
+	batchSize    int
+	retention    time.Duration
+	lastCleanup  time.Time
+	codec        JSONCodec
+	handler      EventHandler
+	crashes      *logger.CrashReporter
+	logger       *logrus.Entry
+	cancel       context.CancelFunc
+	done         chan struct{}
+	err          error
+}
+
+func NewPostgresConsumer(db *sql.DB, dsn string, kafkaCfg *config.KafkaConfig, queueCfg *config.QueueConfig, topics []string) (*PostgresConsumer, error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"component": "postgres_consumer",
+		"group_id":  kafkaCfg.GroupID,
+		"topics":    topics,
+	})
+
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.WithError(err).Warn("Postgres queue listener connection event")
+		}
+	})
+	if err := listener.Listen(postgresQueueChannel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on Postgres queue: %w", err)
+	}
+
+	logger.Info("Postgres queue consumer created successfully")
+	return &PostgresConsumer{
+		db:           db,
+		listener:     listener,
+		topics:       topics,
+		groupID:      kafkaCfg.GroupID,
+		region:       kafkaCfg.Region,
+		newest:       kafkaCfg.InitialOffset == "newest",
+		pollInterval: time.Duration(queueCfg.PollInterval) * time.Second,
+		batchSize:    queueCfg.BatchSize,
+		retention:    time.Duration(queueCfg.Retention) * time.Hour,
+		logger:       logger,
+	}, nil
+}
+
+// EnableCrashReports recovers the handler from panics, failing the event as
+// the handler would have and recording a crash report of it with crashes. It
+// must be called before Subscribe.
+func (c *PostgresConsumer) EnableCrashReports(crashes *logger.CrashReporter) {
+	c.crashes = crashes
+}
+
+func (c *PostgresConsumer) Subscribe(ctx context.Context, handler EventHandler) error {
+	c.handler = handler
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+
+	c.done = make(chan struct{})
+	go func() {
+		c.err = c.run(ctx)
+		close(c.done)
+	}()
+
+	c.logger.Info("Started consuming messages")
+	return nil
+}
+
+func (c *PostgresConsumer) run(ctx context.Context) error {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.poll(ctx); err != nil && ctx.Err() == nil {
+			c.logger.WithError(err).Error("Error consuming messages")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.listener.Notify:
+		case <-ticker.C:
+			c.cleanup(ctx)
+		}
+	}
+}
+
+func (c *PostgresConsumer) poll(ctx context.Context) error {
+	for _, topic := range c.topics {
+		for {
+			count, err := c.pollTopic(ctx, topic)
+			if err != nil {
+				return err
+			}
+			if count < c.batchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// pollTopic handles the next batch of the topic, advancing the group's offset
+// past each event as it is handled. An event the handler fails on is logged
+// and skipped; the Postgres queue has no retry or dead-letter queue.
+//
+// Members of the group take turns by holding an advisory lock on a connection
+// of their own, not a lock on the offset row: that would give the poll a
+// transaction ID, and while the batch is handled every consumer would stop
+// reading at it, see PostgresConsumer. The reads and offset updates are
+// statements of their own for the same reason.
+func (c *PostgresConsumer) pollTopic(ctx context.Context, topic string) (int, error) {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(hashtext($1), hashtext($2))`, c.groupID, topic); err != nil {
+		return 0, fmt.Errorf("failed to lock queue offset: %w", err)
+	}
+	defer c.unlock(conn, topic)
+
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO event_queue_offsets (group_id, topic, last_xid, last_id)
+		VALUES ($1, $2, CASE WHEN $3::boolean THEN pg_snapshot_xmin(pg_current_snapshot()) ELSE '0'::xid8 END, 0)
+		ON CONFLICT (group_id, topic) DO NOTHING
+	`, c.groupID, topic, c.newest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize queue offset: %w", err)
+	}
+
+	var lastXID string
+	var lastID int64
+	err = conn.QueryRowContext(ctx, `
+		SELECT last_xid, last_id FROM event_queue_offsets
+		WHERE group_id = $1 AND topic = $2
+	`, c.groupID, topic).Scan(&lastXID, &lastID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read queue offset: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT xid, id, payload FROM event_queue
+		WHERE topic = $1 AND (xid, id) > ($2::xid8, $3)
+		  AND xid < pg_snapshot_xmin(pg_current_snapshot())
+		ORDER BY xid ASC, id ASC
+		LIMIT $4
+	`, topic, lastXID, lastID, c.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read queue: %w", err)
+	}
+
+	type queuedEvent struct {
+		xid     string
+		id      int64
+		payload []byte
+	}
+	var batch []queuedEvent
+	for rows.Next() {
+		var queued queuedEvent
+		if err := rows.Scan(&queued.xid, &queued.id, &queued.payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan queued event: %w", err)
+		}
+		batch = append(batch, queued)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read queue: %w", err)
+	}
+
+	for _, queued := range batch {
+		if err := c.processMessage(ctx, topic, queued.id, queued.payload); err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			c.logger.WithFields(logrus.Fields{
+				"topic":    topic,
+				"queue_id": queued.id,
+				"error":    err,
+			}).Error("Failed to process message")
+		}
+
+		_, err = conn.ExecContext(ctx, `
+			UPDATE event_queue_offsets SET last_xid = $3::xid8, last_id = $4, updated_at = NOW()
+			WHERE group_id = $1 AND topic = $2
+		`, c.groupID, topic, queued.xid, queued.id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update queue offset: %w", err)
+		}
+	}
+	return len(batch), nil
+}
+
+// unlock releases the advisory lock taken by pollTopic. If that fails the
+// connection is discarded, which releases it, rather than returned to the pool
+// still holding it.
+func (c *PostgresConsumer) unlock(conn *sql.Conn, topic string) {
+	_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1), hashtext($2))`, c.groupID, topic)
+	if err == nil {
+		return
+	}
+	c.logger.WithError(err).Warn("Failed to unlock queue offset; closing the connection")
+	conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+}
+
+func (c *PostgresConsumer) processMessage(ctx context.Context, topic string, id int64, payload []byte) error {
+	event, err := c.codec.Unmarshal(payload)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to unmarshal event")
+		return err
+	}
+
+	if c.region != "" && event.Region != "" && event.Region != c.region {
+		c.logger.WithFields(logrus.Fields{
+			"event_id":     event.ID,
+			"event_type":   event.Type,
+			"event_region": event.Region,
+		}).Debug("Ignoring event from foreign region")
+		return nil
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"topic":      topic,
+		"queue_id":   id,
+	}).Info("Processing event")
+
+	err = handleReporting(ctx, c.handler, event, c.crashes, logrus.Fields{
+		"consumer_group": c.groupID,
+		"topic":          topic,
+		"queue_id":       id,
+	})
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"error":      err,
+		}).Error("Handler failed to process event")
+		return fmt.Errorf("handler failed to process event: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+	}).Info("Event processed successfully")
+
+	return nil
+}
+
+// cleanup deletes events older than the retention, at most once an hour.
+func (c *PostgresConsumer) cleanup(ctx context.Context) {
+	if c.retention <= 0 || time.Since(c.lastCleanup) < postgresQueueCleanupInterval {
+		return
+	}
+	c.lastCleanup = time.Now()
+
+	result, err := c.db.ExecContext(ctx, `DELETE FROM event_queue WHERE created_at < $1`, time.Now().Add(-c.retention))
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to delete expired queued events")
+		return
+	}
+	if deleted, _ := result.RowsAffected(); deleted > 0 {
+		c.logger.WithField("deleted", deleted).Info("Deleted expired queued events")
+	}
+}
+
+// Assignment reports every topic as a single partition; the queue has no
+// partitioning, so the consumer is always healthy.
+func (c *PostgresConsumer) Assignment() PartitionAssignment {
+	assignment := PartitionAssignment{
+		GroupID:    c.groupID,
+		Partitions: make(map[string][]int32, len(c.topics)),
+		Healthy:    true,
+	}
+	for _, topic := range c.topics {
+		assignment.Partitions[topic] = []int32{0}
+		assignment.Count++
+	}
+	return assignment
+}
+
+// Wait blocks until the consumer stops. Database errors are retried, so it
+// returns nil after Close.
+func (c *PostgresConsumer) Wait() error {
+	if c.done == nil {
+		return nil
+	}
+	<-c.done
+	return c.err
+}
+
+func (c *PostgresConsumer) Close() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	c.Wait()
+
+	if err := c.listener.Close(); err != nil {
+		c.logger.WithError(err).Error("Failed to close Postgres queue listener")
+		return fmt.Errorf("failed to close listener: %w", err)
+	}
+	c.logger.Info("Postgres queue consumer closed successfully")
+	return nil
+}
\ No newline at end of file
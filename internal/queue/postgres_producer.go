@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+// postgresQueueChannel is the LISTEN/NOTIFY channel announcing new events; the
+// notification payload is the event's topic.
+const postgresQueueChannel = "event_queue"
+
+// PostgresProducer appends events to the event_queue table for deployments
+// without Kafka. Topics keep their Kafka names.
+type PostgresProducer struct {
+	db     *sql.DB
+	router *TopicRouter
+	region string
+	codec  JSONCodec
+	logger *logrus.Entry
+}
+
+func NewPostgresProducer(db *sql.DB, cfg *config.KafkaConfig) (*PostgresProducer, error) {
+	router, err := NewTopicRouter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresProducer{
+		db:     db,
+		router: router,
+		region: cfg.Region,
+		logger: logrus.WithFields(logrus.Fields{
+			"component": "postgres_producer",
+			"topic":     cfg.OrderTopic,
+		}),
+	}, nil
+}
+
+func (p *PostgresProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	return p.PublishEventToTopic(ctx, p.router.Route(event), event)
+}
+
+func (p *PostgresProducer) PublishEventToTopic(ctx context.Context, topic string, event *models.Event) error {
+	if event.Region == "" {
+		event.Region = p.region
+	}
+
+	payload, err := p.codec.Marshal(event)
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to marshal event")
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO event_queue (topic, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, topic, event.ID, event.Type, payload).Scan(&id)
+	if err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"error":      err,
+		}).Error("Failed to publish event to Postgres queue")
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	// Delivered to listeners when the transaction commits.
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, postgresQueueChannel, topic); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"topic":      topic,
+		"queue_id":   id,
+	}).Info("Event published successfully")
+
+	return nil
+}
+
+func (p *PostgresProducer) Close() error {
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,357 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/eventpb"
+)
+
+// ProtobufCodec encodes events as orders.events.v1.EventEnvelope
+// (api/proto/orders/events/v1/events.proto) through the generated types in
+// pkg/eventpb; run make proto after changing the .proto files.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) ContentType() string {
+	return protobufContentType
+}
+
+func (ProtobufCodec) Marshal(event *models.Event) ([]byte, error) {
+	envelope := &eventpb.EventEnvelope{
+		Id:        event.ID.String(),
+		Type:      string(event.Type),
+		Timestamp: toTimestamp(event.Timestamp),
+		Version:   event.Version,
+		Region:    event.Region,
+		Sequence:  event.Sequence,
+	}
+
+	if err := setPayload(envelope, event); err != nil {
+		return nil, err
+	}
+
+	data, err := proto.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event envelope: %w", err)
+	}
+	return data, nil
+}
+
+func (ProtobufCodec) Unmarshal(data []byte) (*models.Event, error) {
+	var envelope eventpb.EventEnvelope
+	if err := proto.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event envelope: %w", err)
+	}
+
+	event := &models.Event{
+		Type:      models.EventType(envelope.Type),
+		Timestamp: fromTimestamp(envelope.Timestamp),
+		Version:   envelope.Version,
+		Region:    envelope.Region,
+		Sequence:  envelope.Sequence,
+	}
+	var err error
+	if event.ID, err = uuid.Parse(envelope.Id); err != nil {
+		return nil, fmt.Errorf("invalid event id: %w", err)
+	}
+
+	if raw, ok := envelope.Payload.(*eventpb.EventEnvelope_JsonData); ok {
+		if err := json.Unmarshal(raw.JsonData, &event.Data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event data: %w", err)
+		}
+		return event, nil
+	}
+
+	typed, err := unmarshalPayload(&envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	// Consumers read event data as decoded JSON, so mirror that shape.
+	asJSON, err := json.Marshal(typed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert event data: %w", err)
+	}
+	if err := json.Unmarshal(asJSON, &event.Data); err != nil {
+		return nil, fmt.Errorf("failed to convert event data: %w", err)
+	}
+	return event, nil
+}
+
+// setPayload sets the payload of envelope to the data of event, as JSON if
+// its type has no dedicated message.
+func setPayload(envelope *eventpb.EventEnvelope, event *models.Event) error {
+	switch event.Type {
+	case models.OrderCreatedEvent:
+		var d models.OrderCreatedEventData
+		if err := event.DecodeData(&d); err != nil {
+			return fmt.Errorf("invalid %s data: %w", event.Type, err)
+		}
+		envelope.Payload = &eventpb.EventEnvelope_OrderCreated{OrderCreated: &eventpb.OrderCreated{
+			OrderId:           uuidString(d.OrderID),
+			CustomerId:        uuidString(d.CustomerID),
+			Items:             toItems(d.Items),
+			TotalAmount:       d.TotalAmount,
+			CreatedAt:         toTimestamp(d.CreatedAt),
+			TenantId:          d.TenantID,
+			OrderNumber:       d.OrderNumber,
+			ExternalReference: d.ExternalReference,
+			Channel:           string(d.Channel),
+		}}
+	case models.OrderStatusChangedEvent:
+		var d models.OrderStatusChangedEventData
+		if err := event.DecodeData(&d); err != nil {
+			return fmt.Errorf("invalid %s data: %w", event.Type, err)
+		}
+		envelope.Payload = &eventpb.EventEnvelope_OrderStatusChanged{OrderStatusChanged: &eventpb.OrderStatusChanged{
+			OrderId:    uuidString(d.OrderID),
+			CustomerId: uuidString(d.CustomerID),
+			OldStatus:  string(d.OldStatus),
+			NewStatus:  string(d.NewStatus),
+			UpdatedAt:  toTimestamp(d.UpdatedAt),
+			Reason:     d.Reason,
+		}}
+	case models.OrderProcessingEvent:
+		var d models.OrderProcessingEventData
+		if err := event.DecodeData(&d); err != nil {
+			return fmt.Errorf("invalid %s data: %w", event.Type, err)
+		}
+		envelope.Payload = &eventpb.EventEnvelope_OrderProcessing{OrderProcessing: &eventpb.OrderProcessing{
+			OrderId:    uuidString(d.OrderID),
+			CustomerId: uuidString(d.CustomerID),
+			StartedAt:  toTimestamp(d.StartedAt),
+		}}
+	case models.OrderCompletedEvent:
+		var d models.OrderCompletedEventData
+		if err := event.DecodeData(&d); err != nil {
+			return fmt.Errorf("invalid %s data: %w", event.Type, err)
+		}
+		completed := &eventpb.OrderCompleted{
+			OrderId:     uuidString(d.OrderID),
+			CustomerId:  uuidString(d.CustomerID),
+			CompletedAt: toTimestamp(d.CompletedAt),
+			TotalAmount: d.TotalAmount,
+		}
+		if d.EstimatedDelivery != nil {
+			completed.EstimatedDelivery = timestamppb.New(*d.EstimatedDelivery)
+		}
+		envelope.Payload = &eventpb.EventEnvelope_OrderCompleted{OrderCompleted: completed}
+	case models.OrderFailedEvent:
+		var d models.OrderFailedEventData
+		if err := event.DecodeData(&d); err != nil {
+			return fmt.Errorf("invalid %s data: %w", event.Type, err)
+		}
+		envelope.Payload = &eventpb.EventEnvelope_OrderFailed{OrderFailed: &eventpb.OrderFailed{
+			OrderId:       uuidString(d.OrderID),
+			CustomerId:    uuidString(d.CustomerID),
+			FailedAt:      toTimestamp(d.FailedAt),
+			Reason:        d.Reason,
+			Error:         d.Error,
+			FailureCode:   string(d.FailureCode),
+			FailureDetail: d.FailureDetail,
+		}}
+	case models.OrderCanceledEvent:
+		var d models.OrderCanceledEventData
+		if err := event.DecodeData(&d); err != nil {
+			return fmt.Errorf("invalid %s data: %w", event.Type, err)
+		}
+		envelope.Payload = &eventpb.EventEnvelope_OrderCanceled{OrderCanceled: &eventpb.OrderCanceled{
+			OrderId:        uuidString(d.OrderID),
+			CustomerId:     uuidString(d.CustomerID),
+			CanceledAt:     toTimestamp(d.CanceledAt),
+			Reason:         d.Reason,
+			ReasonCode:     string(d.ReasonCode),
+			Actor:          d.Actor,
+			PreviousStatus: string(d.PreviousStatus),
+		}}
+	case models.PaymentAuthorizeRequestEvent, models.InventoryReserveRequestEvent:
+		var d models.SagaCommandData
+		if err := event.DecodeData(&d); err != nil {
+			return fmt.Errorf("invalid %s data: %w", event.Type, err)
+		}
+		envelope.Payload = &eventpb.EventEnvelope_SagaCommand{SagaCommand: &eventpb.SagaCommand{
+			CorrelationId: uuidString(d.CorrelationID),
+			OrderId:       uuidString(d.OrderID),
+			CustomerId:    uuidString(d.CustomerID),
+			Amount:        d.Amount,
+			Items:         toItems(d.Items),
+			ReplyTopic:    d.ReplyTopic,
+		}}
+	case models.PaymentAuthorizeReplyEvent, models.InventoryReserveReplyEvent:
+		var d models.SagaReplyData
+		if err := event.DecodeData(&d); err != nil {
+			return fmt.Errorf("invalid %s data: %w", event.Type, err)
+		}
+		envelope.Payload = &eventpb.EventEnvelope_SagaReply{SagaReply: &eventpb.SagaReply{
+			CorrelationId: uuidString(d.CorrelationID),
+			OrderId:       uuidString(d.OrderID),
+			Success:       d.Success,
+			Reason:        d.Reason,
+		}}
+	default:
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event data: %w", err)
+		}
+		envelope.Payload = &eventpb.EventEnvelope_JsonData{JsonData: data}
+	}
+	return nil
+}
+
+// unmarshalPayload returns the event data of the envelope's event type. A
+// payload missing from the envelope reads as an empty one.
+func unmarshalPayload(envelope *eventpb.EventEnvelope) (interface{}, error) {
+	switch eventType := models.EventType(envelope.Type); eventType {
+	case models.OrderCreatedEvent:
+		p := envelope.GetOrderCreated()
+		return models.OrderCreatedEventData{
+			OrderID:           parseUUID(p.GetOrderId()),
+			TenantID:          p.GetTenantId(),
+			OrderNumber:       p.GetOrderNumber(),
+			ExternalReference: p.GetExternalReference(),
+			Channel:           models.OrderChannel(p.GetChannel()),
+			CustomerID:        parseUUID(p.GetCustomerId()),
+			Items:             fromItems(p.GetItems()),
+			TotalAmount:       p.GetTotalAmount(),
+			CreatedAt:         fromTimestamp(p.GetCreatedAt()),
+		}, nil
+	case models.OrderStatusChangedEvent:
+		p := envelope.GetOrderStatusChanged()
+		return models.OrderStatusChangedEventData{
+			OrderID:    parseUUID(p.GetOrderId()),
+			CustomerID: parseUUID(p.GetCustomerId()),
+			OldStatus:  models.OrderStatus(p.GetOldStatus()),
+			NewStatus:  models.OrderStatus(p.GetNewStatus()),
+			UpdatedAt:  fromTimestamp(p.GetUpdatedAt()),
+			Reason:     p.GetReason(),
+		}, nil
+	case models.OrderProcessingEvent:
+		p := envelope.GetOrderProcessing()
+		return models.OrderProcessingEventData{
+			OrderID:    parseUUID(p.GetOrderId()),
+			CustomerID: parseUUID(p.GetCustomerId()),
+			StartedAt:  fromTimestamp(p.GetStartedAt()),
+		}, nil
+	case models.OrderCompletedEvent:
+		p := envelope.GetOrderCompleted()
+		d := models.OrderCompletedEventData{
+			OrderID:     parseUUID(p.GetOrderId()),
+			CustomerID:  parseUUID(p.GetCustomerId()),
+			CompletedAt: fromTimestamp(p.GetCompletedAt()),
+			TotalAmount: p.GetTotalAmount(),
+		}
+		if p.GetEstimatedDelivery() != nil {
+			estimate := p.GetEstimatedDelivery().AsTime()
+			d.EstimatedDelivery = &estimate
+		}
+		return d, nil
+	case models.OrderFailedEvent:
+		p := envelope.GetOrderFailed()
+		return models.OrderFailedEventData{
+			OrderID:       parseUUID(p.GetOrderId()),
+			CustomerID:    parseUUID(p.GetCustomerId()),
+			FailedAt:      fromTimestamp(p.GetFailedAt()),
+			Reason:        p.GetReason(),
+			Error:         p.GetError(),
+			FailureCode:   models.FailureCode(p.GetFailureCode()),
+			FailureDetail: p.GetFailureDetail(),
+		}, nil
+	case models.OrderCanceledEvent:
+		p := envelope.GetOrderCanceled()
+		return models.OrderCanceledEventData{
+			OrderID:        parseUUID(p.GetOrderId()),
+			CustomerID:     parseUUID(p.GetCustomerId()),
+			PreviousStatus: models.OrderStatus(p.GetPreviousStatus()),
+			CanceledAt:     fromTimestamp(p.GetCanceledAt()),
+			ReasonCode:     models.CancelReasonCode(p.GetReasonCode()),
+			Reason:         p.GetReason(),
+			Actor:          p.GetActor(),
+		}, nil
+	case models.PaymentAuthorizeRequestEvent, models.InventoryReserveRequestEvent:
+		p := envelope.GetSagaCommand()
+		return models.SagaCommandData{
+			CorrelationID: parseUUID(p.GetCorrelationId()),
+			OrderID:       parseUUID(p.GetOrderId()),
+			CustomerID:    parseUUID(p.GetCustomerId()),
+			Amount:        p.GetAmount(),
+			Items:         fromItems(p.GetItems()),
+			ReplyTopic:    p.GetReplyTopic(),
+		}, nil
+	case models.PaymentAuthorizeReplyEvent, models.InventoryReserveReplyEvent:
+		p := envelope.GetSagaReply()
+		return models.SagaReplyData{
+			CorrelationID: parseUUID(p.GetCorrelationId()),
+			OrderID:       parseUUID(p.GetOrderId()),
+			Success:       p.GetSuccess(),
+			Reason:        p.GetReason(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("no protobuf payload for event type %s", eventType)
+	}
+}
+
+func toItems(items []models.OrderItem) []*eventpb.OrderItem {
+	var out []*eventpb.OrderItem
+	for _, item := range items {
+		out = append(out, &eventpb.OrderItem{
+			Id:        uuidString(item.ID),
+			OrderId:   uuidString(item.OrderID),
+			ProductId: uuidString(item.ProductID),
+			Quantity:  int64(item.Quantity),
+			Price:     item.Price,
+			Total:     item.Total,
+		})
+	}
+	return out
+}
+
+func fromItems(items []*eventpb.OrderItem) []models.OrderItem {
+	var out []models.OrderItem
+	for _, item := range items {
+		out = append(out, models.OrderItem{
+			ID:        parseUUID(item.GetId()),
+			OrderID:   parseUUID(item.GetOrderId()),
+			ProductID: parseUUID(item.GetProductId()),
+			Quantity:  int(item.GetQuantity()),
+			Price:     item.GetPrice(),
+			Total:     item.GetTotal(),
+		})
+	}
+	return out
+}
+
+// uuidString leaves a nil UUID unset rather than encoding all zeros.
+func uuidString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
+
+func parseUUID(s string) uuid.UUID {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
+}
+
+// toTimestamp leaves a zero time unset, so it decodes as a zero time again.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
\ No newline at end of file
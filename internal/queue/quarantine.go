@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+// QuarantineStore counts failed attempts at messages and keeps the skip-list
+// of quarantined ones.
+type QuarantineStore interface {
+	// RecordFailure counts a failed attempt at the message and returns the
+	// number of failures so far.
+	RecordFailure(ctx context.Context, key models.MessagePosition, cause error) (int, error)
+	Quarantine(ctx context.Context, key models.MessagePosition) error
+	IsQuarantined(ctx context.Context, key models.MessagePosition) (bool, error)
+	// Clear forgets the failures of a message that was processed after all.
+	Clear(ctx context.Context, key models.MessagePosition) error
+}
+
+// Quarantine gives a failing message MaxAttempts attempts, waiting Backoff
+// times the failures so far between them, before it is dead-lettered.
+// Dead-lettered messages are put on a skip-list, so that one delivered again,
+// e.g. after a rebalance before its offset was committed, is skipped instead
+// of failing and being dead-lettered once more. Failures are counted in the
+// store, so they survive restarts.
+type Quarantine struct {
+	store       QuarantineStore
+	maxAttempts int
+	backoff     time.Duration
+	logger      *logrus.Entry
+}
+
+func NewQuarantine(store QuarantineStore, cfg *config.KafkaConfig) *Quarantine {
+	maxAttempts := cfg.PoisonMaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &Quarantine{
+		store:       store,
+		maxAttempts: maxAttempts,
+		backoff:     time.Duration(cfg.PoisonBackoff) * time.Millisecond,
+		logger:      logrus.WithField("component", "quarantine"),
+	}
+}
+
+func quarantineKey(groupID string, message *sarama.ConsumerMessage) models.MessagePosition {
+	return models.MessagePosition{
+		GroupID:   groupID,
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+	}
+}
+
+// skip reports whether the message is quarantined. A failing lookup is logged
+// and the message processed.
+func (q *Quarantine) skip(ctx context.Context, key models.MessagePosition) bool {
+	quarantined, err := q.store.IsQuarantined(ctx, key)
+	if err != nil {
+		q.logger.WithFields(q.fields(key)).WithError(err).Error("Failed to check message quarantine")
+		return false
+	}
+	if quarantined {
+		q.logger.WithFields(q.fields(key)).Warn("Skipping quarantined message")
+	}
+	return quarantined
+}
+
+// retry records a failed attempt with cause and reports whether the message
+// is to be attempted again, after waiting for the backoff. It reports false
+// once the attempts are used up or ctx is done.
+func (q *Quarantine) retry(ctx context.Context, key models.MessagePosition, cause error) bool {
+	failures, err := q.store.RecordFailure(ctx, key, cause)
+	if err != nil {
+		q.logger.WithFields(q.fields(key)).WithError(err).Error("Failed to record message failure")
+		return false
+	}
+	if failures >= q.maxAttempts {
+		return false
+	}
+
+	timer := time.NewTimer(q.backoff * time.Duration(failures))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// recovered forgets the failures of a message that was processed after
+// failing before.
+func (q *Quarantine) recovered(ctx context.Context, key models.MessagePosition) {
+	if err := q.store.Clear(ctx, key); err != nil {
+		q.logger.WithFields(q.fields(key)).WithError(err).Warn("Failed to clear message failures")
+	}
+}
+
+// quarantine puts a dead-lettered message on the skip-list.
+func (q *Quarantine) quarantine(ctx context.Context, key models.MessagePosition) {
+	if err := q.store.Quarantine(ctx, key); err != nil {
+		q.logger.WithFields(q.fields(key)).WithError(err).Error("Failed to quarantine message")
+		return
+	}
+	q.logger.WithFields(q.fields(key)).Warn("Message quarantined")
+}
+
+func (q *Quarantine) fields(key models.MessagePosition) logrus.Fields {
+	return logrus.Fields{
+		"topic":     key.Topic,
+		"partition": key.Partition,
+		"offset":    key.Offset,
+	}
+}
\ No newline at end of file
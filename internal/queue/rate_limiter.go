@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"order-processing-microservice/pkg/config"
+)
+
+// RateLimiter limits how fast consumers hand events to their handlers, so a
+// backlog after downtime does not overwhelm the database: at most
+// EventsPerSecond events, in bursts of up to Burst, and at most MaxConcurrent
+// handlers at a time. A zero limit is not enforced. One limiter can be shared
+// by several consumers to limit them together.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	slots  chan struct{}
+}
+
+func NewRateLimiter(cfg *config.ConsumerRateLimitConfig) *RateLimiter {
+	burst := float64(max(cfg.Burst, 1))
+	limiter := &RateLimiter{
+		rate:   cfg.EventsPerSecond,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+	if cfg.MaxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	return limiter
+}
+
+// Wait blocks until an event may be handled under the rate limit, or returns
+// ctx's error if ctx is done first.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Acquire blocks until fewer than MaxConcurrent handlers are running, or
+// returns ctx's error if ctx is done first. The handler must call release
+// once done.
+func (l *RateLimiter) Acquire(ctx context.Context) (release func(), err error) {
+	if l.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	}
+}
\ No newline at end of file
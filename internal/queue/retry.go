@@ -0,0 +1,194 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/pkg/config"
+)
+
+// Headers added to messages republished to a retry topic. Existing retry_*
+// headers are replaced on every attempt.
+const (
+	RetryHeaderAttempt       = "retry_attempt"
+	RetryHeaderMaxAttempts   = "retry_max_attempts"
+	RetryHeaderOriginalTopic = "retry_original_topic"
+	RetryHeaderNotBefore     = "retry_not_before"
+	RetryHeaderError         = "retry_error"
+)
+
+// RetryAfter is implemented by handler errors that know when the failed event
+// should next be attempted. The retry queue schedules such messages for
+// RetryAt instead of after their tier's delay.
+type RetryAfter interface {
+	RetryAt() time.Time
+}
+
+// RetryTier is one retry topic level, named after its delay.
+type RetryTier struct {
+	Suffix string
+	Delay  time.Duration
+}
+
+// ParseRetryTiers parses delays such as "1m,5m,30m" into tiers, in order.
+// Empty entries are ignored.
+func ParseRetryTiers(delays []string) ([]RetryTier, error) {
+	var tiers []RetryTier
+	for _, raw := range delays {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		delay, err := time.ParseDuration(raw)
+		if err != nil || delay <= 0 {
+			return nil, fmt.Errorf("invalid retry delay %q", raw)
+		}
+		tiers = append(tiers, RetryTier{Suffix: raw, Delay: delay})
+	}
+	return tiers, nil
+}
+
+// RetryTopic returns the retry topic of tier for topic, e.g.
+// order-events-retry-5m.
+func RetryTopic(topic string, tier RetryTier) string {
+	return topic + "-retry-" + tier.Suffix
+}
+
+// RetryQueue republishes messages whose handler failed to tiered retry topics,
+// one tier per attempt, stamped with the time they become due. Messages that
+// failed on the last tier are left to the dead-letter queue.
+type RetryQueue struct {
+	producer sarama.SyncProducer
+	tiers    []RetryTier
+	logger   *logrus.Entry
+}
+
+func NewRetryQueue(cfg *config.KafkaConfig, tiers []RetryTier) (*RetryQueue, error) {
+	if len(tiers) == 0 {
+		return nil, fmt.Errorf("retry queue requires at least one retry delay")
+	}
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Retry.Max = cfg.RetryAttempts
+	saramaConfig.Producer.Retry.Backoff = time.Millisecond * 250
+
+	producer, err := sarama.NewSyncProducer(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retry producer: %w", err)
+	}
+
+	return NewRetryQueueWithProducer(producer, tiers), nil
+}
+
+func NewRetryQueueWithProducer(producer sarama.SyncProducer, tiers []RetryTier) *RetryQueue {
+	return &RetryQueue{
+		producer: producer,
+		tiers:    tiers,
+		logger:   logrus.WithField("component", "retry_queue"),
+	}
+}
+
+// Topics returns the retry topics of every topic, tier by tier.
+func (q *RetryQueue) Topics(topics []string) []string {
+	retryTopics := make([]string, 0, len(topics)*len(q.tiers))
+	for _, topic := range topics {
+		for _, tier := range q.tiers {
+			retryTopics = append(retryTopics, RetryTopic(topic, tier))
+		}
+	}
+	return retryTopics
+}
+
+// Publish schedules the next attempt of the message that failed with cause,
+// see RetryAfter. It returns false, without publishing, when every tier has been tried.
+func (q *RetryQueue) Publish(ctx context.Context, message *sarama.ConsumerMessage, cause error) (bool, error) {
+	attempt, _ := strconv.Atoi(messageHeader(message, RetryHeaderAttempt))
+	if attempt >= len(q.tiers) {
+		return false, nil
+	}
+
+	originalTopic := messageHeader(message, RetryHeaderOriginalTopic)
+	if originalTopic == "" {
+		originalTopic = message.Topic
+	}
+	tier := q.tiers[attempt]
+	notBefore := time.Now().Add(tier.Delay)
+	var retryAfter RetryAfter
+	if errors.As(cause, &retryAfter) && !retryAfter.RetryAt().IsZero() {
+		notBefore = retryAfter.RetryAt()
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+5)
+	for _, h := range message.Headers {
+		if h == nil || strings.HasPrefix(string(h.Key), "retry_") {
+			continue
+		}
+		headers = append(headers, *h)
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(RetryHeaderAttempt), Value: []byte(strconv.Itoa(attempt + 1))},
+		sarama.RecordHeader{Key: []byte(RetryHeaderMaxAttempts), Value: []byte(strconv.Itoa(len(q.tiers)))},
+		sarama.RecordHeader{Key: []byte(RetryHeaderOriginalTopic), Value: []byte(originalTopic)},
+		sarama.RecordHeader{Key: []byte(RetryHeaderNotBefore), Value: []byte(strconv.FormatInt(notBefore.UnixMilli(), 10))},
+		sarama.RecordHeader{Key: []byte(RetryHeaderError), Value: []byte(cause.Error())},
+	)
+
+	retryMessage := &sarama.ProducerMessage{
+		Topic:   RetryTopic(originalTopic, tier),
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+	if message.Key != nil {
+		retryMessage.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	if _, _, err := q.producer.SendMessage(retryMessage); err != nil {
+		return false, fmt.Errorf("failed to publish to retry topic: %w", err)
+	}
+
+	q.logger.WithFields(logrus.Fields{
+		"topic":      retryMessage.Topic,
+		"partition":  message.Partition,
+		"offset":     message.Offset,
+		"attempt":    attempt + 1,
+		"not_before": notBefore.UTC().Format(time.RFC3339),
+	}).Warn("Message scheduled for retry")
+	return true, nil
+}
+
+// Delay returns how long until a message from a retry topic is due, or zero
+// for messages that are due or were not retried.
+func (q *RetryQueue) Delay(message *sarama.ConsumerMessage) time.Duration {
+	notBefore, err := strconv.ParseInt(messageHeader(message, RetryHeaderNotBefore), 10, 64)
+	if err != nil {
+		return 0
+	}
+	if delay := time.Until(time.UnixMilli(notBefore)); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+func (q *RetryQueue) Close() error {
+	if err := q.producer.Close(); err != nil {
+		return fmt.Errorf("failed to close retry producer: %w", err)
+	}
+	return nil
+}
+
+func messageHeader(message *sarama.ConsumerMessage, key string) string {
+	for _, h := range message.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
\ No newline at end of file
@@ -0,0 +1,167 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"order-processing-microservice/pkg/config"
+)
+
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+// Schema registry error codes for a missing subject, version and schema.
+const (
+	registrySubjectNotFound = 40401
+	registryVersionNotFound = 40402
+	registrySchemaNotFound  = 40403
+)
+
+// SchemaRegistryClient talks to the REST API of a Confluent compatible schema
+// registry.
+type SchemaRegistryClient struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+// SchemaRegistryError is an error response of the registry.
+type SchemaRegistryError struct {
+	StatusCode int
+	ErrorCode  int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *SchemaRegistryError) Error() string {
+	return fmt.Sprintf("schema registry error %d: %s", e.ErrorCode, e.Message)
+}
+
+func NewSchemaRegistryClient(cfg *config.KafkaConfig) *SchemaRegistryClient {
+	timeout := time.Duration(cfg.SchemaRegistryTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &SchemaRegistryClient{
+		baseURL:  strings.TrimRight(cfg.SchemaRegistryURL, "/"),
+		username: cfg.SchemaRegistryUsername,
+		password: cfg.SchemaRegistryPassword,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Register registers schema under subject and returns its ID. Registering a
+// schema that is already registered returns the existing ID.
+func (c *SchemaRegistryClient) Register(ctx context.Context, subject, schema string) (int, error) {
+	var resp struct {
+		ID int `json:"id"`
+	}
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"schema": schema}, &resp); err != nil {
+		return 0, fmt.Errorf("failed to register schema for subject %s: %w", subject, err)
+	}
+	return resp.ID, nil
+}
+
+// Lookup returns the ID of schema if it is registered under subject.
+func (c *SchemaRegistryClient) Lookup(ctx context.Context, subject, schema string) (int, error) {
+	var resp struct {
+		ID int `json:"id"`
+	}
+	path := "/subjects/" + url.PathEscape(subject)
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"schema": schema}, &resp); err != nil {
+		return 0, fmt.Errorf("failed to look up schema for subject %s: %w", subject, err)
+	}
+	return resp.ID, nil
+}
+
+// CheckCompatibility reports whether schema is compatible with the latest
+// version of subject under the subject's compatibility level. A subject
+// without versions accepts any schema.
+func (c *SchemaRegistryClient) CheckCompatibility(ctx context.Context, subject, schema string) (bool, error) {
+	var resp struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	path := "/compatibility/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	err := c.do(ctx, http.MethodPost, path, map[string]string{"schema": schema}, &resp)
+	if isRegistryError(err, registrySubjectNotFound, registryVersionNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check compatibility of subject %s: %w", subject, err)
+	}
+	return resp.IsCompatible, nil
+}
+
+// SchemaByID returns the schema registered under id.
+func (c *SchemaRegistryClient) SchemaByID(ctx context.Context, id int) (string, error) {
+	var resp struct {
+		Schema string `json:"schema"`
+	}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+	return resp.Schema, nil
+}
+
+func isRegistryError(err error, codes ...int) bool {
+	var registryErr *SchemaRegistryError
+	if !errors.As(err, &registryErr) {
+		return false
+	}
+	for _, code := range codes {
+		if registryErr.ErrorCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *SchemaRegistryClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		registryErr := &SchemaRegistryError{StatusCode: resp.StatusCode}
+		if json.Unmarshal(data, registryErr) != nil || registryErr.Message == "" {
+			registryErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return registryErr
+	}
+	return json.Unmarshal(data, out)
+}
\ No newline at end of file
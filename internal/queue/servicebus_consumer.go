@@ -0,0 +1,301 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/Azure/go-amqp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/logger"
+)
+
+const serviceBusReceiveBatch = 10
+
+// ServiceBusConsumer receives events from a session-enabled subscription of
+// each topic; the subscription is named after the group ID, so subscriptions
+// play the role of consumer groups. Each worker holds one session at a time
+// and handles its messages in order. A failed message is abandoned for
+// redelivery and dead-lettered once it has been delivered MaxDeliveryAttempts
+// times; messages that cannot be decoded are dead-lettered immediately.
+type ServiceBusConsumer struct {
+	client        ServiceBusClient
+	topics        []string
+	subscription  string
+	region        string
+	sessions      int
+	sessionIdle   time.Duration
+	maxDeliveries uint32
+	codec         JSONCodec
+	handler       EventHandler
+	crashes       *logger.CrashReporter
+	logger        *logrus.Entry
+	cancel        context.CancelFunc
+	done          chan struct{}
+	err           error
+}
+
+// ServiceBusClient accepts sessions of topic subscriptions. It is the part of
+// *azservicebus.Client the consumer uses, so tests can replace it.
+type ServiceBusClient interface {
+	AcceptNextSessionForSubscription(ctx context.Context, topic, subscription string) (ServiceBusSession, error)
+	Close(ctx context.Context) error
+}
+
+// ServiceBusSession is the part of *azservicebus.SessionReceiver the consumer
+// uses.
+type ServiceBusSession interface {
+	SessionID() string
+	ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error)
+	CompleteMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.CompleteMessageOptions) error
+	AbandonMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.AbandonMessageOptions) error
+	DeadLetterMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.DeadLetterOptions) error
+	Close(ctx context.Context) error
+}
+
+type serviceBusClient struct {
+	client *azservicebus.Client
+}
+
+func (c serviceBusClient) AcceptNextSessionForSubscription(ctx context.Context, topic, subscription string) (ServiceBusSession, error) {
+	receiver, err := c.client.AcceptNextSessionForSubscription(ctx, topic, subscription, nil)
+	if err != nil {
+		return nil, err
+	}
+	return receiver, nil
+}
+
+func (c serviceBusClient) Close(ctx context.Context) error {
+	return c.client.Close(ctx)
+}
+
+func NewServiceBusConsumer(sbCfg *config.ServiceBusConfig, kafkaCfg *config.KafkaConfig, topics []string) (*ServiceBusConsumer, error) {
+	client, err := azservicebus.NewClientFromConnectionString(sbCfg.ConnectionString, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Service Bus client: %w", err)
+	}
+
+	consumer := NewServiceBusConsumerWithClient(serviceBusClient{client: client}, sbCfg, kafkaCfg, topics)
+	consumer.logger.Info("Service Bus consumer created successfully")
+	return consumer, nil
+}
+
+func NewServiceBusConsumerWithClient(client ServiceBusClient, sbCfg *config.ServiceBusConfig, kafkaCfg *config.KafkaConfig, topics []string) *ServiceBusConsumer {
+	logger := logrus.WithFields(logrus.Fields{
+		"component":    "servicebus_consumer",
+		"subscription": kafkaCfg.GroupID,
+		"topics":       topics,
+	})
+
+	return &ServiceBusConsumer{
+		client:        client,
+		topics:        topics,
+		subscription:  kafkaCfg.GroupID,
+		region:        kafkaCfg.Region,
+		sessions:      sbCfg.MaxConcurrentSessions,
+		sessionIdle:   time.Duration(sbCfg.SessionIdleTimeout) * time.Second,
+		maxDeliveries: uint32(sbCfg.MaxDeliveryAttempts),
+		logger:        logger,
+	}
+}
+
+// EnableCrashReports recovers the handler from panics, abandoning or
+// dead-lettering the message as a handler error would and recording a crash
+// report of it with crashes. It must be called before Subscribe.
+func (c *ServiceBusConsumer) EnableCrashReports(crashes *logger.CrashReporter) {
+	c.crashes = crashes
+}
+
+func (c *ServiceBusConsumer) Subscribe(ctx context.Context, handler EventHandler) error {
+	c.handler = handler
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+
+	group, ctx := errgroup.WithContext(ctx)
+	for _, topic := range c.topics {
+		topic := topic
+		for i := 0; i < c.sessions; i++ {
+			group.Go(func() error {
+				return c.consumeSessions(ctx, topic)
+			})
+		}
+	}
+
+	c.done = make(chan struct{})
+	go func() {
+		c.err = group.Wait()
+		close(c.done)
+	}()
+
+	c.logger.Info("Started consuming messages")
+	return nil
+}
+
+// consumeSessions accepts the topic's sessions one after another until ctx is
+// done or a fatal error occurs.
+func (c *ServiceBusConsumer) consumeSessions(ctx context.Context, topic string) error {
+	for {
+		receiver, err := c.client.AcceptNextSessionForSubscription(ctx, topic, c.subscription)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			if isServiceBusCode(err, azservicebus.CodeTimeout) {
+				continue
+			}
+			if isFatalServiceBusError(err) {
+				return fmt.Errorf("fatal error accepting Service Bus session: %w", err)
+			}
+			c.logger.WithError(err).Error("Error accepting Service Bus session")
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		if err := c.consumeSession(ctx, topic, receiver); err != nil && ctx.Err() == nil {
+			c.logger.WithFields(logrus.Fields{
+				"topic":      topic,
+				"session_id": receiver.SessionID(),
+				"error":      err,
+			}).Error("Error consuming Service Bus session")
+		}
+		receiver.Close(context.Background())
+	}
+}
+
+// consumeSession handles the session's messages until it has been idle for
+// the session idle timeout, releasing it to other workers.
+func (c *ServiceBusConsumer) consumeSession(ctx context.Context, topic string, receiver ServiceBusSession) error {
+	for {
+		receiveCtx, cancel := context.WithTimeout(ctx, c.sessionIdle)
+		messages, err := receiver.ReceiveMessages(receiveCtx, serviceBusReceiveBatch, nil)
+		cancel()
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		for _, message := range messages {
+			if err := c.settle(ctx, topic, receiver, message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *ServiceBusConsumer) settle(ctx context.Context, topic string, receiver ServiceBusSession, message *azservicebus.ReceivedMessage) error {
+	logger := c.logger.WithFields(logrus.Fields{
+		"topic":          topic,
+		"message_id":     message.MessageID,
+		"delivery_count": message.DeliveryCount,
+	})
+
+	event, err := c.codec.Unmarshal(message.Body)
+	if err != nil {
+		logger.WithError(err).Error("Failed to unmarshal event, dead-lettering message")
+		return c.deadLetter(ctx, receiver, message, "DecodeFailed", err)
+	}
+
+	if c.region != "" && event.Region != "" && event.Region != c.region {
+		logger.WithFields(logrus.Fields{
+			"event_id":     event.ID,
+			"event_type":   event.Type,
+			"event_region": event.Region,
+		}).Debug("Ignoring event from foreign region")
+		return receiver.CompleteMessage(ctx, message, nil)
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+	})
+	logger.Info("Processing event")
+
+	err = handleReporting(ctx, c.handler, event, c.crashes, logrus.Fields{
+		"subscription":   c.subscription,
+		"topic":          topic,
+		"message_id":     message.MessageID,
+		"delivery_count": message.DeliveryCount,
+	})
+	if err != nil {
+		if message.DeliveryCount >= c.maxDeliveries {
+			logger.WithError(err).Error("Handler failed on the last delivery attempt, dead-lettering message")
+			return c.deadLetter(ctx, receiver, message, "MaxDeliveryAttemptsExceeded", err)
+		}
+		logger.WithError(err).Error("Handler failed to process event, abandoning message for redelivery")
+		return receiver.AbandonMessage(ctx, message, nil)
+	}
+
+	if err := receiver.CompleteMessage(ctx, message, nil); err != nil {
+		return fmt.Errorf("failed to complete message: %w", err)
+	}
+
+	logger.Info("Event processed successfully")
+	return nil
+}
+
+func (c *ServiceBusConsumer) deadLetter(ctx context.Context, receiver ServiceBusSession, message *azservicebus.ReceivedMessage, reason string, cause error) error {
+	description := cause.Error()
+	return receiver.DeadLetterMessage(ctx, message, &azservicebus.DeadLetterOptions{
+		Reason:           &reason,
+		ErrorDescription: &description,
+	})
+}
+
+func isServiceBusCode(err error, code azservicebus.Code) bool {
+	var sbErr *azservicebus.Error
+	return errors.As(err, &sbErr) && sbErr.Code == code
+}
+
+// isFatalServiceBusError reports errors that retrying cannot fix: missing
+// permissions or a missing topic or subscription. The SDK has no code for the
+// latter and returns the AMQP error of the link as is.
+func isFatalServiceBusError(err error) bool {
+	var amqpErr *amqp.Error
+	return isServiceBusCode(err, azservicebus.CodeUnauthorizedAccess) ||
+		errors.As(err, &amqpErr) && amqpErr.Condition == amqp.ErrCondNotFound
+}
+
+// Assignment reports no partitions; Service Bus balances sessions itself.
+func (c *ServiceBusConsumer) Assignment() PartitionAssignment {
+	return PartitionAssignment{
+		GroupID:    c.subscription,
+		Partitions: map[string][]int32{},
+		Healthy:    true,
+	}
+}
+
+// Wait blocks until the consumer stops and returns the fatal error that
+// stopped it, or nil after Close.
+func (c *ServiceBusConsumer) Wait() error {
+	if c.done == nil {
+		return nil
+	}
+	<-c.done
+	return c.err
+}
+
+func (c *ServiceBusConsumer) Close() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	c.Wait()
+
+	if err := c.client.Close(context.Background()); err != nil {
+		c.logger.WithError(err).Error("Failed to close Service Bus consumer")
+		return fmt.Errorf("failed to close consumer: %w", err)
+	}
+	c.logger.Info("Service Bus consumer closed successfully")
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+// ServiceBusProducer publishes events to Azure Service Bus topics named like
+// the Kafka topics. Each message's session ID is the event's order ID, so
+// session-enabled subscriptions receive an order's events in order.
+type ServiceBusProducer struct {
+	client  *azservicebus.Client
+	mu      sync.Mutex
+	senders map[string]*azservicebus.Sender
+	router  *TopicRouter
+	region  string
+	codec   JSONCodec
+	logger  *logrus.Entry
+}
+
+func NewServiceBusProducer(sbCfg *config.ServiceBusConfig, kafkaCfg *config.KafkaConfig) (*ServiceBusProducer, error) {
+	router, err := NewTopicRouter(kafkaCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := azservicebus.NewClientFromConnectionString(sbCfg.ConnectionString, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Service Bus client: %w", err)
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"component": "servicebus_producer",
+		"topic":     kafkaCfg.OrderTopic,
+	})
+	logger.Info("Service Bus producer created successfully")
+
+	return &ServiceBusProducer{
+		client:  client,
+		senders: make(map[string]*azservicebus.Sender),
+		router:  router,
+		region:  kafkaCfg.Region,
+		logger:  logger,
+	}, nil
+}
+
+func (p *ServiceBusProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	return p.PublishEventToTopic(ctx, p.router.Route(event), event)
+}
+
+func (p *ServiceBusProducer) PublishEventToTopic(ctx context.Context, topic string, event *models.Event) error {
+	if event.Region == "" {
+		event.Region = p.region
+	}
+
+	body, err := p.codec.Marshal(event)
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to marshal event")
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	sender, err := p.sender(topic)
+	if err != nil {
+		return err
+	}
+
+	messageID := event.ID.String()
+	sessionID := eventSessionID(event)
+	subject := string(event.Type)
+	contentType := p.codec.ContentType()
+	message := &azservicebus.Message{
+		Body:        body,
+		MessageID:   &messageID,
+		SessionID:   &sessionID,
+		Subject:     &subject,
+		ContentType: &contentType,
+		ApplicationProperties: map[string]any{
+			"event_type": string(event.Type),
+			"region":     event.Region,
+		},
+	}
+
+	if err := sender.SendMessage(ctx, message, nil); err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"error":      err,
+		}).Error("Failed to publish event to Service Bus")
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"topic":      topic,
+		"session_id": sessionID,
+	}).Info("Event published successfully")
+
+	return nil
+}
+
+func (p *ServiceBusProducer) sender(topic string) (*azservicebus.Sender, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if sender, ok := p.senders[topic]; ok {
+		return sender, nil
+	}
+
+	sender, err := p.client.NewSender(topic, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Service Bus sender for %s: %w", topic, err)
+	}
+	p.senders[topic] = sender
+	return sender, nil
+}
+
+// eventSessionID returns the event's order ID, or its event ID for events not
+// about a single order.
+func eventSessionID(event *models.Event) string {
+	var ref struct {
+		OrderID uuid.UUID `json:"order_id"`
+	}
+	if err := event.DecodeData(&ref); err == nil && ref.OrderID != uuid.Nil {
+		return ref.OrderID.String()
+	}
+	return event.ID.String()
+}
+
+func (p *ServiceBusProducer) Close() error {
+	ctx := context.Background()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for topic, sender := range p.senders {
+		if err := sender.Close(ctx); err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"topic": topic,
+				"error": err,
+			}).Error("Failed to close Service Bus sender")
+		}
+	}
+
+	if err := p.client.Close(ctx); err != nil {
+		p.logger.WithError(err).Error("Failed to close Service Bus producer")
+		return fmt.Errorf("failed to close producer: %w", err)
+	}
+	p.logger.Info("Service Bus producer closed successfully")
+	return nil
+}
\ No newline at end of file
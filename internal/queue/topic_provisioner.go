@@ -0,0 +1,174 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/pkg/config"
+)
+
+// TopicProvisioner creates the topics the service uses when they do not exist
+// yet, so deployments to a fresh cluster do not depend on the broker's
+// auto.create.topics.enable. Existing topics are left unchanged.
+type TopicProvisioner struct {
+	admin             sarama.ClusterAdmin
+	partitions        int32
+	replicationFactor int16
+	retention         time.Duration
+	dlqTopic          string
+	dlqRetention      time.Duration
+	logger            *logrus.Entry
+}
+
+func NewTopicProvisioner(cfg *config.KafkaConfig) (*TopicProvisioner, error) {
+	admin, err := sarama.NewClusterAdmin(cfg.Brokers, sarama.NewConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka cluster admin: %w", err)
+	}
+	return NewTopicProvisionerWithAdmin(admin, cfg), nil
+}
+
+func NewTopicProvisionerWithAdmin(admin sarama.ClusterAdmin, cfg *config.KafkaConfig) *TopicProvisioner {
+	return &TopicProvisioner{
+		admin:             admin,
+		partitions:        int32(cfg.TopicPartitions),
+		replicationFactor: int16(cfg.TopicReplicationFactor),
+		retention:         time.Duration(cfg.TopicRetention) * time.Hour,
+		dlqTopic:          cfg.DLQTopic,
+		dlqRetention:      time.Duration(cfg.DLQRetention) * time.Hour,
+		logger:            logrus.WithField("component", "topic_provisioner"),
+	}
+}
+
+// ProvisionedTopics returns every topic of cfg: the order topic, the routed,
+// tenant and consumer topics, the migration topic, their retry topics and the DLQ.
+func ProvisionedTopics(cfg *config.KafkaConfig) ([]string, error) {
+	topics, err := SubscribedTopics(cfg)
+	if err != nil {
+		return nil, err
+	}
+	router, err := NewTopicRouter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	topics = append(topics, router.Topics()...)
+	for _, topic := range router.TenantTopics() {
+		topics = append(topics, topic)
+	}
+	if cfg.MigrationTopic != "" {
+		topics = append(topics, cfg.MigrationTopic)
+	}
+
+	tiers, err := ParseRetryTiers(cfg.RetryDelays)
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range topics {
+		for _, tier := range tiers {
+			topics = append(topics, RetryTopic(topic, tier))
+		}
+	}
+	if cfg.DLQTopic != "" {
+		topics = append(topics, cfg.DLQTopic)
+	}
+
+	seen := make(map[string]bool, len(topics))
+	unique := topics[:0]
+	for _, topic := range topics {
+		if !seen[topic] {
+			seen[topic] = true
+			unique = append(unique, topic)
+		}
+	}
+	sort.Strings(unique)
+	return unique, nil
+}
+
+// Provision creates the topics that do not exist and returns the ones it
+// created.
+func (p *TopicProvisioner) Provision(topics []string) ([]string, error) {
+	existing, err := p.admin.ListTopics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+
+	var created []string
+	for _, topic := range topics {
+		if _, ok := existing[topic]; ok {
+			continue
+		}
+
+		err := p.admin.CreateTopic(topic, p.topicDetail(topic), false)
+		if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+			// Created concurrently by another instance.
+			continue
+		}
+		if err != nil {
+			return created, fmt.Errorf("failed to create topic %s: %w", topic, err)
+		}
+		created = append(created, topic)
+
+		p.logger.WithFields(logrus.Fields{
+			"topic":              topic,
+			"partitions":         p.partitions,
+			"replication_factor": p.replicationFactor,
+		}).Info("Kafka topic created")
+	}
+	return created, nil
+}
+
+// topicDetail is the configuration of a new topic. A partition count or
+// replication factor of -1, and a zero retention, use the broker default.
+func (p *TopicProvisioner) topicDetail(topic string) *sarama.TopicDetail {
+	detail := &sarama.TopicDetail{
+		NumPartitions:     p.partitions,
+		ReplicationFactor: p.replicationFactor,
+	}
+	if detail.NumPartitions <= 0 {
+		detail.NumPartitions = -1
+	}
+	if detail.ReplicationFactor <= 0 {
+		detail.ReplicationFactor = -1
+	}
+
+	retention := p.retention
+	if topic == p.dlqTopic && p.dlqRetention > 0 {
+		retention = p.dlqRetention
+	}
+	if retention > 0 {
+		retentionMS := strconv.FormatInt(retention.Milliseconds(), 10)
+		detail.ConfigEntries = map[string]*string{"retention.ms": &retentionMS}
+	}
+	return detail
+}
+
+func (p *TopicProvisioner) Close() error {
+	return p.admin.Close()
+}
+
+// ProvisionTopics creates the missing topics of cfg when
+// cfg.AutoCreateTopics is set.
+func ProvisionTopics(cfg *config.KafkaConfig) error {
+	if !cfg.AutoCreateTopics {
+		return nil
+	}
+
+	topics, err := ProvisionedTopics(cfg)
+	if err != nil {
+		return err
+	}
+
+	provisioner, err := NewTopicProvisioner(cfg)
+	if err != nil {
+		return err
+	}
+	defer provisioner.Close()
+
+	_, err = provisioner.Provision(topics)
+	return err
+}
\ No newline at end of file
@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+// TopicRouter picks the topic PublishEvent sends an event to. Event types
+// routed in KafkaConfig.TopicRoutes go to their own topic so downstream teams
+// can subscribe to just those. Other events of tenants isolated in
+// KafkaConfig.TenantTopics go to the tenant's topic, consumed by a group of
+// its own, and all remaining events to the order topic.
+type TopicRouter struct {
+	orderTopic string
+	routes     map[models.EventType]string
+	tenants    map[string]string
+}
+
+// NewTopicRouter parses cfg.TopicRoutes, given as event_type=topic entries,
+// and cfg.TenantTopics, given as tenant=topic entries.
+func NewTopicRouter(cfg *config.KafkaConfig) (*TopicRouter, error) {
+	routes := make(map[models.EventType]string, len(cfg.TopicRoutes))
+	for _, entry := range cfg.TopicRoutes {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		eventType, topic, ok := strings.Cut(entry, "=")
+		eventType, topic = strings.TrimSpace(eventType), strings.TrimSpace(topic)
+		if !ok || eventType == "" || topic == "" {
+			return nil, fmt.Errorf("invalid topic route %q, expected event_type=topic", entry)
+		}
+		if existing, ok := routes[models.EventType(eventType)]; ok && existing != topic {
+			return nil, fmt.Errorf("event type %s is routed to both %s and %s", eventType, existing, topic)
+		}
+		routes[models.EventType(eventType)] = topic
+	}
+
+	tenants, err := parseTenantTopics(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range tenants {
+		if topic == cfg.OrderTopic {
+			return nil, fmt.Errorf("tenant topic %s is the order topic", topic)
+		}
+		for eventType, routed := range routes {
+			if routed == topic {
+				return nil, fmt.Errorf("tenant topic %s is also the topic of event type %s", topic, eventType)
+			}
+		}
+	}
+	return &TopicRouter{orderTopic: cfg.OrderTopic, routes: routes, tenants: tenants}, nil
+}
+
+func parseTenantTopics(cfg *config.KafkaConfig) (map[string]string, error) {
+	tenants := make(map[string]string, len(cfg.TenantTopics))
+	owners := make(map[string]string, len(cfg.TenantTopics))
+	for _, entry := range cfg.TenantTopics {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		tenant, topic, ok := strings.Cut(entry, "=")
+		tenant, topic = strings.TrimSpace(tenant), strings.TrimSpace(topic)
+		if !ok || tenant == "" || topic == "" {
+			return nil, fmt.Errorf("invalid tenant topic %q, expected tenant=topic", entry)
+		}
+		if existing, ok := tenants[tenant]; ok && existing != topic {
+			return nil, fmt.Errorf("tenant %s is routed to both %s and %s", tenant, existing, topic)
+		}
+		if owner, ok := owners[topic]; ok && owner != tenant {
+			return nil, fmt.Errorf("tenant topic %s is shared by tenants %s and %s", topic, owner, tenant)
+		}
+		tenants[tenant] = topic
+		owners[topic] = tenant
+	}
+	return tenants, nil
+}
+
+// Route returns the topic event is published to.
+func (r *TopicRouter) Route(event *models.Event) string {
+	if topic, ok := r.routes[event.Type]; ok {
+		return topic
+	}
+	if topic, ok := r.tenants[event.TenantID]; ok && event.TenantID != "" {
+		return topic
+	}
+	return r.orderTopic
+}
+
+// Topic returns the topic events of eventType are published to.
+func (r *TopicRouter) Topic(eventType models.EventType) string {
+	if topic, ok := r.routes[eventType]; ok {
+		return topic
+	}
+	return r.orderTopic
+}
+
+// Topics returns the order topic and every routed topic, sorted and without
+// duplicates. Tenant topics are not included; see TenantTopics.
+func (r *TopicRouter) Topics() []string {
+	seen := map[string]bool{r.orderTopic: true}
+	topics := []string{r.orderTopic}
+	for _, topic := range r.routes {
+		if !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// TenantTopics returns the isolated tenants and their topics.
+func (r *TopicRouter) TenantTopics() map[string]string {
+	return maps.Clone(r.tenants)
+}
+
+// TenantGroupID returns the consumer group that consumes tenant's topic in
+// isolation from groupID.
+func TenantGroupID(groupID, tenant string) string {
+	return groupID + "-tenant-" + tenant
+}
+
+// SubscribedTopics returns the topics the order consumer subscribes to:
+// cfg.ConsumerTopics when set, otherwise every topic events are routed to.
+func SubscribedTopics(cfg *config.KafkaConfig) ([]string, error) {
+	var topics []string
+	for _, topic := range cfg.ConsumerTopics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	if len(topics) > 0 {
+		return topics, nil
+	}
+
+	router, err := NewTopicRouter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return router.Topics(), nil
+}
\ No newline at end of file
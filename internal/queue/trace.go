@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+// Headers carrying the trace context of an event from the request or event
+// that published it. HeaderTraceparent follows the W3C Trace Context format.
+const (
+	HeaderTraceparent   = "traceparent"
+	HeaderCorrelationID = "correlation_id"
+)
+
+// TraceContext is the trace of a request or consumed event, see
+// requestctx.Trace.
+type TraceContext = requestctx.Trace
+
+// WithTraceContext returns a context whose published events carry tc.
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return requestctx.WithTrace(ctx, tc)
+}
+
+// TraceContextFrom returns the trace context of ctx, empty if it has none.
+func TraceContextFrom(ctx context.Context) TraceContext {
+	return requestctx.TraceFrom(ctx)
+}
+
+// ValidTraceparent reports whether traceparent is a version 00 W3C
+// traceparent, see requestctx.ValidTraceparent.
+func ValidTraceparent(traceparent string) bool {
+	return requestctx.ValidTraceparent(traceparent)
+}
+
+// ChildTraceparent returns a traceparent for a new span in the trace of
+// parent, or for a new sampled trace if parent is not valid.
+func ChildTraceparent(parent string) string {
+	if !ValidTraceparent(parent) {
+		return "00-" + randomHex(16) + "-" + randomHex(8) + "-01"
+	}
+	parts := strings.Split(parent, "-")
+	return "00-" + parts[1] + "-" + randomHex(8) + "-" + parts[3]
+}
+
+// traceHeaders returns the headers propagating the trace context of ctx to an
+// event published in a new span of it.
+func traceHeaders(ctx context.Context) []sarama.RecordHeader {
+	tc := TraceContextFrom(ctx)
+	headers := []sarama.RecordHeader{
+		{Key: []byte(HeaderTraceparent), Value: []byte(ChildTraceparent(tc.Traceparent))},
+	}
+	if tc.CorrelationID != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(HeaderCorrelationID), Value: []byte(tc.CorrelationID)})
+	}
+	return headers
+}
+
+// messageTraceContext returns the trace context of a consumed message, in a
+// new span of the publisher's trace.
+func messageTraceContext(message *sarama.ConsumerMessage) TraceContext {
+	return TraceContext{
+		Traceparent:   ChildTraceparent(messageHeader(message, HeaderTraceparent)),
+		CorrelationID: messageHeader(message, HeaderCorrelationID),
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return strings.Repeat("0", 2*n-1) + "1"
+	}
+	return hex.EncodeToString(b)
+}
\ No newline at end of file
@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"database/sql"
+	"fmt"
+
+	"order-processing-microservice/pkg/config"
+)
+
+const (
+	TransportKafka      = "kafka"
+	TransportPostgres   = "postgres"
+	TransportServiceBus = "servicebus"
+)
+
+// NewTransportProducer creates the producer of the configured transport.
+func NewTransportProducer(cfg *config.Config, db *sql.DB) (TopicProducer, error) {
+	switch cfg.Queue.Transport {
+	case TransportKafka, "":
+		return newKafkaTransportProducer(&cfg.Kafka)
+	case TransportPostgres:
+		return NewPostgresProducer(db, &cfg.Kafka)
+	case TransportServiceBus:
+		return NewServiceBusProducer(&cfg.ServiceBus, &cfg.Kafka)
+	default:
+		return nil, fmt.Errorf("unknown queue transport %q", cfg.Queue.Transport)
+	}
+}
+
+// NewTransportConsumer creates a consumer of the configured transport for the
+// topics, using kafkaCfg for the group ID, initial offset and region.
+func NewTransportConsumer(cfg *config.Config, kafkaCfg *config.KafkaConfig, db *sql.DB, topics []string) (Consumer, error) {
+	switch cfg.Queue.Transport {
+	case TransportKafka, "":
+		return NewKafkaConsumerForTopics(kafkaCfg, topics)
+	case TransportPostgres:
+		return NewPostgresConsumer(db, cfg.Database.GetDSN(), kafkaCfg, &cfg.Queue, topics)
+	case TransportServiceBus:
+		return NewServiceBusConsumer(&cfg.ServiceBus, kafkaCfg, topics)
+	default:
+		return nil, fmt.Errorf("unknown queue transport %q", cfg.Queue.Transport)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const watchdogCheckInterval = 5 * time.Second
+
+// WatchdogStatus reports a consumer's stall watchdog. A consumer is stalled
+// when its unpaused partitions have lag but no message was processed for
+// longer than the stall timeout; the watchdog then rebuilds its consumer
+// group client, counting the attempts in SelfHeals and FailedSelfHeals.
+type WatchdogStatus struct {
+	Enabled         bool       `json:"enabled"`
+	StallTimeout    string     `json:"stall_timeout,omitempty"`
+	Stalled         bool       `json:"stalled"`
+	IdleSince       *time.Time `json:"idle_since,omitempty"`
+	SelfHeals       int64      `json:"self_heals"`
+	FailedSelfHeals int64      `json:"failed_self_heals"`
+	LastSelfHeal    *time.Time `json:"last_self_heal,omitempty"`
+}
+
+type consumerWatchdog struct {
+	mu           sync.Mutex
+	timeout      time.Duration
+	newGroup     func() (sarama.ConsumerGroup, error)
+	stalled      bool
+	selfHeals    int64
+	failed       int64
+	lastSelfHeal time.Time
+}
+
+func (w *consumerWatchdog) checkInterval() time.Duration {
+	return min(watchdogCheckInterval, w.timeout)
+}
+
+func (w *consumerWatchdog) setStalled(stalled bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stalled = stalled
+}
+
+func (w *consumerWatchdog) healed(ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !ok {
+		w.failed++
+		return
+	}
+	w.selfHeals++
+	w.lastSelfHeal = time.Now()
+}
+
+func (w *consumerWatchdog) status(idleSince time.Time) WatchdogStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := WatchdogStatus{
+		Enabled:         true,
+		StallTimeout:    w.timeout.String(),
+		Stalled:         w.stalled,
+		SelfHeals:       w.selfHeals,
+		FailedSelfHeals: w.failed,
+	}
+	if !idleSince.IsZero() {
+		status.IdleSince = &idleSince
+	}
+	if !w.lastSelfHeal.IsZero() {
+		lastSelfHeal := w.lastSelfHeal
+		status.LastSelfHeal = &lastSelfHeal
+	}
+	return status
+}
\ No newline at end of file
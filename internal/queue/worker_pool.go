@@ -0,0 +1,191 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+)
+
+// workerQueueDepth is the number of messages of a partition each worker may
+// have in flight.
+const workerQueueDepth = 16
+
+// claimWorkers processes the messages of a claim on a pool of workers.
+// Messages are assigned to workers by key, so the events of one order are
+// processed in order; messages without a key share a worker. Offsets are only
+// marked up to the oldest message still in flight, so a rebalance or restart
+// never skips a message that was not processed.
+type claimWorkers struct {
+	handler  *consumerGroupHandler
+	scaler   *WorkerScaler
+	ctx      context.Context
+	queues   []chan *inflightMessage
+	results  chan *inflightMessage
+	inflight []*inflightMessage
+	wg       sync.WaitGroup
+}
+
+type inflightMessage struct {
+	message *sarama.ConsumerMessage
+	outcome messageOutcome
+	done    bool
+}
+
+func newClaimWorkers(ctx context.Context, handler *consumerGroupHandler, workers int, scaler *WorkerScaler) *claimWorkers {
+	capacity := workers * workerQueueDepth
+	w := &claimWorkers{
+		handler: handler,
+		scaler:  scaler,
+		ctx:     ctx,
+		queues:  make([]chan *inflightMessage, workers),
+		results: make(chan *inflightMessage, capacity),
+	}
+	for i := range w.queues {
+		w.queues[i] = make(chan *inflightMessage, capacity)
+		w.wg.Add(1)
+		go w.run(w.queues[i])
+	}
+	return w
+}
+
+func (w *claimWorkers) run(queue <-chan *inflightMessage) {
+	defer w.wg.Done()
+	for m := range queue {
+		// Messages still queued when the session ends are left for the
+		// next owner of the partition.
+		if w.ctx.Err() != nil || (w.scaler != nil && !w.scaler.Acquire(w.ctx)) {
+			m.outcome = outcomeInterrupted
+		} else {
+			start := time.Now()
+			m.outcome = w.handler.handleMessage(w.ctx, m.message)
+			if w.scaler != nil {
+				w.scaler.Release(time.Since(start))
+			}
+		}
+		w.results <- m
+	}
+}
+
+// backlog returns the number of messages of claim not handled yet: those in
+// flight and those after the last one dispatched.
+func (w *claimWorkers) backlog(claim sarama.ConsumerGroupClaim, next int64) int64 {
+	waiting := int64(0)
+	if next >= 0 {
+		waiting = max(claim.HighWaterMarkOffset()-next, 0)
+	}
+	return int64(len(w.inflight)) + waiting
+}
+
+// full reports whether the pool has as many messages in flight as it can
+// hold; the results channel then has room for every one of them.
+func (w *claimWorkers) full() bool {
+	return len(w.inflight) >= cap(w.results)
+}
+
+func (w *claimWorkers) dispatch(message *sarama.ConsumerMessage) {
+	m := &inflightMessage{message: message}
+	w.inflight = append(w.inflight, m)
+	w.queues[w.worker(message.Key)] <- m
+}
+
+func (w *claimWorkers) worker(key []byte) int {
+	return int(uint32(Murmur2(key)) % uint32(len(w.queues)))
+}
+
+// finished records m as handled and returns the messages, in offset order,
+// that are now handled with no earlier message in flight.
+func (w *claimWorkers) finished(m *inflightMessage) []*inflightMessage {
+	m.done = true
+
+	n := 0
+	for n < len(w.inflight) && w.inflight[n].done && w.inflight[n].outcome != outcomeInterrupted {
+		n++
+	}
+	ready := w.inflight[:n:n]
+	w.inflight = w.inflight[n:]
+	return ready
+}
+
+// close stops the workers once they have drained their queues; results is
+// closed after the last one.
+func (w *claimWorkers) close() {
+	for _, queue := range w.queues {
+		close(queue)
+	}
+	go func() {
+		w.wg.Wait()
+		close(w.results)
+	}()
+}
+
+// consumeConcurrently is ConsumeClaim with a worker pool. When the claim ends
+// it waits for the messages in flight and marks the contiguous ones before
+// returning, so their offsets are committed with the session. With adaptive
+// workers, the pool is rescaled every workerScaleInterval.
+func (h *consumerGroupHandler) consumeConcurrently(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, committer *offsetCommitter, commitTick <-chan time.Time) error {
+	var scaler *WorkerScaler
+	var scaleTick <-chan time.Time
+	if h.minWorkers > 0 && h.minWorkers < h.workers {
+		scaler = NewWorkerScaler(h.minWorkers, h.workers, h.targetLatency)
+		ticker := time.NewTicker(workerScaleInterval)
+		defer ticker.Stop()
+		scaleTick = ticker.C
+		h.workerGauge.set(claim.Topic(), claim.Partition(), scaler.Workers())
+	} else {
+		h.workerGauge.set(claim.Topic(), claim.Partition(), h.workers)
+	}
+	next := claim.InitialOffset()
+
+	workers := newClaimWorkers(session.Context(), h, h.workers, scaler)
+	completeReady := func(m *inflightMessage) {
+		for _, ready := range workers.finished(m) {
+			h.complete(session, ready.message, ready.outcome, committer)
+		}
+	}
+	defer func() {
+		workers.close()
+		for m := range workers.results {
+			completeReady(m)
+		}
+	}()
+
+	for {
+		messages := claim.Messages()
+		if workers.full() {
+			messages = nil
+		}
+
+		select {
+		case message := <-messages:
+			if message == nil {
+				return nil
+			}
+			workers.dispatch(message)
+			next = message.Offset + 1
+
+		case m := <-workers.results:
+			completeReady(m)
+
+		case <-commitTick:
+			committer.commit()
+
+		case <-scaleTick:
+			previous := scaler.Workers()
+			scaled := scaler.Adjust(workers.backlog(claim, next))
+			if scaled != previous {
+				h.workerGauge.set(claim.Topic(), claim.Partition(), scaled)
+				h.logger.WithFields(logrus.Fields{
+					"topic":     claim.Topic(),
+					"partition": claim.Partition(),
+					"workers":   scaled,
+				}).Debug("Claim workers rescaled")
+			}
+
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
\ No newline at end of file
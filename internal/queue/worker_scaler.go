@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// workerScaleInterval is how often the workers of a claim are rescaled.
+const workerScaleInterval = 2 * time.Second
+
+// WorkerScaler adapts how many of a claim's workers may handle messages at
+// once, between min and max. Each interval it adds a worker while messages
+// are waiting and handlers keep within the target latency, halves the workers
+// when handlers get slower than that, and removes a worker when the claim is
+// idle. It starts at min.
+type WorkerScaler struct {
+	mu            sync.Mutex
+	min           int
+	max           int
+	limit         int
+	active        int
+	targetLatency time.Duration
+	latency       time.Duration
+	handled       int
+	wake          chan struct{}
+}
+
+func NewWorkerScaler(min, max int, targetLatency time.Duration) *WorkerScaler {
+	return &WorkerScaler{
+		min:           min,
+		max:           max,
+		limit:         min,
+		targetLatency: targetLatency,
+		wake:          make(chan struct{}),
+	}
+}
+
+// Acquire blocks until fewer workers than the current limit are handling
+// messages, and reports false if ctx is done first. Release must be called
+// after the message is handled.
+func (s *WorkerScaler) Acquire(ctx context.Context) bool {
+	for {
+		s.mu.Lock()
+		if s.active < s.limit {
+			s.active++
+			s.mu.Unlock()
+			return true
+		}
+		wake := s.wake
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-wake:
+		}
+	}
+}
+
+// Release records that a worker finished handling a message in latency.
+func (s *WorkerScaler) Release(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active--
+	s.latency += latency
+	s.handled++
+	s.wakeLocked()
+}
+
+// Adjust rescales the workers given the number of messages of the claim not
+// yet handled, and returns the new limit.
+func (s *WorkerScaler) Adjust(backlog int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	limit := s.limit
+	switch {
+	case s.handled > 0 && s.latency/time.Duration(s.handled) > s.targetLatency:
+		limit = max(s.min, limit/2)
+	case backlog == 0:
+		limit = max(s.min, limit-1)
+	case s.handled > 0 && backlog > int64(limit):
+		limit = min(s.max, limit+1)
+	}
+	s.latency = 0
+	s.handled = 0
+
+	if limit != s.limit {
+		s.limit = limit
+		s.wakeLocked()
+	}
+	return s.limit
+}
+
+// Workers returns the current limit.
+func (s *WorkerScaler) Workers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.limit
+}
+
+func (s *WorkerScaler) wakeLocked() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// ConsumerWorkers reports how many handlers a consumer may run at once on
+// each claimed partition.
+type ConsumerWorkers struct {
+	Partitions map[string]map[int32]int `json:"partitions"`
+	Total      int                      `json:"total"`
+}
+
+type workerGauge struct {
+	mu         sync.Mutex
+	partitions map[string]map[int32]int
+}
+
+func newWorkerGauge() *workerGauge {
+	return &workerGauge{partitions: make(map[string]map[int32]int)}
+}
+
+func (g *workerGauge) set(topic string, partition int32, workers int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.partitions[topic] == nil {
+		g.partitions[topic] = make(map[int32]int)
+	}
+	g.partitions[topic][partition] = workers
+}
+
+func (g *workerGauge) remove(topic string, partition int32) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.partitions[topic], partition)
+	if len(g.partitions[topic]) == 0 {
+		delete(g.partitions, topic)
+	}
+}
+
+func (g *workerGauge) snapshot() ConsumerWorkers {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	workers := ConsumerWorkers{Partitions: make(map[string]map[int32]int, len(g.partitions))}
+	for topic, partitions := range g.partitions {
+		workers.Partitions[topic] = make(map[int32]int, len(partitions))
+		for partition, count := range partitions {
+			workers.Partitions[topic][partition] = count
+			workers.Total += count
+		}
+	}
+	return workers
+}
\ No newline at end of file
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+)
+
+type PostgresAttachmentRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresAttachmentRepository(db *sql.DB) *PostgresAttachmentRepository {
+	return &PostgresAttachmentRepository{
+		db:     db,
+		logger: logrus.WithField("component", "attachment_repository"),
+	}
+}
+
+func (r *PostgresAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	query := `
+		INSERT INTO order_attachments (id, order_id, file_name, content_type, size, checksum, storage_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		attachment.ID, attachment.OrderID, attachment.FileName, attachment.ContentType,
+		attachment.Size, attachment.Checksum, attachment.StorageKey, attachment.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresAttachmentRepository) GetByID(ctx context.Context, orderID, id uuid.UUID) (*models.Attachment, error) {
+	query := `
+		SELECT id, order_id, file_name, content_type, size, checksum, storage_key, created_at
+		FROM order_attachments
+		WHERE order_id = $1 AND id = $2
+	`
+
+	attachment, err := scanAttachment(r.db.QueryRowContext(ctx, query, orderID, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+func (r *PostgresAttachmentRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.Attachment, error) {
+	query := `
+		SELECT id, order_id, file_name, content_type, size, checksum, storage_key, created_at
+		FROM order_attachments
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []*models.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+func (r *PostgresAttachmentRepository) Delete(ctx context.Context, orderID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM order_attachments WHERE order_id = $1 AND id = $2`, orderID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if deleted == 0 {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
+
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := row.Scan(
+		&attachment.ID, &attachment.OrderID, &attachment.FileName, &attachment.ContentType,
+		&attachment.Size, &attachment.Checksum, &attachment.StorageKey, &attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
\ No newline at end of file
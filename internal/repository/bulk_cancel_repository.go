@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+)
+
+type PostgresBulkCancelRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresBulkCancelRepository(db *sql.DB) *PostgresBulkCancelRepository {
+	return &PostgresBulkCancelRepository{
+		db:     db,
+		logger: logrus.WithField("component", "bulk_cancel_repository"),
+	}
+}
+
+func (r *PostgresBulkCancelRepository) Create(ctx context.Context, job *models.BulkCancelJob) error {
+	query := `
+		INSERT INTO bulk_cancel_jobs (id, status, order_status, filter, reason_code, reason, actor, batches, canceled, published, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12)
+	`
+
+	filter, err := json.Marshal(job.Filter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bulk cancel filter: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		job.ID, job.Status, job.OrderStatus, filter, job.ReasonCode, job.Reason, job.Actor,
+		job.Batches, job.Canceled, job.Published, job.CreatedAt, job.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create bulk cancel job: %w", err)
+	}
+
+	return nil
+}
+
+// Update stores the progress of a job.
+func (r *PostgresBulkCancelRepository) Update(ctx context.Context, job *models.BulkCancelJob) error {
+	query := `
+		UPDATE bulk_cancel_jobs
+		SET status = $2, batches = $3, canceled = $4, published = $5, error = NULLIF($6, ''), updated_at = $7, completed_at = $8
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		job.ID, job.Status, job.Batches, job.Canceled, job.Published, job.Error, job.UpdatedAt, job.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update bulk cancel job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrBulkCancelJobNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresBulkCancelRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BulkCancelJob, error) {
+	query := `
+		SELECT id, status, order_status, filter, reason_code, COALESCE(reason, ''), COALESCE(actor, ''), batches, canceled, published, COALESCE(error, ''), created_at, updated_at, completed_at
+		FROM bulk_cancel_jobs
+		WHERE id = $1
+	`
+
+	job := &models.BulkCancelJob{}
+	var filter []byte
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&job.ID, &job.Status, &job.OrderStatus, &filter, &job.ReasonCode, &job.Reason, &job.Actor,
+		&job.Batches, &job.Canceled, &job.Published, &job.Error, &job.CreatedAt, &job.UpdatedAt, &job.CompletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBulkCancelJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get bulk cancel job: %w", err)
+	}
+	if err := json.Unmarshal(filter, &job.Filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal bulk cancel filter: %w", err)
+	}
+
+	return job, nil
+}
\ No newline at end of file
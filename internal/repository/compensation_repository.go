@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type PostgresCompensationRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresCompensationRepository(db *sql.DB) *PostgresCompensationRepository {
+	return &PostgresCompensationRepository{
+		db:     db,
+		logger: logrus.WithField("component", "compensation_repository"),
+	}
+}
+
+func (r *PostgresCompensationRepository) Create(ctx context.Context, compensation *models.Compensation) error {
+	query := `
+		INSERT INTO order_compensations (id, order_id, target_status, completed_steps, failure_code, failure_detail, error, status, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9)
+	`
+
+	steps := make([]string, 0, len(compensation.CompletedSteps))
+	for _, step := range compensation.CompletedSteps {
+		steps = append(steps, string(step))
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		compensation.ID, compensation.OrderID, compensation.TargetStatus, pq.Array(steps),
+		compensation.FailureCode, compensation.FailureDetail, compensation.Error, compensation.Status, compensation.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create compensation: %w", err)
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"compensation_id": compensation.ID,
+		"order_id":        compensation.OrderID,
+		"target_status":   compensation.TargetStatus,
+	}).Warn("Compensation recorded")
+	return nil
+}
+
+func (r *PostgresCompensationRepository) GetPending(ctx context.Context, limit int) ([]*models.Compensation, error) {
+	query := `
+		SELECT id, order_id, target_status, completed_steps, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), error, status, created_at, resolved_at
+		FROM order_compensations
+		WHERE status = $1
+		ORDER BY created_at ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.CompensationStatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending compensations: %w", err)
+	}
+	defer rows.Close()
+
+	var compensations []*models.Compensation
+	for rows.Next() {
+		var compensation models.Compensation
+		var steps pq.StringArray
+		err := rows.Scan(&compensation.ID, &compensation.OrderID, &compensation.TargetStatus, &steps,
+			&compensation.FailureCode, &compensation.FailureDetail, &compensation.Error, &compensation.Status, &compensation.CreatedAt, &compensation.ResolvedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan compensation: %w", err)
+		}
+		for _, step := range steps {
+			compensation.CompletedSteps = append(compensation.CompletedSteps, models.SagaStep(step))
+		}
+		compensations = append(compensations, &compensation)
+	}
+
+	return compensations, rows.Err()
+}
+
+func (r *PostgresCompensationRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.CompensationStatus) error {
+	query := `
+		UPDATE order_compensations
+		SET status = $2, resolved_at = $3
+		WHERE id = $1 AND status = $4
+	`
+
+	var resolvedAt *time.Time
+	if status == models.CompensationStatusResolved {
+		now := time.Now().UTC()
+		resolvedAt = &now
+	}
+
+	result, err := r.db.ExecContext(ctx, query, id, status, resolvedAt, models.CompensationStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to update compensation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrCompensationVersionConflict
+	}
+
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+)
+
+type PostgresDeadLetterRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresDeadLetterRepository(db *sql.DB) *PostgresDeadLetterRepository {
+	return &PostgresDeadLetterRepository{
+		db:     db,
+		logger: logrus.WithField("component", "dead_letter_repository"),
+	}
+}
+
+func (r *PostgresDeadLetterRepository) Create(ctx context.Context, event *models.DeadLetterEvent) error {
+	query := `
+		INSERT INTO dead_letter_events (id, event_id, event_type, topic, partition, "offset", consumer_group, dlq_topic, reason, error, headers, message_key, payload, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	headers, err := json.Marshal(event.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter headers: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		event.ID, event.EventID, event.EventType, event.Topic, event.Partition, event.Offset,
+		event.ConsumerGroup, event.DLQTopic, event.Reason, event.Error, headers, event.Key, event.Payload, event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create dead letter event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresDeadLetterRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DeadLetterEvent, error) {
+	query := `
+		SELECT id, event_id, COALESCE(event_type, ''), topic, partition, "offset", consumer_group, dlq_topic, reason, error, headers, message_key, payload, created_at, requeued_at
+		FROM dead_letter_events
+		WHERE id = $1
+	`
+
+	event, err := scanDeadLetterEvent(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDeadLetterNotFound
+		}
+		return nil, fmt.Errorf("failed to get dead letter event: %w", err)
+	}
+
+	return event, nil
+}
+
+func (r *PostgresDeadLetterRepository) List(ctx context.Context, limit, offset int) ([]*models.DeadLetterEvent, error) {
+	query := `
+		SELECT id, event_id, COALESCE(event_type, ''), topic, partition, "offset", consumer_group, dlq_topic, reason, error, headers, message_key, payload, created_at, requeued_at
+		FROM dead_letter_events
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.DeadLetterEvent
+	for rows.Next() {
+		event, err := scanDeadLetterEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *PostgresDeadLetterRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM dead_letter_events`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count dead letter events: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PostgresDeadLetterRepository) MarkRequeued(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE dead_letter_events SET requeued_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to mark dead letter event requeued: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the dead letter events with the given IDs and returns how
+// many existed.
+func (r *PostgresDeadLetterRepository) Delete(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM dead_letter_events WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dead letter events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dead letter events: %w", err)
+	}
+	return deleted, nil
+}
+
+func scanDeadLetterEvent(row rowScanner) (*models.DeadLetterEvent, error) {
+	event := &models.DeadLetterEvent{}
+	var eventID uuid.NullUUID
+	var headers []byte
+	var requeuedAt sql.NullTime
+
+	err := row.Scan(
+		&event.ID, &eventID, &event.EventType, &event.Topic, &event.Partition, &event.Offset,
+		&event.ConsumerGroup, &event.DLQTopic, &event.Reason, &event.Error, &headers, &event.Key, &event.Payload, &event.CreatedAt, &requeuedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if eventID.Valid {
+		event.EventID = &eventID.UUID
+	}
+	if requeuedAt.Valid {
+		event.RequeuedAt = &requeuedAt.Time
+	}
+	if err := json.Unmarshal(headers, &event.Headers); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dead letter headers: %w", err)
+	}
+
+	return event, nil
+}
\ No newline at end of file
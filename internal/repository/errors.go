@@ -0,0 +1,56 @@
+package repository
+
+import "errors"
+
+// The kinds of failure callers handle, matched with errors.Is. Repositories
+// return them wrapped in an *Error naming the resource, so errors.Is(err,
+// ErrNotFound) holds for a missing order as well as a missing attachment.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrVersionConflict = errors.New("version conflict")
+	ErrDuplicate       = errors.New("already exists")
+)
+
+// Error is a failure of one kind on one kind of resource, e.g. "order not
+// found".
+type Error struct {
+	Resource string
+	Kind     error
+}
+
+func (e *Error) Error() string {
+	return e.Resource + " " + e.Kind.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+var (
+	ErrOrderNotFound              = &Error{Resource: "order", Kind: ErrNotFound}
+	ErrOrderVersionConflict       = &Error{Resource: "order", Kind: ErrVersionConflict}
+	ErrDuplicateExternalReference = &Error{Resource: "external reference", Kind: ErrDuplicate}
+	ErrOrderContextNotFound       = &Error{Resource: "order context", Kind: ErrNotFound}
+	ErrAttachmentNotFound         = &Error{Resource: "attachment", Kind: ErrNotFound}
+	ErrBulkCancelJobNotFound      = &Error{Resource: "bulk cancel job", Kind: ErrNotFound}
+	ErrProcessingWindowsNotFound  = &Error{Resource: "processing windows", Kind: ErrNotFound}
+	ErrDeadLetterNotFound         = &Error{Resource: "dead letter event", Kind: ErrNotFound}
+	ErrTenantQuotaNotFound        = &Error{Resource: "tenant quota", Kind: ErrNotFound}
+	ErrSagaNotFound               = &Error{Resource: "saga", Kind: ErrNotFound}
+
+	// ErrDuplicateSaga means the order already has a saga, e.g. because its
+	// processing event was delivered again.
+	ErrDuplicateSaga = &Error{Resource: "saga", Kind: ErrDuplicate}
+
+	// ErrSagaVersionConflict means the saga was not awaiting the reply it
+	// was advanced with; another reply or the timeout sweep got there first.
+	ErrSagaVersionConflict = &Error{Resource: "saga", Kind: ErrVersionConflict}
+
+	// ErrCompensationVersionConflict means the compensation was no longer
+	// pending, e.g. because another operator resolved it.
+	ErrCompensationVersionConflict = &Error{Resource: "compensation", Kind: ErrVersionConflict}
+)
+
+// ErrEventAlreadyProcessed rejects a status write made while handling an
+// event that already caused one, see WithProcessedEvent.
+var ErrEventAlreadyProcessed = errors.New("event already processed")
\ No newline at end of file
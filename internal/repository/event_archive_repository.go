@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type PostgresEventArchiveRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresEventArchiveRepository(db *sql.DB) *PostgresEventArchiveRepository {
+	return &PostgresEventArchiveRepository{
+		db:     db,
+		logger: logrus.WithField("component", "event_archive_repository"),
+	}
+}
+
+// NextHour returns the hour, in UTC, of the oldest event that occurred before
+// before and after the last completed archive, or nil if there is none.
+func (r *PostgresEventArchiveRepository) NextHour(ctx context.Context, before time.Time) (*time.Time, error) {
+	query := `
+		SELECT date_trunc('hour', MIN(occurred_at) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+		FROM order_events
+		WHERE occurred_at < $1
+		  AND occurred_at >= COALESCE(
+		      (SELECT MAX(hour) + INTERVAL '1 hour' FROM event_archives WHERE status = $2),
+		      '-infinity'::timestamptz)
+	`
+
+	var hour sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, before, models.EventArchiveStatusCompleted).Scan(&hour); err != nil {
+		return nil, fmt.Errorf("failed to find next hour to archive: %w", err)
+	}
+	if !hour.Valid {
+		return nil, nil
+	}
+
+	next := hour.Time.UTC()
+	return &next, nil
+}
+
+// Claim records archive as running and reports whether this caller owns it. An
+// hour that failed, or whose run started before staleBefore, can be claimed
+// again; a completed hour cannot.
+func (r *PostgresEventArchiveRepository) Claim(ctx context.Context, archive *models.EventArchive, staleBefore time.Time) (bool, error) {
+	query := `
+		INSERT INTO event_archives (hour, object_key, status, started_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (hour) DO UPDATE
+		SET object_key = EXCLUDED.object_key, status = EXCLUDED.status, error = NULL, started_at = EXCLUDED.started_at
+		WHERE event_archives.status = $5
+		   OR (event_archives.status = EXCLUDED.status AND event_archives.started_at < $6)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		archive.Hour, archive.ObjectKey, archive.Status, archive.StartedAt, models.EventArchiveStatusFailed, staleBefore,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim event archive: %w", err)
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim event archive: %w", err)
+	}
+	return claimed == 1, nil
+}
+
+func (r *PostgresEventArchiveRepository) Complete(ctx context.Context, archive *models.EventArchive) error {
+	query := `
+		UPDATE event_archives
+		SET status = $2, event_count = $3, size_bytes = $4, error = NULL, completed_at = $5
+		WHERE hour = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, archive.Hour, archive.Status, archive.EventCount, archive.SizeBytes, archive.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete event archive: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresEventArchiveRepository) Fail(ctx context.Context, archive *models.EventArchive) error {
+	query := `UPDATE event_archives SET status = $2, error = $3 WHERE hour = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, archive.Hour, archive.Status, archive.Error); err != nil {
+		return fmt.Errorf("failed to record failed event archive: %w", err)
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"hour":  archive.Hour,
+		"error": archive.Error,
+	}).Warn("Event archive failed")
+	return nil
+}
+
+// EachEvent calls fn with the payload of each event that occurred in
+// [from, to), oldest first, stopping at the first error.
+func (r *PostgresEventArchiveRepository) EachEvent(ctx context.Context, from, to time.Time, fn func(payload []byte) error) error {
+	query := `
+		SELECT payload
+		FROM order_events
+		WHERE occurred_at >= $1 AND occurred_at < $2
+		ORDER BY occurred_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to get events to archive: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payload []byte
+		if err := rows.Scan(&payload); err != nil {
+			return fmt.Errorf("failed to scan event to archive: %w", err)
+		}
+		if err := fn(payload); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ListCompleted returns the completed archives of the hours starting in
+// [from, to), oldest first.
+func (r *PostgresEventArchiveRepository) ListCompleted(ctx context.Context, from, to time.Time) ([]*models.EventArchive, error) {
+	query := `
+		SELECT hour, object_key, status, event_count, size_bytes, started_at, completed_at
+		FROM event_archives
+		WHERE status = $1 AND hour >= $2 AND hour < $3
+		ORDER BY hour ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.EventArchiveStatusCompleted, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event archives: %w", err)
+	}
+	defer rows.Close()
+
+	var archives []*models.EventArchive
+	for rows.Next() {
+		var archive models.EventArchive
+		err := rows.Scan(&archive.Hour, &archive.ObjectKey, &archive.Status, &archive.EventCount,
+			&archive.SizeBytes, &archive.StartedAt, &archive.CompletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event archive: %w", err)
+		}
+		archive.Hour = archive.Hour.UTC()
+		archives = append(archives, &archive)
+	}
+
+	return archives, rows.Err()
+}
\ No newline at end of file
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+)
+
+type PostgresEventStore struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresEventStore(db *sql.DB) *PostgresEventStore {
+	return &PostgresEventStore{
+		db:     db,
+		logger: logrus.WithField("component", "event_store"),
+	}
+}
+
+func (s *PostgresEventStore) Append(ctx context.Context, orderID uuid.UUID, event *models.Event) error {
+	payload, err := event.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	query := `
+		INSERT INTO order_events (id, order_id, event_type, payload, occurred_at, sequence)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	sequence := sql.NullInt64{Int64: int64(event.Sequence), Valid: event.Sequence > 0}
+	_, err = s.db.ExecContext(ctx, query, event.ID, orderID, event.Type, payload, event.Timestamp, sequence)
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+
+	return nil
+}
+
+// NextSequence returns the sequence number of the next event of the order,
+// counting from 1.
+func (s *PostgresEventStore) NextSequence(ctx context.Context, orderID uuid.UUID) (uint64, error) {
+	query := `
+		INSERT INTO order_event_sequences (order_id, last_sequence)
+		VALUES ($1, 1)
+		ON CONFLICT (order_id) DO UPDATE SET last_sequence = order_event_sequences.last_sequence + 1
+		RETURNING last_sequence
+	`
+
+	var sequence uint64
+	if err := s.db.QueryRowContext(ctx, query, orderID).Scan(&sequence); err != nil {
+		return 0, fmt.Errorf("failed to get next event sequence: %w", err)
+	}
+
+	return sequence, nil
+}
+
+// GetByOrderID returns the events of the order that occurred until until, in
+// sequence order. Events recorded before they were numbered come first, in
+// the order of their timestamps.
+func (s *PostgresEventStore) GetByOrderID(ctx context.Context, orderID uuid.UUID, until time.Time) ([]*models.Event, error) {
+	query := `
+		SELECT payload
+		FROM order_events
+		WHERE order_id = $1 AND occurred_at <= $2
+		ORDER BY sequence ASC NULLS FIRST, occurred_at ASC, id ASC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, orderID, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.Event
+	for rows.Next() {
+		var payload []byte
+		if err := rows.Scan(&payload); err != nil {
+			return nil, fmt.Errorf("failed to scan order event: %w", err)
+		}
+
+		var event models.Event
+		if err := event.FromJSON(payload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal order event: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
\ No newline at end of file
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+)
+
+// fallbackForwardLockID makes a single instance forward stored events at a
+// time, so they are published in the order they were stored.
+const fallbackForwardLockID = 7200432
+
+type PostgresFallbackEventRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresFallbackEventRepository(db *sql.DB) *PostgresFallbackEventRepository {
+	return &PostgresFallbackEventRepository{
+		db:     db,
+		logger: logrus.WithField("component", "fallback_event_repository"),
+	}
+}
+
+func (r *PostgresFallbackEventRepository) Store(ctx context.Context, event *models.Event) error {
+	payload, err := event.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	query := `
+		INSERT INTO fallback_events (event_id, event_type, payload)
+		VALUES ($1, $2, $3)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, event.ID, event.Type, payload); err != nil {
+		return fmt.Errorf("failed to store fallback event: %w", err)
+	}
+	return nil
+}
+
+// Forward passes up to limit of the oldest stored events to publish, in the
+// order they were stored, and deletes those it accepted. It stops at the first
+// event publish fails for. Nothing is forwarded while another instance is
+// forwarding.
+func (r *PostgresFallbackEventRepository) Forward(ctx context.Context, limit int, publish func(ctx context.Context, event *models.Event) error) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to forward fallback events: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, fallbackForwardLockID).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to forward fallback events: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT seq, payload
+		FROM fallback_events
+		ORDER BY seq ASC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get fallback events: %w", err)
+	}
+
+	type storedEvent struct {
+		seq   int64
+		event *models.Event
+	}
+	var stored []storedEvent
+	for rows.Next() {
+		var seq int64
+		var payload []byte
+		if err := rows.Scan(&seq, &payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan fallback event: %w", err)
+		}
+		var event models.Event
+		if err := event.FromJSON(payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to unmarshal fallback event %d: %w", seq, err)
+		}
+		stored = append(stored, storedEvent{seq: seq, event: &event})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get fallback events: %w", err)
+	}
+
+	forwarded := 0
+	var publishErr error
+	for _, s := range stored {
+		if publishErr = publish(ctx, s.event); publishErr != nil {
+			break
+		}
+		forwarded++
+	}
+
+	if forwarded > 0 {
+		// Delete by seq rather than up to the last one: a concurrent Store may
+		// commit an event with a lower seq that was not read.
+		seqs := make([]int64, 0, forwarded)
+		for _, s := range stored[:forwarded] {
+			seqs = append(seqs, s.seq)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM fallback_events WHERE seq = ANY($1)`, pq.Array(seqs)); err != nil {
+			return 0, fmt.Errorf("failed to delete forwarded fallback events: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("failed to delete forwarded fallback events: %w", err)
+		}
+	}
+
+	return forwarded, publishErr
+}
+
+func (r *PostgresFallbackEventRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM fallback_events`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count fallback events: %w", err)
+	}
+	return count, nil
+}
\ No newline at end of file
@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+// PostgresIntegrityRepository finds order data that breaks the invariants
+// the service maintains, and repairs it, recording an order_repairs entry in
+// the same transaction as each repair.
+type PostgresIntegrityRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresIntegrityRepository(db *sql.DB) *PostgresIntegrityRepository {
+	return &PostgresIntegrityRepository{
+		db:     db,
+		logger: logrus.WithField("component", "integrity_repository"),
+	}
+}
+
+// Find returns up to limit inconsistencies of kind. Soft-deleted orders are
+// not checked.
+func (r *PostgresIntegrityRepository) Find(ctx context.Context, kind models.InconsistencyKind, limit int) ([]*models.OrderInconsistency, error) {
+	switch kind {
+	case models.InconsistencyOrphanItem:
+		return r.findOrphanItems(ctx, limit)
+	case models.InconsistencyItemTotal:
+		return r.findItemTotalMismatches(ctx, limit)
+	case models.InconsistencyOrderTotal:
+		return r.findOrderTotalMismatches(ctx, limit)
+	case models.InconsistencyUnknownStatus:
+		return r.findUnknownStatuses(ctx, limit)
+	case models.InconsistencyStatusVersion:
+		return r.findStatusVersionMismatches(ctx, limit)
+	default:
+		return nil, fmt.Errorf("unknown inconsistency kind %q", kind)
+	}
+}
+
+func (r *PostgresIntegrityRepository) findOrphanItems(ctx context.Context, limit int) ([]*models.OrderInconsistency, error) {
+	query := `
+		SELECT i.id, i.order_id
+		FROM order_items i
+		LEFT JOIN orders o ON o.id = i.order_id
+		WHERE o.id IS NULL
+		ORDER BY i.order_id, i.id
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphan items: %w", err)
+	}
+	defer rows.Close()
+
+	var found []*models.OrderInconsistency
+	for rows.Next() {
+		var itemID uuid.UUID
+		inconsistency := &models.OrderInconsistency{
+			Kind:     models.InconsistencyOrphanItem,
+			Actual:   "order missing",
+			Expected: "item deleted",
+		}
+		if err := rows.Scan(&itemID, &inconsistency.OrderID); err != nil {
+			return nil, fmt.Errorf("failed to scan orphan item: %w", err)
+		}
+		inconsistency.ItemID = &itemID
+		found = append(found, inconsistency)
+	}
+	return found, rows.Err()
+}
+
+func (r *PostgresIntegrityRepository) findItemTotalMismatches(ctx context.Context, limit int) ([]*models.OrderInconsistency, error) {
+	query := `
+		SELECT i.id, i.order_id, o.status, o.version, i.total::text, ROUND(i.price * i.quantity, 2)::text
+		FROM order_items i
+		JOIN orders o ON o.id = i.order_id
+		WHERE o.deleted_at IS NULL AND i.total <> ROUND(i.price * i.quantity, 2)
+		ORDER BY i.order_id, i.id
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find item total mismatches: %w", err)
+	}
+	defer rows.Close()
+
+	var found []*models.OrderInconsistency
+	for rows.Next() {
+		var itemID uuid.UUID
+		inconsistency := &models.OrderInconsistency{Kind: models.InconsistencyItemTotal}
+		if err := rows.Scan(&itemID, &inconsistency.OrderID, &inconsistency.Status, &inconsistency.Version,
+			&inconsistency.Actual, &inconsistency.Expected); err != nil {
+			return nil, fmt.Errorf("failed to scan item total mismatch: %w", err)
+		}
+		inconsistency.ItemID = &itemID
+		found = append(found, inconsistency)
+	}
+	return found, rows.Err()
+}
+
+func (r *PostgresIntegrityRepository) findOrderTotalMismatches(ctx context.Context, limit int) ([]*models.OrderInconsistency, error) {
+	query := `
+		SELECT o.id, o.status, o.version, o.total_amount::text, COALESCE(SUM(i.total), 0)::text
+		FROM orders o
+		LEFT JOIN order_items i ON i.order_id = o.id
+		WHERE o.deleted_at IS NULL
+		GROUP BY o.id
+		HAVING o.total_amount <> COALESCE(SUM(i.total), 0)
+		ORDER BY o.id
+		LIMIT $1
+	`
+
+	return r.queryOrderInconsistencies(ctx, models.InconsistencyOrderTotal, query, limit)
+}
+
+func (r *PostgresIntegrityRepository) findUnknownStatuses(ctx context.Context, limit int) ([]*models.OrderInconsistency, error) {
+	var statuses []string
+	for status := range models.MinimumVersions() {
+		statuses = append(statuses, string(status))
+	}
+
+	query := `
+		SELECT id, status, version, status, ''
+		FROM orders
+		WHERE deleted_at IS NULL AND status <> ALL($2::text[])
+		ORDER BY id
+		LIMIT $1
+	`
+
+	return r.queryOrderInconsistencies(ctx, models.InconsistencyUnknownStatus, query, limit, pq.Array(statuses))
+}
+
+func (r *PostgresIntegrityRepository) findStatusVersionMismatches(ctx context.Context, limit int) ([]*models.OrderInconsistency, error) {
+	var statuses []string
+	var versions []int64
+	for status, version := range models.MinimumVersions() {
+		statuses = append(statuses, string(status))
+		versions = append(versions, int64(version))
+	}
+
+	query := `
+		SELECT o.id, o.status, o.version, o.version::text, m.version::text
+		FROM orders o
+		JOIN unnest($2::text[], $3::int[]) AS m(status, version) ON m.status = o.status
+		WHERE o.deleted_at IS NULL AND o.version < m.version
+		ORDER BY o.id
+		LIMIT $1
+	`
+
+	return r.queryOrderInconsistencies(ctx, models.InconsistencyStatusVersion, query, limit, pq.Array(statuses), pq.Array(versions))
+}
+
+// queryOrderInconsistencies runs a query selecting the ID, status, version
+// and actual and expected values of inconsistent orders.
+func (r *PostgresIntegrityRepository) queryOrderInconsistencies(ctx context.Context, kind models.InconsistencyKind, query string, limit int, args ...interface{}) ([]*models.OrderInconsistency, error) {
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{limit}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s: %w", kind, err)
+	}
+	defer rows.Close()
+
+	var found []*models.OrderInconsistency
+	for rows.Next() {
+		inconsistency := &models.OrderInconsistency{Kind: kind}
+		if err := rows.Scan(&inconsistency.OrderID, &inconsistency.Status, &inconsistency.Version,
+			&inconsistency.Actual, &inconsistency.Expected); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", kind, err)
+		}
+		found = append(found, inconsistency)
+	}
+	return found, rows.Err()
+}
+
+// Repair corrects inconsistency and records the repair as made by actor. It
+// returns false without changing anything when the data changed since the
+// scan, so a concurrent write is never overwritten.
+func (r *PostgresIntegrityRepository) Repair(ctx context.Context, inconsistency *models.OrderInconsistency, actor string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	repair := &models.OrderRepair{
+		ID:         uuid.New(),
+		Kind:       inconsistency.Kind,
+		OrderID:    inconsistency.OrderID,
+		ItemID:     inconsistency.ItemID,
+		OldValue:   inconsistency.Actual,
+		Actor:      actor,
+		RepairedAt: now,
+	}
+
+	var repaired bool
+	switch inconsistency.Kind {
+	case models.InconsistencyOrphanItem:
+		repaired, err = deleteOrphanItem(ctx, tx, repair)
+	case models.InconsistencyItemTotal:
+		repaired, err = repairItemTotal(ctx, tx, inconsistency, repair)
+	case models.InconsistencyOrderTotal:
+		repaired, err = repairOrderTotal(ctx, tx, inconsistency, repair)
+	case models.InconsistencyStatusVersion:
+		repaired, err = repairStatusVersion(ctx, tx, inconsistency, repair)
+	default:
+		return false, fmt.Errorf("%s cannot be repaired automatically", inconsistency.Kind)
+	}
+	if err != nil || !repaired {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO order_repairs (id, kind, order_id, item_id, old_value, new_value, actor, repaired_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+	`
+
+	if _, err := tx.ExecContext(ctx, query, repair.ID, repair.Kind, repair.OrderID, repair.ItemID,
+		repair.OldValue, repair.NewValue, repair.Actor, repair.RepairedAt); err != nil {
+		return false, fmt.Errorf("failed to record repair: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"kind":      repair.Kind,
+		"order_id":  repair.OrderID,
+		"old_value": repair.OldValue,
+		"new_value": repair.NewValue,
+		"actor":     actor,
+	}).Info("Order inconsistency repaired")
+	return true, nil
+}
+
+// deleteOrphanItem deletes the item, keeping the deleted row in the repair.
+func deleteOrphanItem(ctx context.Context, tx *sql.Tx, repair *models.OrderRepair) (bool, error) {
+	query := `
+		DELETE FROM order_items i
+		WHERE i.id = $1 AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.id = i.order_id)
+		RETURNING row_to_json(i)::text
+	`
+
+	err := tx.QueryRowContext(ctx, query, repair.ItemID).Scan(&repair.OldValue)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete orphan item: %w", err)
+	}
+	return true, nil
+}
+
+// repairItemTotal recomputes the item total, unless it changed since the
+// scan, and bumps the order version, as the order's content changes.
+func repairItemTotal(ctx context.Context, tx *sql.Tx, inconsistency *models.OrderInconsistency, repair *models.OrderRepair) (bool, error) {
+	query := `
+		UPDATE order_items
+		SET total = ROUND(price * quantity, 2)
+		WHERE id = $1 AND total = $2::numeric
+		RETURNING total::text
+	`
+
+	err := tx.QueryRowContext(ctx, query, repair.ItemID, inconsistency.Actual).Scan(&repair.NewValue)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to repair item total: %w", err)
+	}
+
+	orderQuery := `
+		UPDATE orders
+		SET updated_at = $2, version = version + 1
+		WHERE id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, orderQuery, inconsistency.OrderID, repair.RepairedAt); err != nil {
+		return false, fmt.Errorf("failed to update order: %w", err)
+	}
+	return true, nil
+}
+
+func repairOrderTotal(ctx context.Context, tx *sql.Tx, inconsistency *models.OrderInconsistency, repair *models.OrderRepair) (bool, error) {
+	query := `
+		UPDATE orders
+		SET total_amount = (SELECT COALESCE(SUM(total), 0) FROM order_items WHERE order_id = $1),
+		    updated_at = $2, version = version + 1
+		WHERE id = $1 AND version = $3 AND deleted_at IS NULL
+		RETURNING total_amount::text
+	`
+
+	err := tx.QueryRowContext(ctx, query, inconsistency.OrderID, repair.RepairedAt, inconsistency.Version).Scan(&repair.NewValue)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to repair order total: %w", err)
+	}
+	return true, nil
+}
+
+// repairStatusVersion raises the version to the lowest possible for the
+// status. Versions only ever grow, so consumers comparing versions accept
+// the repaired order as the newest.
+func repairStatusVersion(ctx context.Context, tx *sql.Tx, inconsistency *models.OrderInconsistency, repair *models.OrderRepair) (bool, error) {
+	version, err := strconv.Atoi(inconsistency.Expected)
+	if err != nil {
+		return false, fmt.Errorf("invalid expected version %q: %w", inconsistency.Expected, err)
+	}
+
+	query := `
+		UPDATE orders
+		SET version = $3, updated_at = $2
+		WHERE id = $1 AND version = $4 AND status = $5 AND deleted_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, inconsistency.OrderID, repair.RepairedAt, version, inconsistency.Version, inconsistency.Status)
+	if err != nil {
+		return false, fmt.Errorf("failed to repair order version: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	repair.NewValue = inconsistency.Expected
+	return rowsAffected > 0, nil
+}
\ No newline at end of file
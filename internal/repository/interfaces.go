@@ -2,18 +2,165 @@ package repository
 
 import (
 	"context"
-	"order-processing-microservice/internal/models"
+	"time"
+
 	"github.com/google/uuid"
+	"order-processing-microservice/internal/models"
 )
 
-type OrderRepository interface {
+// OrderReader is the read side of the order repository. The status API is
+// wired with a reader only, which may point at a replica.
+type OrderReader interface {
+	GetByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID, opts ...models.LoadOption) ([]*models.Order, error)
+	GetByOrderNumber(ctx context.Context, tenantID, orderNumber string, opts ...models.LoadOption) (*models.Order, error)
+	GetByExternalReference(ctx context.Context, tenantID, reference string, opts ...models.LoadOption) ([]*models.Order, error)
+	GetByCustomerID(ctx context.Context, customerID uuid.UUID, limit, offset int, opts ...models.LoadOption) ([]*models.Order, error)
+	GetByStatus(ctx context.Context, status models.OrderStatus, channel models.OrderChannel, limit, offset int, opts ...models.LoadOption) ([]*models.Order, error)
+	Count(ctx context.Context) (int64, error)
+	CountByStatus(ctx context.Context, status models.OrderStatus, channel models.OrderChannel) (int64, error)
+	CountByCustomerID(ctx context.Context, customerID uuid.UUID) (int64, error)
+	GetOrderStats(ctx context.Context) (*models.OrderStats, error)
+	GetOrderPipeline(ctx context.Context, since time.Time) (*models.OrderPipeline, error)
+	GetChangedSince(ctx context.Context, cursor models.ChangeCursor, until time.Time, limit int) ([]*models.Order, error)
+	StreamByStatus(ctx context.Context, status models.OrderStatus, fn func(*models.Order) error, opts ...models.LoadOption) error
+	StreamChangedSince(ctx context.Context, cursor models.ChangeCursor, until time.Time, fn func(*models.Order) error) error
+}
+
+type OrderWriter interface {
 	Create(ctx context.Context, order *models.Order) error
-	GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
-	GetByCustomerID(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]*models.Order, error)
 	Update(ctx context.Context, order *models.Order) error
 	UpdateStatus(ctx context.Context, id uuid.UUID, status models.OrderStatus, version int) error
+	UpsertItems(ctx context.Context, order *models.Order) ([]models.OrderItemChange, error)
+	AdjustTotals(ctx context.Context, adjustment *models.OrderAdjustment, version int) error
+	MarkCompleted(ctx context.Context, id uuid.UUID) (bool, error)
+	MarkFailed(ctx context.Context, id uuid.UUID, code models.FailureCode, detail string) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	GetByStatus(ctx context.Context, status models.OrderStatus, limit, offset int) ([]*models.Order, error)
+	ClaimPendingForDispatch(ctx context.Context, limit int) ([]*models.Order, error)
+	TransitionStatus(ctx context.Context, from, to models.OrderStatus, filter models.OrderFilter) ([]*models.Order, error)
+	TransitionStatusBatch(ctx context.Context, from, to models.OrderStatus, filter models.OrderFilter, limit int) ([]*models.Order, error)
+	Schedule(ctx context.Context, id uuid.UUID, version int, until time.Time) error
+	ReleaseScheduled(ctx context.Context, now time.Time, limit int) ([]*models.Order, error)
+	UpdateEstimatedDelivery(ctx context.Context, id uuid.UUID, estimate *time.Time) error
+}
+
+type OrderRepository interface {
+	OrderReader
+	OrderWriter
+}
+
+type ProjectionRepository interface {
+	Truncate(ctx context.Context) error
+	UpsertOrder(ctx context.Context, order *models.Order) error
+	ApplyStatus(ctx context.Context, id uuid.UUID, status models.OrderStatus, at time.Time) error
+	ApplyAdjustment(ctx context.Context, adjustment *models.OrderAdjustment) error
+}
+
+type EventStore interface {
+	Append(ctx context.Context, orderID uuid.UUID, event *models.Event) error
+	NextSequence(ctx context.Context, orderID uuid.UUID) (uint64, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID, until time.Time) ([]*models.Event, error)
+}
+
+type ProcessedEventRepository interface {
+	Exists(ctx context.Context, eventID uuid.UUID) (bool, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type OrderContextRepository interface {
+	Create(ctx context.Context, orderContext *models.OrderContext) error
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.OrderContext, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *models.Attachment) error
+	GetByID(ctx context.Context, orderID, id uuid.UUID) (*models.Attachment, error)
+	ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.Attachment, error)
+	Delete(ctx context.Context, orderID, id uuid.UUID) error
+}
+
+type SagaRepository interface {
+	Create(ctx context.Context, saga *models.Saga) error
+	GetByCorrelationID(ctx context.Context, correlationID uuid.UUID) (*models.Saga, error)
+	Update(ctx context.Context, saga *models.Saga, expectedCorrelationID uuid.UUID) error
+	GetTimedOut(ctx context.Context, now time.Time, limit int) ([]*models.Saga, error)
+}
+
+type CompensationRepository interface {
+	Create(ctx context.Context, compensation *models.Compensation) error
+	GetPending(ctx context.Context, limit int) ([]*models.Compensation, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status models.CompensationStatus) error
+}
+
+type QuotaRepository interface {
+	Get(ctx context.Context, tenantID string) (*models.TenantQuota, error)
+	Upsert(ctx context.Context, quota *models.TenantQuota) error
+	GetUsage(ctx context.Context, tenantID string, since time.Time) (*models.TenantQuotaUsage, error)
+}
+
+type ProcessingWindowRepository interface {
+	Get(ctx context.Context, tenantID string) (*models.TenantProcessingWindows, error)
+	Upsert(ctx context.Context, windows *models.TenantProcessingWindows) error
+	Delete(ctx context.Context, tenantID string) error
+}
+
+type BulkCancelRepository interface {
+	Create(ctx context.Context, job *models.BulkCancelJob) error
+	Update(ctx context.Context, job *models.BulkCancelJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.BulkCancelJob, error)
+}
+
+type FallbackEventRepository interface {
+	Store(ctx context.Context, event *models.Event) error
+	Forward(ctx context.Context, limit int, publish func(ctx context.Context, event *models.Event) error) (int, error)
+	Count(ctx context.Context) (int, error)
+}
+
+type ScheduledEventRepository interface {
+	Schedule(ctx context.Context, event *models.Event, deliverAt time.Time) error
+	Cancel(ctx context.Context, eventID uuid.UUID) (bool, error)
+	ReleaseDue(ctx context.Context, now time.Time, limit int, publish func(ctx context.Context, event *models.Event) error) (int, error)
+}
+
+type UsageRepository interface {
+	Add(ctx context.Context, records []models.UsageRecord) error
+	List(ctx context.Context, filter models.UsageFilter) ([]models.UsageRecord, error)
+}
+
+type DeadLetterRepository interface {
+	Create(ctx context.Context, event *models.DeadLetterEvent) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DeadLetterEvent, error)
+	List(ctx context.Context, limit, offset int) ([]*models.DeadLetterEvent, error)
 	Count(ctx context.Context) (int64, error)
-	CountByStatus(ctx context.Context, status models.OrderStatus) (int64, error)
+	MarkRequeued(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, ids []uuid.UUID) (int64, error)
+}
+
+type ProcessingAttemptRepository interface {
+	Start(ctx context.Context, attempt *models.ProcessingAttempt) error
+	Finish(ctx context.Context, attempt *models.ProcessingAttempt) error
+	CountFailures(ctx context.Context, eventID uuid.UUID) (int, error)
+	ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.ProcessingAttempt, error)
+}
+
+type EventArchiveRepository interface {
+	NextHour(ctx context.Context, before time.Time) (*time.Time, error)
+	Claim(ctx context.Context, archive *models.EventArchive, staleBefore time.Time) (bool, error)
+	Complete(ctx context.Context, archive *models.EventArchive) error
+	Fail(ctx context.Context, archive *models.EventArchive) error
+	EachEvent(ctx context.Context, from, to time.Time, fn func(payload []byte) error) error
+	ListCompleted(ctx context.Context, from, to time.Time) ([]*models.EventArchive, error)
+}
+
+type MessageFailureRepository interface {
+	RecordFailure(ctx context.Context, position models.MessagePosition, cause error) (int, error)
+	Quarantine(ctx context.Context, position models.MessagePosition) error
+	IsQuarantined(ctx context.Context, position models.MessagePosition) (bool, error)
+	Clear(ctx context.Context, position models.MessagePosition) error
+}
+
+type IntegrityRepository interface {
+	Find(ctx context.Context, kind models.InconsistencyKind, limit int) ([]*models.OrderInconsistency, error)
+	Repair(ctx context.Context, inconsistency *models.OrderInconsistency, actor string) (bool, error)
 }
\ No newline at end of file
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+)
+
+type PostgresMessageFailureRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresMessageFailureRepository(db *sql.DB) *PostgresMessageFailureRepository {
+	return &PostgresMessageFailureRepository{
+		db:     db,
+		logger: logrus.WithField("component", "message_failure_repository"),
+	}
+}
+
+// RecordFailure counts a failed attempt at the message at position and
+// returns the failures so far.
+func (r *PostgresMessageFailureRepository) RecordFailure(ctx context.Context, position models.MessagePosition, cause error) (int, error) {
+	query := `
+		INSERT INTO message_failures (consumer_group, topic, partition, "offset", failures, last_error, updated_at)
+		VALUES ($1, $2, $3, $4, 1, $5, $6)
+		ON CONFLICT (consumer_group, topic, partition, "offset") DO UPDATE
+		SET failures = message_failures.failures + 1, last_error = EXCLUDED.last_error, updated_at = EXCLUDED.updated_at
+		RETURNING failures
+	`
+
+	var failures int
+	err := r.db.QueryRowContext(ctx, query,
+		position.GroupID, position.Topic, position.Partition, position.Offset, cause.Error(), time.Now().UTC(),
+	).Scan(&failures)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record message failure: %w", err)
+	}
+	return failures, nil
+}
+
+func (r *PostgresMessageFailureRepository) Quarantine(ctx context.Context, position models.MessagePosition) error {
+	query := `
+		INSERT INTO message_failures (consumer_group, topic, partition, "offset", failures, last_error, quarantined_at, updated_at)
+		VALUES ($1, $2, $3, $4, 0, '', $5, $5)
+		ON CONFLICT (consumer_group, topic, partition, "offset") DO UPDATE
+		SET quarantined_at = EXCLUDED.quarantined_at, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, position.GroupID, position.Topic, position.Partition, position.Offset, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to quarantine message: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresMessageFailureRepository) IsQuarantined(ctx context.Context, position models.MessagePosition) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM message_failures
+			WHERE consumer_group = $1 AND topic = $2 AND partition = $3 AND "offset" = $4 AND quarantined_at IS NOT NULL
+		)
+	`
+
+	var quarantined bool
+	err := r.db.QueryRowContext(ctx, query, position.GroupID, position.Topic, position.Partition, position.Offset).Scan(&quarantined)
+	if err != nil {
+		return false, fmt.Errorf("failed to check message quarantine: %w", err)
+	}
+	return quarantined, nil
+}
+
+func (r *PostgresMessageFailureRepository) Clear(ctx context.Context, position models.MessagePosition) error {
+	query := `
+		DELETE FROM message_failures
+		WHERE consumer_group = $1 AND topic = $2 AND partition = $3 AND "offset" = $4 AND quarantined_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, position.GroupID, position.Topic, position.Partition, position.Offset)
+	if err != nil {
+		return fmt.Errorf("failed to clear message failures: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type PostgresOrderContextRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresOrderContextRepository(db *sql.DB) *PostgresOrderContextRepository {
+	return &PostgresOrderContextRepository{
+		db:     db,
+		logger: logrus.WithField("component", "order_context_repository"),
+	}
+}
+
+func (r *PostgresOrderContextRepository) Create(ctx context.Context, orderContext *models.OrderContext) error {
+	query := `
+		INSERT INTO order_contexts (order_id, ip_address, user_agent, api_key_id, origin, request_id, created_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7)
+		ON CONFLICT (order_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		orderContext.OrderID, orderContext.IPAddress, orderContext.UserAgent, orderContext.APIKeyID,
+		orderContext.Origin, orderContext.RequestID, orderContext.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert order context: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresOrderContextRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.OrderContext, error) {
+	query := `
+		SELECT order_id, COALESCE(ip_address, ''), COALESCE(user_agent, ''), COALESCE(api_key_id, ''), COALESCE(origin, ''), COALESCE(request_id, ''), created_at
+		FROM order_contexts
+		WHERE order_id = $1
+	`
+
+	var orderContext models.OrderContext
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&orderContext.OrderID, &orderContext.IPAddress, &orderContext.UserAgent, &orderContext.APIKeyID,
+		&orderContext.Origin, &orderContext.RequestID, &orderContext.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOrderContextNotFound
+		}
+		return nil, fmt.Errorf("failed to get order context: %w", err)
+	}
+	return &orderContext, nil
+}
+
+func (r *PostgresOrderContextRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM order_contexts WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete order contexts: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if deleted > 0 {
+		r.logger.WithFields(requestctx.LogFields(ctx)).WithField("deleted", deleted).Info("Expired order contexts deleted")
+	}
+	return deleted, nil
+}
\ No newline at end of file
@@ -2,48 +2,88 @@ package repository
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/sirupsen/logrus"
 	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/database"
+	"order-processing-microservice/pkg/requestctx"
 )
 
 type PostgresOrderRepository struct {
-	db     *sql.DB
+	db     *database.Pool
+	ids    models.IDGenerator
 	logger *logrus.Entry
 }
 
-func NewPostgresOrderRepository(db *sql.DB) *PostgresOrderRepository {
+func NewPostgresOrderRepository(db *database.Pool) *PostgresOrderRepository {
 	return &PostgresOrderRepository{
 		db:     db,
+		ids:    models.DefaultIDGenerator(),
 		logger: logrus.WithField("component", "order_repository"),
 	}
 }
 
+// SetIDGenerator sets the generator of order item and item change IDs.
+func (r *PostgresOrderRepository) SetIDGenerator(ids models.IDGenerator) {
+	r.ids = ids
+}
+
+// NewPostgresOrderReader returns a repository limited to reads, for services
+// wired against a read replica.
+func NewPostgresOrderReader(db *database.Pool) OrderReader {
+	return NewPostgresOrderRepository(db)
+}
+
 func (r *PostgresOrderRepository) Create(ctx context.Context, order *models.Order) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback()
+	defer tx.Rollback(ctx)
 
 	order.CreatedAt = time.Now().UTC()
 	order.UpdatedAt = order.CreatedAt
 	order.Version = 1
+	if order.TenantID == "" {
+		order.TenantID = models.DefaultTenantID
+	}
+
+	sequenceQuery := `
+		INSERT INTO order_number_sequences (tenant_id, year, last_value)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (tenant_id, year) DO UPDATE SET last_value = order_number_sequences.last_value + 1
+		RETURNING last_value
+	`
+
+	var sequence int64
+	if err := tx.QueryRow(ctx, sequenceQuery, order.TenantID, order.CreatedAt.Year()).Scan(&sequence); err != nil {
+		return fmt.Errorf("failed to allocate order number: %w", err)
+	}
+	order.OrderNumber = models.FormatOrderNumber(order.CreatedAt.Year(), sequence)
 
 	orderQuery := `
-		INSERT INTO orders (id, customer_id, status, total_amount, created_at, updated_at, version)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO orders (id, tenant_id, order_number, external_reference, customer_id, status, total_amount, created_at, updated_at, version, dispatch_lease_until, channel, shipping_method, estimated_delivery)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), NULLIF($13, ''), $14)
 	`
 
-	_, err = tx.ExecContext(ctx, orderQuery,
-		order.ID, order.CustomerID, order.Status, order.TotalAmount,
-		order.CreatedAt, order.UpdatedAt, order.Version,
+	_, err = tx.Exec(ctx, orderQuery,
+		order.ID, order.TenantID, order.OrderNumber, order.ExternalReference, order.CustomerID, order.Status, order.TotalAmount,
+		order.CreatedAt, order.UpdatedAt, order.Version, order.CreatedAt.Add(models.DispatchLease), order.Channel,
+		order.ShippingMethod, order.EstimatedDelivery,
 	)
 	if err != nil {
+		// The index takes the table prefix, if any, like every other.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && strings.HasSuffix(pgErr.ConstraintName, "idx_orders_tenant_external_reference_unique") {
+			return ErrDuplicateExternalReference
+		}
 		return fmt.Errorf("failed to insert order: %w", err)
 	}
 
@@ -53,11 +93,11 @@ func (r *PostgresOrderRepository) Create(ctx context.Context, order *models.Orde
 	`
 
 	for _, item := range order.Items {
-		item.ID = uuid.New()
+		item.ID = r.ids.NewID()
 		item.OrderID = order.ID
 		item.Total = item.Price * float64(item.Quantity)
 
-		_, err = tx.ExecContext(ctx, itemQuery,
+		_, err = tx.Exec(ctx, itemQuery,
 			item.ID, item.OrderID, item.ProductID, item.Quantity, item.Price, item.Total,
 		)
 		if err != nil {
@@ -65,33 +105,40 @@ func (r *PostgresOrderRepository) Create(ctx context.Context, order *models.Orde
 		}
 	}
 
-	if err = tx.Commit(); err != nil {
+	if err = tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	r.logger.WithField("order_id", order.ID).Info("Order created successfully")
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"order_id":     order.ID,
+		"order_number": order.OrderNumber,
+	}).Info("Order created successfully")
 	return nil
 }
 
-func (r *PostgresOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+func (r *PostgresOrderRepository) GetByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error) {
 	orderQuery := `
-		SELECT id, customer_id, status, total_amount, created_at, updated_at, version
+		SELECT id, tenant_id, COALESCE(order_number, ''), COALESCE(external_reference, ''), COALESCE(channel, ''), COALESCE(shipping_method, ''), estimated_delivery, customer_id, status, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), total_amount, created_at, updated_at, version
 		FROM orders
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var order models.Order
-	err := r.db.QueryRowContext(ctx, orderQuery, id).Scan(
-		&order.ID, &order.CustomerID, &order.Status, &order.TotalAmount,
+	err := r.db.QueryRow(ctx, orderQuery, id).Scan(
+		&order.ID, &order.TenantID, &order.OrderNumber, &order.ExternalReference, &order.Channel, &order.ShippingMethod, &order.EstimatedDelivery, &order.CustomerID, &order.Status, &order.FailureCode, &order.FailureDetail, &order.TotalAmount,
 		&order.CreatedAt, &order.UpdatedAt, &order.Version,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("order not found")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
 		}
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
+	if models.NewLoadOptions(opts...).SkipItems {
+		return &order, nil
+	}
+
 	itemsQuery := `
 		SELECT id, order_id, product_id, quantity, price, total
 		FROM order_items
@@ -99,7 +146,7 @@ func (r *PostgresOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*m
 		ORDER BY id
 	`
 
-	rows, err := r.db.QueryContext(ctx, itemsQuery, id)
+	rows, err := r.db.Query(ctx, itemsQuery, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order items: %w", err)
 	}
@@ -119,16 +166,123 @@ func (r *PostgresOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*m
 	return &order, nil
 }
 
-func (r *PostgresOrderRepository) GetByCustomerID(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]*models.Order, error) {
+func (r *PostgresOrderRepository) GetByIDs(ctx context.Context, ids []uuid.UUID, opts ...models.LoadOption) ([]*models.Order, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT id, tenant_id, COALESCE(order_number, ''), COALESCE(external_reference, ''), COALESCE(channel, ''), COALESCE(shipping_method, ''), estimated_delivery, customer_id, status, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), total_amount, created_at, updated_at, version
+		FROM orders
+		WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+	`
+
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders by IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	var found []uuid.UUID
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(&order.ID, &order.TenantID, &order.OrderNumber, &order.ExternalReference, &order.Channel, &order.ShippingMethod, &order.EstimatedDelivery, &order.CustomerID, &order.Status, &order.FailureCode, &order.FailureDetail, &order.TotalAmount,
+			&order.CreatedAt, &order.UpdatedAt, &order.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, &order)
+		found = append(found, order.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get orders by IDs: %w", err)
+	}
+
+	if models.NewLoadOptions(opts...).SkipItems {
+		return orders, nil
+	}
+
+	items, err := r.getItemsForOrders(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		order.Items = items[order.ID]
+	}
+
+	return orders, nil
+}
+
+func (r *PostgresOrderRepository) GetByOrderNumber(ctx context.Context, tenantID, orderNumber string, opts ...models.LoadOption) (*models.Order, error) {
+	query := `
+		SELECT id
+		FROM orders
+		WHERE tenant_id = $1 AND order_number = $2 AND deleted_at IS NULL
+	`
+
+	var id uuid.UUID
+	if err := r.db.QueryRow(ctx, query, tenantID, orderNumber).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order by number: %w", err)
+	}
+
+	return r.GetByID(ctx, id, opts...)
+}
+
+func (r *PostgresOrderRepository) GetByExternalReference(ctx context.Context, tenantID, reference string, opts ...models.LoadOption) ([]*models.Order, error) {
+	query := `
+		SELECT id
+		FROM orders
+		WHERE tenant_id = $1 AND external_reference = $2 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, tenantID, reference, models.MaxExternalReferenceMatches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders by external reference: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan order id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get orders by external reference: %w", err)
+	}
+
+	orders := make([]*models.Order, 0, len(ids))
+	for _, id := range ids {
+		order, err := r.GetByID(ctx, id, opts...)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+func (r *PostgresOrderRepository) GetByCustomerID(ctx context.Context, customerID uuid.UUID, limit, offset int, opts ...models.LoadOption) ([]*models.Order, error) {
+	options := models.NewLoadOptions(opts...)
+
 	query := `
-		SELECT id, customer_id, status, total_amount, created_at, updated_at, version
+		SELECT id, tenant_id, COALESCE(order_number, ''), COALESCE(external_reference, ''), COALESCE(channel, ''), COALESCE(shipping_method, ''), estimated_delivery, customer_id, status, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), total_amount, created_at, updated_at, version
 		FROM orders
-		WHERE customer_id = $1
+		WHERE customer_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, customerID, limit, offset)
+	rows, err := r.db.Query(ctx, query, customerID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get orders by customer ID: %w", err)
 	}
@@ -137,17 +291,19 @@ func (r *PostgresOrderRepository) GetByCustomerID(ctx context.Context, customerI
 	var orders []*models.Order
 	for rows.Next() {
 		var order models.Order
-		err := rows.Scan(&order.ID, &order.CustomerID, &order.Status, &order.TotalAmount,
+		err := rows.Scan(&order.ID, &order.TenantID, &order.OrderNumber, &order.ExternalReference, &order.Channel, &order.ShippingMethod, &order.EstimatedDelivery, &order.CustomerID, &order.Status, &order.FailureCode, &order.FailureDetail, &order.TotalAmount,
 			&order.CreatedAt, &order.UpdatedAt, &order.Version)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
 
-		items, err := r.getOrderItems(ctx, order.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get order items: %w", err)
+		if !options.SkipItems {
+			items, err := r.getOrderItems(ctx, order.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get order items: %w", err)
+			}
+			order.Items = items
 		}
-		order.Items = items
 		orders = append(orders, &order)
 	}
 
@@ -161,26 +317,198 @@ func (r *PostgresOrderRepository) Update(ctx context.Context, order *models.Orde
 	query := `
 		UPDATE orders
 		SET status = $2, total_amount = $3, updated_at = $4, version = $5
-		WHERE id = $1 AND version = $6
+		WHERE id = $1 AND version = $6 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.db.Exec(ctx, query,
 		order.ID, order.Status, order.TotalAmount, order.UpdatedAt, order.Version, order.Version-1,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if result.RowsAffected() == 0 {
+		return r.missingOrConflict(ctx, order.ID)
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithField("order_id", order.ID).Info("Order updated successfully")
+	return nil
+}
+
+// UpsertItems replaces the items of the order with order.Items, matching them
+// to the existing items with models.MergeOrderItems so amended items keep
+// their IDs, and records every quantity change in order_item_changes.
+func (r *PostgresOrderRepository) UpsertItems(ctx context.Context, order *models.Order) ([]models.OrderItemChange, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	itemsQuery := `
+		SELECT id, order_id, product_id, quantity, price, total
+		FROM order_items
+		WHERE order_id = $1
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, itemsQuery, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	var existing []models.OrderItem
+	for rows.Next() {
+		var item models.OrderItem
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.Price, &item.Total); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan order item: %w", err)
+		}
+		existing = append(existing, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	merged, changes, err := models.MergeOrderItems(order.ID, existing, order.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	var total float64
+	for _, item := range merged {
+		total += item.Total
+	}
+
+	updatedAt := time.Now().UTC()
+	orderQuery := `
+		UPDATE orders
+		SET total_amount = $2, updated_at = $3, version = version + 1
+		WHERE id = $1 AND version = $4 AND deleted_at IS NULL
+	`
+
+	result, err := tx.Exec(ctx, orderQuery, order.ID, total, updatedAt, order.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return nil, r.missingOrConflict(ctx, order.ID)
+	}
+
+	changed := make(map[uuid.UUID]bool, len(changes))
+	for _, change := range changes {
+		changed[change.ItemID] = true
+	}
+
+	upsertQuery := `
+		INSERT INTO order_items (id, order_id, product_id, quantity, price, total)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET quantity = EXCLUDED.quantity, price = EXCLUDED.price, total = EXCLUDED.total
+	`
+
+	kept := make(map[uuid.UUID]bool, len(merged))
+	for _, item := range merged {
+		kept[item.ID] = true
+		if !changed[item.ID] {
+			continue
+		}
+		if _, err := tx.Exec(ctx, upsertQuery, item.ID, item.OrderID, item.ProductID, item.Quantity, item.Price, item.Total); err != nil {
+			return nil, fmt.Errorf("failed to upsert order item: %w", err)
+		}
+	}
+
+	changeQuery := `
+		INSERT INTO order_item_changes (id, order_id, item_id, product_id, previous_quantity, quantity, quantity_delta, order_version, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	for _, change := range changes {
+		if !kept[change.ItemID] {
+			if _, err := tx.Exec(ctx, `DELETE FROM order_items WHERE id = $1`, change.ItemID); err != nil {
+				return nil, fmt.Errorf("failed to delete order item: %w", err)
+			}
+		}
+
+		_, err := tx.Exec(ctx, changeQuery,
+			r.ids.NewID(), order.ID, change.ItemID, change.ProductID, change.PreviousQuantity, change.Quantity, change.QuantityDelta,
+			order.Version+1, updatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record order item change: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	order.Items = merged
+	order.TotalAmount = total
+	order.UpdatedAt = updatedAt
+	order.Version++
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"order_id": order.ID,
+		"changes":  len(changes),
+	}).Info("Order items updated successfully")
+	return changes, nil
+}
+
+// AdjustTotals saves adjustment to the order at version: the repriced items
+// and the new total. It sets adjustment.AdjustedAt to the update time.
+func (r *PostgresOrderRepository) AdjustTotals(ctx context.Context, adjustment *models.OrderAdjustment, version int) error {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get affected rows: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	adjustedAt := time.Now().UTC()
+	orderQuery := `
+		UPDATE orders
+		SET total_amount = $2, updated_at = $3, version = version + 1
+		WHERE id = $1 AND version = $4 AND deleted_at IS NULL
+	`
+
+	result, err := tx.Exec(ctx, orderQuery, adjustment.OrderID, adjustment.NewTotal, adjustedAt, version)
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return r.missingOrConflict(ctx, adjustment.OrderID)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("order not found or version conflict")
+	itemQuery := `
+		UPDATE order_items
+		SET price = $3, total = $4
+		WHERE id = $1 AND order_id = $2
+	`
+
+	for _, item := range adjustment.Items {
+		result, err := tx.Exec(ctx, itemQuery, item.ItemID, adjustment.OrderID, item.NewPrice, item.NewTotal)
+		if err != nil {
+			return fmt.Errorf("failed to update order item: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("order item %s not found", item.ItemID)
+		}
 	}
 
-	r.logger.WithField("order_id", order.ID).Info("Order updated successfully")
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	adjustment.AdjustedAt = adjustedAt
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"order_id":  adjustment.OrderID,
+		"old_total": adjustment.OldTotal,
+		"new_total": adjustment.NewTotal,
+	}).Info("Order totals adjusted successfully")
 	return nil
 }
 
@@ -188,61 +516,323 @@ func (r *PostgresOrderRepository) UpdateStatus(ctx context.Context, id uuid.UUID
 	query := `
 		UPDATE orders
 		SET status = $2, updated_at = $3, version = $4
-		WHERE id = $1 AND version = $5
+		WHERE id = $1 AND version = $5 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.ExecContext(ctx, query, id, status, time.Now().UTC(), version+1, version)
+	result, err := r.execStatusUpdate(ctx, query, id, status, time.Now().UTC(), version+1, version)
 	if err != nil {
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if result.RowsAffected() == 0 {
+		return r.missingOrConflict(ctx, id)
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"order_id": id,
+		"status":   status,
+	}).Info("Order status updated successfully")
+	return nil
+}
+
+// execStatusUpdate runs a status update. When ctx carries an event from
+// WithProcessedEvent, the event is recorded in the same transaction, which is
+// only committed if the update changed a row.
+func (r *PostgresOrderRepository) execStatusUpdate(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	eventID, ok := processedEventFrom(ctx)
+	if !ok {
+		return r.db.Exec(ctx, query, args...)
+	}
+
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get affected rows: %w", err)
+		return pgconn.CommandTag{}, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("order not found or version conflict")
+	recorded, err := tx.Exec(ctx, `
+		INSERT INTO processed_events (event_id, processed_at)
+		VALUES ($1, $2)
+		ON CONFLICT (event_id) DO NOTHING
+	`, eventID, time.Now().UTC())
+	if err != nil {
+		return pgconn.CommandTag{}, fmt.Errorf("failed to record processed event: %w", err)
+	}
+	if recorded.RowsAffected() == 0 {
+		return pgconn.CommandTag{}, ErrEventAlreadyProcessed
 	}
 
-	r.logger.WithFields(logrus.Fields{
-		"order_id": id,
-		"status":   status,
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	if result.RowsAffected() == 0 {
+		return result, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pgconn.CommandTag{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// missingOrConflict tells why a versioned update of order id changed no row:
+// ErrOrderNotFound if the order does not exist or was deleted, otherwise
+// ErrOrderVersionConflict.
+func (r *PostgresOrderRepository) missingOrConflict(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1 AND deleted_at IS NULL)`
+	if err := r.db.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check order: %w", err)
+	}
+	if !exists {
+		return ErrOrderNotFound
+	}
+	return ErrOrderVersionConflict
+}
+
+func (r *PostgresOrderRepository) MarkCompleted(ctx context.Context, id uuid.UUID) (bool, error) {
+	return r.finishProcessing(ctx, id, models.OrderStatusCompleted, "", "")
+}
+
+func (r *PostgresOrderRepository) MarkFailed(ctx context.Context, id uuid.UUID, code models.FailureCode, detail string) (bool, error) {
+	return r.finishProcessing(ctx, id, models.OrderStatusFailed, code, detail)
+}
+
+// finishProcessing moves a processing order to a terminal status. It reports
+// false when the order was not processing, e.g. because a redelivered event
+// already finished it, so callers publish the terminal event only once.
+func (r *PostgresOrderRepository) finishProcessing(ctx context.Context, id uuid.UUID, status models.OrderStatus, code models.FailureCode, detail string) (bool, error) {
+	query := `
+		UPDATE orders
+		SET status = $2, failure_code = NULLIF($3, ''), failure_detail = NULLIF($4, ''), updated_at = $5, version = version + 1
+		WHERE id = $1 AND status = $6 AND deleted_at IS NULL
+	`
+
+	result, err := r.execStatusUpdate(ctx, query, id, status, code, detail, time.Now().UTC(), models.OrderStatusProcessing)
+	if err != nil {
+		return false, fmt.Errorf("failed to update order status to %s: %w", status, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"order_id":     id,
+		"status":       status,
+		"failure_code": code,
 	}).Info("Order status updated successfully")
+	return true, nil
+}
+
+func (r *PostgresOrderRepository) TransitionStatus(ctx context.Context, from, to models.OrderStatus, filter models.OrderFilter) ([]*models.Order, error) {
+	query := `
+		UPDATE orders
+		SET status = $2, updated_at = $3, version = version + 1
+		WHERE status = $1 AND deleted_at IS NULL
+	`
+	query, args := appendOrderFilter(query, []interface{}{from, to, time.Now().UTC()}, filter)
+	query += " RETURNING " + transitionedOrderColumns
+
+	orders, err := r.queryTransitioned(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"from":  from,
+		"to":    to,
+		"count": len(orders),
+	}).Info("Order statuses transitioned successfully")
+	return orders, nil
+}
+
+// TransitionStatusBatch moves up to limit of the oldest orders in status from
+// matching filter to status to. Rows locked by a concurrent writer are
+// skipped and picked up by a later batch.
+func (r *PostgresOrderRepository) TransitionStatusBatch(ctx context.Context, from, to models.OrderStatus, filter models.OrderFilter, limit int) ([]*models.Order, error) {
+	selectQuery, args := appendOrderFilter(`
+		SELECT id FROM orders
+		WHERE status = $1 AND deleted_at IS NULL
+	`, []interface{}{from, to, time.Now().UTC(), limit}, filter)
+
+	query := `
+		UPDATE orders
+		SET status = $2, updated_at = $3, version = version + 1
+		WHERE status = $1 AND id IN (` + selectQuery + `
+			ORDER BY created_at, id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + transitionedOrderColumns
+
+	return r.queryTransitioned(ctx, query, args)
+}
+
+const transitionedOrderColumns = "id, tenant_id, COALESCE(order_number, ''), COALESCE(external_reference, ''), COALESCE(channel, ''), COALESCE(shipping_method, ''), estimated_delivery, customer_id, status, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), total_amount, created_at, updated_at, version"
+
+// appendOrderFilter adds the conditions of filter to query, numbering their
+// placeholders after args.
+func appendOrderFilter(query string, args []interface{}, filter models.OrderFilter) (string, []interface{}) {
+	if filter.CustomerID != nil {
+		args = append(args, *filter.CustomerID)
+		query += fmt.Sprintf(" AND customer_id = $%d", len(args))
+	}
+	if filter.CreatedAfter != nil {
+		args = append(args, *filter.CreatedAfter)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if filter.CreatedBefore != nil {
+		args = append(args, *filter.CreatedBefore)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	if filter.Channel != "" {
+		args = append(args, filter.Channel)
+		query += fmt.Sprintf(" AND channel = $%d", len(args))
+	}
+	return query, args
+}
+
+func (r *PostgresOrderRepository) queryTransitioned(ctx context.Context, query string, args []interface{}) ([]*models.Order, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transition order status: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(&order.ID, &order.TenantID, &order.OrderNumber, &order.ExternalReference, &order.Channel, &order.ShippingMethod, &order.EstimatedDelivery, &order.CustomerID, &order.Status, &order.FailureCode, &order.FailureDetail, &order.TotalAmount,
+			&order.CreatedAt, &order.UpdatedAt, &order.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, &order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to transition order status: %w", err)
+	}
+	return orders, nil
+}
+
+// UpdateEstimatedDelivery stores the delivery estimate of an order. The
+// estimate is derived data, so it does not bump the version.
+func (r *PostgresOrderRepository) UpdateEstimatedDelivery(ctx context.Context, id uuid.UUID, estimate *time.Time) error {
+	query := `UPDATE orders SET estimated_delivery = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, id, estimate)
+	if err != nil {
+		return fmt.Errorf("failed to update estimated delivery: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrOrderNotFound
+	}
 	return nil
 }
 
-func (r *PostgresOrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM orders WHERE id = $1`
+// Schedule defers a pending order until the given time, see
+// ReleaseScheduled.
+func (r *PostgresOrderRepository) Schedule(ctx context.Context, id uuid.UUID, version int, until time.Time) error {
+	query := `
+		UPDATE orders
+		SET status = $2, scheduled_for = $3, updated_at = $4, version = $5
+		WHERE id = $1 AND version = $6 AND status = $7 AND deleted_at IS NULL
+	`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.execStatusUpdate(ctx, query, id, models.OrderStatusScheduled, until, time.Now().UTC(), version+1, version, models.OrderStatusPending)
 	if err != nil {
-		return fmt.Errorf("failed to delete order: %w", err)
+		return fmt.Errorf("failed to schedule order: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return r.missingOrConflict(ctx, id)
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"order_id":      id,
+		"scheduled_for": until,
+	}).Info("Order scheduled")
+	return nil
+}
+
+// ReleaseScheduled returns up to limit scheduled orders that are due at now
+// to pending and returns them. Orders without a time, e.g. after a projection
+// rebuild, are due immediately and get rescheduled by the consumer if needed.
+func (r *PostgresOrderRepository) ReleaseScheduled(ctx context.Context, now time.Time, limit int) ([]*models.Order, error) {
+	query := `
+		UPDATE orders
+		SET status = $2, scheduled_for = NULL, updated_at = $3, version = version + 1
+		WHERE id IN (
+			SELECT id
+			FROM orders
+			WHERE status = $1 AND (scheduled_for IS NULL OR scheduled_for <= $3) AND deleted_at IS NULL
+			ORDER BY scheduled_for ASC NULLS FIRST
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, tenant_id, COALESCE(order_number, ''), COALESCE(external_reference, ''), COALESCE(channel, ''), COALESCE(shipping_method, ''), estimated_delivery, customer_id, status, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), total_amount, created_at, updated_at, version
+	`
+
+	rows, err := r.db.Query(ctx, query, models.OrderStatusScheduled, models.OrderStatusPending, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release scheduled orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(&order.ID, &order.TenantID, &order.OrderNumber, &order.ExternalReference, &order.Channel, &order.ShippingMethod, &order.EstimatedDelivery, &order.CustomerID, &order.Status, &order.FailureCode, &order.FailureDetail, &order.TotalAmount,
+			&order.CreatedAt, &order.UpdatedAt, &order.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, &order)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to release scheduled orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (r *PostgresOrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE orders
+		SET deleted_at = $2, updated_at = $2, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL
+	`
 
-	rowsAffected, err := result.RowsAffected()
+	result, err := r.db.Exec(ctx, query, id, time.Now().UTC())
 	if err != nil {
-		return fmt.Errorf("failed to get affected rows: %w", err)
+		return fmt.Errorf("failed to delete order: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("order not found")
+	if result.RowsAffected() == 0 {
+		return ErrOrderNotFound
 	}
 
-	r.logger.WithField("order_id", id).Info("Order deleted successfully")
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithField("order_id", id).Info("Order soft-deleted successfully")
 	return nil
 }
 
-func (r *PostgresOrderRepository) GetByStatus(ctx context.Context, status models.OrderStatus, limit, offset int) ([]*models.Order, error) {
+// GetByStatus returns a page of orders in status, oldest first. A non-empty
+// channel only returns orders placed through it.
+func (r *PostgresOrderRepository) GetByStatus(ctx context.Context, status models.OrderStatus, channel models.OrderChannel, limit, offset int, opts ...models.LoadOption) ([]*models.Order, error) {
+	options := models.NewLoadOptions(opts...)
+
 	query := `
-		SELECT id, customer_id, status, total_amount, created_at, updated_at, version
+		SELECT id, tenant_id, COALESCE(order_number, ''), COALESCE(external_reference, ''), COALESCE(channel, ''), COALESCE(shipping_method, ''), estimated_delivery, customer_id, status, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), total_amount, created_at, updated_at, version
 		FROM orders
-		WHERE status = $1
+		WHERE status = $1 AND deleted_at IS NULL AND ($4 = '' OR channel = $4)
 		ORDER BY created_at ASC
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, status, limit, offset)
+	rows, err := r.db.Query(ctx, query, status, limit, offset, channel)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get orders by status: %w", err)
 	}
@@ -251,18 +841,74 @@ func (r *PostgresOrderRepository) GetByStatus(ctx context.Context, status models
 	var orders []*models.Order
 	for rows.Next() {
 		var order models.Order
-		err := rows.Scan(&order.ID, &order.CustomerID, &order.Status, &order.TotalAmount,
+		err := rows.Scan(&order.ID, &order.TenantID, &order.OrderNumber, &order.ExternalReference, &order.Channel, &order.ShippingMethod, &order.EstimatedDelivery, &order.CustomerID, &order.Status, &order.FailureCode, &order.FailureDetail, &order.TotalAmount,
 			&order.CreatedAt, &order.UpdatedAt, &order.Version)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
 
-		items, err := r.getOrderItems(ctx, order.ID)
+		if !options.SkipItems {
+			items, err := r.getOrderItems(ctx, order.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get order items: %w", err)
+			}
+			order.Items = items
+		}
+		orders = append(orders, &order)
+	}
+
+	return orders, nil
+}
+
+// ClaimPendingForDispatch takes the dispatch lease of up to limit pending
+// orders whose lease is free or expired and returns them. Rows locked by a
+// concurrent claim are skipped, so each order is handed to one publisher at a
+// time.
+func (r *PostgresOrderRepository) ClaimPendingForDispatch(ctx context.Context, limit int) ([]*models.Order, error) {
+	query := `
+		UPDATE orders
+		SET dispatch_lease_until = $3
+		WHERE id IN (
+			SELECT id
+			FROM orders
+			WHERE status = $1 AND deleted_at IS NULL
+				AND (dispatch_lease_until IS NULL OR dispatch_lease_until < $4)
+			ORDER BY created_at ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, tenant_id, COALESCE(order_number, ''), COALESCE(external_reference, ''), COALESCE(channel, ''), COALESCE(shipping_method, ''), estimated_delivery, customer_id, status, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), total_amount, created_at, updated_at, version
+	`
+
+	now := time.Now().UTC()
+	rows, err := r.db.Query(ctx, query, models.OrderStatusPending, limit, now.Add(models.DispatchLease), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	var ids []uuid.UUID
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(&order.ID, &order.TenantID, &order.OrderNumber, &order.ExternalReference, &order.Channel, &order.ShippingMethod, &order.EstimatedDelivery, &order.CustomerID, &order.Status, &order.FailureCode, &order.FailureDetail, &order.TotalAmount,
+			&order.CreatedAt, &order.UpdatedAt, &order.Version)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get order items: %w", err)
+			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
-		order.Items = items
 		orders = append(orders, &order)
+		ids = append(ids, order.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim pending orders: %w", err)
+	}
+
+	items, err := r.getItemsForOrders(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		order.Items = items[order.ID]
 	}
 
 	return orders, nil
@@ -270,9 +916,9 @@ func (r *PostgresOrderRepository) GetByStatus(ctx context.Context, status models
 
 func (r *PostgresOrderRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	query := `SELECT COUNT(*) FROM orders`
+	query := `SELECT COUNT(*) FROM orders WHERE deleted_at IS NULL`
 
-	err := r.db.QueryRowContext(ctx, query).Scan(&count)
+	err := r.db.QueryRow(ctx, query).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count orders: %w", err)
 	}
@@ -280,11 +926,11 @@ func (r *PostgresOrderRepository) Count(ctx context.Context) (int64, error) {
 	return count, nil
 }
 
-func (r *PostgresOrderRepository) CountByStatus(ctx context.Context, status models.OrderStatus) (int64, error) {
+func (r *PostgresOrderRepository) CountByStatus(ctx context.Context, status models.OrderStatus, channel models.OrderChannel) (int64, error) {
 	var count int64
-	query := `SELECT COUNT(*) FROM orders WHERE status = $1`
+	query := `SELECT COUNT(*) FROM orders WHERE status = $1 AND deleted_at IS NULL AND ($2 = '' OR channel = $2)`
 
-	err := r.db.QueryRowContext(ctx, query, status).Scan(&count)
+	err := r.db.QueryRow(ctx, query, status, channel).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count orders by status: %w", err)
 	}
@@ -292,6 +938,448 @@ func (r *PostgresOrderRepository) CountByStatus(ctx context.Context, status mode
 	return count, nil
 }
 
+func (r *PostgresOrderRepository) CountByCustomerID(ctx context.Context, customerID uuid.UUID) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM orders WHERE customer_id = $1 AND deleted_at IS NULL`
+
+	err := r.db.QueryRow(ctx, query, customerID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count orders by customer: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *PostgresOrderRepository) GetOrderStats(ctx context.Context) (*models.OrderStats, error) {
+	query := `
+		SELECT status, COALESCE(failure_code, ''), COALESCE(channel, ''), COUNT(*)
+		FROM orders
+		WHERE deleted_at IS NULL
+		GROUP BY status, failure_code, channel
+	`
+
+	stats, err := r.scanOrderStats(ctx, query)
+	if err != nil {
+		r.logger.WithFields(requestctx.LogFields(ctx)).WithError(err).Warn("Failed to get order stats, collecting metrics separately")
+		if stats, err = r.getOrderStatsByMetric(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	distributions, err := r.getOrderDistributions(ctx)
+	if err != nil {
+		r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+			"metric": models.StatsMetricDistributions,
+			"error":  err,
+		}).Warn("Failed to collect order stats metric")
+		stats.AddError(models.StatsMetricDistributions, err)
+		return stats, nil
+	}
+	stats.Distributions = distributions
+	return stats, nil
+}
+
+// GetOrderPipeline counts the orders created since since by stage and
+// computes their median dwell times from the recorded events: pending from
+// creation to the first order.processing event, processing from then to the
+// first completed, failed or canceled event.
+func (r *PostgresOrderRepository) GetOrderPipeline(ctx context.Context, since time.Time) (*models.OrderPipeline, error) {
+	pipeline := models.NewOrderPipeline(since)
+
+	countQuery := `
+		SELECT status, COUNT(*)
+		FROM orders
+		WHERE deleted_at IS NULL AND created_at >= $1
+		GROUP BY status
+	`
+
+	rows, err := r.db.Query(ctx, countQuery, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orders by stage: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status models.OrderStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan order stage count: %w", err)
+		}
+		pipeline.Add(status, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate order stage counts: %w", err)
+	}
+
+	dwellQuery := `
+		WITH transitions AS (
+			SELECT o.created_at,
+				MIN(e.occurred_at) FILTER (WHERE e.event_type = $2) AS processing_at,
+				MIN(e.occurred_at) FILTER (WHERE e.event_type IN ($3, $4, $5)) AS terminal_at
+			FROM orders o
+			LEFT JOIN order_events e ON e.order_id = o.id AND e.occurred_at >= $1
+			WHERE o.deleted_at IS NULL AND o.created_at >= $1
+			GROUP BY o.id, o.created_at
+		)
+		SELECT
+			COUNT(processing_at),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM processing_at - created_at)),
+			COUNT(terminal_at - processing_at),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM terminal_at - processing_at)),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM terminal_at - created_at))
+		FROM transitions
+	`
+
+	var pendingExited, processingExited int
+	var pendingDwell, processingDwell, leadTime *float64
+	err = r.db.QueryRow(ctx, dwellQuery, since,
+		models.OrderProcessingEvent, models.OrderCompletedEvent, models.OrderFailedEvent, models.OrderCanceledEvent,
+	).Scan(&pendingExited, &pendingDwell, &processingExited, &processingDwell, &leadTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order dwell times: %w", err)
+	}
+
+	pipeline.SetDwell(models.PipelineStagePending, pendingExited, pendingDwell)
+	pipeline.SetDwell(models.PipelineStageProcessing, processingExited, processingDwell)
+	pipeline.MedianLeadTimeSeconds = leadTime
+	return pipeline, nil
+}
+
+// getOrderDistributions buckets the total amount and number of line items of
+// the orders in the database.
+func (r *PostgresOrderRepository) getOrderDistributions(ctx context.Context) (*models.OrderDistributions, error) {
+	distributions := &models.OrderDistributions{}
+
+	valueQuery := fmt.Sprintf(`
+		SELECT %s, COUNT(*), COALESCE(SUM(total_amount), 0)
+		FROM orders
+		WHERE deleted_at IS NULL
+		GROUP BY 1
+	`, bucketExpression("total_amount", models.OrderValueBounds))
+	if err := r.scanDistribution(ctx, valueQuery, &distributions.TotalAmount); err != nil {
+		return nil, err
+	}
+
+	itemsQuery := fmt.Sprintf(`
+		SELECT %s, COUNT(*), COALESCE(SUM(items), 0)
+		FROM (
+			SELECT COUNT(oi.id) AS items
+			FROM orders o
+			LEFT JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.deleted_at IS NULL
+			GROUP BY o.id
+		) order_sizes
+		GROUP BY 1
+	`, bucketExpression("items", models.OrderItemBounds))
+	if err := r.scanDistribution(ctx, itemsQuery, &distributions.ItemsPerOrder); err != nil {
+		return nil, err
+	}
+
+	return distributions, nil
+}
+
+// bucketExpression returns a SQL expression labelling column with the bucket
+// of bounds it falls into, as models.BucketLabel does.
+func bucketExpression(column string, bounds []float64) string {
+	var b strings.Builder
+	b.WriteString("CASE")
+	for _, bound := range bounds {
+		label := models.FormatBound(bound)
+		fmt.Fprintf(&b, " WHEN %s <= %s THEN '%s'", column, label, label)
+	}
+	b.WriteString(" ELSE '+Inf' END")
+	return b.String()
+}
+
+// scanDistribution runs a query returning a bucket label, a count and a sum
+// per row into d.
+func (r *PostgresOrderRepository) scanDistribution(ctx context.Context, query string, d *models.Distribution) error {
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var label string
+		var count int64
+		var sum float64
+		if err := rows.Scan(&label, &count, &sum); err != nil {
+			return err
+		}
+		d.Add(label, count, sum)
+	}
+	return rows.Err()
+}
+
+func (r *PostgresOrderRepository) scanOrderStats(ctx context.Context, query string) (*models.OrderStats, error) {
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &models.OrderStats{}
+	for rows.Next() {
+		var status models.OrderStatus
+		var failureCode models.FailureCode
+		var channel models.OrderChannel
+		var count int
+		if err := rows.Scan(&status, &failureCode, &channel, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan order stats: %w", err)
+		}
+		stats.Add(status, count)
+		stats.AddChannel(channel, count)
+		if status == models.OrderStatusFailed {
+			stats.AddFailure(failureCode, count)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate order stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// getOrderStatsByMetric collects each metric of the order stats with a query
+// of its own, so one that fails leaves the others intact. The stats are
+// degraded if any query fails; an error is returned only if all of them do.
+func (r *PostgresOrderRepository) getOrderStatsByMetric(ctx context.Context) (*models.OrderStats, error) {
+	stats := &models.OrderStats{}
+	metrics := []struct {
+		name  string
+		query string
+		add   func(key string, count int)
+	}{
+		{
+			name:  models.StatsMetricStatuses,
+			query: `SELECT status, COUNT(*) FROM orders WHERE deleted_at IS NULL GROUP BY status`,
+			add:   func(key string, count int) { stats.Add(models.OrderStatus(key), count) },
+		},
+		{
+			name:  models.StatsMetricFailureCodes,
+			query: `SELECT COALESCE(failure_code, ''), COUNT(*) FROM orders WHERE deleted_at IS NULL AND status = 'failed' GROUP BY failure_code`,
+			add:   func(key string, count int) { stats.AddFailure(models.FailureCode(key), count) },
+		},
+		{
+			name:  models.StatsMetricChannels,
+			query: `SELECT COALESCE(channel, ''), COUNT(*) FROM orders WHERE deleted_at IS NULL GROUP BY channel`,
+			add:   func(key string, count int) { stats.AddChannel(models.OrderChannel(key), count) },
+		},
+	}
+
+	var lastErr error
+	for _, metric := range metrics {
+		counts, err := r.countGrouped(ctx, metric.query)
+		if err != nil {
+			r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+				"metric": metric.name,
+				"error":  err,
+			}).Warn("Failed to collect order stats metric")
+			stats.AddError(metric.name, err)
+			lastErr = err
+			continue
+		}
+		for key, count := range counts {
+			metric.add(key, count)
+		}
+	}
+
+	if len(stats.Errors) == len(metrics) {
+		return nil, fmt.Errorf("failed to get order stats: %w", lastErr)
+	}
+	return stats, nil
+}
+
+// countGrouped runs a query returning a key and a count per row.
+func (r *PostgresOrderRepository) countGrouped(ctx context.Context, query string) (map[string]int, error) {
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var key string
+		var count int
+		if err := rows.Scan(&key, &count); err != nil {
+			return nil, err
+		}
+		counts[key] += count
+	}
+	return counts, rows.Err()
+}
+
+func (r *PostgresOrderRepository) GetChangedSince(ctx context.Context, cursor models.ChangeCursor, until time.Time, limit int) ([]*models.Order, error) {
+	query := `
+		SELECT id, tenant_id, COALESCE(order_number, ''), COALESCE(external_reference, ''), COALESCE(channel, ''), COALESCE(shipping_method, ''), estimated_delivery, customer_id, status, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), total_amount, created_at, updated_at, version, deleted_at
+		FROM orders
+		WHERE (updated_at, id) > ($1, $2) AND updated_at < $3
+		ORDER BY updated_at ASC, id ASC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, cursor.UpdatedAt, cursor.ID, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	var ids []uuid.UUID
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(&order.ID, &order.TenantID, &order.OrderNumber, &order.ExternalReference, &order.Channel, &order.ShippingMethod, &order.EstimatedDelivery, &order.CustomerID, &order.Status, &order.FailureCode, &order.FailureDetail, &order.TotalAmount,
+			&order.CreatedAt, &order.UpdatedAt, &order.Version, &order.DeletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, &order)
+		ids = append(ids, order.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate changed orders: %w", err)
+	}
+
+	items, err := r.getItemsForOrders(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		order.Items = items[order.ID]
+	}
+
+	return orders, nil
+}
+
+// streamBatchSize bounds how many orders a stream holds in memory at once.
+const streamBatchSize = 500
+
+// streamOrders calls fn for each order returned by fetch, in order, fetching
+// the batch after the last order of the previous one until a short batch.
+// Each batch is a separate query, so no connection is held while fn runs.
+// An error from fn stops the stream and is returned as is.
+func streamOrders(ctx context.Context, fetch func(after *models.Order) ([]*models.Order, error), fn func(*models.Order) error) error {
+	var after *models.Order
+	for {
+		orders, err := fetch(after)
+		if err != nil {
+			return err
+		}
+		for _, order := range orders {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+		if len(orders) < streamBatchSize {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		after = orders[len(orders)-1]
+	}
+}
+
+// StreamByStatus calls fn for every order in status, oldest first, without
+// loading them all at once.
+func (r *PostgresOrderRepository) StreamByStatus(ctx context.Context, status models.OrderStatus, fn func(*models.Order) error, opts ...models.LoadOption) error {
+	options := models.NewLoadOptions(opts...)
+
+	query := `
+		SELECT id, tenant_id, COALESCE(order_number, ''), COALESCE(external_reference, ''), COALESCE(channel, ''), COALESCE(shipping_method, ''), estimated_delivery, customer_id, status, COALESCE(failure_code, ''), COALESCE(failure_detail, ''), total_amount, created_at, updated_at, version
+		FROM orders
+		WHERE status = $1 AND deleted_at IS NULL AND (created_at, id) > ($2, $3)
+		ORDER BY created_at ASC, id ASC
+		LIMIT $4
+	`
+
+	return streamOrders(ctx, func(after *models.Order) ([]*models.Order, error) {
+		var createdAt time.Time
+		var id uuid.UUID
+		if after != nil {
+			createdAt, id = after.CreatedAt, after.ID
+		}
+
+		rows, err := r.db.Query(ctx, query, status, createdAt, id, streamBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stream orders by status: %w", err)
+		}
+		defer rows.Close()
+
+		var orders []*models.Order
+		var ids []uuid.UUID
+		for rows.Next() {
+			var order models.Order
+			err := rows.Scan(&order.ID, &order.TenantID, &order.OrderNumber, &order.ExternalReference, &order.Channel, &order.ShippingMethod, &order.EstimatedDelivery, &order.CustomerID, &order.Status, &order.FailureCode, &order.FailureDetail, &order.TotalAmount,
+				&order.CreatedAt, &order.UpdatedAt, &order.Version)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan order: %w", err)
+			}
+			orders = append(orders, &order)
+			ids = append(ids, order.ID)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to iterate orders by status: %w", err)
+		}
+
+		if !options.SkipItems {
+			items, err := r.getItemsForOrders(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			for _, order := range orders {
+				order.Items = items[order.ID]
+			}
+		}
+		return orders, nil
+	}, fn)
+}
+
+// StreamChangedSince calls fn for every order changed after cursor and before
+// until, in change order, including soft-deleted ones.
+func (r *PostgresOrderRepository) StreamChangedSince(ctx context.Context, cursor models.ChangeCursor, until time.Time, fn func(*models.Order) error) error {
+	return streamOrders(ctx, func(after *models.Order) ([]*models.Order, error) {
+		next := cursor
+		if after != nil {
+			next = models.ChangeCursor{UpdatedAt: after.UpdatedAt, ID: after.ID}
+		}
+		return r.GetChangedSince(ctx, next, until, streamBatchSize)
+	}, fn)
+}
+
+func (r *PostgresOrderRepository) getItemsForOrders(ctx context.Context, orderIDs []uuid.UUID) (map[uuid.UUID][]models.OrderItem, error) {
+	items := make(map[uuid.UUID][]models.OrderItem, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return items, nil
+	}
+
+	query := `
+		SELECT id, order_id, product_id, quantity, price, total
+		FROM order_items
+		WHERE order_id = ANY($1::uuid[])
+		ORDER BY order_id, id
+	`
+
+	rows, err := r.db.Query(ctx, query, orderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.OrderItem
+		err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.Price, &item.Total)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order item: %w", err)
+		}
+		items[item.OrderID] = append(items[item.OrderID], item)
+	}
+
+	return items, rows.Err()
+}
+
 func (r *PostgresOrderRepository) getOrderItems(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error) {
 	query := `
 		SELECT id, order_id, product_id, quantity, price, total
@@ -300,7 +1388,7 @@ func (r *PostgresOrderRepository) getOrderItems(ctx context.Context, orderID uui
 		ORDER BY id
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, orderID)
+	rows, err := r.db.Query(ctx, query, orderID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order items: %w", err)
 	}
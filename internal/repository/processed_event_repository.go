@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type processedEventKey struct{}
+
+// WithProcessedEvent marks ctx as handling the event. Order status writes
+// made with it record the event in processed_events in the same transaction,
+// and fail with "event already processed" if it was recorded before.
+func WithProcessedEvent(ctx context.Context, eventID uuid.UUID) context.Context {
+	return context.WithValue(ctx, processedEventKey{}, eventID)
+}
+
+func processedEventFrom(ctx context.Context) (uuid.UUID, bool) {
+	eventID, ok := ctx.Value(processedEventKey{}).(uuid.UUID)
+	return eventID, ok
+}
+
+type PostgresProcessedEventRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresProcessedEventRepository(db *sql.DB) *PostgresProcessedEventRepository {
+	return &PostgresProcessedEventRepository{
+		db:     db,
+		logger: logrus.WithField("component", "processed_event_repository"),
+	}
+}
+
+func (r *PostgresProcessedEventRepository) Exists(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM processed_events WHERE event_id = $1)`
+	if err := r.db.QueryRowContext(ctx, query, eventID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check processed event: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *PostgresProcessedEventRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM processed_events WHERE processed_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if deleted > 0 {
+		r.logger.WithFields(requestctx.LogFields(ctx)).WithField("deleted", deleted).Info("Expired processed events deleted")
+	}
+	return deleted, nil
+}
\ No newline at end of file
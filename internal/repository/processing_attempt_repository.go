@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type PostgresProcessingAttemptRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresProcessingAttemptRepository(db *sql.DB) *PostgresProcessingAttemptRepository {
+	return &PostgresProcessingAttemptRepository{
+		db:     db,
+		logger: logrus.WithField("component", "processing_attempt_repository"),
+	}
+}
+
+// Start records a running attempt, numbering it after the order's previous
+// attempts.
+func (r *PostgresProcessingAttemptRepository) Start(ctx context.Context, attempt *models.ProcessingAttempt) error {
+	query := `
+		INSERT INTO processing_attempts (id, order_id, event_id, event_type, attempt, outcome, started_at)
+		SELECT $1, $2, $3, $4, COALESCE(MAX(attempt), 0) + 1, $5, $6
+		FROM processing_attempts
+		WHERE order_id = $2
+		RETURNING attempt
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		attempt.ID, attempt.OrderID, attempt.EventID, attempt.EventType, attempt.Outcome, attempt.StartedAt,
+	).Scan(&attempt.Attempt)
+	if err != nil {
+		return fmt.Errorf("failed to start processing attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresProcessingAttemptRepository) Finish(ctx context.Context, attempt *models.ProcessingAttempt) error {
+	query := `
+		UPDATE processing_attempts
+		SET outcome = $2, error = NULLIF($3, ''), ended_at = $4, next_attempt_at = $5
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, attempt.ID, attempt.Outcome, attempt.Error, attempt.EndedAt, attempt.NextAttemptAt); err != nil {
+		return fmt.Errorf("failed to finish processing attempt: %w", err)
+	}
+
+	if attempt.Outcome == models.AttemptOutcomeFailed {
+		r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+			"order_id":        attempt.OrderID,
+			"event_id":        attempt.EventID,
+			"attempt":         attempt.Attempt,
+			"next_attempt_at": attempt.NextAttemptAt,
+		}).Warn("Processing attempt failed")
+	}
+	return nil
+}
+
+// CountFailures returns how many attempts at handling the event have failed.
+func (r *PostgresProcessingAttemptRepository) CountFailures(ctx context.Context, eventID uuid.UUID) (int, error) {
+	var failures int
+	query := `SELECT COUNT(*) FROM processing_attempts WHERE event_id = $1 AND outcome = $2`
+	if err := r.db.QueryRowContext(ctx, query, eventID, models.AttemptOutcomeFailed).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("failed to count failed processing attempts: %w", err)
+	}
+	return failures, nil
+}
+
+func (r *PostgresProcessingAttemptRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.ProcessingAttempt, error) {
+	query := `
+		SELECT id, order_id, event_id, event_type, attempt, outcome, COALESCE(error, ''), started_at, ended_at, next_attempt_at
+		FROM processing_attempts
+		WHERE order_id = $1
+		ORDER BY attempt ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processing attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*models.ProcessingAttempt
+	for rows.Next() {
+		var attempt models.ProcessingAttempt
+		err := rows.Scan(&attempt.ID, &attempt.OrderID, &attempt.EventID, &attempt.EventType, &attempt.Attempt,
+			&attempt.Outcome, &attempt.Error, &attempt.StartedAt, &attempt.EndedAt, &attempt.NextAttemptAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan processing attempt: %w", err)
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	return attempts, rows.Err()
+}
\ No newline at end of file
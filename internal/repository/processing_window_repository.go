@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type PostgresProcessingWindowRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresProcessingWindowRepository(db *sql.DB) *PostgresProcessingWindowRepository {
+	return &PostgresProcessingWindowRepository{
+		db:     db,
+		logger: logrus.WithField("component", "processing_window_repository"),
+	}
+}
+
+func (r *PostgresProcessingWindowRepository) Get(ctx context.Context, tenantID string) (*models.TenantProcessingWindows, error) {
+	query := `
+		SELECT tenant_id, time_zone, rules, updated_at
+		FROM tenant_processing_windows
+		WHERE tenant_id = $1
+	`
+
+	windows := &models.TenantProcessingWindows{}
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, tenantID).Scan(
+		&windows.TenantID, &windows.TimeZone, pq.Array(&windows.Rules), &updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrProcessingWindowsNotFound
+		}
+		return nil, fmt.Errorf("failed to get processing windows: %w", err)
+	}
+	windows.UpdatedAt = &updatedAt
+
+	return windows, nil
+}
+
+func (r *PostgresProcessingWindowRepository) Upsert(ctx context.Context, windows *models.TenantProcessingWindows) error {
+	query := `
+		INSERT INTO tenant_processing_windows (tenant_id, time_zone, rules, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (tenant_id) DO UPDATE
+		SET time_zone = EXCLUDED.time_zone,
+		    rules = EXCLUDED.rules,
+		    updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, windows.TenantID, windows.TimeZone, pq.Array(windows.Rules)).Scan(&updatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update processing windows: %w", err)
+	}
+	windows.UpdatedAt = &updatedAt
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"tenant_id": windows.TenantID,
+		"time_zone": windows.TimeZone,
+		"rules":     windows.Rules,
+	}).Info("Tenant processing windows updated")
+	return nil
+}
+
+func (r *PostgresProcessingWindowRepository) Delete(ctx context.Context, tenantID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tenant_processing_windows WHERE tenant_id = $1`, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete processing windows: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if deleted == 0 {
+		return ErrProcessingWindowsNotFound
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithField("tenant_id", tenantID).Info("Tenant processing windows deleted")
+	return nil
+}
\ No newline at end of file
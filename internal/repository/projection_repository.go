@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type PostgresProjectionRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresProjectionRepository(db *sql.DB) *PostgresProjectionRepository {
+	return &PostgresProjectionRepository{
+		db:     db,
+		logger: logrus.WithField("component", "projection_repository"),
+	}
+}
+
+// Truncate empties the projection tables, and with them the tables with
+// foreign keys to orders. Their sagas, compensations and item changes are not
+// part of the event stream and are not rebuilt.
+func (r *PostgresProjectionRepository) Truncate(ctx context.Context) error {
+	query := `TRUNCATE TABLE order_item_changes, order_compensations, order_sagas, order_items, orders`
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to truncate projection tables: %w", err)
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).Warn("Projection tables truncated")
+	return nil
+}
+
+func (r *PostgresProjectionRepository) UpsertOrder(ctx context.Context, order *models.Order) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tenantID := order.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenantID
+	}
+
+	orderQuery := `
+		INSERT INTO orders (id, tenant_id, order_number, external_reference, customer_id, status, total_amount, created_at, updated_at, version, channel)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, $9, $10, NULLIF($11, ''))
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	_, err = tx.ExecContext(ctx, orderQuery,
+		order.ID, tenantID, order.OrderNumber, order.ExternalReference, order.CustomerID, order.Status, order.TotalAmount,
+		order.CreatedAt, order.UpdatedAt, order.Version, order.Channel,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert order: %w", err)
+	}
+
+	itemQuery := `
+		INSERT INTO order_items (id, order_id, product_id, quantity, price, total)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING
+	`
+
+	for _, item := range order.Items {
+		if item.ID == uuid.Nil {
+			item.ID = uuid.New()
+		}
+		item.Total = item.Price * float64(item.Quantity)
+
+		_, err = tx.ExecContext(ctx, itemQuery,
+			item.ID, order.ID, item.ProductID, item.Quantity, item.Price, item.Total,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert order item: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresProjectionRepository) ApplyStatus(ctx context.Context, id uuid.UUID, status models.OrderStatus, at time.Time) error {
+	query := `
+		UPDATE orders
+		SET status = $2, updated_at = $3, version = version + 1
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, status, at)
+	if err != nil {
+		return fmt.Errorf("failed to apply order status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrOrderNotFound
+	}
+
+	return nil
+}
+
+// ApplyAdjustment applies an order.adjusted event: the repriced items and the
+// new total.
+func (r *PostgresProjectionRepository) ApplyAdjustment(ctx context.Context, adjustment *models.OrderAdjustment) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	orderQuery := `
+		UPDATE orders
+		SET total_amount = $2, updated_at = $3, version = version + 1
+		WHERE id = $1
+	`
+
+	result, err := tx.ExecContext(ctx, orderQuery, adjustment.OrderID, adjustment.NewTotal, adjustment.AdjustedAt)
+	if err != nil {
+		return fmt.Errorf("failed to apply order adjustment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrOrderNotFound
+	}
+
+	itemQuery := `
+		UPDATE order_items
+		SET price = $3, total = $4
+		WHERE id = $1 AND order_id = $2
+	`
+
+	for _, item := range adjustment.Items {
+		if _, err := tx.ExecContext(ctx, itemQuery, item.ItemID, adjustment.OrderID, item.NewPrice, item.NewTotal); err != nil {
+			return fmt.Errorf("failed to apply order item adjustment: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
\ No newline at end of file
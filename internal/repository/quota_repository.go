@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type PostgresQuotaRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresQuotaRepository(db *sql.DB) *PostgresQuotaRepository {
+	return &PostgresQuotaRepository{
+		db:     db,
+		logger: logrus.WithField("component", "quota_repository"),
+	}
+}
+
+func (r *PostgresQuotaRepository) Get(ctx context.Context, tenantID string) (*models.TenantQuota, error) {
+	query := `
+		SELECT tenant_id, max_active_orders, max_orders_per_day, updated_at
+		FROM tenant_quotas
+		WHERE tenant_id = $1
+	`
+
+	quota := &models.TenantQuota{}
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, tenantID).Scan(
+		&quota.TenantID, &quota.MaxActiveOrders, &quota.MaxOrdersPerDay, &updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTenantQuotaNotFound
+		}
+		return nil, fmt.Errorf("failed to get tenant quota: %w", err)
+	}
+	quota.UpdatedAt = &updatedAt
+
+	return quota, nil
+}
+
+func (r *PostgresQuotaRepository) Upsert(ctx context.Context, quota *models.TenantQuota) error {
+	query := `
+		INSERT INTO tenant_quotas (tenant_id, max_active_orders, max_orders_per_day, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (tenant_id) DO UPDATE
+		SET max_active_orders = EXCLUDED.max_active_orders,
+		    max_orders_per_day = EXCLUDED.max_orders_per_day,
+		    updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, quota.TenantID, quota.MaxActiveOrders, quota.MaxOrdersPerDay).Scan(&updatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant quota: %w", err)
+	}
+	quota.UpdatedAt = &updatedAt
+	quota.Default = false
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"tenant_id":          quota.TenantID,
+		"max_active_orders":  quota.MaxActiveOrders,
+		"max_orders_per_day": quota.MaxOrdersPerDay,
+	}).Info("Tenant quota updated")
+	return nil
+}
+
+// GetUsage counts the tenant's active orders and the orders it created since
+// the given time.
+func (r *PostgresQuotaRepository) GetUsage(ctx context.Context, tenantID string, since time.Time) (*models.TenantQuotaUsage, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = ANY($2)),
+			COUNT(*) FILTER (WHERE created_at >= $3)
+		FROM orders
+		WHERE tenant_id = $1 AND deleted_at IS NULL
+	`
+
+	statuses := make([]string, 0, len(models.ActiveOrderStatuses))
+	for _, status := range models.ActiveOrderStatuses {
+		statuses = append(statuses, string(status))
+	}
+
+	usage := &models.TenantQuotaUsage{}
+	err := r.db.QueryRowContext(ctx, query, tenantID, pq.Array(statuses), since).Scan(&usage.ActiveOrders, &usage.OrdersToday)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant quota usage: %w", err)
+	}
+
+	return usage, nil
+}
\ No newline at end of file
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type PostgresSagaRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresSagaRepository(db *sql.DB) *PostgresSagaRepository {
+	return &PostgresSagaRepository{
+		db:     db,
+		logger: logrus.WithField("component", "saga_repository"),
+	}
+}
+
+func (r *PostgresSagaRepository) Create(ctx context.Context, saga *models.Saga) error {
+	query := `
+		INSERT INTO order_sagas (id, order_id, step, status, correlation_id, deadline, last_error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		saga.ID, saga.OrderID, saga.Step, saga.Status, saga.CorrelationID,
+		saga.Deadline, saga.LastError, saga.CreatedAt, saga.UpdatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateSaga
+		}
+		return fmt.Errorf("failed to create saga: %w", err)
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithFields(logrus.Fields{
+		"saga_id":  saga.ID,
+		"order_id": saga.OrderID,
+		"step":     saga.Step,
+	}).Info("Saga created successfully")
+	return nil
+}
+
+func (r *PostgresSagaRepository) GetByCorrelationID(ctx context.Context, correlationID uuid.UUID) (*models.Saga, error) {
+	query := `
+		SELECT id, order_id, step, status, correlation_id, deadline, last_error, created_at, updated_at
+		FROM order_sagas
+		WHERE correlation_id = $1
+	`
+
+	saga, err := scanSaga(r.db.QueryRowContext(ctx, query, correlationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSagaNotFound
+		}
+		return nil, fmt.Errorf("failed to get saga: %w", err)
+	}
+
+	return saga, nil
+}
+
+func (r *PostgresSagaRepository) Update(ctx context.Context, saga *models.Saga, expectedCorrelationID uuid.UUID) error {
+	query := `
+		UPDATE order_sagas
+		SET step = $2, status = $3, correlation_id = $4, deadline = $5, last_error = $6, updated_at = $7
+		WHERE id = $1 AND correlation_id = $8 AND status = $9
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		saga.ID, saga.Step, saga.Status, saga.CorrelationID, saga.Deadline, saga.LastError, saga.UpdatedAt,
+		expectedCorrelationID, models.SagaStatusAwaitingReply,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update saga: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrSagaVersionConflict
+	}
+
+	return nil
+}
+
+func (r *PostgresSagaRepository) GetTimedOut(ctx context.Context, now time.Time, limit int) ([]*models.Saga, error) {
+	query := `
+		SELECT id, order_id, step, status, correlation_id, deadline, last_error, created_at, updated_at
+		FROM order_sagas
+		WHERE status = $1 AND deadline < $2
+		ORDER BY deadline ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.SagaStatusAwaitingReply, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timed out sagas: %w", err)
+	}
+	defer rows.Close()
+
+	var sagas []*models.Saga
+	for rows.Next() {
+		saga, err := scanSaga(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saga: %w", err)
+		}
+		sagas = append(sagas, saga)
+	}
+
+	return sagas, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSaga(row rowScanner) (*models.Saga, error) {
+	var saga models.Saga
+	var lastError sql.NullString
+	err := row.Scan(&saga.ID, &saga.OrderID, &saga.Step, &saga.Status, &saga.CorrelationID,
+		&saga.Deadline, &lastError, &saga.CreatedAt, &saga.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	saga.LastError = lastError.String
+	return &saga, nil
+}
\ No newline at end of file
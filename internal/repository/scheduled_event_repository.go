@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+)
+
+type PostgresScheduledEventRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresScheduledEventRepository(db *sql.DB) *PostgresScheduledEventRepository {
+	return &PostgresScheduledEventRepository{
+		db:     db,
+		logger: logrus.WithField("component", "scheduled_event_repository"),
+	}
+}
+
+// Schedule stores event for delivery at deliverAt. Scheduling an event again
+// moves its delivery time.
+func (r *PostgresScheduledEventRepository) Schedule(ctx context.Context, event *models.Event, deliverAt time.Time) error {
+	payload, err := event.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	query := `
+		INSERT INTO scheduled_events (event_id, event_type, payload, deliver_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id) DO UPDATE SET payload = EXCLUDED.payload, deliver_at = EXCLUDED.deliver_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, event.ID, event.Type, payload, deliverAt); err != nil {
+		return fmt.Errorf("failed to schedule event: %w", err)
+	}
+	return nil
+}
+
+// Cancel removes a scheduled event and reports whether it was still waiting.
+func (r *PostgresScheduledEventRepository) Cancel(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM scheduled_events WHERE event_id = $1`, eventID)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel scheduled event: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel scheduled event: %w", err)
+	}
+	return deleted > 0, nil
+}
+
+// ReleaseDue passes up to limit events due by now to publish, earliest first,
+// and deletes those it accepted. It stops at the first event publish fails
+// for, which stays scheduled. Events locked by another instance releasing at
+// the same time are skipped.
+func (r *PostgresScheduledEventRepository) ReleaseDue(ctx context.Context, now time.Time, limit int, publish func(ctx context.Context, event *models.Event) error) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release scheduled events: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT event_id, payload
+		FROM scheduled_events
+		WHERE deliver_at <= $1
+		ORDER BY deliver_at ASC
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due scheduled events: %w", err)
+	}
+
+	var due []*models.Event
+	for rows.Next() {
+		var eventID uuid.UUID
+		var payload []byte
+		if err := rows.Scan(&eventID, &payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan scheduled event: %w", err)
+		}
+		var event models.Event
+		if err := event.FromJSON(payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to unmarshal scheduled event %s: %w", eventID, err)
+		}
+		due = append(due, &event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get due scheduled events: %w", err)
+	}
+
+	released := 0
+	var publishErr error
+	for _, event := range due {
+		if publishErr = publish(ctx, event); publishErr != nil {
+			break
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM scheduled_events WHERE event_id = $1`, event.ID); err != nil {
+			return 0, fmt.Errorf("failed to delete released scheduled event: %w", err)
+		}
+		released++
+	}
+
+	if released > 0 {
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("failed to delete released scheduled events: %w", err)
+		}
+	}
+
+	return released, publishErr
+}
\ No newline at end of file
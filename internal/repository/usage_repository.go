@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type PostgresUsageRepository struct {
+	db     *sql.DB
+	logger *logrus.Entry
+}
+
+func NewPostgresUsageRepository(db *sql.DB) *PostgresUsageRepository {
+	return &PostgresUsageRepository{
+		db:     db,
+		logger: logrus.WithField("component", "usage_repository"),
+	}
+}
+
+// Add adds the records' counts to the stored hourly counts.
+func (r *PostgresUsageRepository) Add(ctx context.Context, records []models.UsageRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO tenant_usage (tenant_id, hour, metric, count)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, hour, metric) DO UPDATE
+		SET count = tenant_usage.count + EXCLUDED.count
+	`
+
+	for _, record := range records {
+		if _, err := tx.ExecContext(ctx, query, record.TenantID, record.Hour, record.Metric, record.Count); err != nil {
+			return fmt.Errorf("failed to record usage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit usage: %w", err)
+	}
+
+	r.logger.WithFields(requestctx.LogFields(ctx)).WithField("records", len(records)).Debug("Usage recorded")
+	return nil
+}
+
+func (r *PostgresUsageRepository) List(ctx context.Context, filter models.UsageFilter) ([]models.UsageRecord, error) {
+	query := `
+		SELECT tenant_id, hour, metric, count
+		FROM tenant_usage
+		WHERE hour >= $1 AND hour < $2 AND ($3 = '' OR tenant_id = $3)
+		ORDER BY hour ASC, tenant_id ASC, metric ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.From, filter.To, filter.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	defer rows.Close()
+
+	var records []models.UsageRecord
+	for rows.Next() {
+		var record models.UsageRecord
+		if err := rows.Scan(&record.TenantID, &record.Hour, &record.Metric, &record.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
\ No newline at end of file
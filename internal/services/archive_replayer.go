@@ -0,0 +1,129 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/storage"
+)
+
+// archiveEnd stands in for an open end of a replayed range.
+var archiveEnd = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ArchiveReplayer feeds events from the hourly files written by EventArchiver
+// to a handler, for history that Kafka no longer keeps. Files are read in hour
+// order and events within a file in the order they occurred.
+type ArchiveReplayer struct {
+	repo   repository.EventArchiveRepository
+	store  storage.BlobStore
+	logger *logrus.Entry
+}
+
+func NewArchiveReplayer(repo repository.EventArchiveRepository, store storage.BlobStore) *ArchiveReplayer {
+	return &ArchiveReplayer{
+		repo:   repo,
+		store:  store,
+		logger: logrus.WithField("component", "archive_replayer"),
+	}
+}
+
+// ArchivedUntil returns the end of the newest archived hour, or the zero time
+// if nothing is archived. Events before it are replayed from the archive.
+func (r *ArchiveReplayer) ArchivedUntil(ctx context.Context) (time.Time, error) {
+	archives, err := r.repo.ListCompleted(ctx, time.Time{}, archiveEnd)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(archives) == 0 {
+		return time.Time{}, nil
+	}
+	return archives[len(archives)-1].End(), nil
+}
+
+// ReplayBetween feeds the archived events with a timestamp at or after since
+// and before until to handler, marking ctx as a replay. A zero since or until
+// leaves that end open. It stops at the first event handler fails.
+func (r *ArchiveReplayer) ReplayBetween(ctx context.Context, since, until time.Time, handler queue.EventHandler) (int, error) {
+	to := until
+	if to.IsZero() {
+		to = archiveEnd
+	}
+	archives, err := r.repo.ListCompleted(ctx, since.UTC().Truncate(time.Hour), to)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = queue.WithReplay(ctx)
+	replayed := 0
+	for _, archive := range archives {
+		n, err := r.replayArchive(ctx, archive, since, until, handler)
+		replayed += n
+		if err != nil {
+			return replayed, err
+		}
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"archives":        len(archives),
+		"events_replayed": replayed,
+	}).Info("Archive replay finished")
+	return replayed, nil
+}
+
+func (r *ArchiveReplayer) replayArchive(ctx context.Context, archive *models.EventArchive, since, until time.Time, handler queue.EventHandler) (int, error) {
+	body, err := r.store.Get(ctx, archive.ObjectKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read event archive %s: %w", archive.ObjectKey, err)
+	}
+	defer body.Close()
+
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read event archive %s: %w", archive.ObjectKey, err)
+	}
+	defer gz.Close()
+
+	reader := bufio.NewReader(gz)
+	replayed := 0
+	for line := 1; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+
+		payload, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(payload) == 0 {
+			return replayed, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return replayed, fmt.Errorf("failed to read event archive %s: %w", archive.ObjectKey, err)
+		}
+
+		var event models.Event
+		if err := event.FromJSON(bytes.TrimSpace(payload)); err != nil {
+			r.logger.WithFields(logrus.Fields{
+				"key":   archive.ObjectKey,
+				"line":  line,
+				"error": err,
+			}).Warn("Skipping undecodable archived event")
+			continue
+		}
+		if event.Timestamp.Before(since) || (!until.IsZero() && !event.Timestamp.Before(until)) {
+			continue
+		}
+
+		if err := handler.HandleEvent(ctx, &event); err != nil {
+			return replayed, fmt.Errorf("failed to replay event %s from %s line %d: %w", event.ID, archive.ObjectKey, line, err)
+		}
+		replayed++
+	}
+}
\ No newline at end of file
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/storage"
+	"order-processing-microservice/pkg/config"
+)
+
+// maxAttachmentFileName bounds the stored file name; longer names are cut.
+const maxAttachmentFileName = 255
+
+// The errors Upload rejects a file with, matched with errors.Is.
+var (
+	ErrAttachmentEmpty          = errors.New("attachment is empty")
+	ErrAttachmentTooLarge       = errors.New("attachment too large")
+	ErrAttachmentTypeNotAllowed = errors.New("attachment type not allowed")
+)
+
+// AttachmentService stores files against orders. Content goes to the blob
+// store and metadata to the attachment repository; downloads are served
+// through signed URLs that expire after AttachmentConfig.URLExpiry seconds.
+type AttachmentService struct {
+	repo         repository.AttachmentRepository
+	orders       repository.OrderReader
+	store        storage.BlobStore
+	maxSize      int64
+	allowedTypes map[string]bool
+	urlExpiry    time.Duration
+	logger       *logrus.Entry
+}
+
+func NewAttachmentService(repo repository.AttachmentRepository, orders repository.OrderReader, store storage.BlobStore, cfg *config.AttachmentConfig) *AttachmentService {
+	allowedTypes := make(map[string]bool, len(cfg.AllowedTypes))
+	for _, contentType := range cfg.AllowedTypes {
+		if contentType = strings.TrimSpace(strings.ToLower(contentType)); contentType != "" {
+			allowedTypes[contentType] = true
+		}
+	}
+
+	return &AttachmentService{
+		repo:         repo,
+		orders:       orders,
+		store:        store,
+		maxSize:      cfg.MaxSize,
+		allowedTypes: allowedTypes,
+		urlExpiry:    time.Duration(cfg.URLExpiry) * time.Second,
+		logger:       logrus.WithField("component", "attachment_service"),
+	}
+}
+
+// MaxSize is the largest accepted attachment in bytes.
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload stores body as an attachment of the order. The content type is
+// sniffed from the content rather than trusted from the client.
+func (s *AttachmentService) Upload(ctx context.Context, orderID uuid.UUID, fileName string, body io.Reader) (*models.Attachment, error) {
+	if _, err := s.orders.GetByID(ctx, orderID, models.WithoutItems()); err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(body, s.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if len(content) == 0 {
+		return nil, ErrAttachmentEmpty
+	}
+	if int64(len(content)) > s.maxSize {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrAttachmentTooLarge, s.maxSize)
+	}
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(content))
+	if err != nil || !s.allowedTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentTypeNotAllowed, contentType)
+	}
+
+	checksum := sha256.Sum256(content)
+	attachment := &models.Attachment{
+		ID:          uuid.New(),
+		OrderID:     orderID,
+		FileName:    cleanFileName(fileName),
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+		CreatedAt:   time.Now().UTC(),
+	}
+	attachment.StorageKey = fmt.Sprintf("orders/%s/%s", orderID, attachment.ID)
+
+	if err := s.store.Put(ctx, attachment.StorageKey, bytes.NewReader(content), attachment.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	if err := s.repo.Create(ctx, attachment); err != nil {
+		if deleteErr := s.store.Delete(ctx, attachment.StorageKey); deleteErr != nil {
+			s.logger.WithError(deleteErr).WithField("storage_key", attachment.StorageKey).Warn("Failed to delete orphaned attachment blob")
+		}
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"order_id":      orderID,
+		"attachment_id": attachment.ID,
+		"size":          attachment.Size,
+	}).Info("Attachment uploaded")
+
+	return attachment, nil
+}
+
+func (s *AttachmentService) List(ctx context.Context, orderID uuid.UUID) ([]*models.Attachment, error) {
+	return s.repo.ListByOrderID(ctx, orderID)
+}
+
+// Get returns the attachment with a signed download URL.
+func (s *AttachmentService) Get(ctx context.Context, orderID, id uuid.UUID) (*models.AttachmentDownload, error) {
+	attachment, err := s.repo.GetByID(ctx, orderID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.urlExpiry).UTC()
+	url, err := s.store.SignedURL(ctx, attachment.StorageKey, storage.DownloadOptions{
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+	}, s.urlExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AttachmentDownload{Attachment: attachment, DownloadURL: url, ExpiresAt: expiresAt}, nil
+}
+
+// Delete removes the attachment. The blob is deleted after the metadata, so
+// a failure leaves an unreferenced blob rather than a dangling attachment.
+func (s *AttachmentService) Delete(ctx context.Context, orderID, id uuid.UUID) error {
+	attachment, err := s.repo.GetByID(ctx, orderID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, orderID, id); err != nil {
+		return err
+	}
+
+	if err := s.store.Delete(ctx, attachment.StorageKey); err != nil {
+		s.logger.WithError(err).WithField("storage_key", attachment.StorageKey).Warn("Failed to delete attachment blob")
+	}
+	return nil
+}
+
+func cleanFileName(fileName string) string {
+	fileName = filepath.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if fileName == "." || fileName == "/" {
+		fileName = "attachment"
+	}
+	if len(fileName) > maxAttachmentFileName {
+		fileName = fileName[:maxAttachmentFileName]
+	}
+	return fileName
+}
\ No newline at end of file
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+// Auditor publishes an audit record of every order state change to the audit
+// topic. The request ID is the correlation ID of the change's trace context,
+// so changes the consumer makes carry the ID of the request that caused them.
+type Auditor struct {
+	publisher queue.TopicPublisher
+	topic     string
+	logger    *logrus.Entry
+}
+
+func NewAuditor(publisher queue.TopicPublisher, cfg *config.AuditConfig) *Auditor {
+	return &Auditor{
+		publisher: publisher,
+		topic:     cfg.Topic,
+		logger:    logrus.WithField("component", "auditor"),
+	}
+}
+
+// Record audits the change of order from oldStatus to its current status. A
+// failed publish is logged; it does not undo the change.
+func (a *Auditor) Record(ctx context.Context, order *models.Order, oldStatus models.OrderStatus) {
+	record := models.NewAuditRecord(order, oldStatus, requestctx.Actor(ctx), requestctx.TraceFrom(ctx).CorrelationID)
+	if err := a.publisher.PublishEventToTopic(ctx, a.topic, models.NewOrderAuditEvent(record)); err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"order_id":   order.ID,
+			"action":     record.Action,
+			"new_status": record.NewStatus,
+			"error":      err,
+		}).Error("Failed to publish audit record")
+	}
+}
+
+// RecordAdjustment audits the recalculation of order's total. Like Record, a
+// failed publish is only logged.
+func (a *Auditor) RecordAdjustment(ctx context.Context, order *models.Order, adjustment *models.OrderAdjustment) {
+	record := models.NewAdjustmentAuditRecord(order, adjustment, requestctx.TraceFrom(ctx).CorrelationID)
+	if err := a.publisher.PublishEventToTopic(ctx, a.topic, models.NewOrderAuditEvent(record)); err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"order_id":  order.ID,
+			"action":    record.Action,
+			"new_total": adjustment.NewTotal,
+			"error":     err,
+		}).Error("Failed to publish audit record")
+	}
+}
\ No newline at end of file
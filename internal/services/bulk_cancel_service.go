@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/pkg/config"
+)
+
+// The errors Start rejects a request with, matched with errors.Is.
+var (
+	ErrInvalidBulkCancel        = errors.New("invalid bulk cancel request")
+	ErrBulkCancelFilterRequired = errors.New("filter required")
+)
+
+// BulkCancelService cancels the orders matching a filter in the background,
+// one batch at a time, and records the progress in a job. Canceled orders no
+// longer match the filter, so each batch picks up where the previous one
+// stopped and a job interrupted by a restart can simply be started again.
+type BulkCancelService struct {
+	jobs       repository.BulkCancelRepository
+	orders     *OrderService
+	batchSize  int
+	batchDelay time.Duration
+	stop       chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+	logger     *logrus.Entry
+}
+
+func NewBulkCancelService(jobs repository.BulkCancelRepository, orders *OrderService, cfg *config.BulkCancelConfig) *BulkCancelService {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &BulkCancelService{
+		jobs:       jobs,
+		orders:     orders,
+		batchSize:  batchSize,
+		batchDelay: time.Duration(cfg.BatchDelay) * time.Millisecond,
+		stop:       make(chan struct{}),
+		logger:     logrus.WithField("component", "bulk_cancel_service"),
+	}
+}
+
+// Start records a job for req and cancels the matching orders in the
+// background.
+func (s *BulkCancelService) Start(ctx context.Context, req *models.BulkCancelRequest) (*models.BulkCancelJob, error) {
+	if req.Status == "" {
+		req.Status = models.OrderStatusPending
+	}
+	if !models.IsBulkCancelableStatus(req.Status) {
+		return nil, fmt.Errorf("%w: status %s cannot be bulk canceled", ErrInvalidBulkCancel, req.Status)
+	}
+	if req.ReasonCode == "" {
+		req.ReasonCode = models.CancelReasonAdmin
+	}
+	if !req.ReasonCode.IsValid() {
+		return nil, fmt.Errorf("%w: invalid cancel reason code %s", ErrInvalidBulkCancel, req.ReasonCode)
+	}
+	if req.Channel != "" && !req.Channel.IsValid() {
+		return nil, fmt.Errorf("%w: invalid channel %s", ErrInvalidBulkCancel, req.Channel)
+	}
+	if req.Filter().IsEmpty() && !req.All {
+		return nil, ErrBulkCancelFilterRequired
+	}
+
+	now := time.Now().UTC()
+	job := &models.BulkCancelJob{
+		ID:          uuid.New(),
+		Status:      models.BulkCancelJobRunning,
+		OrderStatus: req.Status,
+		Filter:      req.Filter(),
+		ReasonCode:  req.ReasonCode,
+		Reason:      req.Reason,
+		Actor:       req.Actor,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.jobs.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"job_id":       job.ID,
+		"order_status": job.OrderStatus,
+		"actor":        job.Actor,
+	}).Info("Bulk cancel job started")
+
+	s.wg.Add(1)
+	progress := *job
+	go func() {
+		defer s.wg.Done()
+		s.run(&progress, req)
+	}()
+	return job, nil
+}
+
+func (s *BulkCancelService) GetJob(ctx context.Context, id uuid.UUID) (*models.BulkCancelJob, error) {
+	return s.jobs.GetByID(ctx, id)
+}
+
+// run cancels batches until one comes back short. Batches run detached from
+// the request that started the job; Close stops the job between batches.
+func (s *BulkCancelService) run(job *models.BulkCancelJob, req *models.BulkCancelRequest) {
+	ctx := context.Background()
+	for {
+		select {
+		case <-s.stop:
+			job.Status = models.BulkCancelJobFailed
+			job.Error = "interrupted by shutdown"
+			s.finish(ctx, job)
+			return
+		default:
+		}
+
+		orders, published, err := s.orders.CancelBatch(ctx, req, s.batchSize)
+		if err != nil {
+			job.Status = models.BulkCancelJobFailed
+			job.Error = err.Error()
+			s.finish(ctx, job)
+			return
+		}
+
+		if len(orders) > 0 {
+			job.Batches++
+			job.Canceled += len(orders)
+			job.Published += published
+			job.UpdatedAt = time.Now().UTC()
+		}
+		if len(orders) < s.batchSize {
+			job.Status = models.BulkCancelJobCompleted
+			s.finish(ctx, job)
+			return
+		}
+		s.update(ctx, job)
+
+		select {
+		case <-s.stop:
+		case <-time.After(s.batchDelay):
+		}
+	}
+}
+
+func (s *BulkCancelService) finish(ctx context.Context, job *models.BulkCancelJob) {
+	now := time.Now().UTC()
+	job.UpdatedAt = now
+	job.CompletedAt = &now
+	s.update(ctx, job)
+
+	s.logger.WithFields(logrus.Fields{
+		"job_id":    job.ID,
+		"status":    job.Status,
+		"batches":   job.Batches,
+		"canceled":  job.Canceled,
+		"published": job.Published,
+		"error":     job.Error,
+	}).Info("Bulk cancel job finished")
+}
+
+func (s *BulkCancelService) update(ctx context.Context, job *models.BulkCancelJob) {
+	if err := s.jobs.Update(ctx, job); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"job_id": job.ID,
+			"error":  err,
+		}).Error("Failed to record bulk cancel progress")
+	}
+}
+
+// Wait blocks until every running job has finished.
+func (s *BulkCancelService) Wait() {
+	s.wg.Wait()
+}
+
+// Close stops running jobs after their current batch, marking them failed,
+// and waits for them.
+func (s *BulkCancelService) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.wg.Wait()
+}
\ No newline at end of file
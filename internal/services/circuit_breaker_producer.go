@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/pkg/config"
+)
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// CircuitBreakerStatus reports the state of a CircuitBreakerProducer. Pending
+// is the number of stored events waiting to be forwarded, as of the last
+// forward.
+type CircuitBreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Opens               uint64     `json:"opens"`
+	Stored              uint64     `json:"stored"`
+	Forwarded           uint64     `json:"forwarded"`
+	Pending             int        `json:"pending"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// CircuitBreakerProducer stores events in a fallback table instead of losing
+// them when publishing fails. After FailureThreshold consecutive failures the
+// breaker opens and events are stored without trying the broker. Run forwards
+// stored events in the order they were stored; once the open timeout has
+// passed its first publish probes the broker (half-open), closing the breaker
+// when it succeeds. New events are stored too while older ones are waiting,
+// so they do not overtake them.
+type CircuitBreakerProducer struct {
+	producer        queue.Producer
+	fallback        repository.FallbackEventRepository
+	threshold       int
+	openTimeout     time.Duration
+	forwardInterval time.Duration
+	batchSize       int
+
+	mu     sync.Mutex
+	status CircuitBreakerStatus
+	logger *logrus.Entry
+}
+
+func NewCircuitBreakerProducer(producer queue.Producer, fallback repository.FallbackEventRepository, cfg *config.CircuitBreakerConfig) *CircuitBreakerProducer {
+	forwardInterval := time.Duration(cfg.ForwardInterval) * time.Second
+	if forwardInterval <= 0 {
+		forwardInterval = 5 * time.Second
+	}
+	batchSize := cfg.ForwardBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	return &CircuitBreakerProducer{
+		producer:        producer,
+		fallback:        fallback,
+		threshold:       max(cfg.FailureThreshold, 1),
+		openTimeout:     time.Duration(cfg.OpenTimeout) * time.Second,
+		forwardInterval: forwardInterval,
+		batchSize:       batchSize,
+		status:          CircuitBreakerStatus{State: BreakerClosed},
+		logger:          logrus.WithField("component", "circuit_breaker_producer"),
+	}
+}
+
+func (p *CircuitBreakerProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	if p.publishDirectly() {
+		err := p.producer.PublishEvent(ctx, event)
+		if err == nil {
+			p.succeeded()
+			return nil
+		}
+		// An oversized event would fail again when forwarded.
+		if errors.Is(err, queue.ErrPayloadTooLarge) {
+			return err
+		}
+		p.failed(err)
+	}
+	return p.store(ctx, event)
+}
+
+// publishDirectly reports whether event can be sent to the broker: the
+// breaker is not open and no stored event is waiting.
+func (p *CircuitBreakerProducer) publishDirectly() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status.State != BreakerOpen && p.status.Pending == 0
+}
+
+func (p *CircuitBreakerProducer) store(ctx context.Context, event *models.Event) error {
+	if err := p.fallback.Store(ctx, event); err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"error":      err,
+		}).Error("Failed to store event for forwarding")
+		return fmt.Errorf("failed to publish or store event: %w", err)
+	}
+
+	p.mu.Lock()
+	p.status.Stored++
+	p.status.Pending++
+	p.mu.Unlock()
+
+	p.logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+	}).Warn("Event stored for forwarding")
+	return nil
+}
+
+func (p *CircuitBreakerProducer) succeeded() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.ConsecutiveFailures = 0
+	if p.status.State != BreakerClosed {
+		p.status.State = BreakerClosed
+		p.status.OpenedAt = nil
+		p.logger.Info("Broker recovered, circuit breaker closed")
+	}
+}
+
+func (p *CircuitBreakerProducer) failed(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.ConsecutiveFailures++
+	if p.status.State == BreakerClosed && p.status.ConsecutiveFailures < p.threshold {
+		return
+	}
+	if p.status.State != BreakerOpen {
+		p.status.Opens++
+		p.logger.WithError(err).WithField("consecutive_failures", p.status.ConsecutiveFailures).
+			Warn("Broker unavailable, circuit breaker opened")
+	}
+	now := time.Now()
+	p.status.State = BreakerOpen
+	p.status.OpenedAt = &now
+}
+
+// allowForward reports whether stored events may be forwarded, moving an
+// open breaker whose timeout has passed to half-open.
+func (p *CircuitBreakerProducer) allowForward() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status.State != BreakerOpen {
+		return true
+	}
+	if time.Since(*p.status.OpenedAt) < p.openTimeout {
+		return false
+	}
+	p.status.State = BreakerHalfOpen
+	return true
+}
+
+// Run forwards stored events every forward interval until ctx is done.
+func (p *CircuitBreakerProducer) Run(ctx context.Context) {
+	p.forward(ctx)
+
+	ticker := time.NewTicker(p.forwardInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.forward(ctx)
+		}
+	}
+}
+
+func (p *CircuitBreakerProducer) forward(ctx context.Context) {
+	for p.allowForward() {
+		p.mu.Lock()
+		stored := p.status.Stored
+		p.mu.Unlock()
+
+		forwarded, err := p.fallback.Forward(ctx, p.batchSize, func(ctx context.Context, event *models.Event) error {
+			if err := p.producer.PublishEvent(ctx, event); err != nil {
+				p.failed(err)
+				return err
+			}
+			p.succeeded()
+			return nil
+		})
+		if forwarded > 0 {
+			p.logger.WithField("count", forwarded).Info("Stored events forwarded")
+		}
+		if err != nil {
+			p.logger.WithError(err).Error("Failed to forward stored events")
+		}
+
+		pending, countErr := p.fallback.Count(ctx)
+		if countErr != nil {
+			p.logger.WithError(countErr).Error("Failed to count stored events")
+			return
+		}
+
+		p.mu.Lock()
+		p.status.Forwarded += uint64(forwarded)
+		// An event stored meanwhile may not be counted yet; keep the
+		// local count, which includes it, until the next forward.
+		if p.status.Stored == stored {
+			p.status.Pending = pending
+		}
+		p.mu.Unlock()
+
+		if err != nil || forwarded < p.batchSize || pending == 0 {
+			return
+		}
+	}
+}
+
+// Status returns the state of the breaker and the forwarding counters.
+func (p *CircuitBreakerProducer) Status() CircuitBreakerStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := p.status
+	if status.OpenedAt != nil {
+		openedAt := *status.OpenedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+func (p *CircuitBreakerProducer) Close() error {
+	return p.producer.Close()
+}
\ No newline at end of file
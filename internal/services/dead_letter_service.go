@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+)
+
+// DeadLetterRequeuer publishes a dead-lettered message back to its topic.
+type DeadLetterRequeuer interface {
+	Requeue(ctx context.Context, event *models.DeadLetterEvent) error
+}
+
+// The errors of requeueing and purging dead letters, matched with errors.Is.
+var (
+	ErrDeadLetterRequeueDisabled = errors.New("dead letter requeue is not enabled")
+	ErrTooManyDeadLetters        = errors.New("too many dead letters")
+)
+
+type DeadLetterService struct {
+	deadLetterRepo repository.DeadLetterRepository
+	requeuer       DeadLetterRequeuer
+}
+
+func NewDeadLetterService(deadLetterRepo repository.DeadLetterRepository) *DeadLetterService {
+	return &DeadLetterService{
+		deadLetterRepo: deadLetterRepo,
+	}
+}
+
+// ListDeadLetters returns a page of dead-lettered messages, newest first, and
+// the total count.
+func (s *DeadLetterService) ListDeadLetters(ctx context.Context, limit, offset int) ([]*models.DeadLetterEvent, int64, error) {
+	events, err := s.deadLetterRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.deadLetterRepo.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// EnableRequeue allows dead letters to be published back to their topic.
+func (s *DeadLetterService) EnableRequeue(requeuer DeadLetterRequeuer) {
+	s.requeuer = requeuer
+}
+
+// RequeueDeadLetters publishes the dead letters with the given IDs back to
+// their topic, in the order given, and marks them requeued. It stops at the
+// first one that fails to publish.
+func (s *DeadLetterService) RequeueDeadLetters(ctx context.Context, ids []uuid.UUID) (*models.DeadLetterRequeueResponse, error) {
+	if s.requeuer == nil {
+		return nil, ErrDeadLetterRequeueDisabled
+	}
+	if len(ids) > models.MaxDeadLetterSelection {
+		return nil, fmt.Errorf("%w, at most %d can be selected", ErrTooManyDeadLetters, models.MaxDeadLetterSelection)
+	}
+
+	response := &models.DeadLetterRequeueResponse{Requeued: []uuid.UUID{}, Missing: []uuid.UUID{}}
+	for _, id := range ids {
+		event, err := s.deadLetterRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrDeadLetterNotFound) {
+				response.Missing = append(response.Missing, id)
+				continue
+			}
+			return nil, err
+		}
+
+		if err := s.requeuer.Requeue(ctx, event); err != nil {
+			return nil, err
+		}
+		if err := s.deadLetterRepo.MarkRequeued(ctx, id); err != nil {
+			return nil, err
+		}
+		response.Requeued = append(response.Requeued, id)
+	}
+
+	return response, nil
+}
+
+func (s *DeadLetterService) PurgeDeadLetters(ctx context.Context, ids []uuid.UUID) (*models.DeadLetterPurgeResponse, error) {
+	if len(ids) > models.MaxDeadLetterSelection {
+		return nil, fmt.Errorf("%w, at most %d can be selected", ErrTooManyDeadLetters, models.MaxDeadLetterSelection)
+	}
+
+	purged, err := s.deadLetterRepo.Delete(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DeadLetterPurgeResponse{Purged: purged}, nil
+}
+
+func (s *DeadLetterService) GetDeadLetter(ctx context.Context, id uuid.UUID) (*models.DeadLetterEvent, error) {
+	return s.deadLetterRepo.GetByID(ctx, id)
+}
\ No newline at end of file
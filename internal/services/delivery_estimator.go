@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+// DeliveryEstimator estimates when an order will be delivered: the processing
+// SLA counted from when processing can start, then the transit time of the
+// order's shipping method, both in business days that skip weekends and the
+// configured holidays.
+type DeliveryEstimator struct {
+	location       *time.Location
+	cutoffHour     int
+	processingDays int
+	transitDays    map[models.ShippingMethod]int
+	holidays       map[string]bool
+}
+
+func NewDeliveryEstimator(cfg *config.DeliveryConfig) (*DeliveryEstimator, error) {
+	timeZone := cfg.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid delivery time zone %q", timeZone)
+	}
+
+	holidays := make(map[string]bool, len(cfg.Holidays))
+	for _, raw := range cfg.Holidays {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, raw); err != nil {
+			return nil, fmt.Errorf("invalid delivery holiday %q: expected YYYY-MM-DD", raw)
+		}
+		holidays[raw] = true
+	}
+
+	return &DeliveryEstimator{
+		location:       location,
+		cutoffHour:     cfg.CutoffHour,
+		processingDays: cfg.ProcessingDays,
+		transitDays: map[models.ShippingMethod]int{
+			models.ShippingMethodStandard:  cfg.StandardDays,
+			models.ShippingMethodExpress:   cfg.ExpressDays,
+			models.ShippingMethodOvernight: cfg.OvernightDays,
+		},
+		holidays: holidays,
+	}, nil
+}
+
+// Estimate returns the delivery date of order given that processing starts,
+// or for completed orders finished, at from. Failed, canceled and held orders
+// have no estimate. The date is returned as midnight UTC.
+func (e *DeliveryEstimator) Estimate(order *models.Order, from time.Time) *time.Time {
+	switch order.Status {
+	case models.OrderStatusFailed, models.OrderStatusCanceled, models.OrderStatusOnHold:
+		return nil
+	}
+
+	method := order.ShippingMethod
+	if !method.IsValid() {
+		method = models.ShippingMethodStandard
+	}
+
+	day := e.startDay(from)
+	if order.Status != models.OrderStatusCompleted {
+		day = e.addBusinessDays(day, e.processingDays)
+	}
+	day = e.addBusinessDays(day, e.transitDays[method])
+
+	estimate := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	return &estimate
+}
+
+// startDay is the business day work started at t counts from: the day of t,
+// or the next business day when t is past the cutoff (if any) or not a
+// business day.
+func (e *DeliveryEstimator) startDay(t time.Time) time.Time {
+	local := t.In(e.location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, e.location)
+	if (e.cutoffHour > 0 && local.Hour() >= e.cutoffHour) || !e.isBusinessDay(day) {
+		day = e.addBusinessDays(day, 1)
+	}
+	return day
+}
+
+func (e *DeliveryEstimator) addBusinessDays(day time.Time, n int) time.Time {
+	for n > 0 {
+		day = day.AddDate(0, 0, 1)
+		if e.isBusinessDay(day) {
+			n--
+		}
+	}
+	return day
+}
+
+func (e *DeliveryEstimator) isBusinessDay(day time.Time) bool {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false
+	}
+	return !e.holidays[day.Format(time.DateOnly)]
+}
\ No newline at end of file
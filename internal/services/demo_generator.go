@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+type demoProduct struct {
+	id    uuid.UUID
+	price float64
+}
+
+// DemoGenerator creates a steady stream of synthetic orders for the demo
+// tenant. Customers, products, order sizes and scripted outcomes are all
+// drawn from a generator seeded with DemoConfig.Seed, so two runs with the
+// same settings produce the same sequence of orders.
+type DemoGenerator struct {
+	orderService *OrderService
+	cfg          config.DemoConfig
+	rng          *rand.Rand
+	customers    []uuid.UUID
+	products     []demoProduct
+	run          string
+	sequence     int
+	logger       *logrus.Entry
+}
+
+func NewDemoGenerator(orderService *OrderService, cfg *config.DemoConfig) *DemoGenerator {
+	normalized := *cfg
+	normalized.Customers = max(normalized.Customers, 1)
+	normalized.Products = max(normalized.Products, 1)
+	normalized.MinItems = max(normalized.MinItems, 1)
+	normalized.MaxItems = max(normalized.MaxItems, normalized.MinItems)
+	normalized.MaxQuantity = max(normalized.MaxQuantity, 1)
+
+	g := &DemoGenerator{
+		orderService: orderService,
+		cfg:          normalized,
+		rng:          rand.New(rand.NewSource(cfg.Seed)),
+		// The run ID only keeps references unique across restarts; it is not
+		// drawn from the seeded generator.
+		run:    uuid.New().String()[:8],
+		logger: logrus.WithField("component", "demo_generator"),
+	}
+
+	for i := 0; i < normalized.Customers; i++ {
+		g.customers = append(g.customers, g.uuid())
+	}
+	for i := 0; i < normalized.Products; i++ {
+		price := math.Round((1+g.rng.Float64()*199)*100) / 100
+		g.products = append(g.products, demoProduct{id: g.uuid(), price: price})
+	}
+	return g
+}
+
+func (g *DemoGenerator) uuid() uuid.UUID {
+	id, err := uuid.NewRandomFromReader(g.rng)
+	if err != nil {
+		// rand.Rand reads never fail.
+		panic(err)
+	}
+	return id
+}
+
+// Next returns the request for the next demo order.
+func (g *DemoGenerator) Next() *models.CreateOrderRequest {
+	g.sequence++
+
+	outcome := models.DemoOutcomeComplete
+	if g.rng.Float64() < g.cfg.FailureRate {
+		outcome = models.DemoOutcomeFail
+	}
+
+	req := &models.CreateOrderRequest{
+		TenantID:          models.DemoTenantID,
+		ExternalReference: models.DemoReference(outcome, g.run, g.sequence),
+		CustomerID:        g.customers[g.rng.Intn(len(g.customers))],
+	}
+
+	count := g.cfg.MinItems + g.rng.Intn(g.cfg.MaxItems-g.cfg.MinItems+1)
+	for i := 0; i < count; i++ {
+		product := g.products[g.rng.Intn(len(g.products))]
+		req.Items = append(req.Items, models.CreateOrderItemRequest{
+			ProductID: product.id,
+			Quantity:  1 + g.rng.Intn(g.cfg.MaxQuantity),
+			Price:     product.price,
+		})
+	}
+	return req
+}
+
+// Run creates one order every DemoConfig.Interval milliseconds until ctx is
+// done.
+func (g *DemoGenerator) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(max(g.cfg.Interval, 1)) * time.Millisecond)
+	defer ticker.Stop()
+
+	g.logger.WithFields(logrus.Fields{
+		"run":          g.run,
+		"seed":         g.cfg.Seed,
+		"interval_ms":  g.cfg.Interval,
+		"failure_rate": g.cfg.FailureRate,
+	}).Info("Demo order generator started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			req := g.Next()
+			if _, err := g.orderService.CreateOrder(ctx, req); err != nil && ctx.Err() == nil {
+				g.logger.WithError(err).WithField("external_reference", req.ExternalReference).Error("Failed to create demo order")
+			}
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/storage"
+	"order-processing-microservice/pkg/config"
+)
+
+// archiveLease is how long an hour being archived stays claimed by its run
+// before another run may take it over.
+const archiveLease = time.Hour
+
+// EventArchiver copies recorded events to blob storage, one gzipped NDJSON
+// file per hour, so they can be kept long after Kafka has dropped them. Each
+// file gets a manifest record in the database. Hours are archived oldest
+// first once they have ended plus the configured delay, which leaves time for
+// late events to be recorded.
+type EventArchiver struct {
+	repo     repository.EventArchiveRepository
+	store    storage.BlobStore
+	prefix   string
+	interval time.Duration
+	delay    time.Duration
+	logger   *logrus.Entry
+}
+
+func NewEventArchiver(repo repository.EventArchiveRepository, store storage.BlobStore, cfg *config.EventArchiveConfig) *EventArchiver {
+	interval := time.Duration(cfg.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	delay := time.Duration(cfg.Delay) * time.Second
+	if delay < 0 {
+		delay = 0
+	}
+
+	return &EventArchiver{
+		repo:     repo,
+		store:    store,
+		prefix:   cfg.Prefix,
+		interval: interval,
+		delay:    delay,
+		logger:   logrus.WithField("component", "event_archiver"),
+	}
+}
+
+// Run archives the pending hours every interval until ctx is done.
+func (a *EventArchiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.ArchivePending(ctx); err != nil {
+				a.logger.WithError(err).Error("Failed to archive events")
+			}
+		}
+	}
+}
+
+// ArchivePending archives every hour that is due, oldest first, and returns
+// how many it archived. It stops at an hour another run is archiving or that
+// fails; the hour is retried on the next run.
+func (a *EventArchiver) ArchivePending(ctx context.Context) (int, error) {
+	before := time.Now().Add(-a.delay).UTC().Truncate(time.Hour)
+
+	archived := 0
+	for ctx.Err() == nil {
+		hour, err := a.repo.NextHour(ctx, before)
+		if err != nil {
+			return archived, err
+		}
+		if hour == nil {
+			return archived, nil
+		}
+
+		archive := models.NewEventArchive(a.prefix, *hour)
+		claimed, err := a.repo.Claim(ctx, archive, time.Now().Add(-archiveLease))
+		if err != nil {
+			return archived, err
+		}
+		if !claimed {
+			return archived, nil
+		}
+
+		if err := a.archive(ctx, archive); err != nil {
+			archive.Fail(err)
+			if failErr := a.repo.Fail(ctx, archive); failErr != nil {
+				a.logger.WithError(failErr).Error("Failed to record failed event archive")
+			}
+			return archived, fmt.Errorf("failed to archive events of %s: %w", archive.Hour.Format(time.RFC3339), err)
+		}
+		archived++
+	}
+	return archived, ctx.Err()
+}
+
+// archive writes the events of the archive's hour to a temporary file, so
+// that its size is known and a large hour is not held in memory, uploads it
+// and completes the manifest record.
+func (a *EventArchiver) archive(ctx context.Context, archive *models.EventArchive) error {
+	file, err := os.CreateTemp("", "event-archive-*.ndjson.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	count := 0
+	err = a.repo.EachEvent(ctx, archive.Hour, archive.End(), func(payload []byte) error {
+		if _, err := gz.Write(append(payload, '\n')); err != nil {
+			return fmt.Errorf("failed to write archive file: %w", err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed to size archive file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind archive file: %w", err)
+	}
+
+	if err := a.store.Put(ctx, archive.ObjectKey, file, size, "application/gzip"); err != nil {
+		return err
+	}
+
+	archive.Complete(count, size)
+	if err := a.repo.Complete(ctx, archive); err != nil {
+		return err
+	}
+
+	a.logger.WithFields(logrus.Fields{
+		"hour":   archive.Hour,
+		"key":    archive.ObjectKey,
+		"events": count,
+		"bytes":  size,
+	}).Info("Events archived")
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/pkg/config"
+)
+
+// EventScheduler publishes events at a later time, e.g. to retry a failed
+// order or cancel an unpaid one. Scheduled events are kept in the database
+// until they are due, so they survive restarts, and are published by Run at
+// least once.
+type EventScheduler struct {
+	repo         repository.ScheduledEventRepository
+	producer     queue.Producer
+	pollInterval time.Duration
+	batchSize    int
+	logger       *logrus.Entry
+}
+
+func NewEventScheduler(repo repository.ScheduledEventRepository, producer queue.Producer, cfg *config.EventSchedulerConfig) *EventScheduler {
+	pollInterval := time.Duration(cfg.PollInterval) * time.Second
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	return &EventScheduler{
+		repo:         repo,
+		producer:     producer,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		logger:       logrus.WithField("component", "event_scheduler"),
+	}
+}
+
+// Schedule publishes event once deliverAt has passed. Scheduling an event
+// that is still waiting again moves its delivery time.
+func (s *EventScheduler) Schedule(ctx context.Context, event *models.Event, deliverAt time.Time) error {
+	if err := s.repo.Schedule(ctx, event, deliverAt); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"deliver_at": deliverAt,
+	}).Info("Event scheduled")
+	return nil
+}
+
+// Cancel drops a scheduled event and reports whether it was still waiting.
+func (s *EventScheduler) Cancel(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	return s.repo.Cancel(ctx, eventID)
+}
+
+// Run publishes due events every poll interval until ctx is done.
+func (s *EventScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ReleaseDue(ctx); err != nil {
+				s.logger.WithError(err).Error("Failed to release scheduled events")
+			}
+		}
+	}
+}
+
+// ReleaseDue publishes the events that are due, a batch at a time, earliest
+// first. It stops at the first event that fails to publish, which is retried
+// on the next run.
+func (s *EventScheduler) ReleaseDue(ctx context.Context) error {
+	for {
+		released, err := s.repo.ReleaseDue(ctx, time.Now(), s.batchSize, s.producer.PublishEvent)
+		if released > 0 {
+			s.logger.WithField("count", released).Info("Scheduled events released")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to publish scheduled event: %w", err)
+		}
+		if released < s.batchSize {
+			return nil
+		}
+	}
+}
\ No newline at end of file
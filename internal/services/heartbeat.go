@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+// HeartbeatEmitter publishes a heartbeat event every interval, so consumers
+// can tell a quiet period from a broken pipeline. A producer that can
+// broadcast publishes it to every partition, so each consumer in a group
+// receives one per interval.
+type HeartbeatEmitter struct {
+	producer queue.Producer
+	source   string
+	interval time.Duration
+	sequence uint64
+	logger   *logrus.Entry
+}
+
+// NewHeartbeatEmitter returns an emitter publishing through producer as
+// source, e.g. the host name of the producer instance.
+func NewHeartbeatEmitter(producer queue.Producer, source string, cfg *config.HeartbeatConfig) *HeartbeatEmitter {
+	return &HeartbeatEmitter{
+		producer: producer,
+		source:   source,
+		interval: time.Duration(cfg.Interval) * time.Second,
+		logger:   logrus.WithField("component", "heartbeat_emitter"),
+	}
+}
+
+// Run publishes a heartbeat right away and then every interval until ctx is
+// done. A failed publish is logged; the heartbeat is not retried, the next one
+// replaces it.
+func (e *HeartbeatEmitter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.Emit(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Emit publishes the next heartbeat.
+func (e *HeartbeatEmitter) Emit(ctx context.Context) {
+	e.sequence++
+	event := models.NewHeartbeatEvent(e.source, e.sequence)
+	publish := e.producer.PublishEvent
+	if broadcaster, ok := e.producer.(queue.BroadcastPublisher); ok {
+		publish = broadcaster.BroadcastEvent
+	}
+	if err := publish(ctx, event); err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"sequence": e.sequence,
+			"error":    err,
+		}).Warn("Failed to publish heartbeat")
+	}
+}
+
+// HeartbeatStatus is what a HeartbeatMonitor has seen. Age is the time since
+// the last heartbeat was handled, or since the monitor started if none was;
+// Delay is how long the last one took from the producer to the consumer.
+type HeartbeatStatus struct {
+	Received       uint64     `json:"received"`
+	LastSource     string     `json:"last_source,omitempty"`
+	LastReceivedAt *time.Time `json:"last_received_at,omitempty"`
+	Age            float64    `json:"age_seconds"`
+	Delay          float64    `json:"delay_seconds"`
+	Stale          bool       `json:"stale"`
+}
+
+// HeartbeatMonitor tracks the heartbeats a consumer handles. It reports the
+// pipeline stale once no heartbeat arrived for the configured number of
+// intervals. Heartbeats are broadcast to every partition, so each consumer in
+// a group counts one per interval for each partition it is assigned.
+type HeartbeatMonitor struct {
+	staleAfter time.Duration
+	started    time.Time
+
+	mu         sync.Mutex
+	received   uint64
+	lastSource string
+	lastAt     time.Time
+	lastDelay  time.Duration
+	stale      bool
+
+	logger *logrus.Entry
+}
+
+// NewHeartbeatMonitor returns a monitor that counts from now, so a consumer
+// that never receives a heartbeat goes stale as well.
+func NewHeartbeatMonitor(cfg *config.HeartbeatConfig) *HeartbeatMonitor {
+	return &HeartbeatMonitor{
+		staleAfter: time.Duration(cfg.Interval*cfg.MissedIntervals) * time.Second,
+		started:    time.Now(),
+		logger:     logrus.WithField("component", "heartbeat_monitor"),
+	}
+}
+
+// Observe records a heartbeat event handled now.
+func (m *HeartbeatMonitor) Observe(event *models.Event) {
+	var data models.HeartbeatEventData
+	if err := event.DecodeData(&data); err != nil {
+		m.logger.WithError(err).Warn("Failed to decode heartbeat")
+		return
+	}
+	if data.SentAt.IsZero() {
+		data.SentAt = event.Timestamp
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.received++
+	m.lastSource = data.Source
+	m.lastAt = now
+	m.lastDelay = max(now.Sub(data.SentAt), 0)
+	if m.stale {
+		m.stale = false
+		m.logger.WithField("source", data.Source).Info("Heartbeats resumed")
+	}
+}
+
+// Status returns what the monitor has seen as of now.
+func (m *HeartbeatMonitor) Status() HeartbeatStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status(time.Now())
+}
+
+// status must be called with m.mu held.
+func (m *HeartbeatMonitor) status(now time.Time) HeartbeatStatus {
+	status := HeartbeatStatus{
+		Received:   m.received,
+		LastSource: m.lastSource,
+		Age:        now.Sub(m.started).Seconds(),
+		Delay:      m.lastDelay.Seconds(),
+	}
+	if m.received > 0 {
+		lastAt := m.lastAt.UTC()
+		status.LastReceivedAt = &lastAt
+		status.Age = now.Sub(m.lastAt).Seconds()
+	}
+	status.Stale = m.staleAfter > 0 && status.Age >= m.staleAfter.Seconds()
+	return status
+}
+
+// Run checks every interval whether heartbeats stopped arriving and warns
+// once when they did, until ctx is done.
+func (m *HeartbeatMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+func (m *HeartbeatMonitor) check() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := m.status(time.Now())
+	if !status.Stale || m.stale {
+		return
+	}
+	m.stale = true
+	m.logger.WithFields(logrus.Fields{
+		"age_seconds": int(status.Age),
+		"last_source": status.LastSource,
+		"received":    status.Received,
+	}).Warn("No heartbeat received; the pipeline from the producer may be broken")
+}
+
+// WritePrometheus writes the status as Prometheus gauges and a counter.
+func (m *HeartbeatMonitor) WritePrometheus(w io.Writer) error {
+	status := m.Status()
+	_, err := fmt.Fprintf(w,
+		"# HELP consumer_heartbeat_age_seconds Seconds since the consumer last handled a producer heartbeat.\n"+
+			"# TYPE consumer_heartbeat_age_seconds gauge\n"+
+			"consumer_heartbeat_age_seconds %s\n"+
+			"# HELP consumer_heartbeat_delay_seconds Seconds the last heartbeat took from the producer to the consumer.\n"+
+			"# TYPE consumer_heartbeat_delay_seconds gauge\n"+
+			"consumer_heartbeat_delay_seconds %s\n"+
+			"# HELP consumer_heartbeats_received_total Heartbeats the consumer handled since it started.\n"+
+			"# TYPE consumer_heartbeats_received_total counter\n"+
+			"consumer_heartbeats_received_total %d\n",
+		strconv.FormatFloat(status.Age, 'f', 3, 64),
+		strconv.FormatFloat(status.Delay, 'f', 3, 64),
+		status.Received,
+	)
+	return err
+}
\ No newline at end of file
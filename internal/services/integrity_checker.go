@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+)
+
+// IntegrityChecker scans the order data for inconsistencies the service
+// should never produce, and optionally repairs them.
+type IntegrityChecker struct {
+	repo   repository.IntegrityRepository
+	limit  int
+	logger *logrus.Entry
+}
+
+// NewIntegrityChecker returns a checker reporting up to limit
+// inconsistencies of each kind per run.
+func NewIntegrityChecker(repo repository.IntegrityRepository, limit int) *IntegrityChecker {
+	return &IntegrityChecker{
+		repo:   repo,
+		limit:  limit,
+		logger: logrus.WithField("component", "integrity_checker"),
+	}
+}
+
+// Run runs every check in the order of models.InconsistencyKinds. With
+// repair set, the findings of a check are repaired, as made by actor, before
+// the next check runs; findings that changed since the scan are left for the
+// next run. A failed repair is logged and left unresolved.
+func (c *IntegrityChecker) Run(ctx context.Context, repair bool, actor string) (*models.IntegrityReport, error) {
+	report := models.NewIntegrityReport()
+
+	for _, kind := range models.InconsistencyKinds {
+		found, err := c.repo.Find(ctx, kind, c.limit)
+		if err != nil {
+			return report, fmt.Errorf("failed to check %s: %w", kind, err)
+		}
+		if len(found) >= c.limit {
+			c.logger.WithFields(logrus.Fields{
+				"kind":  kind,
+				"limit": c.limit,
+			}).Warn("Check stopped at the limit; run again for the rest")
+		}
+
+		for _, inconsistency := range found {
+			report.Add(inconsistency)
+			if !repair || !inconsistency.Repairable() {
+				continue
+			}
+
+			repaired, err := c.repo.Repair(ctx, inconsistency, actor)
+			if err != nil {
+				if ctx.Err() != nil {
+					return report, ctx.Err()
+				}
+				c.logger.WithFields(logrus.Fields{
+					"kind":     kind,
+					"order_id": inconsistency.OrderID,
+					"error":    err,
+				}).Error("Failed to repair inconsistency")
+				continue
+			}
+			if repaired {
+				inconsistency.Repaired = true
+				report.Repaired++
+			}
+		}
+	}
+
+	return report, nil
+}
\ No newline at end of file
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/pkg/config"
+)
+
+// OrderContextService keeps the request origin of orders for
+// OrderContextConfig.Retention hours.
+type OrderContextService struct {
+	repo      repository.OrderContextRepository
+	retention time.Duration
+	logger    *logrus.Entry
+}
+
+func NewOrderContextService(repo repository.OrderContextRepository, cfg *config.OrderContextConfig) *OrderContextService {
+	return &OrderContextService{
+		repo:      repo,
+		retention: time.Duration(cfg.Retention) * time.Hour,
+		logger:    logrus.WithField("component", "order_context_service"),
+	}
+}
+
+// Record stores the context of a newly created order. Failures are logged and
+// do not fail the order.
+func (s *OrderContextService) Record(ctx context.Context, order *models.Order, orderContext *models.OrderContext) {
+	record := *orderContext
+	record.OrderID = order.ID
+	record.CreatedAt = order.CreatedAt
+	if len(record.UserAgent) > models.MaxUserAgentLength {
+		record.UserAgent = record.UserAgent[:models.MaxUserAgentLength]
+	}
+
+	if err := s.repo.Create(ctx, &record); err != nil {
+		s.logger.WithError(err).WithField("order_id", order.ID).Warn("Failed to record order context")
+	}
+}
+
+func (s *OrderContextService) Get(ctx context.Context, orderID uuid.UUID) (*models.OrderContext, error) {
+	return s.repo.GetByOrderID(ctx, orderID)
+}
+
+// DeleteExpired deletes the contexts older than the retention. A retention of
+// 0 keeps them forever.
+func (s *OrderContextService) DeleteExpired(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.DeleteBefore(ctx, time.Now().Add(-s.retention))
+}
+
+// Run deletes expired contexts every interval until ctx is done.
+func (s *OrderContextService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+				s.logger.WithError(err).Error("Failed to delete expired order contexts")
+			}
+		}
+	}
+}
\ No newline at end of file
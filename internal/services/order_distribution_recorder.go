@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+	"sync"
+
+	"order-processing-microservice/internal/models"
+)
+
+// OrderDistributionRecorder keeps the value and size distributions of the
+// orders created since the process started, for scraping by Prometheus.
+type OrderDistributionRecorder struct {
+	mu            sync.Mutex
+	distributions models.OrderDistributions
+}
+
+func NewOrderDistributionRecorder() *OrderDistributionRecorder {
+	return &OrderDistributionRecorder{}
+}
+
+func (r *OrderDistributionRecorder) Observe(order *models.Order) {
+	r.mu.Lock()
+	r.distributions.Observe(order)
+	r.mu.Unlock()
+}
+
+// Snapshot returns a copy of the distributions recorded so far.
+func (r *OrderDistributionRecorder) Snapshot() models.OrderDistributions {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := r.distributions
+	snapshot.TotalAmount.Buckets = maps.Clone(snapshot.TotalAmount.Buckets)
+	snapshot.ItemsPerOrder.Buckets = maps.Clone(snapshot.ItemsPerOrder.Buckets)
+	return snapshot
+}
+
+// WritePrometheus writes the distributions as Prometheus histograms in the
+// text exposition format.
+func (r *OrderDistributionRecorder) WritePrometheus(w io.Writer) error {
+	snapshot := r.Snapshot()
+	if err := writeHistogram(w, "orders_created_total_amount", "Total amount of orders created.", models.OrderValueBounds, snapshot.TotalAmount); err != nil {
+		return err
+	}
+	return writeHistogram(w, "orders_created_items", "Number of line items of orders created.", models.OrderItemBounds, snapshot.ItemsPerOrder)
+}
+
+// writeHistogram writes d with cumulative buckets, as Prometheus expects.
+func writeHistogram(w io.Writer, name, help string, bounds []float64, d models.Distribution) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name); err != nil {
+		return err
+	}
+
+	var cumulative int64
+	for _, bound := range bounds {
+		label := models.FormatBound(bound)
+		cumulative += d.Buckets[label]
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, label, cumulative); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %s\n%s_count %d\n",
+		name, d.Count, name, strconv.FormatFloat(d.Sum, 'f', -1, 64), name, d.Count)
+	return err
+}
\ No newline at end of file
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+)
+
+type OrderHistoryService struct {
+	eventStore repository.EventStore
+	attempts   repository.ProcessingAttemptRepository
+	logger     *logrus.Entry
+}
+
+func NewOrderHistoryService(eventStore repository.EventStore) *OrderHistoryService {
+	return &OrderHistoryService{
+		eventStore: eventStore,
+		logger:     logrus.WithField("component", "order_history_service"),
+	}
+}
+
+// EnableAttempts adds the recorded processing attempts of orders to their
+// timelines.
+func (s *OrderHistoryService) EnableAttempts(attempts repository.ProcessingAttemptRepository) {
+	s.attempts = attempts
+}
+
+func (s *OrderHistoryService) GetOrderAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*models.Order, error) {
+	events, err := s.eventStore.GetByOrderID(ctx, id, asOf)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"order_id": id,
+			"as_of":    asOf,
+			"error":    err,
+		}).Error("Failed to load order history")
+		return nil, fmt.Errorf("failed to load order history: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, repository.ErrOrderNotFound
+	}
+
+	order, err := models.ReplayOrder(events)
+	if errors.Is(err, models.ErrOrderNotCreated) {
+		// The order predates the event store; it has no history to replay.
+		return nil, repository.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetTimeline returns the recorded events of an order and, with EnableAttempts,
+// its processing attempts.
+func (s *OrderHistoryService) GetTimeline(ctx context.Context, id uuid.UUID) (*models.OrderTimeline, error) {
+	events, err := s.eventStore.GetByOrderID(ctx, id, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order history: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, repository.ErrOrderNotFound
+	}
+
+	timeline := &models.OrderTimeline{
+		OrderID:  id,
+		Events:   events,
+		Attempts: []*models.ProcessingAttempt{},
+	}
+	if s.attempts != nil {
+		attempts, err := s.attempts.ListByOrderID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load processing attempts: %w", err)
+		}
+		if attempts != nil {
+			timeline.Attempts = attempts
+		}
+	}
+
+	return timeline, nil
+}
\ No newline at end of file
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"time"
@@ -11,12 +12,34 @@ import (
 	"order-processing-microservice/internal/models"
 	"order-processing-microservice/internal/queue"
 	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/database"
+	"order-processing-microservice/pkg/requestctx"
 )
 
 type OrderProcessor struct {
 	orderRepo repository.OrderRepository
 	producer  queue.Producer
 	logger    *logrus.Entry
+
+	sagaRepo     repository.SagaRepository
+	sagaCommands queue.TopicPublisher
+	sagaConfig   *config.SagaConfig
+
+	compensationRepo repository.CompensationRepository
+
+	processedEvents repository.ProcessedEventRepository
+
+	processingWindows *ProcessingWindowService
+
+	delivery *DeliveryEstimator
+
+	attempts repository.ProcessingAttemptRepository
+	backoff  models.BackoffPolicy
+
+	auditor *Auditor
+
+	heartbeats *HeartbeatMonitor
 }
 
 func NewOrderProcessor(orderRepo repository.OrderRepository, producer queue.Producer) *OrderProcessor {
@@ -27,18 +50,201 @@ func NewOrderProcessor(orderRepo repository.OrderRepository, producer queue.Prod
 	}
 }
 
+func (p *OrderProcessor) EnableSaga(sagaRepo repository.SagaRepository, commands queue.TopicPublisher, cfg *config.SagaConfig) {
+	p.sagaRepo = sagaRepo
+	p.sagaCommands = commands
+	p.sagaConfig = cfg
+}
+
+func (p *OrderProcessor) EnableCompensations(compensationRepo repository.CompensationRepository) {
+	p.compensationRepo = compensationRepo
+}
+
+// EnableDeduplication makes redelivered events no-ops. Each event's status
+// update is recorded in processed_events atomically, and events recorded
+// there are skipped.
+func (p *OrderProcessor) EnableDeduplication(processedEvents repository.ProcessedEventRepository) {
+	p.processedEvents = processedEvents
+}
+
+// EnableProcessingWindows defers orders created outside their tenant's
+// processing windows to the scheduled status until the next window opens,
+// see ReleaseScheduledOrders.
+func (p *OrderProcessor) EnableProcessingWindows(processingWindows *ProcessingWindowService) {
+	p.processingWindows = processingWindows
+}
+
+// EnableDeliveryEstimates updates the estimated delivery of orders as the
+// processor moves them through their statuses; completed-order events carry
+// the final estimate.
+func (p *OrderProcessor) EnableDeliveryEstimates(delivery *DeliveryEstimator) {
+	p.delivery = delivery
+}
+
+// EnableAuditing publishes an audit record of every status change the
+// processor makes.
+func (p *OrderProcessor) EnableAuditing(auditor *Auditor) {
+	p.auditor = auditor
+}
+
+// EnableHeartbeatMonitor records the producer heartbeats the processor
+// handles in monitor. Heartbeats are skipped either way.
+func (p *OrderProcessor) EnableHeartbeatMonitor(monitor *HeartbeatMonitor) {
+	p.heartbeats = monitor
+}
+
+func (p *OrderProcessor) audit(ctx context.Context, order *models.Order, oldStatus models.OrderStatus) {
+	if p.auditor != nil {
+		p.auditor.Record(ctx, order, oldStatus)
+	}
+}
+
+// EnableAttemptTracking records every attempt at handling an order's events
+// in processing_attempts. Failed attempts are due again after backoff's delay
+// for the event's failures so far; the error returned for them carries that
+// time for the retry queue, see queue.RetryAfter.
+func (p *OrderProcessor) EnableAttemptTracking(attempts repository.ProcessingAttemptRepository, backoff models.BackoffPolicy) {
+	p.attempts = attempts
+	p.backoff = backoff
+}
+
+// refreshEstimatedDelivery re-estimates the delivery of order, which is in its
+// new status, with processing starting or having finished at from.
+func (p *OrderProcessor) refreshEstimatedDelivery(ctx context.Context, order *models.Order, from time.Time) {
+	if p.delivery == nil {
+		return
+	}
+	order.EstimatedDelivery = p.delivery.Estimate(order, from)
+	if err := p.orderRepo.UpdateEstimatedDelivery(ctx, order.ID, order.EstimatedDelivery); err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"order_id": order.ID,
+			"error":    err,
+		}).Warn("Failed to update estimated delivery")
+	}
+}
+
+func (p *OrderProcessor) sagaEnabled() bool {
+	return p.sagaRepo != nil && p.sagaCommands != nil && p.sagaConfig != nil
+}
+
 func (p *OrderProcessor) HandleEvent(ctx context.Context, event *models.Event) error {
+	if event.Type == models.HeartbeatEvent {
+		if p.heartbeats != nil {
+			p.heartbeats.Observe(event)
+		}
+		return nil
+	}
+
+	ctx = database.WithQueryTag(ctx, "event_id", event.ID.String())
+	ctx = database.WithQueryTag(ctx, "traceparent", requestctx.TraceFrom(ctx).Traceparent)
+	if queue.IsReplay(ctx) {
+		ctx = database.WithQueryTag(ctx, "replay", "true")
+	}
+
+	if p.processedEvents != nil {
+		processed, err := p.processedEvents.Exists(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		if processed {
+			p.logger.WithFields(logrus.Fields{
+				"event_id":   event.ID,
+				"event_type": event.Type,
+			}).Info("Event already processed, skipping duplicate")
+			return nil
+		}
+		ctx = repository.WithProcessedEvent(ctx, event.ID)
+	}
+
+	if p.attempts != nil {
+		return p.trackAttempt(ctx, event)
+	}
+	return p.dispatch(ctx, event)
+}
+
+func (p *OrderProcessor) dispatch(ctx context.Context, event *models.Event) error {
 	switch event.Type {
 	case models.OrderCreatedEvent:
 		return p.handleOrderCreated(ctx, event)
 	case models.OrderProcessingEvent:
 		return p.handleOrderProcessing(ctx, event)
+	case models.PaymentAuthorizeReplyEvent, models.InventoryReserveReplyEvent:
+		return p.handleSagaReply(ctx, event)
 	default:
 		p.logger.WithField("event_type", event.Type).Warn("Unhandled event type")
 		return nil
 	}
 }
 
+// retryableError is a failed attempt's error, due to be retried at retryAt.
+type retryableError struct {
+	err     error
+	retryAt time.Time
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+func (e *retryableError) RetryAt() time.Time {
+	return e.retryAt
+}
+
+// trackAttempt handles event as an attempt recorded against its order. Events
+// without an order, such as saga replies, are handled untracked, as are
+// events whose attempt could not be recorded.
+func (p *OrderProcessor) trackAttempt(ctx context.Context, event *models.Event) error {
+	var ref struct {
+		OrderID uuid.UUID `json:"order_id"`
+	}
+	if err := event.DecodeData(&ref); err != nil || ref.OrderID == uuid.Nil {
+		return p.dispatch(ctx, event)
+	}
+
+	attempt := models.NewProcessingAttempt(ref.OrderID, event)
+	if err := p.attempts.Start(ctx, attempt); err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"order_id": ref.OrderID,
+			"event_id": event.ID,
+			"error":    err,
+		}).Warn("Failed to record processing attempt")
+		return p.dispatch(ctx, event)
+	}
+
+	handleErr := p.dispatch(ctx, event)
+	if handleErr == nil {
+		attempt.Succeed()
+		p.finishAttempt(ctx, attempt)
+		return nil
+	}
+
+	failures, err := p.attempts.CountFailures(ctx, event.ID)
+	if err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"event_id": event.ID,
+			"error":    err,
+		}).Warn("Failed to count failed processing attempts")
+	}
+	retryAt := time.Now().Add(p.backoff.Delay(failures + 1))
+	attempt.Fail(handleErr, retryAt)
+	p.finishAttempt(ctx, attempt)
+	return &retryableError{err: handleErr, retryAt: retryAt}
+}
+
+func (p *OrderProcessor) finishAttempt(ctx context.Context, attempt *models.ProcessingAttempt) {
+	if err := p.attempts.Finish(ctx, attempt); err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"order_id": attempt.OrderID,
+			"attempt":  attempt.Attempt,
+			"error":    err,
+		}).Warn("Failed to record processing attempt outcome")
+	}
+}
+
 func (p *OrderProcessor) handleOrderCreated(ctx context.Context, event *models.Event) error {
 	p.logger.WithField("event_id", event.ID).Info("Processing order created event")
 
@@ -65,9 +271,25 @@ func (p *OrderProcessor) handleOrderCreated(ctx context.Context, event *models.E
 		return nil
 	}
 
+	if p.processingWindows != nil {
+		schedule, err := p.processingWindows.Schedule(ctx, order.TenantID)
+		if err != nil {
+			return fmt.Errorf("failed to get processing windows: %w", err)
+		}
+		if now := time.Now(); schedule != nil && !schedule.IsOpen(now) {
+			return p.scheduleOrder(ctx, order, schedule.NextOpen(now))
+		}
+	}
+
 	if err := p.orderRepo.UpdateStatus(ctx, order.ID, models.OrderStatusProcessing, order.Version); err != nil {
+		if isDuplicateEvent(err) {
+			return p.duplicateEventSkipped(order)
+		}
 		return fmt.Errorf("failed to update order status to processing: %w", err)
 	}
+	order.Status = models.OrderStatusProcessing
+	p.audit(ctx, order, models.OrderStatusPending)
+	p.refreshEstimatedDelivery(ctx, order, time.Now())
 
 	processingEvent := models.NewOrderProcessingEvent(order)
 	if err := p.producer.PublishEvent(ctx, processingEvent); err != nil {
@@ -78,6 +300,62 @@ func (p *OrderProcessor) handleOrderCreated(ctx context.Context, event *models.E
 	return nil
 }
 
+func (p *OrderProcessor) scheduleOrder(ctx context.Context, order *models.Order, until time.Time) error {
+	if err := p.orderRepo.Schedule(ctx, order.ID, order.Version, until); err != nil {
+		if isDuplicateEvent(err) {
+			return p.duplicateEventSkipped(order)
+		}
+		return fmt.Errorf("failed to schedule order: %w", err)
+	}
+
+	order.Status = models.OrderStatusScheduled
+	order.UpdatedAt = time.Now().UTC()
+	order.Version++
+	p.audit(ctx, order, models.OrderStatusPending)
+	p.refreshEstimatedDelivery(ctx, order, until)
+
+	reason := fmt.Sprintf("Outside processing window, scheduled for %s", until.Format(time.RFC3339))
+	if err := p.producer.PublishEvent(ctx, models.NewOrderStatusChangedEvent(order, models.OrderStatusPending, reason)); err != nil {
+		p.logger.WithError(err).Error("Failed to publish order scheduled event")
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"order_id":      order.ID,
+		"tenant_id":     order.TenantID,
+		"scheduled_for": until,
+	}).Info("Order scheduled for next processing window")
+	return nil
+}
+
+// ReleaseScheduledOrders returns scheduled orders whose window has opened to
+// pending; the pending-order sweep then republishes them for processing.
+func (p *OrderProcessor) ReleaseScheduledOrders(ctx context.Context) error {
+	if p.processingWindows == nil {
+		return nil
+	}
+
+	orders, err := p.orderRepo.ReleaseScheduled(ctx, time.Now(), 100)
+	if err != nil {
+		return fmt.Errorf("failed to release scheduled orders: %w", err)
+	}
+
+	for _, order := range orders {
+		p.audit(ctx, order, models.OrderStatusScheduled)
+		event := models.NewOrderStatusChangedEvent(order, models.OrderStatusScheduled, "Processing window opened")
+		if err := p.producer.PublishEvent(ctx, event); err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"order_id": order.ID,
+				"error":    err,
+			}).Error("Failed to publish order status changed event")
+		}
+	}
+
+	if len(orders) > 0 {
+		p.logger.WithField("orders_released", len(orders)).Info("Released scheduled orders")
+	}
+	return nil
+}
+
 func (p *OrderProcessor) handleOrderProcessing(ctx context.Context, event *models.Event) error {
 	p.logger.WithField("event_id", event.ID).Info("Processing order processing event")
 
@@ -104,43 +382,353 @@ func (p *OrderProcessor) handleOrderProcessing(ctx context.Context, event *model
 		return nil
 	}
 
+	if p.sagaEnabled() {
+		return p.startSaga(ctx, order)
+	}
+
 	time.Sleep(time.Duration(rand.Intn(3)+1) * time.Second)
 
+	if outcome, ok := models.DemoOutcome(order); ok {
+		if outcome == models.DemoOutcomeFail {
+			return p.failOrder(ctx, order, models.FailureCodePaymentDeclined, "Processing failed", "Scripted demo failure", nil)
+		}
+		return p.completeOrder(ctx, order, nil)
+	}
+
 	success := rand.Float32() < 0.9
 
 	if success {
-		if err := p.orderRepo.UpdateStatus(ctx, order.ID, models.OrderStatusCompleted, order.Version); err != nil {
-			return fmt.Errorf("failed to update order status to completed: %w", err)
+		return p.completeOrder(ctx, order, nil)
+	}
+	return p.failOrder(ctx, order, models.FailureCodeInternal, "Processing failed", "Random processing failure for simulation", nil)
+}
+
+// completeOrder and failOrder take the saga steps that already took effect so
+// that a failed status write can be recorded for compensation.
+func (p *OrderProcessor) completeOrder(ctx context.Context, order *models.Order, completedSteps []models.SagaStep) error {
+	applied, err := p.orderRepo.MarkCompleted(ctx, order.ID)
+	if isDuplicateEvent(err) {
+		return p.duplicateEventSkipped(order)
+	}
+	if err != nil {
+		p.recordCompensation(ctx, order, models.OrderStatusCompleted, completedSteps, err)
+		return fmt.Errorf("failed to update order status to completed: %w", err)
+	}
+	if !applied {
+		return p.terminalTransitionSkipped(ctx, order, models.OrderStatusCompleted, completedSteps)
+	}
+	order.Status = models.OrderStatusCompleted
+	p.audit(ctx, order, models.OrderStatusProcessing)
+	p.refreshEstimatedDelivery(ctx, order, time.Now())
+
+	completedEvent := models.NewOrderCompletedEvent(order)
+	if err := p.producer.PublishEvent(ctx, completedEvent); err != nil {
+		p.logger.WithError(err).Error("Failed to publish order completed event")
+	}
+
+	p.logger.WithField("order_id", order.ID).Info("Order completed successfully")
+	return nil
+}
+
+func (p *OrderProcessor) failOrder(ctx context.Context, order *models.Order, code models.FailureCode, reason, errMsg string, completedSteps []models.SagaStep) error {
+	order.FailureCode = code
+	order.FailureDetail = errMsg
+	applied, err := p.orderRepo.MarkFailed(ctx, order.ID, code, errMsg)
+	if isDuplicateEvent(err) {
+		return p.duplicateEventSkipped(order)
+	}
+	if err != nil {
+		p.recordCompensation(ctx, order, models.OrderStatusFailed, completedSteps, err)
+		return fmt.Errorf("failed to update order status to failed: %w", err)
+	}
+	if !applied {
+		return p.terminalTransitionSkipped(ctx, order, models.OrderStatusFailed, completedSteps)
+	}
+	order.Status = models.OrderStatusFailed
+	p.audit(ctx, order, models.OrderStatusProcessing)
+	p.refreshEstimatedDelivery(ctx, order, time.Now())
+
+	failedEvent := models.NewOrderFailedEvent(order, reason, errMsg)
+	if err := p.producer.PublishEvent(ctx, failedEvent); err != nil {
+		p.logger.WithError(err).Error("Failed to publish order failed event")
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"order_id":     order.ID,
+		"failure_code": code,
+		"reason":       reason,
+	}).Warn("Order processing failed")
+	return nil
+}
+
+// isDuplicateEvent reports a status write rejected because the event being
+// handled already caused one, see EnableDeduplication.
+func isDuplicateEvent(err error) bool {
+	return errors.Is(err, repository.ErrEventAlreadyProcessed)
+}
+
+func (p *OrderProcessor) duplicateEventSkipped(order *models.Order) error {
+	p.logger.WithField("order_id", order.ID).Info("Event already processed, skipping duplicate status update")
+	return nil
+}
+
+// terminalTransitionSkipped handles an order that was no longer processing
+// when it was about to be finished. A redelivery that finds the order already
+// in the target status is a no-op; any other status means saga side effects
+// may need to be undone.
+func (p *OrderProcessor) terminalTransitionSkipped(ctx context.Context, order *models.Order, target models.OrderStatus, completedSteps []models.SagaStep) error {
+	current, err := p.orderRepo.GetByID(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if current.Status == target {
+		p.logger.WithFields(logrus.Fields{
+			"order_id": order.ID,
+			"status":   current.Status,
+		}).Info("Order already finished, skipping duplicate terminal event")
+		return nil
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"order_id":      order.ID,
+		"status":        current.Status,
+		"target_status": target,
+	}).Warn("Order left processing before it could be finished")
+	p.recordCompensation(ctx, order, target, completedSteps, fmt.Errorf("order is %s, expected %s", current.Status, models.OrderStatusProcessing))
+	return nil
+}
+
+func (p *OrderProcessor) startSaga(ctx context.Context, order *models.Order) error {
+	saga := models.NewSaga(order.ID, models.SagaStepPaymentAuthorization, p.sagaReplyTimeout())
+	if err := p.sagaRepo.Create(ctx, saga); err != nil {
+		if !errors.Is(err, repository.ErrDuplicate) {
+			// Without its saga the timeout sweep would never find the order,
+			// so the event is redelivered instead.
+			return err
+		}
+		// A redelivered processing event finds the saga already started.
+		p.logger.WithField("order_id", order.ID).Warn("Saga already exists for order, skipping")
+		return nil
+	}
+
+	return p.sendSagaCommand(ctx, saga, order)
+}
+
+func (p *OrderProcessor) sendSagaCommand(ctx context.Context, saga *models.Saga, order *models.Order) error {
+	eventType := models.PaymentAuthorizeRequestEvent
+	topic, replyTopic := p.sagaConfig.PaymentRequestTopic, p.sagaConfig.PaymentReplyTopic
+	if saga.Step == models.SagaStepInventoryReservation {
+		eventType = models.InventoryReserveRequestEvent
+		topic, replyTopic = p.sagaConfig.InventoryRequestTopic, p.sagaConfig.InventoryReplyTopic
+	}
+
+	command := models.NewSagaCommandEvent(eventType, saga, order, replyTopic)
+	if err := p.sagaCommands.PublishEventToTopic(ctx, topic, command); err != nil {
+		// The timeout sweep fails the order if the command never goes out.
+		p.logger.WithError(err).Error("Failed to publish saga command")
+		return nil
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"order_id":       order.ID,
+		"saga_id":        saga.ID,
+		"step":           saga.Step,
+		"correlation_id": saga.CorrelationID,
+	}).Info("Saga command sent")
+	return nil
+}
+
+func (p *OrderProcessor) handleSagaReply(ctx context.Context, event *models.Event) error {
+	if !p.sagaEnabled() {
+		p.logger.WithField("event_type", event.Type).Warn("Saga reply received while saga coordination is disabled")
+		return nil
+	}
+
+	var reply models.SagaReplyData
+	if err := event.DecodeData(&reply); err != nil {
+		return fmt.Errorf("invalid saga reply data: %w", err)
+	}
+
+	saga, err := p.sagaRepo.GetByCorrelationID(ctx, reply.CorrelationID)
+	if err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"correlation_id": reply.CorrelationID,
+			"error":          err,
+		}).Warn("No saga for reply, skipping")
+		return nil
+	}
+
+	if saga.Status != models.SagaStatusAwaitingReply {
+		p.logger.WithFields(logrus.Fields{
+			"saga_id": saga.ID,
+			"status":  saga.Status,
+		}).Info("Saga is no longer awaiting a reply, skipping")
+		return nil
+	}
+
+	order, err := p.orderRepo.GetByID(ctx, saga.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	previousCorrelationID := saga.CorrelationID
+
+	if !reply.Success {
+		saga.Finish(models.SagaStatusFailed, reply.Reason)
+		if err := p.sagaRepo.Update(ctx, saga, previousCorrelationID); err != nil {
+			p.logger.WithError(err).Warn("Saga advanced concurrently, skipping reply")
+			return nil
 		}
+		return p.failOrder(ctx, order, models.FailureCodeForSagaStep(saga.Step), fmt.Sprintf("%s rejected", saga.Step), reply.Reason, models.CompletedSagaSteps(saga))
+	}
 
-		completedEvent := models.NewOrderCompletedEvent(order)
-		if err := p.producer.PublishEvent(ctx, completedEvent); err != nil {
-			p.logger.WithError(err).Error("Failed to publish order completed event")
+	if saga.Step == models.SagaStepPaymentAuthorization {
+		saga.AdvanceTo(models.SagaStepInventoryReservation, p.sagaReplyTimeout())
+		if err := p.sagaRepo.Update(ctx, saga, previousCorrelationID); err != nil {
+			p.logger.WithError(err).Warn("Saga advanced concurrently, skipping reply")
+			return nil
 		}
+		return p.sendSagaCommand(ctx, saga, order)
+	}
 
-		p.logger.WithField("order_id", order.ID).Info("Order completed successfully")
-	} else {
-		if err := p.orderRepo.UpdateStatus(ctx, order.ID, models.OrderStatusFailed, order.Version); err != nil {
-			return fmt.Errorf("failed to update order status to failed: %w", err)
+	saga.Finish(models.SagaStatusCompleted, "")
+	if err := p.sagaRepo.Update(ctx, saga, previousCorrelationID); err != nil {
+		p.logger.WithError(err).Warn("Saga advanced concurrently, skipping reply")
+		return nil
+	}
+	return p.completeOrder(ctx, order, models.CompletedSagaSteps(saga))
+}
+
+func (p *OrderProcessor) CheckSagaTimeouts(ctx context.Context) error {
+	if !p.sagaEnabled() {
+		return nil
+	}
+
+	sagas, err := p.sagaRepo.GetTimedOut(ctx, time.Now().UTC(), 100)
+	if err != nil {
+		return fmt.Errorf("failed to get timed out sagas: %w", err)
+	}
+
+	for _, saga := range sagas {
+		previousCorrelationID := saga.CorrelationID
+		errMsg := fmt.Sprintf("no reply for %s before %s", saga.Step, saga.Deadline.Format(time.RFC3339))
+		saga.Finish(models.SagaStatusTimedOut, errMsg)
+		if err := p.sagaRepo.Update(ctx, saga, previousCorrelationID); err != nil {
+			continue
+		}
+
+		order, err := p.orderRepo.GetByID(ctx, saga.OrderID)
+		if err != nil {
+			p.logger.WithError(err).Error("Failed to get order for timed out saga")
+			continue
+		}
+
+		if err := p.failOrder(ctx, order, models.FailureCodeTimeout, fmt.Sprintf("%s timed out", saga.Step), errMsg, models.CompletedSagaSteps(saga)); err != nil {
+			p.logger.WithError(err).Error("Failed to fail order for timed out saga")
+		}
+	}
+
+	return nil
+}
+
+func (p *OrderProcessor) recordCompensation(ctx context.Context, order *models.Order, target models.OrderStatus, completedSteps []models.SagaStep, cause error) {
+	if len(completedSteps) == 0 || p.compensationRepo == nil {
+		return
+	}
+
+	compensation := models.NewCompensation(order, target, completedSteps, cause)
+	if err := p.compensationRepo.Create(ctx, compensation); err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"order_id": order.ID,
+			"error":    err,
+		}).Error("Failed to record compensation")
+	}
+
+	if err := p.producer.PublishEvent(ctx, models.NewCompensationNeededEvent(order, compensation)); err != nil {
+		p.logger.WithError(err).Error("Failed to publish compensation needed event")
+	}
+}
+
+// ReconcileCompensations retries the status writes recorded by
+// recordCompensation. Orders that moved on in the meantime (for example were
+// canceled) cannot be repaired automatically and are flagged for review.
+func (p *OrderProcessor) ReconcileCompensations(ctx context.Context) error {
+	if p.compensationRepo == nil {
+		return nil
+	}
+
+	compensations, err := p.compensationRepo.GetPending(ctx, 100)
+	if err != nil {
+		return fmt.Errorf("failed to get pending compensations: %w", err)
+	}
+
+	for _, compensation := range compensations {
+		order, err := p.orderRepo.GetByID(ctx, compensation.OrderID)
+		if err != nil {
+			p.logger.WithError(err).Error("Failed to get order for compensation")
+			continue
 		}
 
-		failedEvent := models.NewOrderFailedEvent(order, "Processing failed", "Random processing failure for simulation")
-		if err := p.producer.PublishEvent(ctx, failedEvent); err != nil {
-			p.logger.WithError(err).Error("Failed to publish order failed event")
+		status := p.repairOrder(ctx, compensation, order)
+		if status == models.CompensationStatusPending {
+			continue
+		}
+		if err := p.compensationRepo.UpdateStatus(ctx, compensation.ID, status); err != nil {
+			p.logger.WithError(err).Error("Failed to update compensation status")
+			continue
 		}
 
-		p.logger.WithField("order_id", order.ID).Warn("Order processing failed")
+		p.logger.WithFields(logrus.Fields{
+			"compensation_id": compensation.ID,
+			"order_id":        order.ID,
+			"status":          status,
+		}).Info("Compensation reconciled")
 	}
 
 	return nil
 }
 
+func (p *OrderProcessor) repairOrder(ctx context.Context, compensation *models.Compensation, order *models.Order) models.CompensationStatus {
+	switch {
+	case order.Status == compensation.TargetStatus:
+		return models.CompensationStatusResolved
+	case order.Status != models.OrderStatusProcessing:
+		p.logger.WithFields(logrus.Fields{
+			"compensation_id": compensation.ID,
+			"order_id":        order.ID,
+			"status":          order.Status,
+			"target_status":   compensation.TargetStatus,
+		}).Warn("Order can no longer reach compensation target, flagging for manual review")
+		return models.CompensationStatusManualReview
+	}
+
+	var err error
+	if compensation.TargetStatus == models.OrderStatusCompleted {
+		err = p.completeOrder(ctx, order, nil)
+	} else {
+		err = p.failOrder(ctx, order, compensation.FailureCode, "Failed after compensation retry", compensation.FailureDetail, nil)
+	}
+	if err != nil {
+		p.logger.WithError(err).Warn("Compensation retry failed, will retry")
+		return models.CompensationStatusPending
+	}
+	return models.CompensationStatusResolved
+}
+
+func (p *OrderProcessor) sagaReplyTimeout() time.Duration {
+	return time.Duration(p.sagaConfig.ReplyTimeout) * time.Second
+}
+
 func (p *OrderProcessor) ProcessPendingOrders(ctx context.Context) error {
 	p.logger.Info("Processing pending orders")
 
-	orders, err := p.orderRepo.GetByStatus(ctx, models.OrderStatusPending, 100, 0)
+	// Claiming the dispatch lease keeps replicas, and the producer API that
+	// leases an order when it first publishes it, from publishing the same
+	// order concurrently.
+	orders, err := p.orderRepo.ClaimPendingForDispatch(ctx, 100)
 	if err != nil {
-		return fmt.Errorf("failed to get pending orders: %w", err)
+		return fmt.Errorf("failed to claim pending orders: %w", err)
 	}
 
 	for _, order := range orders {
@@ -156,7 +744,7 @@ func (p *OrderProcessor) ProcessPendingOrders(ctx context.Context) error {
 				}).Error("Failed to publish order created event for pending order")
 				continue
 			}
-			
+
 			p.logger.WithField("order_id", order.ID).Info("Republished event for pending order")
 		}
 	}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+)
+
+// OrderQueryService serves the read side of orders. It only holds an
+// OrderReader, so code wired with it cannot write orders; the status API
+// uses it against a read replica when one is configured.
+type OrderQueryService struct {
+	orderRepo repository.OrderReader
+	logger    *logrus.Entry
+}
+
+func NewOrderQueryService(orderRepo repository.OrderReader) *OrderQueryService {
+	return &OrderQueryService{
+		orderRepo: orderRepo,
+		logger:    logrus.WithField("component", "order_query_service"),
+	}
+}
+
+func (s *OrderQueryService) GetOrderByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, id, opts...)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"order_id": id,
+			"error":    err,
+		}).Error("Failed to get order")
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return order, nil
+}
+
+// GetAllowedTransitions returns the statuses the order may currently be
+// moved to, as enforced by status updates.
+func (s *OrderQueryService) GetAllowedTransitions(ctx context.Context, id uuid.UUID) (*models.OrderTransitions, error) {
+	order, err := s.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OrderTransitions{
+		OrderID: order.ID,
+		Status:  order.Status,
+		Allowed: order.AllowedTransitions(),
+		Version: order.Version,
+	}, nil
+}
+
+// GetOrdersByIDs returns the orders found in the order of ids, without
+// duplicates, and the IDs that were not found.
+func (s *OrderQueryService) GetOrdersByIDs(ctx context.Context, ids []uuid.UUID, opts ...models.LoadOption) ([]*models.Order, []uuid.UUID, error) {
+	unique := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	found, err := s.orderRepo.GetByIDs(ctx, unique, opts...)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get orders by IDs")
+		return nil, nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]*models.Order, len(found))
+	for _, order := range found {
+		byID[order.ID] = order
+	}
+
+	orders := make([]*models.Order, 0, len(found))
+	missing := make([]uuid.UUID, 0)
+	for _, id := range unique {
+		if order, ok := byID[id]; ok {
+			orders = append(orders, order)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	return orders, missing, nil
+}
+
+func (s *OrderQueryService) GetOrderByNumber(ctx context.Context, tenantID, orderNumber string, opts ...models.LoadOption) (*models.Order, error) {
+	order, err := s.orderRepo.GetByOrderNumber(ctx, tenantID, orderNumber, opts...)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"order_number": orderNumber,
+			"error":        err,
+		}).Error("Failed to get order by number")
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return order, nil
+}
+
+func (s *OrderQueryService) GetOrdersByExternalReference(ctx context.Context, tenantID, reference string, opts ...models.LoadOption) ([]*models.Order, error) {
+	orders, err := s.orderRepo.GetByExternalReference(ctx, tenantID, reference, opts...)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get orders by external reference")
+		return nil, fmt.Errorf("failed to get orders by external reference: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (s *OrderQueryService) GetOrdersByCustomerID(ctx context.Context, customerID uuid.UUID, limit, offset int, opts ...models.LoadOption) ([]*models.Order, error) {
+	orders, err := s.orderRepo.GetByCustomerID(ctx, customerID, limit, offset, opts...)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"customer_id": customerID,
+			"error":       err,
+		}).Error("Failed to get orders by customer ID")
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (s *OrderQueryService) CountOrdersByCustomerID(ctx context.Context, customerID uuid.UUID) (int64, error) {
+	count, err := s.orderRepo.CountByCustomerID(ctx, customerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	return count, nil
+}
+
+func (s *OrderQueryService) GetOrdersByStatus(ctx context.Context, status models.OrderStatus, channel models.OrderChannel, limit, offset int, opts ...models.LoadOption) ([]*models.Order, error) {
+	orders, err := s.orderRepo.GetByStatus(ctx, status, channel, limit, offset, opts...)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"status": status,
+			"error":  err,
+		}).Error("Failed to get orders by status")
+		return nil, fmt.Errorf("failed to get orders by status: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (s *OrderQueryService) CountOrdersByStatus(ctx context.Context, status models.OrderStatus, channel models.OrderChannel) (int64, error) {
+	count, err := s.orderRepo.CountByStatus(ctx, status, channel)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count orders by status: %w", err)
+	}
+
+	return count, nil
+}
+
+func (s *OrderQueryService) GetOrderStats(ctx context.Context) (*models.OrderStats, error) {
+	stats, err := s.orderRepo.GetOrderStats(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get order stats")
+		return nil, fmt.Errorf("failed to get order stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetOrderPipeline returns the stage counts and dwell times of the orders
+// created since since.
+func (s *OrderQueryService) GetOrderPipeline(ctx context.Context, since time.Time) (*models.OrderPipeline, error) {
+	pipeline, err := s.orderRepo.GetOrderPipeline(ctx, since)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get order pipeline")
+		return nil, fmt.Errorf("failed to get order pipeline: %w", err)
+	}
+
+	return pipeline, nil
+}
+
+func (s *OrderQueryService) GetOrderChanges(ctx context.Context, cursor models.ChangeCursor, safetyLag time.Duration, limit int) ([]*models.Order, models.ChangeCursor, bool, error) {
+	until := time.Now().UTC().Add(-safetyLag)
+
+	orders, err := s.orderRepo.GetChangedSince(ctx, cursor, until, limit+1)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get order changes")
+		return nil, cursor, false, fmt.Errorf("failed to get order changes: %w", err)
+	}
+
+	hasMore := len(orders) > limit
+	if hasMore {
+		orders = orders[:limit]
+	}
+
+	next := cursor
+	if len(orders) > 0 {
+		last := orders[len(orders)-1]
+		next = models.ChangeCursor{UpdatedAt: last.UpdatedAt, ID: last.ID}
+	}
+
+	return orders, next, hasMore, nil
+}
+
+// StreamOrdersByStatus calls fn for every order in status, oldest first, for
+// jobs that scan more orders than fit in memory. An error from fn stops the
+// stream and is returned unwrapped.
+func (s *OrderQueryService) StreamOrdersByStatus(ctx context.Context, status models.OrderStatus, fn func(*models.Order) error, opts ...models.LoadOption) error {
+	return s.orderRepo.StreamByStatus(ctx, status, fn, opts...)
+}
+
+// StreamOrderChanges calls fn for every order changed after cursor, up to
+// safetyLag ago, in change order.
+func (s *OrderQueryService) StreamOrderChanges(ctx context.Context, cursor models.ChangeCursor, safetyLag time.Duration, fn func(*models.Order) error) error {
+	return s.orderRepo.StreamChangedSince(ctx, cursor, time.Now().UTC().Add(-safetyLag), fn)
+}
\ No newline at end of file
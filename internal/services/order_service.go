@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,28 +11,113 @@ import (
 	"order-processing-microservice/internal/models"
 	"order-processing-microservice/internal/queue"
 	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/pkg/requestctx"
 )
 
+// The errors RecalculateTotals rejects a request with, matched with
+// errors.Is.
+var (
+	ErrTooManyOrders = errors.New("too many orders")
+	ErrInvalidPrice  = errors.New("invalid price")
+)
+
+// OrderService handles order writes; reads are served by the embedded
+// OrderQueryService over the same repository.
 type OrderService struct {
-	orderRepo repository.OrderRepository
-	producer  queue.Producer
-	logger    *logrus.Entry
+	*OrderQueryService
+	orderRepo    repository.OrderRepository
+	producer     queue.Producer
+	quotaService *QuotaService
+	usageMeter   *UsageMeter
+	contexts     *OrderContextService
+	delivery     *DeliveryEstimator
+	distribution *OrderDistributionRecorder
+	history      repository.EventStore
+	auditor      *Auditor
+	ids          models.IDGenerator
+	logger       *logrus.Entry
 }
 
 func NewOrderService(orderRepo repository.OrderRepository, producer queue.Producer) *OrderService {
 	return &OrderService{
-		orderRepo: orderRepo,
-		producer:  producer,
-		logger:    logrus.WithField("component", "order_service"),
+		OrderQueryService: NewOrderQueryService(orderRepo),
+		orderRepo:         orderRepo,
+		producer:          producer,
+		ids:               models.DefaultIDGenerator(),
+		logger:            logrus.WithField("component", "order_service"),
+	}
+}
+
+func (s *OrderService) EnableQuotas(quotaService *QuotaService) {
+	s.quotaService = quotaService
+}
+
+func (s *OrderService) EnableUsageMetering(usageMeter *UsageMeter) {
+	s.usageMeter = usageMeter
+}
+
+// EnableOrderContext records the request origin passed in
+// CreateOrderRequest.Context for each order created.
+func (s *OrderService) EnableOrderContext(contexts *OrderContextService) {
+	s.contexts = contexts
+}
+
+// EnableDeliveryEstimates sets the estimated delivery of new orders and
+// updates it on status changes.
+func (s *OrderService) EnableDeliveryEstimates(delivery *DeliveryEstimator) {
+	s.delivery = delivery
+}
+
+// EnableDistributionMetrics records the value and size of each order
+// created in recorder.
+func (s *OrderService) EnableDistributionMetrics(recorder *OrderDistributionRecorder) {
+	s.distribution = recorder
+}
+
+// EnableIdempotentStatusUpdates makes a status update that repeats the
+// order's last status change, with the same reason, succeed without a new
+// event instead of failing as an invalid transition, so clients can retry
+// updates safely. The last change is read from history.
+func (s *OrderService) EnableIdempotentStatusUpdates(history repository.EventStore) {
+	s.history = history
+}
+
+// EnableAuditing publishes an audit record of every order the service
+// creates or changes the status of.
+func (s *OrderService) EnableAuditing(auditor *Auditor) {
+	s.auditor = auditor
+}
+
+// SetIDGenerator sets the generator of order IDs.
+func (s *OrderService) SetIDGenerator(ids models.IDGenerator) {
+	s.ids = ids
+}
+
+func (s *OrderService) recordUsage(tenantID string, metric models.UsageMetric) {
+	if s.usageMeter != nil {
+		s.usageMeter.Record(tenantID, metric)
+	}
+}
+
+func (s *OrderService) audit(ctx context.Context, order *models.Order, oldStatus models.OrderStatus) {
+	if s.auditor != nil {
+		s.auditor.Record(ctx, order, oldStatus)
 	}
 }
 
 func (s *OrderService) CreateOrder(ctx context.Context, req *models.CreateOrderRequest) (*models.Order, error) {
 	order := &models.Order{
-		ID:         uuid.New(),
-		CustomerID: req.CustomerID,
-		Status:     models.OrderStatusPending,
-		Items:      make([]models.OrderItem, 0, len(req.Items)),
+		ID:                s.ids.NewID(),
+		TenantID:          req.TenantID,
+		ExternalReference: req.ExternalReference,
+		Channel:           req.Channel,
+		ShippingMethod:    req.ShippingMethod,
+		CustomerID:        req.CustomerID,
+		Status:            models.OrderStatusPending,
+		Items:             make([]models.OrderItem, 0, len(req.Items)),
+	}
+	if order.ShippingMethod == "" {
+		order.ShippingMethod = models.ShippingMethodStandard
 	}
 
 	for _, item := range req.Items {
@@ -44,53 +130,84 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *models.CreateOrderR
 	}
 
 	order.CalculateTotalAmount()
+	if s.delivery != nil {
+		order.EstimatedDelivery = s.delivery.Estimate(order, time.Now())
+	}
+
+	if s.quotaService != nil {
+		if err := s.quotaService.CheckCreate(ctx, order.TenantID); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.orderRepo.Create(ctx, order); err != nil {
 		s.logger.WithError(err).Error("Failed to create order")
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
+	s.recordUsage(order.TenantID, models.UsageMetricOrdersCreated)
+	s.audit(ctx, order, "")
+	if s.distribution != nil {
+		s.distribution.Observe(order)
+	}
+	if s.contexts != nil && req.Context != nil {
+		s.contexts.Record(ctx, order, req.Context)
+	}
 
 	event := models.NewOrderCreatedEvent(order)
 	if err := s.producer.PublishEvent(ctx, event); err != nil {
 		s.logger.WithError(err).Error("Failed to publish order created event")
+	} else {
+		s.recordUsage(order.TenantID, models.UsageMetricEventsPublished)
 	}
 
 	s.logger.WithField("order_id", order.ID).Info("Order created successfully")
 	return order, nil
 }
 
-func (s *OrderService) GetOrderByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
-	order, err := s.orderRepo.GetByID(ctx, id)
-	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"order_id": id,
-			"error":    err,
-		}).Error("Failed to get order")
-		return nil, fmt.Errorf("failed to get order: %w", err)
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, newStatus models.OrderStatus, reason string) error {
+	if newStatus == models.OrderStatusCanceled {
+		return s.CancelOrder(ctx, id, &models.CancelOrderRequest{
+			ReasonCode: models.CancelReasonAdmin,
+			Reason:     reason,
+			Actor:      "status-api",
+		})
 	}
 
-	return order, nil
+	return s.changeStatus(ctx, id, newStatus, func(order *models.Order, oldStatus models.OrderStatus) *models.Event {
+		return models.NewOrderStatusChangedEvent(order, oldStatus, reason)
+	})
 }
 
-func (s *OrderService) GetOrdersByCustomerID(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]*models.Order, error) {
-	orders, err := s.orderRepo.GetByCustomerID(ctx, customerID, limit, offset)
-	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"customer_id": customerID,
-			"error":       err,
-		}).Error("Failed to get orders by customer ID")
-		return nil, fmt.Errorf("failed to get orders: %w", err)
+func (s *OrderService) CancelOrder(ctx context.Context, id uuid.UUID, req *models.CancelOrderRequest) error {
+	if req.ReasonCode == "" {
+		req.ReasonCode = models.CancelReasonUserRequest
+	}
+	if !req.ReasonCode.IsValid() {
+		return fmt.Errorf("invalid cancel reason code: %s", req.ReasonCode)
+	}
+	if req.Actor != "" && requestctx.Actor(ctx) == "" {
+		ctx = requestctx.WithActor(ctx, req.Actor)
 	}
 
-	return orders, nil
+	return s.changeStatus(ctx, id, models.OrderStatusCanceled, func(order *models.Order, oldStatus models.OrderStatus) *models.Event {
+		return models.NewOrderCanceledEvent(order, oldStatus, req)
+	})
 }
 
-func (s *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, newStatus models.OrderStatus, reason string) error {
+func (s *OrderService) changeStatus(ctx context.Context, id uuid.UUID, newStatus models.OrderStatus, newEvent func(*models.Order, models.OrderStatus) *models.Event) error {
 	order, err := s.orderRepo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get order: %w", err)
 	}
 
+	if order.Status == newStatus && s.isRepeatedStatusChange(ctx, order, newEvent(order, order.Status)) {
+		s.logger.WithFields(logrus.Fields{
+			"order_id": id,
+			"status":   newStatus,
+		}).Info("Order already in requested status, skipping update")
+		return nil
+	}
+
 	if !order.IsValidStatusTransition(newStatus) {
 		return fmt.Errorf("invalid status transition from %s to %s", order.Status, newStatus)
 	}
@@ -103,10 +220,17 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, newS
 	order.Status = newStatus
 	order.UpdatedAt = time.Now().UTC()
 	order.Version++
+	s.audit(ctx, order, oldStatus)
+	s.refreshEstimatedDelivery(ctx, order)
 
-	event := models.NewOrderStatusChangedEvent(order, oldStatus, reason)
+	event := newEvent(order, oldStatus)
 	if err := s.producer.PublishEvent(ctx, event); err != nil {
-		s.logger.WithError(err).Error("Failed to publish order status changed event")
+		s.logger.WithFields(logrus.Fields{
+			"event_type": event.Type,
+			"error":      err,
+		}).Error("Failed to publish order status event")
+	} else {
+		s.recordUsage(order.TenantID, models.UsageMetricEventsPublished)
 	}
 
 	s.logger.WithFields(logrus.Fields{
@@ -118,47 +242,236 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, newS
 	return nil
 }
 
-func (s *OrderService) CancelOrder(ctx context.Context, id uuid.UUID, reason string) error {
-	return s.UpdateOrderStatus(ctx, id, models.OrderStatusCanceled, reason)
+func (s *OrderService) HoldOrders(ctx context.Context, filter models.OrderFilter, reason string) ([]*models.Order, error) {
+	return s.transitionOrders(ctx, models.OrderStatusPending, models.OrderStatusOnHold, filter, reason)
 }
 
-func (s *OrderService) GetOrdersByStatus(ctx context.Context, status models.OrderStatus, limit, offset int) ([]*models.Order, error) {
-	orders, err := s.orderRepo.GetByStatus(ctx, status, limit, offset)
+// ReleaseOrders returns held orders to pending; the consumer's pending-order
+// sweep then republishes them for processing.
+func (s *OrderService) ReleaseOrders(ctx context.Context, filter models.OrderFilter, reason string) ([]*models.Order, error) {
+	return s.transitionOrders(ctx, models.OrderStatusOnHold, models.OrderStatusPending, filter, reason)
+}
+
+func (s *OrderService) transitionOrders(ctx context.Context, from, to models.OrderStatus, filter models.OrderFilter, reason string) ([]*models.Order, error) {
+	orders, err := s.orderRepo.TransitionStatus(ctx, from, to, filter)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
-			"status": status,
-			"error":  err,
-		}).Error("Failed to get orders by status")
-		return nil, fmt.Errorf("failed to get orders by status: %w", err)
+			"from":  from,
+			"to":    to,
+			"error": err,
+		}).Error("Failed to transition orders")
+		return nil, fmt.Errorf("failed to transition orders: %w", err)
+	}
+
+	for _, order := range orders {
+		s.audit(ctx, order, from)
+		s.refreshEstimatedDelivery(ctx, order)
+		event := models.NewOrderStatusChangedEvent(order, from, reason)
+		if err := s.producer.PublishEvent(ctx, event); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"order_id": order.ID,
+				"error":    err,
+			}).Error("Failed to publish order status changed event")
+		} else {
+			s.recordUsage(order.TenantID, models.UsageMetricEventsPublished)
+		}
 	}
 
+	s.logger.WithFields(logrus.Fields{
+		"from":   from,
+		"to":     to,
+		"count":  len(orders),
+		"reason": reason,
+	}).Info("Orders transitioned successfully")
+
 	return orders, nil
 }
 
-func (s *OrderService) GetOrderStats(ctx context.Context) (map[string]int64, error) {
-	stats := make(map[string]int64)
+// CancelBatch cancels up to limit of the oldest orders matching req and
+// publishes an order.canceled event for each. It returns the canceled orders
+// and the number of events published.
+func (s *OrderService) CancelBatch(ctx context.Context, req *models.BulkCancelRequest, limit int) ([]*models.Order, int, error) {
+	orders, err := s.orderRepo.TransitionStatusBatch(ctx, req.Status, models.OrderStatusCanceled, req.Filter(), limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to cancel orders: %w", err)
+	}
+
+	cancel := &models.CancelOrderRequest{ReasonCode: req.ReasonCode, Reason: req.Reason, Actor: req.Actor}
+	if req.Actor != "" && requestctx.Actor(ctx) == "" {
+		ctx = requestctx.WithActor(ctx, req.Actor)
+	}
+	published := 0
+	for _, order := range orders {
+		s.audit(ctx, order, req.Status)
+		s.refreshEstimatedDelivery(ctx, order)
+		event := models.NewOrderCanceledEvent(order, req.Status, cancel)
+		if err := s.producer.PublishEvent(ctx, event); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"order_id": order.ID,
+				"error":    err,
+			}).Error("Failed to publish order canceled event")
+			continue
+		}
+		published++
+		s.recordUsage(order.TenantID, models.UsageMetricEventsPublished)
+	}
 
-	totalCount, err := s.orderRepo.Count(ctx)
+	return orders, published, nil
+}
+
+// RecalculateTotals recomputes the totals of the orders in req, repricing
+// the items of the products in req.Prices. Every order whose totals change is
+// saved at its current version, audited and announced with an order.adjusted
+// event; unless req.DryRun is set, in which case nothing is saved. Orders
+// that cannot be loaded or saved are reported in the result's Failed.
+func (s *OrderService) RecalculateTotals(ctx context.Context, req *models.RecalculateTotalsRequest) (*models.RecalculationResult, error) {
+	if len(req.OrderIDs) > models.MaxRecalculationOrders {
+		return nil, fmt.Errorf("%w, at most %d can be recalculated at once", ErrTooManyOrders, models.MaxRecalculationOrders)
+	}
+	for productID, price := range req.Prices {
+		if price < 0 {
+			return nil, fmt.Errorf("%w for product %s: %v", ErrInvalidPrice, productID, price)
+		}
+	}
+	if req.Actor != "" && requestctx.Actor(ctx) == "" {
+		ctx = requestctx.WithActor(ctx, req.Actor)
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.OrderIDs))
+	seen := make(map[uuid.UUID]bool, len(req.OrderIDs))
+	for _, id := range req.OrderIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	orders, err := s.orderRepo.GetByIDs(ctx, ids)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get total order count: %w", err)
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	byID := make(map[uuid.UUID]*models.Order, len(orders))
+	for _, order := range orders {
+		byID[order.ID] = order
 	}
-	stats["total"] = totalCount
 
-	statuses := []models.OrderStatus{
-		models.OrderStatusPending,
-		models.OrderStatusProcessing,
-		models.OrderStatusCompleted,
-		models.OrderStatusCanceled,
-		models.OrderStatusFailed,
+	result := &models.RecalculationResult{
+		DryRun:      req.DryRun,
+		Adjustments: []*models.OrderAdjustment{},
+		Unchanged:   []uuid.UUID{},
+		Failed:      make(map[uuid.UUID]string),
 	}
 
-	for _, status := range statuses {
-		count, err := s.orderRepo.CountByStatus(ctx, status)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get count for status %s: %w", status, err)
+	for _, id := range ids {
+		order, ok := byID[id]
+		if !ok {
+			result.Failed[id] = repository.ErrOrderNotFound.Error()
+			continue
+		}
+
+		adjustment := models.AdjustOrder(order, req.Prices)
+		if adjustment == nil {
+			result.Unchanged = append(result.Unchanged, id)
+			continue
+		}
+		adjustment.Reason = req.Reason
+		adjustment.Actor = requestctx.Actor(ctx)
+		if req.DryRun {
+			result.Adjustments = append(result.Adjustments, adjustment)
+			continue
+		}
+
+		if err := s.orderRepo.AdjustTotals(ctx, adjustment, order.Version); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"order_id": id,
+				"error":    err,
+			}).Error("Failed to adjust order totals")
+			result.Failed[id] = err.Error()
+			continue
+		}
+
+		adjustment.Apply(order)
+		result.Adjustments = append(result.Adjustments, adjustment)
+		if s.auditor != nil {
+			s.auditor.RecordAdjustment(ctx, order, adjustment)
+		}
+
+		event := models.NewOrderAdjustedEvent(order, adjustment)
+		if err := s.producer.PublishEvent(ctx, event); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"order_id": id,
+				"error":    err,
+			}).Error("Failed to publish order adjusted event")
+		} else {
+			s.recordUsage(order.TenantID, models.UsageMetricEventsPublished)
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"adjusted":  len(result.Adjustments),
+		"unchanged": len(result.Unchanged),
+		"failed":    len(result.Failed),
+		"dry_run":   req.DryRun,
+		"reason":    req.Reason,
+	}).Info("Order totals recalculated")
+
+	return result, nil
+}
+
+// isRepeatedStatusChange reports whether event repeats the last status change
+// recorded for order: the same type, new status and reason. Errors reading
+// the history are logged and treated as no repeat.
+func (s *OrderService) isRepeatedStatusChange(ctx context.Context, order *models.Order, event *models.Event) bool {
+	if s.history == nil {
+		return false
+	}
+
+	events, err := s.history.GetByOrderID(ctx, order.ID, time.Now().UTC())
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"order_id": order.ID,
+			"error":    err,
+		}).Error("Failed to load order history")
+		return false
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		last := events[i]
+		switch last.Type {
+		case models.OrderStatusChangedEvent, models.OrderCanceledEvent:
+			return last.Type == event.Type && sameStatusChange(last, event)
+		case models.OrderCreatedEvent, models.OrderProcessingEvent, models.OrderCompletedEvent, models.OrderFailedEvent:
+			return false
 		}
-		stats[string(status)] = count
 	}
+	return false
+}
 
-	return stats, nil
+// sameStatusChange compares the new status and reason of two
+// order.status.changed or order.canceled events.
+func sameStatusChange(a, b *models.Event) bool {
+	type statusChange struct {
+		NewStatus  models.OrderStatus      `json:"new_status"`
+		ReasonCode models.CancelReasonCode `json:"reason_code"`
+		Reason     string                  `json:"reason"`
+	}
+	var changeA, changeB statusChange
+	if a.DecodeData(&changeA) != nil || b.DecodeData(&changeB) != nil {
+		return false
+	}
+	return changeA == changeB
+}
+
+// refreshEstimatedDelivery re-estimates the delivery of an order whose status
+// changed. A failed write leaves the previous estimate, so it is only logged.
+func (s *OrderService) refreshEstimatedDelivery(ctx context.Context, order *models.Order) {
+	if s.delivery == nil {
+		return
+	}
+	order.EstimatedDelivery = s.delivery.Estimate(order, time.Now())
+	if err := s.orderRepo.UpdateEstimatedDelivery(ctx, order.ID, order.EstimatedDelivery); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"order_id": order.ID,
+			"error":    err,
+		}).Warn("Failed to update estimated delivery")
+	}
 }
\ No newline at end of file
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+)
+
+// ErrInvalidProcessingWindows rejects windows whose time zone or rules do not
+// parse.
+var ErrInvalidProcessingWindows = errors.New("invalid processing windows")
+
+// ProcessingWindowService manages the per-tenant windows the consumer
+// processes orders in. Tenants without windows are processed at any time.
+type ProcessingWindowService struct {
+	repo   repository.ProcessingWindowRepository
+	logger *logrus.Entry
+}
+
+func NewProcessingWindowService(repo repository.ProcessingWindowRepository) *ProcessingWindowService {
+	return &ProcessingWindowService{
+		repo:   repo,
+		logger: logrus.WithField("component", "processing_window_service"),
+	}
+}
+
+func (s *ProcessingWindowService) GetWindows(ctx context.Context, tenantID string) (*models.TenantProcessingWindows, error) {
+	return s.repo.Get(ctx, tenantID)
+}
+
+// UpdateWindows replaces the tenant's windows after validating the rules.
+func (s *ProcessingWindowService) UpdateWindows(ctx context.Context, tenantID string, req *models.UpdateProcessingWindowsRequest) (*models.TenantProcessingWindows, error) {
+	timeZone := req.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	if _, err := models.ParseProcessingSchedule(timeZone, req.Rules); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProcessingWindows, err)
+	}
+
+	windows := &models.TenantProcessingWindows{
+		TenantID: tenantID,
+		TimeZone: timeZone,
+		Rules:    req.Rules,
+	}
+	if err := s.repo.Upsert(ctx, windows); err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+func (s *ProcessingWindowService) DeleteWindows(ctx context.Context, tenantID string) error {
+	return s.repo.Delete(ctx, tenantID)
+}
+
+// Schedule returns the parsed windows of the tenant, or nil when its orders
+// may be processed at any time. Rules that no longer parse, e.g. after a time
+// zone was removed from the host, are ignored rather than blocking orders.
+func (s *ProcessingWindowService) Schedule(ctx context.Context, tenantID string) (*models.ProcessingSchedule, error) {
+	if tenantID == "" {
+		return nil, nil
+	}
+
+	windows, err := s.repo.Get(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, repository.ErrProcessingWindowsNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	schedule, err := models.ParseProcessingSchedule(windows.TimeZone, windows.Rules)
+	if err != nil {
+		s.logger.WithError(err).WithField("tenant_id", tenantID).Error("Ignoring invalid processing windows")
+		return nil, nil
+	}
+	return schedule, nil
+}
\ No newline at end of file
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+)
+
+type ProjectionBuilder struct {
+	repo   repository.ProjectionRepository
+	logger *logrus.Entry
+}
+
+func NewProjectionBuilder(repo repository.ProjectionRepository) *ProjectionBuilder {
+	return &ProjectionBuilder{
+		repo:   repo,
+		logger: logrus.WithField("component", "projection_builder"),
+	}
+}
+
+func (b *ProjectionBuilder) Reset(ctx context.Context) error {
+	return b.repo.Truncate(ctx)
+}
+
+func (b *ProjectionBuilder) HandleEvent(ctx context.Context, event *models.Event) error {
+	switch event.Type {
+	case models.OrderCreatedEvent:
+		return b.applyCreated(ctx, event)
+	case models.OrderStatusChangedEvent:
+		var data models.OrderStatusChangedEventData
+		if err := event.DecodeData(&data); err != nil {
+			return fmt.Errorf("failed to decode event data: %w", err)
+		}
+		return b.applyStatus(ctx, data.OrderID, data.NewStatus, data.UpdatedAt)
+	case models.OrderProcessingEvent:
+		var data models.OrderProcessingEventData
+		if err := event.DecodeData(&data); err != nil {
+			return fmt.Errorf("failed to decode event data: %w", err)
+		}
+		return b.applyStatus(ctx, data.OrderID, models.OrderStatusProcessing, data.StartedAt)
+	case models.OrderCompletedEvent:
+		var data models.OrderCompletedEventData
+		if err := event.DecodeData(&data); err != nil {
+			return fmt.Errorf("failed to decode event data: %w", err)
+		}
+		return b.applyStatus(ctx, data.OrderID, models.OrderStatusCompleted, data.CompletedAt)
+	case models.OrderFailedEvent:
+		var data models.OrderFailedEventData
+		if err := event.DecodeData(&data); err != nil {
+			return fmt.Errorf("failed to decode event data: %w", err)
+		}
+		return b.applyStatus(ctx, data.OrderID, models.OrderStatusFailed, data.FailedAt)
+	case models.OrderCanceledEvent:
+		var data models.OrderCanceledEventData
+		if err := event.DecodeData(&data); err != nil {
+			return fmt.Errorf("failed to decode event data: %w", err)
+		}
+		return b.applyStatus(ctx, data.OrderID, models.OrderStatusCanceled, data.CanceledAt)
+	case models.OrderAdjustedEvent:
+		return b.applyAdjusted(ctx, event)
+	default:
+		b.logger.WithField("event_type", event.Type).Debug("Skipping event not relevant to projection")
+		return nil
+	}
+}
+
+func (b *ProjectionBuilder) applyCreated(ctx context.Context, event *models.Event) error {
+	var data models.OrderCreatedEventData
+	if err := event.DecodeData(&data); err != nil {
+		return fmt.Errorf("failed to decode event data: %w", err)
+	}
+
+	order := &models.Order{
+		ID:                data.OrderID,
+		TenantID:          data.TenantID,
+		OrderNumber:       data.OrderNumber,
+		ExternalReference: data.ExternalReference,
+		Channel:           data.Channel,
+		CustomerID:        data.CustomerID,
+		Status:            models.OrderStatusPending,
+		Items:             data.Items,
+		TotalAmount:       data.TotalAmount,
+		CreatedAt:         data.CreatedAt,
+		UpdatedAt:         data.CreatedAt,
+		Version:           1,
+	}
+
+	if err := b.repo.UpsertOrder(ctx, order); err != nil {
+		return fmt.Errorf("failed to project order created event: %w", err)
+	}
+	return nil
+}
+
+func (b *ProjectionBuilder) applyStatus(ctx context.Context, orderID uuid.UUID, status models.OrderStatus, at time.Time) error {
+	if err := b.repo.ApplyStatus(ctx, orderID, status, at); err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			b.logger.WithFields(logrus.Fields{
+				"order_id": orderID,
+				"status":   status,
+			}).Warn("Status event for unknown order, skipping")
+			return nil
+		}
+		return fmt.Errorf("failed to project status change: %w", err)
+	}
+	return nil
+}
+
+func (b *ProjectionBuilder) applyAdjusted(ctx context.Context, event *models.Event) error {
+	var adjustment models.OrderAdjustment
+	if err := event.DecodeData(&adjustment); err != nil {
+		return fmt.Errorf("failed to decode event data: %w", err)
+	}
+
+	if err := b.repo.ApplyAdjustment(ctx, &adjustment); err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			b.logger.WithField("order_id", adjustment.OrderID).Warn("Adjusted event for unknown order, skipping")
+			return nil
+		}
+		return fmt.Errorf("failed to project order adjustment: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/pkg/config"
+)
+
+// QuotaService enforces per-tenant order quotas. The check and the insert
+// are not atomic, so concurrent creates can overshoot a quota slightly.
+type QuotaService struct {
+	quotaRepo repository.QuotaRepository
+	defaults  *config.QuotaConfig
+	logger    *logrus.Entry
+}
+
+func NewQuotaService(quotaRepo repository.QuotaRepository, defaults *config.QuotaConfig) *QuotaService {
+	return &QuotaService{
+		quotaRepo: quotaRepo,
+		defaults:  defaults,
+		logger:    logrus.WithField("component", "quota_service"),
+	}
+}
+
+// GetQuota returns the tenant's quota, or the configured defaults when the
+// tenant has none of its own.
+func (s *QuotaService) GetQuota(ctx context.Context, tenantID string) (*models.TenantQuota, error) {
+	quota, err := s.quotaRepo.Get(ctx, tenantID)
+	if err == nil {
+		return quota, nil
+	}
+	if !errors.Is(err, repository.ErrTenantQuotaNotFound) {
+		return nil, err
+	}
+
+	return &models.TenantQuota{
+		TenantID:        tenantID,
+		MaxActiveOrders: s.defaults.MaxActiveOrders,
+		MaxOrdersPerDay: s.defaults.MaxOrdersPerDay,
+		Default:         true,
+	}, nil
+}
+
+func (s *QuotaService) GetQuotaStatus(ctx context.Context, tenantID string) (*models.TenantQuotaResponse, error) {
+	quota, err := s.GetQuota(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.quotaRepo.GetUsage(ctx, tenantID, models.QuotaDayStart(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TenantQuotaResponse{Quota: quota, Usage: usage}, nil
+}
+
+// UpdateQuota sets the limits given in req, keeping the others unchanged.
+func (s *QuotaService) UpdateQuota(ctx context.Context, tenantID string, req *models.UpdateTenantQuotaRequest) (*models.TenantQuota, error) {
+	quota, err := s.GetQuota(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MaxActiveOrders != nil {
+		quota.MaxActiveOrders = *req.MaxActiveOrders
+	}
+	if req.MaxOrdersPerDay != nil {
+		quota.MaxOrdersPerDay = *req.MaxOrdersPerDay
+	}
+
+	if err := s.quotaRepo.Upsert(ctx, quota); err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+// CheckCreate returns an error when the tenant may not create another order.
+func (s *QuotaService) CheckCreate(ctx context.Context, tenantID string) error {
+	quota, err := s.GetQuota(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to check tenant quota: %w", err)
+	}
+	if quota.Unlimited() {
+		return nil
+	}
+
+	usage, err := s.quotaRepo.GetUsage(ctx, tenantID, models.QuotaDayStart(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to check tenant quota: %w", err)
+	}
+
+	if err := quota.Check(usage); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"tenant_id":     tenantID,
+			"active_orders": usage.ActiveOrders,
+			"orders_today":  usage.OrdersToday,
+		}).Warn("Order rejected by tenant quota")
+		return err
+	}
+	return nil
+}
\ No newline at end of file
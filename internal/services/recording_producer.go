@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+)
+
+// RecordingProducer numbers the events of each order and records them in the
+// event store once they are published. An event published in a Kafka
+// transaction is recorded when the transaction commits, so the store never
+// holds an event that a failed publish or an aborted transaction undid.
+type RecordingProducer struct {
+	producer queue.Producer
+	store    repository.EventStore
+	logger   *logrus.Entry
+}
+
+func NewRecordingProducer(producer queue.Producer, store repository.EventStore) *RecordingProducer {
+	return &RecordingProducer{
+		producer: producer,
+		store:    store,
+		logger:   logrus.WithField("component", "recording_producer"),
+	}
+}
+
+func (p *RecordingProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	var ref struct {
+		OrderID uuid.UUID `json:"order_id"`
+	}
+	if err := event.DecodeData(&ref); err != nil || ref.OrderID == uuid.Nil {
+		return p.producer.PublishEvent(ctx, event)
+	}
+
+	// An event published again, e.g. from the fallback store, keeps its
+	// number.
+	if event.Sequence == 0 {
+		sequence, err := p.store.NextSequence(ctx, ref.OrderID)
+		if err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"event_id":   event.ID,
+				"event_type": event.Type,
+				"error":      err,
+			}).Error("Failed to number event, publishing it without a sequence")
+		}
+		event.Sequence = sequence
+	}
+
+	if err := p.producer.PublishEvent(ctx, event); err != nil {
+		return err
+	}
+
+	queue.AfterCommit(ctx, func() {
+		if err := p.store.Append(ctx, ref.OrderID, event); err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"event_id":   event.ID,
+				"event_type": event.Type,
+				"error":      err,
+			}).Error("Failed to record event in event store")
+		}
+	})
+	return nil
+}
+
+func (p *RecordingProducer) Close() error {
+	return p.producer.Close()
+}
\ No newline at end of file
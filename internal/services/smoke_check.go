@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+)
+
+const SmokeTenantID = "smoke"
+
+// EventReplayer replays the events published to the order topic since a
+// point in time.
+type EventReplayer interface {
+	ReplaySince(ctx context.Context, since time.Time, handler queue.EventHandler) (int, error)
+}
+
+type SmokeResult struct {
+	OrderID  uuid.UUID          `json:"order_id"`
+	Status   models.OrderStatus `json:"status"`
+	Events   []models.EventType `json:"events"`
+	Duration time.Duration      `json:"duration"`
+}
+
+// SmokeCheck pushes a synthetic order through the pipeline: it creates the
+// order, waits for the consumer to complete it, checks that its lifecycle
+// events reached the topic and then deletes it.
+type SmokeCheck struct {
+	orderService *OrderService
+	orderRepo    repository.OrderRepository
+	replayer     EventReplayer
+	pollInterval time.Duration
+	logger       *logrus.Entry
+}
+
+// NewSmokeCheck creates a smoke check. A nil replayer skips the topic check,
+// for transports other than Kafka.
+func NewSmokeCheck(orderService *OrderService, orderRepo repository.OrderRepository, replayer EventReplayer, pollInterval time.Duration) *SmokeCheck {
+	return &SmokeCheck{
+		orderService: orderService,
+		orderRepo:    orderRepo,
+		replayer:     replayer,
+		pollInterval: pollInterval,
+		logger:       logrus.WithField("component", "smoke_check"),
+	}
+}
+
+// Run executes the check; ctx bounds how long it waits for the order and its
+// events.
+func (s *SmokeCheck) Run(ctx context.Context) (*SmokeResult, error) {
+	start := time.Now()
+
+	order, err := s.orderService.CreateOrder(ctx, &models.CreateOrderRequest{
+		TenantID:          SmokeTenantID,
+		ExternalReference: "smoke-" + uuid.New().String(),
+		CustomerID:        uuid.New(),
+		Items: []models.CreateOrderItemRequest{
+			{ProductID: uuid.New(), Quantity: 1, Price: 1},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create smoke order: %w", err)
+	}
+	defer s.cleanup(order.ID)
+
+	result := &SmokeResult{OrderID: order.ID, Status: order.Status}
+	s.logger.WithField("order_id", order.ID).Info("Smoke order created")
+
+	status, err := s.waitForCompletion(ctx, order.ID)
+	result.Status = status
+	if err != nil {
+		return result, err
+	}
+
+	if s.replayer != nil {
+		events, err := s.waitForEvents(ctx, order.ID, start)
+		result.Events = events
+		if err != nil {
+			return result, err
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+func (s *SmokeCheck) waitForCompletion(ctx context.Context, id uuid.UUID) (models.OrderStatus, error) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	var status models.OrderStatus
+	for {
+		order, err := s.orderRepo.GetByID(ctx, id)
+		if err != nil && ctx.Err() == nil {
+			return status, fmt.Errorf("failed to get smoke order: %w", err)
+		}
+		if order != nil {
+			status = order.Status
+		}
+
+		switch status {
+		case models.OrderStatusCompleted:
+			return status, nil
+		case models.OrderStatusFailed, models.OrderStatusCanceled:
+			return status, fmt.Errorf("smoke order ended in status %s", status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, fmt.Errorf("smoke order did not complete, last status %s: %w", status, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForEvents replays the topic until the created, processing and
+// completed events of the order are all on it; the completed event may land
+// shortly after the order row is updated.
+func (s *SmokeCheck) waitForEvents(ctx context.Context, id uuid.UUID, since time.Time) ([]models.EventType, error) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		collector := &orderEventCollector{orderID: id.String()}
+		if _, err := s.replayer.ReplaySince(ctx, since, collector); err != nil && ctx.Err() == nil {
+			return collector.types, fmt.Errorf("failed to read order topic: %w", err)
+		}
+
+		missing := collector.missing(models.OrderCreatedEvent, models.OrderProcessingEvent, models.OrderCompletedEvent)
+		if len(missing) == 0 {
+			return collector.types, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return collector.types, fmt.Errorf("smoke order events missing from topic: %v", missing)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *SmokeCheck) cleanup(id uuid.UUID) {
+	// The run context may already be done by the time cleanup runs.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.orderRepo.Delete(ctx, id); err != nil {
+		s.logger.WithError(err).WithField("order_id", id).Warn("Failed to delete smoke order")
+	}
+}
+
+type orderEventCollector struct {
+	orderID string
+	types   []models.EventType
+}
+
+func (c *orderEventCollector) HandleEvent(ctx context.Context, event *models.Event) error {
+	var data map[string]interface{}
+	if err := event.DecodeData(&data); err != nil {
+		return nil
+	}
+	if id, _ := data["order_id"].(string); id == c.orderID {
+		c.types = append(c.types, event.Type)
+	}
+	return nil
+}
+
+func (c *orderEventCollector) missing(types ...models.EventType) []models.EventType {
+	seen := make(map[models.EventType]bool, len(c.types))
+	for _, t := range c.types {
+		seen[t] = true
+	}
+
+	var missing []models.EventType
+	for _, t := range types {
+		if !seen[t] {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
\ No newline at end of file
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+// ThrottleStats counts the events of one type seen by a ThrottlingProducer.
+// Delayed events were held back and published when their interval elapsed;
+// Dropped events were superseded by a later event of the same order before
+// that.
+type ThrottleStats struct {
+	Published uint64 `json:"published"`
+	Delayed   uint64 `json:"delayed"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+type throttleKey struct {
+	orderID   uuid.UUID
+	eventType models.EventType
+}
+
+// throttleWindow is the interval after an order's last published event of a
+// type. Only the latest event received during the window is kept.
+type throttleWindow struct {
+	pending *models.Event
+	timer   *time.Timer
+}
+
+// ThrottlingProducer publishes at most one event of each throttled type per
+// order and interval. An event received during the interval is held back and
+// published when it elapses, replacing any event already held, so a client
+// flipping an order's status repeatedly yields one event per interval carrying
+// the latest state. Events of other types, and events without an order, pass
+// through.
+type ThrottlingProducer struct {
+	producer  queue.Producer
+	intervals map[models.EventType]time.Duration
+
+	mu      sync.Mutex
+	windows map[throttleKey]*throttleWindow
+	stats   map[models.EventType]*ThrottleStats
+	logger  *logrus.Entry
+}
+
+func NewThrottlingProducer(producer queue.Producer, cfg *config.EventThrottleConfig) (*ThrottlingProducer, error) {
+	intervals := make(map[models.EventType]time.Duration)
+	for _, entry := range cfg.Intervals {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eventType, raw, ok := strings.Cut(entry, "=")
+		if !ok || eventType == "" {
+			return nil, fmt.Errorf("invalid event throttle interval %q, expected event_type=duration", entry)
+		}
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid event throttle interval %q, expected event_type=duration", entry)
+		}
+		intervals[models.EventType(eventType)] = interval
+	}
+
+	return &ThrottlingProducer{
+		producer:  producer,
+		intervals: intervals,
+		windows:   make(map[throttleKey]*throttleWindow),
+		stats:     make(map[models.EventType]*ThrottleStats),
+		logger:    logrus.WithField("component", "throttling_producer"),
+	}, nil
+}
+
+func (p *ThrottlingProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	interval, ok := p.intervals[event.Type]
+	if !ok {
+		return p.producer.PublishEvent(ctx, event)
+	}
+
+	var ref struct {
+		OrderID uuid.UUID `json:"order_id"`
+	}
+	if err := event.DecodeData(&ref); err != nil || ref.OrderID == uuid.Nil {
+		return p.producer.PublishEvent(ctx, event)
+	}
+	key := throttleKey{orderID: ref.OrderID, eventType: event.Type}
+
+	p.mu.Lock()
+	stats := p.statsFor(event.Type)
+	if window, ok := p.windows[key]; ok {
+		if window.pending != nil {
+			stats.Dropped++
+		} else {
+			stats.Delayed++
+		}
+		window.pending = event
+		p.mu.Unlock()
+		return nil
+	}
+
+	window := &throttleWindow{}
+	window.timer = time.AfterFunc(interval, func() { p.release(key, interval) })
+	p.windows[key] = window
+	stats.Published++
+	p.mu.Unlock()
+
+	return p.producer.PublishEvent(ctx, event)
+}
+
+// release ends the window of key, publishing the event held during it, which
+// opens a new window.
+func (p *ThrottlingProducer) release(key throttleKey, interval time.Duration) {
+	p.mu.Lock()
+	window, ok := p.windows[key]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+	event := window.pending
+	if event == nil {
+		delete(p.windows, key)
+		p.mu.Unlock()
+		return
+	}
+	window.pending = nil
+	window.timer.Reset(interval)
+	p.mu.Unlock()
+
+	p.publishHeld(event)
+}
+
+func (p *ThrottlingProducer) publishHeld(event *models.Event) {
+	if err := p.producer.PublishEvent(context.Background(), event); err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"error":      err,
+		}).Error("Failed to publish throttled event")
+	}
+}
+
+func (p *ThrottlingProducer) statsFor(eventType models.EventType) *ThrottleStats {
+	stats, ok := p.stats[eventType]
+	if !ok {
+		stats = &ThrottleStats{}
+		p.stats[eventType] = stats
+	}
+	return stats
+}
+
+// ThrottleStats returns the counts of each throttled event type since start.
+func (p *ThrottlingProducer) ThrottleStats() map[models.EventType]ThrottleStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make(map[models.EventType]ThrottleStats, len(p.stats))
+	for eventType, s := range p.stats {
+		stats[eventType] = *s
+	}
+	return stats
+}
+
+// Flush publishes the events still held back, without waiting for their
+// intervals.
+func (p *ThrottlingProducer) Flush() {
+	p.mu.Lock()
+	var held []*models.Event
+	for key, window := range p.windows {
+		window.timer.Stop()
+		if window.pending != nil {
+			held = append(held, window.pending)
+		}
+		delete(p.windows, key)
+	}
+	p.mu.Unlock()
+
+	for _, event := range held {
+		p.publishHeld(event)
+	}
+}
+
+func (p *ThrottlingProducer) Close() error {
+	p.Flush()
+	return p.producer.Close()
+}
\ No newline at end of file
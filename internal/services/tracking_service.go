@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/pkg/config"
+)
+
+// The errors Track returns for tokens it does not accept, matched with
+// errors.Is.
+var (
+	ErrInvalidTrackingToken = errors.New("invalid tracking token")
+	ErrTrackingTokenExpired = errors.New("tracking token expired")
+)
+
+// TrackingService issues and resolves order tracking tokens. A token is the
+// order ID and expiry signed with TrackingConfig.SigningKey, so nothing is
+// stored and every token of an order stays valid until it expires; rotating
+// the key revokes all of them.
+type TrackingService struct {
+	orders     *OrderQueryService
+	signingKey []byte
+	ttl        time.Duration
+	baseURL    string
+	showItems  bool
+	showTotal  bool
+}
+
+func NewTrackingService(orders *OrderQueryService, cfg *config.TrackingConfig) *TrackingService {
+	return &TrackingService{
+		orders:     orders,
+		signingKey: []byte(cfg.SigningKey),
+		ttl:        time.Duration(cfg.TTL) * time.Hour,
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		showItems:  cfg.ShowItems,
+		showTotal:  cfg.ShowTotal,
+	}
+}
+
+// IssueToken creates a tracking token for an existing order.
+func (s *TrackingService) IssueToken(ctx context.Context, orderID uuid.UUID) (*models.TrackingToken, error) {
+	if _, err := s.orders.GetOrderByID(ctx, orderID, models.WithoutItems()); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.ttl).UTC().Truncate(time.Second)
+	payload := make([]byte, 24)
+	copy(payload, orderID[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(expiresAt.Unix()))
+
+	token := base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+	return &models.TrackingToken{
+		Token:     token,
+		URL:       s.baseURL + "/track/" + token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Track returns the public view of the order a token was issued for.
+func (s *TrackingService) Track(ctx context.Context, token string) (*models.OrderTrackingView, error) {
+	orderID, err := s.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []models.LoadOption
+	if !s.showItems {
+		opts = append(opts, models.WithoutItems())
+	}
+	order, err := s.orders.GetOrderByID(ctx, orderID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &models.OrderTrackingView{
+		OrderNumber: order.OrderNumber,
+		Status:      order.Status,
+		CreatedAt:   order.CreatedAt,
+		UpdatedAt:   order.UpdatedAt,
+	}
+	if s.showItems {
+		for _, item := range order.Items {
+			view.Items = append(view.Items, models.TrackingItem{ProductID: item.ProductID, Quantity: item.Quantity})
+		}
+	}
+	if s.showTotal {
+		total := order.TotalAmount
+		view.TotalAmount = &total
+	}
+	return view, nil
+}
+
+func (s *TrackingService) parseToken(token string) (uuid.UUID, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != 24 {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(payload)) {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+
+	if time.Now().Unix() > int64(binary.BigEndian.Uint64(payload[16:])) {
+		return uuid.Nil, ErrTrackingTokenExpired
+	}
+
+	orderID, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+	return orderID, nil
+}
+
+func (s *TrackingService) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
\ No newline at end of file
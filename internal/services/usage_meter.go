@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+)
+
+type usageKey struct {
+	tenantID string
+	hour     time.Time
+	metric   models.UsageMetric
+}
+
+// UsageMeter counts per-tenant usage in memory, aggregated by hour, and
+// periodically adds the counts to the usage table. Counts not yet flushed are
+// lost if the process dies.
+type UsageMeter struct {
+	usageRepo repository.UsageRepository
+	mu        sync.Mutex
+	counts    map[usageKey]int64
+	logger    *logrus.Entry
+}
+
+func NewUsageMeter(usageRepo repository.UsageRepository) *UsageMeter {
+	return &UsageMeter{
+		usageRepo: usageRepo,
+		counts:    make(map[usageKey]int64),
+		logger:    logrus.WithField("component", "usage_meter"),
+	}
+}
+
+func (m *UsageMeter) Record(tenantID string, metric models.UsageMetric) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenantID
+	}
+	key := usageKey{tenantID: tenantID, hour: models.UsageHour(time.Now()), metric: metric}
+
+	m.mu.Lock()
+	m.counts[key]++
+	m.mu.Unlock()
+}
+
+// Flush adds the pending counts to the usage table. On failure the counts are
+// kept for the next flush.
+func (m *UsageMeter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	counts := m.counts
+	m.counts = make(map[usageKey]int64)
+	m.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	records := make([]models.UsageRecord, 0, len(counts))
+	for key, count := range counts {
+		records = append(records, models.UsageRecord{
+			TenantID: key.tenantID,
+			Hour:     key.hour,
+			Metric:   key.metric,
+			Count:    count,
+		})
+	}
+
+	if err := m.usageRepo.Add(ctx, records); err != nil {
+		m.mu.Lock()
+		for key, count := range counts {
+			m.counts[key] += count
+		}
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes every interval until ctx is done.
+func (m *UsageMeter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Flush(ctx); err != nil {
+				m.logger.WithError(err).Error("Failed to flush usage")
+			}
+		}
+	}
+}
+
+func (m *UsageMeter) GetUsage(ctx context.Context, filter models.UsageFilter) (*models.UsageReport, error) {
+	records, err := m.usageRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewUsageReport(filter, records), nil
+}
\ No newline at end of file
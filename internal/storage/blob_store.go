@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"order-processing-microservice/pkg/config"
+)
+
+// DownloadOptions are the response headers a signed download URL serves the
+// blob with.
+type DownloadOptions struct {
+	FileName    string
+	ContentType string
+}
+
+// ErrBlobNotFound is returned by Get for a missing key.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores opaque blobs under keys. Get returns ErrBlobNotFound for a
+// missing key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	SignedURL(ctx context.Context, key string, opts DownloadOptions, expiry time.Duration) (string, error)
+}
+
+// SignedURLVerifier is implemented by stores whose signed URLs are served by
+// this service instead of the storage backend. VerifySignedURL checks the
+// query of such a URL and returns the key and options it was signed for.
+type SignedURLVerifier interface {
+	VerifySignedURL(query url.Values) (string, DownloadOptions, error)
+}
+
+// NewBlobStore creates the blob store selected by cfg.Backend.
+func NewBlobStore(ctx context.Context, cfg *config.AttachmentConfig) (BlobStore, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalBlobStore(cfg)
+	case "s3":
+		return NewS3BlobStore(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unknown attachment backend %q", cfg.Backend)
+	}
+}
+
+// NewArchiveStore creates the event archive store selected by cfg.Backend.
+// Archives are never handed out through signed URLs, so the local backend
+// needs no signing key.
+func NewArchiveStore(ctx context.Context, cfg *config.EventArchiveConfig) (BlobStore, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return newLocalBlobStore(cfg.LocalPath, "", "")
+	case "s3":
+		if cfg.S3Bucket == "" {
+			return nil, fmt.Errorf("event archive S3 bucket is required for the s3 backend")
+		}
+		return newS3BlobStore(ctx, cfg.S3Bucket, cfg.S3Region, cfg.S3Endpoint)
+	default:
+		return nil, fmt.Errorf("unknown event archive backend %q", cfg.Backend)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"order-processing-microservice/pkg/config"
+)
+
+// LocalDownloadPath is the route that serves the signed URLs of a
+// LocalBlobStore.
+const LocalDownloadPath = "/api/v1/attachments/download"
+
+// LocalBlobStore keeps blobs as files under a directory, for single-node
+// deployments and development. Its signed URLs point at LocalDownloadPath on
+// PublicURL and carry an HMAC of the key, options and expiry.
+type LocalBlobStore struct {
+	root       string
+	publicURL  string
+	signingKey []byte
+}
+
+func NewLocalBlobStore(cfg *config.AttachmentConfig) (*LocalBlobStore, error) {
+	if cfg.SigningKey == "" {
+		return nil, fmt.Errorf("attachment signing key is required for the local backend")
+	}
+	return newLocalBlobStore(cfg.LocalPath, cfg.PublicURL, cfg.SigningKey)
+}
+
+// newLocalBlobStore creates a store under path. Without a signing key it
+// cannot sign URLs.
+func newLocalBlobStore(path, publicURL, signingKey string) (*LocalBlobStore, error) {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve blob path: %w", err)
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	return &LocalBlobStore{
+		root:       root,
+		publicURL:  strings.TrimRight(publicURL, "/"),
+		signingKey: []byte(signingKey),
+	}, nil
+}
+
+func (s *LocalBlobStore) path(key string) (string, error) {
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, s.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return path, nil
+}
+
+func (s *LocalBlobStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// Written to a temporary file first so a failed upload never leaves a
+	// partial blob under the key.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return file, nil
+}
+
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) SignedURL(ctx context.Context, key string, opts DownloadOptions, expiry time.Duration) (string, error) {
+	if len(s.signingKey) == 0 {
+		return "", fmt.Errorf("blob store has no signing key")
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("key", key)
+	query.Set("name", opts.FileName)
+	query.Set("type", opts.ContentType)
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(key, opts, expires))
+	return s.publicURL + LocalDownloadPath + "?" + query.Encode(), nil
+}
+
+func (s *LocalBlobStore) VerifySignedURL(query url.Values) (string, DownloadOptions, error) {
+	key := query.Get("key")
+	opts := DownloadOptions{FileName: query.Get("name"), ContentType: query.Get("type")}
+	expires := query.Get("expires")
+
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil || !hmac.Equal(signature, s.mac(key, opts, expires)) {
+		return "", DownloadOptions{}, fmt.Errorf("invalid signature")
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return "", DownloadOptions{}, fmt.Errorf("signed URL expired")
+	}
+	return key, opts, nil
+}
+
+func (s *LocalBlobStore) sign(key string, opts DownloadOptions, expires string) string {
+	return hex.EncodeToString(s.mac(key, opts, expires))
+}
+
+func (s *LocalBlobStore) mac(key string, opts DownloadOptions, expires string) []byte {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(strings.Join([]string{key, opts.FileName, opts.ContentType, expires}, "\n")))
+	return mac.Sum(nil)
+}
\ No newline at end of file
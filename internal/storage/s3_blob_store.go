@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"order-processing-microservice/pkg/config"
+)
+
+// S3BlobStore keeps blobs in an S3 bucket. Credentials come from the default
+// AWS chain (environment, shared config or instance role). S3Endpoint points
+// it at an S3-compatible store such as MinIO, using path-style addressing.
+type S3BlobStore struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
+}
+
+func NewS3BlobStore(ctx context.Context, cfg *config.AttachmentConfig) (*S3BlobStore, error) {
+	if cfg.S3Bucket == "" {
+		return nil, fmt.Errorf("attachment S3 bucket is required for the s3 backend")
+	}
+	return newS3BlobStore(ctx, cfg.S3Bucket, cfg.S3Region, cfg.S3Endpoint)
+}
+
+func newS3BlobStore(ctx context.Context, bucket, region, endpoint string) (*S3BlobStore, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &S3BlobStore{
+		client:  client,
+		presign: s3.NewPresignClient(client),
+		bucket:  bucket,
+	}, nil
+}
+
+func (s *S3BlobStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	return nil
+}
+
+func (s *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	return output.Body, nil
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+func (s *S3BlobStore) SignedURL(ctx context.Context, key string, opts DownloadOptions, expiry time.Duration) (string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}
+	if opts.FileName != "" {
+		input.ResponseContentDisposition = aws.String(mime.FormatMediaType("attachment", map[string]string{"filename": opts.FileName}))
+	}
+	if opts.ContentType != "" {
+		input.ResponseContentType = aws.String(opts.ContentType)
+	}
+
+	request, err := s.presign.PresignGetObject(ctx, input, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign blob URL: %w", err)
+	}
+	return request.URL, nil
+}
\ No newline at end of file
@@ -0,0 +1,460 @@
+// Package mockserver is an in-memory fake of the producer API for testing
+// clients of the order service without running it, Kafka or Postgres. It
+// serves the order endpoints under the same paths and with the same response
+// envelopes, and moves orders from pending to processing to completed on its
+// own, as the consumer would.
+package mockserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"order-processing-microservice/pkg/utils"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusCanceled   = "canceled"
+	StatusFailed     = "failed"
+	StatusOnHold     = "on_hold"
+	StatusScheduled  = "scheduled"
+)
+
+// transitions mirrors the status transitions the service allows.
+var transitions = map[string][]string{
+	StatusPending:    {StatusProcessing, StatusCanceled, StatusOnHold, StatusScheduled},
+	StatusProcessing: {StatusCompleted, StatusFailed, StatusCanceled},
+	StatusCompleted:  {},
+	StatusCanceled:   {},
+	StatusFailed:     {StatusPending},
+	StatusOnHold:     {StatusPending, StatusCanceled},
+	StatusScheduled:  {StatusPending, StatusCanceled},
+}
+
+var cancelReasonCodes = []string{"user_request", "expired", "fraud", "admin"}
+
+type OrderItem struct {
+	ID        uuid.UUID `json:"id"`
+	OrderID   uuid.UUID `json:"order_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	Price     float64   `json:"price"`
+	Total     float64   `json:"total"`
+}
+
+type Order struct {
+	ID                uuid.UUID   `json:"id"`
+	OrderNumber       string      `json:"order_number,omitempty"`
+	ExternalReference string      `json:"external_reference,omitempty"`
+	Channel           string      `json:"channel,omitempty"`
+	ShippingMethod    string      `json:"shipping_method,omitempty"`
+	CustomerID        uuid.UUID   `json:"customer_id"`
+	Status            string      `json:"status"`
+	FailureCode       string      `json:"failure_code,omitempty"`
+	FailureDetail     string      `json:"failure_detail,omitempty"`
+	Items             []OrderItem `json:"items"`
+	TotalAmount       float64     `json:"total_amount"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+type createOrderRequest struct {
+	ExternalReference string    `json:"external_reference,omitempty" binding:"omitempty,max=128"`
+	Channel           string    `json:"channel,omitempty" binding:"omitempty,oneof=web mobile api pos"`
+	ShippingMethod    string    `json:"shipping_method,omitempty" binding:"omitempty,oneof=standard express overnight"`
+	CustomerID        uuid.UUID `json:"customer_id" binding:"required"`
+	Items             []struct {
+		ProductID uuid.UUID `json:"product_id" binding:"required"`
+		Quantity  int       `json:"quantity" binding:"required,min=1"`
+		Price     float64   `json:"price" binding:"required,min=0"`
+	} `json:"items" binding:"required,min=1,dive"`
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithProgression sets how long an order stays pending before it is
+// processing, and processing before it is completed. A zero delay stops
+// orders at that status until Advance or SetStatus moves them on. The
+// defaults are one and two seconds.
+func WithProgression(processing, completion time.Duration) Option {
+	return func(s *Server) {
+		s.processingDelay = processing
+		s.completionDelay = completion
+	}
+}
+
+// WithClock replaces time.Now, so tests can move orders along without
+// sleeping.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// Server is a running fake of the producer API. Its URL is the base URL to
+// point clients at; Close shuts it down.
+type Server struct {
+	*httptest.Server
+
+	processingDelay time.Duration
+	completionDelay time.Duration
+	now             func() time.Time
+
+	mu       sync.Mutex
+	orders   map[uuid.UUID]*Order
+	sequence int64
+}
+
+func New(opts ...Option) *Server {
+	s := &Server{
+		processingDelay: time.Second,
+		completionDelay: 2 * time.Second,
+		now:             time.Now,
+		orders:          make(map[uuid.UUID]*Order),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	r := gin.New()
+	s.registerRoutes(r)
+	s.Server = httptest.NewServer(r)
+	return s
+}
+
+func (s *Server) registerRoutes(r *gin.Engine) {
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+
+	api := r.Group("/api/v1")
+	{
+		orders := api.Group("/orders")
+		{
+			orders.POST("", s.createOrder)
+			orders.GET("/:id", s.getOrder)
+			orders.GET("/number/:code", s.getOrderByNumber)
+			orders.PUT("/:id/status", s.updateOrderStatus)
+			orders.PUT("/:id/cancel", s.cancelOrder)
+		}
+
+		customers := api.Group("/customers")
+		{
+			customers.GET("/:customerId/orders", s.getOrdersByCustomer)
+			customers.GET("/:customerId/orders/count", s.countOrdersByCustomer)
+		}
+	}
+}
+
+// Order returns a copy of the order with the given ID, as the API would
+// return it.
+func (s *Server) Order(id uuid.UUID) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return Order{}, false
+	}
+	s.progress(order)
+	return copyOrder(order), true
+}
+
+// Advance moves a pending order to processing, or a processing order to
+// completed, without waiting for the progression delay.
+func (s *Server) Advance(id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return fmt.Errorf("order not found")
+	}
+	s.progress(order)
+
+	switch order.Status {
+	case StatusPending:
+		s.setStatus(order, StatusProcessing, s.now().UTC())
+	case StatusProcessing:
+		s.setStatus(order, StatusCompleted, s.now().UTC())
+	default:
+		return fmt.Errorf("order in status %s does not advance", order.Status)
+	}
+	return nil
+}
+
+// SetStatus puts an order in any status, ignoring the allowed transitions,
+// e.g. to simulate a failed order. failureCode is only kept for failed
+// orders.
+func (s *Server) SetStatus(id uuid.UUID, status, failureCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return fmt.Errorf("order not found")
+	}
+	if _, ok := transitions[status]; !ok {
+		return fmt.Errorf("invalid status: %s", status)
+	}
+
+	s.setStatus(order, status, s.now().UTC())
+	if status == StatusFailed {
+		order.FailureCode = failureCode
+	}
+	return nil
+}
+
+func (s *Server) setStatus(order *Order, status string, at time.Time) {
+	order.Status = status
+	order.UpdatedAt = at
+	order.FailureCode = ""
+	order.FailureDetail = ""
+}
+
+// progress applies the status changes that are due by now. Each step happens
+// its delay after the previous change.
+func (s *Server) progress(order *Order) {
+	now := s.now()
+	for {
+		var delay time.Duration
+		var next string
+		switch order.Status {
+		case StatusPending:
+			delay, next = s.processingDelay, StatusProcessing
+		case StatusProcessing:
+			delay, next = s.completionDelay, StatusCompleted
+		default:
+			return
+		}
+		if delay <= 0 || now.Before(order.UpdatedAt.Add(delay)) {
+			return
+		}
+		s.setStatus(order, next, order.UpdatedAt.Add(delay))
+	}
+}
+
+func (s *Server) createOrder(c *gin.Context) {
+	var req createOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithValidationError(c, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.ExternalReference != "" {
+		for _, existing := range s.orders {
+			if existing.ExternalReference == req.ExternalReference {
+				utils.RespondWithError(c, http.StatusConflict, fmt.Errorf("external reference already exists"), "An order with this external reference already exists")
+				return
+			}
+		}
+	}
+
+	now := s.now().UTC()
+	s.sequence++
+	order := &Order{
+		ID:                uuid.New(),
+		OrderNumber:       fmt.Sprintf("ORD-%04d-%06d", now.Year(), s.sequence),
+		ExternalReference: req.ExternalReference,
+		Channel:           req.Channel,
+		ShippingMethod:    req.ShippingMethod,
+		CustomerID:        req.CustomerID,
+		Status:            StatusPending,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	for _, item := range req.Items {
+		total := item.Price * float64(item.Quantity)
+		order.Items = append(order.Items, OrderItem{
+			ID:        uuid.New(),
+			OrderID:   order.ID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+			Total:     total,
+		})
+		order.TotalAmount += total
+	}
+	s.orders[order.ID] = order
+
+	utils.RespondWithCreated(c, copyOrder(order), "Order created successfully")
+}
+
+func (s *Server) getOrder(c *gin.Context) {
+	order, ok := s.lookup(c)
+	if !ok {
+		return
+	}
+	utils.RespondWithSuccess(c, order)
+}
+
+func (s *Server) getOrderByNumber(c *gin.Context) {
+	code := c.Param("code")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, order := range s.orders {
+		if order.OrderNumber == code {
+			s.progress(order)
+			utils.RespondWithSuccess(c, copyOrder(order))
+			return
+		}
+	}
+	utils.RespondWithNotFound(c, "Order")
+}
+
+func (s *Server) updateOrderStatus(c *gin.Context) {
+	var req struct {
+		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason,omitempty"`
+	}
+	s.changeStatus(c, func() (string, bool) {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondWithValidationError(c, err)
+			return "", false
+		}
+		return req.Status, true
+	}, "Order status updated successfully")
+}
+
+func (s *Server) cancelOrder(c *gin.Context) {
+	var req struct {
+		ReasonCode string `json:"reason_code,omitempty"`
+		Reason     string `json:"reason,omitempty"`
+	}
+	s.changeStatus(c, func() (string, bool) {
+		// As in the service, a missing or malformed body cancels by user
+		// request.
+		_ = c.ShouldBindJSON(&req)
+		if req.ReasonCode != "" && !slices.Contains(cancelReasonCodes, req.ReasonCode) {
+			utils.RespondWithError(c, http.StatusBadRequest,
+				fmt.Errorf("invalid reason code"), "Valid reason codes: user_request, expired, fraud, admin")
+			return "", false
+		}
+		return StatusCanceled, true
+	}, "Order cancelled successfully")
+}
+
+// changeStatus applies the status returned by parse to the order in the
+// path, if the transition is allowed. A repeated change is accepted.
+func (s *Server) changeStatus(c *gin.Context, parse func() (string, bool), message string) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order ID format")
+		return
+	}
+
+	status, ok := parse()
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		utils.RespondWithNotFound(c, "Order")
+		return
+	}
+	s.progress(order)
+
+	if order.Status != status {
+		if !slices.Contains(transitions[order.Status], status) {
+			utils.RespondWithError(c, http.StatusBadRequest, fmt.Errorf("invalid status transition from %s to %s", order.Status, status))
+			return
+		}
+		s.setStatus(order, status, s.now().UTC())
+	}
+
+	utils.RespondWithSuccess(c, nil, message)
+}
+
+func (s *Server) getOrdersByCustomer(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("customerId"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid customer ID format")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	orders := s.customerOrders(customerID)
+	page := []Order{}
+	if offset < len(orders) {
+		page = orders[offset:min(offset+limit, len(orders))]
+	}
+
+	utils.RespondWithList(c, page, utils.NewOffsetMeta(limit, offset, len(page)).WithTotal(int64(len(orders))))
+}
+
+func (s *Server) countOrdersByCustomer(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("customerId"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid customer ID format")
+		return
+	}
+
+	utils.RespondWithCount(c, int64(len(s.customerOrders(customerID))))
+}
+
+// customerOrders returns the orders of a customer, newest first.
+func (s *Server) customerOrders(customerID uuid.UUID) []Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var orders []Order
+	for _, order := range s.orders {
+		if order.CustomerID == customerID {
+			s.progress(order)
+			orders = append(orders, copyOrder(order))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].OrderNumber > orders[j].OrderNumber
+	})
+	return orders
+}
+
+func (s *Server) lookup(c *gin.Context) (Order, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err, "Invalid order ID format")
+		return Order{}, false
+	}
+
+	order, ok := s.Order(id)
+	if !ok {
+		utils.RespondWithNotFound(c, "Order")
+		return Order{}, false
+	}
+	return order, true
+}
+
+func copyOrder(order *Order) Order {
+	copied := *order
+	copied.Items = append([]OrderItem(nil), order.Items...)
+	return copied
+}
\ No newline at end of file
@@ -8,49 +8,373 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Kafka    KafkaConfig    `mapstructure:"kafka"`
-	Logger   LoggerConfig   `mapstructure:"logger"`
+	Server             ServerConfig             `mapstructure:"server"`
+	Database           DatabaseConfig           `mapstructure:"database"`
+	Kafka              KafkaConfig              `mapstructure:"kafka"`
+	Logger             LoggerConfig             `mapstructure:"logger"`
+	Cache              CacheConfig              `mapstructure:"cache"`
+	Export             ExportConfig             `mapstructure:"export"`
+	Saga               SagaConfig               `mapstructure:"saga"`
+	Quota              QuotaConfig              `mapstructure:"quota"`
+	Usage              UsageConfig              `mapstructure:"usage"`
+	Queue              QueueConfig              `mapstructure:"queue"`
+	ServiceBus         ServiceBusConfig         `mapstructure:"servicebus"`
+	Demo               DemoConfig               `mapstructure:"demo"`
+	OrderContext       OrderContextConfig       `mapstructure:"order_context"`
+	Attachment         AttachmentConfig         `mapstructure:"attachment"`
+	Tracking           TrackingConfig           `mapstructure:"tracking"`
+	ProcessingWindows  ProcessingWindowsConfig  `mapstructure:"processing_windows"`
+	Delivery           DeliveryConfig           `mapstructure:"delivery"`
+	BulkCancel         BulkCancelConfig         `mapstructure:"bulk_cancel"`
+	EventThrottle      EventThrottleConfig      `mapstructure:"event_throttle"`
+	CircuitBreaker     CircuitBreakerConfig     `mapstructure:"circuit_breaker"`
+	EventScheduler     EventSchedulerConfig     `mapstructure:"event_scheduler"`
+	ConsumerRateLimit  ConsumerRateLimitConfig  `mapstructure:"consumer_rate_limit"`
+	ProcessingAttempts ProcessingAttemptsConfig `mapstructure:"processing_attempts"`
+	EventArchive       EventArchiveConfig       `mapstructure:"event_archive"`
+	Audit              AuditConfig              `mapstructure:"audit"`
+	EventFeed          EventFeedConfig          `mapstructure:"event_feed"`
+	Heartbeat          HeartbeatConfig          `mapstructure:"heartbeat"`
 }
 
+// ServerConfig configures the HTTP server. AdminPort, used by the consumer,
+// serves the admin API separately from the health endpoints; 0 disables it.
 type ServerConfig struct {
 	Host         string `mapstructure:"host"`
 	Port         int    `mapstructure:"port"`
+	AdminPort    int    `mapstructure:"admin_port"`
 	ReadTimeout  int    `mapstructure:"read_timeout"`
 	WriteTimeout int    `mapstructure:"write_timeout"`
 }
 
 type DatabaseConfig struct {
-	Host         string `mapstructure:"host"`
-	Port         int    `mapstructure:"port"`
-	Username     string `mapstructure:"username"`
-	Password     string `mapstructure:"password"`
-	Database     string `mapstructure:"database"`
-	SSLMode      string `mapstructure:"ssl_mode"`
-	MaxOpenConns int    `mapstructure:"max_open_conns"`
-	MaxIdleConns int    `mapstructure:"max_idle_conns"`
+	Host                    string `mapstructure:"host"`
+	Port                    int    `mapstructure:"port"`
+	Username                string `mapstructure:"username"`
+	Password                string `mapstructure:"password"`
+	Database                string `mapstructure:"database"`
+	SSLMode                 string `mapstructure:"ssl_mode"`
+	MaxOpenConns            int    `mapstructure:"max_open_conns"`
+	MaxIdleConns            int    `mapstructure:"max_idle_conns"`
+	MaxConnIdleTime         int    `mapstructure:"max_conn_idle_time"`
+	StatementCacheCapacity  int    `mapstructure:"statement_cache_capacity"`
+	CDCEnabled              bool   `mapstructure:"cdc_enabled"`
+	CDCPublication          string `mapstructure:"cdc_publication"`
+	UniqueExternalReference bool   `mapstructure:"unique_external_reference"`
+	ApplicationName         string `mapstructure:"application_name"`
+	QueryComments           bool   `mapstructure:"query_comments"`
+	ReplicaHost             string `mapstructure:"replica_host"`
+	IDStrategy              string `mapstructure:"id_strategy"`
+	Schema                  string `mapstructure:"schema"`
+	TablePrefix             string `mapstructure:"table_prefix"`
 }
 
 type KafkaConfig struct {
-	Brokers         []string `mapstructure:"brokers"`
-	GroupID         string   `mapstructure:"group_id"`
-	OrderTopic      string   `mapstructure:"order_topic"`
-	RetryAttempts   int      `mapstructure:"retry_attempts"`
-	SessionTimeout  int      `mapstructure:"session_timeout"`
-	CommitInterval  int      `mapstructure:"commit_interval"`
-	EnableAutoCommit bool    `mapstructure:"enable_auto_commit"`
+	Brokers                  []string `mapstructure:"brokers"`
+	GroupID                  string   `mapstructure:"group_id"`
+	OrderTopic               string   `mapstructure:"order_topic"`
+	RetryAttempts            int      `mapstructure:"retry_attempts"`
+	SessionTimeout           int      `mapstructure:"session_timeout"`
+	CommitInterval           int      `mapstructure:"commit_interval"`
+	EnableAutoCommit         bool     `mapstructure:"enable_auto_commit"`
+	CommitBatchSize          int      `mapstructure:"commit_batch_size"`
+	ConsumerWorkers          int      `mapstructure:"consumer_workers"`
+	ConsumerMinWorkers       int      `mapstructure:"consumer_min_workers"`
+	ConsumerTargetLatency    int      `mapstructure:"consumer_target_latency"`
+	InitialOffset            string   `mapstructure:"initial_offset"`
+	RebalanceStrategy        string   `mapstructure:"rebalance_strategy"`
+	Region                   string   `mapstructure:"region"`
+	CloudEventsTopics        []string `mapstructure:"cloudevents_topics"`
+	TopicRoutes              []string `mapstructure:"topic_routes"`
+	TenantTopics             []string `mapstructure:"tenant_topics"`
+	ConsumerTopics           []string `mapstructure:"consumer_topics"`
+	CloudEventsSource        string   `mapstructure:"cloudevents_source"`
+	Codec                    string   `mapstructure:"codec"`
+	MigrationTopic           string   `mapstructure:"migration_topic"`
+	MigrationPhase           string   `mapstructure:"migration_phase"`
+	MigrationIdle            int      `mapstructure:"migration_idle"`
+	MigrationSkew            int      `mapstructure:"migration_skew"`
+	EmptyAssignmentThreshold int      `mapstructure:"empty_assignment_threshold"`
+	ReadyMaxLag              int      `mapstructure:"ready_max_lag"`
+	StallTimeout             int      `mapstructure:"stall_timeout"`
+	KeyStrategy              string   `mapstructure:"key_strategy"`
+	KeyHeader                string   `mapstructure:"key_header"`
+	Partitioner              string   `mapstructure:"partitioner"`
+	DLQTopic                 string   `mapstructure:"dlq_topic"`
+	PoisonMaxAttempts        int      `mapstructure:"poison_max_attempts"`
+	PoisonBackoff            int      `mapstructure:"poison_backoff"`
+	RetryDelays              []string `mapstructure:"retry_delays"`
+	MaxMessageBytes          int      `mapstructure:"max_message_bytes"`
+	PublishTimeout           int      `mapstructure:"publish_timeout"`
+	SecondaryBrokers         []string `mapstructure:"secondary_brokers"`
+	FailoverThreshold        int      `mapstructure:"failover_threshold"`
+	FailoverPeriod           int      `mapstructure:"failover_period"`
+	FailbackInterval         int      `mapstructure:"failback_interval"`
+	Idempotent               bool     `mapstructure:"idempotent"`
+	TransactionalID          string   `mapstructure:"transactional_id"`
+
+	// AutoCreateTopics creates missing topics at startup with
+	// TopicPartitions, TopicReplicationFactor and TopicRetention (hours, 0
+	// for the broker default); the DLQ uses DLQRetention when set.
+	AutoCreateTopics       bool `mapstructure:"auto_create_topics"`
+	TopicPartitions        int  `mapstructure:"topic_partitions"`
+	TopicReplicationFactor int  `mapstructure:"topic_replication_factor"`
+	TopicRetention         int  `mapstructure:"topic_retention"`
+	DLQRetention           int  `mapstructure:"dlq_retention"`
+
+	// SchemaRegistryURL is required by the avro codec; consumers with it set
+	// also decode avro events.
+	SchemaRegistryURL          string `mapstructure:"schema_registry_url"`
+	SchemaRegistryUsername     string `mapstructure:"schema_registry_username"`
+	SchemaRegistryPassword     string `mapstructure:"schema_registry_password"`
+	SchemaRegistryTimeout      int    `mapstructure:"schema_registry_timeout"`
+	SchemaRegistryAutoRegister bool   `mapstructure:"schema_registry_auto_register"`
 }
 
+// LoggerConfig also sets where the reports of recovered panics go: a JSON
+// file each in CrashDir and a POST to CrashEndpoint. With neither set they are
+// only logged.
 type LoggerConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level         string   `mapstructure:"level"`
+	Format        string   `mapstructure:"format"`
+	RedactEnabled bool     `mapstructure:"redact_enabled"`
+	RedactFields  []string `mapstructure:"redact_fields"`
+	AllowFields   []string `mapstructure:"allow_fields"`
+	CrashDir      string   `mapstructure:"crash_dir"`
+	CrashEndpoint string   `mapstructure:"crash_endpoint"`
+}
+
+type CacheConfig struct {
+	Enabled            bool `mapstructure:"enabled"`
+	TTL                int  `mapstructure:"ttl"`
+	StaleTTL           int  `mapstructure:"stale_ttl"`
+	RefreshTimeout     int  `mapstructure:"refresh_timeout"`
+	MaxEntries         int  `mapstructure:"max_entries"`
+	StatusCacheSize    int  `mapstructure:"status_cache_size"`
+	LiveStreamInterval int  `mapstructure:"live_stream_interval"`
+}
+
+type ExportConfig struct {
+	MaxLimit  int `mapstructure:"max_limit"`
+	SafetyLag int `mapstructure:"safety_lag"`
+}
+
+type SagaConfig struct {
+	Enabled               bool   `mapstructure:"enabled"`
+	PaymentRequestTopic   string `mapstructure:"payment_request_topic"`
+	PaymentReplyTopic     string `mapstructure:"payment_reply_topic"`
+	InventoryRequestTopic string `mapstructure:"inventory_request_topic"`
+	InventoryReplyTopic   string `mapstructure:"inventory_reply_topic"`
+	ReplyTimeout          int    `mapstructure:"reply_timeout"`
+	TimeoutCheckInterval  int    `mapstructure:"timeout_check_interval"`
+}
+
+// QuotaConfig holds the quotas of tenants without their own; 0 is unlimited.
+type QuotaConfig struct {
+	MaxActiveOrders int `mapstructure:"max_active_orders"`
+	MaxOrdersPerDay int `mapstructure:"max_orders_per_day"`
+}
+
+type UsageConfig struct {
+	Enabled       bool `mapstructure:"enabled"`
+	FlushInterval int  `mapstructure:"flush_interval"`
+}
+
+// QueueConfig selects the event transport. The postgres transport keeps
+// events in the database for single-node deployments without Kafka.
+// DedupRetention is how many hours the consumer remembers processed events.
+type QueueConfig struct {
+	Transport      string `mapstructure:"transport"`
+	PollInterval   int    `mapstructure:"poll_interval"`
+	BatchSize      int    `mapstructure:"batch_size"`
+	Retention      int    `mapstructure:"retention"`
+	DedupRetention int    `mapstructure:"dedup_retention"`
+}
+
+// DemoConfig drives the producer's synthetic order generator. Interval is in
+// milliseconds and FailureRate is the share of orders scripted to fail.
+type DemoConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Interval    int     `mapstructure:"interval"`
+	Seed        int64   `mapstructure:"seed"`
+	FailureRate float64 `mapstructure:"failure_rate"`
+	Customers   int     `mapstructure:"customers"`
+	Products    int     `mapstructure:"products"`
+	MinItems    int     `mapstructure:"min_items"`
+	MaxItems    int     `mapstructure:"max_items"`
+	MaxQuantity int     `mapstructure:"max_quantity"`
+}
+
+// OrderContextConfig controls capturing the request origin of new orders.
+// Retention is how many hours a context is kept; 0 keeps it forever.
+type OrderContextConfig struct {
+	Enabled   bool `mapstructure:"enabled"`
+	Retention int  `mapstructure:"retention"`
+}
+
+// AttachmentConfig controls files uploaded against orders. Backend is "local"
+// (files under LocalPath, downloads served by the producer at PublicURL and
+// signed with SigningKey) or "s3". MaxSize is in bytes and URLExpiry in
+// seconds; AllowedTypes lists the accepted MIME types.
+type AttachmentConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	Backend      string   `mapstructure:"backend"`
+	MaxSize      int64    `mapstructure:"max_size"`
+	AllowedTypes []string `mapstructure:"allowed_types"`
+	URLExpiry    int      `mapstructure:"url_expiry"`
+	SigningKey   string   `mapstructure:"signing_key"`
+	LocalPath    string   `mapstructure:"local_path"`
+	PublicURL    string   `mapstructure:"public_url"`
+	S3Bucket     string   `mapstructure:"s3_bucket"`
+	S3Region     string   `mapstructure:"s3_region"`
+	S3Endpoint   string   `mapstructure:"s3_endpoint"`
+}
+
+// TrackingConfig controls the public order tracking links of the status API.
+// Tokens are signed with SigningKey and expire after TTL hours; BaseURL is
+// where /track/{token} is reachable. Items (product and quantity) and the
+// order total are only shown when ShowItems and ShowTotal are set.
+type TrackingConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	SigningKey string `mapstructure:"signing_key"`
+	TTL        int    `mapstructure:"ttl"`
+	BaseURL    string `mapstructure:"base_url"`
+	ShowItems  bool   `mapstructure:"show_items"`
+	ShowTotal  bool   `mapstructure:"show_total"`
+}
+
+// ProcessingWindowsConfig makes the consumer honour the per-tenant
+// processing windows managed through the admin API.
+type ProcessingWindowsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// DeliveryConfig controls delivery date estimates. ProcessingDays is the
+// processing SLA and StandardDays, ExpressDays and OvernightDays the transit
+// time per shipping method, all in business days of TimeZone. Orders that
+// start processing after CutoffHour count from the next business day.
+// Weekends and Holidays (YYYY-MM-DD) are not business days.
+type DeliveryConfig struct {
+	Enabled        bool     `mapstructure:"enabled"`
+	TimeZone       string   `mapstructure:"time_zone"`
+	CutoffHour     int      `mapstructure:"cutoff_hour"`
+	ProcessingDays int      `mapstructure:"processing_days"`
+	StandardDays   int      `mapstructure:"standard_days"`
+	ExpressDays    int      `mapstructure:"express_days"`
+	OvernightDays  int      `mapstructure:"overnight_days"`
+	Holidays       []string `mapstructure:"holidays"`
+}
+
+// BulkCancelConfig sizes the batches of bulk cancel jobs. BatchDelay, in
+// milliseconds, is the pause between batches.
+type BulkCancelConfig struct {
+	BatchSize  int `mapstructure:"batch_size"`
+	BatchDelay int `mapstructure:"batch_delay"`
+}
+
+// EventThrottleConfig limits the events of each type published per order.
+// Intervals are event_type=duration entries, e.g.
+// order.status.changed=5s; other event types are not throttled.
+type EventThrottleConfig struct {
+	Enabled   bool     `mapstructure:"enabled"`
+	Intervals []string `mapstructure:"intervals"`
+}
+
+// CircuitBreakerConfig configures the producer's circuit breaker. It opens
+// after FailureThreshold consecutive failed publishes and stays open for
+// OpenTimeout seconds; stored events are forwarded every ForwardInterval
+// seconds, ForwardBatchSize at a time.
+type CircuitBreakerConfig struct {
+	Enabled          bool `mapstructure:"enabled"`
+	FailureThreshold int  `mapstructure:"failure_threshold"`
+	OpenTimeout      int  `mapstructure:"open_timeout"`
+	ForwardInterval  int  `mapstructure:"forward_interval"`
+	ForwardBatchSize int  `mapstructure:"forward_batch_size"`
+}
+
+// EventSchedulerConfig configures delivery of events scheduled for later.
+// Due events are published every PollInterval seconds, BatchSize at a time.
+type EventSchedulerConfig struct {
+	Enabled      bool `mapstructure:"enabled"`
+	PollInterval int  `mapstructure:"poll_interval"`
+	BatchSize    int  `mapstructure:"batch_size"`
+}
+
+// ConsumerRateLimitConfig limits the events the consumer handles to
+// EventsPerSecond, in bursts of up to Burst, and the handlers running at once
+// to MaxConcurrent. A zero limit is not enforced.
+type ConsumerRateLimitConfig struct {
+	Enabled         bool    `mapstructure:"enabled"`
+	EventsPerSecond float64 `mapstructure:"events_per_second"`
+	Burst           int     `mapstructure:"burst"`
+	MaxConcurrent   int     `mapstructure:"max_concurrent"`
+}
+
+// ProcessingAttemptsConfig configures the recording of processing attempts
+// per order. Failed attempts are retried after BackoffBase seconds, doubling
+// per failure up to BackoffMax seconds, less a random BackoffJitter share.
+type ProcessingAttemptsConfig struct {
+	Enabled       bool    `mapstructure:"enabled"`
+	BackoffBase   int     `mapstructure:"backoff_base"`
+	BackoffMax    int     `mapstructure:"backoff_max"`
+	BackoffJitter float64 `mapstructure:"backoff_jitter"`
+}
+
+// EventArchiveConfig controls the hourly archive of recorded events to blob
+// storage, for retention beyond what Kafka keeps. Every Interval seconds each
+// hour that ended at least Delay seconds ago is written as gzipped NDJSON to
+// Prefix/YYYY/MM/DD/HH.ndjson.gz. Backend is "local" (files under LocalPath)
+// or "s3".
+type EventArchiveConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Interval   int    `mapstructure:"interval"`
+	Delay      int    `mapstructure:"delay"`
+	Prefix     string `mapstructure:"prefix"`
+	Backend    string `mapstructure:"backend"`
+	LocalPath  string `mapstructure:"local_path"`
+	S3Bucket   string `mapstructure:"s3_bucket"`
+	S3Region   string `mapstructure:"s3_region"`
+	S3Endpoint string `mapstructure:"s3_endpoint"`
+}
+
+// AuditConfig enables publishing an audit record of every order state change
+// to Topic, separately from the business events.
+type AuditConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Topic   string `mapstructure:"topic"`
+}
+
+// EventFeedConfig serves the order events followed by the status API as the
+// OrderEvents gRPC service on Port; 0 disables it. Each stream buffers up to
+// Buffer events and is closed if it falls further behind.
+type EventFeedConfig struct {
+	Port   int `mapstructure:"port"`
+	Buffer int `mapstructure:"buffer"`
+}
+
+// HeartbeatConfig makes the producer publish a heartbeat event every Interval
+// seconds and the consumer report how long ago it last handled one, so a
+// quiet period can be told apart from a broken pipeline. The consumer warns
+// once no heartbeat arrived for MissedIntervals intervals.
+type HeartbeatConfig struct {
+	Enabled         bool `mapstructure:"enabled"`
+	Interval        int  `mapstructure:"interval"`
+	MissedIntervals int  `mapstructure:"missed_intervals"`
+}
+
+// ServiceBusConfig configures the servicebus transport. Consumers abandon a
+// failed message for redelivery and dead-letter it after MaxDeliveryAttempts.
+type ServiceBusConfig struct {
+	ConnectionString      string `mapstructure:"connection_string"`
+	MaxDeliveryAttempts   int    `mapstructure:"max_delivery_attempts"`
+	MaxConcurrentSessions int    `mapstructure:"max_concurrent_sessions"`
+	SessionIdleTimeout    int    `mapstructure:"session_idle_timeout"`
 }
 
 func Load(configFile string) (*Config, error) {
 	viper.SetConfigFile(configFile)
 	viper.SetConfigType("env")
-	
+
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
@@ -71,6 +395,7 @@ func Load(configFile string) (*Config, error) {
 func setDefaults() {
 	viper.SetDefault("server.host", "localhost")
 	viper.SetDefault("server.port", 8080)
+	viper.SetDefault("server.admin_port", 0)
 	viper.SetDefault("server.read_timeout", 10)
 	viper.SetDefault("server.write_timeout", 10)
 
@@ -82,20 +407,197 @@ func setDefaults() {
 	viper.SetDefault("database.ssl_mode", "disable")
 	viper.SetDefault("database.max_open_conns", 25)
 	viper.SetDefault("database.max_idle_conns", 5)
+	viper.SetDefault("database.max_conn_idle_time", 1800)
+	viper.SetDefault("database.statement_cache_capacity", 512)
+	viper.SetDefault("database.cdc_enabled", false)
+	viper.SetDefault("database.cdc_publication", "order_cdc")
+	viper.SetDefault("database.unique_external_reference", false)
+	viper.SetDefault("database.application_name", "order-processing-microservice")
+	viper.SetDefault("database.query_comments", true)
+	viper.SetDefault("database.replica_host", "")
+	viper.SetDefault("database.id_strategy", "uuidv4")
+	viper.SetDefault("database.schema", "")
+	viper.SetDefault("database.table_prefix", "")
 
 	viper.SetDefault("kafka.brokers", []string{"localhost:9092"})
 	viper.SetDefault("kafka.group_id", "order-processing-group")
 	viper.SetDefault("kafka.order_topic", "order-events")
 	viper.SetDefault("kafka.retry_attempts", 3)
+	viper.SetDefault("kafka.poison_max_attempts", 3)
+	viper.SetDefault("kafka.poison_backoff", 1000)
 	viper.SetDefault("kafka.session_timeout", 30000)
 	viper.SetDefault("kafka.commit_interval", 1000)
-	viper.SetDefault("kafka.enable_auto_commit", true)
+	viper.SetDefault("kafka.enable_auto_commit", false)
+	viper.SetDefault("kafka.commit_batch_size", 100)
+	viper.SetDefault("kafka.consumer_workers", 1)
+	viper.SetDefault("kafka.consumer_min_workers", 0)
+	viper.SetDefault("kafka.consumer_target_latency", 200)
+	viper.SetDefault("kafka.initial_offset", "oldest")
+	viper.SetDefault("kafka.rebalance_strategy", "sticky")
+	viper.SetDefault("kafka.key_strategy", "order_id")
+	viper.SetDefault("kafka.partitioner", "hash")
+	viper.SetDefault("kafka.max_message_bytes", 1000000)
+	viper.SetDefault("kafka.publish_timeout", 5000)
+	viper.SetDefault("kafka.failover_threshold", 3)
+	viper.SetDefault("kafka.failover_period", 0)
+	viper.SetDefault("kafka.failback_interval", 60)
+	viper.SetDefault("kafka.idempotent", false)
+	viper.SetDefault("kafka.transactional_id", "")
+	viper.SetDefault("kafka.region", "")
+	viper.SetDefault("kafka.cloudevents_topics", []string{})
+	viper.SetDefault("kafka.topic_routes", []string{})
+	viper.SetDefault("kafka.tenant_topics", []string{})
+	viper.SetDefault("kafka.consumer_topics", []string{})
+	viper.SetDefault("kafka.cloudevents_source", "/order-processing-microservice")
+	viper.SetDefault("kafka.codec", "json")
+	viper.SetDefault("kafka.schema_registry_url", "")
+	viper.SetDefault("kafka.schema_registry_timeout", 10)
+	viper.SetDefault("kafka.schema_registry_auto_register", true)
+	viper.SetDefault("kafka.auto_create_topics", false)
+	viper.SetDefault("kafka.topic_partitions", 3)
+	viper.SetDefault("kafka.topic_replication_factor", 1)
+	viper.SetDefault("kafka.topic_retention", 0)
+	viper.SetDefault("kafka.dlq_retention", 0)
+	viper.SetDefault("kafka.migration_topic", "")
+	viper.SetDefault("kafka.migration_phase", "")
+	viper.SetDefault("kafka.migration_idle", 30)
+	viper.SetDefault("kafka.migration_skew", 5)
+	viper.SetDefault("kafka.empty_assignment_threshold", 60)
+	viper.SetDefault("kafka.ready_max_lag", 10000)
+	viper.SetDefault("kafka.stall_timeout", 0)
 
 	viper.SetDefault("logger.level", "info")
 	viper.SetDefault("logger.format", "json")
+	viper.SetDefault("logger.redact_enabled", true)
+
+	viper.SetDefault("cache.enabled", true)
+	viper.SetDefault("cache.ttl", 5)
+	viper.SetDefault("cache.stale_ttl", 30)
+	viper.SetDefault("cache.refresh_timeout", 5)
+	viper.SetDefault("cache.max_entries", 1000)
+	viper.SetDefault("cache.status_cache_size", 10000)
+	viper.SetDefault("cache.live_stream_interval", 1)
+
+	viper.SetDefault("export.max_limit", 1000)
+	viper.SetDefault("export.safety_lag", 5)
+
+	viper.SetDefault("saga.enabled", false)
+	viper.SetDefault("saga.payment_request_topic", "payment.authorize.request")
+	viper.SetDefault("saga.payment_reply_topic", "payment.authorize.reply")
+	viper.SetDefault("saga.inventory_request_topic", "inventory.reserve.request")
+	viper.SetDefault("saga.inventory_reply_topic", "inventory.reserve.reply")
+	viper.SetDefault("saga.reply_timeout", 30)
+	viper.SetDefault("saga.timeout_check_interval", 10)
+
+	viper.SetDefault("quota.max_active_orders", 0)
+	viper.SetDefault("quota.max_orders_per_day", 0)
+
+	viper.SetDefault("usage.enabled", true)
+	viper.SetDefault("usage.flush_interval", 60)
+
+	viper.SetDefault("queue.transport", "kafka")
+	viper.SetDefault("queue.poll_interval", 5)
+	viper.SetDefault("queue.batch_size", 100)
+	viper.SetDefault("queue.retention", 168)
+	viper.SetDefault("queue.dedup_retention", 168)
+
+	viper.SetDefault("servicebus.max_delivery_attempts", 5)
+	viper.SetDefault("servicebus.max_concurrent_sessions", 8)
+	viper.SetDefault("servicebus.session_idle_timeout", 5)
+
+	viper.SetDefault("demo.enabled", false)
+	viper.SetDefault("demo.interval", 1000)
+	viper.SetDefault("demo.seed", 1)
+	viper.SetDefault("demo.failure_rate", 0.1)
+	viper.SetDefault("demo.customers", 50)
+	viper.SetDefault("demo.products", 20)
+	viper.SetDefault("demo.min_items", 1)
+	viper.SetDefault("demo.max_items", 5)
+	viper.SetDefault("demo.max_quantity", 3)
+
+	viper.SetDefault("order_context.enabled", true)
+	viper.SetDefault("order_context.retention", 2160)
+
+	viper.SetDefault("attachment.enabled", false)
+	viper.SetDefault("attachment.backend", "local")
+	viper.SetDefault("attachment.max_size", 10485760)
+	viper.SetDefault("attachment.allowed_types", []string{"application/pdf", "image/png", "image/jpeg"})
+	viper.SetDefault("attachment.url_expiry", 900)
+	viper.SetDefault("attachment.local_path", "./data/attachments")
+	viper.SetDefault("attachment.public_url", "http://localhost:8080")
+	viper.SetDefault("attachment.s3_region", "us-east-1")
+
+	viper.SetDefault("tracking.enabled", false)
+	viper.SetDefault("tracking.ttl", 720)
+	viper.SetDefault("tracking.base_url", "http://localhost:9080")
+	viper.SetDefault("tracking.show_items", false)
+	viper.SetDefault("tracking.show_total", false)
+
+	viper.SetDefault("processing_windows.enabled", false)
+
+	viper.SetDefault("delivery.enabled", false)
+	viper.SetDefault("delivery.time_zone", "UTC")
+	viper.SetDefault("delivery.cutoff_hour", 14)
+	viper.SetDefault("delivery.processing_days", 1)
+	viper.SetDefault("delivery.standard_days", 5)
+	viper.SetDefault("delivery.express_days", 2)
+	viper.SetDefault("delivery.overnight_days", 1)
+	viper.SetDefault("delivery.holidays", []string{})
+
+	viper.SetDefault("bulk_cancel.batch_size", 100)
+	viper.SetDefault("bulk_cancel.batch_delay", 100)
+
+	viper.SetDefault("event_throttle.enabled", false)
+	viper.SetDefault("event_throttle.intervals", []string{})
+
+	viper.SetDefault("circuit_breaker.enabled", false)
+	viper.SetDefault("circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("circuit_breaker.open_timeout", 30)
+	viper.SetDefault("circuit_breaker.forward_interval", 5)
+	viper.SetDefault("circuit_breaker.forward_batch_size", 100)
+
+	viper.SetDefault("event_scheduler.enabled", false)
+	viper.SetDefault("event_scheduler.poll_interval", 5)
+	viper.SetDefault("event_scheduler.batch_size", 100)
+
+	viper.SetDefault("consumer_rate_limit.enabled", false)
+	viper.SetDefault("consumer_rate_limit.events_per_second", 100)
+	viper.SetDefault("consumer_rate_limit.burst", 100)
+	viper.SetDefault("consumer_rate_limit.max_concurrent", 10)
+
+	viper.SetDefault("processing_attempts.enabled", false)
+	viper.SetDefault("processing_attempts.backoff_base", 30)
+	viper.SetDefault("processing_attempts.backoff_max", 1800)
+	viper.SetDefault("processing_attempts.backoff_jitter", 0.2)
+
+	viper.SetDefault("event_archive.enabled", false)
+	viper.SetDefault("event_archive.interval", 300)
+	viper.SetDefault("event_archive.delay", 600)
+	viper.SetDefault("event_archive.prefix", "events")
+	viper.SetDefault("event_archive.backend", "local")
+	viper.SetDefault("event_archive.local_path", "./data/event-archive")
+	viper.SetDefault("event_archive.s3_region", "us-east-1")
+
+	viper.SetDefault("audit.enabled", false)
+	viper.SetDefault("audit.topic", "order-audit")
+
+	viper.SetDefault("event_feed.port", 0)
+	viper.SetDefault("event_feed.buffer", 256)
+
+	viper.SetDefault("heartbeat.enabled", false)
+	viper.SetDefault("heartbeat.interval", 30)
+	viper.SetDefault("heartbeat.missed_intervals", 3)
 }
 
 func (d *DatabaseConfig) GetDSN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		d.Host, d.Port, d.Username, d.Password, d.Database, d.SSLMode)
+	escape := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	if d.ApplicationName != "" {
+		dsn += fmt.Sprintf(" application_name='%s'", escape.Replace(d.ApplicationName))
+	}
+	if d.Schema != "" {
+		dsn += fmt.Sprintf(" search_path='%s'", escape.Replace(d.Schema))
+	}
+	return dsn
 }
\ No newline at end of file
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+)
+
+// migrationsTable records the version of the schema, as maintained by
+// golang-migrate. It takes the table prefix like the other tables.
+const migrationsTable = "schema_migrations"
+
+// ErrSchemaOutOfDate is returned by CheckSchema when migrations embedded in
+// the binary have not been applied to the database.
+var ErrSchemaOutOfDate = errors.New("database schema is out of date")
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+func migrationSource() (source.Driver, error) {
+	return iofs.New(migrationFiles, "migrations")
+}
+
+// SchemaVersion returns the version of the newest migration embedded in the
+// binary, which is the schema version the services expect.
+func SchemaVersion() (uint, error) {
+	src, err := migrationSource()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
+
+// CheckSchema returns ErrSchemaOutOfDate unless every embedded migration has
+// been applied, so services refuse to run against a schema they do not know.
+// A schema newer than the binary is accepted, as during a rolling deploy,
+// since migrations are written to stay compatible with the previous release.
+// It only reads the migrations table and never changes the database.
+func (p *PostgresDB) CheckSchema(ctx context.Context) error {
+	expected, err := SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := p.SchemaState(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w: migration %d failed part way; repair it and run `migrate force %d`", ErrSchemaOutOfDate, version, version)
+	}
+	if version < expected {
+		return fmt.Errorf("%w: database is at version %d, binary expects %d; run `migrate up`", ErrSchemaOutOfDate, version, expected)
+	}
+	if version > expected {
+		logrus.WithFields(logrus.Fields{
+			"schema_version":   version,
+			"expected_version": expected,
+		}).Warn("Database schema is newer than this binary")
+	}
+	return nil
+}
+
+// SchemaState returns the applied schema version, 0 before the first
+// migration, and whether its migration failed part way.
+func (p *PostgresDB) SchemaState(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := p.db.QueryRowContext(ctx, `SELECT version, dirty FROM `+p.versionTable()+` LIMIT 1`).Scan(&version, &dirty)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.As(err, &pgErr) && pgErr.Code == "42P01":
+		// No migration has been applied yet.
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	case version < 0:
+		return 0, dirty, nil
+	}
+	return uint(version), dirty, nil
+}
+
+// Migrate creates the configured schema if needed and applies every pending
+// migration, then the schema objects that depend on configuration rather
+// than on the schema version.
+func (p *PostgresDB) Migrate(ctx context.Context) error {
+	if p.cfg.Schema != "" {
+		if _, err := p.db.ExecContext(ctx, `CREATE SCHEMA IF NOT EXISTS `+pgx.Identifier{p.cfg.Schema}.Sanitize()); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+
+	err := p.withMigrate(ctx, func(m *migrate.Migrate) error {
+		return m.Up()
+	})
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return p.applyOptionalSchema(ctx)
+}
+
+// Rollback reverts the newest steps applied migrations.
+func (p *PostgresDB) Rollback(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	err := p.withMigrate(ctx, func(m *migrate.Migrate) error {
+		return m.Steps(-steps)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
+	return nil
+}
+
+// ForceSchemaVersion records version as applied and clean without running
+// anything, to recover after a failed migration has been repaired by hand.
+func (p *PostgresDB) ForceSchemaVersion(ctx context.Context, version int) error {
+	err := p.withMigrate(ctx, func(m *migrate.Migrate) error {
+		return m.Force(version)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to force schema version: %w", err)
+	}
+	return nil
+}
+
+// withMigrate runs fn with a migrator holding a dedicated connection, which
+// is released afterwards without closing the pool.
+func (p *PostgresDB) withMigrate(ctx context.Context, fn func(*migrate.Migrate) error) error {
+	src, err := migrationSource()
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		src.Close()
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{MigrationsTable: p.versionTable()})
+	if err != nil {
+		src.Close()
+		conn.Close()
+		return fmt.Errorf("failed to open migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	m.Log = migrateLogger{logrus.WithField("component", "migrate")}
+	defer m.Close()
+
+	return fn(m)
+}
+
+func (p *PostgresDB) versionTable() string {
+	return p.cfg.TablePrefix + migrationsTable
+}
+
+// applyOptionalSchema creates the objects enabled by DatabaseConfig. They
+// are idempotent and applied after every migration run, so enabling one
+// takes effect with the next `migrate up`.
+func (p *PostgresDB) applyOptionalSchema(ctx context.Context) error {
+	var queries []string
+	if p.cfg.UniqueExternalReference {
+		queries = append(queries, createExternalReferenceUniqueIndex)
+	}
+	if p.cfg.CDCEnabled {
+		queries = append(queries, setReplicaIdentity, fmt.Sprintf(createPublication, p.cfg.CDCPublication))
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// migrateLogger passes golang-migrate's progress messages to logrus.
+type migrateLogger struct {
+	logger *logrus.Entry
+}
+
+func (l migrateLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l migrateLogger) Verbose() bool {
+	return false
+}
+
+const setReplicaIdentity = `
+ALTER TABLE orders REPLICA IDENTITY FULL;
+ALTER TABLE order_items REPLICA IDENTITY FULL;
+`
+
+const createPublication = `
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_publication WHERE pubname = '%[1]s') THEN
+        CREATE PUBLICATION %[1]s FOR TABLE orders, order_items;
+    END IF;
+END
+$$;
+`
+
+const createExternalReferenceUniqueIndex = `
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_tenant_external_reference_unique
+    ON orders(tenant_id, external_reference) WHERE external_reference IS NOT NULL;
+`
\ No newline at end of file
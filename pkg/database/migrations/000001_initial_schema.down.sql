@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS message_failures;
+DROP TABLE IF EXISTS event_archives;
+DROP TABLE IF EXISTS processing_attempts;
+DROP TABLE IF EXISTS scheduled_events;
+DROP TABLE IF EXISTS fallback_events;
+DROP TABLE IF EXISTS bulk_cancel_jobs;
+DROP TABLE IF EXISTS order_attachments;
+DROP TABLE IF EXISTS order_contexts;
+DROP TABLE IF EXISTS processed_events;
+DROP TABLE IF EXISTS dead_letter_events;
+DROP TABLE IF EXISTS event_queue_offsets;
+DROP TABLE IF EXISTS event_queue;
+DROP TABLE IF EXISTS tenant_usage;
+DROP TABLE IF EXISTS tenant_processing_windows;
+DROP TABLE IF EXISTS tenant_quotas;
+DROP TABLE IF EXISTS order_item_changes;
+DROP TABLE IF EXISTS order_compensations;
+DROP TABLE IF EXISTS order_sagas;
+DROP TABLE IF EXISTS order_events;
+DROP TABLE IF EXISTS order_number_sequences;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
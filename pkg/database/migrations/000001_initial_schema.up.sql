@@ -0,0 +1,281 @@
+-- Baseline: the schema previously created at startup. Statements are
+-- idempotent so existing databases can be brought under migration control.
+
+CREATE TABLE IF NOT EXISTS orders (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    total_amount DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
+    total DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    UNIQUE(order_id, product_id)
+);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS order_number VARCHAR(32);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS external_reference VARCHAR(128);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS failure_code VARCHAR(32);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS failure_detail TEXT;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS dispatch_lease_until TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS channel VARCHAR(16);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS scheduled_for TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_method VARCHAR(16);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS estimated_delivery DATE;
+
+CREATE TABLE IF NOT EXISTS order_number_sequences (
+    tenant_id VARCHAR(64) NOT NULL,
+    year INTEGER NOT NULL,
+    last_value BIGINT NOT NULL,
+    PRIMARY KEY (tenant_id, year)
+);
+
+CREATE TABLE IF NOT EXISTS order_events (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS order_sagas (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    step VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    correlation_id UUID NOT NULL UNIQUE,
+    deadline TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS order_compensations (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    target_status VARCHAR(50) NOT NULL,
+    completed_steps TEXT[] NOT NULL DEFAULT '{}',
+    failure_code VARCHAR(32),
+    failure_detail TEXT,
+    error TEXT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Item IDs are not foreign keys so the changes of removed items are kept.
+CREATE TABLE IF NOT EXISTS order_item_changes (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    previous_quantity INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    quantity_delta INTEGER NOT NULL,
+    order_version INTEGER NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS tenant_quotas (
+    tenant_id VARCHAR(64) PRIMARY KEY,
+    max_active_orders INTEGER NOT NULL DEFAULT 0,
+    max_orders_per_day INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS tenant_processing_windows (
+    tenant_id VARCHAR(64) PRIMARY KEY,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    rules TEXT[] NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS tenant_usage (
+    tenant_id VARCHAR(64) NOT NULL,
+    hour TIMESTAMP WITH TIME ZONE NOT NULL,
+    metric VARCHAR(50) NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, hour, metric)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_usage_hour ON tenant_usage(hour);
+
+CREATE TABLE IF NOT EXISTS event_queue (
+    id BIGSERIAL PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_queue_topic_id ON event_queue(topic, id);
+CREATE INDEX IF NOT EXISTS idx_event_queue_created_at ON event_queue(created_at);
+
+CREATE TABLE IF NOT EXISTS event_queue_offsets (
+    group_id VARCHAR(255) NOT NULL,
+    topic VARCHAR(255) NOT NULL,
+    last_id BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, topic)
+);
+
+CREATE TABLE IF NOT EXISTS dead_letter_events (
+    id UUID PRIMARY KEY,
+    event_id UUID,
+    event_type VARCHAR(100),
+    topic VARCHAR(255) NOT NULL,
+    partition INTEGER NOT NULL,
+    "offset" BIGINT NOT NULL,
+    consumer_group VARCHAR(255) NOT NULL,
+    dlq_topic VARCHAR(255) NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    error TEXT NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    payload BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letter_events_created_at ON dead_letter_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_dead_letter_events_event_id ON dead_letter_events(event_id);
+
+ALTER TABLE dead_letter_events ADD COLUMN IF NOT EXISTS message_key BYTEA;
+ALTER TABLE dead_letter_events ADD COLUMN IF NOT EXISTS requeued_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS processed_events (
+    event_id UUID PRIMARY KEY,
+    processed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_events_processed_at ON processed_events(processed_at);
+
+CREATE TABLE IF NOT EXISTS order_contexts (
+    order_id UUID PRIMARY KEY,
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    api_key_id VARCHAR(128),
+    origin TEXT,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_contexts_created_at ON order_contexts(created_at);
+
+CREATE TABLE IF NOT EXISTS order_attachments (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(128) NOT NULL,
+    size BIGINT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_attachments_order_id ON order_attachments(order_id, created_at);
+
+CREATE TABLE IF NOT EXISTS bulk_cancel_jobs (
+    id UUID PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    order_status VARCHAR(50) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    reason_code VARCHAR(32) NOT NULL,
+    reason TEXT,
+    actor VARCHAR(255),
+    batches INTEGER NOT NULL DEFAULT 0,
+    canceled INTEGER NOT NULL DEFAULT 0,
+    published INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS fallback_events (
+    seq BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS scheduled_events (
+    event_id UUID PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    deliver_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_events_deliver_at ON scheduled_events(deliver_at);
+
+CREATE TABLE IF NOT EXISTS processing_attempts (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    attempt INTEGER NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (order_id, attempt)
+);
+
+CREATE TABLE IF NOT EXISTS event_archives (
+    hour TIMESTAMP WITH TIME ZONE PRIMARY KEY,
+    object_key TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    event_count INTEGER NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_events_occurred_at ON order_events(occurred_at);
+
+CREATE TABLE IF NOT EXISTS message_failures (
+    consumer_group VARCHAR(255) NOT NULL,
+    topic VARCHAR(255) NOT NULL,
+    partition INTEGER NOT NULL,
+    "offset" BIGINT NOT NULL,
+    failures INTEGER NOT NULL,
+    last_error TEXT NOT NULL,
+    quarantined_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (consumer_group, topic, partition, "offset")
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_updated_at_id ON orders(updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_orders_status_created_at_id ON orders(status, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_orders_channel ON orders(channel);
+CREATE INDEX IF NOT EXISTS idx_orders_scheduled_for ON orders(scheduled_for) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items(product_id);
+CREATE INDEX IF NOT EXISTS idx_order_events_order_id_occurred_at ON order_events(order_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_order_sagas_status_deadline ON order_sagas(status, deadline);
+CREATE INDEX IF NOT EXISTS idx_order_compensations_status_created_at ON order_compensations(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_order_item_changes_order_id ON order_item_changes(order_id, changed_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_tenant_order_number ON orders(tenant_id, order_number);
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_external_reference ON orders(tenant_id, external_reference);
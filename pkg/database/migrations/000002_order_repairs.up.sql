@@ -0,0 +1,15 @@
+-- Audit entries of the repairs made by `fsck -repair`. Order and item IDs are
+-- not foreign keys: orphaned items have no order, and deleted rows are kept
+-- in old_value.
+CREATE TABLE IF NOT EXISTS order_repairs (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    order_id UUID NOT NULL,
+    item_id UUID,
+    old_value TEXT NOT NULL,
+    new_value TEXT,
+    actor VARCHAR(255) NOT NULL,
+    repaired_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_repairs_order_id ON order_repairs(order_id, repaired_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_order_events_order_id_sequence;
+ALTER TABLE order_events DROP COLUMN IF EXISTS sequence;
+DROP TABLE IF EXISTS order_event_sequences;
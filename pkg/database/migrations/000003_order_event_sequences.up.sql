@@ -0,0 +1,12 @@
+-- The last sequence number given to an event of each order, see
+-- models.Event.Sequence. Not a foreign key: events published after an order
+-- was deleted are numbered as well.
+CREATE TABLE IF NOT EXISTS order_event_sequences (
+    order_id UUID PRIMARY KEY,
+    last_sequence BIGINT NOT NULL
+);
+
+-- Events recorded before numbering was introduced have no sequence.
+ALTER TABLE order_events ADD COLUMN IF NOT EXISTS sequence BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_order_events_order_id_sequence ON order_events(order_id, sequence);
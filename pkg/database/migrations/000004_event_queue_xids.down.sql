@@ -0,0 +1,3 @@
+ALTER TABLE event_queue_offsets DROP COLUMN IF EXISTS last_xid;
+DROP INDEX IF EXISTS idx_event_queue_topic_xid_id;
+ALTER TABLE event_queue DROP COLUMN IF EXISTS xid;
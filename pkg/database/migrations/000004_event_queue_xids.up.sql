@@ -0,0 +1,11 @@
+-- The transaction that queued each event. Consumers read events in (xid, id)
+-- order and only those of transactions older than every transaction still in
+-- flight, so an event whose transaction commits late is never skipped. Needs
+-- PostgreSQL 13 or later. Events queued before have the xid of this migration.
+ALTER TABLE event_queue ADD COLUMN IF NOT EXISTS xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX IF NOT EXISTS idx_event_queue_topic_xid_id ON event_queue(topic, xid, id);
+
+-- Existing offsets keep their position among the events queued before.
+ALTER TABLE event_queue_offsets ADD COLUMN IF NOT EXISTS last_xid xid8 NOT NULL DEFAULT '0';
+UPDATE event_queue_offsets SET last_xid = pg_current_xact_id();
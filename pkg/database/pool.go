@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"order-processing-microservice/pkg/config"
+)
+
+// PoolConn is the part of a pgx connection pool that a Pool runs statements
+// on.
+type PoolConn interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Pool runs statements on the pgx connection pool of a PostgresDB. They are
+// rewritten like those sent through GetDB, annotated with the query tags of
+// their context and with table names prefixed as configured. Batches, COPY
+// and explicitly prepared statements bypass the rewriting.
+type Pool struct {
+	conn    PoolConn
+	rewrite func(ctx context.Context, query string) string
+}
+
+// NewPoolWithConn returns a Pool running statements on conn, rewritten as cfg
+// configures.
+func NewPoolWithConn(conn PoolConn, cfg *config.DatabaseConfig) (*Pool, error) {
+	rewrite, err := newRewrite(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{conn: conn, rewrite: rewrite}, nil
+}
+
+func (p *Pool) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	return p.conn.Exec(ctx, p.sql(ctx, query), args...)
+}
+
+func (p *Pool) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	return p.conn.Query(ctx, p.sql(ctx, query), args...)
+}
+
+func (p *Pool) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return p.conn.QueryRow(ctx, p.sql(ctx, query), args...)
+}
+
+// Begin starts a transaction on a connection of the pool, which is released
+// when the transaction is committed or rolled back.
+func (p *Pool) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := p.conn.Begin(ctx)
+	if err != nil || p.rewrite == nil {
+		return tx, err
+	}
+	return rewritingTx{Tx: tx, rewrite: p.rewrite}, nil
+}
+
+func (p *Pool) sql(ctx context.Context, query string) string {
+	if p.rewrite == nil {
+		return query
+	}
+	return p.rewrite(ctx, query)
+}
+
+// rewritingTx is a transaction of a Pool that rewrites its statements, and
+// those of its savepoints, like the pool does.
+type rewritingTx struct {
+	pgx.Tx
+	rewrite func(ctx context.Context, query string) string
+}
+
+func (t rewritingTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := t.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rewritingTx{Tx: tx, rewrite: t.rewrite}, nil
+}
+
+func (t rewritingTx) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	return t.Tx.Exec(ctx, t.rewrite(ctx, query), args...)
+}
+
+func (t rewritingTx) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	return t.Tx.Query(ctx, t.rewrite(ctx, query), args...)
+}
+
+func (t rewritingTx) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return t.Tx.QueryRow(ctx, t.rewrite(ctx, query), args...)
+}
\ No newline at end of file
@@ -1,108 +1,143 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/sirupsen/logrus"
 	"order-processing-microservice/pkg/config"
 )
 
+// PostgresDB holds a pgx connection pool. GetPool exposes it to the
+// repositories written against pgx; GetDB exposes it through database/sql to
+// the others, so both count against the same connection limit.
 type PostgresDB struct {
-	db *sql.DB
+	conns *pgxpool.Pool
+	pool  *Pool
+	db    *sql.DB
+	cfg   *config.DatabaseConfig
 }
 
 func NewPostgresDB(cfg *config.DatabaseConfig) (*PostgresDB, error) {
-	db, err := sql.Open("postgres", cfg.GetDSN())
+	if cfg.Schema != "" && !validIdentifier.MatchString(cfg.Schema) {
+		return nil, fmt.Errorf("invalid schema %q: use lowercase letters, digits and underscores", cfg.Schema)
+	}
+	rewrite, err := newRewrite(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database connection: %w", err)
+		return nil, err
+	}
+	poolCfg, err := poolConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database configuration: %w", err)
 	}
 
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetConnMaxLifetime(time.Hour)
-
-	if err := db.Ping(); err != nil {
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	if err := pool.Ping(context.Background()); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	logrus.Info("Successfully connected to PostgreSQL database")
-	
-	return &PostgresDB{db: db}, nil
+	var connector driver.Connector = stdlib.GetPoolConnector(pool)
+	if rewrite != nil {
+		connector = rewritingConnector{Connector: connector, rewrite: rewrite}
+	}
+	db := sql.OpenDB(connector)
+	// Idle connections are kept by the pool; database/sql holding on to them
+	// would starve the users of GetPool.
+	db.SetMaxIdleConns(0)
+
+	logrus.WithFields(logrus.Fields{
+		"max_conns":  poolCfg.MaxConns,
+		"query_mode": poolCfg.ConnConfig.DefaultQueryExecMode,
+	}).Info("Successfully connected to PostgreSQL database")
+
+	return &PostgresDB{
+		conns: pool,
+		pool:  &Pool{conn: pool, rewrite: rewrite},
+		db:    db,
+		cfg:   cfg,
+	}, nil
 }
 
-func (p *PostgresDB) GetDB() *sql.DB {
-	return p.db
-}
+// poolConfig sizes the pool from cfg. Statements are prepared once per
+// connection and cached, unless query comments are enabled: their tags make
+// nearly every statement unique, so each would be prepared for a single use.
+// They are then sent unprepared, in one round trip like cached statements.
+func poolConfig(cfg *config.DatabaseConfig) (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(cfg.GetDSN())
+	if err != nil {
+		return nil, err
+	}
 
-func (p *PostgresDB) Close() error {
-	if p.db != nil {
-		return p.db.Close()
+	if cfg.MaxOpenConns > 0 {
+		poolCfg.MaxConns = int32(cfg.MaxOpenConns)
+	}
+	poolCfg.MinConns = int32(max(min(cfg.MaxIdleConns, int(poolCfg.MaxConns)), 0))
+	poolCfg.MaxConnLifetime = time.Hour
+	if cfg.MaxConnIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = time.Duration(cfg.MaxConnIdleTime) * time.Second
 	}
-	return nil
-}
 
-func (p *PostgresDB) Ping() error {
-	return p.db.Ping()
+	if cfg.QueryComments || cfg.StatementCacheCapacity <= 0 {
+		poolCfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+		poolCfg.ConnConfig.StatementCacheCapacity = 0
+	} else {
+		poolCfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+		poolCfg.ConnConfig.StatementCacheCapacity = cfg.StatementCacheCapacity
+	}
+	return poolCfg, nil
 }
 
-func (p *PostgresDB) CreateTables() error {
-	queries := []string{
-		createOrdersTable,
-		createOrderItemsTable,
-		createIndexes,
+// newRewrite returns the rewriting applied to every statement when query
+// comments or a table prefix are enabled, see WithQueryTag and TableRewriter,
+// and nil otherwise.
+func newRewrite(cfg *config.DatabaseConfig) (func(ctx context.Context, query string) string, error) {
+	if !cfg.QueryComments && cfg.TablePrefix == "" {
+		return nil, nil
 	}
 
-	tx, err := p.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+	var tables *TableRewriter
+	if cfg.TablePrefix != "" {
+		var err error
+		if tables, err = NewTableRewriter(cfg.TablePrefix); err != nil {
+			return nil, err
+		}
 	}
-	defer tx.Rollback()
 
-	for _, query := range queries {
-		if _, err := tx.Exec(query); err != nil {
-			return fmt.Errorf("failed to execute query: %w", err)
+	return func(ctx context.Context, query string) string {
+		if tables != nil {
+			query = tables.Rewrite(query)
 		}
-	}
+		if cfg.QueryComments {
+			query = AnnotateQuery(ctx, query)
+		}
+		return query
+	}, nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
+func (p *PostgresDB) GetDB() *sql.DB {
+	return p.db
+}
 
-	logrus.Info("Successfully created database tables")
-	return nil
+func (p *PostgresDB) GetPool() *Pool {
+	return p.pool
 }
 
-const createOrdersTable = `
-CREATE TABLE IF NOT EXISTS orders (
-    id UUID PRIMARY KEY,
-    customer_id UUID NOT NULL,
-    status VARCHAR(50) NOT NULL DEFAULT 'pending',
-    total_amount DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
-    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-    version INTEGER NOT NULL DEFAULT 1
-);
-`
-
-const createOrderItemsTable = `
-CREATE TABLE IF NOT EXISTS order_items (
-    id UUID PRIMARY KEY,
-    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
-    product_id UUID NOT NULL,
-    quantity INTEGER NOT NULL CHECK (quantity > 0),
-    price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
-    total DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
-    UNIQUE(order_id, product_id)
-);
-`
-
-const createIndexes = `
-CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id);
-CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
-CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);
-CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
-CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items(product_id);
-`
\ No newline at end of file
+func (p *PostgresDB) Close() error {
+	err := p.db.Close()
+	p.conns.Close()
+	return err
+}
+
+func (p *PostgresDB) Ping() error {
+	return p.conns.Ping(context.Background())
+}
\ No newline at end of file
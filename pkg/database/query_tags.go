@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type queryTagsKey struct{}
+
+type queryTag struct {
+	key   string
+	value string
+}
+
+// WithQueryTag returns a context whose SQL statements are prefixed with a
+// comment carrying key='value', so queries seen in pg_stat_activity or the
+// slow query log can be traced back to the request or event that issued them.
+// A tag replaces an earlier one with the same key; empty values are ignored.
+func WithQueryTag(ctx context.Context, key, value string) context.Context {
+	if value == "" {
+		return ctx
+	}
+
+	existing, _ := ctx.Value(queryTagsKey{}).([]queryTag)
+	tags := make([]queryTag, 0, len(existing)+1)
+	for _, tag := range existing {
+		if tag.key != key {
+			tags = append(tags, tag)
+		}
+	}
+	tags = append(tags, queryTag{key: key, value: value})
+	return context.WithValue(ctx, queryTagsKey{}, tags)
+}
+
+// AnnotateQuery prefixes query with an sqlcommenter-style comment of the tags
+// in ctx, sorted by key, e.g. /*event_id='1f0c',request_id='abc'*/ SELECT ...
+// Keys and values are URL-encoded so they cannot end the comment. The comment
+// leads the statement because pg_stat_activity truncates long queries.
+func AnnotateQuery(ctx context.Context, query string) string {
+	tags, _ := ctx.Value(queryTagsKey{}).([]queryTag)
+	if len(tags) == 0 {
+		return query
+	}
+
+	parts := make([]string, len(tags))
+	for i, tag := range tags {
+		parts[i] = url.QueryEscape(tag.key) + "='" + url.QueryEscape(tag.value) + "'"
+	}
+	sort.Strings(parts)
+	return "/*" + strings.Join(parts, ",") + "*/ " + query
+}
+
+// rewritingConnector opens connections whose statements are passed through
+// rewrite, which annotates them with the query tags of their context and
+// prefixes table names as configured.
+type rewritingConnector struct {
+	driver.Connector
+	rewrite func(ctx context.Context, query string) string
+}
+
+func (c rewritingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rewritingConn{Conn: conn, rewrite: c.rewrite}, nil
+}
+
+// rewritingConn forwards to the driver connection, rewriting statements run
+// through the context-aware methods database/sql uses.
+type rewritingConn struct {
+	driver.Conn
+	rewrite func(ctx context.Context, query string) string
+}
+
+func (c *rewritingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return queryer.QueryContext(ctx, c.rewrite(ctx, query), args)
+}
+
+func (c *rewritingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, c.rewrite(ctx, query), args)
+}
+
+func (c *rewritingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, c.rewrite(ctx, query))
+	}
+	return c.Conn.Prepare(c.rewrite(ctx, query))
+}
+
+func (c *rewritingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// CheckNamedValue lets the driver accept the argument types it supports
+// natively, as it does on connections that are not rewritten.
+func (c *rewritingConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (c *rewritingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *rewritingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *rewritingConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
\ No newline at end of file
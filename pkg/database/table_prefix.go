@@ -0,0 +1,139 @@
+package database
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// maxIdentifierLength is PostgreSQL's limit; longer names are truncated.
+const maxIdentifierLength = 63
+
+var (
+	validIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	createdName     = regexp.MustCompile(`(?i)CREATE\s+(?:UNIQUE\s+)?(?:TABLE|INDEX)\s+(?:IF\s+NOT\s+EXISTS\s+)?([a-z_][a-z0-9_]*)`)
+)
+
+// TableRewriter prefixes the names of the service's tables and indexes in
+// SQL statements, so several instances can share one schema. The names are
+// those created by the migrations, so the statements of repositories and of
+// migrations are rewritten alike and new tables need no registration.
+type TableRewriter struct {
+	prefix string
+	names  map[string]bool
+}
+
+func NewTableRewriter(prefix string) (*TableRewriter, error) {
+	if !validIdentifier.MatchString(prefix) {
+		return nil, fmt.Errorf("invalid table prefix %q: use lowercase letters, digits and underscores", prefix)
+	}
+
+	statements := []string{createExternalReferenceUniqueIndex}
+	err := fs.WalkDir(migrationFiles, "migrations", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".up.sql") {
+			return err
+		}
+		data, err := migrationFiles.ReadFile(path)
+		statements = append(statements, string(data))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	r := &TableRewriter{prefix: prefix, names: make(map[string]bool)}
+	for _, statement := range statements {
+		for _, match := range createdName.FindAllStringSubmatch(statement, -1) {
+			name := strings.ToLower(match[1])
+			if len(prefix)+len(name) > maxIdentifierLength {
+				return nil, fmt.Errorf("table prefix %q makes %s longer than %d characters", prefix, name, maxIdentifierLength)
+			}
+			r.names[name] = true
+		}
+	}
+	return r, nil
+}
+
+// Rewrite prefixes the unquoted identifiers of query naming a table or index
+// of the service. String literals, quoted identifiers and comments are left
+// alone; dollar-quoted bodies, such as those of DO blocks, are rewritten like
+// the statement around them.
+func (r *TableRewriter) Rewrite(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 4*len(r.prefix))
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(query) {
+				if query[end] == c {
+					// A doubled quote escapes itself.
+					if end+1 < len(query) && query[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end+1, len(query))
+			b.WriteString(query[i:end])
+			i = end
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case isIdentifierStart(c):
+			end := i + 1
+			for end < len(query) && isIdentifierPart(query[end]) {
+				end++
+			}
+			word := query[i:end]
+			if r.names[strings.ToLower(word)] {
+				b.WriteString(r.prefix)
+			}
+			b.WriteString(word)
+			i = end
+		case c == '$' || isDigit(c):
+			// Parameters such as $1 and numbers are copied whole so their
+			// digits are not taken for the start of an identifier.
+			end := i + 1
+			for end < len(query) && isIdentifierPart(query[end]) {
+				end++
+			}
+			b.WriteString(query[i:end])
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c) || c == '$'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
\ No newline at end of file
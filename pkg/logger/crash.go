@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order-processing-microservice/pkg/config"
+)
+
+// CrashReport describes a recovered panic: the panic value, the stack of the
+// goroutine that panicked, what it was working on and the state of the
+// process at the time.
+type CrashReport struct {
+	ID         string        `json:"id"`
+	Service    string        `json:"service"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	Panic      string        `json:"panic"`
+	Stack      string        `json:"stack"`
+	Goroutines int           `json:"goroutines"`
+	Memory     MemorySummary `json:"memory"`
+	Context    logrus.Fields `json:"context,omitempty"`
+}
+
+// MemorySummary is the part of runtime.MemStats worth looking at after a
+// crash.
+type MemorySummary struct {
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapInuseBytes uint64 `json:"heap_inuse_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	SysBytes       uint64 `json:"sys_bytes"`
+	NumGC          uint32 `json:"num_gc"`
+	PauseTotalNs   uint64 `json:"pause_total_ns"`
+}
+
+// NewCrashReport captures a report of recovered. It must be called from the
+// deferred function that recovered it, for the stack to be the one that
+// panicked. Sensitive context fields are redacted.
+func NewCrashReport(service string, recovered interface{}, fields logrus.Fields) *CrashReport {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	if r := GetRedactor(); r != nil {
+		fields = r.RedactFields(fields)
+	}
+
+	return &CrashReport{
+		ID:         uuid.New().String(),
+		Service:    service,
+		OccurredAt: time.Now().UTC(),
+		Panic:      fmt.Sprint(recovered),
+		Stack:      string(debug.Stack()),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: MemorySummary{
+			HeapAllocBytes: stats.HeapAlloc,
+			HeapInuseBytes: stats.HeapInuse,
+			HeapObjects:    stats.HeapObjects,
+			SysBytes:       stats.Sys,
+			NumGC:          stats.NumGC,
+			PauseTotalNs:   stats.PauseTotalNs,
+		},
+		Context: fields,
+	}
+}
+
+// CrashReporter records the crash reports of a service. Each report is
+// written as a JSON file to the crash directory and posted to the crash
+// endpoint, if set, and summarized in the log with its ID. Without either the
+// stack is logged too, so that it is not lost.
+type CrashReporter struct {
+	service  string
+	dir      string
+	endpoint string
+	client   *http.Client
+	logger   *logrus.Entry
+}
+
+func NewCrashReporter(service string, cfg *config.LoggerConfig) *CrashReporter {
+	return &CrashReporter{
+		service:  service,
+		dir:      cfg.CrashDir,
+		endpoint: cfg.CrashEndpoint,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		logger:   logrus.WithField("component", "crash_reporter"),
+	}
+}
+
+// Report captures a report of recovered, as NewCrashReport does, and records
+// it. Failing to write or post the report is logged.
+func (r *CrashReporter) Report(recovered interface{}, fields logrus.Fields) *CrashReport {
+	report := NewCrashReport(r.service, recovered, fields)
+
+	entry := r.logger.WithFields(report.Context).WithFields(logrus.Fields{
+		"crash_id":   report.ID,
+		"panic":      report.Panic,
+		"goroutines": report.Goroutines,
+		"heap_alloc": report.Memory.HeapAllocBytes,
+	})
+
+	if r.dir != "" {
+		path, err := r.write(report)
+		if err != nil {
+			r.logger.WithError(err).WithField("crash_id", report.ID).Error("Failed to write crash report")
+		} else {
+			entry = entry.WithField("crash_file", path)
+		}
+	}
+	if r.endpoint != "" {
+		if err := r.post(report); err != nil {
+			r.logger.WithError(err).WithField("crash_id", report.ID).Error("Failed to post crash report")
+		}
+	}
+	if r.dir == "" && r.endpoint == "" {
+		entry = entry.WithField("stack", report.Stack)
+	}
+
+	entry.Error("Recovered from panic")
+	return report
+}
+
+func (r *CrashReporter) write(report *CrashReport) (string, error) {
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal crash report: %w", err)
+	}
+
+	name := fmt.Sprintf("crash-%s-%s.json", report.OccurredAt.Format("20060102T150405Z"), report.ID)
+	path := filepath.Join(r.dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	return path, nil
+}
+
+func (r *CrashReporter) post(report *CrashReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal crash report: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, r.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create crash report request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send crash report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("crash endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
\ No newline at end of file
@@ -27,6 +27,12 @@ func Init(cfg *config.LoggerConfig) {
 	}
 
 	logrus.SetOutput(os.Stdout)
+
+	if cfg.RedactEnabled {
+		redactor := NewRedactor(cfg.RedactFields, cfg.AllowFields)
+		SetRedactor(redactor)
+		logrus.AddHook(&redactionHook{redactor: redactor})
+	}
 }
 
 func WithFields(fields logrus.Fields) *logrus.Entry {
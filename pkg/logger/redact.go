@@ -0,0 +1,211 @@
+package logger
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redactedValue = "[REDACTED]"
+
+type MaskFunc func(value string) string
+
+var DefaultRedactFields = []string{
+	"customer_id",
+	"client_ip",
+	"email",
+	"address",
+	"phone",
+	"password",
+	"authorization",
+	"token",
+	"payload",
+}
+
+var fieldMasks = map[string]MaskFunc{
+	"customer_id": MaskPartial,
+	"client_ip":   MaskIP,
+	"email":       MaskEmail,
+}
+
+var alwaysAllowed = map[string]bool{
+	"component": true,
+	"error":     true,
+}
+
+type Redactor struct {
+	deny  map[string]MaskFunc
+	allow map[string]bool
+}
+
+func NewRedactor(denyFields, allowFields []string) *Redactor {
+	if len(denyFields) == 0 {
+		denyFields = DefaultRedactFields
+	}
+
+	r := &Redactor{
+		deny:  make(map[string]MaskFunc, len(denyFields)),
+		allow: make(map[string]bool, len(allowFields)),
+	}
+
+	for _, field := range denyFields {
+		key := normalizeKey(field)
+		if mask, ok := fieldMasks[key]; ok {
+			r.deny[key] = mask
+		} else {
+			r.deny[key] = MaskAll
+		}
+	}
+
+	for _, field := range allowFields {
+		r.allow[normalizeKey(field)] = true
+	}
+
+	return r
+}
+
+func (r *Redactor) IsSensitive(key string) bool {
+	key = normalizeKey(key)
+	if _, denied := r.deny[key]; denied {
+		return true
+	}
+	return len(r.allow) > 0 && !r.allow[key] && !alwaysAllowed[key]
+}
+
+func (r *Redactor) Mask(key, value string) string {
+	key = normalizeKey(key)
+	if mask, ok := r.deny[key]; ok {
+		return mask(value)
+	}
+	if r.IsSensitive(key) {
+		return MaskAll(value)
+	}
+	return value
+}
+
+func (r *Redactor) RedactFields(fields logrus.Fields) logrus.Fields {
+	redacted := make(logrus.Fields, len(fields))
+	for key, value := range fields {
+		if !r.IsSensitive(key) {
+			redacted[key] = value
+			continue
+		}
+		redacted[key] = r.Mask(key, fmt.Sprint(value))
+	}
+	return redacted
+}
+
+func (r *Redactor) RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return redactedValue
+	}
+
+	for key, vals := range values {
+		if !r.IsSensitive(key) {
+			continue
+		}
+		for i, val := range vals {
+			vals[i] = r.Mask(key, val)
+		}
+	}
+
+	return values.Encode()
+}
+
+func MaskAll(string) string {
+	return redactedValue
+}
+
+func MaskPartial(value string) string {
+	if len(value) <= 4 {
+		return redactedValue
+	}
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+}
+
+func MaskEmail(value string) string {
+	at := strings.LastIndex(value, "@")
+	if at <= 0 {
+		return redactedValue
+	}
+	return value[:1] + "***" + value[at:]
+}
+
+func MaskIP(value string) string {
+	if i := strings.LastIndex(value, "."); i > 0 {
+		return value[:i] + ".x"
+	}
+	if i := strings.LastIndex(value, ":"); i > 0 {
+		return value[:i] + ":x"
+	}
+	return redactedValue
+}
+
+func normalizeKey(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		} else if r == '-' {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type redactionHook struct {
+	redactor *Redactor
+}
+
+func (h *redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactionHook) Fire(entry *logrus.Entry) error {
+	entry.Data = h.redactor.RedactFields(entry.Data)
+	return nil
+}
+
+var (
+	redactorMu      sync.RWMutex
+	defaultRedactor *Redactor
+)
+
+func SetRedactor(r *Redactor) {
+	redactorMu.Lock()
+	defer redactorMu.Unlock()
+	defaultRedactor = r
+}
+
+func GetRedactor() *Redactor {
+	redactorMu.RLock()
+	defer redactorMu.RUnlock()
+	return defaultRedactor
+}
+
+func RedactQuery(rawQuery string) string {
+	if r := GetRedactor(); r != nil {
+		return r.RedactQuery(rawQuery)
+	}
+	return rawQuery
+}
+
+func RedactValue(key, value string) string {
+	if r := GetRedactor(); r != nil && r.IsSensitive(key) {
+		return r.Mask(key, value)
+	}
+	return value
+}
\ No newline at end of file
@@ -0,0 +1,150 @@
+// Package requestctx carries the values scoped to one API request, or to one
+// event the consumer handles, through its context: the request ID, the
+// principal that made it, its tenant and its trace.
+package requestctx
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	requestIDKey struct{}
+	principalKey struct{}
+	tenantKey    struct{}
+	traceKey     struct{}
+)
+
+// WithRequestID returns a context of the request with ID id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, empty if it has none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Principal is the caller a request is attributed to: the actor order changes
+// are audited as made by, and the ID of the API key the gateway authenticated
+// it with, if any.
+type Principal struct {
+	Actor    string
+	APIKeyID string
+}
+
+// WithPrincipal returns a context whose changes are made by principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal of ctx, empty if it has none.
+func PrincipalFrom(ctx context.Context) Principal {
+	principal, _ := ctx.Value(principalKey{}).(Principal)
+	return principal
+}
+
+// WithActor returns a context whose order changes are audited as made by
+// actor, keeping the API key of its principal.
+func WithActor(ctx context.Context, actor string) context.Context {
+	principal := PrincipalFrom(ctx)
+	principal.Actor = actor
+	return WithPrincipal(ctx, principal)
+}
+
+// Actor returns the actor of ctx, empty if it has none.
+func Actor(ctx context.Context) string {
+	return PrincipalFrom(ctx).Actor
+}
+
+// WithTenant returns a context of a request made for tenantID.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// Tenant returns the tenant of ctx, empty if it has none.
+func Tenant(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID
+}
+
+// Trace ties the logs of one order together across services: the W3C
+// traceparent of the current span and the ID of the request that started the
+// flow.
+type Trace struct {
+	Traceparent   string
+	CorrelationID string
+}
+
+// WithTrace returns a context whose published events carry trace.
+func WithTrace(ctx context.Context, trace Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// TraceFrom returns the trace of ctx, empty if it has none.
+func TraceFrom(ctx context.Context) Trace {
+	trace, _ := ctx.Value(traceKey{}).(Trace)
+	return trace
+}
+
+// TraceID returns the trace ID of the traceparent, or "" if it is not valid.
+func (t Trace) TraceID() string {
+	if !ValidTraceparent(t.Traceparent) {
+		return ""
+	}
+	return strings.Split(t.Traceparent, "-")[1]
+}
+
+// LogFields returns the trace and correlation IDs to log, omitting empty ones.
+func (t Trace) LogFields() logrus.Fields {
+	fields := logrus.Fields{}
+	if traceID := t.TraceID(); traceID != "" {
+		fields["trace_id"] = traceID
+	}
+	if t.CorrelationID != "" {
+		fields["correlation_id"] = t.CorrelationID
+	}
+	return fields
+}
+
+// ValidTraceparent reports whether traceparent is a version 00 W3C
+// traceparent, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func ValidTraceparent(traceparent string) bool {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return false
+	}
+	return isLowerHex(parts[1], 32) && isLowerHex(parts[2], 16) && isLowerHex(parts[3], 2) &&
+		strings.Trim(parts[1], "0") != "" && strings.Trim(parts[2], "0") != ""
+}
+
+// LogFields returns the request ID, tenant, actor and trace of ctx to log,
+// omitting empty ones.
+func LogFields(ctx context.Context) logrus.Fields {
+	fields := TraceFrom(ctx).LogFields()
+	if id := RequestID(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	if tenantID := Tenant(ctx); tenantID != "" {
+		fields["tenant_id"] = tenantID
+	}
+	if actor := Actor(ctx); actor != "" {
+		fields["actor"] = actor
+	}
+	return fields
+}
+
+func isLowerHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
\ No newline at end of file
@@ -2,10 +2,13 @@ package utils
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+const TotalCountHeader = "X-Total-Count"
+
 type ErrorResponse struct {
 	Error   string      `json:"error"`
 	Message string      `json:"message,omitempty"`
@@ -19,6 +22,45 @@ type SuccessResponse struct {
 	Meta    interface{} `json:"meta,omitempty"`
 }
 
+type PaginationMeta struct {
+	Limit      int    `json:"limit"`
+	Offset     *int   `json:"offset,omitempty"`
+	Cursor     string `json:"cursor,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+	Count      int    `json:"count"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// NewOffsetMeta describes a limit/offset page. Without a total, HasMore is a
+// guess based on whether the page came back full.
+func NewOffsetMeta(limit, offset, count int) *PaginationMeta {
+	return &PaginationMeta{
+		Limit:   limit,
+		Offset:  &offset,
+		Count:   count,
+		HasMore: count >= limit,
+	}
+}
+
+func NewCursorMeta(limit int, cursor, nextCursor string, count int, hasMore bool) *PaginationMeta {
+	return &PaginationMeta{
+		Limit:      limit,
+		Cursor:     cursor,
+		NextCursor: nextCursor,
+		Count:      count,
+		HasMore:    hasMore,
+	}
+}
+
+func (m *PaginationMeta) WithTotal(total int64) *PaginationMeta {
+	m.Total = &total
+	if m.Offset != nil {
+		m.HasMore = int64(*m.Offset+m.Count) < total
+	}
+	return m
+}
+
 func RespondWithError(c *gin.Context, code int, err error, message ...string) {
 	var msg string
 	if len(message) > 0 {
@@ -48,6 +90,35 @@ func RespondWithSuccess(c *gin.Context, data interface{}, message ...string) {
 	c.JSON(http.StatusOK, response)
 }
 
+func RespondWithList(c *gin.Context, data interface{}, meta *PaginationMeta) {
+	if meta != nil && meta.Total != nil {
+		c.Header(TotalCountHeader, strconv.FormatInt(*meta.Total, 10))
+	}
+
+	response := SuccessResponse{
+		Data: data,
+		Meta: meta,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+type CountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// RespondWithCount serves count endpoints and HEAD requests on list routes.
+// The count is always sent in X-Total-Count; HEAD responses have no body.
+func RespondWithCount(c *gin.Context, count int64) {
+	c.Header(TotalCountHeader, strconv.FormatInt(count, 10))
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	RespondWithSuccess(c, CountResponse{Count: count})
+}
+
 func RespondWithCreated(c *gin.Context, data interface{}, message ...string) {
 	var msg string
 	if len(message) > 0 {
@@ -62,6 +133,20 @@ func RespondWithCreated(c *gin.Context, data interface{}, message ...string) {
 	c.JSON(http.StatusCreated, response)
 }
 
+func RespondWithAccepted(c *gin.Context, data interface{}, message ...string) {
+	var msg string
+	if len(message) > 0 {
+		msg = message[0]
+	}
+
+	response := SuccessResponse{
+		Data:    data,
+		Message: msg,
+	}
+
+	c.JSON(http.StatusAccepted, response)
+}
+
 func RespondWithValidationError(c *gin.Context, err error) {
 	response := ErrorResponse{
 		Error:   "Validation failed",
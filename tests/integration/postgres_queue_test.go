@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+var queueDatabase = config.DatabaseConfig{
+	Host:     "localhost",
+	Port:     5432,
+	Username: "postgres",
+	Password: "postgres",
+	Database: "orders_db",
+	SSLMode:  "disable",
+}
+
+func TestPostgresQueue_LateCommitIsNotSkipped(t *testing.T) {
+	// Skip if not running integration tests
+	if testing.Short() {
+		t.Skip("Skipping integration tests")
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", queueDatabase.GetDSN())
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.PingContext(ctx), "Postgres should be available")
+
+	topic := "queue-test-" + uuid.NewString()
+	kafkaCfg := &config.KafkaConfig{OrderTopic: topic, GroupID: "queue-test-" + uuid.NewString()}
+
+	// The first event's transaction takes its queue ID and commits after the
+	// second event is published.
+	late := models.NewEvent(models.OrderCreatedEvent, nil)
+	payload, err := queue.JSONCodec{}.Marshal(late)
+	require.NoError(t, err)
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO event_queue (topic, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+	`, topic, late.ID, late.Type, payload)
+	require.NoError(t, err)
+
+	producer, err := queue.NewPostgresProducer(db, kafkaCfg)
+	require.NoError(t, err)
+	early := models.NewEvent(models.OrderCreatedEvent, nil)
+	require.NoError(t, producer.PublishEventToTopic(ctx, topic, early))
+
+	consumer, err := queue.NewPostgresConsumer(db, queueDatabase.GetDSN(), kafkaCfg,
+		&config.QueueConfig{PollInterval: 1, BatchSize: 10}, []string{topic})
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	handled := make(chan uuid.UUID, 2)
+	require.NoError(t, consumer.Subscribe(ctx, queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		handled <- event.ID
+		return nil
+	})))
+
+	select {
+	case id := <-handled:
+		t.Fatalf("event %s handled while an older transaction was in flight", id)
+	case <-time.After(3 * time.Second):
+	}
+
+	require.NoError(t, tx.Commit())
+
+	var ids []uuid.UUID
+	for len(ids) < 2 {
+		select {
+		case id := <-handled:
+			ids = append(ids, id)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("handled %d of 2 events", len(ids))
+		}
+	}
+	assert.Equal(t, []uuid.UUID{late.ID, early.ID}, ids)
+}
+
+func TestPostgresQueue_HandlerDoesNotHoldBackOtherGroups(t *testing.T) {
+	// Skip if not running integration tests
+	if testing.Short() {
+		t.Skip("Skipping integration tests")
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", queueDatabase.GetDSN())
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.PingContext(ctx), "Postgres should be available")
+
+	topic := "queue-test-" + uuid.NewString()
+	queueCfg := &config.QueueConfig{PollInterval: 1, BatchSize: 10}
+	producer, err := queue.NewPostgresProducer(db, &config.KafkaConfig{OrderTopic: topic})
+	require.NoError(t, err)
+	first := models.NewEvent(models.OrderCreatedEvent, nil)
+	require.NoError(t, producer.PublishEventToTopic(ctx, topic, first))
+
+	// The first group is still handling the first event when the second is
+	// published.
+	slow, err := queue.NewPostgresConsumer(db, queueDatabase.GetDSN(),
+		&config.KafkaConfig{OrderTopic: topic, GroupID: "queue-test-" + uuid.NewString()}, queueCfg, []string{topic})
+	require.NoError(t, err)
+	defer slow.Close()
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	require.NoError(t, slow.Subscribe(ctx, queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		if event.ID == first.ID {
+			close(started)
+			<-release
+		}
+		return nil
+	})))
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("first event not handled")
+	}
+	second := models.NewEvent(models.OrderCreatedEvent, nil)
+	require.NoError(t, producer.PublishEventToTopic(ctx, topic, second))
+
+	other, err := queue.NewPostgresConsumer(db, queueDatabase.GetDSN(),
+		&config.KafkaConfig{OrderTopic: topic, GroupID: "queue-test-" + uuid.NewString()}, queueCfg, []string{topic})
+	require.NoError(t, err)
+	defer other.Close()
+	handled := make(chan uuid.UUID, 2)
+	require.NoError(t, other.Subscribe(ctx, queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		handled <- event.ID
+		return nil
+	})))
+
+	var ids []uuid.UUID
+	for len(ids) < 2 {
+		select {
+		case id := <-handled:
+			ids = append(ids, id)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("other group handled %d of 2 events while the first group was busy", len(ids))
+		}
+	}
+	assert.Equal(t, []uuid.UUID{first.ID, second.ID}, ids)
+}
\ No newline at end of file
@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/database"
+)
+
+func TestProjectionBuilder_ResetMigratedSchema(t *testing.T) {
+	// Skip if not running integration tests
+	if testing.Short() {
+		t.Skip("Skipping integration tests")
+	}
+
+	ctx := context.Background()
+	cfg := queueDatabase
+	cfg.Schema = "projection_test_" + uuid.New().String()[:8]
+	db, err := database.NewPostgresDB(&cfg)
+	require.NoError(t, err, "Postgres should be available")
+	defer db.Close()
+	defer db.GetDB().ExecContext(ctx, `DROP SCHEMA `+cfg.Schema+` CASCADE`)
+	require.NoError(t, db.Migrate(ctx))
+
+	// An order with a row in every table referencing orders.
+	orderID := uuid.New()
+	for _, statement := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{`INSERT INTO orders (id, customer_id) VALUES ($1, $2)`, []interface{}{orderID, uuid.New()}},
+		{`INSERT INTO order_items (id, order_id, product_id, quantity, price) VALUES ($1, $2, $3, 1, 10)`, []interface{}{uuid.New(), orderID, uuid.New()}},
+		{`INSERT INTO order_sagas (id, order_id, step, status, correlation_id, deadline) VALUES ($1, $2, 'payment', 'running', $3, NOW())`, []interface{}{uuid.New(), orderID, uuid.New()}},
+		{`INSERT INTO order_compensations (id, order_id, target_status, error, status) VALUES ($1, $2, 'failed', 'publish failed', 'pending')`, []interface{}{uuid.New(), orderID}},
+		{`INSERT INTO order_item_changes (id, order_id, item_id, product_id, previous_quantity, quantity, quantity_delta, order_version) VALUES ($1, $2, $3, $4, 1, 2, 1, 2)`, []interface{}{uuid.New(), orderID, uuid.New(), uuid.New()}},
+	} {
+		_, err := db.GetDB().ExecContext(ctx, statement.query, statement.args...)
+		require.NoError(t, err, statement.query)
+	}
+
+	repo := repository.NewPostgresProjectionRepository(db.GetDB())
+	require.NoError(t, services.NewProjectionBuilder(repo).Reset(ctx))
+
+	for _, table := range []string{"orders", "order_items", "order_sagas", "order_compensations", "order_item_changes"} {
+		var count int
+		require.NoError(t, db.GetDB().QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table).Scan(&count))
+		assert.Zero(t, count, table)
+	}
+
+	// The reset order can be projected again.
+	order := &models.Order{ID: orderID, CustomerID: uuid.New(), Status: models.OrderStatusPending, Version: 1}
+	require.NoError(t, repo.UpsertOrder(ctx, order))
+}
\ No newline at end of file
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/cache"
+	"order-processing-microservice/internal/models"
+)
+
+// countingLoader loads every order as pending and counts the loads.
+type countingLoader struct {
+	loads int
+	err   error
+}
+
+func (l *countingLoader) load(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	l.loads++
+	if l.err != nil {
+		return nil, l.err
+	}
+	return &models.Order{ID: id, Status: models.OrderStatusPending, UpdatedAt: time.Now()}, nil
+}
+
+func TestOrderStatusCache_GetLoadsOnMiss(t *testing.T) {
+	loader := &countingLoader{}
+	statusCache := cache.NewOrderStatusCache(10, loader.load)
+	id := uuid.New()
+
+	entry, err := statusCache.Get(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPending, entry.Status)
+
+	_, err = statusCache.Get(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loader.loads)
+
+	stats := statusCache.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 1, stats.Entries)
+}
+
+func TestOrderStatusCache_GetLoaderError(t *testing.T) {
+	loader := &countingLoader{err: errors.New("database unavailable")}
+	statusCache := cache.NewOrderStatusCache(10, loader.load)
+
+	_, err := statusCache.Get(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, loader.err)
+	assert.Zero(t, statusCache.Stats().Entries, "a failed load is not cached")
+}
+
+func TestOrderStatusCache_UpdateKeepsNewest(t *testing.T) {
+	statusCache := cache.NewOrderStatusCache(10, (&countingLoader{}).load)
+	id := uuid.New()
+	now := time.Now()
+
+	statusCache.Update(cache.OrderStatusEntry{OrderID: id, Status: models.OrderStatusProcessing, UpdatedAt: now, Sequence: 2})
+	// Sequences win over skewed timestamps.
+	statusCache.Update(cache.OrderStatusEntry{OrderID: id, Status: models.OrderStatusPending, UpdatedAt: now.Add(time.Minute), Sequence: 1})
+	entry, err := statusCache.Get(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusProcessing, entry.Status)
+
+	// Without a sequence on both sides the timestamp decides.
+	statusCache.Update(cache.OrderStatusEntry{OrderID: id, Status: models.OrderStatusPending, UpdatedAt: now.Add(-time.Minute)})
+	entry, err = statusCache.Get(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusProcessing, entry.Status)
+
+	statusCache.Update(cache.OrderStatusEntry{OrderID: id, Status: models.OrderStatusCompleted, UpdatedAt: now.Add(time.Minute)})
+	entry, err = statusCache.Get(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusCompleted, entry.Status)
+}
+
+func TestOrderStatusCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	loader := &countingLoader{}
+	statusCache := cache.NewOrderStatusCache(2, loader.load)
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+	ctx := context.Background()
+
+	for _, id := range []uuid.UUID{first, second} {
+		_, err := statusCache.Get(ctx, id)
+		require.NoError(t, err)
+	}
+	// Using first makes second the least recently used.
+	_, err := statusCache.Get(ctx, first)
+	require.NoError(t, err)
+	_, err = statusCache.Get(ctx, third)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1), statusCache.Stats().Evictions)
+	loads := loader.loads
+	_, err = statusCache.Get(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, loads, loader.loads, "first is still cached")
+	_, err = statusCache.Get(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, loads+1, loader.loads, "second was evicted")
+}
+
+func TestOrderStatusCache_HandleEvent(t *testing.T) {
+	loader := &countingLoader{}
+	statusCache := cache.NewOrderStatusCache(10, loader.load)
+	orderID := uuid.New()
+	ctx := context.Background()
+
+	event := models.NewEvent(models.OrderStatusChangedEvent, map[string]interface{}{
+		"order_id":   orderID.String(),
+		"new_status": "processing",
+	})
+	event.Sequence = 3
+	require.NoError(t, statusCache.HandleEvent(ctx, event))
+
+	entry, err := statusCache.Get(ctx, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, cache.OrderStatusEntry{
+		OrderID:   orderID,
+		Status:    models.OrderStatusProcessing,
+		UpdatedAt: event.Timestamp,
+		Sequence:  3,
+	}, entry)
+	assert.Zero(t, loader.loads)
+
+	// An event that does not tell the status drops the entry.
+	adjusted := models.NewEvent(models.OrderAdjustedEvent, map[string]interface{}{"order_id": orderID.String()})
+	require.NoError(t, statusCache.HandleEvent(ctx, adjusted))
+	_, err = statusCache.Get(ctx, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loader.loads)
+
+	invalid := models.NewEvent(models.OrderCompletedEvent, map[string]interface{}{"order_id": "not-a-uuid"})
+	assert.Error(t, statusCache.HandleEvent(ctx, invalid))
+}
\ No newline at end of file
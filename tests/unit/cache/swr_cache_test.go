@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/cache"
+	"order-processing-microservice/pkg/config"
+)
+
+// sequenceLoader returns 1, 2, 3, ... on successive loads, or err if set.
+type sequenceLoader struct {
+	loads atomic.Int32
+	err   error
+}
+
+func (l *sequenceLoader) load(ctx context.Context) (interface{}, error) {
+	n := l.loads.Add(1)
+	if l.err != nil {
+		return nil, l.err
+	}
+	return int(n), nil
+}
+
+func TestSWRCache_FreshEntryIsHit(t *testing.T) {
+	swr := cache.NewSWRCache(&config.CacheConfig{TTL: 60, StaleTTL: 60, RefreshTimeout: 5})
+	loader := &sequenceLoader{}
+
+	value, state, err := swr.Get(context.Background(), "stats", loader.load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, cache.StateMiss, state)
+
+	value, state, err = swr.Get(context.Background(), "stats", loader.load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, cache.StateHit, state)
+	assert.Equal(t, int32(1), loader.loads.Load())
+
+	stats := swr.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 1, stats.Entries)
+}
+
+func TestSWRCache_StaleEntryIsServedAndRefreshed(t *testing.T) {
+	// A zero TTL makes every entry stale as soon as it is stored.
+	swr := cache.NewSWRCache(&config.CacheConfig{TTL: 0, StaleTTL: 60, RefreshTimeout: 5})
+	loader := &sequenceLoader{}
+
+	_, _, err := swr.Get(context.Background(), "stats", loader.load)
+	require.NoError(t, err)
+
+	value, state, err := swr.Get(context.Background(), "stats", loader.load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value, "the stale value is served without waiting for the refresh")
+	assert.Equal(t, cache.StateStale, state)
+
+	assert.Eventually(t, func() bool {
+		value, _, _ := swr.Get(context.Background(), "stats", func(ctx context.Context) (interface{}, error) {
+			return nil, errors.New("only refreshes may load")
+		})
+		return value == 2
+	}, time.Second, 10*time.Millisecond, "the refreshed value replaces the stale one")
+	assert.GreaterOrEqual(t, swr.Stats().Refreshes, uint64(1))
+	assert.Equal(t, uint64(1), swr.Stats().Misses)
+}
+
+func TestSWRCache_FailedRefreshKeepsStaleValue(t *testing.T) {
+	swr := cache.NewSWRCache(&config.CacheConfig{TTL: 0, StaleTTL: 60, RefreshTimeout: 5})
+	loader := &sequenceLoader{}
+	_, _, err := swr.Get(context.Background(), "stats", loader.load)
+	require.NoError(t, err)
+
+	failing := &sequenceLoader{err: errors.New("database unavailable")}
+	value, state, err := swr.Get(context.Background(), "stats", failing.load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, cache.StateStale, state)
+
+	assert.Eventually(t, func() bool { return swr.Stats().RefreshErrors == 1 }, time.Second, 10*time.Millisecond)
+	value, _, err = swr.Get(context.Background(), "stats", loader.load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
+
+func TestSWRCache_ExpiredEntryIsLoaded(t *testing.T) {
+	// Without a TTL or stale TTL entries expire as soon as they are stored.
+	swr := cache.NewSWRCache(&config.CacheConfig{TTL: 0, StaleTTL: 0, RefreshTimeout: 5})
+	loader := &sequenceLoader{}
+
+	for want := 1; want <= 2; want++ {
+		value, state, err := swr.Get(context.Background(), "stats", loader.load)
+		require.NoError(t, err)
+		assert.Equal(t, want, value)
+		assert.Equal(t, cache.StateMiss, state)
+	}
+	assert.Equal(t, uint64(2), swr.Stats().Misses)
+}
+
+func TestSWRCache_LoaderErrorIsNotCached(t *testing.T) {
+	swr := cache.NewSWRCache(&config.CacheConfig{TTL: 60, StaleTTL: 60, RefreshTimeout: 5})
+	failing := &sequenceLoader{err: errors.New("database unavailable")}
+
+	_, state, err := swr.Get(context.Background(), "stats", failing.load)
+	assert.ErrorIs(t, err, failing.err)
+	assert.Equal(t, cache.StateMiss, state)
+	assert.Zero(t, swr.Stats().Entries)
+}
+
+func TestSWRCache_EvictsOldestOverMaxEntries(t *testing.T) {
+	swr := cache.NewSWRCache(&config.CacheConfig{TTL: 60, StaleTTL: 60, RefreshTimeout: 5, MaxEntries: 2})
+	loader := &sequenceLoader{}
+
+	for _, key := range []string{"first", "second", "third"} {
+		_, _, err := swr.Get(context.Background(), key, loader.load)
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, 2, swr.Stats().Entries)
+	assert.Equal(t, uint64(1), swr.Stats().Evictions)
+	_, state, err := swr.Get(context.Background(), "first", loader.load)
+	require.NoError(t, err)
+	assert.Equal(t, cache.StateMiss, state, "the oldest entry was evicted")
+}
+
+func TestSWRCache_NilCacheBypasses(t *testing.T) {
+	var swr *cache.SWRCache
+	loader := &sequenceLoader{}
+
+	value, state, err := swr.Get(context.Background(), "stats", loader.load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, cache.StateBypass, state)
+	assert.Equal(t, cache.Stats{}, swr.Stats())
+}
\ No newline at end of file
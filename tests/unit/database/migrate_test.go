@@ -0,0 +1,47 @@
+package database
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/pkg/database"
+)
+
+const migrationsDir = "../../../pkg/database/migrations"
+
+func TestSchemaVersion_IsNewestMigration(t *testing.T) {
+	entries, err := os.ReadDir(migrationsDir)
+	require.NoError(t, err)
+
+	var newest uint64
+	for _, entry := range entries {
+		version, err := strconv.ParseUint(strings.SplitN(entry.Name(), "_", 2)[0], 10, 64)
+		require.NoError(t, err, entry.Name())
+		newest = max(newest, version)
+	}
+
+	version, err := database.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, uint(newest), version)
+}
+
+func TestMigrations_EveryUpHasDown(t *testing.T) {
+	entries, err := os.ReadDir(migrationsDir)
+	require.NoError(t, err)
+
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+	for name := range names {
+		if base, ok := strings.CutSuffix(name, ".up.sql"); ok {
+			assert.True(t, names[base+".down.sql"], "%s has no down migration", name)
+			continue
+		}
+		assert.True(t, strings.HasSuffix(name, ".down.sql"), "%s is not a migration", name)
+	}
+}
\ No newline at end of file
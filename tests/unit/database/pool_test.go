@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/database"
+)
+
+// recordingConn records the statements it is sent, and those of the
+// transactions it begins.
+type recordingConn struct {
+	queries []string
+}
+
+func (c *recordingConn) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	c.queries = append(c.queries, query)
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *recordingConn) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	c.queries = append(c.queries, query)
+	return nil, nil
+}
+
+func (c *recordingConn) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	c.queries = append(c.queries, query)
+	return nil
+}
+
+func (c *recordingConn) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &recordingTx{conn: c}, nil
+}
+
+type recordingTx struct {
+	pgx.Tx
+	conn *recordingConn
+}
+
+func (t *recordingTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &recordingTx{conn: t.conn}, nil
+}
+
+func (t *recordingTx) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	return t.conn.Exec(ctx, query, args...)
+}
+
+func (t *recordingTx) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	return t.conn.Query(ctx, query, args...)
+}
+
+func (t *recordingTx) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return t.conn.QueryRow(ctx, query, args...)
+}
+
+// runStatements sends a statement through each method of q.
+func runStatements(t *testing.T, ctx context.Context, q interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+}) {
+	t.Helper()
+
+	_, err := q.Exec(ctx, "DELETE FROM orders WHERE id = $1")
+	require.NoError(t, err)
+	_, err = q.Query(ctx, "SELECT id FROM order_items")
+	require.NoError(t, err)
+	q.QueryRow(ctx, "SELECT COUNT(*) FROM orders")
+}
+
+func TestPool_RewritesStatements(t *testing.T) {
+	conn := &recordingConn{}
+	pool, err := database.NewPoolWithConn(conn, &config.DatabaseConfig{TablePrefix: "east_", QueryComments: true})
+	require.NoError(t, err)
+	ctx := database.WithQueryTag(context.Background(), "request_id", "req-1")
+
+	runStatements(t, ctx, pool)
+
+	assert.Equal(t, []string{
+		"/*request_id='req-1'*/ DELETE FROM east_orders WHERE id = $1",
+		"/*request_id='req-1'*/ SELECT id FROM east_order_items",
+		"/*request_id='req-1'*/ SELECT COUNT(*) FROM east_orders",
+	}, conn.queries)
+}
+
+func TestPool_RewritesTransactionsAndSavepoints(t *testing.T) {
+	conn := &recordingConn{}
+	pool, err := database.NewPoolWithConn(conn, &config.DatabaseConfig{TablePrefix: "east_"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	runStatements(t, ctx, tx)
+
+	savepoint, err := tx.Begin(ctx)
+	require.NoError(t, err)
+	runStatements(t, ctx, savepoint)
+
+	rewritten := []string{
+		"DELETE FROM east_orders WHERE id = $1",
+		"SELECT id FROM east_order_items",
+		"SELECT COUNT(*) FROM east_orders",
+	}
+	assert.Equal(t, append(rewritten, rewritten...), conn.queries)
+}
+
+func TestPool_WithoutRewriting(t *testing.T) {
+	conn := &recordingConn{}
+	pool, err := database.NewPoolWithConn(conn, &config.DatabaseConfig{})
+	require.NoError(t, err)
+	// Tags are ignored unless query comments are enabled.
+	ctx := database.WithQueryTag(context.Background(), "request_id", "req-1")
+
+	runStatements(t, ctx, pool)
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	assert.IsType(t, &recordingTx{}, tx, "transactions are not wrapped when nothing is rewritten")
+
+	assert.Equal(t, []string{
+		"DELETE FROM orders WHERE id = $1",
+		"SELECT id FROM order_items",
+		"SELECT COUNT(*) FROM orders",
+	}, conn.queries)
+}
+
+func TestNewPoolWithConn_RejectsInvalidPrefix(t *testing.T) {
+	_, err := database.NewPoolWithConn(&recordingConn{}, &config.DatabaseConfig{TablePrefix: "East-"})
+	assert.Error(t, err)
+}
\ No newline at end of file
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/pkg/database"
+)
+
+func TestAnnotateQuery_WithoutTags(t *testing.T) {
+	assert.Equal(t, "SELECT 1", database.AnnotateQuery(context.Background(), "SELECT 1"))
+}
+
+func TestAnnotateQuery_SortsTags(t *testing.T) {
+	ctx := database.WithQueryTag(context.Background(), "request_id", "20240101-req")
+	ctx = database.WithQueryTag(ctx, "event_id", "1f0c")
+
+	assert.Equal(t, "/*event_id='1f0c',request_id='20240101-req'*/ SELECT 1", database.AnnotateQuery(ctx, "SELECT 1"))
+}
+
+func TestAnnotateQuery_ReplacesAndSkipsEmptyTags(t *testing.T) {
+	ctx := database.WithQueryTag(context.Background(), "request_id", "first")
+	ctx = database.WithQueryTag(ctx, "request_id", "second")
+	ctx = database.WithQueryTag(ctx, "traceparent", "")
+
+	assert.Equal(t, "/*request_id='second'*/ SELECT 1", database.AnnotateQuery(ctx, "SELECT 1"))
+}
+
+func TestAnnotateQuery_EscapesValues(t *testing.T) {
+	ctx := database.WithQueryTag(context.Background(), "request_id", "x'*/ DROP TABLE orders; /*")
+
+	annotated := database.AnnotateQuery(ctx, "SELECT 1")
+	assert.Equal(t, "/*request_id='x%27%2A%2F+DROP+TABLE+orders%3B+%2F%2A'*/ SELECT 1", annotated)
+}
\ No newline at end of file
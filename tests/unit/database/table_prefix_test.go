@@ -0,0 +1,46 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/pkg/database"
+)
+
+func TestTableRewriter_PrefixesTablesAndIndexes(t *testing.T) {
+	rewriter, err := database.NewTableRewriter("east_")
+	require.NoError(t, err)
+
+	query := `SELECT o.id, COUNT(oi.id) FROM orders o JOIN order_items oi ON oi.order_id = o.id WHERE o.status = $1 GROUP BY o.id`
+	assert.Equal(t,
+		`SELECT o.id, COUNT(oi.id) FROM east_orders o JOIN east_order_items oi ON oi.order_id = o.id WHERE o.status = $1 GROUP BY o.id`,
+		rewriter.Rewrite(query))
+
+	assert.Equal(t,
+		`CREATE INDEX IF NOT EXISTS east_idx_orders_status ON east_orders(status);`,
+		rewriter.Rewrite(`CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);`))
+
+	assert.Equal(t,
+		`UPDATE east_message_failures SET failures = east_message_failures.failures + 1`,
+		rewriter.Rewrite(`UPDATE message_failures SET failures = message_failures.failures + 1`))
+}
+
+func TestTableRewriter_LeavesLiteralsAndCommentsAlone(t *testing.T) {
+	rewriter, err := database.NewTableRewriter("east_")
+	require.NoError(t, err)
+
+	query := `/* orders */ SELECT 'orders', "orders", 'it''s orders' FROM orders -- orders` + "\nWHERE order_events_count > 0"
+	assert.Equal(t,
+		`/* orders */ SELECT 'orders', "orders", 'it''s orders' FROM east_orders -- orders`+"\nWHERE order_events_count > 0",
+		rewriter.Rewrite(query))
+}
+
+func TestTableRewriter_RejectsInvalidPrefix(t *testing.T) {
+	_, err := database.NewTableRewriter("East-")
+	assert.Error(t, err)
+
+	_, err = database.NewTableRewriter(strings.Repeat("a", 30))
+	assert.ErrorContains(t, err, "longer than")
+}
\ No newline at end of file
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/handlers"
+	"order-processing-microservice/internal/queue"
+)
+
+// failingConsumer fails every pause, resume and cluster switch with err.
+type failingConsumer struct {
+	err error
+}
+
+func (c *failingConsumer) Pause(topic string, partitions []int32) error {
+	return c.err
+}
+
+func (c *failingConsumer) Resume(topic string, partitions []int32) error {
+	return c.err
+}
+
+func (c *failingConsumer) Paused() map[string][]int32 {
+	return nil
+}
+
+func (c *failingConsumer) SwitchCluster(cluster string) error {
+	return c.err
+}
+
+func (c *failingConsumer) Cluster() queue.ConsumerCluster {
+	return queue.ConsumerCluster{}
+}
+
+func postConsumerAdmin(consumer *failingConsumer, path, body string) int {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewConsumerAdminHandlers()
+	h.RegisterConsumer("orders", consumer)
+	h.RegisterClusterSwitch("orders", consumer)
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/consumers/orders/"+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, req)
+	return recorder.Code
+}
+
+func TestConsumerAdminHandlers_MapsPauseErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("%w: payments", queue.ErrTopicNotConsumed), http.StatusBadRequest},
+		{fmt.Errorf("%w and must be resumed as a whole: orders", queue.ErrTopicPausedAsWhole), http.StatusConflict},
+		{errors.New("consumer closed"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.status, postConsumerAdmin(&failingConsumer{err: tt.err}, "resume", `{"topic":"orders"}`), tt.err.Error())
+	}
+}
+
+func TestConsumerAdminHandlers_MapsClusterSwitchErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("%w %q", queue.ErrUnknownCluster, "tertiary"), http.StatusBadRequest},
+		{queue.ErrClusterSwitchDisabled, http.StatusNotImplemented},
+		{fmt.Errorf("failed to connect to secondary Kafka cluster: %w", queue.ErrBrokerUnavailable), http.StatusServiceUnavailable},
+		{errors.New("consumer closed"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.status, postConsumerAdmin(&failingConsumer{err: tt.err}, "cluster", `{"cluster":"secondary"}`), tt.err.Error())
+	}
+}
\ No newline at end of file
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/cache"
+	"order-processing-microservice/internal/handlers"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+)
+
+// newLiveStreamServer serves the status routes with a status cache loading
+// the orders in orders.
+func newLiveStreamServer(t *testing.T, orders map[uuid.UUID]*models.Order, interval time.Duration) (*httptest.Server, *cache.OrderStatusCache) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	statusCache := cache.NewOrderStatusCache(10, func(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+		order, ok := orders[id]
+		if !ok {
+			return nil, repository.ErrOrderNotFound
+		}
+		return order, nil
+	})
+	router := gin.New()
+	handlers.NewStatusHandlers(nil, nil, statusCache, interval).RegisterRoutes(router)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, statusCache
+}
+
+func getLiveStream(t *testing.T, server *httptest.Server, id string) *http.Response {
+	t.Helper()
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(server.URL + "/api/v1/status/live/" + id)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestStreamOrderStatus_TerminalOrderEndsStream(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusCompleted, UpdatedAt: time.Now()}
+	server, _ := newLiveStreamServer(t, map[uuid.UUID]*models.Order{order.ID: order}, time.Second)
+
+	resp := getLiveStream(t, server, order.ID.String())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(body), "event:status"))
+	assert.Contains(t, string(body), `"status":"completed"`)
+}
+
+func TestStreamOrderStatus_SendsChangesUntilTerminal(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending, UpdatedAt: time.Now()}
+	// A zero interval falls back to the default rather than panicking.
+	server, statusCache := newLiveStreamServer(t, map[uuid.UUID]*models.Order{order.ID: order}, 0)
+
+	resp := getLiveStream(t, server, order.ID.String())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if strings.HasPrefix(line, "data:") {
+			assert.Contains(t, line, `"status":"pending"`)
+			break
+		}
+	}
+
+	statusCache.Update(cache.OrderStatusEntry{
+		OrderID:   order.ID,
+		Status:    models.OrderStatusCompleted,
+		UpdatedAt: order.UpdatedAt.Add(time.Second),
+	})
+
+	rest, err := io.ReadAll(reader)
+	require.NoError(t, err, "the stream ends once the order is terminal")
+	assert.Equal(t, 1, strings.Count(string(rest), "event:status"))
+	assert.Contains(t, string(rest), `"status":"completed"`)
+}
+
+func TestStreamOrderStatus_Errors(t *testing.T) {
+	server, _ := newLiveStreamServer(t, nil, time.Second)
+
+	assert.Equal(t, http.StatusBadRequest, getLiveStream(t, server, "not-a-uuid").StatusCode)
+	assert.Equal(t, http.StatusNotFound, getLiveStream(t, server, uuid.NewString()).StatusCode)
+}
\ No newline at end of file
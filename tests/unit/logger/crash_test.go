@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/logger"
+)
+
+func TestNewCrashReport_CapturesStackAndRedactsContext(t *testing.T) {
+	logger.SetRedactor(logger.NewRedactor(nil, nil))
+	defer logger.SetRedactor(nil)
+
+	var report *logger.CrashReport
+	func() {
+		defer func() {
+			report = logger.NewCrashReport("consumer", recover(), logrus.Fields{
+				"event_id": "evt-1",
+				"password": "hunter2",
+			})
+		}()
+		panic("boom")
+	}()
+
+	assert.Equal(t, "consumer", report.Service)
+	assert.Equal(t, "boom", report.Panic)
+	assert.Contains(t, report.Stack, "TestNewCrashReport_CapturesStackAndRedactsContext")
+	assert.Positive(t, report.Goroutines)
+	assert.Positive(t, report.Memory.SysBytes)
+	assert.Equal(t, "evt-1", report.Context["event_id"])
+	assert.Equal(t, "[REDACTED]", report.Context["password"])
+}
+
+func TestCrashReporter_WritesAndPostsReport(t *testing.T) {
+	var posted logger.CrashReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	reporter := logger.NewCrashReporter("producer-api", &config.LoggerConfig{CrashDir: dir, CrashEndpoint: server.URL})
+	report := reporter.Report("nil map", logrus.Fields{"route": "/api/v1/orders"})
+
+	files, err := filepath.Glob(filepath.Join(dir, "crash-*-"+report.ID+".json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	var written logger.CrashReport
+	require.NoError(t, json.Unmarshal(data, &written))
+
+	assert.Equal(t, report.ID, written.ID)
+	assert.Equal(t, "nil map", written.Panic)
+	assert.Equal(t, "/api/v1/orders", written.Context["route"])
+	assert.Equal(t, report.ID, posted.ID)
+}
\ No newline at end of file
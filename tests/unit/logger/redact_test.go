@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/pkg/logger"
+)
+
+func TestRedactor_RedactFields(t *testing.T) {
+	redactor := logger.NewRedactor(nil, nil)
+
+	fields := logrus.Fields{
+		"customer_id": "123e4567-e89b-12d3-a456-426614174000",
+		"client_ip":   "192.168.1.42",
+		"password":    "hunter2",
+		"order_id":    "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+	}
+
+	redacted := redactor.RedactFields(fields)
+
+	assert.Equal(t, strings.Repeat("*", 32)+"4000", redacted["customer_id"])
+	assert.Equal(t, "192.168.1.x", redacted["client_ip"])
+	assert.Equal(t, "[REDACTED]", redacted["password"])
+	assert.Equal(t, fields["order_id"], redacted["order_id"])
+	assert.Equal(t, "hunter2", fields["password"], "original fields must not be mutated")
+}
+
+func TestRedactor_AllowList(t *testing.T) {
+	redactor := logger.NewRedactor(nil, []string{"order_id", "status"})
+
+	redacted := redactor.RedactFields(logrus.Fields{
+		"order_id":  "abc",
+		"status":    "pending",
+		"component": "order_service",
+		"reason":    "free text from the client",
+	})
+
+	assert.Equal(t, "abc", redacted["order_id"])
+	assert.Equal(t, "pending", redacted["status"])
+	assert.Equal(t, "order_service", redacted["component"])
+	assert.Equal(t, "[REDACTED]", redacted["reason"])
+}
+
+func TestRedactor_RedactQuery(t *testing.T) {
+	redactor := logger.NewRedactor([]string{"email"}, nil)
+
+	assert.Equal(t, "email=j%2A%2A%2A%40example.com&limit=10", redactor.RedactQuery("limit=10&email=jane@example.com"))
+	assert.Equal(t, "", redactor.RedactQuery(""))
+}
+
+func TestRedactor_IsSensitiveNormalizesKeys(t *testing.T) {
+	redactor := logger.NewRedactor(nil, nil)
+
+	assert.True(t, redactor.IsSensitive("customerId"))
+	assert.True(t, redactor.IsSensitive("client-ip"))
+	assert.False(t, redactor.IsSensitive("id"))
+}
\ No newline at end of file
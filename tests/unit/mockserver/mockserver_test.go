@@ -0,0 +1,126 @@
+package mockserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/pkg/client/mockserver"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type orderEnvelope struct {
+	Data mockserver.Order `json:"data"`
+}
+
+func doJSON(t *testing.T, method, url string, body interface{}) *http.Response {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func createOrder(t *testing.T, server *mockserver.Server, customerID uuid.UUID) mockserver.Order {
+	t.Helper()
+	resp := doJSON(t, http.MethodPost, server.URL+"/api/v1/orders", map[string]interface{}{
+		"customer_id": customerID,
+		"items": []map[string]interface{}{
+			{"product_id": uuid.New(), "quantity": 2, "price": 10.5},
+		},
+	})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created orderEnvelope
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	return created.Data
+}
+
+func getOrder(t *testing.T, server *mockserver.Server, id uuid.UUID) mockserver.Order {
+	t.Helper()
+	resp, err := http.Get(server.URL + "/api/v1/orders/" + id.String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var fetched orderEnvelope
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&fetched))
+	return fetched.Data
+}
+
+func TestServer_OrdersProgressToCompleted(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)}
+	server := mockserver.New(mockserver.WithProgression(time.Second, 2*time.Second), mockserver.WithClock(clock.Now))
+	defer server.Close()
+
+	order := createOrder(t, server, uuid.New())
+	assert.Equal(t, mockserver.StatusPending, order.Status)
+	assert.Equal(t, "ORD-2024-000001", order.OrderNumber)
+	assert.Equal(t, 21.0, order.TotalAmount)
+
+	clock.Add(time.Second)
+	assert.Equal(t, mockserver.StatusProcessing, getOrder(t, server, order.ID).Status)
+
+	clock.Add(2 * time.Second)
+	assert.Equal(t, mockserver.StatusCompleted, getOrder(t, server, order.ID).Status)
+
+	resp := doJSON(t, http.MethodPut, server.URL+"/api/v1/orders/"+order.ID.String()+"/cancel", map[string]string{})
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_ManualProgression(t *testing.T) {
+	server := mockserver.New(mockserver.WithProgression(0, 0))
+	defer server.Close()
+
+	customerID := uuid.New()
+	order := createOrder(t, server, customerID)
+	createOrder(t, server, customerID)
+
+	require.NoError(t, server.Advance(order.ID))
+	assert.Equal(t, mockserver.StatusProcessing, getOrder(t, server, order.ID).Status)
+
+	require.NoError(t, server.SetStatus(order.ID, mockserver.StatusFailed, "payment_declined"))
+	failed := getOrder(t, server, order.ID)
+	assert.Equal(t, mockserver.StatusFailed, failed.Status)
+	assert.Equal(t, "payment_declined", failed.FailureCode)
+
+	resp := doJSON(t, http.MethodPut, server.URL+"/api/v1/orders/"+order.ID.String()+"/status", map[string]string{"status": "pending"})
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, mockserver.StatusPending, getOrder(t, server, order.ID).Status)
+
+	listResp, err := http.Get(server.URL + "/api/v1/customers/" + customerID.String() + "/orders")
+	require.NoError(t, err)
+	defer listResp.Body.Close()
+	assert.Equal(t, "2", listResp.Header.Get("X-Total-Count"))
+}
\ No newline at end of file
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestCompletedSagaSteps(t *testing.T) {
+	saga := models.NewSaga(uuid.New(), models.SagaStepPaymentAuthorization, 0)
+	assert.Empty(t, models.CompletedSagaSteps(saga))
+
+	saga.AdvanceTo(models.SagaStepInventoryReservation, 0)
+	assert.Equal(t, []models.SagaStep{models.SagaStepPaymentAuthorization}, models.CompletedSagaSteps(saga))
+
+	saga.Finish(models.SagaStatusCompleted, "")
+	assert.Equal(t, []models.SagaStep{
+		models.SagaStepPaymentAuthorization,
+		models.SagaStepInventoryReservation,
+	}, models.CompletedSagaSteps(saga))
+}
+
+func TestNewCompensationNeededEvent(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), CustomerID: uuid.New()}
+	steps := []models.SagaStep{models.SagaStepPaymentAuthorization}
+	compensation := models.NewCompensation(order, models.OrderStatusCompleted, steps, errors.New("order not found or version conflict"))
+
+	assert.Equal(t, models.CompensationStatusPending, compensation.Status)
+
+	event := models.NewCompensationNeededEvent(order, compensation)
+	assert.Equal(t, models.OrderCompensationNeededEvent, event.Type)
+
+	var data models.CompensationNeededEventData
+	require.NoError(t, event.DecodeData(&data))
+	assert.Equal(t, compensation.ID, data.CompensationID)
+	assert.Equal(t, models.OrderStatusCompleted, data.TargetStatus)
+	assert.Equal(t, steps, data.CompletedSteps)
+	assert.Equal(t, "order not found or version conflict", data.Error)
+}
\ No newline at end of file
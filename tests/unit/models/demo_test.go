@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/models"
+)
+
+func TestDemoOutcome(t *testing.T) {
+	demo := func(reference string) *models.Order {
+		return &models.Order{TenantID: models.DemoTenantID, ExternalReference: reference}
+	}
+
+	outcome, ok := models.DemoOutcome(demo(models.DemoReference(models.DemoOutcomeFail, "3f2a9c1e", 42)))
+	assert.True(t, ok)
+	assert.Equal(t, models.DemoOutcomeFail, outcome)
+
+	outcome, ok = models.DemoOutcome(demo(models.DemoReference(models.DemoOutcomeComplete, "3f2a9c1e", 1)))
+	assert.True(t, ok)
+	assert.Equal(t, models.DemoOutcomeComplete, outcome)
+
+	_, ok = models.DemoOutcome(demo("demo-explode-3f2a9c1e-000001"))
+	assert.False(t, ok)
+	_, ok = models.DemoOutcome(demo("PO-12345"))
+	assert.False(t, ok)
+	_, ok = models.DemoOutcome(&models.Order{TenantID: "acme", ExternalReference: "demo-fail-3f2a9c1e-000001"})
+	assert.False(t, ok)
+}
\ No newline at end of file
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestBuildEventCatalog_DescribesEveryOrderEvent(t *testing.T) {
+	catalog := models.BuildEventCatalog()
+
+	types := make(map[models.EventType]models.EventDescriptor)
+	for _, descriptor := range catalog.Events {
+		types[descriptor.Type] = descriptor
+	}
+
+	for _, eventType := range []models.EventType{
+		models.OrderCreatedEvent,
+		models.OrderStatusChangedEvent,
+		models.OrderProcessingEvent,
+		models.OrderCompletedEvent,
+		models.OrderFailedEvent,
+		models.OrderCanceledEvent,
+	} {
+		descriptor, ok := types[eventType]
+		require.True(t, ok, "missing catalog entry for %s", eventType)
+		assert.Equal(t, "object", descriptor.Schema["type"])
+		assert.NotEmpty(t, descriptor.Version)
+	}
+}
+
+func TestJSONSchemaFor_MapsGoTypes(t *testing.T) {
+	schema := models.JSONSchemaFor(models.OrderFailedEventData{})
+	properties := schema["properties"].(map[string]interface{})
+
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "uuid"}, properties["order_id"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "date-time"}, properties["failed_at"])
+	assert.Equal(t, []string{"order_id", "customer_id", "failed_at", "reason", "failure_code"}, schema["required"])
+
+	created := models.JSONSchemaFor(models.OrderCreatedEventData{})
+	items := created["properties"].(map[string]interface{})["items"].(map[string]interface{})
+	assert.Equal(t, "array", items["type"])
+	itemProperties := items["items"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, itemProperties["quantity"])
+}
\ No newline at end of file
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestChangeCursor_RoundTrip(t *testing.T) {
+	cursor := models.ChangeCursor{
+		UpdatedAt: time.Date(2025, 8, 30, 12, 0, 30, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	parsed, err := models.ParseChangeCursor(cursor.Encode())
+	require.NoError(t, err)
+
+	assert.True(t, cursor.UpdatedAt.Equal(parsed.UpdatedAt))
+	assert.Equal(t, cursor.ID, parsed.ID)
+}
+
+func TestParseChangeCursor_Empty(t *testing.T) {
+	parsed, err := models.ParseChangeCursor("")
+	require.NoError(t, err)
+
+	assert.True(t, parsed.UpdatedAt.IsZero())
+	assert.Equal(t, uuid.Nil, parsed.ID)
+}
+
+func TestParseChangeCursor_Invalid(t *testing.T) {
+	for _, input := range []string{"not base64!", "bm8tc2VwYXJhdG9y", "eHx5"} {
+		_, err := models.ParseChangeCursor(input)
+		assert.Error(t, err, input)
+	}
+}
\ No newline at end of file
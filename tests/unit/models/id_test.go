@@ -0,0 +1,52 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestIDGenerator_TimeOrderedStrategies(t *testing.T) {
+	for _, strategy := range []string{models.IDStrategyUUIDv7, models.IDStrategyULID} {
+		generator, err := models.NewIDGenerator(strategy)
+		require.NoError(t, err)
+
+		previous := generator.NewID()
+		for i := 0; i < 1000; i++ {
+			id := generator.NewID()
+			require.Negative(t, bytes.Compare(previous[:], id[:]), "%s IDs should increase", strategy)
+			previous = id
+		}
+	}
+}
+
+func TestIDGenerator_StrategiesShareTimestampLayout(t *testing.T) {
+	v7, err := models.NewIDGenerator(models.IDStrategyUUIDv7)
+	require.NoError(t, err)
+	ulid, err := models.NewIDGenerator(models.IDStrategyULID)
+	require.NoError(t, err)
+
+	first := v7.NewID()
+	time.Sleep(2 * time.Millisecond)
+	second := ulid.NewID()
+	time.Sleep(2 * time.Millisecond)
+	third := v7.NewID()
+
+	assert.Negative(t, bytes.Compare(first[:], second[:]))
+	assert.Negative(t, bytes.Compare(second[:], third[:]))
+	assert.Equal(t, uuid.Version(7), third.Version())
+}
+
+func TestNewIDGenerator(t *testing.T) {
+	generator, err := models.NewIDGenerator("")
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(4), generator.NewID().Version())
+
+	_, err = models.NewIDGenerator("snowflake")
+	assert.ErrorContains(t, err, "unsupported ID strategy")
+}
\ No newline at end of file
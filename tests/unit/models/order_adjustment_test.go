@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func adjustableOrder() *models.Order {
+	orderID := uuid.New()
+	return &models.Order{
+		ID:          orderID,
+		CustomerID:  uuid.New(),
+		Items:       existingItems(orderID),
+		TotalAmount: 25,
+		Version:     3,
+	}
+}
+
+func TestAdjustOrder_RepricesProducts(t *testing.T) {
+	order := adjustableOrder()
+
+	adjustment := models.AdjustOrder(order, map[uuid.UUID]float64{order.Items[0].ProductID: 12.499})
+	require.NotNil(t, adjustment)
+
+	assert.Equal(t, 25.0, adjustment.OldTotal)
+	assert.Equal(t, 30.0, adjustment.NewTotal)
+	assert.Equal(t, 5.0, adjustment.Delta)
+	assert.Equal(t, 4, adjustment.Version)
+	require.Len(t, adjustment.Items, 1)
+	assert.Equal(t, models.ItemAdjustment{
+		ItemID:    order.Items[0].ID,
+		ProductID: order.Items[0].ProductID,
+		Quantity:  2,
+		OldPrice:  10,
+		NewPrice:  12.5,
+		OldTotal:  20,
+		NewTotal:  25,
+	}, adjustment.Items[0])
+
+	assert.Equal(t, 25.0, order.TotalAmount, "AdjustOrder must not change the order")
+}
+
+func TestAdjustOrder_FixesStaleTotals(t *testing.T) {
+	order := adjustableOrder()
+	order.Items[1].Total = 0
+	order.TotalAmount = 20.004
+
+	adjustment := models.AdjustOrder(order, nil)
+	require.NotNil(t, adjustment)
+
+	assert.Equal(t, 25.0, adjustment.NewTotal)
+	require.Len(t, adjustment.Items, 1)
+	assert.Equal(t, order.Items[1].ID, adjustment.Items[0].ItemID)
+	assert.Equal(t, 5.0, adjustment.Items[0].NewTotal)
+}
+
+func TestAdjustOrder_NilWhenCorrect(t *testing.T) {
+	order := adjustableOrder()
+	order.TotalAmount = 25.001
+
+	assert.Nil(t, models.AdjustOrder(order, nil))
+	assert.Nil(t, models.AdjustOrder(order, map[uuid.UUID]float64{order.Items[1].ProductID: 5}))
+}
+
+func TestOrderAdjustment_Apply(t *testing.T) {
+	order := adjustableOrder()
+	adjustment := models.AdjustOrder(order, map[uuid.UUID]float64{order.Items[1].ProductID: 7})
+	require.NotNil(t, adjustment)
+	adjustment.AdjustedAt = time.Date(2025, 9, 2, 10, 0, 0, 0, time.UTC)
+
+	adjustment.Apply(order)
+
+	assert.Equal(t, 27.0, order.TotalAmount)
+	assert.Equal(t, 7.0, order.Items[1].Price)
+	assert.Equal(t, 7.0, order.Items[1].Total)
+	assert.Equal(t, 10.0, order.Items[0].Price)
+	assert.Equal(t, 4, order.Version)
+	assert.Equal(t, adjustment.AdjustedAt, order.UpdatedAt)
+}
\ No newline at end of file
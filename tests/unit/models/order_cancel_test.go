@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestCancelReasonCode_IsValid(t *testing.T) {
+	assert.True(t, models.CancelReasonUserRequest.IsValid())
+	assert.True(t, models.CancelReasonExpired.IsValid())
+	assert.True(t, models.CancelReasonFraud.IsValid())
+	assert.True(t, models.CancelReasonAdmin.IsValid())
+	assert.False(t, models.CancelReasonCode("changed_mind").IsValid())
+	assert.False(t, models.CancelReasonCode("").IsValid())
+}
+
+func TestNewOrderCanceledEvent(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), CustomerID: uuid.New(), Status: models.OrderStatusCanceled}
+	event := models.NewOrderCanceledEvent(order, models.OrderStatusProcessing, &models.CancelOrderRequest{
+		ReasonCode: models.CancelReasonAdmin,
+		Reason:     "Duplicate order",
+		Actor:      "ops@example.com",
+	})
+
+	assert.Equal(t, models.OrderCanceledEvent, event.Type)
+
+	var data models.OrderCanceledEventData
+	require.NoError(t, event.DecodeData(&data))
+	assert.Equal(t, order.ID, data.OrderID)
+	assert.Equal(t, models.OrderStatusProcessing, data.PreviousStatus)
+	assert.Equal(t, models.CancelReasonAdmin, data.ReasonCode)
+	assert.Equal(t, "ops@example.com", data.Actor)
+}
\ No newline at end of file
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestParseOrderFields(t *testing.T) {
+	fields, err := models.ParseOrderFields("")
+	require.NoError(t, err)
+	assert.Nil(t, fields)
+	assert.True(t, fields.Has("items"))
+	assert.Empty(t, fields.LoadOptions())
+
+	fields, err = models.ParseOrderFields(" id, status ,total_amount")
+	require.NoError(t, err)
+	assert.True(t, fields.Has("status"))
+	assert.False(t, fields.Has("items"))
+	assert.True(t, models.NewLoadOptions(fields.LoadOptions()...).SkipItems)
+
+	fields, err = models.ParseOrderFields("id,items")
+	require.NoError(t, err)
+	assert.False(t, models.NewLoadOptions(fields.LoadOptions()...).SkipItems)
+
+	_, err = models.ParseOrderFields("id,version")
+	assert.Error(t, err)
+}
+
+func TestOrderFieldsSelect(t *testing.T) {
+	response := &models.OrderResponse{
+		ID:          uuid.New(),
+		CustomerID:  uuid.New(),
+		Status:      models.OrderStatusCompleted,
+		TotalAmount: 59.98,
+	}
+
+	var all models.OrderFields
+	assert.Same(t, response, all.Select(response))
+
+	fields, err := models.ParseOrderFields("id,status")
+	require.NoError(t, err)
+
+	selected, ok := fields.Select(response).(map[string]json.RawMessage)
+	require.True(t, ok)
+	assert.Len(t, selected, 2)
+	assert.JSONEq(t, `"completed"`, string(selected["status"]))
+	assert.JSONEq(t, `"`+response.ID.String()+`"`, string(selected["id"]))
+}
\ No newline at end of file
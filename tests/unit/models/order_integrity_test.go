@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/models"
+)
+
+func TestMinimumVersions_FollowsStateMachine(t *testing.T) {
+	assert.Equal(t, map[models.OrderStatus]int{
+		models.OrderStatusPending:    1,
+		models.OrderStatusProcessing: 2,
+		models.OrderStatusCanceled:   2,
+		models.OrderStatusOnHold:     2,
+		models.OrderStatusScheduled:  2,
+		models.OrderStatusCompleted:  3,
+		models.OrderStatusFailed:     3,
+	}, models.MinimumVersions())
+}
+
+func TestIntegrityReport_CountsUnresolved(t *testing.T) {
+	report := models.NewIntegrityReport()
+	report.Add(&models.OrderInconsistency{Kind: models.InconsistencyOrderTotal, OrderID: uuid.New()})
+	report.Add(&models.OrderInconsistency{Kind: models.InconsistencyOrderTotal, OrderID: uuid.New()})
+	report.Add(&models.OrderInconsistency{Kind: models.InconsistencyUnknownStatus, OrderID: uuid.New()})
+	report.Repaired = 2
+
+	assert.Equal(t, 2, report.Found[models.InconsistencyOrderTotal])
+	assert.Equal(t, 1, report.Unresolved())
+	assert.False(t, report.Inconsistencies[2].Repairable())
+}
\ No newline at end of file
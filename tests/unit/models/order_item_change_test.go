@@ -0,0 +1,81 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func existingItems(orderID uuid.UUID) []models.OrderItem {
+	return []models.OrderItem{
+		{ID: uuid.New(), OrderID: orderID, ProductID: uuid.New(), Quantity: 2, Price: 10, Total: 20},
+		{ID: uuid.New(), OrderID: orderID, ProductID: uuid.New(), Quantity: 1, Price: 5, Total: 5},
+	}
+}
+
+func TestMergeOrderItems_PreservesIDs(t *testing.T) {
+	orderID := uuid.New()
+	existing := existingItems(orderID)
+
+	amended := []models.OrderItem{
+		{ID: existing[0].ID, ProductID: existing[0].ProductID, Quantity: 3, Price: 10},
+		{ProductID: existing[1].ProductID, Quantity: 1, Price: 5},
+	}
+
+	merged, changes, err := models.MergeOrderItems(orderID, existing, amended)
+	require.NoError(t, err)
+
+	require.Len(t, merged, 2)
+	assert.Equal(t, existing[0].ID, merged[0].ID)
+	assert.Equal(t, 30.0, merged[0].Total)
+	assert.Equal(t, existing[1], merged[1])
+
+	require.Len(t, changes, 1)
+	assert.Equal(t, models.OrderItemChange{
+		ItemID:           existing[0].ID,
+		ProductID:        existing[0].ProductID,
+		PreviousQuantity: 2,
+		Quantity:         3,
+		QuantityDelta:    1,
+	}, changes[0])
+}
+
+func TestMergeOrderItems_AddsAndRemoves(t *testing.T) {
+	orderID := uuid.New()
+	existing := existingItems(orderID)
+	productID := uuid.New()
+
+	amended := []models.OrderItem{
+		{ID: existing[0].ID, Quantity: 2, Price: 10},
+		{ProductID: productID, Quantity: 4, Price: 2.5},
+	}
+
+	merged, changes, err := models.MergeOrderItems(orderID, existing, amended)
+	require.NoError(t, err)
+
+	require.Len(t, merged, 2)
+	assert.Equal(t, existing[0], merged[0])
+	assert.NotEqual(t, uuid.Nil, merged[1].ID)
+	assert.Equal(t, orderID, merged[1].OrderID)
+	assert.Equal(t, 10.0, merged[1].Total)
+
+	require.Len(t, changes, 2)
+	assert.Equal(t, merged[1].ID, changes[0].ItemID)
+	assert.Equal(t, 0, changes[0].PreviousQuantity)
+	assert.Equal(t, 4, changes[0].QuantityDelta)
+	assert.Equal(t, existing[1].ID, changes[1].ItemID)
+	assert.Equal(t, 0, changes[1].Quantity)
+	assert.Equal(t, -1, changes[1].QuantityDelta)
+}
+
+func TestMergeOrderItems_UnknownItemID(t *testing.T) {
+	orderID := uuid.New()
+
+	_, _, err := models.MergeOrderItems(orderID, existingItems(orderID), []models.OrderItem{
+		{ID: uuid.New(), ProductID: uuid.New(), Quantity: 1, Price: 1},
+	})
+	assert.Error(t, err)
+}
\ No newline at end of file
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/models"
+)
+
+func TestFormatOrderNumber(t *testing.T) {
+	assert.Equal(t, "ORD-2024-000123", models.FormatOrderNumber(2024, 123))
+	assert.Equal(t, "ORD-2024-1234567", models.FormatOrderNumber(2024, 1234567))
+}
+
+func TestIsValidOrderNumber(t *testing.T) {
+	assert.True(t, models.IsValidOrderNumber("ORD-2024-000123"))
+	assert.True(t, models.IsValidOrderNumber(models.FormatOrderNumber(2025, 1)))
+	assert.False(t, models.IsValidOrderNumber("ORD-24-000123"))
+	assert.False(t, models.IsValidOrderNumber("ord-2024-000123"))
+	assert.False(t, models.IsValidOrderNumber("f47ac10b-58cc-4372-a567-0e02b2c3d479"))
+}
\ No newline at end of file
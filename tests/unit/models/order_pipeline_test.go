@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestOrderPipeline_AddGroupsStatusesByStage(t *testing.T) {
+	pipeline := models.NewOrderPipeline(time.Now().UTC())
+	pipeline.Add(models.OrderStatusPending, 4)
+	pipeline.Add(models.OrderStatusOnHold, 1)
+	pipeline.Add(models.OrderStatusScheduled, 2)
+	pipeline.Add(models.OrderStatusProcessing, 3)
+	pipeline.Add(models.OrderStatusCompleted, 10)
+	pipeline.Add(models.OrderStatusFailed, 2)
+
+	require.Len(t, pipeline.Stages, 3)
+	assert.Equal(t, models.PipelineStagePending, pipeline.Stages[0].Stage)
+	assert.Equal(t, 7, pipeline.Stage(models.PipelineStagePending).Count)
+	assert.Equal(t, 3, pipeline.Stage(models.PipelineStageProcessing).Count)
+	assert.Equal(t, map[models.OrderStatus]int{models.OrderStatusCompleted: 10, models.OrderStatusFailed: 2},
+		pipeline.Stage(models.PipelineStageTerminal).Statuses)
+	assert.Equal(t, 22, pipeline.Total)
+}
+
+func TestOrderPipeline_SetDwell(t *testing.T) {
+	pipeline := models.NewOrderPipeline(time.Now().UTC())
+	median := 2.5
+	pipeline.SetDwell(models.PipelineStagePending, 8, &median)
+	pipeline.SetDwell(models.PipelineStageProcessing, 0, nil)
+
+	pending := pipeline.Stage(models.PipelineStagePending)
+	assert.Equal(t, 8, pending.Exited)
+	require.NotNil(t, pending.MedianDwellSeconds)
+	assert.Equal(t, 2.5, *pending.MedianDwellSeconds)
+	assert.Nil(t, pipeline.Stage(models.PipelineStageProcessing).MedianDwellSeconds)
+}
\ No newline at end of file
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/models"
+)
+
+func TestOrderStats_Add(t *testing.T) {
+	stats := &models.OrderStats{}
+	stats.Add(models.OrderStatusPending, 5)
+	stats.Add(models.OrderStatusCompleted, 10)
+	stats.Add(models.OrderStatusOnHold, 1)
+	stats.Add(models.OrderStatus("archived"), 2)
+
+	assert.Equal(t, &models.OrderStats{
+		Pending:   5,
+		Completed: 10,
+		OnHold:    1,
+		Total:     18,
+	}, stats)
+}
+
+func TestOrderStats_AddFailure(t *testing.T) {
+	stats := &models.OrderStats{}
+	stats.AddFailure(models.FailureCodeTimeout, 2)
+	stats.AddFailure(models.FailureCodeTimeout, 1)
+	stats.AddFailure("", 4)
+
+	assert.Equal(t, map[models.FailureCode]int{models.FailureCodeTimeout: 3}, stats.FailureCodes)
+}
+
+func TestOrderStats_AddChannel(t *testing.T) {
+	stats := &models.OrderStats{}
+	stats.AddChannel(models.OrderChannelWeb, 2)
+	stats.AddChannel(models.OrderChannelPOS, 1)
+	stats.AddChannel(models.OrderChannelWeb, 3)
+	stats.AddChannel("", 4)
+
+	assert.Equal(t, map[models.OrderChannel]int{models.OrderChannelWeb: 5, models.OrderChannelPOS: 1}, stats.Channels)
+}
+
+func TestOrderStats_AddError(t *testing.T) {
+	stats := &models.OrderStats{}
+	assert.False(t, stats.Degraded)
+
+	stats.AddError(models.StatsMetricChannels, errors.New("statement timeout"))
+	assert.True(t, stats.Degraded)
+	assert.Equal(t, map[string]string{models.StatsMetricChannels: "statement timeout"}, stats.Errors)
+}
+
+func TestOrderChannel_IsValid(t *testing.T) {
+	for _, channel := range []models.OrderChannel{models.OrderChannelWeb, models.OrderChannelMobile, models.OrderChannelAPI, models.OrderChannelPOS} {
+		assert.True(t, channel.IsValid(), channel)
+	}
+	assert.False(t, models.OrderChannel("").IsValid())
+	assert.False(t, models.OrderChannel("kiosk").IsValid())
+}
+
+func TestFailureCodeForSagaStep(t *testing.T) {
+	assert.Equal(t, models.FailureCodePaymentDeclined, models.FailureCodeForSagaStep(models.SagaStepPaymentAuthorization))
+	assert.Equal(t, models.FailureCodeInventoryUnavailable, models.FailureCodeForSagaStep(models.SagaStepInventoryReservation))
+	assert.Equal(t, models.FailureCodeInternal, models.FailureCodeForSagaStep(models.SagaStep("shipping")))
+}
\ No newline at end of file
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/models"
+)
+
+func TestOrder_AllowedTransitions(t *testing.T) {
+	order := &models.Order{Status: models.OrderStatusProcessing}
+	assert.Equal(t, []models.OrderStatus{models.OrderStatusCompleted, models.OrderStatusFailed, models.OrderStatusCanceled}, order.AllowedTransitions())
+
+	for _, status := range order.AllowedTransitions() {
+		assert.True(t, order.IsValidStatusTransition(status))
+	}
+	assert.False(t, order.IsValidStatusTransition(models.OrderStatusPending))
+
+	completed := &models.Order{Status: models.OrderStatusCompleted}
+	assert.NotNil(t, completed.AllowedTransitions())
+	assert.Empty(t, completed.AllowedTransitions())
+}
+
+func TestOrder_AllowedTransitionsReturnsCopy(t *testing.T) {
+	order := &models.Order{Status: models.OrderStatusFailed}
+	order.AllowedTransitions()[0] = models.OrderStatusCompleted
+
+	assert.Equal(t, []models.OrderStatus{models.OrderStatusPending}, order.AllowedTransitions())
+}
\ No newline at end of file
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestBackoffPolicy_DoublesUpToMax(t *testing.T) {
+	policy := models.BackoffPolicy{Base: 30 * time.Second, Max: 5 * time.Minute}
+
+	assert.Equal(t, 30*time.Second, policy.Delay(0))
+	assert.Equal(t, 30*time.Second, policy.Delay(1))
+	assert.Equal(t, time.Minute, policy.Delay(2))
+	assert.Equal(t, 4*time.Minute, policy.Delay(4))
+	assert.Equal(t, 5*time.Minute, policy.Delay(5))
+	assert.Equal(t, 5*time.Minute, policy.Delay(60))
+}
+
+func TestBackoffPolicy_JitterShortensDelay(t *testing.T) {
+	policy := models.BackoffPolicy{Base: time.Minute, Max: time.Hour, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.Delay(3)
+		assert.GreaterOrEqual(t, delay, 2*time.Minute)
+		assert.LessOrEqual(t, delay, 4*time.Minute)
+	}
+}
+
+func TestProcessingAttempt_Fail(t *testing.T) {
+	event := models.NewEvent(models.OrderCreatedEvent, nil)
+	attempt := models.NewProcessingAttempt(uuid.New(), event)
+	assert.Equal(t, models.AttemptOutcomeRunning, attempt.Outcome)
+	assert.Equal(t, event.ID, attempt.EventID)
+
+	retryAt := time.Now().Add(time.Minute)
+	attempt.Fail(errors.New("connection refused"), retryAt)
+
+	assert.Equal(t, models.AttemptOutcomeFailed, attempt.Outcome)
+	assert.Equal(t, "connection refused", attempt.Error)
+	require.NotNil(t, attempt.EndedAt)
+	require.NotNil(t, attempt.NextAttemptAt)
+	assert.Equal(t, retryAt, *attempt.NextAttemptAt)
+}
\ No newline at end of file
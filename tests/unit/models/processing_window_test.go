@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestProcessingSchedule_BusinessHours(t *testing.T) {
+	schedule, err := models.ParseProcessingSchedule("Europe/Berlin", []string{"mon-fri 09:00-17:00"})
+	require.NoError(t, err)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// Wednesday 2025-09-03.
+	assert.True(t, schedule.IsOpen(time.Date(2025, 9, 3, 9, 0, 0, 0, berlin)))
+	assert.False(t, schedule.IsOpen(time.Date(2025, 9, 3, 17, 0, 0, 0, berlin)))
+	assert.False(t, schedule.IsOpen(time.Date(2025, 9, 3, 6, 30, 0, 0, time.UTC)), "08:30 in Berlin")
+	assert.True(t, schedule.IsOpen(time.Date(2025, 9, 3, 7, 30, 0, 0, time.UTC)), "09:30 in Berlin")
+
+	friday := time.Date(2025, 9, 5, 18, 0, 0, 0, berlin)
+	assert.Equal(t, time.Date(2025, 9, 8, 9, 0, 0, 0, berlin).UTC(), schedule.NextOpen(friday))
+
+	open := time.Date(2025, 9, 3, 12, 0, 0, 0, berlin)
+	assert.True(t, open.Equal(schedule.NextOpen(open)))
+}
+
+func TestProcessingSchedule_OvernightWindow(t *testing.T) {
+	schedule, err := models.ParseProcessingSchedule("", []string{"fri 22:00-02:00"})
+	require.NoError(t, err)
+
+	assert.True(t, schedule.IsOpen(time.Date(2025, 9, 5, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, schedule.IsOpen(time.Date(2025, 9, 6, 1, 59, 0, 0, time.UTC)))
+	assert.False(t, schedule.IsOpen(time.Date(2025, 9, 6, 2, 0, 0, 0, time.UTC)))
+	assert.False(t, schedule.IsOpen(time.Date(2025, 9, 5, 1, 0, 0, 0, time.UTC)), "the window belongs to Friday evening")
+}
+
+func TestParseProcessingSchedule_RejectsInvalidRules(t *testing.T) {
+	for _, rules := range [][]string{
+		{"weekdays 09:00-17:00"},
+		{"mon-fri 9-17"},
+		{"mon 25:00-26:00"},
+		{"mon 09:00-09:00"},
+		{"mon-fri"},
+		{},
+	} {
+		_, err := models.ParseProcessingSchedule("UTC", rules)
+		assert.Error(t, err, rules)
+	}
+
+	_, err := models.ParseProcessingSchedule("Mars/Olympus", []string{"* 00:00-24:00"})
+	assert.ErrorContains(t, err, "invalid time zone")
+}
\ No newline at end of file
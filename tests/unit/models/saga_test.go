@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+)
+
+func TestSaga_AdvanceToIssuesNewCorrelationID(t *testing.T) {
+	saga := models.NewSaga(uuid.New(), models.SagaStepPaymentAuthorization, 30*time.Second)
+	firstCorrelationID := saga.CorrelationID
+
+	saga.AdvanceTo(models.SagaStepInventoryReservation, time.Minute)
+
+	assert.Equal(t, models.SagaStepInventoryReservation, saga.Step)
+	assert.Equal(t, models.SagaStatusAwaitingReply, saga.Status)
+	assert.NotEqual(t, firstCorrelationID, saga.CorrelationID)
+	assert.True(t, saga.Deadline.After(time.Now().Add(30*time.Second)))
+}
+
+func TestSagaCommandEvent_CarriesCorrelationAndReplyTopic(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), CustomerID: uuid.New(), TotalAmount: 42.5}
+	saga := models.NewSaga(order.ID, models.SagaStepPaymentAuthorization, time.Second)
+
+	event := models.NewSagaCommandEvent(models.PaymentAuthorizeRequestEvent, saga, order, "payment.authorize.reply")
+
+	var data models.SagaCommandData
+	require.NoError(t, event.DecodeData(&data))
+	assert.Equal(t, models.PaymentAuthorizeRequestEvent, event.Type)
+	assert.Equal(t, saga.CorrelationID, data.CorrelationID)
+	assert.Equal(t, order.ID, data.OrderID)
+	assert.Equal(t, 42.5, data.Amount)
+	assert.Equal(t, "payment.authorize.reply", data.ReplyTopic)
+}
\ No newline at end of file
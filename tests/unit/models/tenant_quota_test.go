@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/models"
+)
+
+func TestTenantQuota_Check(t *testing.T) {
+	quota := &models.TenantQuota{TenantID: "acme", MaxActiveOrders: 10, MaxOrdersPerDay: 100}
+
+	assert.NoError(t, quota.Check(&models.TenantQuotaUsage{ActiveOrders: 9, OrdersToday: 99}))
+
+	err := quota.Check(&models.TenantQuotaUsage{ActiveOrders: 10, OrdersToday: 5})
+	assert.ErrorIs(t, err, models.ErrActiveOrderQuotaExceeded)
+
+	err = quota.Check(&models.TenantQuotaUsage{ActiveOrders: 1, OrdersToday: 100})
+	assert.ErrorIs(t, err, models.ErrDailyOrderQuotaExceeded)
+}
+
+func TestTenantQuota_ZeroIsUnlimited(t *testing.T) {
+	quota := &models.TenantQuota{TenantID: "acme"}
+
+	assert.True(t, quota.Unlimited())
+	assert.NoError(t, quota.Check(&models.TenantQuotaUsage{ActiveOrders: 1000, OrdersToday: 1000}))
+
+	quota.MaxOrdersPerDay = 5
+	assert.False(t, quota.Unlimited())
+	assert.NoError(t, quota.Check(&models.TenantQuotaUsage{ActiveOrders: 1000, OrdersToday: 4}))
+}
+
+func TestQuotaDayStart(t *testing.T) {
+	at := time.Date(2024, 1, 15, 1, 30, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+
+	assert.Equal(t, time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC), models.QuotaDayStart(at))
+}
\ No newline at end of file
@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+// fakeSchemaRegistry implements the registry endpoints the avro codec uses.
+type fakeSchemaRegistry struct {
+	mu           sync.Mutex
+	schemas      []string
+	subjects     map[string][]int
+	incompatible bool
+}
+
+func newFakeSchemaRegistry(t *testing.T) (*fakeSchemaRegistry, *config.KafkaConfig) {
+	registry := &fakeSchemaRegistry{subjects: make(map[string][]int)}
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+	return registry, &config.KafkaConfig{SchemaRegistryURL: server.URL, SchemaRegistryAutoRegister: true}
+}
+
+func (r *fakeSchemaRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	json.NewDecoder(req.Body).Decode(&body)
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+	switch {
+	case parts[0] == "compatibility":
+		if len(r.subjects[parts[2]]) == 0 {
+			writeRegistryError(w, http.StatusNotFound, 40401)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]bool{"is_compatible": !r.incompatible})
+	case parts[0] == "subjects" && len(parts) == 3:
+		r.schemas = append(r.schemas, body.Schema)
+		id := len(r.schemas)
+		r.subjects[parts[1]] = append(r.subjects[parts[1]], id)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	case parts[0] == "subjects":
+		for _, id := range r.subjects[parts[1]] {
+			if r.schemas[id-1] == body.Schema {
+				json.NewEncoder(w).Encode(map[string]int{"id": id})
+				return
+			}
+		}
+		writeRegistryError(w, http.StatusNotFound, 40401)
+	case parts[0] == "schemas":
+		id, _ := strconv.Atoi(parts[2])
+		if id < 1 || id > len(r.schemas) {
+			writeRegistryError(w, http.StatusNotFound, 40403)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": r.schemas[id-1]})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeRegistryError(w http.ResponseWriter, status, code int) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error_code": code, "message": "not found"})
+}
+
+func TestAvroCodec_RoundTrip(t *testing.T) {
+	_, cfg := newFakeSchemaRegistry(t)
+	producer, err := queue.NewAvroCodec(queue.NewSchemaRegistryClient(cfg), true)
+	require.NoError(t, err)
+	require.NoError(t, producer.RegisterSchemas(context.Background()))
+
+	order := &models.Order{
+		ID:          uuid.New(),
+		CustomerID:  uuid.New(),
+		TenantID:    "acme",
+		Channel:     models.OrderChannelMobile,
+		TotalAmount: 59.97,
+		CreatedAt:   time.Date(2025, 8, 30, 12, 0, 0, 123456000, time.UTC),
+		Items: []models.OrderItem{
+			{ID: uuid.New(), ProductID: uuid.New(), Quantity: 3, Price: 19.99, Total: 59.97},
+		},
+	}
+	event := models.NewOrderCreatedEvent(order)
+	event.Region = "eu-west-1"
+	event.Sequence = 42
+
+	encoded, err := producer.Marshal(event)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0), encoded[0], "confluent wire format magic byte")
+
+	// A separate consumer fetches the writer schema from the registry.
+	consumer, err := queue.NewAvroCodec(queue.NewSchemaRegistryClient(cfg), false)
+	require.NoError(t, err)
+	decoded, err := consumer.Unmarshal(encoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, event.ID, decoded.ID)
+	assert.Equal(t, event.Type, decoded.Type)
+	assert.Equal(t, "eu-west-1", decoded.Region)
+	assert.Equal(t, uint64(42), decoded.Sequence)
+	assert.True(t, event.Timestamp.Truncate(time.Microsecond).Equal(decoded.Timestamp))
+
+	var data models.OrderCreatedEventData
+	require.NoError(t, decoded.DecodeData(&data))
+	assert.Equal(t, order.ID, data.OrderID)
+	assert.Equal(t, "acme", data.TenantID)
+	assert.Equal(t, models.OrderChannelMobile, data.Channel)
+	assert.Empty(t, data.OrderNumber)
+	assert.Equal(t, order.TotalAmount, data.TotalAmount)
+	assert.True(t, order.CreatedAt.Equal(data.CreatedAt))
+	require.Len(t, data.Items, 1)
+	assert.Equal(t, 3, data.Items[0].Quantity)
+
+	_, isMap := decoded.Data.(map[string]interface{})
+	assert.True(t, isMap, "decoded data should match the JSON codec's shape")
+}
+
+func TestAvroCodec_SubjectPerEventType(t *testing.T) {
+	registry, cfg := newFakeSchemaRegistry(t)
+	codec, err := queue.NewAvroCodec(queue.NewSchemaRegistryClient(cfg), true)
+	require.NoError(t, err)
+	require.NoError(t, codec.RegisterSchemas(context.Background()))
+
+	assert.Contains(t, codec.Subjects(), "orders.events.v1.OrderCreated")
+	assert.Contains(t, codec.Subjects(), "orders.events.v1.PaymentAuthorizeRequest")
+	assert.Contains(t, codec.Subjects(), "orders.events.v1.InventoryReserveRequest")
+	assert.Len(t, registry.subjects, len(codec.Subjects()))
+
+	// Event types without a schema use the generic record.
+	event := models.NewEvent(models.OrderCompensationNeededEvent, map[string]interface{}{"order_id": "abc", "steps": []string{"refund"}})
+	encoded, err := codec.Marshal(event)
+	require.NoError(t, err)
+	decoded, err := codec.Unmarshal(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderCompensationNeededEvent, decoded.Type)
+	assert.Equal(t, "abc", decoded.Data.(map[string]interface{})["order_id"])
+}
+
+func TestAvroCodec_RejectsIncompatibleSchema(t *testing.T) {
+	registry, cfg := newFakeSchemaRegistry(t)
+	codec, err := queue.NewAvroCodec(queue.NewSchemaRegistryClient(cfg), true)
+	require.NoError(t, err)
+	require.NoError(t, codec.RegisterSchemas(context.Background()))
+
+	registry.incompatible = true
+	err = codec.RegisterSchemas(context.Background())
+	assert.ErrorContains(t, err, "incompatible")
+}
+
+func TestAvroCodec_RequiresRegisteredSchemasWithoutAutoRegister(t *testing.T) {
+	_, cfg := newFakeSchemaRegistry(t)
+	codec, err := queue.NewAvroCodec(queue.NewSchemaRegistryClient(cfg), false)
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, codec.RegisterSchemas(context.Background()), "not registered")
+
+	_, err = codec.Marshal(models.NewOrderProcessingEvent(&models.Order{ID: uuid.New()}))
+	assert.ErrorContains(t, err, "not registered")
+}
+
+func TestDecodeMessage_AvroWithoutRegistry(t *testing.T) {
+	message := &sarama.ConsumerMessage{
+		Headers: []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte("application/vnd.kafka.avro.v2")}},
+		Value:   []byte{0, 0, 0, 0, 1},
+	}
+	_, err := queue.DecodeMessage(message)
+	assert.ErrorContains(t, err, "schema registry")
+}
\ No newline at end of file
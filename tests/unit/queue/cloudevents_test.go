@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+)
+
+const eventID = "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+
+func TestDecodeMessage_Structured(t *testing.T) {
+	message := &sarama.ConsumerMessage{
+		Value: []byte(`{"specversion":"1.0","id":"` + eventID + `","source":"/orders","type":"order.created",` +
+			`"time":"2025-08-30T12:00:00Z","region":"eu-west-1","eventversion":"1.0","sequence":3,"data":{"order_id":"abc"}}`),
+	}
+
+	event, err := queue.DecodeMessage(message)
+	require.NoError(t, err)
+
+	assert.Equal(t, eventID, event.ID.String())
+	assert.Equal(t, models.OrderCreatedEvent, event.Type)
+	assert.Equal(t, "eu-west-1", event.Region)
+	assert.Equal(t, "1.0", event.Version)
+	assert.Equal(t, uint64(3), event.Sequence)
+	assert.Equal(t, "abc", event.Data.(map[string]interface{})["order_id"])
+}
+
+func TestDecodeMessage_Binary(t *testing.T) {
+	message := &sarama.ConsumerMessage{
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("ce_specversion"), Value: []byte("1.0")},
+			{Key: []byte("ce_id"), Value: []byte(eventID)},
+			{Key: []byte("ce_type"), Value: []byte("order.completed")},
+			{Key: []byte("ce_time"), Value: []byte("2025-08-30T12:00:00.5Z")},
+			{Key: []byte("ce_sequence"), Value: []byte("4")},
+		},
+		Value: []byte(`{"order_id":"abc"}`),
+	}
+
+	event, err := queue.DecodeMessage(message)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.OrderCompletedEvent, event.Type)
+	assert.Equal(t, 500000000, event.Timestamp.Nanosecond())
+	assert.Equal(t, uint64(4), event.Sequence)
+	assert.Equal(t, "abc", event.Data.(map[string]interface{})["order_id"])
+}
+
+func TestDecodeMessage_LegacyEvent(t *testing.T) {
+	message := &sarama.ConsumerMessage{
+		Value: []byte(`{"id":"` + eventID + `","type":"order.failed","data":{"order_id":"abc"},"version":"1.0"}`),
+	}
+
+	event, err := queue.DecodeMessage(message)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.OrderFailedEvent, event.Type)
+	assert.Equal(t, "1.0", event.Version)
+}
+
+func TestParseCloudEventsModes(t *testing.T) {
+	modes, err := queue.ParseCloudEventsModes([]string{"order-events=structured", " payments = Binary ", ""})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"order-events": "structured", "payments": "binary"}, modes)
+
+	_, err = queue.ParseCloudEventsModes([]string{"order-events=xml"})
+	assert.Error(t, err)
+
+	_, err = queue.ParseCloudEventsModes([]string{"order-events"})
+	assert.Error(t, err)
+}
\ No newline at end of file
@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/queue"
+)
+
+func TestKafkaConsumer_SwitchClusterRejoinsOnOtherCluster(t *testing.T) {
+	primary := newFakeConsumerGroup(nil)
+	secondary := newFakeConsumerGroup(nil)
+	consumer := newTestConsumer(primary)
+	var connected []string
+	consumer.EnableClusterSwitch(func(cluster string) (sarama.ConsumerGroup, error) {
+		connected = append(connected, cluster)
+		return secondary, nil
+	})
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+	assert.Equal(t, queue.ClusterPrimary, consumer.Cluster().Active)
+
+	require.NoError(t, consumer.SwitchCluster(queue.ClusterSecondary))
+
+	select {
+	case <-primary.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("primary consumer group client was not closed")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for secondary.calls.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("consumer did not rejoin on the secondary cluster")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.NoError(t, consumer.SwitchCluster(queue.ClusterSecondary))
+	assert.Equal(t, []string{queue.ClusterSecondary}, connected, "switching to the active cluster does not reconnect")
+
+	status := consumer.Cluster()
+	assert.Equal(t, queue.ClusterSecondary, status.Active)
+	assert.Equal(t, uint64(1), status.Switches)
+	assert.NotNil(t, status.SwitchedAt)
+}
+
+func TestKafkaConsumer_SwitchClusterKeepsClientOnError(t *testing.T) {
+	primary := newFakeConsumerGroup(nil)
+	consumer := newTestConsumer(primary)
+	consumer.EnableClusterSwitch(func(cluster string) (sarama.ConsumerGroup, error) {
+		return nil, errors.New("kafka: client has run out of available brokers")
+	})
+
+	err := consumer.SwitchCluster(queue.ClusterSecondary)
+	assert.ErrorIs(t, err, queue.ErrBrokerUnavailable)
+	assert.Contains(t, err.Error(), "failed to connect")
+	assert.Equal(t, queue.ClusterPrimary, consumer.Cluster().Active)
+
+	select {
+	case <-primary.closed:
+		t.Fatal("primary consumer group client was closed")
+	default:
+	}
+}
+
+func TestKafkaConsumer_SwitchClusterRequiresSecondary(t *testing.T) {
+	consumer := newTestConsumer(newFakeConsumerGroup(nil))
+
+	err := consumer.SwitchCluster(queue.ClusterSecondary)
+	assert.ErrorIs(t, err, queue.ErrClusterSwitchDisabled)
+}
+
+func TestKafkaConsumer_SwitchClusterRejectsUnknownCluster(t *testing.T) {
+	consumer := newTestConsumer(newFakeConsumerGroup(nil))
+	consumer.EnableClusterSwitch(func(cluster string) (sarama.ConsumerGroup, error) {
+		return newFakeConsumerGroup(nil), nil
+	})
+
+	err := consumer.SwitchCluster("tertiary")
+	assert.ErrorIs(t, err, queue.ErrUnknownCluster)
+}
\ No newline at end of file
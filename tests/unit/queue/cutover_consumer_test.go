@@ -0,0 +1,178 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+type partitionOffsets struct {
+	oldest int64
+	newest int64
+	// byTime maps a timestamp in milliseconds to the offset of the first
+	// message at or after it; timestamps missing have none.
+	byTime map[int64]int64
+}
+
+// fakeOffsetClient serves the partitions and offsets of topics.
+type fakeOffsetClient struct {
+	sarama.Client
+	topics map[string]map[int32]partitionOffsets
+}
+
+func (c *fakeOffsetClient) Partitions(topic string) ([]int32, error) {
+	partitions := make([]int32, 0, len(c.topics[topic]))
+	for partition := range c.topics[topic] {
+		partitions = append(partitions, partition)
+	}
+	return partitions, nil
+}
+
+func (c *fakeOffsetClient) GetOffset(topic string, partition int32, at int64) (int64, error) {
+	offsets := c.topics[topic][partition]
+	switch at {
+	case sarama.OffsetNewest:
+		return offsets.newest, nil
+	case sarama.OffsetOldest:
+		return offsets.oldest, nil
+	}
+	if offset, ok := offsets.byTime[at]; ok {
+		return offset, nil
+	}
+	return -1, nil
+}
+
+// fakeOffsetAdmin serves the group's committed offsets.
+type fakeOffsetAdmin struct {
+	sarama.ClusterAdmin
+	committed map[string]map[int32]int64
+	closed    int
+}
+
+func (a *fakeOffsetAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	response := &sarama.OffsetFetchResponse{}
+	for topic, partitions := range a.committed {
+		for partition, offset := range partitions {
+			response.AddBlock(topic, partition, &sarama.OffsetFetchResponseBlock{Offset: offset})
+		}
+	}
+	return response, nil
+}
+
+func (a *fakeOffsetAdmin) Close() error {
+	a.closed++
+	return nil
+}
+
+func newTestCutoverConsumer(t *testing.T, client *fakeOffsetClient, admin *fakeOffsetAdmin) *queue.CutoverConsumer {
+	t.Helper()
+
+	cfg := &config.KafkaConfig{
+		GroupID:        "order-processor",
+		OrderTopic:     "orders",
+		MigrationTopic: "orders-v2",
+		MigrationPhase: queue.MigrationPhaseCutover,
+	}
+	consumer, err := queue.NewCutoverConsumerWithClient(cfg, client, admin)
+	require.NoError(t, err)
+	return consumer
+}
+
+func TestCutoverConsumer_Drained(t *testing.T) {
+	tests := []struct {
+		name      string
+		committed map[int32]int64
+		drained   bool
+	}{
+		{
+			name:      "committed up to the high watermarks",
+			committed: map[int32]int64{0: 10, 1: 4},
+			drained:   true,
+		},
+		{
+			name:      "partition behind",
+			committed: map[int32]int64{0: 10, 1: 3},
+			drained:   false,
+		},
+		{
+			name:      "partition with messages never committed",
+			committed: map[int32]int64{0: 10},
+			drained:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeOffsetClient{topics: map[string]map[int32]partitionOffsets{
+				"orders": {
+					0: {oldest: 0, newest: 10},
+					1: {oldest: 2, newest: 4},
+					// Empty after retention; nothing left to consume.
+					2: {oldest: 7, newest: 7},
+				},
+			}}
+			admin := &fakeOffsetAdmin{committed: map[string]map[int32]int64{"orders": tt.committed}}
+
+			drained, err := newTestCutoverConsumer(t, client, admin).Drained()
+			require.NoError(t, err)
+			assert.Equal(t, tt.drained, drained)
+		})
+	}
+}
+
+func TestCutoverConsumer_StartOffsets(t *testing.T) {
+	startAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := &fakeOffsetClient{topics: map[string]map[int32]partitionOffsets{
+		"orders-v2": {
+			0: {oldest: 0, newest: 20, byTime: map[int64]int64{startAt.UnixMilli(): 12}},
+			1: {oldest: 3, newest: 9},
+			2: {oldest: 0, newest: 40, byTime: map[int64]int64{startAt.UnixMilli(): 30}},
+		},
+	}}
+	// The group already consumed partition 2 of the migration topic.
+	admin := &fakeOffsetAdmin{committed: map[string]map[int32]int64{"orders-v2": {2: 35}}}
+	consumer := newTestCutoverConsumer(t, client, admin)
+
+	offsets, err := consumer.StartOffsets(startAt)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int64{
+		0: 12,
+		// No message since startAt: start at the end of the partition.
+		1: 9,
+	}, offsets)
+
+	offsets, err = consumer.StartOffsets(time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int64{0: 0, 1: 3}, offsets, "without a last message on the old topic the whole topic is read")
+}
+
+func TestCutoverConsumer_CloseTwice(t *testing.T) {
+	admin := &fakeOffsetAdmin{}
+	consumer := newTestCutoverConsumer(t, &fakeOffsetClient{}, admin)
+
+	require.NoError(t, consumer.Close())
+	require.NotPanics(t, func() { consumer.Close() })
+	assert.Equal(t, 1, admin.closed)
+	assert.NoError(t, consumer.Wait())
+}
+
+func TestRecentEvents_EvictsOldest(t *testing.T) {
+	recent := queue.NewRecentEvents(2)
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	recent.Add(first)
+	recent.Add(second)
+	// Adding a remembered ID again does not make it the newest.
+	recent.Add(first)
+	recent.Add(third)
+
+	assert.False(t, recent.Contains(first))
+	assert.True(t, recent.Contains(second))
+	assert.True(t, recent.Contains(third))
+}
\ No newline at end of file
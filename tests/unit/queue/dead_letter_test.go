@@ -0,0 +1,163 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+)
+
+type fakeSyncProducer struct {
+	sarama.SyncProducer
+	sent    []*sarama.ProducerMessage
+	sendErr error
+}
+
+func (p *fakeSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	if p.sendErr != nil {
+		return -1, -1, p.sendErr
+	}
+	p.sent = append(p.sent, msg)
+	return 0, int64(len(p.sent)), nil
+}
+
+func (p *fakeSyncProducer) IsTransactional() bool {
+	return false
+}
+
+func (p *fakeSyncProducer) Close() error {
+	return nil
+}
+
+type fakeDeadLetterRecorder struct {
+	records []*models.DeadLetterEvent
+}
+
+func (r *fakeDeadLetterRecorder) Create(ctx context.Context, event *models.DeadLetterEvent) error {
+	r.records = append(r.records, event)
+	return nil
+}
+
+func headerValue(headers []sarama.RecordHeader, key string) string {
+	for _, h := range headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func failedMessage(eventID uuid.UUID) *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{
+		Topic:     "order-events",
+		Partition: 3,
+		Offset:    42,
+		Key:       []byte(eventID.String()),
+		Value:     []byte(`{"id":"` + eventID.String() + `"}`),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("event_id"), Value: []byte(eventID.String())},
+			{Key: []byte("event_type"), Value: []byte("order.created")},
+		},
+	}
+}
+
+func TestDeadLetterQueue_PublishHandlerFailure(t *testing.T) {
+	producer := &fakeSyncProducer{}
+	recorder := &fakeDeadLetterRecorder{}
+	dlq := queue.NewDeadLetterQueueWithProducer(producer, "order-events-dlq", recorder)
+
+	eventID := uuid.New()
+	message := failedMessage(eventID)
+	require.NoError(t, dlq.Publish(context.Background(), "order-processing-group", message, errors.New("handler failed to process event")))
+
+	require.Len(t, producer.sent, 1)
+	sent := producer.sent[0]
+	assert.Equal(t, "order-events-dlq", sent.Topic)
+
+	value, err := sent.Value.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, message.Value, value)
+
+	assert.Equal(t, eventID.String(), headerValue(sent.Headers, "event_id"))
+	assert.Equal(t, string(models.DeadLetterReasonHandlerFailed), headerValue(sent.Headers, queue.DeadLetterHeaderReason))
+	assert.Equal(t, "handler failed to process event", headerValue(sent.Headers, queue.DeadLetterHeaderError))
+	assert.Equal(t, "order-events", headerValue(sent.Headers, queue.DeadLetterHeaderTopic))
+	assert.Equal(t, "3", headerValue(sent.Headers, queue.DeadLetterHeaderPartition))
+	assert.Equal(t, "42", headerValue(sent.Headers, queue.DeadLetterHeaderOffset))
+	assert.Equal(t, "order-processing-group", headerValue(sent.Headers, queue.DeadLetterHeaderGroup))
+
+	require.Len(t, recorder.records, 1)
+	record := recorder.records[0]
+	require.NotNil(t, record.EventID)
+	assert.Equal(t, eventID, *record.EventID)
+	assert.Equal(t, "order.created", record.EventType)
+	assert.Equal(t, int64(42), record.Offset)
+	assert.Equal(t, "order-events-dlq", record.DLQTopic)
+	assert.Equal(t, message.Key, record.Key)
+}
+
+func TestDeadLetterQueue_PublishFailureIsReturned(t *testing.T) {
+	producer := &fakeSyncProducer{sendErr: sarama.ErrOutOfBrokers}
+	recorder := &fakeDeadLetterRecorder{}
+	dlq := queue.NewDeadLetterQueueWithProducer(producer, "order-events-dlq", recorder)
+
+	err := dlq.Publish(context.Background(), "order-processing-group", failedMessage(uuid.New()), fmt.Errorf("boom"))
+	assert.ErrorIs(t, err, sarama.ErrOutOfBrokers)
+	assert.Empty(t, recorder.records)
+}
+
+func TestKafkaConsumer_DeadLettersUndecodableMessages(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = []*sarama.ConsumerMessage{{Topic: "order-events", Value: []byte("not an event")}}
+	producer := &fakeSyncProducer{}
+	consumer := newTestConsumer(group)
+	consumer.EnableDeadLetters(queue.NewDeadLetterQueueWithProducer(producer, "order-events-dlq", nil))
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 1)
+	require.Len(t, producer.sent, 1)
+	assert.Equal(t, string(models.DeadLetterReasonDecodeFailed), headerValue(producer.sent[0].Headers, queue.DeadLetterHeaderReason))
+}
+
+func TestDeadLetterRequeuer_RequeuesRetriedMessageToMainTopic(t *testing.T) {
+	producer := &fakeSyncProducer{}
+	requeuer := queue.NewDeadLetterRequeuerWithProducer(producer)
+
+	eventID := uuid.New()
+	event := &models.DeadLetterEvent{
+		ID:    uuid.New(),
+		Topic: "order-events-retry-30m",
+		Headers: map[string]string{
+			"event_id":                     eventID.String(),
+			queue.RetryHeaderAttempt:       "3",
+			queue.RetryHeaderOriginalTopic: "order-events",
+		},
+		Key:     []byte(eventID.String()),
+		Payload: []byte(`{"id":"` + eventID.String() + `"}`),
+	}
+	require.NoError(t, requeuer.Requeue(context.Background(), event))
+
+	require.Len(t, producer.sent, 1)
+	sent := producer.sent[0]
+	assert.Equal(t, "order-events", sent.Topic)
+
+	key, err := sent.Key.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, event.Key, key)
+	value, err := sent.Value.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, event.Payload, value)
+
+	require.Len(t, sent.Headers, 1)
+	assert.Equal(t, eventID.String(), headerValue(sent.Headers, "event_id"))
+}
\ No newline at end of file
@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"google.golang.org/protobuf/encoding/protowire"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+)
+
+func feedEvent(t *testing.T, eventType models.EventType, customerID uuid.UUID) *models.Event {
+	t.Helper()
+	order := &models.Order{ID: uuid.New(), CustomerID: customerID, Status: models.OrderStatusCompleted}
+	event := models.NewOrderCompletedEvent(order)
+	event.Type = eventType
+	return event
+}
+
+func TestEventFeed_FiltersByTypeAndCustomer(t *testing.T) {
+	feed := queue.NewEventFeed(10)
+	customerID := uuid.New()
+	events, unsubscribe := feed.Subscribe(queue.FeedFilter{
+		Types:      []models.EventType{models.OrderCompletedEvent},
+		CustomerID: customerID.String(),
+	})
+	defer unsubscribe()
+
+	matching := feedEvent(t, models.OrderCompletedEvent, customerID)
+	require.NoError(t, feed.HandleEvent(context.Background(), feedEvent(t, models.OrderFailedEvent, customerID)))
+	require.NoError(t, feed.HandleEvent(context.Background(), feedEvent(t, models.OrderCompletedEvent, uuid.New())))
+	require.NoError(t, feed.HandleEvent(context.Background(), matching))
+
+	require.Len(t, events, 1)
+	assert.Equal(t, matching.ID, (<-events).ID)
+}
+
+func TestEventFeed_ClosesStreamThatFallsBehind(t *testing.T) {
+	feed := queue.NewEventFeed(1)
+	events, unsubscribe := feed.Subscribe(queue.FeedFilter{})
+	defer unsubscribe()
+
+	require.NoError(t, feed.HandleEvent(context.Background(), feedEvent(t, models.OrderCompletedEvent, uuid.New())))
+	require.NoError(t, feed.HandleEvent(context.Background(), feedEvent(t, models.OrderCompletedEvent, uuid.New())))
+
+	_, ok := <-events
+	assert.True(t, ok)
+	_, ok = <-events
+	assert.False(t, ok)
+	assert.Equal(t, 0, feed.Streams())
+}
+
+// h2cClient speaks cleartext HTTP/2, as gRPC clients do.
+func h2cClient() *http.Client {
+	return &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+func streamRequest(customerID string) io.Reader {
+	var message []byte
+	message = protowire.AppendTag(message, 1, protowire.BytesType)
+	message = protowire.AppendString(message, string(models.OrderCompletedEvent))
+	message = protowire.AppendTag(message, 2, protowire.BytesType)
+	message = protowire.AppendString(message, customerID)
+
+	frame := make([]byte, 5)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return bytes.NewReader(append(frame, message...))
+}
+
+func TestFeedServer_StreamsMatchingEventsAsEnvelopes(t *testing.T) {
+	feed := queue.NewEventFeed(10)
+	feedServer := queue.NewFeedServer(feed)
+	server := httptest.NewServer(feedServer.Handler())
+	defer server.Close()
+
+	customerID := uuid.New()
+	req, err := http.NewRequest(http.MethodPost, server.URL+queue.OrderEventsStreamMethod, streamRequest(customerID.String()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc")
+
+	resp, err := h2cClient().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/grpc", resp.Header.Get("Content-Type"))
+
+	require.Eventually(t, func() bool { return feed.Streams() == 1 }, time.Second, 10*time.Millisecond)
+	event := feedEvent(t, models.OrderCompletedEvent, customerID)
+	require.NoError(t, feed.HandleEvent(context.Background(), feedEvent(t, models.OrderFailedEvent, customerID)))
+	require.NoError(t, feed.HandleEvent(context.Background(), event))
+
+	var prefix [5]byte
+	_, err = io.ReadFull(resp.Body, prefix[:])
+	require.NoError(t, err)
+	message := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+	_, err = io.ReadFull(resp.Body, message)
+	require.NoError(t, err)
+
+	decoded, err := queue.ProtobufCodec{}.Unmarshal(message)
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, decoded.ID)
+	assert.Equal(t, models.OrderCompletedEvent, decoded.Type)
+
+	feedServer.Close()
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "14", resp.Trailer.Get("Grpc-Status"))
+}
+
+func TestFeedServer_RejectsUnknownMethod(t *testing.T) {
+	server := httptest.NewServer(queue.NewFeedServer(queue.NewEventFeed(10)).Handler())
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/orders.events.v1.OrderEvents/Replay", streamRequest(""))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc")
+
+	resp, err := h2cClient().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "12", resp.Trailer.Get("Grpc-Status"))
+}
\ No newline at end of file
@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+type fakeTopicProducer struct {
+	err       error
+	published int
+}
+
+func (p *fakeTopicProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	return p.PublishEventToTopic(ctx, "order-events", event)
+}
+
+func (p *fakeTopicProducer) PublishEventToTopic(ctx context.Context, topic string, event *models.Event) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.published++
+	return nil
+}
+
+func (p *fakeTopicProducer) Close() error {
+	return nil
+}
+
+func TestFailoverProducer_FailsOverAfterThreshold(t *testing.T) {
+	primary := &fakeTopicProducer{err: errors.New("kafka: client has run out of available brokers")}
+	secondary := &fakeTopicProducer{}
+	producer := queue.NewFailoverProducer(primary, secondary, &config.KafkaConfig{
+		FailoverThreshold: 2,
+		FailbackInterval:  60,
+	})
+	event := models.NewEvent(models.OrderCreatedEvent, nil)
+
+	require.Error(t, producer.PublishEvent(context.Background(), event))
+	assert.Equal(t, queue.ClusterPrimary, producer.ClusterStatus().Active)
+	assert.Zero(t, secondary.published)
+
+	require.NoError(t, producer.PublishEvent(context.Background(), event))
+	require.NoError(t, producer.PublishEvent(context.Background(), event))
+
+	status := producer.ClusterStatus()
+	assert.Equal(t, queue.ClusterSecondary, status.Active)
+	assert.Equal(t, uint64(1), status.Failovers)
+	assert.NotNil(t, status.FailedOverAt)
+	assert.Equal(t, 2, secondary.published)
+}
+
+func TestFailoverProducer_FailsBackWhenPrimaryRecovers(t *testing.T) {
+	primary := &fakeTopicProducer{err: errors.New("connection refused")}
+	secondary := &fakeTopicProducer{}
+	producer := queue.NewFailoverProducer(primary, secondary, &config.KafkaConfig{FailoverThreshold: 1})
+	event := models.NewEvent(models.OrderCreatedEvent, nil)
+
+	require.NoError(t, producer.PublishEvent(context.Background(), event))
+	assert.Equal(t, queue.ClusterSecondary, producer.ClusterStatus().Active)
+
+	primary.err = nil
+	require.NoError(t, producer.PublishEvent(context.Background(), event))
+
+	status := producer.ClusterStatus()
+	assert.Equal(t, queue.ClusterPrimary, status.Active)
+	assert.Equal(t, uint64(1), status.Failbacks)
+	assert.Nil(t, status.FailedOverAt)
+	assert.Equal(t, 1, primary.published)
+	assert.Equal(t, 1, secondary.published)
+}
+
+func TestFailoverProducer_OversizedEventDoesNotFailOver(t *testing.T) {
+	primary := &fakeTopicProducer{err: queue.ErrPayloadTooLarge}
+	secondary := &fakeTopicProducer{}
+	producer := queue.NewFailoverProducer(primary, secondary, &config.KafkaConfig{FailoverThreshold: 1})
+
+	err := producer.PublishEvent(context.Background(), models.NewEvent(models.OrderCreatedEvent, nil))
+	require.ErrorIs(t, err, queue.ErrPayloadTooLarge)
+	assert.Equal(t, queue.ClusterPrimary, producer.ClusterStatus().Active)
+	assert.Zero(t, secondary.published)
+}
+
+func TestFailoverProducer_WaitsForFailoverPeriod(t *testing.T) {
+	primary := &fakeTopicProducer{err: errors.New("connection refused")}
+	secondary := &fakeTopicProducer{}
+	producer := queue.NewFailoverProducer(primary, secondary, &config.KafkaConfig{
+		FailoverThreshold: 1,
+		FailoverPeriod:    60,
+	})
+	event := models.NewEvent(models.OrderCreatedEvent, nil)
+
+	require.Error(t, producer.PublishEvent(context.Background(), event))
+	require.Error(t, producer.PublishEvent(context.Background(), event))
+
+	status := producer.ClusterStatus()
+	assert.Equal(t, queue.ClusterPrimary, status.Active)
+	assert.Equal(t, 2, status.ConsecutiveFailures)
+	assert.Zero(t, secondary.published)
+}
\ No newline at end of file
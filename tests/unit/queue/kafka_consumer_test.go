@@ -0,0 +1,577 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+type fakeConsumerGroup struct {
+	consumeErr error
+	claims     map[string][]int32
+	messages   []*sarama.ConsumerMessage
+	highWater  int64
+	marked     atomic.Int32
+	commits    atomic.Int32
+	calls      atomic.Int32
+	errs       chan error
+	closed     chan struct{}
+
+	mu      sync.Mutex
+	paused  map[string][]int32
+	resumed map[string][]int32
+}
+
+func newFakeConsumerGroup(consumeErr error) *fakeConsumerGroup {
+	return &fakeConsumerGroup{
+		consumeErr: consumeErr,
+		errs:       make(chan error, 1),
+		closed:     make(chan struct{}),
+	}
+}
+
+func (g *fakeConsumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	g.calls.Add(1)
+	if g.consumeErr != nil {
+		return g.consumeErr
+	}
+
+	if g.claims != nil {
+		session := &fakeSession{ctx: ctx, claims: g.claims, marked: &g.marked, commits: &g.commits}
+		if err := handler.Setup(session); err != nil {
+			return err
+		}
+		defer handler.Cleanup(session)
+
+		if len(g.messages) > 0 {
+			claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, len(g.messages)), highWater: g.highWater}
+			for _, message := range g.messages {
+				claim.messages <- message
+			}
+			close(claim.messages)
+			g.messages = nil
+			if err := handler.ConsumeClaim(session, claim); err != nil {
+				return err
+			}
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-g.closed:
+		return sarama.ErrClosedConsumerGroup
+	}
+}
+
+func (g *fakeConsumerGroup) Errors() <-chan error {
+	return g.errs
+}
+
+func (g *fakeConsumerGroup) Close() error {
+	close(g.closed)
+	return nil
+}
+
+func (g *fakeConsumerGroup) Pause(partitions map[string][]int32) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.paused = partitions
+}
+
+func (g *fakeConsumerGroup) Resume(partitions map[string][]int32) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.resumed = partitions
+}
+
+func (g *fakeConsumerGroup) PauseAll()  {}
+func (g *fakeConsumerGroup) ResumeAll() {}
+
+type fakeSession struct {
+	ctx     context.Context
+	claims  map[string][]int32
+	marked  *atomic.Int32
+	commits *atomic.Int32
+}
+
+func (s *fakeSession) Claims() map[string][]int32 {
+	return s.claims
+}
+
+func (s *fakeSession) MemberID() string {
+	return "member-1"
+}
+
+func (s *fakeSession) GenerationID() int32 {
+	return 1
+}
+
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {}
+
+func (s *fakeSession) Commit() {
+	s.commits.Add(1)
+}
+
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked.Add(1)
+}
+
+func (s *fakeSession) Context() context.Context {
+	return s.ctx
+}
+
+type fakeClaim struct {
+	messages  chan *sarama.ConsumerMessage
+	highWater int64
+}
+
+func (c *fakeClaim) Topic() string {
+	return "order-events"
+}
+
+func (c *fakeClaim) Partition() int32 {
+	return 0
+}
+
+func (c *fakeClaim) InitialOffset() int64 {
+	return 0
+}
+
+func (c *fakeClaim) HighWaterMarkOffset() int64 {
+	return c.highWater
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
+
+type noopHandler struct{}
+
+func (noopHandler) HandleEvent(ctx context.Context, event *models.Event) error {
+	return nil
+}
+
+func waitResult(t *testing.T, consumer *queue.KafkaConsumer) error {
+	t.Helper()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- consumer.Wait()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer did not stop")
+		return nil
+	}
+}
+
+func newTestConsumer(group sarama.ConsumerGroup) *queue.KafkaConsumer {
+	return newTestConsumerWithThreshold(group, 0)
+}
+
+func newTestConsumerWithThreshold(group sarama.ConsumerGroup, threshold int) *queue.KafkaConsumer {
+	cfg := &config.KafkaConfig{GroupID: "test-group", EmptyAssignmentThreshold: threshold}
+	return queue.NewKafkaConsumerWithGroup(group, cfg, []string{"order-events"})
+}
+
+func waitForAssignment(t *testing.T, consumer *queue.KafkaConsumer, count int) queue.PartitionAssignment {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		assignment := consumer.Assignment()
+		if assignment.Count == count {
+			return assignment
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d assigned partitions, got %d", count, assignment.Count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitForMarked(t *testing.T, group *fakeConsumerGroup, count int32) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for group.marked.Load() < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d marked messages, got %d", count, group.marked.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKafkaConsumer_FatalConsumeErrorStopsConsumer(t *testing.T) {
+	group := newFakeConsumerGroup(sarama.ErrTopicAuthorizationFailed)
+	consumer := newTestConsumer(group)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+
+	err := waitResult(t, consumer)
+	assert.True(t, errors.Is(err, sarama.ErrTopicAuthorizationFailed))
+	assert.Equal(t, int32(1), group.calls.Load())
+
+	require.NoError(t, consumer.Close())
+}
+
+func TestKafkaConsumer_FatalGroupErrorStopsConsumer(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	consumer := newTestConsumer(group)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	group.errs <- sarama.ErrGroupAuthorizationFailed
+
+	err := waitResult(t, consumer)
+	assert.True(t, errors.Is(err, sarama.ErrGroupAuthorizationFailed))
+
+	require.NoError(t, consumer.Close())
+}
+
+func TestKafkaConsumer_TransientErrorKeepsConsuming(t *testing.T) {
+	group := newFakeConsumerGroup(sarama.ErrOutOfBrokers)
+	consumer := newTestConsumer(group)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	group.errs <- sarama.ErrNotCoordinatorForConsumer
+
+	time.Sleep(1500 * time.Millisecond)
+	assert.GreaterOrEqual(t, group.calls.Load(), int32(2))
+
+	require.NoError(t, consumer.Close())
+	assert.NoError(t, waitResult(t, consumer))
+}
+
+func TestKafkaConsumer_CloseStopsWithoutError(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	consumer := newTestConsumer(group)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	require.NoError(t, consumer.Close())
+
+	assert.NoError(t, waitResult(t, consumer))
+	select {
+	case <-group.closed:
+	default:
+		t.Fatal("consumer group was not closed")
+	}
+}
+
+func TestKafkaConsumer_WaitWithoutSubscribe(t *testing.T) {
+	consumer := newTestConsumer(newFakeConsumerGroup(nil))
+
+	assert.NoError(t, consumer.Wait())
+}
+
+func TestKafkaConsumer_ReportsAssignedPartitions(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {2, 0, 1}}
+	consumer := newTestConsumerWithThreshold(group, 60)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+
+	assignment := waitForAssignment(t, consumer, 3)
+	assert.Equal(t, "test-group", assignment.GroupID)
+	assert.Equal(t, []int32{0, 1, 2}, assignment.Partitions["order-events"])
+	assert.Nil(t, assignment.EmptySince)
+	assert.True(t, assignment.Healthy)
+}
+
+func TestKafkaConsumer_EmptyAssignmentBecomesUnhealthy(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{}
+	consumer := newTestConsumerWithThreshold(group, 1)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+
+	assignment := consumer.Assignment()
+	assert.Equal(t, 0, assignment.Count)
+	assert.NotNil(t, assignment.EmptySince)
+	assert.True(t, assignment.Healthy)
+
+	time.Sleep(1100 * time.Millisecond)
+	assert.False(t, consumer.Assignment().Healthy)
+}
+
+func TestKafkaConsumer_EmptyAssignmentCheckDisabled(t *testing.T) {
+	consumer := newTestConsumerWithThreshold(newFakeConsumerGroup(nil), 0)
+
+	assignment := consumer.Assignment()
+	assert.Equal(t, 0, assignment.Count)
+	assert.True(t, assignment.Healthy)
+}
+
+func commitTestGroup(t *testing.T, count int) *fakeConsumerGroup {
+	t.Helper()
+
+	event := models.NewEvent(models.OrderCreatedEvent, nil)
+	value, err := event.ToJSON()
+	require.NoError(t, err)
+
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	for i := 0; i < count; i++ {
+		group.messages = append(group.messages, &sarama.ConsumerMessage{Topic: "order-events", Offset: int64(i), Value: value})
+	}
+	return group
+}
+
+func TestKafkaConsumer_ManualCommitBatchesMarkedOffsets(t *testing.T) {
+	group := commitTestGroup(t, 5)
+	cfg := &config.KafkaConfig{GroupID: "test-group", CommitBatchSize: 2, CommitInterval: 60000}
+	consumer := queue.NewKafkaConsumerWithGroup(group, cfg, []string{"order-events"})
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 5)
+	deadline := time.Now().Add(5 * time.Second)
+	for group.commits.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Two full batches, then the remainder when the claim ends.
+	assert.Equal(t, int32(3), group.commits.Load())
+}
+
+func TestKafkaConsumer_ManualCommitSkipsFailedMessages(t *testing.T) {
+	group := commitTestGroup(t, 2)
+	cfg := &config.KafkaConfig{GroupID: "test-group", CommitBatchSize: 1}
+	consumer := queue.NewKafkaConsumerWithGroup(group, cfg, []string{"order-events"})
+
+	failing := queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		return errors.New("database unavailable")
+	})
+	require.NoError(t, consumer.Subscribe(context.Background(), failing))
+	defer consumer.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(0), group.marked.Load())
+	assert.Equal(t, int32(0), group.commits.Load())
+}
+
+func TestKafkaConsumer_AutoCommitLeavesCommitsToSarama(t *testing.T) {
+	group := commitTestGroup(t, 3)
+	cfg := &config.KafkaConfig{GroupID: "test-group", EnableAutoCommit: true}
+	consumer := queue.NewKafkaConsumerWithGroup(group, cfg, []string{"order-events"})
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 3)
+	assert.Equal(t, int32(0), group.commits.Load())
+}
+
+func TestKafkaConsumer_WorkersMarkContiguousOffsets(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+
+	var blocked *models.Event
+	for i, key := range []string{"order-1", "order-2", "order-2"} {
+		event := models.NewEvent(models.OrderCreatedEvent, nil)
+		if i == 0 {
+			blocked = event
+		}
+		value, err := event.ToJSON()
+		require.NoError(t, err)
+		group.messages = append(group.messages, &sarama.ConsumerMessage{Topic: "order-events", Offset: int64(i), Key: []byte(key), Value: value})
+	}
+
+	release := make(chan struct{})
+	var processed atomic.Int32
+	handler := queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		if event.ID == blocked.ID {
+			<-release
+		}
+		processed.Add(1)
+		return nil
+	})
+
+	cfg := &config.KafkaConfig{GroupID: "test-group", CommitBatchSize: 1, CommitInterval: 60000, ConsumerWorkers: 2}
+	consumer := queue.NewKafkaConsumerWithGroup(group, cfg, []string{"order-events"})
+	require.NoError(t, consumer.Subscribe(context.Background(), handler))
+	defer consumer.Close()
+
+	// order-2 is processed while order-1, on another worker, is still in
+	// flight, but offsets past order-1 are not marked yet.
+	deadline := time.Now().Add(5 * time.Second)
+	for processed.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, int32(2), processed.Load())
+	assert.Equal(t, int32(0), group.marked.Load())
+
+	close(release)
+	waitForMarked(t, group, 3)
+	assert.Equal(t, int32(3), processed.Load())
+}
+
+func TestKafkaConsumer_SkipsFailedMessageWithoutDeadLetters(t *testing.T) {
+	group := commitTestGroup(t, 2)
+	cfg := &config.KafkaConfig{GroupID: "test-group", CommitBatchSize: 1}
+	consumer := queue.NewKafkaConsumerWithGroup(group, cfg, []string{"order-events"})
+
+	var handled atomic.Int32
+	failing := queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		handled.Add(1)
+		return errors.New("order not found")
+	})
+	require.NoError(t, consumer.Subscribe(context.Background(), failing))
+	defer consumer.Close()
+
+	// With nowhere to hand them off, failed messages are logged and skipped
+	// rather than ending the session and rebalancing onto them again.
+	assert.Eventually(t, func() bool { return handled.Load() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), group.calls.Load())
+}
+
+// togglingSyncProducer fails to send while fail is set.
+type togglingSyncProducer struct {
+	fakeSyncProducer
+	mu       sync.Mutex
+	fail     bool
+	attempts int
+}
+
+func (p *togglingSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts++
+	if p.fail {
+		return -1, -1, sarama.ErrOutOfBrokers
+	}
+	return p.fakeSyncProducer.SendMessage(msg)
+}
+
+func (p *togglingSyncProducer) setFail(fail bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fail = fail
+}
+
+func (p *togglingSyncProducer) counts() (attempts, sent int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.attempts, len(p.sent)
+}
+
+func TestKafkaConsumer_WorkersHoldPartitionWhileDeadLetteringFails(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+
+	var failing *models.Event
+	for i, key := range []string{"order-1", "order-2", "order-2"} {
+		event := models.NewEvent(models.OrderCreatedEvent, nil)
+		if i == 0 {
+			failing = event
+		}
+		value, err := event.ToJSON()
+		require.NoError(t, err)
+		group.messages = append(group.messages, &sarama.ConsumerMessage{Topic: "order-events", Offset: int64(i), Key: []byte(key), Value: value})
+	}
+
+	var processed atomic.Int32
+	handler := queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		if event.ID == failing.ID {
+			return errors.New("database unavailable")
+		}
+		processed.Add(1)
+		return nil
+	})
+
+	producer := &togglingSyncProducer{fail: true}
+	cfg := &config.KafkaConfig{GroupID: "test-group", CommitBatchSize: 1, CommitInterval: 60000, ConsumerWorkers: 2}
+	consumer := queue.NewKafkaConsumerWithGroup(group, cfg, []string{"order-events"})
+	consumer.EnableDeadLetters(queue.NewDeadLetterQueueWithProducer(producer, "order-events-dlq", &fakeDeadLetterRecorder{}))
+	require.NoError(t, consumer.Subscribe(context.Background(), handler))
+	defer consumer.Close()
+
+	// order-2 is processed while order-1 cannot be dead-lettered; marking it
+	// would commit past the failed message.
+	assert.Eventually(t, func() bool {
+		attempts, _ := producer.counts()
+		return attempts >= 2 && processed.Load() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(0), group.marked.Load())
+
+	producer.setFail(false)
+	waitForMarked(t, group, 3)
+	_, sent := producer.counts()
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, int32(1), group.calls.Load(), "the session keeps running while the partition backs off")
+}
+
+func TestKafkaConsumer_PauseAndResumePartitions(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0, 1}}
+	consumer := newTestConsumer(group)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+	waitForAssignment(t, consumer, 2)
+
+	require.NoError(t, consumer.Pause("order-events", []int32{1}))
+	assert.Equal(t, map[string][]int32{"order-events": {1}}, consumer.Paused())
+	assert.Equal(t, map[string][]int32{"order-events": {1}}, group.paused)
+
+	require.NoError(t, consumer.Resume("order-events", []int32{1}))
+	assert.Empty(t, consumer.Paused())
+	assert.Equal(t, map[string][]int32{"order-events": {1}}, group.resumed)
+
+	err := consumer.Pause("payments", nil)
+	assert.ErrorIs(t, err, queue.ErrTopicNotConsumed)
+	assert.ErrorContains(t, err, "payments")
+}
+
+func TestKafkaConsumer_PausedTopicResumesAsAWhole(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0, 1}}
+	consumer := newTestConsumer(group)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+	waitForAssignment(t, consumer, 2)
+
+	require.NoError(t, consumer.Pause("", nil))
+	assert.Equal(t, map[string][]int32{"order-events": {0, 1}}, consumer.Paused())
+
+	assert.ErrorIs(t, consumer.Resume("order-events", []int32{0}), queue.ErrTopicPausedAsWhole)
+	assert.Equal(t, map[string][]int32{"order-events": {0, 1}}, consumer.Paused())
+
+	require.NoError(t, consumer.Resume("order-events", nil))
+	assert.Empty(t, consumer.Paused())
+}
+
+func TestNewBalanceStrategy(t *testing.T) {
+	for name, want := range map[string]string{
+		"":           sarama.StickyBalanceStrategyName,
+		"sticky":     sarama.StickyBalanceStrategyName,
+		"roundrobin": sarama.RoundRobinBalanceStrategyName,
+		"range":      sarama.RangeBalanceStrategyName,
+	} {
+		strategy, err := queue.NewBalanceStrategy(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, strategy.Name())
+	}
+
+	_, err := queue.NewBalanceStrategy("cooperative-sticky")
+	assert.ErrorContains(t, err, "not supported")
+	_, err = queue.NewBalanceStrategy("fastest")
+	assert.ErrorContains(t, err, "unsupported rebalance strategy")
+}
\ No newline at end of file
@@ -0,0 +1,138 @@
+package queue
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+func TestKafkaProducer_RejectsOversizedPayload(t *testing.T) {
+	fake := &fakeSyncProducer{}
+	producer, err := queue.NewKafkaProducerWithProducer(fake, &config.KafkaConfig{
+		OrderTopic:      "order-events",
+		MaxMessageBytes: 2048,
+	})
+	require.NoError(t, err)
+
+	small := models.NewEvent(models.OrderCreatedEvent, map[string]string{"note": "ok"})
+	require.NoError(t, producer.PublishEvent(context.Background(), small))
+
+	large := models.NewEvent(models.OrderCreatedEvent, map[string]string{"note": strings.Repeat("x", 4096)})
+	err = producer.PublishEvent(context.Background(), large)
+	require.ErrorIs(t, err, queue.ErrPayloadTooLarge)
+	assert.Len(t, fake.sent, 1)
+
+	stats := producer.PayloadStats()[models.OrderCreatedEvent]
+	assert.Equal(t, uint64(2), stats.Count)
+	assert.Equal(t, uint64(1), stats.Rejected)
+	assert.Greater(t, stats.Max, 4096)
+	assert.Equal(t, uint64(1), stats.Buckets["1KiB"])
+	assert.Equal(t, uint64(1), stats.Buckets["16KiB"])
+}
+
+func TestKafkaProducer_ZeroLimitDisablesGuard(t *testing.T) {
+	fake := &fakeSyncProducer{}
+	producer, err := queue.NewKafkaProducerWithProducer(fake, &config.KafkaConfig{OrderTopic: "order-events"})
+	require.NoError(t, err)
+
+	event := models.NewEvent(models.OrderCompletedEvent, map[string]string{"note": strings.Repeat("x", 2<<20)})
+	require.NoError(t, producer.PublishEvent(context.Background(), event))
+
+	stats := producer.PayloadStats()[models.OrderCompletedEvent]
+	assert.Equal(t, uint64(0), stats.Rejected)
+	assert.Equal(t, uint64(1), stats.Buckets["+Inf"])
+}
+
+// blockingSyncProducer holds every send until release is closed.
+type blockingSyncProducer struct {
+	fakeSyncProducer
+	release chan struct{}
+}
+
+func (p *blockingSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	<-p.release
+	return 0, 0, nil
+}
+
+func TestKafkaProducer_PublishTimesOut(t *testing.T) {
+	fake := &blockingSyncProducer{release: make(chan struct{})}
+	defer close(fake.release)
+	producer, err := queue.NewKafkaProducerWithProducer(fake, &config.KafkaConfig{
+		OrderTopic:     "order-events",
+		PublishTimeout: 20,
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = producer.PublishEvent(context.Background(), models.NewEvent(models.OrderCreatedEvent, nil))
+	require.ErrorIs(t, err, queue.ErrPublishTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, queue.ErrBrokerUnavailable)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestKafkaProducer_PublishHonorsContext(t *testing.T) {
+	fake := &blockingSyncProducer{release: make(chan struct{})}
+	defer close(fake.release)
+	producer, err := queue.NewKafkaProducerWithProducer(fake, &config.KafkaConfig{OrderTopic: "order-events"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	err = producer.PublishEvent(ctx, models.NewEvent(models.OrderCreatedEvent, nil))
+	require.ErrorIs(t, err, queue.ErrPublishTimeout)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestKafkaProducer_BrokerErrorIsTyped(t *testing.T) {
+	fake := &fakeSyncProducer{sendErr: sarama.ErrOutOfBrokers}
+	producer, err := queue.NewKafkaProducerWithProducer(fake, &config.KafkaConfig{OrderTopic: "order-events"})
+	require.NoError(t, err)
+
+	err = producer.PublishEvent(context.Background(), models.NewEvent(models.OrderCreatedEvent, nil))
+	require.ErrorIs(t, err, queue.ErrBrokerUnavailable)
+	assert.ErrorIs(t, err, sarama.ErrOutOfBrokers)
+	assert.NotErrorIs(t, err, queue.ErrPublishTimeout)
+}
+
+func TestKafkaProducer_BroadcastEvent(t *testing.T) {
+	fake := &fakeSyncProducer{}
+	client := &fakeOffsetClient{topics: map[string]map[int32]partitionOffsets{
+		"order-events":    {0: {}, 1: {}, 2: {}},
+		"order-events-v2": {0: {}, 1: {}},
+	}}
+	producer, err := queue.NewKafkaProducerWithClient(fake, client, &config.KafkaConfig{
+		OrderTopic:     "order-events",
+		MigrationTopic: "order-events-v2",
+		MigrationPhase: queue.MigrationPhaseDual,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, producer.BroadcastEvent(context.Background(), models.NewHeartbeatEvent("producer-1", 1)))
+
+	sent := map[string][]int32{}
+	for _, message := range fake.sent {
+		sent[message.Topic] = append(sent[message.Topic], message.Partition)
+	}
+	assert.ElementsMatch(t, []int32{0, 1, 2}, sent["order-events"])
+	assert.ElementsMatch(t, []int32{0, 1}, sent["order-events-v2"])
+}
+
+func TestKafkaProducer_BroadcastEventWithoutClient(t *testing.T) {
+	producer, err := queue.NewKafkaProducerWithProducer(&fakeSyncProducer{}, &config.KafkaConfig{OrderTopic: "order-events"})
+	require.NoError(t, err)
+
+	assert.Error(t, producer.BroadcastEvent(context.Background(), models.NewHeartbeatEvent("producer-1", 1)))
+}
\ No newline at end of file
@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+func eventMessages(t *testing.T, count int) []*sarama.ConsumerMessage {
+	messages := make([]*sarama.ConsumerMessage, 0, count)
+	for i := 0; i < count; i++ {
+		value, err := models.NewEvent(models.OrderCreatedEvent, nil).ToJSON()
+		require.NoError(t, err)
+		messages = append(messages, &sarama.ConsumerMessage{Topic: "order-events", Offset: int64(i), Value: value})
+	}
+	return messages
+}
+
+func newLagTestConsumer(group sarama.ConsumerGroup, maxLag int) *queue.KafkaConsumer {
+	cfg := &config.KafkaConfig{GroupID: "test-group", ReadyMaxLag: maxLag}
+	return queue.NewKafkaConsumerWithGroup(group, cfg, []string{"order-events"})
+}
+
+func TestKafkaConsumer_NotReadyBeforeJoining(t *testing.T) {
+	consumer := newLagTestConsumer(newFakeConsumerGroup(nil), 10)
+
+	lag := consumer.Lag()
+	assert.False(t, lag.Ready)
+	assert.Equal(t, int64(10), lag.Threshold)
+}
+
+func TestKafkaConsumer_NotReadyWhileLagExceedsThreshold(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = eventMessages(t, 1)
+	group.highWater = 5
+	consumer := newLagTestConsumer(group, 2)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 1)
+	lag := consumer.Lag()
+	assert.Equal(t, int64(4), lag.Total)
+	assert.Equal(t, int64(4), lag.Partitions["order-events"][0])
+	assert.False(t, lag.Ready)
+}
+
+func TestKafkaConsumer_ReadyOnceCaughtUp(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = eventMessages(t, 3)
+	group.highWater = 5
+	consumer := newLagTestConsumer(group, 2)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 3)
+	lag := consumer.Lag()
+	assert.Equal(t, int64(2), lag.Total)
+	assert.True(t, lag.Ready)
+}
\ No newline at end of file
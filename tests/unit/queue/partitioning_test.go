@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+)
+
+// Expected values from the Java client's Utils.murmur2 tests.
+func TestMurmur2_MatchesJavaClient(t *testing.T) {
+	cases := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+
+	for key, expected := range cases {
+		assert.Equal(t, expected, queue.Murmur2([]byte(key)), key)
+	}
+}
+
+func TestMurmur2Partitioner(t *testing.T) {
+	partitioner := queue.NewMurmur2Partitioner("order-events")
+
+	partition, err := partitioner.Partition(&sarama.ProducerMessage{Key: sarama.StringEncoder("foobar")}, 12)
+	require.NoError(t, err)
+	assert.Equal(t, (int32(-790332482)&0x7fffffff)%12, partition)
+
+	partition, err = partitioner.Partition(&sarama.ProducerMessage{}, 12)
+	require.NoError(t, err)
+	assert.True(t, partition >= 0 && partition < 12)
+	assert.True(t, partitioner.RequiresConsistency())
+}
+
+func TestNewKeyFunc(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), CustomerID: uuid.New()}
+	created := models.NewOrderCreatedEvent(order)
+	headers := []sarama.RecordHeader{{Key: []byte("event_type"), Value: []byte(created.Type)}}
+
+	key, err := queue.NewKeyFunc(queue.KeyStrategyEventID, "")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID.String(), key(created, headers))
+
+	key, err = queue.NewKeyFunc(queue.KeyStrategyOrderID, "")
+	require.NoError(t, err)
+	assert.Equal(t, order.ID.String(), key(created, headers))
+
+	key, err = queue.NewKeyFunc("", "")
+	require.NoError(t, err)
+	assert.Equal(t, order.ID.String(), key(created, headers))
+
+	key, err = queue.NewKeyFunc(queue.KeyStrategyCustomerID, "")
+	require.NoError(t, err)
+	assert.Equal(t, order.CustomerID.String(), key(created, headers))
+
+	key, err = queue.NewKeyFunc(queue.KeyStrategyHeader, "event_type")
+	require.NoError(t, err)
+	assert.Equal(t, string(created.Type), key(created, headers))
+	assert.Equal(t, created.ID.String(), key(created, nil))
+}
+
+func TestNewKeyFunc_Invalid(t *testing.T) {
+	_, err := queue.NewKeyFunc(queue.KeyStrategyHeader, "")
+	assert.Error(t, err)
+
+	_, err = queue.NewKeyFunc("partition", "")
+	assert.Error(t, err)
+
+	_, err = queue.NewPartitioner("crc32")
+	assert.Error(t, err)
+}
\ No newline at end of file
@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+)
+
+func TestProtobufCodec_RoundTrip(t *testing.T) {
+	order := &models.Order{
+		ID:          uuid.New(),
+		CustomerID:  uuid.New(),
+		Channel:     models.OrderChannelMobile,
+		TotalAmount: 59.97,
+		CreatedAt:   time.Date(2025, 8, 30, 12, 0, 0, 123456789, time.UTC),
+		Items: []models.OrderItem{
+			{ID: uuid.New(), ProductID: uuid.New(), Quantity: 3, Price: 19.99, Total: 59.97},
+		},
+	}
+	event := models.NewOrderCreatedEvent(order)
+	event.Region = "eu-west-1"
+	event.Sequence = 42
+
+	codec := queue.ProtobufCodec{}
+	encoded, err := codec.Marshal(event)
+	require.NoError(t, err)
+
+	decoded, err := codec.Unmarshal(encoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, event.ID, decoded.ID)
+	assert.Equal(t, event.Type, decoded.Type)
+	assert.Equal(t, event.Version, decoded.Version)
+	assert.Equal(t, "eu-west-1", decoded.Region)
+	assert.Equal(t, uint64(42), decoded.Sequence)
+	assert.True(t, event.Timestamp.Equal(decoded.Timestamp))
+
+	var data models.OrderCreatedEventData
+	require.NoError(t, decoded.DecodeData(&data))
+	assert.Equal(t, order.ID, data.OrderID)
+	assert.Equal(t, models.OrderChannelMobile, data.Channel)
+	assert.Equal(t, order.TotalAmount, data.TotalAmount)
+	assert.True(t, order.CreatedAt.Equal(data.CreatedAt))
+	require.Len(t, data.Items, 1)
+	assert.Equal(t, order.Items[0].ProductID, data.Items[0].ProductID)
+	assert.Equal(t, 3, data.Items[0].Quantity)
+
+	_, isMap := decoded.Data.(map[string]interface{})
+	assert.True(t, isMap, "decoded data should match the JSON codec's shape")
+}
+
+func TestProtobufCodec_CanceledEvent(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), CustomerID: uuid.New(), UpdatedAt: time.Now().UTC()}
+	event := models.NewOrderCanceledEvent(order, models.OrderStatusPending, &models.CancelOrderRequest{
+		ReasonCode: models.CancelReasonFraud,
+		Reason:     "Card reported stolen",
+		Actor:      "risk-team",
+	})
+
+	codec := queue.ProtobufCodec{}
+	encoded, err := codec.Marshal(event)
+	require.NoError(t, err)
+	decoded, err := codec.Unmarshal(encoded)
+	require.NoError(t, err)
+
+	var data models.OrderCanceledEventData
+	require.NoError(t, decoded.DecodeData(&data))
+	assert.Equal(t, models.CancelReasonFraud, data.ReasonCode)
+	assert.Equal(t, "risk-team", data.Actor)
+	assert.Equal(t, models.OrderStatusPending, data.PreviousStatus)
+	assert.Equal(t, "Card reported stolen", data.Reason)
+}
+
+func TestProtobufCodec_SmallerThanJSON(t *testing.T) {
+	event := models.NewOrderFailedEvent(&models.Order{ID: uuid.New(), CustomerID: uuid.New()}, "Processing failed", "timeout")
+
+	asJSON, err := json.Marshal(event)
+	require.NoError(t, err)
+	asProto, err := queue.ProtobufCodec{}.Marshal(event)
+	require.NoError(t, err)
+
+	assert.Less(t, len(asProto), len(asJSON))
+}
+
+func TestNewCodec(t *testing.T) {
+	codec, err := queue.NewCodec("")
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", codec.ContentType())
+
+	codec, err = queue.NewCodec("protobuf")
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-protobuf", codec.ContentType())
+
+	_, err = queue.NewCodec("avro")
+	assert.Error(t, err)
+}
\ No newline at end of file
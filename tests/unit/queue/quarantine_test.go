@@ -0,0 +1,144 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+// memoryQuarantineStore keeps message failures in a map.
+type memoryQuarantineStore struct {
+	mu          sync.Mutex
+	failures    map[models.MessagePosition]int
+	quarantined map[models.MessagePosition]bool
+}
+
+func newMemoryQuarantineStore() *memoryQuarantineStore {
+	return &memoryQuarantineStore{
+		failures:    map[models.MessagePosition]int{},
+		quarantined: map[models.MessagePosition]bool{},
+	}
+}
+
+func (s *memoryQuarantineStore) RecordFailure(ctx context.Context, position models.MessagePosition, cause error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[position]++
+	return s.failures[position], nil
+}
+
+func (s *memoryQuarantineStore) Quarantine(ctx context.Context, position models.MessagePosition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quarantined[position] = true
+	return nil
+}
+
+func (s *memoryQuarantineStore) IsQuarantined(ctx context.Context, position models.MessagePosition) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.quarantined[position], nil
+}
+
+func (s *memoryQuarantineStore) Clear(ctx context.Context, position models.MessagePosition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, position)
+	return nil
+}
+
+// flakyHandler fails the first failures calls.
+type flakyHandler struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (h *flakyHandler) HandleEvent(ctx context.Context, event *models.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	if h.calls <= h.failures {
+		return errors.New("database unavailable")
+	}
+	return nil
+}
+
+func (h *flakyHandler) callCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls
+}
+
+func newQuarantineTestConsumer(group *fakeConsumerGroup, store queue.QuarantineStore, producer *fakeSyncProducer) *queue.KafkaConsumer {
+	consumer := newTestConsumer(group)
+	consumer.EnableDeadLetters(queue.NewDeadLetterQueueWithProducer(producer, "order-events-dlq", nil))
+	consumer.EnableQuarantine(queue.NewQuarantine(store, &config.KafkaConfig{PoisonMaxAttempts: 3, PoisonBackoff: 1}))
+	return consumer
+}
+
+func TestKafkaConsumer_QuarantineAttemptsFailingMessageAgain(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = eventMessages(t, 1)
+	store := newMemoryQuarantineStore()
+	producer := &fakeSyncProducer{}
+	handler := &flakyHandler{failures: 2}
+	consumer := newQuarantineTestConsumer(group, store, producer)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), handler))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 1)
+	assert.Equal(t, 3, handler.callCount())
+	assert.Empty(t, producer.sent)
+	assert.Empty(t, store.failures, "failures are cleared once the message is processed")
+	assert.Empty(t, store.quarantined)
+}
+
+func TestKafkaConsumer_QuarantineDeadLettersAfterMaxAttempts(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = eventMessages(t, 2)
+	store := newMemoryQuarantineStore()
+	producer := &fakeSyncProducer{}
+	handler := &flakyHandler{failures: 3}
+	consumer := newQuarantineTestConsumer(group, store, producer)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), handler))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 2)
+	assert.Equal(t, 4, handler.callCount(), "the second message is processed after the first is dead-lettered")
+	require.Len(t, producer.sent, 1)
+	assert.Equal(t, "0", headerValue(producer.sent[0].Headers, queue.DeadLetterHeaderOffset))
+
+	position := models.MessagePosition{GroupID: "test-group", Topic: "order-events", Partition: 0, Offset: 0}
+	assert.Equal(t, 3, store.failures[position])
+	assert.True(t, store.quarantined[position])
+}
+
+func TestKafkaConsumer_QuarantineSkipsQuarantinedMessage(t *testing.T) {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = eventMessages(t, 1)
+	store := newMemoryQuarantineStore()
+	store.quarantined[models.MessagePosition{GroupID: "test-group", Topic: "order-events", Offset: 0}] = true
+	producer := &fakeSyncProducer{}
+	handler := &flakyHandler{}
+	consumer := newQuarantineTestConsumer(group, store, producer)
+
+	require.NoError(t, consumer.Subscribe(context.Background(), handler))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 1)
+	assert.Zero(t, handler.callCount())
+	assert.Empty(t, producer.sent)
+}
\ No newline at end of file
@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+func TestRateLimiter_WaitsOnceBurstIsSpent(t *testing.T) {
+	limiter := queue.NewRateLimiter(&config.ConsumerRateLimitConfig{EventsPerSecond: 20, Burst: 2})
+	ctx := context.Background()
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(ctx))
+	require.NoError(t, limiter.Wait(ctx))
+	assert.Less(t, time.Since(start), 25*time.Millisecond)
+
+	require.NoError(t, limiter.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestRateLimiter_WaitStopsWithContext(t *testing.T) {
+	limiter := queue.NewRateLimiter(&config.ConsumerRateLimitConfig{EventsPerSecond: 0.1, Burst: 1})
+	require.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestRateLimiter_LimitsConcurrentHandlers(t *testing.T) {
+	limiter := queue.NewRateLimiter(&config.ConsumerRateLimitConfig{MaxConcurrent: 1})
+
+	release, err := limiter.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	release, err = limiter.Acquire(context.Background())
+	require.NoError(t, err)
+	release()
+}
\ No newline at end of file
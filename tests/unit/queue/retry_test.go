@@ -0,0 +1,153 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+)
+
+func retryTiers(t *testing.T) []queue.RetryTier {
+	tiers, err := queue.ParseRetryTiers([]string{"1m", " 5m", "", "30m"})
+	require.NoError(t, err)
+	return tiers
+}
+
+func toConsumerMessage(msg *sarama.ProducerMessage) *sarama.ConsumerMessage {
+	value, _ := msg.Value.Encode()
+	headers := make([]*sarama.RecordHeader, 0, len(msg.Headers))
+	for i := range msg.Headers {
+		headers = append(headers, &msg.Headers[i])
+	}
+	return &sarama.ConsumerMessage{Topic: msg.Topic, Value: value, Headers: headers}
+}
+
+func TestParseRetryTiers(t *testing.T) {
+	tiers := retryTiers(t)
+	require.Len(t, tiers, 3)
+	assert.Equal(t, "order-events-retry-5m", queue.RetryTopic("order-events", tiers[1]))
+	assert.Equal(t, 30*time.Minute, tiers[2].Delay)
+
+	_, err := queue.ParseRetryTiers([]string{"soon"})
+	assert.Error(t, err)
+	_, err = queue.ParseRetryTiers([]string{"0s"})
+	assert.Error(t, err)
+}
+
+func TestRetryQueue_Topics(t *testing.T) {
+	retries := queue.NewRetryQueueWithProducer(&fakeSyncProducer{}, retryTiers(t))
+
+	assert.Equal(t, []string{
+		"order-events-retry-1m",
+		"order-events-retry-5m",
+		"order-events-retry-30m",
+	}, retries.Topics([]string{"order-events"}))
+}
+
+func TestRetryQueue_PublishWalksTiersThenGivesUp(t *testing.T) {
+	producer := &fakeSyncProducer{}
+	retries := queue.NewRetryQueueWithProducer(producer, retryTiers(t))
+	cause := errors.New("connection refused")
+
+	message := &sarama.ConsumerMessage{
+		Topic: "order-events",
+		Value: []byte(`{"id":"1"}`),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("event_type"), Value: []byte("order.created")},
+		},
+	}
+
+	for i, topic := range []string{"order-events-retry-1m", "order-events-retry-5m", "order-events-retry-30m"} {
+		scheduled, err := retries.Publish(context.Background(), message, cause)
+		require.NoError(t, err)
+		require.True(t, scheduled)
+
+		sent := producer.sent[i]
+		assert.Equal(t, topic, sent.Topic)
+		assert.Equal(t, "order.created", headerValue(sent.Headers, "event_type"))
+		assert.Equal(t, "order-events", headerValue(sent.Headers, queue.RetryHeaderOriginalTopic))
+		assert.Equal(t, "3", headerValue(sent.Headers, queue.RetryHeaderMaxAttempts))
+		assert.Equal(t, "connection refused", headerValue(sent.Headers, queue.RetryHeaderError))
+
+		message = toConsumerMessage(sent)
+		assert.Greater(t, retries.Delay(message), time.Duration(0))
+	}
+
+	scheduled, err := retries.Publish(context.Background(), message, cause)
+	require.NoError(t, err)
+	assert.False(t, scheduled)
+	assert.Len(t, producer.sent, 3)
+}
+
+type retryAtError struct {
+	at time.Time
+}
+
+func (e *retryAtError) Error() string {
+	return "payment service unavailable"
+}
+
+func (e *retryAtError) RetryAt() time.Time {
+	return e.at
+}
+
+func TestRetryQueue_PublishHonorsRetryAfter(t *testing.T) {
+	producer := &fakeSyncProducer{}
+	retries := queue.NewRetryQueueWithProducer(producer, retryTiers(t))
+	retryAt := time.Now().Add(2 * time.Hour)
+	cause := fmt.Errorf("handler failed to process event: %w", &retryAtError{at: retryAt})
+
+	scheduled, err := retries.Publish(context.Background(), &sarama.ConsumerMessage{Topic: "order-events"}, cause)
+	require.NoError(t, err)
+	require.True(t, scheduled)
+
+	sent := producer.sent[0]
+	assert.Equal(t, "order-events-retry-1m", sent.Topic)
+	assert.Equal(t, strconv.FormatInt(retryAt.UnixMilli(), 10), headerValue(sent.Headers, queue.RetryHeaderNotBefore))
+	assert.Greater(t, retries.Delay(toConsumerMessage(sent)), time.Hour)
+}
+
+func TestRetryQueue_DelayWithoutHeaderIsZero(t *testing.T) {
+	retries := queue.NewRetryQueueWithProducer(&fakeSyncProducer{}, retryTiers(t))
+
+	assert.Zero(t, retries.Delay(&sarama.ConsumerMessage{Topic: "order-events"}))
+}
+
+func TestKafkaConsumer_RetriesHandlerFailures(t *testing.T) {
+	event := models.NewEvent(models.OrderCreatedEvent, nil)
+	value, err := event.ToJSON()
+	require.NoError(t, err)
+
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = []*sarama.ConsumerMessage{
+		{Topic: "order-events", Value: value},
+		{Topic: "order-events", Offset: 1, Value: []byte("not an event")},
+	}
+	retryProducer := &fakeSyncProducer{}
+	dlqProducer := &fakeSyncProducer{}
+	consumer := newTestConsumer(group)
+	consumer.EnableDeadLetters(queue.NewDeadLetterQueueWithProducer(dlqProducer, "order-events-dlq", nil))
+	consumer.EnableRetries(queue.NewRetryQueueWithProducer(retryProducer, retryTiers(t)))
+
+	failing := queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		return errors.New("database unavailable")
+	})
+	require.NoError(t, consumer.Subscribe(context.Background(), failing))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 2)
+	require.Len(t, retryProducer.sent, 1)
+	assert.Equal(t, "order-events-retry-1m", retryProducer.sent[0].Topic)
+	assert.Equal(t, "1", headerValue(retryProducer.sent[0].Headers, queue.RetryHeaderAttempt))
+	require.Len(t, dlqProducer.sent, 1)
+	assert.Equal(t, string(models.DeadLetterReasonDecodeFailed), headerValue(dlqProducer.sent[0].Headers, queue.DeadLetterHeaderReason))
+}
\ No newline at end of file
@@ -0,0 +1,196 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/Azure/go-amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+type fakeServiceBusClient struct {
+	mu       sync.Mutex
+	results  []interface{}
+	accepted atomic.Int32
+}
+
+// AcceptNextSessionForSubscription returns the scripted sessions and errors in
+// order, then blocks until ctx is done.
+func (c *fakeServiceBusClient) AcceptNextSessionForSubscription(ctx context.Context, topic, subscription string) (queue.ServiceBusSession, error) {
+	c.accepted.Add(1)
+	c.mu.Lock()
+	if len(c.results) == 0 {
+		c.mu.Unlock()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	result := c.results[0]
+	c.results = c.results[1:]
+	c.mu.Unlock()
+
+	if err, ok := result.(error); ok {
+		return nil, err
+	}
+	return result.(*fakeServiceBusSession), nil
+}
+
+func (c *fakeServiceBusClient) Close(ctx context.Context) error {
+	return nil
+}
+
+type fakeServiceBusSession struct {
+	mu           sync.Mutex
+	messages     []*azservicebus.ReceivedMessage
+	completed    []string
+	abandoned    []string
+	deadLettered map[string]string
+	closed       bool
+}
+
+func newFakeServiceBusSession(messages ...*azservicebus.ReceivedMessage) *fakeServiceBusSession {
+	return &fakeServiceBusSession{messages: messages, deadLettered: make(map[string]string)}
+}
+
+func (s *fakeServiceBusSession) SessionID() string {
+	return "order-1"
+}
+
+func (s *fakeServiceBusSession) ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := s.messages
+	s.messages = nil
+	return messages, nil
+}
+
+func (s *fakeServiceBusSession) CompleteMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.CompleteMessageOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completed = append(s.completed, message.MessageID)
+	return nil
+}
+
+func (s *fakeServiceBusSession) AbandonMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.AbandonMessageOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.abandoned = append(s.abandoned, message.MessageID)
+	return nil
+}
+
+func (s *fakeServiceBusSession) DeadLetterMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.DeadLetterOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLettered[message.MessageID] = *options.Reason
+	return nil
+}
+
+func (s *fakeServiceBusSession) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeServiceBusSession) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func newTestServiceBusConsumer(client queue.ServiceBusClient) *queue.ServiceBusConsumer {
+	return queue.NewServiceBusConsumerWithClient(client,
+		&config.ServiceBusConfig{MaxConcurrentSessions: 1, SessionIdleTimeout: 1, MaxDeliveryAttempts: 3},
+		&config.KafkaConfig{GroupID: "order-processor", Region: "eu-west"},
+		[]string{"orders"})
+}
+
+func serviceBusMessage(t *testing.T, id string, deliveryCount uint32, event *models.Event) *azservicebus.ReceivedMessage {
+	body := []byte("not an event")
+	if event != nil {
+		var err error
+		body, err = queue.JSONCodec{}.Marshal(event)
+		require.NoError(t, err)
+	}
+	return &azservicebus.ReceivedMessage{MessageID: id, DeliveryCount: deliveryCount, Body: body}
+}
+
+func TestServiceBusConsumer_SettlesMessages(t *testing.T) {
+	failing := models.NewEvent(models.OrderCreatedEvent, nil)
+	foreign := models.NewEvent(models.OrderCreatedEvent, nil)
+	foreign.Region = "us-east"
+
+	session := newFakeServiceBusSession(
+		serviceBusMessage(t, "ok", 1, models.NewEvent(models.OrderCreatedEvent, nil)),
+		serviceBusMessage(t, "undecodable", 1, nil),
+		serviceBusMessage(t, "failed-first", 1, failing),
+		serviceBusMessage(t, "failed-last", 3, failing),
+		serviceBusMessage(t, "foreign", 1, foreign),
+	)
+	client := &fakeServiceBusClient{results: []interface{}{session}}
+	consumer := newTestServiceBusConsumer(client)
+
+	var handled atomic.Int32
+	handler := queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		handled.Add(1)
+		if event.ID == failing.ID {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+	require.NoError(t, consumer.Subscribe(context.Background(), handler))
+	assert.Eventually(t, session.isClosed, time.Second, 10*time.Millisecond)
+	require.NoError(t, consumer.Close())
+
+	assert.Equal(t, []string{"ok", "foreign"}, session.completed)
+	assert.Equal(t, []string{"failed-first"}, session.abandoned)
+	assert.Equal(t, map[string]string{
+		"undecodable": "DecodeFailed",
+		"failed-last": "MaxDeliveryAttemptsExceeded",
+	}, session.deadLettered)
+	assert.Equal(t, int32(3), handled.Load(), "foreign-region and undecodable messages never reach the handler")
+}
+
+func TestServiceBusConsumer_FatalAcceptErrorStopsConsumer(t *testing.T) {
+	tests := map[string]error{
+		"unauthorized":         &azservicebus.Error{Code: azservicebus.CodeUnauthorizedAccess},
+		"missing subscription": fmt.Errorf("link failed: %w", &amqp.Error{Condition: amqp.ErrCondNotFound}),
+		"missing topic":        &amqp.Error{Condition: amqp.ErrCondNotFound},
+	}
+
+	for name, acceptErr := range tests {
+		t.Run(name, func(t *testing.T) {
+			consumer := newTestServiceBusConsumer(&fakeServiceBusClient{results: []interface{}{acceptErr}})
+			require.NoError(t, consumer.Subscribe(context.Background(), queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+				return nil
+			})))
+
+			err := consumer.Wait()
+			require.Error(t, err)
+			assert.ErrorIs(t, err, acceptErr)
+		})
+	}
+}
+
+func TestServiceBusConsumer_RetriesAcceptTimeout(t *testing.T) {
+	timeout := &azservicebus.Error{Code: azservicebus.CodeTimeout}
+	client := &fakeServiceBusClient{results: []interface{}{timeout, timeout}}
+	consumer := newTestServiceBusConsumer(client)
+	require.NoError(t, consumer.Subscribe(context.Background(), queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		return nil
+	})))
+
+	assert.Eventually(t, func() bool { return client.accepted.Load() == 3 }, time.Second, 10*time.Millisecond,
+		"a timed out accept waits for the next session")
+	require.NoError(t, consumer.Close())
+	assert.NoError(t, consumer.Wait())
+}
\ No newline at end of file
@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+// fakeClusterAdmin records the topics created through it.
+type fakeClusterAdmin struct {
+	sarama.ClusterAdmin
+	topics  map[string]sarama.TopicDetail
+	created map[string]*sarama.TopicDetail
+	race    string
+}
+
+func (a *fakeClusterAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	return a.topics, nil
+}
+
+func (a *fakeClusterAdmin) CreateTopic(topic string, detail *sarama.TopicDetail, validateOnly bool) error {
+	if topic == a.race {
+		return sarama.ErrTopicAlreadyExists
+	}
+	a.created[topic] = detail
+	return nil
+}
+
+func (a *fakeClusterAdmin) Close() error {
+	return nil
+}
+
+func TestProvisionedTopics(t *testing.T) {
+	topics, err := queue.ProvisionedTopics(&config.KafkaConfig{
+		OrderTopic:  "order-events",
+		TopicRoutes: []string{"order.failed=order-failures"},
+		RetryDelays: []string{"1m", "10m"},
+		DLQTopic:    "order-events-dlq",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"order-events",
+		"order-events-dlq",
+		"order-events-retry-10m",
+		"order-events-retry-1m",
+		"order-failures",
+		"order-failures-retry-10m",
+		"order-failures-retry-1m",
+	}, topics)
+}
+
+func TestTopicProvisioner_CreatesMissingTopics(t *testing.T) {
+	admin := &fakeClusterAdmin{
+		topics:  map[string]sarama.TopicDetail{"order-events": {}},
+		created: make(map[string]*sarama.TopicDetail),
+		race:    "order-events-retry-1m",
+	}
+	provisioner := queue.NewTopicProvisionerWithAdmin(admin, &config.KafkaConfig{
+		DLQTopic:               "order-events-dlq",
+		TopicPartitions:        12,
+		TopicReplicationFactor: 3,
+		TopicRetention:         168,
+		DLQRetention:           720,
+	})
+
+	created, err := provisioner.Provision([]string{"order-events", "order-events-dlq", "order-events-retry-1m", "order-failures"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"order-events-dlq", "order-failures"}, created)
+
+	detail := admin.created["order-failures"]
+	require.NotNil(t, detail)
+	assert.Equal(t, int32(12), detail.NumPartitions)
+	assert.Equal(t, int16(3), detail.ReplicationFactor)
+	assert.Equal(t, "604800000", *detail.ConfigEntries["retention.ms"])
+	assert.Equal(t, "2592000000", *admin.created["order-events-dlq"].ConfigEntries["retention.ms"])
+}
+
+func TestTopicProvisioner_BrokerDefaults(t *testing.T) {
+	admin := &fakeClusterAdmin{topics: map[string]sarama.TopicDetail{}, created: make(map[string]*sarama.TopicDetail)}
+	provisioner := queue.NewTopicProvisionerWithAdmin(admin, &config.KafkaConfig{})
+
+	_, err := provisioner.Provision([]string{"order-events"})
+	require.NoError(t, err)
+
+	detail := admin.created["order-events"]
+	assert.Equal(t, int32(-1), detail.NumPartitions)
+	assert.Equal(t, int16(-1), detail.ReplicationFactor)
+	assert.Nil(t, detail.ConfigEntries)
+}
\ No newline at end of file
@@ -0,0 +1,121 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+func TestTopicRouter_RoutesEventTypes(t *testing.T) {
+	router, err := queue.NewTopicRouter(&config.KafkaConfig{
+		OrderTopic:  "order-events",
+		TopicRoutes: []string{"order.created=order-created", " order.failed = order-failures ", "order.canceled=order-failures", ""},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "order-created", router.Topic(models.OrderCreatedEvent))
+	assert.Equal(t, "order-failures", router.Topic(models.OrderFailedEvent))
+	assert.Equal(t, "order-events", router.Topic(models.OrderCompletedEvent))
+	assert.Equal(t, []string{"order-created", "order-events", "order-failures"}, router.Topics())
+}
+
+func TestTopicRouter_RejectsInvalidRoutes(t *testing.T) {
+	for _, route := range []string{"order.created", "order.created=", "=order-created"} {
+		_, err := queue.NewTopicRouter(&config.KafkaConfig{OrderTopic: "order-events", TopicRoutes: []string{route}})
+		assert.ErrorContains(t, err, "invalid topic route", route)
+	}
+
+	_, err := queue.NewTopicRouter(&config.KafkaConfig{
+		OrderTopic:  "order-events",
+		TopicRoutes: []string{"order.failed=order-failures", "order.failed=order-errors"},
+	})
+	assert.ErrorContains(t, err, "routed to both")
+}
+
+func TestTopicRouter_RoutesIsolatedTenants(t *testing.T) {
+	router, err := queue.NewTopicRouter(&config.KafkaConfig{
+		OrderTopic:   "order-events",
+		TopicRoutes:  []string{"order.failed=order-failures"},
+		TenantTopics: []string{"acme=orders-acme", " globex = orders-globex ", ""},
+	})
+	require.NoError(t, err)
+
+	acme := &models.Order{TenantID: "acme", Status: models.OrderStatusCompleted}
+	assert.Equal(t, "orders-acme", router.Route(models.NewOrderCompletedEvent(acme)))
+	assert.Equal(t, "order-failures", router.Route(models.NewOrderFailedEvent(acme, "failed", "")), "event type routes apply to every tenant")
+	assert.Equal(t, "order-events", router.Route(models.NewOrderCompletedEvent(&models.Order{TenantID: "initech"})))
+	assert.Equal(t, "order-events", router.Route(models.NewEvent(models.OrderCompletedEvent, nil)))
+
+	assert.Equal(t, map[string]string{"acme": "orders-acme", "globex": "orders-globex"}, router.TenantTopics())
+	assert.Equal(t, []string{"order-events", "order-failures"}, router.Topics(), "tenant topics are not consumed by the order group")
+	assert.Equal(t, "order-consumer-tenant-acme", queue.TenantGroupID("order-consumer", "acme"))
+}
+
+func TestTopicRouter_RejectsSharedTenantTopics(t *testing.T) {
+	for _, tenantTopics := range [][]string{
+		{"acme"},
+		{"acme=orders-acme", "acme=orders-acme-2"},
+		{"acme=orders-shared", "globex=orders-shared"},
+		{"acme=order-events"},
+		{"acme=order-failures"},
+	} {
+		_, err := queue.NewTopicRouter(&config.KafkaConfig{
+			OrderTopic:   "order-events",
+			TopicRoutes:  []string{"order.failed=order-failures"},
+			TenantTopics: tenantTopics,
+		})
+		assert.Error(t, err, tenantTopics)
+	}
+}
+
+func TestSubscribedTopics(t *testing.T) {
+	cfg := &config.KafkaConfig{OrderTopic: "order-events", TopicRoutes: []string{"order.failed=order-failures"}}
+	topics, err := queue.SubscribedTopics(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"order-events", "order-failures"}, topics)
+
+	cfg.ConsumerTopics = []string{"order-created", " "}
+	topics, err = queue.SubscribedTopics(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"order-created"}, topics)
+}
+
+func TestKafkaProducer_PublishesToRoutedTopic(t *testing.T) {
+	fake := &fakeSyncProducer{}
+	producer, err := queue.NewKafkaProducerWithProducer(fake, &config.KafkaConfig{
+		OrderTopic:     "order-events",
+		TopicRoutes:    []string{"order.failed=order-failures"},
+		MigrationTopic: "order-events-v2",
+		MigrationPhase: queue.MigrationPhaseCutover,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, producer.PublishEvent(context.Background(), models.NewEvent(models.OrderFailedEvent, nil)))
+	require.NoError(t, producer.PublishEvent(context.Background(), models.NewEvent(models.OrderCompletedEvent, nil)))
+
+	require.Len(t, fake.sent, 2)
+	assert.Equal(t, "order-failures", fake.sent[0].Topic)
+	assert.Equal(t, "order-events-v2", fake.sent[1].Topic)
+}
+
+func TestKafkaProducer_PublishesToTenantTopic(t *testing.T) {
+	fake := &fakeSyncProducer{}
+	producer, err := queue.NewKafkaProducerWithProducer(fake, &config.KafkaConfig{
+		OrderTopic:     "order-events",
+		TenantTopics:   []string{"acme=orders-acme"},
+		MigrationTopic: "order-events-v2",
+		MigrationPhase: queue.MigrationPhaseDual,
+	})
+	require.NoError(t, err)
+
+	order := &models.Order{TenantID: "acme"}
+	require.NoError(t, producer.PublishEvent(context.Background(), models.NewOrderProcessingEvent(order)))
+
+	require.Len(t, fake.sent, 1, "tenant topics are not migrated")
+	assert.Equal(t, "orders-acme", fake.sent[0].Topic)
+}
\ No newline at end of file
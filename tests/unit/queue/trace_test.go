@@ -0,0 +1,90 @@
+package queue
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+const parentTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestValidTraceparent(t *testing.T) {
+	assert.True(t, queue.ValidTraceparent(parentTraceparent))
+	assert.False(t, queue.ValidTraceparent(""))
+	assert.False(t, queue.ValidTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	assert.False(t, queue.ValidTraceparent("00-00000000000000000000000000000000-00f067aa0ba902b7-01"))
+	assert.False(t, queue.ValidTraceparent("00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"))
+}
+
+func TestChildTraceparent(t *testing.T) {
+	child := queue.ChildTraceparent(parentTraceparent)
+	require.True(t, queue.ValidTraceparent(child))
+	assert.True(t, strings.HasPrefix(child, "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+	assert.NotEqual(t, parentTraceparent, child)
+	assert.True(t, strings.HasSuffix(child, "-01"))
+
+	fresh := queue.ChildTraceparent("garbage")
+	require.True(t, queue.ValidTraceparent(fresh))
+	assert.NotEqual(t, queue.TraceContext{Traceparent: parentTraceparent}.TraceID(), queue.TraceContext{Traceparent: fresh}.TraceID())
+}
+
+func TestKafkaProducer_InjectsTraceHeaders(t *testing.T) {
+	fake := &fakeSyncProducer{}
+	producer, err := queue.NewKafkaProducerWithProducer(fake, &config.KafkaConfig{OrderTopic: "order-events"})
+	require.NoError(t, err)
+
+	ctx := queue.WithTraceContext(context.Background(), queue.TraceContext{
+		Traceparent:   parentTraceparent,
+		CorrelationID: "20240101120000-req",
+	})
+	require.NoError(t, producer.PublishEvent(ctx, models.NewEvent(models.OrderCreatedEvent, nil)))
+
+	require.Len(t, fake.sent, 1)
+	traceparent := headerValue(fake.sent[0].Headers, queue.HeaderTraceparent)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", queue.TraceContext{Traceparent: traceparent}.TraceID())
+	assert.Equal(t, "20240101120000-req", headerValue(fake.sent[0].Headers, queue.HeaderCorrelationID))
+}
+
+func TestKafkaConsumer_ExtractsTraceHeaders(t *testing.T) {
+	event := models.NewEvent(models.OrderCreatedEvent, nil)
+	value, err := event.ToJSON()
+	require.NoError(t, err)
+
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = []*sarama.ConsumerMessage{{
+		Topic: "order-events",
+		Value: value,
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(queue.HeaderTraceparent), Value: []byte(parentTraceparent)},
+			{Key: []byte(queue.HeaderCorrelationID), Value: []byte("20240101120000-req")},
+		},
+	}}
+	consumer := newTestConsumer(group)
+
+	var mu sync.Mutex
+	var handled queue.TraceContext
+	handler := queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = queue.TraceContextFrom(ctx)
+		return nil
+	})
+	require.NoError(t, consumer.Subscribe(context.Background(), handler))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 1)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handled.TraceID())
+	assert.NotEqual(t, parentTraceparent, handled.Traceparent)
+	assert.Equal(t, "20240101120000-req", handled.CorrelationID)
+}
\ No newline at end of file
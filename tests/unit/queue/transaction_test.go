@@ -0,0 +1,192 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+// fakeTxnProducer records the transactional calls made on it, in order.
+type fakeTxnProducer struct {
+	sarama.SyncProducer
+	mu  sync.Mutex
+	ops []string
+}
+
+func (p *fakeTxnProducer) record(op string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ops = append(p.ops, op)
+}
+
+func (p *fakeTxnProducer) recorded() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.ops...)
+}
+
+func (p *fakeTxnProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.record("send")
+	return 0, 0, nil
+}
+
+func (p *fakeTxnProducer) IsTransactional() bool {
+	return true
+}
+
+func (p *fakeTxnProducer) BeginTxn() error {
+	p.record("begin")
+	return nil
+}
+
+func (p *fakeTxnProducer) CommitTxn() error {
+	p.record("commit")
+	return nil
+}
+
+func (p *fakeTxnProducer) AbortTxn() error {
+	p.record("abort")
+	return nil
+}
+
+func (p *fakeTxnProducer) AddMessageToTxn(msg *sarama.ConsumerMessage, groupID string, metadata *string) error {
+	p.record(fmt.Sprintf("offset %s %d", groupID, msg.Offset))
+	return nil
+}
+
+func (p *fakeTxnProducer) Close() error {
+	return nil
+}
+
+func newTxnProducer(t *testing.T, fake *fakeTxnProducer) *queue.KafkaProducer {
+	t.Helper()
+
+	producer, err := queue.NewKafkaProducerWithProducer(fake, &config.KafkaConfig{
+		OrderTopic:      "order-events",
+		TransactionalID: "consumer-1",
+	})
+	require.NoError(t, err)
+	return producer
+}
+
+func TestKafkaProducer_TransactCommitsEventsWithOffset(t *testing.T) {
+	fake := &fakeTxnProducer{}
+	producer := newTxnProducer(t, fake)
+
+	message := &sarama.ConsumerMessage{Topic: "order-events", Offset: 7}
+	err := producer.Transact(context.Background(), "group", message, func(ctx context.Context) error {
+		return producer.PublishEvent(ctx, models.NewEvent(models.OrderProcessingEvent, nil))
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"begin", "send", "offset group 7", "commit"}, fake.recorded())
+}
+
+func TestKafkaProducer_TransactAbortsOnHandlerError(t *testing.T) {
+	fake := &fakeTxnProducer{}
+	producer := newTxnProducer(t, fake)
+
+	message := &sarama.ConsumerMessage{Topic: "order-events", Offset: 7}
+	err := producer.Transact(context.Background(), "group", message, func(ctx context.Context) error {
+		if err := producer.PublishEvent(ctx, models.NewEvent(models.OrderProcessingEvent, nil)); err != nil {
+			return err
+		}
+		return errors.New("database unavailable")
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"begin", "send", "abort"}, fake.recorded())
+}
+
+func TestKafkaProducer_AfterCommitRunsOnlyOnCommit(t *testing.T) {
+	fake := &fakeTxnProducer{}
+	producer := newTxnProducer(t, fake)
+	message := &sarama.ConsumerMessage{Topic: "order-events", Offset: 7}
+
+	var committed bool
+	err := producer.Transact(context.Background(), "group", message, func(ctx context.Context) error {
+		queue.AfterCommit(ctx, func() { committed = true })
+		assert.False(t, committed)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, committed)
+
+	var aborted bool
+	err = producer.Transact(context.Background(), "group", message, func(ctx context.Context) error {
+		queue.AfterCommit(ctx, func() { aborted = true })
+		return errors.New("database unavailable")
+	})
+	require.Error(t, err)
+	assert.False(t, aborted)
+}
+
+func TestAfterCommit_RunsImmediatelyOutsideTransaction(t *testing.T) {
+	var ran bool
+	queue.AfterCommit(context.Background(), func() { ran = true })
+	assert.True(t, ran)
+}
+
+func TestKafkaProducer_PublishOutsideTransactUsesOwnTransaction(t *testing.T) {
+	fake := &fakeTxnProducer{}
+	producer := newTxnProducer(t, fake)
+
+	require.NoError(t, producer.PublishEvent(context.Background(), models.NewEvent(models.OrderCreatedEvent, nil)))
+
+	assert.Equal(t, []string{"begin", "send", "commit"}, fake.recorded())
+}
+
+func TestKafkaProducer_TransactRequiresTransactionalProducer(t *testing.T) {
+	producer, err := queue.NewKafkaProducerWithProducer(&fakeSyncProducer{}, &config.KafkaConfig{OrderTopic: "order-events"})
+	require.NoError(t, err)
+
+	err = producer.Transact(context.Background(), "group", &sarama.ConsumerMessage{}, nil)
+	require.Error(t, err)
+}
+
+func TestKafkaConsumer_CommitsOffsetsInTransactions(t *testing.T) {
+	event := models.NewEvent(models.OrderCreatedEvent, nil)
+	value, err := event.ToJSON()
+	require.NoError(t, err)
+
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = []*sarama.ConsumerMessage{
+		{Topic: "order-events", Value: value},
+		{Topic: "order-events", Offset: 1, Value: []byte("not an event")},
+	}
+	fake := &fakeTxnProducer{}
+	producer := newTxnProducer(t, fake)
+	consumer := newTestConsumer(group)
+	consumer.EnableDeadLetters(queue.NewDeadLetterQueueWithProducer(&fakeSyncProducer{}, "order-events-dlq", nil))
+	consumer.EnableTransactions(producer)
+
+	handler := queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		return producer.PublishEvent(ctx, models.NewEvent(models.OrderProcessingEvent, nil))
+	})
+	require.NoError(t, consumer.Subscribe(context.Background(), handler))
+	defer consumer.Close()
+
+	expected := []string{
+		"begin", "send", "offset test-group 0", "commit",
+		"begin", "abort",
+		"begin", "offset test-group 1", "commit",
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(fake.recorded()) < len(expected) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, expected, fake.recorded())
+	assert.Equal(t, int32(0), group.marked.Load())
+}
\ No newline at end of file
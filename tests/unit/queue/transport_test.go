@@ -0,0 +1,35 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/pkg/config"
+)
+
+func TestNewTransportProducer_UnknownTransport(t *testing.T) {
+	cfg := &config.Config{Queue: config.QueueConfig{Transport: "rabbitmq"}}
+
+	_, err := queue.NewTransportProducer(cfg, nil)
+	assert.ErrorContains(t, err, `unknown queue transport "rabbitmq"`)
+}
+
+func TestNewTransportConsumer_UnknownTransport(t *testing.T) {
+	cfg := &config.Config{Queue: config.QueueConfig{Transport: "rabbitmq"}}
+
+	_, err := queue.NewTransportConsumer(cfg, &cfg.Kafka, nil, []string{"order-events"})
+	assert.ErrorContains(t, err, `unknown queue transport "rabbitmq"`)
+}
+
+func TestNewTransportProducer_Postgres(t *testing.T) {
+	cfg := &config.Config{
+		Queue: config.QueueConfig{Transport: queue.TransportPostgres},
+		Kafka: config.KafkaConfig{OrderTopic: "order-events"},
+	}
+
+	producer, err := queue.NewTransportProducer(cfg, nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &queue.PostgresProducer{}, producer)
+	assert.NoError(t, producer.Close())
+}
\ No newline at end of file
@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStalledGroup returns a group whose only partition is left with lag
+// after its one message is processed.
+func newStalledGroup(t *testing.T) *fakeConsumerGroup {
+	group := newFakeConsumerGroup(nil)
+	group.claims = map[string][]int32{"order-events": {0}}
+	group.messages = eventMessages(t, 1)
+	group.highWater = 5
+	return group
+}
+
+func TestKafkaConsumer_WatchdogRebuildsStalledConsumer(t *testing.T) {
+	stalled := newStalledGroup(t)
+	rebuilt := newFakeConsumerGroup(nil)
+	consumer := newTestConsumer(stalled)
+	consumer.EnableWatchdog(50*time.Millisecond, func() (sarama.ConsumerGroup, error) {
+		return rebuilt, nil
+	})
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+
+	select {
+	case <-stalled.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled consumer group client was not closed")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for rebuilt.calls.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("consumer did not rejoin with the rebuilt client")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	status := consumer.Watchdog()
+	assert.True(t, status.Enabled)
+	assert.Equal(t, int64(1), status.SelfHeals)
+	assert.Zero(t, status.FailedSelfHeals)
+	assert.NotNil(t, status.LastSelfHeal)
+}
+
+func TestKafkaConsumer_WatchdogIgnoresPausedPartitions(t *testing.T) {
+	group := newStalledGroup(t)
+	consumer := newTestConsumer(group)
+	consumer.EnableWatchdog(250*time.Millisecond, func() (sarama.ConsumerGroup, error) {
+		t.Error("paused consumer was rebuilt")
+		return newFakeConsumerGroup(nil), nil
+	})
+
+	require.NoError(t, consumer.Subscribe(context.Background(), noopHandler{}))
+	defer consumer.Close()
+
+	waitForMarked(t, group, 1)
+	require.NoError(t, consumer.Pause("order-events", nil))
+	time.Sleep(600 * time.Millisecond)
+
+	status := consumer.Watchdog()
+	assert.False(t, status.Stalled)
+	assert.Nil(t, status.IdleSince)
+	assert.Zero(t, status.SelfHeals)
+}
\ No newline at end of file
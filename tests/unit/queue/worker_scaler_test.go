@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/queue"
+)
+
+func handleOnce(t *testing.T, scaler *queue.WorkerScaler, latency time.Duration) {
+	t.Helper()
+	if !scaler.Acquire(context.Background()) {
+		t.Fatal("worker not acquired")
+	}
+	scaler.Release(latency)
+}
+
+func TestWorkerScaler_ScalesWithBacklogAndLatency(t *testing.T) {
+	scaler := queue.NewWorkerScaler(1, 4, 100*time.Millisecond)
+	assert.Equal(t, 1, scaler.Workers())
+
+	for range 3 {
+		handleOnce(t, scaler, 10*time.Millisecond)
+		scaler.Adjust(50)
+	}
+	assert.Equal(t, 4, scaler.Workers())
+
+	handleOnce(t, scaler, 10*time.Millisecond)
+	assert.Equal(t, 4, scaler.Adjust(50), "scaled past max")
+
+	handleOnce(t, scaler, 300*time.Millisecond)
+	assert.Equal(t, 2, scaler.Adjust(50))
+
+	assert.Equal(t, 1, scaler.Adjust(0))
+	assert.Equal(t, 1, scaler.Adjust(0), "scaled below min")
+}
+
+func TestWorkerScaler_HoldsWithoutProgress(t *testing.T) {
+	scaler := queue.NewWorkerScaler(2, 4, 100*time.Millisecond)
+
+	assert.Equal(t, 2, scaler.Adjust(50))
+}
+
+func TestWorkerScaler_AcquireWaitsForLimit(t *testing.T) {
+	scaler := queue.NewWorkerScaler(1, 2, 100*time.Millisecond)
+	assert.True(t, scaler.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.False(t, scaler.Acquire(ctx))
+
+	acquired := make(chan bool, 1)
+	go func() {
+		acquired <- scaler.Acquire(context.Background())
+	}()
+	scaler.Release(time.Millisecond)
+
+	select {
+	case ok := <-acquired:
+		assert.True(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("released worker was not acquired")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,51 @@
+package requestctx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+func TestRequestctx_EmptyWithoutValues(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Empty(t, requestctx.RequestID(ctx))
+	assert.Empty(t, requestctx.Tenant(ctx))
+	assert.Equal(t, requestctx.Principal{}, requestctx.PrincipalFrom(ctx))
+	assert.Equal(t, requestctx.Trace{}, requestctx.TraceFrom(ctx))
+	assert.Empty(t, requestctx.LogFields(ctx))
+}
+
+func TestWithActor_KeepsAPIKey(t *testing.T) {
+	ctx := requestctx.WithPrincipal(context.Background(), requestctx.Principal{Actor: "anonymous", APIKeyID: "key-1"})
+	ctx = requestctx.WithActor(ctx, "alice")
+
+	assert.Equal(t, requestctx.Principal{Actor: "alice", APIKeyID: "key-1"}, requestctx.PrincipalFrom(ctx))
+	assert.Equal(t, "alice", requestctx.Actor(ctx))
+}
+
+func TestLogFields(t *testing.T) {
+	ctx := requestctx.WithRequestID(context.Background(), "req-1")
+	ctx = requestctx.WithTenant(ctx, "acme")
+	ctx = requestctx.WithActor(ctx, "alice")
+	ctx = requestctx.WithTrace(ctx, requestctx.Trace{
+		Traceparent:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		CorrelationID: "req-1",
+	})
+
+	assert.Equal(t, logrus.Fields{
+		"request_id":     "req-1",
+		"tenant_id":      "acme",
+		"actor":          "alice",
+		"trace_id":       "4bf92f3577b34da6a3ce929d0e0e4736",
+		"correlation_id": "req-1",
+	}, requestctx.LogFields(ctx))
+}
+
+func TestTrace_TraceIDOfInvalidTraceparent(t *testing.T) {
+	assert.Empty(t, requestctx.Trace{Traceparent: "garbage"}.TraceID())
+	assert.Empty(t, requestctx.Trace{}.LogFields())
+}
\ No newline at end of file
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+func archivedOrderEvent(t *testing.T, at time.Time) (*models.Event, archivedEvent) {
+	t.Helper()
+	event := models.NewEvent(models.OrderCreatedEvent, map[string]string{"note": at.Format(time.RFC3339)})
+	event.Timestamp = at
+	payload, err := event.ToJSON()
+	require.NoError(t, err)
+	return event, archivedEvent{occurredAt: at, payload: payload}
+}
+
+func TestArchiveReplayer_ReplaysArchivedRangeAsReplay(t *testing.T) {
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-4 * time.Hour)
+	repo := &memoryEventArchiveRepository{archives: map[time.Time]*models.EventArchive{}}
+	var events []*models.Event
+	for _, at := range []time.Time{
+		hour.Add(10 * time.Minute),
+		hour.Add(40 * time.Minute),
+		hour.Add(time.Hour + 5*time.Minute),
+		hour.Add(2*time.Hour + 30*time.Minute),
+	} {
+		event, archived := archivedOrderEvent(t, at)
+		events = append(events, event)
+		repo.events = append(repo.events, archived)
+	}
+
+	store := &fakeBlobStore{blobs: map[string][]byte{}}
+	_, err := services.NewEventArchiver(repo, store, &config.EventArchiveConfig{Prefix: "events"}).ArchivePending(context.Background())
+	require.NoError(t, err)
+
+	replayer := services.NewArchiveReplayer(repo, store)
+	archivedUntil, err := replayer.ArchivedUntil(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, hour.Add(3*time.Hour), archivedUntil)
+
+	var replayed []*models.Event
+	handler := queue.EventHandlerFunc(func(ctx context.Context, event *models.Event) error {
+		assert.True(t, queue.IsReplay(ctx))
+		replayed = append(replayed, event)
+		return nil
+	})
+
+	count, err := replayer.ReplayBetween(context.Background(), hour.Add(30*time.Minute), hour.Add(2*time.Hour), handler)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, replayed, 2)
+	assert.Equal(t, events[1].ID, replayed[0].ID)
+	assert.Equal(t, events[2].ID, replayed[1].ID)
+
+	replayed = nil
+	count, err = replayer.ReplayBetween(context.Background(), time.Time{}, time.Time{}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+}
+
+func TestArchiveReplayer_NothingArchived(t *testing.T) {
+	repo := &memoryEventArchiveRepository{archives: map[time.Time]*models.EventArchive{}}
+	replayer := services.NewArchiveReplayer(repo, &fakeBlobStore{blobs: map[string][]byte{}})
+
+	archivedUntil, err := replayer.ArchivedUntil(context.Background())
+	require.NoError(t, err)
+	assert.True(t, archivedUntil.IsZero())
+}
\ No newline at end of file
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/internal/storage"
+	"order-processing-microservice/pkg/config"
+)
+
+type fakeBlobStore struct {
+	blobs map[string][]byte
+}
+
+func (s *fakeBlobStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.blobs[key] = content
+	return nil
+}
+
+func (s *fakeBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	content, ok := s.blobs[key]
+	if !ok {
+		return nil, storage.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (s *fakeBlobStore) Delete(ctx context.Context, key string) error {
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *fakeBlobStore) SignedURL(ctx context.Context, key string, opts storage.DownloadOptions, expiry time.Duration) (string, error) {
+	return "https://blobs.example.com/" + key, nil
+}
+
+type fakeAttachmentRepository struct {
+	attachments []*models.Attachment
+	createErr   error
+}
+
+func (r *fakeAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	r.attachments = append(r.attachments, attachment)
+	return nil
+}
+
+func (r *fakeAttachmentRepository) GetByID(ctx context.Context, orderID, id uuid.UUID) (*models.Attachment, error) {
+	for _, attachment := range r.attachments {
+		if attachment.OrderID == orderID && attachment.ID == id {
+			return attachment, nil
+		}
+	}
+	return nil, repository.ErrAttachmentNotFound
+}
+
+func (r *fakeAttachmentRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.Attachment, error) {
+	return r.attachments, nil
+}
+
+func (r *fakeAttachmentRepository) Delete(ctx context.Context, orderID, id uuid.UUID) error {
+	return nil
+}
+
+// existingOrderReader finds every order.
+type existingOrderReader struct {
+	repository.OrderReader
+}
+
+func (r existingOrderReader) GetByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error) {
+	return &models.Order{ID: id}, nil
+}
+
+func newAttachmentService(repo *fakeAttachmentRepository, store *fakeBlobStore) *services.AttachmentService {
+	return services.NewAttachmentService(repo, existingOrderReader{}, store, &config.AttachmentConfig{
+		MaxSize:      1024,
+		AllowedTypes: []string{"application/pdf", "image/png"},
+		URLExpiry:    60,
+	})
+}
+
+func TestAttachmentService_UploadSniffsTypeAndStoresBlob(t *testing.T) {
+	repo := &fakeAttachmentRepository{}
+	store := &fakeBlobStore{blobs: map[string][]byte{}}
+	service := newAttachmentService(repo, store)
+
+	orderID := uuid.New()
+	attachment, err := service.Upload(context.Background(), orderID, `C:\uploads\po-4711.pdf`, strings.NewReader("%PDF-1.7 purchase order"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "po-4711.pdf", attachment.FileName)
+	assert.Equal(t, "application/pdf", attachment.ContentType)
+	assert.Equal(t, int64(len("%PDF-1.7 purchase order")), attachment.Size)
+	assert.Len(t, attachment.Checksum, 64)
+	assert.Equal(t, "%PDF-1.7 purchase order", string(store.blobs[attachment.StorageKey]))
+	require.Len(t, repo.attachments, 1)
+
+	download, err := service.Get(context.Background(), orderID, attachment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://blobs.example.com/"+attachment.StorageKey, download.DownloadURL)
+}
+
+func TestAttachmentService_UploadRejectsInvalidFiles(t *testing.T) {
+	store := &fakeBlobStore{blobs: map[string][]byte{}}
+	service := newAttachmentService(&fakeAttachmentRepository{}, store)
+
+	_, err := service.Upload(context.Background(), uuid.New(), "big.pdf", strings.NewReader("%PDF-"+strings.Repeat("x", 1024)))
+	assert.ErrorIs(t, err, services.ErrAttachmentTooLarge)
+
+	_, err = service.Upload(context.Background(), uuid.New(), "po.pdf", strings.NewReader("<html><script>alert(1)</script></html>"))
+	assert.ErrorIs(t, err, services.ErrAttachmentTypeNotAllowed)
+
+	_, err = service.Upload(context.Background(), uuid.New(), "empty.pdf", strings.NewReader(""))
+	assert.ErrorIs(t, err, services.ErrAttachmentEmpty)
+
+	assert.Empty(t, store.blobs)
+}
+
+func TestAttachmentService_UploadDeletesBlobWhenInsertFails(t *testing.T) {
+	store := &fakeBlobStore{blobs: map[string][]byte{}}
+	service := newAttachmentService(&fakeAttachmentRepository{createErr: fmt.Errorf("insert failed")}, store)
+
+	_, err := service.Upload(context.Background(), uuid.New(), "po.pdf", strings.NewReader("%PDF-1.7"))
+	assert.Error(t, err)
+	assert.Empty(t, store.blobs)
+}
\ No newline at end of file
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+	"order-processing-microservice/pkg/requestctx"
+)
+
+type topicRecorder struct {
+	topics []string
+	events []*models.Event
+}
+
+func (r *topicRecorder) PublishEventToTopic(ctx context.Context, topic string, event *models.Event) error {
+	r.topics = append(r.topics, topic)
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *topicRecorder) records(t *testing.T) []*models.AuditRecord {
+	t.Helper()
+
+	records := make([]*models.AuditRecord, 0, len(r.events))
+	for _, event := range r.events {
+		require.Equal(t, models.OrderAuditEvent, event.Type)
+		record, ok := event.Data.(*models.AuditRecord)
+		require.True(t, ok)
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAuditor_RecordsActorAndRequestID(t *testing.T) {
+	publisher := &topicRecorder{}
+	auditor := services.NewAuditor(publisher, &config.AuditConfig{Topic: "order-audit"})
+
+	ctx := requestctx.WithActor(context.Background(), "alice")
+	ctx = queue.WithTraceContext(ctx, queue.TraceContext{CorrelationID: "req-42"})
+	order := &models.Order{ID: uuid.New(), TenantID: "acme", Status: models.OrderStatusCanceled}
+	auditor.Record(ctx, order, models.OrderStatusPending)
+
+	require.Equal(t, []string{"order-audit"}, publisher.topics)
+	record := publisher.records(t)[0]
+	assert.Equal(t, order.ID, record.OrderID)
+	assert.Equal(t, "acme", record.TenantID)
+	assert.Equal(t, models.AuditActionCancel, record.Action)
+	assert.Equal(t, "alice", record.Actor)
+	assert.Equal(t, models.OrderStatusPending, record.OldStatus)
+	assert.Equal(t, models.OrderStatusCanceled, record.NewStatus)
+	assert.Equal(t, "req-42", record.RequestID)
+	assert.Equal(t, record.ID, publisher.events[0].ID)
+}
+
+func TestNewAuditRecord_Actions(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending}
+	created := models.NewAuditRecord(order, "", "", "")
+	assert.Equal(t, models.AuditActionCreate, created.Action)
+	assert.Equal(t, models.SystemActor, created.Actor)
+
+	order.Status = models.OrderStatusOnHold
+	assert.Equal(t, models.AuditActionStatusChange, models.NewAuditRecord(order, models.OrderStatusPending, "ops", "").Action)
+}
+
+func TestOrderProcessor_AuditsStatusChanges(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending, Version: 1}
+	publisher := &topicRecorder{}
+	processor := services.NewOrderProcessor(&pendingOrderRepository{order: order}, &countingProducer{})
+	processor.EnableAuditing(services.NewAuditor(publisher, &config.AuditConfig{Topic: "order-audit"}))
+
+	ctx := queue.WithTraceContext(context.Background(), queue.TraceContext{CorrelationID: "req-7"})
+	require.NoError(t, processor.HandleEvent(ctx, orderCreatedEvent(order)))
+
+	records := publisher.records(t)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.AuditActionStatusChange, records[0].Action)
+	assert.Equal(t, models.SystemActor, records[0].Actor)
+	assert.Equal(t, models.OrderStatusPending, records[0].OldStatus)
+	assert.Equal(t, models.OrderStatusProcessing, records[0].NewStatus)
+	assert.Equal(t, "req-7", records[0].RequestID)
+}
+
+func TestOrderProcessor_DoesNotAuditFailedUpdates(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending, Version: 1}
+	publisher := &topicRecorder{}
+	repo := &pendingOrderRepository{order: order, updateErr: assert.AnError}
+	processor := services.NewOrderProcessor(repo, &countingProducer{})
+	processor.EnableAuditing(services.NewAuditor(publisher, &config.AuditConfig{Topic: "order-audit"}))
+
+	require.Error(t, processor.HandleEvent(context.Background(), orderCreatedEvent(order)))
+	assert.Empty(t, publisher.events)
+}
\ No newline at end of file
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+// fakeBulkCancelRepository keeps jobs in memory.
+type fakeBulkCancelRepository struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]models.BulkCancelJob
+}
+
+func (r *fakeBulkCancelRepository) Create(ctx context.Context, job *models.BulkCancelJob) error {
+	return r.Update(ctx, job)
+}
+
+func (r *fakeBulkCancelRepository) Update(ctx context.Context, job *models.BulkCancelJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *fakeBulkCancelRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BulkCancelJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, repository.ErrBulkCancelJobNotFound
+	}
+	return &job, nil
+}
+
+// cancelableOrderRepository cancels orders from a fixed set of matching
+// orders, a batch at a time.
+type cancelableOrderRepository struct {
+	repository.OrderRepository
+	remaining int
+	batches   []int
+	failAt    int
+}
+
+func (r *cancelableOrderRepository) TransitionStatusBatch(ctx context.Context, from, to models.OrderStatus, filter models.OrderFilter, limit int) ([]*models.Order, error) {
+	if r.failAt > 0 && len(r.batches)+1 == r.failAt {
+		return nil, fmt.Errorf("connection reset")
+	}
+	n := min(limit, r.remaining)
+	r.remaining -= n
+	r.batches = append(r.batches, n)
+
+	orders := make([]*models.Order, n)
+	for i := range orders {
+		orders[i] = &models.Order{ID: uuid.New(), CustomerID: *filter.CustomerID, Status: to}
+	}
+	return orders, nil
+}
+
+func newBulkCancelService(repo *cancelableOrderRepository, producer *countingProducer) (*services.BulkCancelService, *fakeBulkCancelRepository) {
+	jobs := &fakeBulkCancelRepository{jobs: make(map[uuid.UUID]models.BulkCancelJob)}
+	orderService := services.NewOrderService(repo, producer)
+	return services.NewBulkCancelService(jobs, orderService, &config.BulkCancelConfig{BatchSize: 10}), jobs
+}
+
+func TestBulkCancelService_CancelsInBatches(t *testing.T) {
+	repo := &cancelableOrderRepository{remaining: 25}
+	producer := &countingProducer{}
+	service, jobs := newBulkCancelService(repo, producer)
+
+	customerID := uuid.New()
+	job, err := service.Start(context.Background(), &models.BulkCancelRequest{CustomerID: &customerID})
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkCancelJobRunning, job.Status)
+	assert.Equal(t, models.OrderStatusPending, job.OrderStatus)
+	assert.Equal(t, models.CancelReasonAdmin, job.ReasonCode)
+	service.Wait()
+
+	finished, err := jobs.GetByID(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkCancelJobCompleted, finished.Status)
+	assert.Equal(t, 3, finished.Batches)
+	assert.Equal(t, 25, finished.Canceled)
+	assert.Equal(t, 25, finished.Published)
+	assert.NotNil(t, finished.CompletedAt)
+	assert.Equal(t, []int{10, 10, 5}, repo.batches)
+	assert.Equal(t, 25, producer.published)
+}
+
+func TestBulkCancelService_RecordsFailedBatch(t *testing.T) {
+	repo := &cancelableOrderRepository{remaining: 25, failAt: 2}
+	service, jobs := newBulkCancelService(repo, &countingProducer{})
+
+	customerID := uuid.New()
+	job, err := service.Start(context.Background(), &models.BulkCancelRequest{CustomerID: &customerID})
+	require.NoError(t, err)
+	service.Wait()
+
+	finished, err := jobs.GetByID(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkCancelJobFailed, finished.Status)
+	assert.Equal(t, 10, finished.Canceled)
+	assert.Contains(t, finished.Error, "connection reset")
+}
+
+func TestBulkCancelService_ValidatesRequest(t *testing.T) {
+	service, _ := newBulkCancelService(&cancelableOrderRepository{}, &countingProducer{})
+	customerID := uuid.New()
+
+	_, err := service.Start(context.Background(), &models.BulkCancelRequest{})
+	assert.ErrorIs(t, err, services.ErrBulkCancelFilterRequired)
+
+	_, err = service.Start(context.Background(), &models.BulkCancelRequest{CustomerID: &customerID, Status: models.OrderStatusProcessing})
+	assert.ErrorIs(t, err, services.ErrInvalidBulkCancel)
+	assert.ErrorContains(t, err, "status processing cannot be bulk canceled")
+
+	_, err = service.Start(context.Background(), &models.BulkCancelRequest{CustomerID: &customerID, ReasonCode: "bored"})
+	assert.ErrorIs(t, err, services.ErrInvalidBulkCancel)
+	assert.ErrorContains(t, err, "invalid cancel reason code")
+}
\ No newline at end of file
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+// flakyProducer fails with err while it is set.
+type flakyProducer struct {
+	err       error
+	published []uuid.UUID
+}
+
+func (p *flakyProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+func (p *flakyProducer) Close() error {
+	return nil
+}
+
+// memoryFallbackRepository keeps stored events in a slice.
+type memoryFallbackRepository struct {
+	events []*models.Event
+}
+
+func (r *memoryFallbackRepository) Store(ctx context.Context, event *models.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *memoryFallbackRepository) Forward(ctx context.Context, limit int, publish func(ctx context.Context, event *models.Event) error) (int, error) {
+	forwarded := 0
+	var err error
+	for _, event := range r.events[:min(limit, len(r.events))] {
+		if err = publish(ctx, event); err != nil {
+			break
+		}
+		forwarded++
+	}
+	r.events = r.events[forwarded:]
+	return forwarded, err
+}
+
+func (r *memoryFallbackRepository) Count(ctx context.Context) (int, error) {
+	return len(r.events), nil
+}
+
+func newBreakerEvents(n int) []*models.Event {
+	events := make([]*models.Event, n)
+	for i := range events {
+		events[i] = &models.Event{ID: uuid.New(), Type: models.OrderCreatedEvent}
+	}
+	return events
+}
+
+func TestCircuitBreakerProducer_StoresAndForwardsInOrder(t *testing.T) {
+	producer := &flakyProducer{err: errors.New("broker down")}
+	fallback := &memoryFallbackRepository{}
+	breaker := services.NewCircuitBreakerProducer(producer, fallback, &config.CircuitBreakerConfig{
+		FailureThreshold: 1,
+		ForwardBatchSize: 2,
+	})
+	ctx := context.Background()
+	events := newBreakerEvents(5)
+
+	for _, event := range events[:3] {
+		require.NoError(t, breaker.PublishEvent(ctx, event))
+	}
+	status := breaker.Status()
+	assert.Equal(t, services.BreakerOpen, status.State)
+	assert.Equal(t, uint64(1), status.Opens)
+	assert.Equal(t, uint64(3), status.Stored)
+	assert.Equal(t, 3, status.Pending)
+	assert.Empty(t, producer.published)
+
+	// The broker recovers; the open timeout of zero lets the forwarder probe
+	// it straight away.
+	producer.err = nil
+	require.NoError(t, breaker.PublishEvent(ctx, events[3]))
+	assert.Empty(t, producer.published, "a new event must not overtake stored ones")
+
+	runCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	breaker.Run(runCtx)
+
+	assert.Equal(t, []uuid.UUID{events[0].ID, events[1].ID, events[2].ID, events[3].ID}, producer.published)
+	status = breaker.Status()
+	assert.Equal(t, services.BreakerClosed, status.State)
+	assert.Equal(t, uint64(4), status.Forwarded)
+	assert.Zero(t, status.Pending)
+	assert.Nil(t, status.OpenedAt)
+
+	require.NoError(t, breaker.PublishEvent(ctx, events[4]))
+	assert.Len(t, producer.published, 5)
+	assert.Empty(t, fallback.events)
+}
+
+func TestCircuitBreakerProducer_DoesNotStoreOversizedEvents(t *testing.T) {
+	producer := &flakyProducer{err: fmt.Errorf("event is 2048 bytes: %w", queue.ErrPayloadTooLarge)}
+	fallback := &memoryFallbackRepository{}
+	breaker := services.NewCircuitBreakerProducer(producer, fallback, &config.CircuitBreakerConfig{FailureThreshold: 1})
+
+	err := breaker.PublishEvent(context.Background(), newBreakerEvents(1)[0])
+	assert.ErrorIs(t, err, queue.ErrPayloadTooLarge)
+	assert.Empty(t, fallback.events)
+	assert.Equal(t, services.BreakerClosed, breaker.Status().State)
+}
\ No newline at end of file
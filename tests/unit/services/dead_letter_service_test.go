@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+)
+
+func TestDeadLetterService_RejectsInvalidSelections(t *testing.T) {
+	service := services.NewDeadLetterService(nil)
+	ids := make([]uuid.UUID, models.MaxDeadLetterSelection+1)
+
+	_, err := service.RequeueDeadLetters(context.Background(), ids)
+	assert.ErrorIs(t, err, services.ErrDeadLetterRequeueDisabled)
+
+	_, err = service.PurgeDeadLetters(context.Background(), ids)
+	assert.ErrorIs(t, err, services.ErrTooManyDeadLetters)
+}
\ No newline at end of file
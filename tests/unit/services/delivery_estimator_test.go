@@ -0,0 +1,91 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+func newDeliveryConfig() *config.DeliveryConfig {
+	return &config.DeliveryConfig{
+		Enabled:        true,
+		TimeZone:       "UTC",
+		CutoffHour:     14,
+		ProcessingDays: 1,
+		StandardDays:   5,
+		ExpressDays:    2,
+		OvernightDays:  1,
+	}
+}
+
+func TestDeliveryEstimator_Estimate(t *testing.T) {
+	cfg := newDeliveryConfig()
+	cfg.Holidays = []string{"2025-09-17"}
+	estimator, err := services.NewDeliveryEstimator(cfg)
+	require.NoError(t, err)
+
+	// 2025-09-01 is a Monday.
+	tests := []struct {
+		name   string
+		status models.OrderStatus
+		method models.ShippingMethod
+		from   time.Time
+		want   string
+	}{
+		{"before cutoff", models.OrderStatusPending, models.ShippingMethodStandard, time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC), "2025-09-09"},
+		{"after cutoff", models.OrderStatusPending, models.ShippingMethodStandard, time.Date(2025, 9, 1, 15, 0, 0, 0, time.UTC), "2025-09-10"},
+		{"friday after cutoff", models.OrderStatusPending, models.ShippingMethodExpress, time.Date(2025, 9, 5, 15, 0, 0, 0, time.UTC), "2025-09-11"},
+		{"weekend", models.OrderStatusPending, models.ShippingMethodOvernight, time.Date(2025, 9, 6, 9, 0, 0, 0, time.UTC), "2025-09-10"},
+		{"holiday", models.OrderStatusPending, models.ShippingMethodExpress, time.Date(2025, 9, 15, 10, 0, 0, 0, time.UTC), "2025-09-19"},
+		{"completed", models.OrderStatusCompleted, models.ShippingMethodOvernight, time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC), "2025-09-02"},
+		{"defaults to standard", models.OrderStatusPending, "", time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC), "2025-09-09"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &models.Order{Status: tt.status, ShippingMethod: tt.method}
+			estimate := estimator.Estimate(order, tt.from)
+			require.NotNil(t, estimate)
+			assert.Equal(t, tt.want, estimate.Format(time.DateOnly))
+		})
+	}
+}
+
+func TestDeliveryEstimator_UsesLocalCutoff(t *testing.T) {
+	cfg := newDeliveryConfig()
+	cfg.TimeZone = "America/New_York"
+	estimator, err := services.NewDeliveryEstimator(cfg)
+	require.NoError(t, err)
+
+	// 16:00 UTC is 12:00 in New York, before the cutoff.
+	order := &models.Order{Status: models.OrderStatusPending, ShippingMethod: models.ShippingMethodExpress}
+	estimate := estimator.Estimate(order, time.Date(2025, 9, 1, 16, 0, 0, 0, time.UTC))
+	require.NotNil(t, estimate)
+	assert.Equal(t, "2025-09-04", estimate.Format(time.DateOnly))
+}
+
+func TestDeliveryEstimator_NoEstimateForClosedOrders(t *testing.T) {
+	estimator, err := services.NewDeliveryEstimator(newDeliveryConfig())
+	require.NoError(t, err)
+
+	for _, status := range []models.OrderStatus{models.OrderStatusFailed, models.OrderStatusCanceled, models.OrderStatusOnHold} {
+		order := &models.Order{Status: status, ShippingMethod: models.ShippingMethodExpress}
+		assert.Nil(t, estimator.Estimate(order, time.Now()), status)
+	}
+}
+
+func TestNewDeliveryEstimator_RejectsInvalidConfig(t *testing.T) {
+	cfg := newDeliveryConfig()
+	cfg.TimeZone = "Mars/Olympus_Mons"
+	_, err := services.NewDeliveryEstimator(cfg)
+	assert.ErrorContains(t, err, "invalid delivery time zone")
+
+	cfg = newDeliveryConfig()
+	cfg.Holidays = []string{"25.12.2025"}
+	_, err = services.NewDeliveryEstimator(cfg)
+	assert.ErrorContains(t, err, "invalid delivery holiday")
+}
\ No newline at end of file
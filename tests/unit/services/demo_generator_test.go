@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+func demoConfig() *config.DemoConfig {
+	return &config.DemoConfig{
+		Seed:        7,
+		FailureRate: 0.25,
+		Customers:   3,
+		Products:    4,
+		MinItems:    2,
+		MaxItems:    4,
+		MaxQuantity: 2,
+	}
+}
+
+func TestDemoGenerator_SameSeedSameOrders(t *testing.T) {
+	first := services.NewDemoGenerator(nil, demoConfig())
+	second := services.NewDemoGenerator(nil, demoConfig())
+
+	for i := 0; i < 50; i++ {
+		a, b := first.Next(), second.Next()
+		outcomeA, _ := models.DemoOutcome(&models.Order{TenantID: a.TenantID, ExternalReference: a.ExternalReference})
+		outcomeB, _ := models.DemoOutcome(&models.Order{TenantID: b.TenantID, ExternalReference: b.ExternalReference})
+
+		assert.Equal(t, outcomeA, outcomeB)
+		assert.Equal(t, a.CustomerID, b.CustomerID)
+		assert.Equal(t, a.Items, b.Items)
+	}
+}
+
+func TestDemoGenerator_Distributions(t *testing.T) {
+	generator := services.NewDemoGenerator(nil, demoConfig())
+
+	customers := map[uuid.UUID]bool{}
+	failures := 0
+	for i := 0; i < 400; i++ {
+		req := generator.Next()
+		assert.Equal(t, models.DemoTenantID, req.TenantID)
+		assert.GreaterOrEqual(t, len(req.Items), 2)
+		assert.LessOrEqual(t, len(req.Items), 4)
+		for _, item := range req.Items {
+			assert.GreaterOrEqual(t, item.Quantity, 1)
+			assert.LessOrEqual(t, item.Quantity, 2)
+			assert.Greater(t, item.Price, 0.0)
+		}
+
+		customers[req.CustomerID] = true
+		outcome, ok := models.DemoOutcome(&models.Order{TenantID: req.TenantID, ExternalReference: req.ExternalReference})
+		assert.True(t, ok)
+		if outcome == models.DemoOutcomeFail {
+			failures++
+		}
+	}
+
+	assert.Len(t, customers, 3)
+	assert.InDelta(t, 100, failures, 40)
+}
+
+func TestDemoGenerator_ZeroFailureRate(t *testing.T) {
+	cfg := demoConfig()
+	cfg.FailureRate = 0
+	generator := services.NewDemoGenerator(nil, cfg)
+
+	for i := 0; i < 100; i++ {
+		req := generator.Next()
+		outcome, _ := models.DemoOutcome(&models.Order{TenantID: req.TenantID, ExternalReference: req.ExternalReference})
+		assert.Equal(t, models.DemoOutcomeComplete, outcome)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+type archivedEvent struct {
+	occurredAt time.Time
+	payload    []byte
+}
+
+// memoryEventArchiveRepository keeps events and manifest records in memory.
+type memoryEventArchiveRepository struct {
+	events   []archivedEvent
+	archives map[time.Time]*models.EventArchive
+}
+
+func (r *memoryEventArchiveRepository) NextHour(ctx context.Context, before time.Time) (*time.Time, error) {
+	var after time.Time
+	for hour, archive := range r.archives {
+		if archive.Status == models.EventArchiveStatusCompleted && !hour.Add(time.Hour).Before(after) {
+			after = hour.Add(time.Hour)
+		}
+	}
+
+	var next *time.Time
+	for _, event := range r.events {
+		if event.occurredAt.Before(after) || !event.occurredAt.Before(before) {
+			continue
+		}
+		hour := event.occurredAt.Truncate(time.Hour)
+		if next == nil || hour.Before(*next) {
+			next = &hour
+		}
+	}
+	return next, nil
+}
+
+func (r *memoryEventArchiveRepository) Claim(ctx context.Context, archive *models.EventArchive, staleBefore time.Time) (bool, error) {
+	if existing, ok := r.archives[archive.Hour]; ok && existing.Status != models.EventArchiveStatusFailed &&
+		!(existing.Status == models.EventArchiveStatusRunning && existing.StartedAt.Before(staleBefore)) {
+		return false, nil
+	}
+	copied := *archive
+	r.archives[archive.Hour] = &copied
+	return true, nil
+}
+
+func (r *memoryEventArchiveRepository) Complete(ctx context.Context, archive *models.EventArchive) error {
+	copied := *archive
+	r.archives[archive.Hour] = &copied
+	return nil
+}
+
+func (r *memoryEventArchiveRepository) Fail(ctx context.Context, archive *models.EventArchive) error {
+	copied := *archive
+	r.archives[archive.Hour] = &copied
+	return nil
+}
+
+func (r *memoryEventArchiveRepository) EachEvent(ctx context.Context, from, to time.Time, fn func(payload []byte) error) error {
+	for _, event := range r.events {
+		if !event.occurredAt.Before(from) && event.occurredAt.Before(to) {
+			if err := fn(event.payload); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *memoryEventArchiveRepository) ListCompleted(ctx context.Context, from, to time.Time) ([]*models.EventArchive, error) {
+	var archives []*models.EventArchive
+	for hour, archive := range r.archives {
+		if archive.Status == models.EventArchiveStatusCompleted && !hour.Before(from) && hour.Before(to) {
+			archives = append(archives, archive)
+		}
+	}
+	sort.Slice(archives, func(i, j int) bool { return archives[i].Hour.Before(archives[j].Hour) })
+	return archives, nil
+}
+
+type erroringBlobStore struct {
+	fakeBlobStore
+}
+
+func (s *erroringBlobStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	return errors.New("bucket unavailable")
+}
+
+func readArchive(t *testing.T, content []byte) []string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	require.NoError(t, err)
+
+	var lines []string
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestEventArchiver_ArchivesCompletedHours(t *testing.T) {
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-3 * time.Hour)
+	repo := &memoryEventArchiveRepository{
+		events: []archivedEvent{
+			{occurredAt: hour.Add(5 * time.Minute), payload: []byte(`{"id":"a"}`)},
+			{occurredAt: hour.Add(50 * time.Minute), payload: []byte(`{"id":"b"}`)},
+			{occurredAt: hour.Add(2*time.Hour + time.Minute), payload: []byte(`{"id":"c"}`)},
+			{occurredAt: time.Now().UTC(), payload: []byte(`{"id":"current"}`)},
+		},
+		archives: map[time.Time]*models.EventArchive{},
+	}
+	store := &fakeBlobStore{blobs: map[string][]byte{}}
+	archiver := services.NewEventArchiver(repo, store, &config.EventArchiveConfig{Prefix: "events"})
+
+	archived, err := archiver.ArchivePending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, archived)
+
+	first := repo.archives[hour]
+	require.NotNil(t, first)
+	assert.Equal(t, models.EventArchiveStatusCompleted, first.Status)
+	assert.Equal(t, models.EventArchiveKey("events", hour), first.ObjectKey)
+	assert.Equal(t, 2, first.EventCount)
+	assert.Equal(t, int64(len(store.blobs[first.ObjectKey])), first.SizeBytes)
+	assert.Equal(t, []string{`{"id":"a"}`, `{"id":"b"}`}, readArchive(t, store.blobs[first.ObjectKey]))
+
+	assert.NotContains(t, repo.archives, hour.Add(time.Hour), "hours without events produce no archive")
+	third := repo.archives[hour.Add(2*time.Hour)]
+	require.NotNil(t, third)
+	assert.Equal(t, []string{`{"id":"c"}`}, readArchive(t, store.blobs[third.ObjectKey]))
+	assert.Len(t, store.blobs, 2, "the current hour is not archived")
+
+	archived, err = archiver.ArchivePending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, archived)
+}
+
+func TestEventArchiver_SkipsHourClaimedByAnotherRun(t *testing.T) {
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	repo := &memoryEventArchiveRepository{
+		events: []archivedEvent{{occurredAt: hour.Add(time.Minute), payload: []byte(`{}`)}},
+		archives: map[time.Time]*models.EventArchive{
+			hour: {Hour: hour, Status: models.EventArchiveStatusRunning, StartedAt: time.Now()},
+		},
+	}
+	store := &fakeBlobStore{blobs: map[string][]byte{}}
+	archiver := services.NewEventArchiver(repo, store, &config.EventArchiveConfig{Prefix: "events"})
+
+	archived, err := archiver.ArchivePending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, archived)
+	assert.Empty(t, store.blobs)
+}
+
+func TestEventArchiver_RecordsFailedHour(t *testing.T) {
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	repo := &memoryEventArchiveRepository{
+		events:   []archivedEvent{{occurredAt: hour.Add(time.Minute), payload: []byte(`{}`)}},
+		archives: map[time.Time]*models.EventArchive{},
+	}
+	archiver := services.NewEventArchiver(repo, &erroringBlobStore{}, &config.EventArchiveConfig{Prefix: "events"})
+
+	_, err := archiver.ArchivePending(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, models.EventArchiveStatusFailed, repo.archives[hour].Status)
+	assert.Contains(t, repo.archives[hour].Error, "bucket unavailable")
+
+	store := &fakeBlobStore{blobs: map[string][]byte{}}
+	archived, err := services.NewEventArchiver(repo, store, &config.EventArchiveConfig{Prefix: "events"}).ArchivePending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived, "a failed hour is retried")
+}
\ No newline at end of file
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+type scheduledEvent struct {
+	event     *models.Event
+	deliverAt time.Time
+}
+
+// memoryScheduledEventRepository keeps scheduled events in a slice.
+type memoryScheduledEventRepository struct {
+	scheduled []scheduledEvent
+}
+
+func (r *memoryScheduledEventRepository) Schedule(ctx context.Context, event *models.Event, deliverAt time.Time) error {
+	r.Cancel(ctx, event.ID)
+	r.scheduled = append(r.scheduled, scheduledEvent{event: event, deliverAt: deliverAt})
+	return nil
+}
+
+func (r *memoryScheduledEventRepository) Cancel(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	for i, s := range r.scheduled {
+		if s.event.ID == eventID {
+			r.scheduled = append(r.scheduled[:i], r.scheduled[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryScheduledEventRepository) ReleaseDue(ctx context.Context, now time.Time, limit int, publish func(ctx context.Context, event *models.Event) error) (int, error) {
+	sort.SliceStable(r.scheduled, func(i, j int) bool {
+		return r.scheduled[i].deliverAt.Before(r.scheduled[j].deliverAt)
+	})
+
+	released := 0
+	for len(r.scheduled) > 0 && released < limit && !r.scheduled[0].deliverAt.After(now) {
+		if err := publish(ctx, r.scheduled[0].event); err != nil {
+			return released, err
+		}
+		r.scheduled = r.scheduled[1:]
+		released++
+	}
+	return released, nil
+}
+
+func TestEventScheduler_ReleasesDueEventsInOrder(t *testing.T) {
+	repo := &memoryScheduledEventRepository{}
+	producer := &flakyProducer{}
+	scheduler := services.NewEventScheduler(repo, producer, &config.EventSchedulerConfig{BatchSize: 2})
+	ctx := context.Background()
+
+	now := time.Now()
+	events := newBreakerEvents(4)
+	require.NoError(t, scheduler.Schedule(ctx, events[0], now.Add(-time.Minute)))
+	require.NoError(t, scheduler.Schedule(ctx, events[1], now.Add(-2*time.Minute)))
+	require.NoError(t, scheduler.Schedule(ctx, events[2], now.Add(-3*time.Minute)))
+	require.NoError(t, scheduler.Schedule(ctx, events[3], now.Add(time.Hour)))
+
+	require.NoError(t, scheduler.ReleaseDue(ctx))
+	assert.Equal(t, []uuid.UUID{events[2].ID, events[1].ID, events[0].ID}, producer.published)
+	require.Len(t, repo.scheduled, 1)
+	assert.Equal(t, events[3].ID, repo.scheduled[0].event.ID)
+
+	cancelled, err := scheduler.Cancel(ctx, events[3].ID)
+	require.NoError(t, err)
+	assert.True(t, cancelled)
+	assert.Empty(t, repo.scheduled)
+}
+
+func TestEventScheduler_FailedEventStaysScheduled(t *testing.T) {
+	repo := &memoryScheduledEventRepository{}
+	producer := &flakyProducer{err: errors.New("broker down")}
+	scheduler := services.NewEventScheduler(repo, producer, &config.EventSchedulerConfig{})
+	ctx := context.Background()
+
+	event := newBreakerEvents(1)[0]
+	require.NoError(t, scheduler.Schedule(ctx, event, time.Now().Add(-time.Second)))
+
+	assert.ErrorContains(t, scheduler.ReleaseDue(ctx), "broker down")
+	assert.Len(t, repo.scheduled, 1)
+
+	producer.err = nil
+	require.NoError(t, scheduler.ReleaseDue(ctx))
+	assert.Equal(t, []uuid.UUID{event.ID}, producer.published)
+	assert.Empty(t, repo.scheduled)
+}
\ No newline at end of file
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+func TestHeartbeatEmitter_CountsSequence(t *testing.T) {
+	producer := &eventLogProducer{}
+	emitter := services.NewHeartbeatEmitter(producer, "producer-1", &config.HeartbeatConfig{Interval: 30})
+
+	emitter.Emit(context.Background())
+	emitter.Emit(context.Background())
+
+	events := producer.published()
+	require.Len(t, events, 2)
+	for i, event := range events {
+		assert.Equal(t, models.HeartbeatEvent, event.Type)
+		var data models.HeartbeatEventData
+		require.NoError(t, event.DecodeData(&data))
+		assert.Equal(t, "producer-1", data.Source)
+		assert.Equal(t, uint64(i+1), data.Sequence)
+		assert.Equal(t, event.Timestamp, data.SentAt)
+	}
+}
+
+// broadcastingProducer records broadcast events apart from published ones.
+type broadcastingProducer struct {
+	eventLogProducer
+	broadcast []*models.Event
+}
+
+func (p *broadcastingProducer) BroadcastEvent(ctx context.Context, event *models.Event) error {
+	p.broadcast = append(p.broadcast, event)
+	return nil
+}
+
+func TestHeartbeatEmitter_BroadcastsWhenSupported(t *testing.T) {
+	producer := &broadcastingProducer{}
+	emitter := services.NewHeartbeatEmitter(producer, "producer-1", &config.HeartbeatConfig{Interval: 30})
+
+	emitter.Emit(context.Background())
+
+	assert.Empty(t, producer.published())
+	require.Len(t, producer.broadcast, 1)
+	assert.Equal(t, models.HeartbeatEvent, producer.broadcast[0].Type)
+}
+
+func TestHeartbeatMonitor_Observe(t *testing.T) {
+	monitor := services.NewHeartbeatMonitor(&config.HeartbeatConfig{Interval: 30, MissedIntervals: 3})
+
+	status := monitor.Status()
+	assert.Zero(t, status.Received)
+	assert.Nil(t, status.LastReceivedAt)
+	assert.False(t, status.Stale)
+
+	event := models.NewHeartbeatEvent("producer-1", 7)
+	event.Timestamp = event.Timestamp.Add(-2 * time.Second)
+	monitor.Observe(event)
+
+	status = monitor.Status()
+	assert.Equal(t, uint64(1), status.Received)
+	assert.Equal(t, "producer-1", status.LastSource)
+	require.NotNil(t, status.LastReceivedAt)
+	assert.Less(t, status.Age, 1.0)
+	assert.Less(t, status.Delay, 1.0, "delay is measured from sent_at, not the event timestamp")
+	assert.False(t, status.Stale)
+}
+
+func TestHeartbeatMonitor_ZeroIntervalNeverStale(t *testing.T) {
+	monitor := services.NewHeartbeatMonitor(&config.HeartbeatConfig{Interval: 0, MissedIntervals: 3})
+
+	assert.False(t, monitor.Status().Stale, "a zero interval disables the stale check")
+}
+
+func TestHeartbeatMonitor_WritePrometheus(t *testing.T) {
+	monitor := services.NewHeartbeatMonitor(&config.HeartbeatConfig{Interval: 30, MissedIntervals: 3})
+	monitor.Observe(models.NewHeartbeatEvent("producer-1", 1))
+	monitor.Observe(models.NewHeartbeatEvent("producer-2", 1))
+
+	var b strings.Builder
+	require.NoError(t, monitor.WritePrometheus(&b))
+
+	assert.Contains(t, b.String(), "# TYPE consumer_heartbeat_age_seconds gauge\n")
+	assert.Contains(t, b.String(), "# TYPE consumer_heartbeat_delay_seconds gauge\n")
+	assert.Contains(t, b.String(), "consumer_heartbeats_received_total 2\n")
+}
\ No newline at end of file
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+)
+
+// fakeIntegrityRepository returns fixed findings per kind and records the
+// order in which checks and repairs run.
+type fakeIntegrityRepository struct {
+	found    map[models.InconsistencyKind][]*models.OrderInconsistency
+	changed  map[uuid.UUID]bool
+	calls    []string
+	repaired []models.InconsistencyKind
+}
+
+func (r *fakeIntegrityRepository) Find(ctx context.Context, kind models.InconsistencyKind, limit int) ([]*models.OrderInconsistency, error) {
+	r.calls = append(r.calls, "find "+string(kind))
+	return r.found[kind], nil
+}
+
+func (r *fakeIntegrityRepository) Repair(ctx context.Context, inconsistency *models.OrderInconsistency, actor string) (bool, error) {
+	r.calls = append(r.calls, "repair "+string(inconsistency.Kind))
+	if r.changed[inconsistency.OrderID] {
+		return false, nil
+	}
+	r.repaired = append(r.repaired, inconsistency.Kind)
+	return true, nil
+}
+
+func TestIntegrityChecker_ReportsWithoutRepairing(t *testing.T) {
+	repo := &fakeIntegrityRepository{found: map[models.InconsistencyKind][]*models.OrderInconsistency{
+		models.InconsistencyOrderTotal: {{Kind: models.InconsistencyOrderTotal, OrderID: uuid.New(), Actual: "10.00", Expected: "12.50"}},
+	}}
+
+	report, err := services.NewIntegrityChecker(repo, 100).Run(context.Background(), false, "fsck")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Unresolved())
+	assert.Empty(t, repo.repaired)
+}
+
+func TestIntegrityChecker_RepairsEachCheckBeforeTheNext(t *testing.T) {
+	changedOrder := uuid.New()
+	repo := &fakeIntegrityRepository{
+		found: map[models.InconsistencyKind][]*models.OrderInconsistency{
+			models.InconsistencyItemTotal:     {{Kind: models.InconsistencyItemTotal, OrderID: uuid.New()}},
+			models.InconsistencyOrderTotal:    {{Kind: models.InconsistencyOrderTotal, OrderID: changedOrder}},
+			models.InconsistencyUnknownStatus: {{Kind: models.InconsistencyUnknownStatus, OrderID: uuid.New(), Actual: "shipped"}},
+		},
+		changed: map[uuid.UUID]bool{changedOrder: true},
+	}
+
+	report, err := services.NewIntegrityChecker(repo, 100).Run(context.Background(), true, "fsck")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"find orphan_item",
+		"find item_total_mismatch",
+		"repair item_total_mismatch",
+		"find order_total_mismatch",
+		"repair order_total_mismatch",
+		"find unknown_status",
+		"find status_version_mismatch",
+	}, repo.calls)
+	assert.Equal(t, 1, report.Repaired)
+	assert.Equal(t, 2, report.Unresolved())
+	assert.True(t, report.Inconsistencies[0].Repaired)
+	assert.False(t, report.Inconsistencies[1].Repaired)
+}
\ No newline at end of file
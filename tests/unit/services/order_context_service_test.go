@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+type fakeOrderContextRepository struct {
+	created []*models.OrderContext
+	cutoffs []time.Time
+}
+
+func (r *fakeOrderContextRepository) Create(ctx context.Context, orderContext *models.OrderContext) error {
+	r.created = append(r.created, orderContext)
+	return nil
+}
+
+func (r *fakeOrderContextRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.OrderContext, error) {
+	for _, orderContext := range r.created {
+		if orderContext.OrderID == orderID {
+			return orderContext, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeOrderContextRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.cutoffs = append(r.cutoffs, cutoff)
+	return 0, nil
+}
+
+func TestOrderContextService_RecordStampsOrder(t *testing.T) {
+	repo := &fakeOrderContextRepository{}
+	service := services.NewOrderContextService(repo, &config.OrderContextConfig{Retention: 24})
+
+	order := &models.Order{ID: uuid.New(), CreatedAt: time.Now().UTC()}
+	captured := &models.OrderContext{IPAddress: "203.0.113.7", UserAgent: strings.Repeat("a", 2*models.MaxUserAgentLength)}
+	service.Record(context.Background(), order, captured)
+
+	require.Len(t, repo.created, 1)
+	assert.Equal(t, order.ID, repo.created[0].OrderID)
+	assert.Equal(t, order.CreatedAt, repo.created[0].CreatedAt)
+	assert.Equal(t, "203.0.113.7", repo.created[0].IPAddress)
+	assert.Len(t, repo.created[0].UserAgent, models.MaxUserAgentLength)
+	assert.Equal(t, uuid.Nil, captured.OrderID, "the captured context is not modified")
+}
+
+func TestOrderContextService_DeleteExpired(t *testing.T) {
+	repo := &fakeOrderContextRepository{}
+	service := services.NewOrderContextService(repo, &config.OrderContextConfig{Retention: 24})
+
+	_, err := service.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repo.cutoffs, 1)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), repo.cutoffs[0], time.Minute)
+
+	keepForever := services.NewOrderContextService(repo, &config.OrderContextConfig{})
+	_, err = keepForever.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, repo.cutoffs, 1)
+}
\ No newline at end of file
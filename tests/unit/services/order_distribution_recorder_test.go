@@ -0,0 +1,34 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+)
+
+func TestOrderDistributionRecorder_WritePrometheus(t *testing.T) {
+	recorder := services.NewOrderDistributionRecorder()
+	recorder.Observe(&models.Order{TotalAmount: 10, Items: make([]models.OrderItem, 1)})
+	recorder.Observe(&models.Order{TotalAmount: 30.5, Items: make([]models.OrderItem, 3)})
+	recorder.Observe(&models.Order{TotalAmount: 9000, Items: make([]models.OrderItem, 25)})
+
+	snapshot := recorder.Snapshot()
+	assert.Equal(t, map[string]int64{"10": 1, "50": 1, "+Inf": 1}, snapshot.TotalAmount.Buckets)
+	assert.Equal(t, map[string]int64{"1": 1, "3": 1, "+Inf": 1}, snapshot.ItemsPerOrder.Buckets)
+
+	var out strings.Builder
+	require.NoError(t, recorder.WritePrometheus(&out))
+	metrics := out.String()
+	assert.Contains(t, metrics, "# TYPE orders_created_total_amount histogram\n")
+	assert.Contains(t, metrics, "orders_created_total_amount_bucket{le=\"10\"} 1\n")
+	assert.Contains(t, metrics, "orders_created_total_amount_bucket{le=\"25\"} 1\n")
+	assert.Contains(t, metrics, "orders_created_total_amount_bucket{le=\"5000\"} 2\n")
+	assert.Contains(t, metrics, "orders_created_total_amount_bucket{le=\"+Inf\"} 3\n")
+	assert.Contains(t, metrics, "orders_created_total_amount_sum 9040.5\n")
+	assert.Contains(t, metrics, "orders_created_items_bucket{le=\"3\"} 2\n")
+	assert.Contains(t, metrics, "orders_created_items_count 3\n")
+}
\ No newline at end of file
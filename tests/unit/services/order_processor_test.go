@@ -0,0 +1,435 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+type fakeProcessedEvents struct {
+	processed map[uuid.UUID]bool
+}
+
+func (f *fakeProcessedEvents) Exists(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	return f.processed[eventID], nil
+}
+
+func (f *fakeProcessedEvents) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+// pendingOrderRepository serves one pending order; calls to any other method
+// panic through the nil embedded interface.
+type pendingOrderRepository struct {
+	repository.OrderRepository
+	order     *models.Order
+	updateErr error
+	updates   int
+}
+
+func (r *pendingOrderRepository) GetByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error) {
+	return r.order, nil
+}
+
+func (r *pendingOrderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.OrderStatus, version int) error {
+	r.updates++
+	return r.updateErr
+}
+
+type countingProducer struct {
+	published int
+}
+
+func (p *countingProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	p.published++
+	return nil
+}
+
+func (p *countingProducer) Close() error {
+	return nil
+}
+
+func orderCreatedEvent(order *models.Order) *models.Event {
+	event := models.NewOrderCreatedEvent(order)
+	event.Data = map[string]interface{}{"order_id": order.ID.String()}
+	return event
+}
+
+func TestOrderProcessor_SkipsProcessedEvents(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending, Version: 1}
+	event := orderCreatedEvent(order)
+	repo := &pendingOrderRepository{order: order}
+	producer := &countingProducer{}
+
+	processor := services.NewOrderProcessor(repo, producer)
+	processor.EnableDeduplication(&fakeProcessedEvents{processed: map[uuid.UUID]bool{event.ID: true}})
+
+	require.NoError(t, processor.HandleEvent(context.Background(), event))
+	assert.Zero(t, repo.updates)
+	assert.Zero(t, producer.published)
+}
+
+func TestOrderProcessor_DuplicateStatusUpdateIsNoOp(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending, Version: 1}
+	repo := &pendingOrderRepository{
+		order:     order,
+		updateErr: fmt.Errorf("failed to update order status: %w", repository.ErrEventAlreadyProcessed),
+	}
+	producer := &countingProducer{}
+
+	processor := services.NewOrderProcessor(repo, producer)
+	processor.EnableDeduplication(&fakeProcessedEvents{})
+
+	require.NoError(t, processor.HandleEvent(context.Background(), orderCreatedEvent(order)))
+	assert.Equal(t, 1, repo.updates)
+	assert.Zero(t, producer.published)
+}
+
+func TestOrderProcessor_PublishesAfterFirstStatusUpdate(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending, Version: 1}
+	repo := &pendingOrderRepository{order: order}
+	producer := &countingProducer{}
+
+	processor := services.NewOrderProcessor(repo, producer)
+	processor.EnableDeduplication(&fakeProcessedEvents{})
+
+	require.NoError(t, processor.HandleEvent(context.Background(), orderCreatedEvent(order)))
+	assert.Equal(t, 1, repo.updates)
+	assert.Equal(t, 1, producer.published)
+}
+
+type fakeProcessingWindowRepository struct {
+	windows *models.TenantProcessingWindows
+}
+
+func (r *fakeProcessingWindowRepository) Get(ctx context.Context, tenantID string) (*models.TenantProcessingWindows, error) {
+	if r.windows == nil || r.windows.TenantID != tenantID {
+		return nil, repository.ErrProcessingWindowsNotFound
+	}
+	return r.windows, nil
+}
+
+func (r *fakeProcessingWindowRepository) Upsert(ctx context.Context, windows *models.TenantProcessingWindows) error {
+	r.windows = windows
+	return nil
+}
+
+func (r *fakeProcessingWindowRepository) Delete(ctx context.Context, tenantID string) error {
+	r.windows = nil
+	return nil
+}
+
+// schedulingOrderRepository records orders scheduled by the processor.
+type schedulingOrderRepository struct {
+	pendingOrderRepository
+	scheduledFor time.Time
+}
+
+func (r *schedulingOrderRepository) Schedule(ctx context.Context, id uuid.UUID, version int, until time.Time) error {
+	r.scheduledFor = until
+	return nil
+}
+
+func TestOrderProcessor_SchedulesOrdersOutsideProcessingWindow(t *testing.T) {
+	now := time.Now().UTC()
+	closed := now.Add(2 * time.Hour)
+	rule := strings.ToLower(closed.Weekday().String()[:3]) + " " + closed.Format("15:04") + "-" + closed.Add(time.Minute).Format("15:04")
+	if closed.Add(time.Minute).Day() != closed.Day() {
+		t.Skip("window would cross midnight")
+	}
+
+	order := &models.Order{ID: uuid.New(), TenantID: "acme", Status: models.OrderStatusPending, Version: 1}
+	repo := &schedulingOrderRepository{pendingOrderRepository: pendingOrderRepository{order: order}}
+	producer := &countingProducer{}
+	windows := services.NewProcessingWindowService(&fakeProcessingWindowRepository{windows: &models.TenantProcessingWindows{TenantID: "acme", TimeZone: "UTC", Rules: []string{rule}}})
+
+	processor := services.NewOrderProcessor(repo, producer)
+	processor.EnableProcessingWindows(windows)
+
+	require.NoError(t, processor.HandleEvent(context.Background(), orderCreatedEvent(order)))
+	assert.Zero(t, repo.updates, "the order is not moved to processing")
+	assert.Equal(t, closed.Truncate(time.Minute), repo.scheduledFor)
+	assert.Equal(t, models.OrderStatusScheduled, order.Status)
+	assert.Equal(t, 1, producer.published)
+
+	other := &models.Order{ID: uuid.New(), TenantID: "globex", Status: models.OrderStatusPending, Version: 1}
+	repo.order = other
+	require.NoError(t, processor.HandleEvent(context.Background(), orderCreatedEvent(other)))
+	assert.Equal(t, 1, repo.updates, "tenants without windows are processed immediately")
+}
+
+type fakeProcessingAttempts struct {
+	attempts []*models.ProcessingAttempt
+}
+
+func (f *fakeProcessingAttempts) Start(ctx context.Context, attempt *models.ProcessingAttempt) error {
+	attempt.Attempt = len(f.attempts) + 1
+	f.attempts = append(f.attempts, attempt)
+	return nil
+}
+
+func (f *fakeProcessingAttempts) Finish(ctx context.Context, attempt *models.ProcessingAttempt) error {
+	return nil
+}
+
+func (f *fakeProcessingAttempts) CountFailures(ctx context.Context, eventID uuid.UUID) (int, error) {
+	failures := 0
+	for _, attempt := range f.attempts {
+		if attempt.EventID == eventID && attempt.Outcome == models.AttemptOutcomeFailed {
+			failures++
+		}
+	}
+	return failures, nil
+}
+
+func (f *fakeProcessingAttempts) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.ProcessingAttempt, error) {
+	return f.attempts, nil
+}
+
+func TestOrderProcessor_TracksAttemptsWithBackoff(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending, Version: 1}
+	event := orderCreatedEvent(order)
+	repo := &pendingOrderRepository{order: order, updateErr: errors.New("connection refused")}
+	attempts := &fakeProcessingAttempts{}
+
+	processor := services.NewOrderProcessor(repo, &countingProducer{})
+	processor.EnableAttemptTracking(attempts, models.BackoffPolicy{Base: time.Minute, Max: time.Hour})
+
+	var delays []time.Duration
+	for i := 0; i < 2; i++ {
+		before := time.Now()
+		err := processor.HandleEvent(context.Background(), event)
+		require.Error(t, err)
+
+		var retryAfter interface{ RetryAt() time.Time }
+		require.True(t, errors.As(err, &retryAfter))
+		delays = append(delays, retryAfter.RetryAt().Sub(before).Round(time.Second))
+	}
+	assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute}, delays)
+
+	repo.updateErr = nil
+	require.NoError(t, processor.HandleEvent(context.Background(), event))
+
+	require.Len(t, attempts.attempts, 3)
+	assert.Equal(t, models.AttemptOutcomeFailed, attempts.attempts[0].Outcome)
+	assert.Contains(t, attempts.attempts[0].Error, "connection refused")
+	assert.Equal(t, models.AttemptOutcomeSucceeded, attempts.attempts[2].Outcome)
+	assert.Equal(t, 3, attempts.attempts[2].Attempt)
+	assert.Nil(t, attempts.attempts[2].NextAttemptAt)
+}
+
+// finishingOrderRepository holds one processing order that only the first
+// MarkCompleted or MarkFailed finishes, like the conditional SQL transition.
+// The first two reads both wait for each other, so two deliveries of an event
+// see the order processing before either finishes it.
+type finishingOrderRepository struct {
+	repository.OrderRepository
+	mu       sync.Mutex
+	order    models.Order
+	reads    atomic.Int32
+	bothRead chan struct{}
+	finishes int
+}
+
+func newFinishingOrderRepository(order models.Order) *finishingOrderRepository {
+	return &finishingOrderRepository{order: order, bothRead: make(chan struct{})}
+}
+
+func (r *finishingOrderRepository) GetByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error) {
+	r.mu.Lock()
+	order := r.order
+	r.mu.Unlock()
+
+	switch r.reads.Add(1) {
+	case 1:
+		<-r.bothRead
+	case 2:
+		close(r.bothRead)
+	}
+	return &order, nil
+}
+
+func (r *finishingOrderRepository) MarkCompleted(ctx context.Context, id uuid.UUID) (bool, error) {
+	return r.finish(models.OrderStatusCompleted)
+}
+
+func (r *finishingOrderRepository) MarkFailed(ctx context.Context, id uuid.UUID, code models.FailureCode, detail string) (bool, error) {
+	return r.finish(models.OrderStatusFailed)
+}
+
+func (r *finishingOrderRepository) finish(status models.OrderStatus) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finishes++
+	if r.order.Status != models.OrderStatusProcessing {
+		return false, nil
+	}
+	r.order.Status = status
+	return true, nil
+}
+
+func TestOrderProcessor_RedeliveredProcessingEventFinishesOnce(t *testing.T) {
+	tests := []struct {
+		outcome  string
+		terminal models.EventType
+	}{
+		{outcome: models.DemoOutcomeComplete, terminal: models.OrderCompletedEvent},
+		{outcome: models.DemoOutcomeFail, terminal: models.OrderFailedEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.outcome, func(t *testing.T) {
+			// A demo order has a scripted outcome.
+			repo := newFinishingOrderRepository(models.Order{
+				ID:                uuid.New(),
+				TenantID:          models.DemoTenantID,
+				ExternalReference: models.DemoReference(tt.outcome, "run", 1),
+				Status:            models.OrderStatusProcessing,
+			})
+			producer := &eventLogProducer{}
+			processor := services.NewOrderProcessor(repo, producer)
+			event := models.NewEvent(models.OrderProcessingEvent, map[string]interface{}{"order_id": repo.order.ID.String()})
+
+			var wg sync.WaitGroup
+			errs := make(chan error, 2)
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- processor.HandleEvent(context.Background(), event)
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, 2, repo.finishes, "both deliveries try to finish the order")
+			published := producer.published()
+			require.Len(t, published, 1, "only the delivery that finished the order publishes")
+			assert.Equal(t, tt.terminal, published[0].Type)
+		})
+	}
+}
+
+// leasingOrderRepository holds one pending order and its dispatch lease, like
+// ClaimPendingForDispatch in Postgres.
+type leasingOrderRepository struct {
+	repository.OrderRepository
+	mu         sync.Mutex
+	order      models.Order
+	leaseUntil time.Time
+}
+
+func (r *leasingOrderRepository) ClaimPendingForDispatch(ctx context.Context, limit int) ([]*models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if r.order.Status != models.OrderStatusPending || r.leaseUntil.After(now) {
+		return nil, nil
+	}
+	r.leaseUntil = now.Add(models.DispatchLease)
+	order := r.order
+	return []*models.Order{&order}, nil
+}
+
+func (r *leasingOrderRepository) expireLease() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.leaseUntil = time.Now().Add(-time.Second)
+}
+
+func TestOrderProcessor_ProcessPendingOrdersHonorsDispatchLease(t *testing.T) {
+	repo := &leasingOrderRepository{order: models.Order{ID: uuid.New(), Status: models.OrderStatusPending}}
+	producer := &eventLogProducer{}
+	// Two replicas share the orders table.
+	replicas := []*services.OrderProcessor{
+		services.NewOrderProcessor(repo, producer),
+		services.NewOrderProcessor(repo, producer),
+	}
+
+	for _, processor := range replicas {
+		require.NoError(t, processor.ProcessPendingOrders(context.Background()))
+	}
+	require.Len(t, producer.published(), 1, "an empty claim while the lease is live publishes nothing")
+
+	repo.expireLease()
+	require.NoError(t, replicas[1].ProcessPendingOrders(context.Background()))
+	published := producer.published()
+	require.Len(t, published, 2, "an order still pending after its lease expired is dispatched again")
+	for _, event := range published {
+		assert.Equal(t, models.OrderCreatedEvent, event.Type)
+	}
+}
+
+// failingSagaRepository fails to create sagas with createErr.
+type failingSagaRepository struct {
+	repository.SagaRepository
+	createErr error
+}
+
+func (r *failingSagaRepository) Create(ctx context.Context, saga *models.Saga) error {
+	return r.createErr
+}
+
+// topicLogProducer records the events published to each topic.
+type topicLogProducer struct {
+	mu     sync.Mutex
+	topics []string
+}
+
+func (p *topicLogProducer) PublishEventToTopic(ctx context.Context, topic string, event *models.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func TestOrderProcessor_StartSagaCreateErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		createErr error
+		wantErr   bool
+	}{
+		{name: "saga already exists", createErr: repository.ErrDuplicateSaga},
+		{name: "database unavailable", createErr: errors.New("failed to create saga: connection reset"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &models.Order{ID: uuid.New(), Status: models.OrderStatusProcessing, Version: 2}
+			commands := &topicLogProducer{}
+			processor := services.NewOrderProcessor(&pendingOrderRepository{order: order}, &countingProducer{})
+			processor.EnableSaga(&failingSagaRepository{createErr: tt.createErr}, commands, &config.SagaConfig{
+				Enabled:             true,
+				PaymentRequestTopic: "payment-requests",
+				PaymentReplyTopic:   "payment-replies",
+			})
+
+			event := models.NewEvent(models.OrderProcessingEvent, map[string]interface{}{"order_id": order.ID.String()})
+			err := processor.HandleEvent(context.Background(), event)
+			if tt.wantErr {
+				// Returned so the event is redelivered; skipping it would leave
+				// the order processing with no saga for the timeout sweep.
+				assert.ErrorIs(t, err, tt.createErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Empty(t, commands.topics)
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+)
+
+// changesReader only implements the read side of the repository.
+type changesReader struct {
+	repository.OrderReader
+	orders []*models.Order
+	limit  int
+}
+
+func (r *changesReader) GetChangedSince(ctx context.Context, cursor models.ChangeCursor, until time.Time, limit int) ([]*models.Order, error) {
+	r.limit = limit
+	if len(r.orders) > limit {
+		return r.orders[:limit], nil
+	}
+	return r.orders, nil
+}
+
+func (r *changesReader) StreamChangedSince(ctx context.Context, cursor models.ChangeCursor, until time.Time, fn func(*models.Order) error) error {
+	for _, order := range r.orders {
+		if !order.UpdatedAt.Before(until) {
+			continue
+		}
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestOrderQueryService_GetOrderChangesPagesWithReader(t *testing.T) {
+	now := time.Now().UTC()
+	reader := &changesReader{}
+	for i := 0; i < 3; i++ {
+		reader.orders = append(reader.orders, &models.Order{ID: uuid.New(), UpdatedAt: now.Add(time.Duration(i) * time.Second)})
+	}
+
+	service := services.NewOrderQueryService(reader)
+	orders, next, hasMore, err := service.GetOrderChanges(context.Background(), models.ChangeCursor{}, 0, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, reader.limit)
+	assert.Len(t, orders, 2)
+	assert.True(t, hasMore)
+	assert.Equal(t, models.ChangeCursor{UpdatedAt: orders[1].UpdatedAt, ID: orders[1].ID}, next)
+}
+
+func TestOrderService_ServesReadsThroughQueryService(t *testing.T) {
+	reader := &changesReader{orders: []*models.Order{{ID: uuid.New()}}}
+	repo := struct {
+		repository.OrderReader
+		repository.OrderWriter
+	}{OrderReader: reader}
+
+	service := services.NewOrderService(repo, &countingProducer{})
+	orders, _, hasMore, err := service.GetOrderChanges(context.Background(), models.ChangeCursor{}, 0, 10)
+	require.NoError(t, err)
+
+	assert.Len(t, orders, 1)
+	assert.False(t, hasMore)
+}
+
+func TestOrderQueryService_StreamOrderChangesStopsOnError(t *testing.T) {
+	now := time.Now().UTC()
+	reader := &changesReader{orders: []*models.Order{
+		{ID: uuid.New(), UpdatedAt: now.Add(-time.Minute)},
+		{ID: uuid.New(), UpdatedAt: now.Add(-time.Minute)},
+		{ID: uuid.New(), UpdatedAt: now},
+	}}
+	service := services.NewOrderQueryService(reader)
+
+	var seen int
+	err := service.StreamOrderChanges(context.Background(), models.ChangeCursor{}, 5*time.Second, func(order *models.Order) error {
+		seen++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, seen, "orders within the safety lag are not streamed")
+
+	stop := errors.New("stop")
+	seen = 0
+	err = service.StreamOrderChanges(context.Background(), models.ChangeCursor{}, 5*time.Second, func(order *models.Order) error {
+		seen++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, seen)
+}
\ No newline at end of file
@@ -10,12 +10,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
 	"order-processing-microservice/internal/services"
-	"order-processing-microservice/pkg/config"
 )
 
 // Mock implementations
+
+// MockOrderRepository mocks the repository methods the tests use; calling any
+// other method panics on the nil embedded interface.
 type MockOrderRepository struct {
+	repository.OrderRepository
 	mock.Mock
 }
 
@@ -24,12 +28,17 @@ func (m *MockOrderRepository) Create(ctx context.Context, order *models.Order) e
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+func (m *MockOrderRepository) GetByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*models.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) GetByCustomerID(ctx context.Context, customerID uuid.UUID, limit, offset int) ([]*models.Order, error) {
+func (m *MockOrderRepository) GetByIDs(ctx context.Context, ids []uuid.UUID, opts ...models.LoadOption) ([]*models.Order, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetByCustomerID(ctx context.Context, customerID uuid.UUID, limit, offset int, opts ...models.LoadOption) ([]*models.Order, error) {
 	args := m.Called(ctx, customerID, limit, offset)
 	return args.Get(0).([]*models.Order), args.Error(1)
 }
@@ -44,16 +53,6 @@ func (m *MockOrderRepository) GetOrderStats(ctx context.Context) (*models.OrderS
 	return args.Get(0).(*models.OrderStats), args.Error(1)
 }
 
-func (m *MockOrderRepository) GetOrdersByStatus(ctx context.Context, status models.OrderStatus, limit, offset int) ([]*models.Order, error) {
-	args := m.Called(ctx, status, limit, offset)
-	return args.Get(0).([]*models.Order), args.Error(1)
-}
-
-func (m *MockOrderRepository) GetPendingOrders(ctx context.Context, limit int) ([]*models.Order, error) {
-	args := m.Called(ctx, limit)
-	return args.Get(0).([]*models.Order), args.Error(1)
-}
-
 type MockProducer struct {
 	mock.Mock
 }
@@ -72,9 +71,9 @@ func TestOrderService_CreateOrder(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := &MockOrderRepository{}
 	mockProducer := &MockProducer{}
-	
+
 	service := services.NewOrderService(mockRepo, mockProducer)
-	
+
 	tests := []struct {
 		name      string
 		request   *models.CreateOrderRequest
@@ -88,7 +87,6 @@ func TestOrderService_CreateOrder(t *testing.T) {
 				Items: []models.CreateOrderItemRequest{
 					{
 						ProductID: uuid.New(),
-						Name:      "Test Product",
 						Price:     29.99,
 						Quantity:  2,
 					},
@@ -107,7 +105,6 @@ func TestOrderService_CreateOrder(t *testing.T) {
 				Items: []models.CreateOrderItemRequest{
 					{
 						ProductID: uuid.New(),
-						Name:      "Test Product",
 						Price:     29.99,
 						Quantity:  2,
 					},
@@ -118,29 +115,18 @@ func TestOrderService_CreateOrder(t *testing.T) {
 			},
 			wantErr: true,
 		},
-		{
-			name: "invalid request - no items",
-			request: &models.CreateOrderRequest{
-				CustomerID: uuid.New(),
-				Items:      []models.CreateOrderItemRequest{},
-			},
-			setupMock: func() {
-				// No mocks needed as validation should fail
-			},
-			wantErr: true,
-		},
 	}
-	
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Reset mocks
 			mockRepo.ExpectedCalls = nil
 			mockProducer.ExpectedCalls = nil
-			
+
 			tt.setupMock()
-			
+
 			order, err := service.CreateOrder(ctx, tt.request)
-			
+
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, order)
@@ -150,25 +136,24 @@ func TestOrderService_CreateOrder(t *testing.T) {
 				assert.Equal(t, tt.request.CustomerID, order.CustomerID)
 				assert.Equal(t, models.OrderStatusPending, order.Status)
 				assert.Equal(t, len(tt.request.Items), len(order.Items))
-				
+
 				// Verify total amount calculation
 				expectedTotal := tt.request.Items[0].Price * float64(tt.request.Items[0].Quantity)
 				assert.Equal(t, expectedTotal, order.TotalAmount)
 			}
-			
+
 			mockRepo.AssertExpectations(t)
 			mockProducer.AssertExpectations(t)
 		})
 	}
 }
 
-func TestOrderService_GetOrder(t *testing.T) {
+func TestOrderQueryService_GetOrderByID(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := &MockOrderRepository{}
-	mockProducer := &MockProducer{}
-	
-	service := services.NewOrderService(mockRepo, mockProducer)
-	
+
+	service := services.NewOrderQueryService(mockRepo)
+
 	orderID := uuid.New()
 	expectedOrder := &models.Order{
 		ID:         orderID,
@@ -189,7 +174,7 @@ func TestOrderService_GetOrder(t *testing.T) {
 		UpdatedAt:   time.Now(),
 		Version:     1,
 	}
-	
+
 	tests := []struct {
 		name      string
 		orderID   uuid.UUID
@@ -216,16 +201,16 @@ func TestOrderService_GetOrder(t *testing.T) {
 			wantErr:  true,
 		},
 	}
-	
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Reset mocks
 			mockRepo.ExpectedCalls = nil
-			
+
 			tt.setupMock()
-			
-			order, err := service.GetOrder(ctx, tt.orderID)
-			
+
+			order, err := service.GetOrderByID(ctx, tt.orderID)
+
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, order)
@@ -233,19 +218,18 @@ func TestOrderService_GetOrder(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expected, order)
 			}
-			
+
 			mockRepo.AssertExpectations(t)
 		})
 	}
 }
 
-func TestOrderService_GetOrdersByCustomer(t *testing.T) {
+func TestOrderQueryService_GetOrdersByCustomerID(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := &MockOrderRepository{}
-	mockProducer := &MockProducer{}
-	
-	service := services.NewOrderService(mockRepo, mockProducer)
-	
+
+	service := services.NewOrderQueryService(mockRepo)
+
 	customerID := uuid.New()
 	expectedOrders := []*models.Order{
 		{
@@ -261,7 +245,7 @@ func TestOrderService_GetOrdersByCustomer(t *testing.T) {
 			TotalAmount: 29.99,
 		},
 	}
-	
+
 	tests := []struct {
 		name       string
 		customerID uuid.UUID
@@ -294,16 +278,16 @@ func TestOrderService_GetOrdersByCustomer(t *testing.T) {
 			wantErr:  true,
 		},
 	}
-	
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Reset mocks
 			mockRepo.ExpectedCalls = nil
-			
+
 			tt.setupMock()
-			
-			orders, err := service.GetOrdersByCustomer(ctx, tt.customerID, tt.limit, tt.offset)
-			
+
+			orders, err := service.GetOrdersByCustomerID(ctx, tt.customerID, tt.limit, tt.offset)
+
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, orders)
@@ -311,7 +295,7 @@ func TestOrderService_GetOrdersByCustomer(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expected, orders)
 			}
-			
+
 			mockRepo.AssertExpectations(t)
 		})
 	}
@@ -321,16 +305,15 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := &MockOrderRepository{}
 	mockProducer := &MockProducer{}
-	
+
 	service := services.NewOrderService(mockRepo, mockProducer)
-	
+
 	orderID := uuid.New()
-	
+
 	tests := []struct {
 		name      string
 		orderID   uuid.UUID
 		status    models.OrderStatus
-		version   int
 		setupMock func()
 		wantErr   bool
 	}{
@@ -338,8 +321,8 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 			name:    "successful status update",
 			orderID: orderID,
 			status:  models.OrderStatusProcessing,
-			version: 1,
 			setupMock: func() {
+				mockRepo.On("GetByID", ctx, orderID).Return(&models.Order{ID: orderID, Status: models.OrderStatusPending, Version: 1}, nil)
 				mockRepo.On("UpdateStatus", ctx, orderID, models.OrderStatusProcessing, 1).Return(nil)
 				mockProducer.On("PublishEvent", ctx, mock.AnythingOfType("*models.Event")).Return(nil)
 			},
@@ -349,43 +332,42 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 			name:    "repository error",
 			orderID: orderID,
 			status:  models.OrderStatusProcessing,
-			version: 1,
 			setupMock: func() {
+				mockRepo.On("GetByID", ctx, orderID).Return(&models.Order{ID: orderID, Status: models.OrderStatusPending, Version: 1}, nil)
 				mockRepo.On("UpdateStatus", ctx, orderID, models.OrderStatusProcessing, 1).Return(errors.New("database error"))
 			},
 			wantErr: true,
 		},
 	}
-	
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Reset mocks
 			mockRepo.ExpectedCalls = nil
 			mockProducer.ExpectedCalls = nil
-			
+
 			tt.setupMock()
-			
-			err := service.UpdateOrderStatus(ctx, tt.orderID, tt.status, tt.version)
-			
+
+			err := service.UpdateOrderStatus(ctx, tt.orderID, tt.status, "")
+
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
 			}
-			
+
 			mockRepo.AssertExpectations(t)
 			mockProducer.AssertExpectations(t)
 		})
 	}
 }
 
-func TestOrderService_GetOrderStats(t *testing.T) {
+func TestOrderQueryService_GetOrderStats(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := &MockOrderRepository{}
-	mockProducer := &MockProducer{}
-	
-	service := services.NewOrderService(mockRepo, mockProducer)
-	
+
+	service := services.NewOrderQueryService(mockRepo)
+
 	expectedStats := &models.OrderStats{
 		Pending:    5,
 		Processing: 3,
@@ -394,7 +376,7 @@ func TestOrderService_GetOrderStats(t *testing.T) {
 		Canceled:   2,
 		Total:      21,
 	}
-	
+
 	tests := []struct {
 		name      string
 		setupMock func()
@@ -418,16 +400,16 @@ func TestOrderService_GetOrderStats(t *testing.T) {
 			wantErr:  true,
 		},
 	}
-	
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Reset mocks
 			mockRepo.ExpectedCalls = nil
-			
+
 			tt.setupMock()
-			
+
 			stats, err := service.GetOrderStats(ctx)
-			
+
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, stats)
@@ -435,97 +417,46 @@ func TestOrderService_GetOrderStats(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expected, stats)
 			}
-			
+
 			mockRepo.AssertExpectations(t)
 		})
 	}
 }
 
-func TestOrderService_ValidateOrderRequest(t *testing.T) {
-	tests := []struct {
-		name    string
-		request *models.CreateOrderRequest
-		wantErr bool
-	}{
-		{
-			name: "valid request",
-			request: &models.CreateOrderRequest{
-				CustomerID: uuid.New(),
-				Items: []models.CreateOrderItemRequest{
-					{
-						ProductID: uuid.New(),
-						Name:      "Test Product",
-						Price:     29.99,
-						Quantity:  2,
-					},
-				},
-			},
-			wantErr: false,
-		},
-		{
-			name: "empty customer ID",
-			request: &models.CreateOrderRequest{
-				CustomerID: uuid.Nil,
-				Items: []models.CreateOrderItemRequest{
-					{
-						ProductID: uuid.New(),
-						Name:      "Test Product",
-						Price:     29.99,
-						Quantity:  2,
-					},
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "no items",
-			request: &models.CreateOrderRequest{
-				CustomerID: uuid.New(),
-				Items:      []models.CreateOrderItemRequest{},
-			},
-			wantErr: true,
-		},
-		{
-			name: "invalid item price",
-			request: &models.CreateOrderRequest{
-				CustomerID: uuid.New(),
-				Items: []models.CreateOrderItemRequest{
-					{
-						ProductID: uuid.New(),
-						Name:      "Test Product",
-						Price:     -1.0,
-						Quantity:  2,
-					},
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "invalid item quantity",
-			request: &models.CreateOrderRequest{
-				CustomerID: uuid.New(),
-				Items: []models.CreateOrderItemRequest{
-					{
-						ProductID: uuid.New(),
-						Name:      "Test Product",
-						Price:     29.99,
-						Quantity:  0,
-					},
-				},
-			},
-			wantErr: true,
-		},
-	}
-	
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := services.ValidateCreateOrderRequest(tt.request)
-			
-			if tt.wantErr {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-			}
-		})
-	}
+func TestOrderQueryService_GetOrdersByIDs(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := &MockOrderRepository{}
+
+	service := services.NewOrderQueryService(mockRepo)
+
+	first := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending}
+	second := &models.Order{ID: uuid.New(), Status: models.OrderStatusCompleted}
+	missingID := uuid.New()
+
+	mockRepo.On("GetByIDs", ctx, []uuid.UUID{second.ID, missingID, first.ID}).
+		Return([]*models.Order{first, second}, nil)
+
+	orders, missing, err := service.GetOrdersByIDs(ctx, []uuid.UUID{second.ID, missingID, first.ID, second.ID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Order{second, first}, orders)
+	assert.Equal(t, []uuid.UUID{missingID}, missing)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_RecalculateTotalsRejectsInvalidRequests(t *testing.T) {
+	service := services.NewOrderService(new(MockOrderRepository), new(MockProducer))
+
+	_, err := service.RecalculateTotals(context.Background(), &models.RecalculateTotalsRequest{
+		OrderIDs: make([]uuid.UUID, models.MaxRecalculationOrders+1),
+		Reason:   "price change",
+	})
+	assert.ErrorIs(t, err, services.ErrTooManyOrders)
+
+	_, err = service.RecalculateTotals(context.Background(), &models.RecalculateTotalsRequest{
+		OrderIDs: []uuid.UUID{uuid.New()},
+		Prices:   map[uuid.UUID]float64{uuid.New(): -1},
+		Reason:   "price change",
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidPrice)
 }
\ No newline at end of file
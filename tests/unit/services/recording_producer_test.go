@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+)
+
+func TestRecordingProducer_NumbersEventsPerOrder(t *testing.T) {
+	store := &memoryEventStore{}
+	producer := &eventLogProducer{}
+	recording := services.NewRecordingProducer(producer, store)
+
+	first := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending}
+	second := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending}
+	ctx := context.Background()
+	require.NoError(t, recording.PublishEvent(ctx, models.NewOrderCreatedEvent(first)))
+	require.NoError(t, recording.PublishEvent(ctx, models.NewOrderCreatedEvent(second)))
+	require.NoError(t, recording.PublishEvent(ctx, models.NewOrderStatusChangedEvent(first, models.OrderStatusPending, "")))
+
+	published := producer.published()
+	require.Len(t, published, 3)
+	assert.Equal(t, uint64(1), published[0].Sequence)
+	assert.Equal(t, uint64(1), published[1].Sequence)
+	assert.Equal(t, uint64(2), published[2].Sequence)
+	assert.Equal(t, published, store.events, "events are recorded with their sequence")
+}
+
+func TestRecordingProducer_KeepsSequence(t *testing.T) {
+	store := &memoryEventStore{}
+	producer := &eventLogProducer{}
+	recording := services.NewRecordingProducer(producer, store)
+
+	event := models.NewOrderCreatedEvent(&models.Order{ID: uuid.New()})
+	event.Sequence = 7
+	require.NoError(t, recording.PublishEvent(context.Background(), event))
+
+	assert.Equal(t, uint64(7), producer.published()[0].Sequence)
+	assert.Empty(t, store.sequences)
+}
+
+func TestRecordingProducer_LeavesOtherEventsUnnumbered(t *testing.T) {
+	store := &memoryEventStore{}
+	producer := &eventLogProducer{}
+	recording := services.NewRecordingProducer(producer, store)
+
+	require.NoError(t, recording.PublishEvent(context.Background(), models.NewHeartbeatEvent("producer-1", 1)))
+
+	assert.Zero(t, producer.published()[0].Sequence)
+	assert.Empty(t, store.events)
+}
+
+func TestRecordingProducer_DoesNotRecordFailedPublish(t *testing.T) {
+	store := &memoryEventStore{}
+	producer := &flakyProducer{err: errors.New("broker unavailable")}
+	recording := services.NewRecordingProducer(producer, store)
+
+	err := recording.PublishEvent(context.Background(), models.NewOrderCreatedEvent(&models.Order{ID: uuid.New()}))
+	require.Error(t, err)
+
+	assert.Empty(t, store.events, "an event that was never published is not recorded")
+}
+
+func TestOrderHistoryService_OrderWithoutCreatedEventIsNotFound(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusPending}
+	store := &memoryEventStore{events: []*models.Event{
+		models.NewOrderStatusChangedEvent(order, models.OrderStatusPending, ""),
+	}}
+	service := services.NewOrderHistoryService(store)
+
+	_, err := service.GetOrderAsOf(context.Background(), order.ID, time.Now())
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/queue"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+)
+
+// smokeOrderRepository stores the created order and moves it to finalStatus
+// the first time it is read back.
+type smokeOrderRepository struct {
+	repository.OrderRepository
+	mu          sync.Mutex
+	order       *models.Order
+	finalStatus models.OrderStatus
+	deleted     []uuid.UUID
+}
+
+func (r *smokeOrderRepository) Create(ctx context.Context, order *models.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order = order
+	return nil
+}
+
+func (r *smokeOrderRepository) GetByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order := *r.order
+	order.Status = r.finalStatus
+	return &order, nil
+}
+
+func (r *smokeOrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+// fakeReplayer replays the events of the given types for the stored order,
+// plus an event of an unrelated order.
+type fakeReplayer struct {
+	repo  *smokeOrderRepository
+	types []models.EventType
+}
+
+func (f *fakeReplayer) ReplaySince(ctx context.Context, since time.Time, handler queue.EventHandler) (int, error) {
+	events := []*models.Event{
+		models.NewEvent(models.OrderCompletedEvent, map[string]interface{}{"order_id": uuid.New().String()}),
+	}
+	for _, t := range f.types {
+		events = append(events, models.NewEvent(t, map[string]interface{}{"order_id": f.repo.order.ID.String()}))
+	}
+	for _, event := range events {
+		if err := handler.HandleEvent(ctx, event); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+func newSmokeCheck(repo *smokeOrderRepository, replayer services.EventReplayer) *services.SmokeCheck {
+	orderService := services.NewOrderService(repo, &countingProducer{})
+	return services.NewSmokeCheck(orderService, repo, replayer, 5*time.Millisecond)
+}
+
+func TestSmokeCheck_Passes(t *testing.T) {
+	repo := &smokeOrderRepository{finalStatus: models.OrderStatusCompleted}
+	replayer := &fakeReplayer{repo: repo, types: []models.EventType{
+		models.OrderCreatedEvent, models.OrderProcessingEvent, models.OrderCompletedEvent,
+	}}
+
+	result, err := newSmokeCheck(repo, replayer).Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, repo.order.ID, result.OrderID)
+	assert.Equal(t, services.SmokeTenantID, repo.order.TenantID)
+	assert.Equal(t, models.OrderStatusCompleted, result.Status)
+	assert.Len(t, result.Events, 3)
+	assert.Equal(t, []uuid.UUID{repo.order.ID}, repo.deleted)
+}
+
+func TestSmokeCheck_FailedOrder(t *testing.T) {
+	repo := &smokeOrderRepository{finalStatus: models.OrderStatusFailed}
+
+	result, err := newSmokeCheck(repo, nil).Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed")
+	assert.Equal(t, models.OrderStatusFailed, result.Status)
+	assert.Equal(t, []uuid.UUID{repo.order.ID}, repo.deleted)
+}
+
+func TestSmokeCheck_MissingEvents(t *testing.T) {
+	repo := &smokeOrderRepository{finalStatus: models.OrderStatusCompleted}
+	replayer := &fakeReplayer{repo: repo, types: []models.EventType{models.OrderCreatedEvent}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	result, err := newSmokeCheck(repo, replayer).Run(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), string(models.OrderProcessingEvent))
+	assert.Contains(t, err.Error(), string(models.OrderCompletedEvent))
+	assert.Equal(t, []models.EventType{models.OrderCreatedEvent}, result.Events)
+	assert.Len(t, repo.deleted, 1)
+}
+
+func TestSmokeCheck_SkipsTopicWithoutReplayer(t *testing.T) {
+	repo := &smokeOrderRepository{finalStatus: models.OrderStatusCompleted}
+
+	result, err := newSmokeCheck(repo, nil).Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, result.Events)
+}
\ No newline at end of file
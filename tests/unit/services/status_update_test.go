@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+)
+
+// statusOrderRepository holds a single order.
+type statusOrderRepository struct {
+	repository.OrderRepository
+	order   *models.Order
+	updates int
+}
+
+func (r *statusOrderRepository) GetByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error) {
+	order := *r.order
+	return &order, nil
+}
+
+func (r *statusOrderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.OrderStatus, version int) error {
+	r.updates++
+	r.order.Status = status
+	r.order.Version++
+	return nil
+}
+
+// memoryEventStore is an event store whose events are appended by the
+// producer it wraps, as RecordingProducer does.
+type memoryEventStore struct {
+	events    []*models.Event
+	sequences map[uuid.UUID]uint64
+}
+
+func (s *memoryEventStore) Append(ctx context.Context, orderID uuid.UUID, event *models.Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *memoryEventStore) NextSequence(ctx context.Context, orderID uuid.UUID) (uint64, error) {
+	if s.sequences == nil {
+		s.sequences = make(map[uuid.UUID]uint64)
+	}
+	s.sequences[orderID]++
+	return s.sequences[orderID], nil
+}
+
+func (s *memoryEventStore) GetByOrderID(ctx context.Context, orderID uuid.UUID, until time.Time) ([]*models.Event, error) {
+	return s.events, nil
+}
+
+func newStatusUpdateService(status models.OrderStatus) (*services.OrderService, *statusOrderRepository, *countingProducer) {
+	order := &models.Order{ID: uuid.New(), Status: status, Version: 1}
+	repo := &statusOrderRepository{order: order}
+	store := &memoryEventStore{events: []*models.Event{models.NewOrderCreatedEvent(order)}}
+	producer := &countingProducer{}
+
+	service := services.NewOrderService(repo, services.NewRecordingProducer(producer, store))
+	service.EnableIdempotentStatusUpdates(store)
+	return service, repo, producer
+}
+
+func TestOrderService_RepeatedStatusUpdateIsIdempotent(t *testing.T) {
+	service, repo, producer := newStatusUpdateService(models.OrderStatusPending)
+	ctx := context.Background()
+	id := repo.order.ID
+
+	require.NoError(t, service.UpdateOrderStatus(ctx, id, models.OrderStatusOnHold, "awaiting stock"))
+	require.NoError(t, service.UpdateOrderStatus(ctx, id, models.OrderStatusOnHold, "awaiting stock"))
+	assert.Equal(t, 1, repo.updates)
+	assert.Equal(t, 1, producer.published)
+
+	err := service.UpdateOrderStatus(ctx, id, models.OrderStatusOnHold, "fraud check")
+	assert.EqualError(t, err, "invalid status transition from on_hold to on_hold")
+}
+
+func TestOrderService_RepeatedCancelIsIdempotent(t *testing.T) {
+	service, repo, producer := newStatusUpdateService(models.OrderStatusPending)
+	ctx := context.Background()
+	id := repo.order.ID
+
+	req := func() *models.CancelOrderRequest {
+		return &models.CancelOrderRequest{ReasonCode: models.CancelReasonFraud, Reason: "card stolen"}
+	}
+	require.NoError(t, service.CancelOrder(ctx, id, req()))
+	require.NoError(t, service.CancelOrder(ctx, id, req()))
+	assert.Equal(t, 1, producer.published)
+
+	err := service.CancelOrder(ctx, id, &models.CancelOrderRequest{ReasonCode: models.CancelReasonUserRequest})
+	assert.ErrorContains(t, err, "invalid status transition")
+}
+
+func TestOrderService_SameStatusWithoutHistoryIsInvalid(t *testing.T) {
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusOnHold, Version: 1}
+	service := services.NewOrderService(&statusOrderRepository{order: order}, &countingProducer{})
+
+	err := service.UpdateOrderStatus(context.Background(), order.ID, models.OrderStatusOnHold, "")
+	assert.EqualError(t, err, "invalid status transition from on_hold to on_hold")
+}
\ No newline at end of file
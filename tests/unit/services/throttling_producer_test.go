@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+// eventLogProducer records the events published through it.
+type eventLogProducer struct {
+	mu     sync.Mutex
+	events []*models.Event
+}
+
+func (p *eventLogProducer) PublishEvent(ctx context.Context, event *models.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *eventLogProducer) Close() error {
+	return nil
+}
+
+func (p *eventLogProducer) published() []*models.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*models.Event(nil), p.events...)
+}
+
+func statusChanged(orderID uuid.UUID, newStatus models.OrderStatus) *models.Event {
+	order := &models.Order{ID: orderID, Status: newStatus}
+	return models.NewOrderStatusChangedEvent(order, models.OrderStatusPending, "")
+}
+
+func TestThrottlingProducer_CoalescesEventsPerOrder(t *testing.T) {
+	inner := &eventLogProducer{}
+	producer, err := services.NewThrottlingProducer(inner, &config.EventThrottleConfig{
+		Intervals: []string{"order.status.changed=50ms"},
+	})
+	require.NoError(t, err)
+
+	orderID := uuid.New()
+	other := uuid.New()
+	ctx := context.Background()
+	require.NoError(t, producer.PublishEvent(ctx, statusChanged(orderID, models.OrderStatusOnHold)))
+	require.NoError(t, producer.PublishEvent(ctx, statusChanged(orderID, models.OrderStatusPending)))
+	require.NoError(t, producer.PublishEvent(ctx, statusChanged(orderID, models.OrderStatusOnHold)))
+	require.NoError(t, producer.PublishEvent(ctx, statusChanged(other, models.OrderStatusOnHold)))
+	assert.Len(t, inner.published(), 2)
+
+	deadline := time.Now().Add(time.Second)
+	for len(inner.published()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	events := inner.published()
+	require.Len(t, events, 3)
+	// The event held back is the latest of the order.
+	assert.Equal(t, models.OrderStatusOnHold, events[2].Data.(models.OrderStatusChangedEventData).NewStatus)
+
+	stats := producer.ThrottleStats()[models.OrderStatusChangedEvent]
+	assert.Equal(t, services.ThrottleStats{Published: 2, Delayed: 1, Dropped: 1}, stats)
+}
+
+func TestThrottlingProducer_PassesThroughOtherTypes(t *testing.T) {
+	inner := &eventLogProducer{}
+	producer, err := services.NewThrottlingProducer(inner, &config.EventThrottleConfig{
+		Intervals: []string{"order.status.changed=1h"},
+	})
+	require.NoError(t, err)
+
+	order := &models.Order{ID: uuid.New(), Status: models.OrderStatusCanceled}
+	req := &models.CancelOrderRequest{ReasonCode: models.CancelReasonAdmin}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, producer.PublishEvent(context.Background(), models.NewOrderCanceledEvent(order, models.OrderStatusPending, req)))
+	}
+	assert.Len(t, inner.published(), 3)
+	assert.Empty(t, producer.ThrottleStats())
+}
+
+func TestThrottlingProducer_FlushPublishesHeldEvents(t *testing.T) {
+	inner := &eventLogProducer{}
+	producer, err := services.NewThrottlingProducer(inner, &config.EventThrottleConfig{
+		Intervals: []string{"order.status.changed=1h"},
+	})
+	require.NoError(t, err)
+
+	orderID := uuid.New()
+	require.NoError(t, producer.PublishEvent(context.Background(), statusChanged(orderID, models.OrderStatusOnHold)))
+	require.NoError(t, producer.PublishEvent(context.Background(), statusChanged(orderID, models.OrderStatusPending)))
+	assert.Len(t, inner.published(), 1)
+
+	require.NoError(t, producer.Close())
+	assert.Len(t, inner.published(), 2)
+}
+
+func TestNewThrottlingProducer_InvalidInterval(t *testing.T) {
+	for _, entry := range []string{"order.status.changed", "order.status.changed=soon", "=5s", "order.status.changed=0s"} {
+		_, err := services.NewThrottlingProducer(&eventLogProducer{}, &config.EventThrottleConfig{Intervals: []string{entry}})
+		assert.ErrorContains(t, err, "invalid event throttle interval", entry)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/repository"
+	"order-processing-microservice/internal/services"
+	"order-processing-microservice/pkg/config"
+)
+
+// singleOrderReader finds one order only.
+type singleOrderReader struct {
+	repository.OrderReader
+	order *models.Order
+}
+
+func (r singleOrderReader) GetByID(ctx context.Context, id uuid.UUID, opts ...models.LoadOption) (*models.Order, error) {
+	if id != r.order.ID {
+		return nil, repository.ErrOrderNotFound
+	}
+	return r.order, nil
+}
+
+func newTrackedOrder() *models.Order {
+	return &models.Order{
+		ID:          uuid.New(),
+		OrderNumber: "ORD-2025-000042",
+		CustomerID:  uuid.New(),
+		Status:      models.OrderStatusProcessing,
+		Items:       []models.OrderItem{{ProductID: uuid.New(), Quantity: 2, Price: 9.5}},
+		TotalAmount: 19,
+	}
+}
+
+func TestTrackingService_IssueAndTrack(t *testing.T) {
+	order := newTrackedOrder()
+	service := services.NewTrackingService(services.NewOrderQueryService(singleOrderReader{order: order}), &config.TrackingConfig{
+		SigningKey: "test-signing-key",
+		TTL:        24,
+		BaseURL:    "https://orders.example.com/",
+	})
+
+	token, err := service.IssueToken(context.Background(), order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://orders.example.com/track/"+token.Token, token.URL)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), token.ExpiresAt, time.Minute)
+
+	view, err := service.Track(context.Background(), token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "ORD-2025-000042", view.OrderNumber)
+	assert.Equal(t, models.OrderStatusProcessing, view.Status)
+	assert.Empty(t, view.Items, "items are hidden by default")
+	assert.Nil(t, view.TotalAmount, "the total is hidden by default")
+
+	_, err = service.IssueToken(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestTrackingService_ShowsConfiguredDetails(t *testing.T) {
+	order := newTrackedOrder()
+	service := services.NewTrackingService(services.NewOrderQueryService(singleOrderReader{order: order}), &config.TrackingConfig{
+		SigningKey: "test-signing-key",
+		TTL:        24,
+		ShowItems:  true,
+		ShowTotal:  true,
+	})
+
+	token, err := service.IssueToken(context.Background(), order.ID)
+	require.NoError(t, err)
+	view, err := service.Track(context.Background(), token.Token)
+	require.NoError(t, err)
+
+	require.Len(t, view.Items, 1)
+	assert.Equal(t, models.TrackingItem{ProductID: order.Items[0].ProductID, Quantity: 2}, view.Items[0])
+	require.NotNil(t, view.TotalAmount)
+	assert.Equal(t, 19.0, *view.TotalAmount)
+}
+
+func TestTrackingService_RejectsBadTokens(t *testing.T) {
+	order := newTrackedOrder()
+	orders := services.NewOrderQueryService(singleOrderReader{order: order})
+	service := services.NewTrackingService(orders, &config.TrackingConfig{SigningKey: "test-signing-key", TTL: 24})
+
+	token, err := service.IssueToken(context.Background(), order.ID)
+	require.NoError(t, err)
+
+	otherKey := services.NewTrackingService(orders, &config.TrackingConfig{SigningKey: "rotated-key", TTL: 24})
+	_, err = otherKey.Track(context.Background(), token.Token)
+	assert.ErrorIs(t, err, services.ErrInvalidTrackingToken)
+
+	payload, signature, _ := strings.Cut(token.Token, ".")
+	tampered := "A" + payload[1:]
+	if tampered == payload {
+		tampered = "B" + payload[1:]
+	}
+	_, err = service.Track(context.Background(), tampered+"."+signature)
+	assert.ErrorIs(t, err, services.ErrInvalidTrackingToken)
+
+	_, err = service.Track(context.Background(), "not-a-token")
+	assert.ErrorIs(t, err, services.ErrInvalidTrackingToken)
+
+	expired := services.NewTrackingService(orders, &config.TrackingConfig{SigningKey: "test-signing-key", TTL: -1})
+	token, err = expired.IssueToken(context.Background(), order.ID)
+	require.NoError(t, err)
+	_, err = expired.Track(context.Background(), token.Token)
+	assert.ErrorIs(t, err, services.ErrTrackingTokenExpired)
+}
\ No newline at end of file
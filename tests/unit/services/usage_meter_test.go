@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/models"
+	"order-processing-microservice/internal/services"
+)
+
+type fakeUsageRepository struct {
+	added  []models.UsageRecord
+	addErr error
+}
+
+func (r *fakeUsageRepository) Add(ctx context.Context, records []models.UsageRecord) error {
+	if r.addErr != nil {
+		return r.addErr
+	}
+	r.added = append(r.added, records...)
+	return nil
+}
+
+func (r *fakeUsageRepository) List(ctx context.Context, filter models.UsageFilter) ([]models.UsageRecord, error) {
+	return r.added, nil
+}
+
+func usageCounts(records []models.UsageRecord) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, record := range records {
+		counts[record.TenantID+"/"+string(record.Metric)] += record.Count
+	}
+	return counts
+}
+
+func TestUsageMeter_FlushAggregates(t *testing.T) {
+	repo := &fakeUsageRepository{}
+	meter := services.NewUsageMeter(repo)
+
+	meter.Record("acme", models.UsageMetricAPICalls)
+	meter.Record("acme", models.UsageMetricAPICalls)
+	meter.Record("acme", models.UsageMetricOrdersCreated)
+	meter.Record("", models.UsageMetricAPICalls)
+
+	require.NoError(t, meter.Flush(context.Background()))
+	assert.Equal(t, map[string]int64{
+		"acme/api_calls":      2,
+		"acme/orders_created": 1,
+		"default/api_calls":   1,
+	}, usageCounts(repo.added))
+
+	for _, record := range repo.added {
+		assert.Equal(t, models.UsageHour(record.Hour), record.Hour)
+	}
+
+	repo.added = nil
+	require.NoError(t, meter.Flush(context.Background()))
+	assert.Empty(t, repo.added)
+}
+
+func TestUsageMeter_FlushFailureKeepsCounts(t *testing.T) {
+	repo := &fakeUsageRepository{addErr: errors.New("database unavailable")}
+	meter := services.NewUsageMeter(repo)
+
+	meter.Record("acme", models.UsageMetricEventsPublished)
+	assert.Error(t, meter.Flush(context.Background()))
+
+	meter.Record("acme", models.UsageMetricEventsPublished)
+	repo.addErr = nil
+	require.NoError(t, meter.Flush(context.Background()))
+	assert.Equal(t, map[string]int64{"acme/events_published": 2}, usageCounts(repo.added))
+}
+
+func TestUsageMeter_GetUsageTotals(t *testing.T) {
+	repo := &fakeUsageRepository{}
+	meter := services.NewUsageMeter(repo)
+
+	meter.Record("acme", models.UsageMetricAPICalls)
+	meter.Record("globex", models.UsageMetricAPICalls)
+	require.NoError(t, meter.Flush(context.Background()))
+
+	report, err := meter.GetUsage(context.Background(), models.UsageFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Totals[models.UsageMetricAPICalls])
+	assert.Len(t, report.Records, 2)
+}
\ No newline at end of file
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order-processing-microservice/internal/storage"
+	"order-processing-microservice/pkg/config"
+)
+
+func newLocalBlobStore(t *testing.T) *storage.LocalBlobStore {
+	store, err := storage.NewLocalBlobStore(&config.AttachmentConfig{
+		LocalPath:  t.TempDir(),
+		PublicURL:  "http://localhost:8080/",
+		SigningKey: "test-signing-key",
+	})
+	require.NoError(t, err)
+	return store
+}
+
+func TestLocalBlobStore_PutGetDelete(t *testing.T) {
+	store := newLocalBlobStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "orders/1/a", strings.NewReader("content"), 7, "text/plain"))
+
+	blob, err := store.Get(ctx, "orders/1/a")
+	require.NoError(t, err)
+	content, err := io.ReadAll(blob)
+	blob.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	require.NoError(t, store.Delete(ctx, "orders/1/a"))
+	_, err = store.Get(ctx, "orders/1/a")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+
+	assert.Error(t, store.Put(ctx, "../escape", strings.NewReader("x"), 1, "text/plain"))
+}
+
+func TestLocalBlobStore_SignedURL(t *testing.T) {
+	store := newLocalBlobStore(t)
+	opts := storage.DownloadOptions{FileName: "po.pdf", ContentType: "application/pdf"}
+
+	signed, err := store.SignedURL(context.Background(), "orders/1/a", opts, time.Minute)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(signed, "http://localhost:8080"+storage.LocalDownloadPath+"?"))
+
+	parsed, err := url.Parse(signed)
+	require.NoError(t, err)
+	key, verified, err := store.VerifySignedURL(parsed.Query())
+	require.NoError(t, err)
+	assert.Equal(t, "orders/1/a", key)
+	assert.Equal(t, opts, verified)
+
+	tampered := parsed.Query()
+	tampered.Set("key", "orders/2/b")
+	_, _, err = store.VerifySignedURL(tampered)
+	assert.ErrorContains(t, err, "invalid signature")
+
+	expired, err := store.SignedURL(context.Background(), "orders/1/a", opts, -time.Minute)
+	require.NoError(t, err)
+	parsed, err = url.Parse(expired)
+	require.NoError(t, err)
+	_, _, err = store.VerifySignedURL(parsed.Query())
+	assert.ErrorContains(t, err, "signed URL expired")
+}
\ No newline at end of file
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"order-processing-microservice/pkg/utils"
+)
+
+func TestNewOffsetMeta(t *testing.T) {
+	meta := utils.NewOffsetMeta(10, 20, 10)
+	assert.Equal(t, 20, *meta.Offset)
+	assert.Nil(t, meta.Total)
+	assert.True(t, meta.HasMore)
+
+	meta = utils.NewOffsetMeta(10, 20, 10).WithTotal(30)
+	assert.Equal(t, int64(30), *meta.Total)
+	assert.False(t, meta.HasMore)
+
+	meta = utils.NewOffsetMeta(10, 0, 10).WithTotal(11)
+	assert.True(t, meta.HasMore)
+}
+
+func TestNewCursorMeta(t *testing.T) {
+	meta := utils.NewCursorMeta(100, "abc", "def", 3, false)
+	assert.Nil(t, meta.Offset)
+	assert.Equal(t, "abc", meta.Cursor)
+	assert.Equal(t, "def", meta.NextCursor)
+	assert.Equal(t, 3, meta.Count)
+	assert.False(t, meta.HasMore)
+}
+
+func TestRespondWithCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/customers/abc/orders/count", nil)
+
+	utils.RespondWithCount(c, 42)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "42", recorder.Header().Get(utils.TotalCountHeader))
+	assert.JSONEq(t, `{"data":{"count":42}}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodHead, "/api/v1/customers/abc/orders", nil)
+
+	utils.RespondWithCount(c, 42)
+	c.Writer.WriteHeaderNow()
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "42", recorder.Header().Get(utils.TotalCountHeader))
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestRespondWithListSetsTotalCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	utils.RespondWithList(c, []string{"a"}, utils.NewOffsetMeta(10, 0, 1).WithTotal(7))
+	assert.Equal(t, "7", recorder.Header().Get(utils.TotalCountHeader))
+
+	recorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(recorder)
+
+	utils.RespondWithList(c, []string{"a"}, utils.NewOffsetMeta(10, 0, 1))
+	assert.Empty(t, recorder.Header().Get(utils.TotalCountHeader))
+}
\ No newline at end of file